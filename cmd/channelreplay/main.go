@@ -0,0 +1,73 @@
+// channelreplay reconstruye el canal de un usuario en un instante dado a partir
+// del historial de eventos de canal.
+//
+//	DATABASE_URL=... go run ./cmd/channelreplay -user 12 -at 2025-01-31T10:15:00Z
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"time"
+
+	"walkie-backend/internal/config"
+	"walkie-backend/internal/services"
+
+	"github.com/joho/godotenv"
+	"gorm.io/gorm"
+)
+
+func main() {
+	_ = godotenv.Load(".env")
+	if err := run(os.Args[1:], os.Stdout, config.OpenDB); err != nil {
+		log.Fatal(err)
+	}
+}
+
+func run(args []string, out io.Writer, open func(string) (*gorm.DB, error)) error {
+	fs := flag.NewFlagSet("channelreplay", flag.ContinueOnError)
+	userID := fs.Uint("user", 0, "ID del usuario")
+	atRaw := fs.String("at", "", "instante RFC3339 (por defecto ahora)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *userID == 0 {
+		return fmt.Errorf("se requiere -user")
+	}
+
+	at := time.Now()
+	if *atRaw != "" {
+		parsed, err := time.Parse(time.RFC3339, *atRaw)
+		if err != nil {
+			return fmt.Errorf("-at inválido: %w", err)
+		}
+		at = parsed
+	}
+
+	// Sólo lee el historial: no migra ni arranca el pool del servidor
+	db, err := open(os.Getenv("DATABASE_URL"))
+	if err != nil {
+		return fmt.Errorf("error conectando a la base de datos: %w", err)
+	}
+	channel, events, err := services.ReplayChannelState(db, *userID, at)
+	if err != nil {
+		return err
+	}
+
+	for _, ev := range events {
+		fmt.Fprintf(out, "%s  %-16s %-10s -> %-10s actor=%s origen=%s request=%s\n",
+			ev.CreatedAt.UTC().Format(time.RFC3339), ev.Type, orDash(ev.FromChannel), orDash(ev.ToChannel),
+			ev.Actor, ev.Source, orDash(ev.RequestID))
+	}
+	fmt.Fprintf(out, "\nUsuario %d en %s: %s\n", *userID, at.UTC().Format(time.RFC3339), orDash(channel))
+	return nil
+}
+
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+
+	"walkie-backend/internal/config"
+
+	"gorm.io/gorm"
+)
+
+func noDB(string) (*gorm.DB, error) { return nil, errors.New("no debería abrirse") }
+
+func TestRun_RequiresUser(t *testing.T) {
+	var out bytes.Buffer
+	if err := run([]string{}, &out, noDB); err == nil {
+		t.Fatal("expected error without -user")
+	}
+}
+
+func TestRun_InvalidTimestamp(t *testing.T) {
+	var out bytes.Buffer
+	if err := run([]string{"-user", "3", "-at", "ayer"}, &out, noDB); err == nil {
+		t.Fatal("expected error for invalid -at")
+	}
+}
+
+func TestOrDash(t *testing.T) {
+	if orDash("") != "-" || orDash("canal-1") != "canal-1" {
+		t.Fatal("unexpected orDash output")
+	}
+}
+
+func TestRun_OpensDatabaseURL(t *testing.T) {
+	t.Setenv("DATABASE_URL", "file:channelreplay?mode=memory&cache=shared")
+	var opened string
+	open := func(dsn string) (*gorm.DB, error) {
+		opened = dsn
+		db, err := config.OpenDB(dsn)
+		if err != nil {
+			return nil, err
+		}
+		_, err = config.Migrate(db)
+		return db, err
+	}
+
+	var out bytes.Buffer
+	if err := run([]string{"-user", "3"}, &out, open); err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	if opened != "file:channelreplay?mode=memory&cache=shared" {
+		t.Fatalf("expected DATABASE_URL to be opened, got %q", opened)
+	}
+	if !strings.Contains(out.String(), "Usuario 3 en") {
+		t.Fatalf("unexpected output %q", out.String())
+	}
+
+	if err := run([]string{"-user", "3"}, &out, noDB); err == nil || !strings.Contains(err.Error(), "no debería abrirse") {
+		t.Fatalf("expected open error, got %v", err)
+	}
+}
@@ -0,0 +1,434 @@
+// loadtest simula muchos usuarios hablando a la vez en varios canales a través de la API
+// HTTP y el WebSocket reales, con el STT y la IA sustituidos por un simulador local, y
+// resume el rendimiento y la latencia de cada etapa.
+//
+// Sin -server arranca el backend en el mismo proceso sobre SQLite en memoria:
+//
+//	go run ./cmd/loadtest -users 50 -channels 5 -rate 6 -duration 1m
+//
+// Contra un servidor ya desplegado, éste debe arrancar con las variables que imprime
+// loadtest para usar el simulador (-mock-addr debe ser accesible desde el servidor):
+//
+//	go run ./cmd/loadtest -server http://staging:8080 -mock-addr 0.0.0.0:9099 -users 200
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"math/rand"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"text/tabwriter"
+	"time"
+
+	"walkie-backend/internal/config"
+	httproutes "walkie-backend/internal/httpHandler"
+	"walkie-backend/pkg/client"
+
+	"gorm.io/gorm/logger"
+)
+
+func main() {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	if err := run(ctx, os.Args[1:], os.Stdout); err != nil {
+		log.Fatal(err)
+	}
+}
+
+type options struct {
+	server   string
+	users    int
+	channels int
+	rate     float64
+	duration time.Duration
+	clip     time.Duration
+	sttDelay time.Duration
+	aiDelay  time.Duration
+	mockAddr string
+	prefix   string
+	pin      int
+	verbose  bool
+}
+
+func parseFlags(args []string) (options, error) {
+	var o options
+	fs := flag.NewFlagSet("loadtest", flag.ContinueOnError)
+	fs.StringVar(&o.server, "server", "", "URL del servidor; vacío arranca el backend en este proceso")
+	fs.IntVar(&o.users, "users", 20, "usuarios simulados")
+	fs.IntVar(&o.channels, "channels", 4, "canales entre los que se reparten los usuarios")
+	fs.Float64Var(&o.rate, "rate", 6, "clips por minuto de cada usuario (media)")
+	fs.DurationVar(&o.duration, "duration", 30*time.Second, "duración de la prueba")
+	fs.DurationVar(&o.clip, "clip", time.Second, "duración de cada clip sintético")
+	fs.DurationVar(&o.sttDelay, "stt-delay", 300*time.Millisecond, "latencia del STT simulado")
+	fs.DurationVar(&o.aiDelay, "ai-delay", 200*time.Millisecond, "latencia de la IA simulada")
+	fs.StringVar(&o.mockAddr, "mock-addr", "127.0.0.1:0", "dirección del STT/IA simulado")
+	fs.StringVar(&o.prefix, "prefix", "carga", "prefijo de los nombres de usuario")
+	fs.IntVar(&o.pin, "pin", 4321, "PIN de los usuarios simulados")
+	fs.BoolVar(&o.verbose, "v", false, "muestra los logs del backend embebido")
+	if err := fs.Parse(args); err != nil {
+		return o, err
+	}
+	if o.users <= 0 || o.channels <= 0 || o.rate <= 0 || o.duration <= 0 || o.clip <= 0 {
+		return o, errors.New("-users, -channels, -rate, -duration y -clip deben ser positivos")
+	}
+	return o, nil
+}
+
+func run(ctx context.Context, args []string, out io.Writer) error {
+	o, err := parseFlags(args)
+	if err != nil {
+		return err
+	}
+
+	mock, err := startMock(o.mockAddr, o.sttDelay, o.aiDelay)
+	if err != nil {
+		return err
+	}
+	defer mock.Close()
+
+	server := strings.TrimRight(o.server, "/")
+	if server == "" {
+		var stopServer func()
+		if server, stopServer, err = startEmbedded(o, mock); err != nil {
+			return err
+		}
+		defer stopServer()
+		fmt.Fprintf(out, "Backend embebido en %s (SQLite en memoria)\n", server)
+	} else {
+		fmt.Fprintf(out, "El servidor %s debe usar el STT/IA simulado:\n", server)
+		for _, kv := range sortedEnv(mock.env()) {
+			fmt.Fprintf(out, "  %s\n", kv)
+		}
+	}
+
+	channels, err := pickChannels(ctx, server, o.channels)
+	if err != nil {
+		return err
+	}
+
+	before, _ := scrapeStages(ctx, server)
+	res, err := simulate(ctx, o, server, channels)
+	if err != nil {
+		return err
+	}
+	after, scrapeErr := scrapeStages(ctx, server)
+
+	res.stages = diffStages(before, after)
+	res.scrapeErr = scrapeErr
+	res.sttCalls, res.aiCalls = mock.transcripts.Load(), mock.completions.Load()
+	report(out, o, channels, res)
+	return nil
+}
+
+// startEmbedded configura el entorno para el simulador y sirve las rutas reales en un
+// puerto local. Las variables ya definidas (p. ej. DATABASE_URL o los límites) se respetan
+// salvo las del STT y la IA, que siempre apuntan al simulador.
+func startEmbedded(o options, mock *mockBackend) (string, func(), error) {
+	for k, v := range mock.env() {
+		os.Setenv(k, v)
+	}
+	defaults := map[string]string{
+		"DATABASE_URL":              "file:loadtest?mode=memory&cache=shared",
+		"SEED_CHANNELS":             strconv.Itoa(o.channels),
+		"SEED_CHANNEL_MAX_USERS":    strconv.Itoa(o.users),
+		"RATE_LIMIT_INGEST_PER_MIN": "0",
+		"RATE_LIMIT_POLL_PER_MIN":   "0",
+		"DB_CONNECT_ATTEMPTS":       "1",
+		// SQLite en memoria con caché compartida devuelve "database is locked" con
+		// escrituras concurrentes; una sola conexión las serializa
+		"DB_MAX_OPEN_CONNS": "1",
+	}
+	for k, v := range defaults {
+		if _, ok := os.LookupEnv(k); !ok {
+			os.Setenv(k, v)
+		}
+	}
+	if !o.verbose {
+		log.SetOutput(io.Discard)
+		logger.Default = logger.Default.LogMode(logger.Silent)
+	}
+	config.ConnectDB()
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return "", nil, err
+	}
+	mux := http.NewServeMux()
+	httproutes.Routes(mux)
+	srv := &http.Server{Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+	go func() { _ = srv.Serve(ln) }()
+	return "http://" + ln.Addr().String(), func() { _ = srv.Close() }, nil
+}
+
+// pickChannels elige los primeros n canales públicos del servidor
+func pickChannels(ctx context.Context, server string, n int) ([]string, error) {
+	c, err := client.New(server)
+	if err != nil {
+		return nil, err
+	}
+	list, err := c.ListChannels(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("no se pudieron listar los canales: %w", err)
+	}
+	codes := make([]string, 0, len(list))
+	for _, ch := range list {
+		codes = append(codes, ch.Code)
+	}
+	sort.Strings(codes)
+	if len(codes) < n {
+		return nil, fmt.Errorf("el servidor tiene %d canales públicos y se pidieron %d", len(codes), n)
+	}
+	return codes[:n], nil
+}
+
+// result es lo que se mide durante la prueba
+type result struct {
+	st        *stats
+	ready     int
+	elapsed   time.Duration
+	stages    map[string]stageStat
+	scrapeErr error
+	sttCalls  int64
+	aiCalls   int64
+}
+
+// simulate prepara a todos los usuarios y, cuando están escuchando, los pone a hablar
+// durante o.duration
+func simulate(ctx context.Context, o options, server string, channels []string) (*result, error) {
+	st := newStats()
+	sends := &sendLog{start: make(map[uint]time.Time)}
+	clip := syntheticClip(o.clip)
+	interval := time.Duration(float64(time.Minute) / o.rate)
+	runID := strconv.FormatInt(time.Now().Unix()%100000, 10)
+
+	subCtx, cancelSubs := context.WithCancel(ctx)
+	defer cancelSubs()
+
+	var (
+		mu    sync.Mutex
+		users []*simUser
+		subs  []*client.Subscription
+		wg    sync.WaitGroup
+	)
+	for i := 0; i < o.users; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			c, err := client.New(server, client.WithRetries(1, 0), client.WithUserAgent("walkie-loadtest"))
+			if err != nil {
+				st.fail("setup", err)
+				return
+			}
+			u := &simUser{c: c, channel: channels[i%len(channels)], interval: interval, clip: clip, st: st, sends: sends}
+			sub, err := u.setup(subCtx, fmt.Sprintf("%s-%s-%d", o.prefix, runID, i), o.pin)
+			if err != nil {
+				st.fail("setup", err)
+				return
+			}
+			mu.Lock()
+			users = append(users, u)
+			subs = append(subs, sub)
+			mu.Unlock()
+		}(i)
+	}
+	wg.Wait()
+	if len(users) == 0 {
+		return nil, fmt.Errorf("ningún usuario pudo conectarse: %v", firstError(st))
+	}
+
+	talkCtx, stopTalking := context.WithTimeout(ctx, o.duration)
+	defer stopTalking()
+	start := time.Now()
+	for i, u := range users {
+		wg.Add(1)
+		go func(u *simUser, seed int64) {
+			defer wg.Done()
+			u.talk(talkCtx, rand.New(rand.NewSource(seed)))
+		}(u, start.UnixNano()+int64(i))
+	}
+	wg.Wait()
+	elapsed := time.Since(start)
+
+	// Un margen para que llegue el audio que seguía en camino
+	select {
+	case <-time.After(o.clip + time.Second):
+	case <-ctx.Done():
+	}
+	for _, sub := range subs {
+		_ = sub.Close()
+	}
+	return &result{st: st, ready: len(users), elapsed: elapsed}, nil
+}
+
+func firstError(st *stats) string {
+	for msg := range st.errors {
+		return msg
+	}
+	return "sin detalles"
+}
+
+// stageStat es una serie de walkie_ingest_stage de /metrics
+type stageStat struct {
+	count uint64
+	sum   float64
+}
+
+// scrapeStages lee los tiempos por etapa de /metrics del servidor
+func scrapeStages(ctx context.Context, server string) (map[string]stageStat, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, server+"/metrics", nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("/metrics respondió %d", resp.StatusCode)
+	}
+	return parseStages(resp.Body), nil
+}
+
+// parseStages extrae walkie_ingest_stage_count y _sum_seconds del formato de Prometheus
+func parseStages(r io.Reader) map[string]stageStat {
+	out := make(map[string]stageStat)
+	sc := bufio.NewScanner(r)
+	for sc.Scan() {
+		line := sc.Text()
+		var suffix string
+		switch {
+		case strings.HasPrefix(line, "walkie_ingest_stage_count{"):
+			suffix = "count"
+		case strings.HasPrefix(line, "walkie_ingest_stage_sum_seconds{"):
+			suffix = "sum"
+		default:
+			continue
+		}
+		_, rest, _ := strings.Cut(line, `stage="`)
+		stage, rest, ok := strings.Cut(rest, `"`)
+		if !ok {
+			continue
+		}
+		fields := strings.Fields(rest)
+		if len(fields) == 0 {
+			continue
+		}
+		value, err := strconv.ParseFloat(fields[len(fields)-1], 64)
+		if err != nil {
+			continue
+		}
+		s := out[stage]
+		if suffix == "count" {
+			s.count = uint64(value)
+		} else {
+			s.sum = value
+		}
+		out[stage] = s
+	}
+	return out
+}
+
+// diffStages resta las series de antes de la prueba para quedarse con las de la prueba
+func diffStages(before, after map[string]stageStat) map[string]stageStat {
+	out := make(map[string]stageStat, len(after))
+	for stage, a := range after {
+		b := before[stage]
+		if a.count > b.count {
+			out[stage] = stageStat{count: a.count - b.count, sum: a.sum - b.sum}
+		}
+	}
+	return out
+}
+
+func report(out io.Writer, o options, channels []string, res *result) {
+	st := res.st
+	secs := res.elapsed.Seconds()
+	sent := 0
+	for _, n := range st.outcomes {
+		sent += n
+	}
+
+	fmt.Fprintf(out, "\n%d/%d usuarios en %d canales (%s), %.1f clips/min por usuario de %s, durante %s\n",
+		res.ready, o.users, len(channels), strings.Join(channels, ", "), o.rate, o.clip, res.elapsed.Round(time.Millisecond))
+	fmt.Fprintf(out, "Clips enviados: %d (%.1f/s)\n", sent, float64(sent)/secs)
+	outcomes := make([]string, 0, len(st.outcomes))
+	for name := range st.outcomes {
+		outcomes = append(outcomes, name)
+	}
+	sort.Strings(outcomes)
+	for _, name := range outcomes {
+		fmt.Fprintf(out, "  %-14s %d\n", name, st.outcomes[name])
+	}
+	fmt.Fprintf(out, "Audio recibido por WebSocket: %d clips (%.1f/s)\n", st.received, float64(st.received)/secs)
+	fmt.Fprintf(out, "Llamadas al simulador: %d transcripciones, %d a la IA\n", res.sttCalls, res.aiCalls)
+
+	tw := tabwriter.NewWriter(out, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(out, "\nLatencias medidas por los clientes")
+	fmt.Fprintln(tw, "\tn\tp50\tp95\tp99\tmáx\t")
+	for _, name := range []string{"auth", "join", "ws_connect", "ingest", "delivery"} {
+		values := st.latencies[name]
+		if len(values) == 0 {
+			continue
+		}
+		p50, p95, p99, maxV := percentiles(values)
+		fmt.Fprintf(tw, "%s\t%d\t%s\t%s\t%s\t%s\t\n", name, len(values), ms(p50), ms(p95), ms(p99), ms(maxV))
+	}
+	tw.Flush()
+
+	fmt.Fprintln(out, "\nEtapas de /audio/ingest en el servidor (walkie_ingest_stage)")
+	if res.scrapeErr != nil {
+		fmt.Fprintf(out, "  no disponibles: %v\n", res.scrapeErr)
+	} else {
+		stages := make([]string, 0, len(res.stages))
+		for name := range res.stages {
+			stages = append(stages, name)
+		}
+		sort.Slice(stages, func(i, j int) bool { return res.stages[stages[i]].sum > res.stages[stages[j]].sum })
+		fmt.Fprintln(tw, "\tn\tmedia\ttotal\t")
+		for _, name := range stages {
+			s := res.stages[name]
+			avg := time.Duration(s.sum / float64(s.count) * float64(time.Second))
+			fmt.Fprintf(tw, "%s\t%d\t%s\t%s\t\n", name, s.count, ms(avg), time.Duration(s.sum*float64(time.Second)).Round(time.Millisecond))
+		}
+		tw.Flush()
+	}
+
+	if len(st.errors) > 0 {
+		fmt.Fprintln(out, "\nErrores")
+		msgs := make([]string, 0, len(st.errors))
+		for msg := range st.errors {
+			msgs = append(msgs, msg)
+		}
+		sort.Strings(msgs)
+		for _, msg := range msgs {
+			fmt.Fprintf(out, "  %4d× %s\n", st.errors[msg], msg)
+		}
+	}
+}
+
+func ms(d time.Duration) string {
+	return fmt.Sprintf("%.1fms", float64(d)/float64(time.Millisecond))
+}
+
+func sortedEnv(env map[string]string) []string {
+	out := make([]string, 0, len(env))
+	for k, v := range env {
+		out = append(out, k+"="+v)
+	}
+	sort.Strings(out)
+	return out
+}
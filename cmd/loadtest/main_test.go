@@ -0,0 +1,86 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"walkie-backend/pkg/audio"
+	"walkie-backend/pkg/stt"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSyntheticClipPassesFilters(t *testing.T) {
+	clip := syntheticClip(time.Second)
+	assert.True(t, stt.DetectSpeech(clip, stt.DefaultSpeechThresholds()))
+	c, ok := audio.ClassifyWAV(clip)
+	require.True(t, ok)
+	assert.Equal(t, audio.ClassSpeech, c.Class)
+}
+
+func TestParseStages(t *testing.T) {
+	before := parseStages(strings.NewReader(`# TYPE walkie_ingest_stage summary
+walkie_ingest_stage_count{stage="stt"} 2
+walkie_ingest_stage_sum_seconds{stage="stt"} 0.5
+walkie_ingest_stage_max_seconds{stage="stt"} 0.3
+`))
+	after := parseStages(strings.NewReader(`walkie_ingest_stage_count{stage="stt"} 5
+walkie_ingest_stage_sum_seconds{stage="stt"} 1.7
+walkie_ingest_stage_count{stage="vad"} 3
+walkie_ingest_stage_sum_seconds{stage="vad"} 0.003
+walkie_http_requests_total{route="/auth"} 9
+`))
+	diff := diffStages(before, after)
+	require.Len(t, diff, 2)
+	assert.Equal(t, uint64(3), diff["stt"].count)
+	assert.InDelta(t, 1.2, diff["stt"].sum, 1e-9)
+	assert.Equal(t, uint64(3), diff["vad"].count)
+}
+
+func TestPercentiles(t *testing.T) {
+	values := make([]time.Duration, 100)
+	for i := range values {
+		values[i] = time.Duration(100-i) * time.Millisecond
+	}
+	p50, p95, p99, maxV := percentiles(values)
+	assert.Equal(t, 50*time.Millisecond, p50)
+	assert.Equal(t, 95*time.Millisecond, p95)
+	assert.Equal(t, 99*time.Millisecond, p99)
+	assert.Equal(t, 100*time.Millisecond, maxV)
+}
+
+func TestRunEmbedded(t *testing.T) {
+	for _, k := range []string{
+		"ASSEMBLYAI_API_KEY", "ASSEMBLYAI_API_URL", "AI_PROVIDER", "OLLAMA_URL", "STT_STREAMING",
+		"SEED_CHANNELS", "SEED_CHANNEL_MAX_USERS", "RATE_LIMIT_INGEST_PER_MIN", "RATE_LIMIT_POLL_PER_MIN",
+		"DB_CONNECT_ATTEMPTS", "DB_MAX_OPEN_CONNS",
+	} {
+		t.Setenv(k, "")
+	}
+	t.Setenv("DATABASE_URL", "file:loadtest_run?mode=memory&cache=shared")
+	t.Setenv("SEED_CHANNELS", "1")
+	t.Setenv("SEED_CHANNEL_MAX_USERS", "5")
+	t.Setenv("RATE_LIMIT_INGEST_PER_MIN", "0")
+	t.Setenv("RATE_LIMIT_POLL_PER_MIN", "0")
+	t.Setenv("DB_MAX_OPEN_CONNS", "1")
+
+	var out bytes.Buffer
+	err := run(context.Background(), []string{
+		"-users", "2", "-channels", "1", "-rate", "60", "-duration", "2s",
+		"-stt-delay", "10ms", "-ai-delay", "10ms",
+	}, &out)
+	require.NoError(t, err, out.String())
+
+	report := out.String()
+	assert.Contains(t, report, "2/2 usuarios en 1 canales")
+	assert.Contains(t, report, "Clips enviados:")
+	assert.Contains(t, report, "Latencias medidas por los clientes")
+	assert.Contains(t, report, "ingest")
+	assert.Contains(t, report, "Etapas de /audio/ingest en el servidor")
+	assert.Contains(t, report, "stt")
+	assert.NotContains(t, report, "Errores")
+}
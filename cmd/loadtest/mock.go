@@ -0,0 +1,112 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"sync/atomic"
+	"time"
+)
+
+// mockTranscript es lo que "dice" cada clip sintético; no contiene palabras de ningún
+// comando para que todo se difunda como conversación
+const mockTranscript = "aquí unidad de carga, todo en orden"
+
+// mockBackend imita la API de AssemblyAI (/v2) y una API de chat compatible con OpenAI
+// (/v1/chat/completions) con latencias fijas, para cargar el backend sin coste externo
+type mockBackend struct {
+	URL      string
+	sttDelay time.Duration
+	aiDelay  time.Duration
+	srv      *http.Server
+
+	transcripts atomic.Int64
+	completions atomic.Int64
+}
+
+func startMock(addr string, sttDelay, aiDelay time.Duration) (*mockBackend, error) {
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("no se pudo abrir el STT/IA simulado en %s: %w", addr, err)
+	}
+	m := &mockBackend{sttDelay: sttDelay, aiDelay: aiDelay}
+	port := ln.Addr().(*net.TCPAddr).Port
+	m.URL = fmt.Sprintf("http://127.0.0.1:%d", port)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /v2/upload", func(w http.ResponseWriter, r *http.Request) {
+		writeMockJSON(w, map[string]string{"upload_url": m.URL + "/v2/files/clip"})
+	})
+	mux.HandleFunc("POST /v2/transcript", func(w http.ResponseWriter, r *http.Request) {
+		id := m.transcripts.Add(1)
+		writeMockJSON(w, map[string]string{"id": fmt.Sprintf("t%d", id), "status": "queued"})
+	})
+	mux.HandleFunc("GET /v2/transcript/{id}", func(w http.ResponseWriter, r *http.Request) {
+		if !sleepCtx(r, m.sttDelay) {
+			return
+		}
+		writeMockJSON(w, map[string]string{"id": r.PathValue("id"), "status": "completed", "text": mockTranscript})
+	})
+	// Sondeos de /readyz con READYZ_PROBE_CLIENTS=true
+	mux.HandleFunc("GET /v2/transcript", func(w http.ResponseWriter, r *http.Request) {
+		writeMockJSON(w, map[string]any{"transcripts": []any{}})
+	})
+	mux.HandleFunc("GET /v1/models", func(w http.ResponseWriter, r *http.Request) {
+		writeMockJSON(w, map[string]any{"data": []map[string]string{{"id": "loadtest"}}})
+	})
+	mux.HandleFunc("POST /v1/chat/completions", func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			MaxTokens int `json:"max_tokens"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		m.completions.Add(1)
+		if !sleepCtx(r, m.aiDelay) {
+			return
+		}
+		// La moderación pide una sola palabra; el resto de llamadas esperan la clasificación
+		content := `{"is_command":false,"intent":"conversation","reply":"","state":""}`
+		if req.MaxTokens > 0 && req.MaxTokens <= 5 {
+			content = "no"
+		}
+		writeMockJSON(w, map[string]any{
+			"choices": []map[string]any{{"message": map[string]string{"role": "assistant", "content": content}}},
+		})
+	})
+
+	m.srv = &http.Server{Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+	go func() { _ = m.srv.Serve(ln) }()
+	return m, nil
+}
+
+// env son las variables con las que el servidor usa este simulador
+func (m *mockBackend) env() map[string]string {
+	return map[string]string{
+		"ASSEMBLYAI_API_KEY": "loadtest",
+		"ASSEMBLYAI_API_URL": m.URL + "/v2",
+		"AI_PROVIDER":        "ollama",
+		"OLLAMA_URL":         m.URL + "/v1",
+		"STT_STREAMING":      "false",
+	}
+}
+
+func (m *mockBackend) Close() error {
+	return m.srv.Close()
+}
+
+func writeMockJSON(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func sleepCtx(r *http.Request, d time.Duration) bool {
+	if d <= 0 {
+		return true
+	}
+	select {
+	case <-time.After(d):
+		return true
+	case <-r.Context().Done():
+		return false
+	}
+}
@@ -0,0 +1,237 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"math/rand"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"walkie-backend/pkg/audio"
+	"walkie-backend/pkg/client"
+)
+
+// Resultados de cada clip enviado
+const (
+	outcomeBroadcast = "difundido"
+	outcomeDropped   = "descartado"
+	outcomeCommand   = "comando"
+	outcomeBusy      = "canal_ocupado"
+	outcomeLimited   = "limitado_429"
+	outcomeOverload  = "saturado_503"
+	outcomeError     = "error"
+)
+
+// stats acumula latencias y resultados de todos los usuarios simulados
+type stats struct {
+	mu        sync.Mutex
+	latencies map[string][]time.Duration
+	outcomes  map[string]int
+	received  int
+	errors    map[string]int
+}
+
+func newStats() *stats {
+	return &stats{
+		latencies: make(map[string][]time.Duration),
+		outcomes:  make(map[string]int),
+		errors:    make(map[string]int),
+	}
+}
+
+func (s *stats) observe(name string, d time.Duration) {
+	s.mu.Lock()
+	s.latencies[name] = append(s.latencies[name], d)
+	s.mu.Unlock()
+}
+
+func (s *stats) outcome(name string) {
+	s.mu.Lock()
+	s.outcomes[name]++
+	s.mu.Unlock()
+}
+
+// fail anota un error; sólo se guarda el texto para no inundar la salida
+func (s *stats) fail(stage string, err error) {
+	s.mu.Lock()
+	s.errors[fmt.Sprintf("%s: %v", stage, err)]++
+	s.mu.Unlock()
+}
+
+func (s *stats) audioReceived() {
+	s.mu.Lock()
+	s.received++
+	s.mu.Unlock()
+}
+
+// percentiles devuelve p50, p95, p99 y máximo
+func percentiles(values []time.Duration) (p50, p95, p99, maxV time.Duration) {
+	if len(values) == 0 {
+		return
+	}
+	sorted := append([]time.Duration(nil), values...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	at := func(p float64) time.Duration {
+		return sorted[int(math.Ceil(p*float64(len(sorted))))-1]
+	}
+	return at(0.50), at(0.95), at(0.99), sorted[len(sorted)-1]
+}
+
+// sendLog recuerda cuándo empezó cada usuario su último envío, para medir cuánto tarda
+// el audio en llegar a los oyentes. El canal sólo deja hablar a uno a la vez, así que
+// el último envío del hablante es el clip que se recibe.
+type sendLog struct {
+	mu    sync.Mutex
+	start map[uint]time.Time
+}
+
+func (l *sendLog) mark(userID uint, t time.Time) {
+	l.mu.Lock()
+	l.start[userID] = t
+	l.mu.Unlock()
+}
+
+func (l *sendLog) since(userID uint) (time.Duration, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	t, ok := l.start[userID]
+	if !ok {
+		return 0, false
+	}
+	return time.Since(t), true
+}
+
+// simUser es un usuario simulado: se autentica, entra en su canal, escucha por WebSocket
+// y habla a intervalos aleatorios alrededor de interval
+type simUser struct {
+	c        *client.Client
+	id       uint
+	channel  string
+	interval time.Duration
+	clip     []byte
+	st       *stats
+	sends    *sendLog
+}
+
+// setup deja al usuario conectado y escuchando; devuelve la suscripción para cerrarla al final
+func (u *simUser) setup(ctx context.Context, name string, pin int) (*client.Subscription, error) {
+	t0 := time.Now()
+	if _, err := u.c.Authenticate(ctx, name, pin); err != nil {
+		return nil, fmt.Errorf("auth: %w", err)
+	}
+	u.st.observe("auth", time.Since(t0))
+
+	me, err := u.c.Me(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("me: %w", err)
+	}
+	u.id = me.ID
+
+	t0 = time.Now()
+	if _, err := u.c.ConnectChannel(ctx, u.channel); err != nil {
+		return nil, fmt.Errorf("join %s: %w", u.channel, err)
+	}
+	u.st.observe("join", time.Since(t0))
+
+	t0 = time.Now()
+	sub, err := u.c.Subscribe(ctx, u.channel)
+	if err != nil {
+		return nil, fmt.Errorf("ws: %w", err)
+	}
+	u.st.observe("ws_connect", time.Since(t0))
+	go u.listen(sub)
+	return sub, nil
+}
+
+// listen mide la entrega: cada frame de audio llega después del aviso de transmisión
+// con el hablante en from
+func (u *simUser) listen(sub *client.Subscription) {
+	var speaker uint
+	for ev := range sub.Events() {
+		switch ev.Type {
+		case "transmission":
+			var msg struct {
+				Action string `json:"action"`
+				From   uint   `json:"from"`
+			}
+			if json.Unmarshal(ev.Raw, &msg) == nil && msg.Action == "start" {
+				speaker = msg.From
+			}
+		case "audio":
+			if speaker == 0 || speaker == u.id {
+				continue
+			}
+			u.st.audioReceived()
+			if d, ok := u.sends.since(speaker); ok {
+				u.st.observe("delivery", d)
+			}
+		}
+	}
+}
+
+// talk envía clips hasta que se cancele ctx
+func (u *simUser) talk(ctx context.Context, rng *rand.Rand) {
+	// Arranque escalonado para que no hablen todos en el mismo instante
+	wait := time.Duration(rng.Int63n(int64(u.interval) + 1))
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(wait):
+		}
+
+		start := time.Now()
+		u.sends.mark(u.id, start)
+		res, err := u.c.IngestAudio(ctx, u.clip, "audio/wav")
+		if ctx.Err() != nil {
+			return
+		}
+		u.st.observe("ingest", time.Since(start))
+		u.st.outcome(classify(res, err))
+		if err != nil && classify(res, err) == outcomeError {
+			u.st.fail("ingest", err)
+		}
+
+		// Intervalos exponenciales: las peticiones llegan como un proceso de Poisson
+		wait = time.Duration(rng.ExpFloat64() * float64(u.interval))
+	}
+}
+
+func classify(res *client.IngestResult, err error) string {
+	var apiErr *client.APIError
+	switch {
+	case errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusConflict:
+		return outcomeBusy
+	case errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusTooManyRequests:
+		return outcomeLimited
+	case errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusServiceUnavailable:
+		return outcomeOverload
+	case err != nil:
+		return outcomeError
+	case res.Command != nil:
+		return outcomeCommand
+	case res.TransmissionID != "":
+		return outcomeBroadcast
+	}
+	return outcomeDropped
+}
+
+// syntheticClip genera un WAV PCM 16 bits mono a 16 kHz que pasa el detector de voz y el
+// filtro de ruido: un tono de 180 Hz con armónico, modulado a ritmo de sílabas
+func syntheticClip(length time.Duration) []byte {
+	const rate = 16000
+	n := int(length.Seconds() * rate)
+	samples := make([]int16, n)
+	for i := range samples {
+		t := float64(i) / rate
+		envelope := 0.55 + 0.45*math.Sin(2*math.Pi*4*t)
+		v := math.Sin(2*math.Pi*180*t) + 0.4*math.Sin(2*math.Pi*360*t)
+		samples[i] = int16(6000 * envelope * v)
+	}
+	return audio.EncodeWAV(samples, rate)
+}
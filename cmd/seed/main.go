@@ -0,0 +1,65 @@
+// seed crea los canales públicos por defecto (canal-1..canal-N, o los de -list) en un entorno nuevo.
+// Es idempotente: los canales que ya existen no se modifican.
+//
+//	DATABASE_URL=... go run ./cmd/seed -channels 8 -max-users 50 -echo
+//	DATABASE_URL=... go run ./cmd/seed -list "logistica:Logística,obra-norte:Obra Norte"
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+
+	"walkie-backend/internal/config"
+
+	"github.com/joho/godotenv"
+	"gorm.io/gorm"
+)
+
+func main() {
+	_ = godotenv.Load(".env")
+	if err := run(os.Args[1:], os.Stdout, config.OpenDB); err != nil {
+		log.Fatal(err)
+	}
+}
+
+func run(args []string, out io.Writer, open func(string) (*gorm.DB, error)) error {
+	defaults := config.SeedOptionsFromEnv()
+	fs := flag.NewFlagSet("seed", flag.ContinueOnError)
+	channels := fs.Int("channels", defaults.Channels, "número de canales públicos (SEED_CHANNELS)")
+	maxUsers := fs.Int("max-users", defaults.MaxUsers, "capacidad de cada canal (SEED_CHANNEL_MAX_USERS)")
+	echo := fs.Bool("echo", defaults.Echo, "crear también el canal eco (ECHO_CHANNEL_ENABLED)")
+	rawList := fs.String("list", os.Getenv("SEED_CHANNEL_LIST"), "canales con código y nombre, \"codigo:Nombre,...\" (SEED_CHANNEL_LIST)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *channels < 0 || *maxUsers <= 0 {
+		return fmt.Errorf("-channels debe ser >= 0 y -max-users > 0")
+	}
+	list, err := config.ParseSeedChannelList(*rawList)
+	if err != nil {
+		return err
+	}
+
+	db, err := open(os.Getenv("DATABASE_URL"))
+	if err != nil {
+		return fmt.Errorf("error conectando a la base de datos: %w", err)
+	}
+	if _, err := config.Migrate(db); err != nil {
+		return err
+	}
+
+	created, err := config.SeedChannels(db, config.SeedOptions{Channels: *channels, MaxUsers: *maxUsers, Echo: *echo, List: list})
+	for _, code := range created {
+		fmt.Fprintf(out, "Canal creado: %s\n", code)
+	}
+	if err != nil {
+		return err
+	}
+	if len(created) == 0 {
+		fmt.Fprintln(out, "Los canales por defecto ya existen")
+	}
+	return nil
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"walkie-backend/internal/config"
+	"walkie-backend/internal/models"
+
+	"gorm.io/gorm"
+)
+
+func TestRun_SeedsIdempotently(t *testing.T) {
+	t.Setenv("DATABASE_URL", "file:seed_cmd?mode=memory&cache=shared")
+	var db *gorm.DB
+	open := func(dsn string) (*gorm.DB, error) {
+		var err error
+		db, err = config.OpenDB(dsn)
+		return db, err
+	}
+
+	var out bytes.Buffer
+	if err := run([]string{"-channels", "3", "-max-users", "40", "-echo"}, &out, open); err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	for _, code := range []string{"canal-1", "canal-2", "canal-3", "eco"} {
+		if !strings.Contains(out.String(), "Canal creado: "+code) {
+			t.Errorf("expected %s created, got %q", code, out.String())
+		}
+	}
+
+	var ch models.Channel
+	if err := db.Where("code = ?", "canal-2").First(&ch).Error; err != nil {
+		t.Fatalf("canal-2: %v", err)
+	}
+	if ch.MaxUsers != 40 || ch.IsPrivate {
+		t.Errorf("unexpected channel %+v", ch)
+	}
+
+	out.Reset()
+	if err := run([]string{"-channels", "3", "-max-users", "40", "-echo"}, &out, open); err != nil {
+		t.Fatalf("second run: %v", err)
+	}
+	if !strings.Contains(out.String(), "ya existen") {
+		t.Errorf("expected nothing created on rerun, got %q", out.String())
+	}
+}
+
+func TestRun_RejectsInvalidFlags(t *testing.T) {
+	if err := run([]string{"-max-users", "0"}, &bytes.Buffer{}, config.OpenDB); err == nil {
+		t.Fatal("expected error for -max-users 0")
+	}
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"net"
+	"strings"
+
+	"walkie-backend/internal/httpHandler/handlers"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+)
+
+// grpcAddress devuelve la dirección de la API gRPC; sin GRPC_PORT no se arranca
+func grpcAddress(getEnv func(string) string) string {
+	port := strings.TrimSpace(getEnv("GRPC_PORT"))
+	if port == "" {
+		return ""
+	}
+	return ":" + port
+}
+
+// grpcServerOptions usa el mismo certificado que HTTPS cuando hay TLS_CERT_FILE y
+// TLS_KEY_FILE; con autocert o sin TLS la API gRPC va en claro
+func grpcServerOptions(getEnv func(string) string) ([]grpc.ServerOption, error) {
+	settings := loadTLSSettings(getEnv)
+	if settings.certFile == "" || settings.keyFile == "" {
+		return nil, nil
+	}
+	creds, err := credentials.NewServerTLSFromFile(settings.certFile, settings.keyFile)
+	if err != nil {
+		return nil, fmt.Errorf("certificado TLS para gRPC: %w", err)
+	}
+	return []grpc.ServerOption{grpc.Creds(creds)}, nil
+}
+
+// startGRPC arranca la API gRPC en segundo plano si GRPC_PORT está configurado
+func startGRPC(getEnv func(string) string) {
+	addr := grpcAddress(getEnv)
+	if addr == "" {
+		return
+	}
+	opts, err := grpcServerOptions(getEnv)
+	if err != nil {
+		log.Printf("API gRPC desactivada: %v", err)
+		return
+	}
+	lis, err := net.Listen("tcp", addr)
+	if err != nil {
+		log.Printf("API gRPC desactivada: %v", err)
+		return
+	}
+	srv := handlers.NewGRPCServer(opts...)
+	go func() {
+		log.Printf("API gRPC escuchando en %s", addr)
+		if err := srv.Serve(lis); err != nil {
+			log.Printf("API gRPC detenida: %v", err)
+		}
+	}()
+}
@@ -0,0 +1,32 @@
+package main
+
+import "testing"
+
+func TestGRPCAddress(t *testing.T) {
+	if addr := grpcAddress(func(string) string { return "" }); addr != "" {
+		t.Fatalf("expected gRPC disabled, got %s", addr)
+	}
+	if addr := grpcAddress(func(string) string { return " 9090 " }); addr != ":9090" {
+		t.Fatalf("expected :9090, got %s", addr)
+	}
+}
+
+func TestGRPCServerOptions(t *testing.T) {
+	opts, err := grpcServerOptions(func(string) string { return "" })
+	if err != nil || len(opts) != 0 {
+		t.Fatalf("expected plaintext without certificate, got %d options (err=%v)", len(opts), err)
+	}
+
+	_, err = grpcServerOptions(func(key string) string {
+		switch key {
+		case "TLS_CERT_FILE":
+			return "/no/existe/cert.pem"
+		case "TLS_KEY_FILE":
+			return "/no/existe/key.pem"
+		}
+		return ""
+	})
+	if err == nil {
+		t.Fatal("expected error for missing certificate")
+	}
+}
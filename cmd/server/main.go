@@ -1,31 +1,80 @@
 package main
 
 import (
+	"context"
+	"errors"
+	"io/fs"
 	"log"
 	"net/http"
 	"os"
+	"os/signal"
 	"strings"
+	"syscall"
 	httproutes "walkie-backend/internal/httpHandler"
+	"walkie-backend/internal/httpHandler/handlers"
 
 	"walkie-backend/internal/config"
+	"walkie-backend/pkg/stt"
+	"walkie-backend/pkg/tracing"
 
 	"github.com/joho/godotenv"
 )
 
 func main() {
-	if err := run(http.ListenAndServe, config.ConnectDB); err != nil {
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		if err := runMigrate(os.Args[2:], os.Stdout, config.OpenDB); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+	if err := run(listenFromEnv, config.ConnectDB); err != nil {
 		log.Fatal(err)
 	}
 }
 
-func run(listen func(string, http.Handler) error, connectDB func()) error {
+// run carga .env antes de leer la configuración; newListen elige HTTP o TLS con el entorno
+// ya cargado
+func run(newListen func(func(string) string) func(string, http.Handler) error, connectDB func()) error {
 	_ = godotenv.Load(".env")
+	listen := newListen(os.Getenv)
+
+	if tracer := tracing.InitFromEnv(); tracer != nil {
+		defer tracer.Shutdown(context.Background())
+	}
 
 	addr, handler := buildServer(os.Getenv, connectDB, httproutes.Routes)
-	log.Println("Server running at http://localhost" + addr)
+	handlers.StartIntentPatternReloader()
+	handlers.StartModerationReloader()
+	registerLocalSTT()
+	reloadClientsOnSIGHUP()
+	startGRPC(os.Getenv)
 	return listen(addr, handler)
 }
 
+// registerLocalSTT instala el reconocedor de LOCAL_STT_URL para responder a las
+// confirmaciones cortas sin pasar por el STT remoto
+func registerLocalSTT() {
+	if client := stt.NewLocalClientFromEnv(); client != nil {
+		handlers.RegisterLocalSTT(client)
+		log.Printf("STT local para confirmaciones habilitado")
+	}
+}
+
+// reloadClientsOnSIGHUP vuelve a leer .env con SIGHUP y recrea los clientes de STT, IA y
+// TTS, para rotar claves sin reiniciar el proceso
+func reloadClientsOnSIGHUP() {
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	go func() {
+		for range hup {
+			if err := godotenv.Overload(".env"); err != nil && !errors.Is(err, fs.ErrNotExist) {
+				log.Printf("No se pudo recargar .env: %v", err)
+			}
+			handlers.ResetExternalClients("SIGHUP")
+		}
+	}()
+}
+
 func buildServer(
 	getEnv func(string) string,
 	connectDB func(),
@@ -34,6 +83,9 @@ func buildServer(
 	if connectDB != nil {
 		connectDB()
 	}
+	handlers.StartMaintenance()
+	handlers.StartAnnouncementScheduler()
+	handlers.StartWSSupervisor()
 
 	mux := http.NewServeMux()
 	if registerRoutes != nil {
@@ -2,6 +2,8 @@ package main
 
 import (
 	"net/http"
+	"os"
+	"path/filepath"
 	"testing"
 )
 
@@ -63,7 +65,7 @@ func TestRun(t *testing.T) {
 			return nil
 		}
 
-		err := run(mockListen, func() {})
+		err := run(func(func(string) string) func(string, http.Handler) error { return mockListen }, func() {})
 		if err != nil {
 			t.Fatalf("run returned error: %v", err)
 		}
@@ -75,4 +77,35 @@ func TestRun(t *testing.T) {
 			t.Error("expected listen to be called with handler")
 		}
 	})
+
+	t.Run("TLS settings from .env are read before choosing the listener", func(t *testing.T) {
+		dir := t.TempDir()
+		if err := os.WriteFile(filepath.Join(dir, ".env"), []byte("TLS_CERT_FILE=/tmp/cert.pem\nTLS_KEY_FILE=/tmp/key.pem\n"), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		wd, err := os.Getwd()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Chdir(dir); err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { _ = os.Chdir(wd) })
+		t.Setenv("TLS_CERT_FILE", "")
+		t.Setenv("TLS_KEY_FILE", "")
+		os.Unsetenv("TLS_CERT_FILE")
+		os.Unsetenv("TLS_KEY_FILE")
+
+		var tlsEnabled bool
+		newListen := func(getEnv func(string) string) func(string, http.Handler) error {
+			tlsEnabled = loadTLSSettings(getEnv).enabled()
+			return func(string, http.Handler) error { return nil }
+		}
+		if err := run(newListen, func() {}); err != nil {
+			t.Fatalf("run returned error: %v", err)
+		}
+		if !tlsEnabled {
+			t.Error("expected TLS settings from .env to enable TLS")
+		}
+	})
 }
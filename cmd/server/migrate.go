@@ -0,0 +1,62 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"walkie-backend/internal/config"
+
+	"github.com/joho/godotenv"
+	"gorm.io/gorm"
+)
+
+const migrateUsage = "uso: server migrate [up|status]"
+
+// runMigrate atiende "server migrate": "up" (por defecto) aplica las migraciones
+// pendientes y "status" lista cuáles están aplicadas
+func runMigrate(args []string, out io.Writer, open func(string) (*gorm.DB, error)) error {
+	_ = godotenv.Load(".env")
+
+	cmd := "up"
+	if len(args) > 0 {
+		cmd = args[0]
+	}
+	if len(args) > 1 || (cmd != "up" && cmd != "status") {
+		return errors.New(migrateUsage)
+	}
+
+	db, err := open(os.Getenv("DATABASE_URL"))
+	if err != nil {
+		return fmt.Errorf("error conectando a la base de datos: %w", err)
+	}
+
+	if cmd == "status" {
+		statuses, err := config.Migrations(db)
+		if err != nil {
+			return err
+		}
+		for _, s := range statuses {
+			if s.Applied {
+				fmt.Fprintf(out, "%s\taplicada %s\n", s.ID, s.AppliedAt.UTC().Format(time.RFC3339))
+			} else {
+				fmt.Fprintf(out, "%s\tpendiente\n", s.ID)
+			}
+		}
+		return nil
+	}
+
+	applied, err := config.Migrate(db)
+	for _, id := range applied {
+		fmt.Fprintf(out, "aplicada %s\n", id)
+	}
+	if err != nil {
+		return err
+	}
+	if len(applied) == 0 {
+		fmt.Fprintln(out, "No hay migraciones pendientes")
+	}
+	return nil
+}
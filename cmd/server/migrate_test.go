@@ -0,0 +1,51 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"walkie-backend/internal/config"
+)
+
+func TestRunMigrate_UpThenStatus(t *testing.T) {
+	t.Setenv("DATABASE_URL", "file:migrate_cmd?mode=memory&cache=shared")
+
+	var out bytes.Buffer
+	if err := runMigrate([]string{"status"}, &out, config.OpenDB); err != nil {
+		t.Fatalf("status: %v", err)
+	}
+	if !strings.Contains(out.String(), "0001_initial_schema\tpendiente") {
+		t.Fatalf("expected pending initial migration, got %q", out.String())
+	}
+
+	out.Reset()
+	if err := runMigrate(nil, &out, config.OpenDB); err != nil {
+		t.Fatalf("up: %v", err)
+	}
+	if !strings.Contains(out.String(), "aplicada 0001_initial_schema") {
+		t.Fatalf("expected initial migration applied, got %q", out.String())
+	}
+
+	out.Reset()
+	if err := runMigrate([]string{"up"}, &out, config.OpenDB); err != nil {
+		t.Fatalf("second up: %v", err)
+	}
+	if !strings.Contains(out.String(), "No hay migraciones pendientes") {
+		t.Fatalf("expected nothing pending, got %q", out.String())
+	}
+
+	out.Reset()
+	if err := runMigrate([]string{"status"}, &out, config.OpenDB); err != nil {
+		t.Fatalf("status: %v", err)
+	}
+	if !strings.Contains(out.String(), "0001_initial_schema\taplicada") {
+		t.Fatalf("expected applied status, got %q", out.String())
+	}
+}
+
+func TestRunMigrate_RejectsUnknownCommand(t *testing.T) {
+	if err := runMigrate([]string{"down"}, &bytes.Buffer{}, config.OpenDB); err == nil {
+		t.Fatal("expected usage error")
+	}
+}
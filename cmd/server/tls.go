@@ -0,0 +1,153 @@
+package main
+
+import (
+	"crypto/tls"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/acme/autocert"
+)
+
+const (
+	defaultHSTSMaxAge   = 31536000
+	defaultRedirectAddr = ":80"
+	defaultAutocertDir  = "certs"
+)
+
+// tlsSettings agrupa la configuración de TLS nativo para despliegues sin proxy
+type tlsSettings struct {
+	certFile      string
+	keyFile       string
+	autocertHosts []string
+	autocertCache string
+	redirectAddr  string
+	hstsMaxAge    int
+}
+
+func (s tlsSettings) enabled() bool {
+	return (s.certFile != "" && s.keyFile != "") || len(s.autocertHosts) > 0
+}
+
+func loadTLSSettings(getEnv func(string) string) tlsSettings {
+	s := tlsSettings{
+		certFile:      strings.TrimSpace(getEnv("TLS_CERT_FILE")),
+		keyFile:       strings.TrimSpace(getEnv("TLS_KEY_FILE")),
+		autocertCache: strings.TrimSpace(getEnv("TLS_AUTOCERT_CACHE")),
+		redirectAddr:  strings.TrimSpace(getEnv("TLS_REDIRECT_ADDR")),
+		hstsMaxAge:    defaultHSTSMaxAge,
+	}
+	for _, host := range strings.Split(getEnv("TLS_AUTOCERT_HOSTS"), ",") {
+		if host = strings.TrimSpace(host); host != "" {
+			s.autocertHosts = append(s.autocertHosts, host)
+		}
+	}
+	if s.autocertCache == "" {
+		s.autocertCache = defaultAutocertDir
+	}
+	if s.redirectAddr == "" {
+		s.redirectAddr = defaultRedirectAddr
+	}
+	if raw := strings.TrimSpace(getEnv("HSTS_MAX_AGE")); raw != "" {
+		if v, err := strconv.Atoi(raw); err == nil && v >= 0 {
+			s.hstsMaxAge = v
+		} else {
+			log.Printf("HSTS_MAX_AGE inválido (%s), usando %d", raw, defaultHSTSMaxAge)
+		}
+	}
+	return s
+}
+
+// withHSTS agrega Strict-Transport-Security a todas las respuestas
+func withHSTS(next http.Handler, maxAge int) http.Handler {
+	if maxAge <= 0 {
+		return next
+	}
+	value := fmt.Sprintf("max-age=%d; includeSubDomains", maxAge)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Strict-Transport-Security", value)
+		next.ServeHTTP(w, r)
+	})
+}
+
+// httpsRedirect redirige cualquier petición HTTP al mismo recurso en HTTPS
+func httpsRedirect(httpsAddr string) http.Handler {
+	_, port, _ := net.SplitHostPort(httpsAddr)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		host := r.Host
+		if h, _, err := net.SplitHostPort(host); err == nil {
+			host = h
+		}
+		if port != "" && port != "443" {
+			host = net.JoinHostPort(host, port)
+		}
+		http.Redirect(w, r, "https://"+host+r.URL.RequestURI(), http.StatusPermanentRedirect)
+	})
+}
+
+func newTLSServer(addr string, handler http.Handler, s tlsSettings) (*http.Server, http.Handler) {
+	protocols := new(http.Protocols)
+	protocols.SetHTTP1(true)
+	protocols.SetHTTP2(true)
+
+	srv := &http.Server{
+		Addr:              addr,
+		Handler:           withHSTS(handler, s.hstsMaxAge),
+		Protocols:         protocols,
+		ReadHeaderTimeout: 10 * time.Second,
+		TLSConfig:         &tls.Config{MinVersion: tls.VersionTLS12},
+	}
+
+	redirect := httpsRedirect(addr)
+	if len(s.autocertHosts) > 0 {
+		manager := &autocert.Manager{
+			Prompt:     autocert.AcceptTOS,
+			HostPolicy: autocert.HostWhitelist(s.autocertHosts...),
+			Cache:      autocert.DirCache(s.autocertCache),
+		}
+		srv.TLSConfig = manager.TLSConfig()
+		srv.TLSConfig.MinVersion = tls.VersionTLS12
+		// El listener HTTP también responde los desafíos ACME http-01
+		redirect = manager.HTTPHandler(redirect)
+	}
+	return srv, redirect
+}
+
+// serveTLS arranca el servidor HTTPS y, si está configurado, el listener de redirección HTTP
+func serveTLS(addr string, handler http.Handler, s tlsSettings) error {
+	srv, redirect := newTLSServer(addr, handler, s)
+
+	if s.redirectAddr != "off" {
+		go func() {
+			log.Printf("Redirección HTTP->HTTPS escuchando en %s", s.redirectAddr)
+			redirectSrv := &http.Server{Addr: s.redirectAddr, Handler: redirect, ReadHeaderTimeout: 10 * time.Second}
+			if err := redirectSrv.ListenAndServe(); err != nil {
+				log.Printf("Listener de redirección detenido: %v", err)
+			}
+		}()
+	}
+
+	log.Println("Server running at https://localhost" + addr)
+	if len(s.autocertHosts) > 0 {
+		return srv.ListenAndServeTLS("", "")
+	}
+	return srv.ListenAndServeTLS(s.certFile, s.keyFile)
+}
+
+// listenFromEnv elige entre HTTP plano y TLS según las variables de entorno
+func listenFromEnv(getEnv func(string) string) func(string, http.Handler) error {
+	settings := loadTLSSettings(getEnv)
+	if !settings.enabled() {
+		return func(addr string, handler http.Handler) error {
+			log.Println("Server running at http://localhost" + addr)
+			return http.ListenAndServe(addr, handler)
+		}
+	}
+	return func(addr string, handler http.Handler) error {
+		return serveTLS(addr, handler, settings)
+	}
+}
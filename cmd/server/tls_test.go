@@ -0,0 +1,83 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func envMap(values map[string]string) func(string) string {
+	return func(key string) string { return values[key] }
+}
+
+func TestLoadTLSSettings_Disabled(t *testing.T) {
+	s := loadTLSSettings(envMap(nil))
+	if s.enabled() {
+		t.Fatal("expected TLS disabled without cert or autocert hosts")
+	}
+	if s.redirectAddr != defaultRedirectAddr || s.hstsMaxAge != defaultHSTSMaxAge {
+		t.Fatalf("unexpected defaults %+v", s)
+	}
+}
+
+func TestLoadTLSSettings_Autocert(t *testing.T) {
+	s := loadTLSSettings(envMap(map[string]string{
+		"TLS_AUTOCERT_HOSTS": "walkie.example.com, radio.example.com",
+		"HSTS_MAX_AGE":       "600",
+		"TLS_REDIRECT_ADDR":  "off",
+	}))
+	if !s.enabled() {
+		t.Fatal("expected TLS enabled")
+	}
+	if len(s.autocertHosts) != 2 || s.autocertHosts[1] != "radio.example.com" {
+		t.Fatalf("unexpected hosts %v", s.autocertHosts)
+	}
+	if s.hstsMaxAge != 600 || s.redirectAddr != "off" {
+		t.Fatalf("unexpected settings %+v", s)
+	}
+}
+
+func TestWithHSTS(t *testing.T) {
+	h := withHSTS(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}), 100)
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	if got := rec.Header().Get("Strict-Transport-Security"); got != "max-age=100; includeSubDomains" {
+		t.Fatalf("unexpected HSTS header %q", got)
+	}
+}
+
+func TestHTTPSRedirect(t *testing.T) {
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "http://walkie.example.com/audio/poll?x=1", nil)
+	httpsRedirect(":8443").ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusPermanentRedirect {
+		t.Fatalf("expected 308, got %d", rec.Code)
+	}
+	if loc := rec.Header().Get("Location"); loc != "https://walkie.example.com:8443/audio/poll?x=1" {
+		t.Fatalf("unexpected location %q", loc)
+	}
+
+	rec = httptest.NewRecorder()
+	httpsRedirect(":443").ServeHTTP(rec, req)
+	if loc := rec.Header().Get("Location"); loc != "https://walkie.example.com/audio/poll?x=1" {
+		t.Fatalf("unexpected location %q", loc)
+	}
+}
+
+func TestNewTLSServer_EnablesHTTP2(t *testing.T) {
+	srv, redirect := newTLSServer(":8443", http.NewServeMux(), tlsSettings{
+		autocertHosts: []string{"walkie.example.com"},
+		autocertCache: t.TempDir(),
+		hstsMaxAge:    defaultHSTSMaxAge,
+	})
+	if !srv.Protocols.HTTP2() || !srv.Protocols.HTTP1() {
+		t.Fatal("expected HTTP/1.1 and HTTP/2 enabled")
+	}
+	if srv.TLSConfig == nil || srv.TLSConfig.GetCertificate == nil {
+		t.Fatal("expected autocert certificate callback")
+	}
+	if redirect == nil {
+		t.Fatal("expected redirect handler")
+	}
+}
@@ -0,0 +1,121 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"mime"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+// players son los reproductores que se prueban, en orden, si no se indica --player
+var players = [][]string{
+	{"ffplay", "-nodisp", "-autoexit", "-loglevel", "quiet"},
+	{"aplay", "-q"},
+	{"paplay"},
+	{"afplay"},
+}
+
+// audioSink guarda y reproduce los clips recibidos según los flags --out, --play y --player
+type audioSink struct {
+	dir    string
+	play   bool
+	player string
+}
+
+func (s *audioSink) flags(cmd *cobra.Command) {
+	cmd.Flags().StringVar(&s.dir, "out", "", "directorio donde guardar cada clip")
+	cmd.Flags().BoolVar(&s.play, "play", false, "reproduce cada clip al recibirlo")
+	cmd.Flags().StringVar(&s.player, "player", os.Getenv("WALKIE_PLAYER"), "comando que reproduce un fichero, p. ej. \"mpv --no-video\" (WALKIE_PLAYER)")
+}
+
+// handle guarda el clip si hay --out y lo reproduce si hay --play; played indica que
+// se reprodujo completo
+func (s *audioSink) handle(ctx context.Context, w io.Writer, audio []byte, contentType, name string) (played bool, err error) {
+	if s.dir == "" && !s.play {
+		return false, nil
+	}
+	path, err := s.write(audio, contentType, name)
+	if err != nil {
+		return false, err
+	}
+	if s.dir != "" {
+		fmt.Fprintf(w, "  guardado en %s\n", path)
+	} else {
+		defer os.Remove(path)
+	}
+	if !s.play {
+		return false, nil
+	}
+	args, err := s.playerArgs()
+	if err != nil {
+		return false, err
+	}
+	cmd := exec.CommandContext(ctx, args[0], append(args[1:], path)...)
+	cmd.Stdout, cmd.Stderr = io.Discard, w
+	if err := cmd.Run(); err != nil {
+		if ctx.Err() != nil {
+			return false, nil
+		}
+		return false, fmt.Errorf("no se pudo reproducir con %s: %w", args[0], err)
+	}
+	return true, nil
+}
+
+func (s *audioSink) write(audio []byte, contentType, name string) (string, error) {
+	ext := ".wav"
+	if exts, _ := mime.ExtensionsByType(contentType); len(exts) > 0 {
+		ext = exts[0]
+	}
+	if name == "" || name == "-" {
+		name = "clip"
+	}
+	if s.dir == "" {
+		f, err := os.CreateTemp("", "walkie-*"+ext)
+		if err != nil {
+			return "", err
+		}
+		defer f.Close()
+		_, err = f.Write(audio)
+		return f.Name(), err
+	}
+	if err := os.MkdirAll(s.dir, 0o755); err != nil {
+		return "", err
+	}
+	path := filepath.Join(s.dir, name+ext)
+	return path, os.WriteFile(path, audio, 0o644)
+}
+
+func (s *audioSink) playerArgs() ([]string, error) {
+	if s.player != "" {
+		return strings.Fields(s.player), nil
+	}
+	for _, p := range players {
+		if _, err := exec.LookPath(p[0]); err == nil {
+			return p, nil
+		}
+	}
+	return nil, fmt.Errorf("no se encontró reproductor (ffplay, aplay, paplay, afplay); usa --player")
+}
+
+// audioContentType deduce el tipo MIME de la extensión del fichero
+func audioContentType(path string) string {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".wav":
+		return "audio/wav"
+	case ".ogg", ".opus":
+		return "audio/ogg"
+	case ".webm":
+		return "audio/webm"
+	case ".flac":
+		return "audio/flac"
+	case ".mp3":
+		return "audio/mpeg"
+	}
+	return "application/octet-stream"
+}
@@ -0,0 +1,421 @@
+// walkie es una herramienta de línea de comandos para probar y operar el backend sin
+// la app: inicia sesión, entra en canales, envía WAV, recoge o reproduce el audio
+// recibido y muestra los eventos del WebSocket.
+//
+//	go run ./cmd/walkie auth --name Juan --pin 1234
+//	go run ./cmd/walkie join canal-1
+//	go run ./cmd/walkie send saludo.wav
+//	go run ./cmd/walkie poll --follow --play
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strconv"
+	"syscall"
+	"time"
+
+	"walkie-backend/pkg/client"
+
+	"github.com/spf13/cobra"
+)
+
+func main() {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	if err := newRootCmd().ExecuteContext(ctx); err != nil {
+		os.Exit(1)
+	}
+}
+
+// options son los flags comunes a todos los comandos
+type options struct {
+	server      string
+	token       string
+	name        string
+	pin         int
+	sessionFile string
+	retries     int
+}
+
+func newRootCmd() *cobra.Command {
+	opts := &options{}
+	root := &cobra.Command{
+		Use:          "walkie",
+		Short:        "Cliente de pruebas y operación del backend Walkie-Talkie IA",
+		SilenceUsage: true,
+	}
+	pf := root.PersistentFlags()
+	pf.StringVar(&opts.server, "server", envOr("WALKIE_SERVER", "http://localhost:80"), "URL del servidor (WALKIE_SERVER)")
+	pf.StringVar(&opts.token, "token", os.Getenv("WALKIE_TOKEN"), "token de sesión; si falta se usa la sesión guardada por auth (WALKIE_TOKEN)")
+	pf.StringVar(&opts.name, "name", os.Getenv("WALKIE_NAME"), "nombre para iniciar sesión en cada comando (WALKIE_NAME)")
+	pf.IntVar(&opts.pin, "pin", envInt("WALKIE_PIN"), "PIN de 4 dígitos (WALKIE_PIN)")
+	pf.StringVar(&opts.sessionFile, "session", defaultSessionFile(), "fichero donde auth guarda la sesión")
+	pf.IntVar(&opts.retries, "retries", 3, "intentos por petición ante errores transitorios")
+
+	root.AddCommand(
+		newAuthCmd(opts),
+		newMeCmd(opts),
+		newChannelsCmd(opts),
+		newJoinCmd(opts),
+		newLeaveCmd(opts),
+		newSendCmd(opts),
+		newPollCmd(opts),
+		newEventsCmd(opts),
+	)
+	return root
+}
+
+// session es lo que auth deja en disco para los comandos siguientes; no guarda el PIN
+type session struct {
+	Server string `json:"server"`
+	Token  string `json:"token"`
+	Name   string `json:"name"`
+}
+
+func defaultSessionFile() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return ".walkie-session.json"
+	}
+	return filepath.Join(dir, "walkie", "session.json")
+}
+
+func saveSession(path string, s session) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return err
+	}
+	data, _ := json.MarshalIndent(s, "", "  ")
+	return os.WriteFile(path, data, 0o600)
+}
+
+func loadSession(path string) (session, error) {
+	var s session
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return s, err
+	}
+	return s, json.Unmarshal(data, &s)
+}
+
+// newClient crea el cliente con la credencial disponible: --token, --name y --pin, o
+// la sesión guardada por auth
+func (o *options) newClient(ctx context.Context, needAuth bool) (*client.Client, error) {
+	clientOpts := []client.Option{client.WithRetries(o.retries, 250*time.Millisecond), client.WithUserAgent("walkie-cli")}
+	if !needAuth {
+		return client.New(o.server, clientOpts...)
+	}
+
+	switch {
+	case o.token != "":
+		return client.New(o.server, append(clientOpts, client.WithToken(o.token))...)
+	case o.name != "":
+		c, err := client.New(o.server, clientOpts...)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := c.Authenticate(ctx, o.name, o.pin); err != nil {
+			return nil, fmt.Errorf("no se pudo iniciar sesión: %w", err)
+		}
+		return c, nil
+	}
+
+	s, err := loadSession(o.sessionFile)
+	if err != nil || s.Token == "" {
+		return nil, errors.New("sin sesión: ejecuta walkie auth o pasa --token o --name y --pin")
+	}
+	if s.Server != "" && s.Server != o.server {
+		return nil, fmt.Errorf("la sesión guardada es de %s; pasa --server %s o vuelve a ejecutar auth", s.Server, s.Server)
+	}
+	return client.New(o.server, append(clientOpts, client.WithToken(s.Token))...)
+}
+
+func newAuthCmd(o *options) *cobra.Command {
+	return &cobra.Command{
+		Use:   "auth",
+		Short: "Inicia sesión (o registra al usuario) y guarda la sesión",
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			if o.name == "" || o.pin == 0 {
+				return errors.New("se requieren --name y --pin")
+			}
+			c, err := o.newClient(cmd.Context(), false)
+			if err != nil {
+				return err
+			}
+			res, err := c.Authenticate(cmd.Context(), o.name, o.pin)
+			if err != nil {
+				return err
+			}
+			if err := saveSession(o.sessionFile, session{Server: o.server, Token: res.Token, Name: o.name}); err != nil {
+				return fmt.Errorf("no se pudo guardar la sesión: %w", err)
+			}
+			fmt.Fprintf(cmd.OutOrStdout(), "%s\nSesión guardada en %s\n", res.Message, o.sessionFile)
+			return nil
+		},
+	}
+}
+
+func newMeCmd(o *options) *cobra.Command {
+	return &cobra.Command{
+		Use:   "me",
+		Short: "Muestra el perfil del usuario",
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			c, err := o.newClient(cmd.Context(), true)
+			if err != nil {
+				return err
+			}
+			me, err := c.Me(cmd.Context())
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(cmd.OutOrStdout(), "Usuario %d: %s, canal %s\n", me.ID, me.DisplayName, orDash(me.CurrentChannel))
+			return nil
+		},
+	}
+}
+
+func newChannelsCmd(o *options) *cobra.Command {
+	var members bool
+	cmd := &cobra.Command{
+		Use:   "channels",
+		Short: "Lista los canales públicos",
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			c, err := o.newClient(cmd.Context(), false)
+			if err != nil {
+				return err
+			}
+			channels, err := c.ListChannels(cmd.Context())
+			if err != nil {
+				return err
+			}
+			out := cmd.OutOrStdout()
+			for _, ch := range channels {
+				fmt.Fprintf(out, "%-16s %-24s máx %d\n", ch.Code, ch.Name, ch.MaxUsers)
+				if !members {
+					continue
+				}
+				users, err := c.ChannelUsers(cmd.Context(), ch.Code)
+				if err != nil {
+					return err
+				}
+				for _, u := range users {
+					fmt.Fprintf(out, "    %d %s\n", u.ID, u.DisplayName)
+				}
+			}
+			return nil
+		},
+	}
+	cmd.Flags().BoolVar(&members, "members", false, "muestra también los usuarios conectados")
+	return cmd
+}
+
+func newJoinCmd(o *options) *cobra.Command {
+	return &cobra.Command{
+		Use:   "join CANAL",
+		Short: "Entra en un canal",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			c, err := o.newClient(cmd.Context(), true)
+			if err != nil {
+				return err
+			}
+			resp, err := c.ConnectChannel(cmd.Context(), args[0])
+			if err != nil {
+				return err
+			}
+			fmt.Fprintln(cmd.OutOrStdout(), resp.Message)
+			return nil
+		},
+	}
+}
+
+func newLeaveCmd(o *options) *cobra.Command {
+	return &cobra.Command{
+		Use:   "leave",
+		Short: "Sale del canal actual",
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			c, err := o.newClient(cmd.Context(), true)
+			if err != nil {
+				return err
+			}
+			resp, err := c.DisconnectChannel(cmd.Context())
+			if err != nil {
+				return err
+			}
+			fmt.Fprintln(cmd.OutOrStdout(), resp.Message)
+			return nil
+		},
+	}
+}
+
+func newSendCmd(o *options) *cobra.Command {
+	var contentType string
+	cmd := &cobra.Command{
+		Use:   "send FICHERO",
+		Short: "Envía un clip de audio al canal actual (o como comando de voz)",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			audio, err := os.ReadFile(args[0])
+			if err != nil {
+				return err
+			}
+			if contentType == "" {
+				contentType = audioContentType(args[0])
+			}
+			c, err := o.newClient(cmd.Context(), true)
+			if err != nil {
+				return err
+			}
+			res, err := c.IngestAudio(cmd.Context(), audio, contentType)
+			if err != nil {
+				return err
+			}
+			out := cmd.OutOrStdout()
+			if res.Command != nil {
+				fmt.Fprintf(out, "[%s] %s\n", res.Command.Intent, res.Command.Message)
+				return nil
+			}
+			fmt.Fprintf(out, "Difundido: transmisión %s", orDash(res.TransmissionID))
+			if res.Priority != "" {
+				fmt.Fprintf(out, " (%s)", res.Priority)
+			}
+			fmt.Fprintln(out)
+			return nil
+		},
+	}
+	cmd.Flags().StringVar(&contentType, "type", "", "tipo MIME; por defecto se deduce de la extensión")
+	return cmd
+}
+
+func newPollCmd(o *options) *cobra.Command {
+	var (
+		follow   bool
+		interval time.Duration
+		ack      bool
+		out      audioSink
+	)
+	cmd := &cobra.Command{
+		Use:   "poll",
+		Short: "Recoge el audio pendiente; con --follow sigue esperando hasta Ctrl+C",
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			ctx := cmd.Context()
+			c, err := o.newClient(ctx, true)
+			if err != nil {
+				return err
+			}
+			w := cmd.OutOrStdout()
+			for {
+				clip, err := c.PollAudio(ctx, client.PollOptions{Ack: ack})
+				if err != nil {
+					if ctx.Err() != nil {
+						return nil
+					}
+					return err
+				}
+				if clip == nil {
+					if !follow {
+						fmt.Fprintln(w, "No hay audio pendiente")
+						return nil
+					}
+					select {
+					case <-ctx.Done():
+						return nil
+					case <-time.After(interval):
+					}
+					continue
+				}
+
+				fmt.Fprintf(w, "%s  de %d en %s, %d bytes, transmisión %s", clip.Timestamp.Local().Format(time.TimeOnly),
+					clip.From, orDash(clip.Channel), len(clip.Audio), orDash(clip.TransmissionID))
+				if clip.Priority != "" {
+					fmt.Fprintf(w, " (%s)", clip.Priority)
+				}
+				if clip.Notice != "" {
+					fmt.Fprintf(w, " — %s", clip.Notice)
+				}
+				fmt.Fprintln(w)
+
+				played, err := out.handle(ctx, w, clip.Audio, clip.ContentType, clip.TransmissionID)
+				if err != nil {
+					return err
+				}
+				if ack && clip.DeliveryID != "" {
+					if err := c.AckAudio(ctx, clip.DeliveryID); err != nil {
+						return err
+					}
+				}
+				if played && clip.TransmissionID != "" {
+					_ = c.MarkPlayed(ctx, clip.TransmissionID)
+				}
+			}
+		},
+	}
+	cmd.Flags().BoolVar(&follow, "follow", false, "sigue recogiendo audio hasta Ctrl+C")
+	cmd.Flags().DurationVar(&interval, "interval", time.Second, "espera entre peticiones cuando no hay audio")
+	cmd.Flags().BoolVar(&ack, "ack", false, "usa entregas confirmadas (X-Audio-Ack)")
+	out.flags(cmd)
+	return cmd
+}
+
+func newEventsCmd(o *options) *cobra.Command {
+	var (
+		channel string
+		out     audioSink
+	)
+	cmd := &cobra.Command{
+		Use:   "events",
+		Short: "Muestra los eventos del WebSocket hasta Ctrl+C; el audio se resume en una línea",
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			ctx := cmd.Context()
+			c, err := o.newClient(ctx, true)
+			if err != nil {
+				return err
+			}
+			sub, err := c.Subscribe(ctx, channel)
+			if err != nil {
+				return err
+			}
+			defer sub.Close()
+
+			w := cmd.OutOrStdout()
+			for ev := range sub.Events() {
+				if ev.Type != "audio" {
+					fmt.Fprintln(w, string(ev.Raw))
+					continue
+				}
+				fmt.Fprintf(w, "audio seq=%d %d bytes\n", ev.Seq, len(ev.Audio))
+				if _, err := out.handle(ctx, w, ev.Audio, "", "seq-"+strconv.FormatUint(ev.Seq, 10)); err != nil {
+					return err
+				}
+			}
+			return sub.Err()
+		},
+	}
+	cmd.Flags().StringVar(&channel, "channel", "", "canal del socket; por defecto el canal actual del usuario")
+	out.flags(cmd)
+	return cmd
+}
+
+func envOr(key, fallback string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return fallback
+}
+
+func envInt(key string) int {
+	n, _ := strconv.Atoi(os.Getenv(key))
+	return n
+}
+
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
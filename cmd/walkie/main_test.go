@@ -0,0 +1,128 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func fakeServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	writeJSON := func(w http.ResponseWriter, v any) {
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(v)
+	}
+	authed := func(h http.HandlerFunc) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			if r.Header.Get("X-Auth-Token") != "tok-juan" {
+				w.WriteHeader(http.StatusUnauthorized)
+				writeJSON(w, map[string]string{"error": "Token inválido"})
+				return
+			}
+			h(w, r)
+		}
+	}
+	polled := false
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /v1/auth", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, map[string]any{"message": "inicio de sesión exitoso", "token": "tok-juan"})
+	})
+	mux.HandleFunc("GET /v1/channels/public", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, []map[string]any{{"code": "canal-1", "name": "Canal 1", "maxUsers": 10}})
+	})
+	mux.HandleFunc("POST /v1/channels/{code}/connect", authed(func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, map[string]any{"status": "ok", "message": "Conectado al canal " + r.PathValue("code")})
+	}))
+	mux.HandleFunc("POST /v1/audio/ingest", authed(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		assert.Equal(t, "RIFFdatos", string(body))
+		assert.Equal(t, "audio/wav", r.Header.Get("Content-Type"))
+		w.Header().Set("X-Transmission-ID", "tx-1")
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	mux.HandleFunc("GET /v1/audio/poll", authed(func(w http.ResponseWriter, r *http.Request) {
+		if polled {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		polled = true
+		w.Header().Set("Content-Type", "audio/wav")
+		w.Header().Set("X-Audio-From", "3")
+		w.Header().Set("X-Channel", "canal-1")
+		w.Header().Set("X-Transmission-ID", "tx-9")
+		_, _ = w.Write([]byte("RIFFrecibido"))
+	}))
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func runWalkie(t *testing.T, args ...string) (string, error) {
+	t.Helper()
+	var out bytes.Buffer
+	cmd := newRootCmd()
+	cmd.SetArgs(args)
+	cmd.SetOut(&out)
+	cmd.SetErr(&out)
+	err := cmd.Execute()
+	return out.String(), err
+}
+
+func TestWalkieCLI_SessionAndAudio(t *testing.T) {
+	srv := fakeServer(t)
+	dir := t.TempDir()
+	common := []string{"--server", srv.URL, "--session", filepath.Join(dir, "session.json"), "--token", "", "--name", ""}
+
+	_, err := runWalkie(t, append(common, "join", "canal-1")...)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "sin sesión")
+
+	out, err := runWalkie(t, append(common, "auth", "--name", "Juan", "--pin", "1234")...)
+	require.NoError(t, err)
+	assert.Contains(t, out, "inicio de sesión exitoso")
+
+	out, err = runWalkie(t, append(common, "channels")...)
+	require.NoError(t, err)
+	assert.Contains(t, out, "canal-1")
+
+	out, err = runWalkie(t, append(common, "join", "canal-1")...)
+	require.NoError(t, err)
+	assert.Contains(t, out, "Conectado al canal canal-1")
+
+	clip := filepath.Join(dir, "saludo.wav")
+	require.NoError(t, os.WriteFile(clip, []byte("RIFFdatos"), 0o644))
+	out, err = runWalkie(t, append(common, "send", clip)...)
+	require.NoError(t, err)
+	assert.Contains(t, out, "transmisión tx-1")
+
+	clips := filepath.Join(dir, "clips")
+	out, err = runWalkie(t, append(common, "poll", "--out", clips)...)
+	require.NoError(t, err)
+	assert.Contains(t, out, "de 3 en canal-1")
+	saved, err := os.ReadFile(filepath.Join(clips, "tx-9.wav"))
+	require.NoError(t, err)
+	assert.Equal(t, "RIFFrecibido", string(saved))
+
+	out, err = runWalkie(t, append(common, "poll")...)
+	require.NoError(t, err)
+	assert.Contains(t, out, "No hay audio pendiente")
+
+	_, err = runWalkie(t, "--server", "http://otro:80", "--session", filepath.Join(dir, "session.json"), "--token", "", "--name", "", "leave")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "la sesión guardada es de")
+}
+
+func TestAudioContentType(t *testing.T) {
+	assert.Equal(t, "audio/wav", audioContentType("a.WAV"))
+	assert.Equal(t, "audio/ogg", audioContentType("a.opus"))
+	assert.Equal(t, "application/octet-stream", audioContentType("a.bin"))
+}
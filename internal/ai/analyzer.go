@@ -0,0 +1,84 @@
+package ai
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"walkie-backend/pkg/qwen"
+)
+
+// PendingAnyChannel es el canal pendiente cuando se preguntó "¿a qué canal?" sin proponer uno
+const PendingAnyChannel = qwen.PendingAnyChannel
+
+// CommandResult es la clasificación de una transcripción, independiente del proveedor
+type CommandResult struct {
+	IsCommand      bool     `json:"is_command"`
+	Intent         string   `json:"intent"`
+	Reply          string   `json:"reply"`
+	Channels       []string `json:"channels,omitempty"`
+	State          string   `json:"state"`
+	PendingChannel string   `json:"pending_channel,omitempty"`
+	Recipient      string   `json:"recipient,omitempty"`
+	Alias          string   `json:"alias,omitempty"`
+	// Confidence es la seguridad de la clasificación (0-1); 0 significa que el proveedor
+	// no la indicó y se trata como segura
+	Confidence float64 `json:"confidence,omitempty"`
+}
+
+// Analyzer clasifica una transcripción como comando o conversación
+type Analyzer interface {
+	AnalyzeTranscript(ctx context.Context, transcript string, channels []string, currentState string, pendingChannel string) (CommandResult, error)
+}
+
+// Pinger comprueba que el proveedor responde y acepta las credenciales sin gastar tokens
+type Pinger interface {
+	Ping(ctx context.Context) error
+}
+
+// Summarizer resume en estilo hablado una conversación ("[hh:mm] nombre: texto" por línea).
+// Lo implementan los proveedores de chat; el resto puede no soportarlo.
+type Summarizer interface {
+	Summarize(ctx context.Context, lines []string) (string, error)
+}
+
+// Assistant responde preguntas dirigidas al asistente del canal; lines es la conversación
+// reciente con el mismo formato que Summarizer
+type Assistant interface {
+	Answer(ctx context.Context, question string, lines []string) (string, error)
+}
+
+// AbuseClassifier decide si una transcripción contiene insultos, acoso o amenazas
+type AbuseClassifier interface {
+	IsAbusive(ctx context.Context, transcript string) (bool, error)
+}
+
+const (
+	ProviderQwen     = "qwen"
+	ProviderDeepseek = "deepseek"
+	ProviderOllama   = "ollama"
+)
+
+// Provider devuelve el proveedor configurado en AI_PROVIDER (qwen por defecto)
+func Provider() string {
+	p := strings.ToLower(strings.TrimSpace(os.Getenv("AI_PROVIDER")))
+	if p == "" {
+		return ProviderQwen
+	}
+	return p
+}
+
+// New crea el analizador del proveedor configurado
+func New() (Analyzer, error) {
+	switch p := Provider(); p {
+	case ProviderQwen:
+		return NewQwen()
+	case ProviderDeepseek:
+		return NewDeepseek()
+	case ProviderOllama:
+		return NewOllama()
+	default:
+		return nil, fmt.Errorf("AI_PROVIDER desconocido: %s", p)
+	}
+}
@@ -0,0 +1,76 @@
+package ai
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNew_SelectsProvider(t *testing.T) {
+	t.Setenv("AI_PROVIDER", "")
+	a, err := New()
+	require.NoError(t, err)
+	assert.NotNil(t, a)
+	_, summarizes := a.(Summarizer)
+	assert.True(t, summarizes, "los proveedores de chat deben poder resumir")
+	_, moderates := a.(AbuseClassifier)
+	assert.True(t, moderates, "los proveedores de chat deben poder moderar")
+	_, answers := a.(Assistant)
+	assert.True(t, answers, "los proveedores de chat deben poder responder como asistente")
+
+	t.Setenv("AI_PROVIDER", "deepseek")
+	t.Setenv("DEEPSEEK_API_KEY", "")
+	_, err = New()
+	assert.Error(t, err)
+
+	t.Setenv("DEEPSEEK_API_KEY", "k")
+	a, err = New()
+	require.NoError(t, err)
+	assert.NotNil(t, a)
+
+	t.Setenv("AI_PROVIDER", "Ollama")
+	a, err = New()
+	require.NoError(t, err)
+	assert.NotNil(t, a)
+
+	t.Setenv("AI_PROVIDER", "gpt-local")
+	_, err = New()
+	assert.Error(t, err)
+}
+
+func TestOllamaAdapter_UsesConfiguredEndpoint(t *testing.T) {
+	var model, auth string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/v1/chat/completions", r.URL.Path)
+		auth = r.Header.Get("Authorization")
+		var body struct {
+			Model string `json:"model"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		model = body.Model
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"choices": []map[string]any{{"message": map[string]string{
+				"role":    "assistant",
+				"content": `{"is_command":true,"intent":"request_channel_disconnect","state":"canal-1"}`,
+			}}},
+		})
+	}))
+	defer server.Close()
+
+	t.Setenv("OLLAMA_URL", server.URL+"/v1")
+	t.Setenv("OLLAMA_MODEL", "llama-test")
+	a, err := NewOllama()
+	require.NoError(t, err)
+
+	res, err := a.AnalyzeTranscript(context.Background(), "prueba adaptador ollama", []string{"canal-1"}, "canal-1", "")
+	require.NoError(t, err)
+	assert.True(t, res.IsCommand)
+	assert.Equal(t, "request_channel_disconnect", res.Intent)
+	assert.Equal(t, "llama-test", model)
+	assert.Empty(t, auth)
+}
@@ -0,0 +1,95 @@
+package ai
+
+import (
+	"context"
+	"errors"
+	"os"
+	"strings"
+
+	"walkie-backend/pkg/qwen"
+)
+
+const (
+	defaultDeepseekURL   = "https://api.deepseek.com/v1"
+	defaultDeepseekModel = "deepseek-chat"
+	defaultOllamaURL     = "http://localhost:11434/v1"
+	defaultOllamaModel   = "qwen2.5:7b"
+)
+
+// chatAnalyzer adapta el cliente de chat compatible con OpenAI de pkg/qwen; Deepseek y
+// Ollama exponen la misma API, así que sólo cambian la URL, el modelo y la clave
+type chatAnalyzer struct {
+	client *qwen.Client
+}
+
+func (a *chatAnalyzer) AnalyzeTranscript(ctx context.Context, transcript string, channels []string, currentState string, pendingChannel string) (CommandResult, error) {
+	res, err := a.client.AnalyzeTranscript(ctx, transcript, channels, currentState, pendingChannel)
+	return fromQwen(res), err
+}
+
+func (a *chatAnalyzer) Summarize(ctx context.Context, lines []string) (string, error) {
+	return a.client.Summarize(ctx, lines)
+}
+
+func (a *chatAnalyzer) IsAbusive(ctx context.Context, transcript string) (bool, error) {
+	return a.client.IsAbusive(ctx, transcript)
+}
+
+func (a *chatAnalyzer) Answer(ctx context.Context, question string, lines []string) (string, error) {
+	return a.client.Answer(ctx, question, lines)
+}
+
+func (a *chatAnalyzer) Ping(ctx context.Context) error {
+	return a.client.Ping(ctx)
+}
+
+func fromQwen(r qwen.CommandResult) CommandResult {
+	return CommandResult{
+		IsCommand:      r.IsCommand,
+		Intent:         r.Intent,
+		Reply:          r.Reply,
+		Channels:       r.Channels,
+		State:          r.State,
+		PendingChannel: r.PendingChannel,
+		Recipient:      r.Recipient,
+		Alias:          r.Alias,
+		Confidence:     r.Confidence,
+	}
+}
+
+func envOr(key, fallback string) string {
+	if v := strings.TrimSpace(os.Getenv(key)); v != "" {
+		return v
+	}
+	return fallback
+}
+
+// NewQwen usa AI_API_URL, AI_MODEL y DO_AI_ACCESS_KEY (Qwen en DigitalOcean)
+func NewQwen() (Analyzer, error) {
+	client, err := qwen.NewClient()
+	if err != nil {
+		return nil, err
+	}
+	return &chatAnalyzer{client: client}, nil
+}
+
+// NewDeepseek usa DEEPSEEK_API_KEY (obligatoria), DEEPSEEK_API_URL y DEEPSEEK_MODEL
+func NewDeepseek() (Analyzer, error) {
+	key := strings.TrimSpace(os.Getenv("DEEPSEEK_API_KEY"))
+	if key == "" {
+		return nil, errors.New("DEEPSEEK_API_KEY no está configurada")
+	}
+	return &chatAnalyzer{client: qwen.NewClientWithConfig(qwen.Config{
+		BaseURL: envOr("DEEPSEEK_API_URL", defaultDeepseekURL),
+		Model:   envOr("DEEPSEEK_MODEL", defaultDeepseekModel),
+		APIKey:  key,
+	})}, nil
+}
+
+// NewOllama usa OLLAMA_URL y OLLAMA_MODEL; Ollama local no necesita clave
+func NewOllama() (Analyzer, error) {
+	return &chatAnalyzer{client: qwen.NewClientWithConfig(qwen.Config{
+		BaseURL: envOr("OLLAMA_URL", defaultOllamaURL),
+		Model:   envOr("OLLAMA_MODEL", defaultOllamaModel),
+	})}, nil
+}
@@ -1,10 +1,14 @@
 package config
 
 import (
+	"context"
+	"fmt"
 	"log"
 	"os"
+	"regexp"
 	"strings"
 	"sync"
+	"time"
 	"walkie-backend/internal/models"
 
 	"gorm.io/driver/postgres"
@@ -19,18 +23,62 @@ var (
 
 func ConnectDB() {
 	once.Do(func() {
-		db, err := connectAndMigrate(os.Getenv("DATABASE_URL"))
+		db, err := connectWithRetry(os.Getenv("DATABASE_URL"), connectAttempts(), connectAndMigrate)
 		if err != nil {
 			log.Fatal("Error connecting PostgreSQL:", err)
 		}
 		DB = db
+		SetDBAvailable(true)
+		StartHealthMonitor(context.Background())
+		connectReadReplica()
 		log.Println("DB connected, migrated and seeded")
 	})
 }
 
+// connectWithRetry reintenta la conexión inicial con backoff exponencial
+func connectWithRetry(dsn string, attempts int, connect func(string) (*gorm.DB, error)) (*gorm.DB, error) {
+	backoff := initialBackoff
+	var lastErr error
+	for attempt := 1; attempt <= attempts; attempt++ {
+		db, err := connect(dsn)
+		if err == nil {
+			return db, nil
+		}
+		lastErr = err
+		if attempt < attempts {
+			log.Printf("Intento %d/%d de conexión a la base de datos falló: %v (reintento en %s)", attempt, attempts, err, backoff)
+			time.Sleep(backoff)
+			backoff = nextBackoff(backoff)
+		}
+	}
+	return nil, lastErr
+}
+
 func connectAndMigrate(dsn string) (*gorm.DB, error) {
+	db, err := OpenDB(dsn)
+	if err != nil {
+		return nil, err
+	}
+
+	if migrateOnStart() {
+		applied, err := Migrate(db)
+		if err != nil {
+			return nil, err
+		}
+		for _, id := range applied {
+			log.Printf("Migración aplicada: %s", id)
+		}
+	}
+
+	seedDatabase(db)
+	return db, nil
+}
+
+// OpenDB abre la base de datos de DATABASE_URL sin migrar: Postgres por defecto
+// (URL postgres:// o DSN clave=valor) y SQLite para ":memory:" y "file:..."
+func OpenDB(dsn string) (*gorm.DB, error) {
 	var dialector gorm.Dialector
-	if dsn == ":memory:" || strings.HasPrefix(dsn, "file::") {
+	if dsn == ":memory:" || strings.HasPrefix(dsn, "file:") {
 		dialector = sqlite.Open(dsn)
 	} else {
 		dialector = postgres.Open(dsn)
@@ -40,38 +88,179 @@ func connectAndMigrate(dsn string) (*gorm.DB, error) {
 	if err != nil {
 		return nil, err
 	}
-
-	if err := db.AutoMigrate(
-		&models.User{},
-		&models.Channel{},
-		&models.ChannelMembership{},
-	); err != nil {
+	if err := db.Use(NewQueryMetrics()); err != nil {
+		return nil, err
+	}
+	if err := configurePool(db); err != nil {
 		return nil, err
 	}
-
-	seedDatabase(db)
 	return db, nil
 }
 
-func seedDatabase(db *gorm.DB) {
-	channels := []models.Channel{
-		{Code: "canal-1", Name: "Canal 1", MaxUsers: 100, IsPrivate: false},
-		{Code: "canal-2", Name: "Canal 2", MaxUsers: 100, IsPrivate: false},
-		{Code: "canal-3", Name: "Canal 3", MaxUsers: 100, IsPrivate: false},
-		{Code: "canal-4", Name: "Canal 4", MaxUsers: 100, IsPrivate: false},
-		{Code: "canal-5", Name: "Canal 5", MaxUsers: 100, IsPrivate: false},
-	}
-
-	for _, ch := range channels {
-		var count int64
-		db.Model(&models.Channel{}).Where("code = ?", ch.Code).Count(&count)
-		if count == 0 {
-			if err := db.Create(&ch).Error; err != nil {
-				log.Printf("Error seeding channel %s: %v", ch.Code, err)
-			} else {
-				log.Printf("Canal creado: %s", ch.Code)
-			}
+// configurePool aplica DB_MAX_OPEN_CONNS, DB_MAX_IDLE_CONNS, DB_CONN_MAX_LIFETIME y
+// DB_CONN_MAX_IDLE_TIME; la réplica de lectura usa los mismos valores
+func configurePool(db *gorm.DB) error {
+	sqlDB, err := db.DB()
+	if err != nil {
+		return err
+	}
+	if n := IntFromEnv("DB_MAX_OPEN_CONNS", 0); n > 0 {
+		sqlDB.SetMaxOpenConns(n)
+	}
+	if n := IntFromEnv("DB_MAX_IDLE_CONNS", 0); n > 0 {
+		sqlDB.SetMaxIdleConns(n)
+	}
+	if d := DurationFromEnv("DB_CONN_MAX_LIFETIME", 0); d > 0 {
+		sqlDB.SetConnMaxLifetime(d)
+	}
+	if d := DurationFromEnv("DB_CONN_MAX_IDLE_TIME", 0); d > 0 {
+		sqlDB.SetConnMaxIdleTime(d)
+	}
+	return nil
+}
+
+// migrateOnStart indica si el servidor aplica las migraciones al arrancar
+// (DB_MIGRATE_ON_START=false las deja para "server migrate")
+func migrateOnStart() bool {
+	return strings.TrimSpace(strings.ToLower(os.Getenv("DB_MIGRATE_ON_START"))) != "false"
+}
+
+const (
+	defaultSeedChannels = 5
+	defaultSeedMaxUsers = 100
+)
+
+var seedCodePattern = regexp.MustCompile(`^[a-z0-9][a-z0-9-]{0,63}$`)
+
+// SeedOptions define los canales públicos por defecto: los de List o, si está vacía,
+// canal-1..canal-N
+type SeedOptions struct {
+	Channels int
+	MaxUsers int
+	Echo     bool
+	List     []SeedChannel
+}
+
+// SeedChannel es un canal con código y nombre propios
+type SeedChannel struct {
+	Code string
+	Name string
+}
+
+// SeedOptionsFromEnv lee SEED_CHANNELS (5), SEED_CHANNEL_MAX_USERS (100), SEED_CHANNEL_LIST
+// y ECHO_CHANNEL_ENABLED
+func SeedOptionsFromEnv() SeedOptions {
+	list, err := ParseSeedChannelList(os.Getenv("SEED_CHANNEL_LIST"))
+	if err != nil {
+		log.Printf("SEED_CHANNEL_LIST inválido, usando canal-1..canal-N: %v", err)
+		list = nil
+	}
+	return SeedOptions{
+		Channels: IntFromEnv("SEED_CHANNELS", defaultSeedChannels),
+		MaxUsers: IntFromEnv("SEED_CHANNEL_MAX_USERS", defaultSeedMaxUsers),
+		Echo:     strings.EqualFold(strings.TrimSpace(os.Getenv("ECHO_CHANNEL_ENABLED")), "true"),
+		List:     list,
+	}
+}
+
+// ParseSeedChannelList lee una lista "codigo:Nombre,otro-codigo:Otro nombre"; sin nombre
+// se usa el código
+func ParseSeedChannelList(raw string) ([]SeedChannel, error) {
+	var list []SeedChannel
+	seen := map[string]bool{}
+	for _, item := range strings.Split(raw, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		code, name, _ := strings.Cut(item, ":")
+		code = strings.ToLower(strings.TrimSpace(code))
+		name = strings.TrimSpace(name)
+		if !seedCodePattern.MatchString(code) {
+			return nil, fmt.Errorf("código de canal inválido %q: usa minúsculas, números y guiones", code)
+		}
+		if seen[code] {
+			return nil, fmt.Errorf("código de canal repetido %q", code)
+		}
+		seen[code] = true
+		if name == "" {
+			name = code
+		}
+		list = append(list, SeedChannel{Code: code, Name: name})
+	}
+	return list, nil
+}
+
+// seedList devuelve los canales a crear: la lista explícita o canal-1..canal-N
+func (o SeedOptions) seedList() []SeedChannel {
+	if len(o.List) > 0 {
+		return o.List
+	}
+	list := make([]SeedChannel, 0, o.Channels)
+	for i := 1; i <= o.Channels; i++ {
+		list = append(list, SeedChannel{Code: fmt.Sprintf("canal-%d", i), Name: fmt.Sprintf("Canal %d", i)})
+	}
+	return list
+}
+
+// SeedChannels crea los canales por defecto que falten y devuelve sus códigos; los
+// existentes no se tocan (ni se recuperan los borrados), así que se puede ejecutar en cada arranque
+func SeedChannels(db *gorm.DB, opts SeedOptions) ([]string, error) {
+	maxUsers := opts.MaxUsers
+	if maxUsers <= 0 {
+		maxUsers = defaultSeedMaxUsers
+	}
+
+	var created []string
+	for _, seed := range opts.seedList() {
+		ch := models.Channel{
+			Code:     seed.Code,
+			Name:     seed.Name,
+			MaxUsers: maxUsers,
 		}
+		ok, err := createChannelIfMissing(db, ch)
+		if err != nil {
+			return created, err
+		}
+		if ok {
+			created = append(created, ch.Code)
+		}
+	}
+
+	if opts.Echo {
+		echo := models.Channel{Code: "eco", Name: "Canal Eco", MaxUsers: maxUsers, Kind: models.ChannelKindEcho}
+		ok, err := createChannelIfMissing(db, echo)
+		if err != nil {
+			return created, err
+		}
+		if ok {
+			created = append(created, echo.Code)
+		}
+	}
+	return created, nil
+}
+
+func createChannelIfMissing(db *gorm.DB, ch models.Channel) (bool, error) {
+	var count int64
+	if err := db.Unscoped().Model(&models.Channel{}).Where("code = ?", ch.Code).Count(&count).Error; err != nil {
+		return false, fmt.Errorf("error buscando canal %s: %w", ch.Code, err)
+	}
+	if count > 0 {
+		return false, nil
+	}
+	if err := db.Create(&ch).Error; err != nil {
+		return false, fmt.Errorf("error creando canal %s: %w", ch.Code, err)
+	}
+	return true, nil
+}
+
+func seedDatabase(db *gorm.DB) {
+	created, err := SeedChannels(db, SeedOptionsFromEnv())
+	for _, code := range created {
+		log.Printf("Canal creado: %s", code)
+	}
+	if err != nil {
+		log.Printf("Error sembrando canales: %v", err)
 	}
 
 	log.Println("Database seeding completed")
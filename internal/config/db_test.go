@@ -2,6 +2,7 @@ package config
 
 import (
 	"reflect"
+	"strings"
 	"sync"
 	"testing"
 
@@ -118,3 +119,81 @@ func TestConnectDB(t *testing.T) {
 		t.Fatalf("expected 5 channels, got %d", channelCount)
 	}
 }
+
+func TestSeedDatabase_EchoChannelWhenEnabled(t *testing.T) {
+	t.Setenv("ECHO_CHANNEL_ENABLED", "true")
+	db, err := gorm.Open(sqlite.Open("file:echo_seed?mode=memory&cache=shared"), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	if err != nil {
+		t.Fatalf("failed to open sqlite: %v", err)
+	}
+	if err := db.AutoMigrate(&models.Channel{}); err != nil {
+		t.Fatalf("failed to migrate: %v", err)
+	}
+
+	seedDatabase(db)
+	seedDatabase(db)
+
+	var echo models.Channel
+	if err := db.Where("code = ?", "eco").First(&echo).Error; err != nil {
+		t.Fatalf("expected echo channel: %v", err)
+	}
+	if !echo.IsEcho() {
+		t.Fatalf("expected echo kind, got %q", echo.Kind)
+	}
+}
+
+func TestSeedChannels_UsesOptions(t *testing.T) {
+	db := setupTestDB(t)
+	db.Unscoped().Where("1 = 1").Delete(&models.Channel{})
+
+	created, err := SeedChannels(db, SeedOptions{Channels: 7, MaxUsers: 25})
+	if err != nil {
+		t.Fatalf("SeedChannels: %v", err)
+	}
+	if len(created) != 7 || created[6] != "canal-7" {
+		t.Fatalf("unexpected created channels %v", created)
+	}
+
+	created, err = SeedChannels(db, SeedOptions{Channels: 7, MaxUsers: 25})
+	if err != nil || len(created) != 0 {
+		t.Fatalf("expected idempotent seed, got %v (%v)", created, err)
+	}
+
+	var ch models.Channel
+	db.Where("code = ?", "canal-7").First(&ch)
+	if ch.MaxUsers != 25 {
+		t.Fatalf("expected MaxUsers 25, got %d", ch.MaxUsers)
+	}
+}
+
+func TestSeedChannels_UsesExplicitList(t *testing.T) {
+	db := setupTestDB(t)
+	db.Unscoped().Where("1 = 1").Delete(&models.Channel{})
+
+	list, err := ParseSeedChannelList(" logistica:Logística , obra-norte:Obra Norte,patio")
+	if err != nil {
+		t.Fatalf("ParseSeedChannelList: %v", err)
+	}
+	created, err := SeedChannels(db, SeedOptions{Channels: 5, MaxUsers: 10, List: list})
+	if err != nil {
+		t.Fatalf("SeedChannels: %v", err)
+	}
+	if strings.Join(created, ",") != "logistica,obra-norte,patio" {
+		t.Fatalf("unexpected created channels %v", created)
+	}
+
+	var ch models.Channel
+	db.Where("code = ?", "obra-norte").First(&ch)
+	if ch.Name != "Obra Norte" {
+		t.Fatalf("expected name Obra Norte, got %q", ch.Name)
+	}
+
+	if _, err := ParseSeedChannelList("Canal Uno:Uno"); err == nil {
+		t.Fatal("expected error for invalid code")
+	}
+	if _, err := ParseSeedChannelList("a:A,a:B"); err == nil {
+		t.Fatal("expected error for repeated code")
+	}
+}
@@ -0,0 +1,70 @@
+package config
+
+import (
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// IntFromEnv lee un entero no negativo; vacío devuelve fallback y un valor inválido lo
+// avisa en el log y también devuelve fallback
+func IntFromEnv(key string, fallback int) int {
+	return parseIntEnv(key, fallback, 0)
+}
+
+// PositiveIntFromEnv es IntFromEnv para valores que no pueden ser 0
+func PositiveIntFromEnv(key string, fallback int) int {
+	return parseIntEnv(key, fallback, 1)
+}
+
+func parseIntEnv(key string, fallback, min int) int {
+	raw := strings.TrimSpace(os.Getenv(key))
+	if raw == "" {
+		return fallback
+	}
+	n, err := strconv.Atoi(raw)
+	if err != nil || n < min {
+		log.Printf("%s inválido (%s), usando %d", key, raw, fallback)
+		return fallback
+	}
+	return n
+}
+
+// FloatFromEnv lee un número entre min y max, ambos incluidos
+func FloatFromEnv(key string, fallback, min, max float64) float64 {
+	raw := strings.TrimSpace(os.Getenv(key))
+	if raw == "" {
+		return fallback
+	}
+	v, err := strconv.ParseFloat(raw, 64)
+	if err != nil || v < min || v > max {
+		log.Printf("%s inválido (%s), usando %g", key, raw, fallback)
+		return fallback
+	}
+	return v
+}
+
+// DurationFromEnv lee una duración positiva ("90s", "5m")
+func DurationFromEnv(key string, fallback time.Duration) time.Duration {
+	return parseDurationEnv(key, fallback, false)
+}
+
+// OptionalDurationFromEnv admite además "0", que desactiva lo que controle la variable
+func OptionalDurationFromEnv(key string, fallback time.Duration) time.Duration {
+	return parseDurationEnv(key, fallback, true)
+}
+
+func parseDurationEnv(key string, fallback time.Duration, allowZero bool) time.Duration {
+	raw := strings.TrimSpace(os.Getenv(key))
+	if raw == "" {
+		return fallback
+	}
+	d, err := time.ParseDuration(raw)
+	if err != nil || d < 0 || (d == 0 && !allowZero) {
+		log.Printf("%s inválido (%s), usando %s", key, raw, fallback)
+		return fallback
+	}
+	return d
+}
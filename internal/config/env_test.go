@@ -0,0 +1,54 @@
+package config
+
+import (
+	"testing"
+	"time"
+)
+
+func TestEnvHelpers_FallBackOnEmptyOrInvalidValues(t *testing.T) {
+	cases := []struct {
+		raw  string
+		want int
+	}{
+		{"", 7},
+		{"12", 12},
+		{"0", 0},
+		{"-3", 7},
+		{"doce", 7},
+	}
+	for _, tc := range cases {
+		t.Setenv("TEST_INT", tc.raw)
+		if got := IntFromEnv("TEST_INT", 7); got != tc.want {
+			t.Errorf("IntFromEnv(%q) = %d, want %d", tc.raw, got, tc.want)
+		}
+	}
+	t.Setenv("TEST_INT", "0")
+	if got := PositiveIntFromEnv("TEST_INT", 7); got != 7 {
+		t.Errorf("PositiveIntFromEnv(0) = %d, want fallback", got)
+	}
+
+	t.Setenv("TEST_FLOAT", "0.4")
+	if got := FloatFromEnv("TEST_FLOAT", 0.5, 0, 1); got != 0.4 {
+		t.Errorf("FloatFromEnv(0.4) = %g", got)
+	}
+	t.Setenv("TEST_FLOAT", "1.5")
+	if got := FloatFromEnv("TEST_FLOAT", 0.5, 0, 1); got != 0.5 {
+		t.Errorf("FloatFromEnv out of range = %g, want fallback", got)
+	}
+
+	t.Setenv("TEST_DURATION", "90s")
+	if got := DurationFromEnv("TEST_DURATION", time.Minute); got != 90*time.Second {
+		t.Errorf("DurationFromEnv(90s) = %s", got)
+	}
+	t.Setenv("TEST_DURATION", "0")
+	if got := DurationFromEnv("TEST_DURATION", time.Minute); got != time.Minute {
+		t.Errorf("DurationFromEnv(0) = %s, want fallback", got)
+	}
+	if got := OptionalDurationFromEnv("TEST_DURATION", time.Minute); got != 0 {
+		t.Errorf("OptionalDurationFromEnv(0) = %s, want 0", got)
+	}
+	t.Setenv("TEST_DURATION", "-5s")
+	if got := OptionalDurationFromEnv("TEST_DURATION", time.Minute); got != time.Minute {
+		t.Errorf("OptionalDurationFromEnv(-5s) = %s, want fallback", got)
+	}
+}
@@ -0,0 +1,103 @@
+package config
+
+import (
+	"context"
+	"errors"
+	"log"
+	"sync/atomic"
+	"time"
+)
+
+// ErrDBUnavailable se devuelve cuando la base de datos no responde
+var ErrDBUnavailable = errors.New("base de datos no disponible")
+
+const (
+	defaultQueryTimeout    = 5 * time.Second
+	defaultHealthInterval  = 10 * time.Second
+	defaultConnectAttempts = 5
+	initialBackoff         = 500 * time.Millisecond
+	maxBackoff             = 30 * time.Second
+)
+
+var dbDown atomic.Bool
+
+// DBAvailable indica si la última verificación de salud de la base de datos fue exitosa
+func DBAvailable() bool {
+	return !dbDown.Load()
+}
+
+// SetDBAvailable fuerza el estado de salud de la base de datos
+func SetDBAvailable(available bool) {
+	dbDown.Store(!available)
+}
+
+// QueryTimeout devuelve el tiempo máximo por consulta (DB_QUERY_TIMEOUT)
+func QueryTimeout() time.Duration {
+	return DurationFromEnv("DB_QUERY_TIMEOUT", defaultQueryTimeout)
+}
+
+// PingDB verifica que la conexión actual responda
+func PingDB(ctx context.Context) error {
+	if DB == nil {
+		return ErrDBUnavailable
+	}
+	sqlDB, err := DB.DB()
+	if err != nil {
+		return err
+	}
+	return sqlDB.PingContext(ctx)
+}
+
+// StartHealthMonitor verifica periódicamente la base de datos y marca el modo degradado
+func StartHealthMonitor(ctx context.Context) {
+	interval := DurationFromEnv("DB_HEALTH_INTERVAL", defaultHealthInterval)
+	go monitorDB(ctx, interval, PingDB)
+}
+
+func monitorDB(ctx context.Context, interval time.Duration, ping func(context.Context) error) {
+	backoff := initialBackoff
+	for {
+		wait := interval
+		if err := checkOnce(ctx, ping); err != nil {
+			wait = backoff
+			backoff = nextBackoff(backoff)
+		} else {
+			backoff = initialBackoff
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(wait):
+		}
+	}
+}
+
+func checkOnce(ctx context.Context, ping func(context.Context) error) error {
+	pingCtx, cancel := context.WithTimeout(ctx, QueryTimeout())
+	defer cancel()
+
+	err := ping(pingCtx)
+	wasDown := dbDown.Load()
+	switch {
+	case err != nil && !wasDown:
+		log.Printf("Base de datos no disponible, entrando en modo degradado: %v", err)
+		dbDown.Store(true)
+	case err == nil && wasDown:
+		log.Println("Base de datos recuperada, saliendo de modo degradado")
+		dbDown.Store(false)
+	}
+	return err
+}
+
+func nextBackoff(current time.Duration) time.Duration {
+	next := current * 2
+	if next > maxBackoff {
+		return maxBackoff
+	}
+	return next
+}
+
+func connectAttempts() int {
+	return PositiveIntFromEnv("DB_CONNECT_ATTEMPTS", defaultConnectAttempts)
+}
@@ -0,0 +1,140 @@
+package config
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+func TestCheckOnce_TogglesDegradedMode(t *testing.T) {
+	defer SetDBAvailable(true)
+	SetDBAvailable(true)
+
+	failing := func(context.Context) error { return errors.New("conexión rechazada") }
+	if err := checkOnce(context.Background(), failing); err == nil {
+		t.Fatal("expected ping error")
+	}
+	if DBAvailable() {
+		t.Fatal("expected DB to be marked unavailable")
+	}
+
+	healthy := func(context.Context) error { return nil }
+	if err := checkOnce(context.Background(), healthy); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !DBAvailable() {
+		t.Fatal("expected DB to be marked available again")
+	}
+}
+
+func TestMonitorDB_RecoversWithoutReopening(t *testing.T) {
+	defer SetDBAvailable(true)
+	SetDBAvailable(true)
+
+	// database/sql vuelve a marcar las conexiones caídas; basta con que el ping responda
+	var mu sync.Mutex
+	failures := 0
+	ping := func(context.Context) error {
+		mu.Lock()
+		defer mu.Unlock()
+		failures++
+		if failures <= 2 {
+			return errors.New("conexión rota")
+		}
+		return nil
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go monitorDB(ctx, time.Hour, ping)
+
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		mu.Lock()
+		done := failures > 2
+		mu.Unlock()
+		if done && DBAvailable() {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatal("expected DB to be available once the ping succeeds")
+}
+
+func TestConnectWithRetry_EventuallySucceeds(t *testing.T) {
+	calls := 0
+	connect := func(string) (*gorm.DB, error) {
+		calls++
+		if calls < 2 {
+			return nil, errors.New("aún no")
+		}
+		return &gorm.DB{}, nil
+	}
+
+	db, err := connectWithRetry("dsn", 3, connect)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if db == nil || calls != 2 {
+		t.Fatalf("expected success on second attempt, calls=%d", calls)
+	}
+}
+
+func TestConnectWithRetry_ReturnsLastError(t *testing.T) {
+	want := errors.New("sin conexión")
+	_, err := connectWithRetry("dsn", 1, func(string) (*gorm.DB, error) { return nil, want })
+	if !errors.Is(err, want) {
+		t.Fatalf("expected %v, got %v", want, err)
+	}
+}
+
+func TestNextBackoff_Caps(t *testing.T) {
+	if got := nextBackoff(time.Second); got != 2*time.Second {
+		t.Fatalf("expected 2s, got %s", got)
+	}
+	if got := nextBackoff(maxBackoff); got != maxBackoff {
+		t.Fatalf("expected cap %s, got %s", maxBackoff, got)
+	}
+}
+
+func TestQueryTimeout_FromEnv(t *testing.T) {
+	t.Setenv("DB_QUERY_TIMEOUT", "750ms")
+	if got := QueryTimeout(); got != 750*time.Millisecond {
+		t.Fatalf("expected 750ms, got %s", got)
+	}
+	t.Setenv("DB_QUERY_TIMEOUT", "nope")
+	if got := QueryTimeout(); got != defaultQueryTimeout {
+		t.Fatalf("expected default, got %s", got)
+	}
+}
+
+func TestReadReplica_WithdrawnWhileDown(t *testing.T) {
+	originalReadDB := ReadDB
+	t.Cleanup(func() {
+		ReadDB = originalReadDB
+		replicaDown.Store(false)
+	})
+
+	ReadDB = nil
+	if ReadReplica() != nil {
+		t.Fatal("expected no replica when DATABASE_READ_URL is unset")
+	}
+
+	db := setupTestDB(t)
+	ReadDB = db
+	checkReplica(context.Background(), db)
+	if ReadReplica() != db {
+		t.Fatal("expected healthy replica to be used")
+	}
+
+	sqlDB, _ := db.DB()
+	_ = sqlDB.Close()
+	checkReplica(context.Background(), db)
+	if ReadReplica() != nil {
+		t.Fatal("expected replica to be withdrawn after failed ping")
+	}
+}
@@ -0,0 +1,269 @@
+package config
+
+import (
+	"fmt"
+	"time"
+
+	"walkie-backend/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// Migration es un cambio de esquema versionado; el ID se guarda en schema_migrations
+// y nunca se cambia una vez publicado. Los cambios nuevos se añaden al final de migrations.
+type Migration struct {
+	ID      string
+	Migrate func(tx *gorm.DB) error
+}
+
+// SchemaMigration registra una migración ya aplicada
+type SchemaMigration struct {
+	ID        string `gorm:"primaryKey;size:255"`
+	AppliedAt time.Time
+}
+
+// MigrationStatus indica si una migración está aplicada y cuándo
+type MigrationStatus struct {
+	ID        string
+	Applied   bool
+	AppliedAt time.Time
+}
+
+var migrations = []Migration{
+	{
+		ID: "0001_initial_schema",
+		Migrate: func(tx *gorm.DB) error {
+			if err := tx.AutoMigrate(
+				&models.User{},
+				&models.Channel{},
+				&models.ChannelMembership{},
+				&models.AuditEntry{},
+				&models.SigningKey{},
+				&models.ChannelEvent{},
+				&models.QueuedAudio{},
+				&models.ChannelTransmission{},
+				&models.TransmissionBlob{},
+				&models.RefreshToken{},
+				&models.Device{},
+				&models.ChannelMessage{},
+				&models.Transcript{},
+				&models.IntentPattern{},
+				&models.ChannelAlias{},
+			); err != nil {
+				return err
+			}
+			return EnsureTranscriptSearch(tx)
+		},
+	},
+	{
+		ID: "0002_channel_membership_roles",
+		Migrate: func(tx *gorm.DB) error {
+			return tx.AutoMigrate(&models.ChannelMembership{})
+		},
+	},
+	{
+		ID: "0003_channel_mutes",
+		Migrate: func(tx *gorm.DB) error {
+			return tx.AutoMigrate(&models.ChannelMute{})
+		},
+	},
+	{
+		ID: "0004_queued_audio_attempts",
+		Migrate: func(tx *gorm.DB) error {
+			return tx.AutoMigrate(&models.QueuedAudio{})
+		},
+	},
+	{
+		ID: "0005_user_public_keys",
+		Migrate: func(tx *gorm.DB) error {
+			return tx.AutoMigrate(&models.User{})
+		},
+	},
+	{
+		ID: "0006_channel_recordings",
+		Migrate: func(tx *gorm.DB) error {
+			return tx.AutoMigrate(&models.ChannelRecording{}, &models.RecordingClip{})
+		},
+	},
+	{
+		ID: "0007_audio_object_keys",
+		Migrate: func(tx *gorm.DB) error {
+			return tx.AutoMigrate(&models.ChannelTransmission{}, &models.QueuedAudio{})
+		},
+	},
+	{
+		ID: "0008_user_usage",
+		Migrate: func(tx *gorm.DB) error {
+			return tx.AutoMigrate(&models.UserUsage{})
+		},
+	},
+	{
+		ID: "0009_audit_commands",
+		Migrate: func(tx *gorm.DB) error {
+			return tx.AutoMigrate(&models.AuditEntry{})
+		},
+	},
+	{
+		ID: "0010_user_profile",
+		Migrate: func(tx *gorm.DB) error {
+			return tx.AutoMigrate(&models.User{})
+		},
+	},
+	{
+		ID: "0011_sessions",
+		Migrate: func(tx *gorm.DB) error {
+			return tx.AutoMigrate(&models.Session{}, &models.RefreshToken{})
+		},
+	},
+	{
+		ID: "0012_moderation_rules",
+		Migrate: func(tx *gorm.DB) error {
+			if err := tx.AutoMigrate(&models.ModerationRule{}); err != nil {
+				return err
+			}
+			for _, phrase := range models.DefaultModerationPhrases {
+				rule := models.ModerationRule{
+					Pattern:     models.NormalizeModerationText(phrase),
+					Action:      models.ModerationActionBlock,
+					Description: "inyección de prompt",
+				}
+				if err := tx.Where("pattern = ? AND is_regex = ?", rule.Pattern, false).FirstOrCreate(&rule).Error; err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	},
+	{
+		ID: "0013_abuse_mutes",
+		Migrate: func(tx *gorm.DB) error {
+			return tx.AutoMigrate(&models.ChannelMembership{})
+		},
+	},
+	{
+		ID: "0014_channel_assistant",
+		Migrate: func(tx *gorm.DB) error {
+			return tx.AutoMigrate(&models.Channel{})
+		},
+	},
+	{
+		ID: "0015_announcements",
+		Migrate: func(tx *gorm.DB) error {
+			return tx.AutoMigrate(&models.Announcement{})
+		},
+	},
+	{
+		ID: "0016_user_locations",
+		Migrate: func(tx *gorm.DB) error {
+			return tx.AutoMigrate(&models.UserLocation{})
+		},
+	},
+	{
+		ID: "0017_user_dnd",
+		Migrate: func(tx *gorm.DB) error {
+			return tx.AutoMigrate(&models.User{})
+		},
+	},
+	{
+		ID: "0018_favorite_channels",
+		Migrate: func(tx *gorm.DB) error {
+			return tx.AutoMigrate(&models.FavoriteChannel{})
+		},
+	},
+	{
+		ID: "0019_shared_audio_blobs",
+		Migrate: func(tx *gorm.DB) error {
+			return tx.AutoMigrate(&models.QueuedAudio{}, &models.QueuedAudioBlob{})
+		},
+	},
+	{
+		ID: "0020_incoming_webhooks",
+		Migrate: func(tx *gorm.DB) error {
+			return tx.AutoMigrate(&models.IncomingWebhook{})
+		},
+	},
+	{
+		ID: "0021_webhook_subscriptions",
+		Migrate: func(tx *gorm.DB) error {
+			return tx.AutoMigrate(&models.WebhookSubscription{}, &models.WebhookDeadLetter{})
+		},
+	},
+	{
+		ID: "0022_channel_dial_in",
+		Migrate: func(tx *gorm.DB) error {
+			return tx.AutoMigrate(&models.Channel{})
+		},
+	},
+	{
+		ID: "0023_audio_receipts",
+		Migrate: func(tx *gorm.DB) error {
+			return tx.AutoMigrate(&models.AudioReceipt{}, &models.QueuedAudio{})
+		},
+	},
+	{
+		ID: "0024_audio_delivery_leases",
+		Migrate: func(tx *gorm.DB) error {
+			return tx.AutoMigrate(&models.QueuedAudio{})
+		},
+	},
+}
+
+// Migrate aplica en orden las migraciones pendientes, cada una en su transacción,
+// y devuelve los IDs aplicados
+func Migrate(db *gorm.DB) ([]string, error) {
+	return runMigrations(db, migrations)
+}
+
+// Migrations devuelve el estado de cada migración conocida
+func Migrations(db *gorm.DB) ([]MigrationStatus, error) {
+	applied, err := appliedMigrations(db)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]MigrationStatus, 0, len(migrations))
+	for _, m := range migrations {
+		at, ok := applied[m.ID]
+		out = append(out, MigrationStatus{ID: m.ID, Applied: ok, AppliedAt: at})
+	}
+	return out, nil
+}
+
+func runMigrations(db *gorm.DB, list []Migration) ([]string, error) {
+	applied, err := appliedMigrations(db)
+	if err != nil {
+		return nil, err
+	}
+
+	var done []string
+	for _, m := range list {
+		if _, ok := applied[m.ID]; ok {
+			continue
+		}
+		err := db.Transaction(func(tx *gorm.DB) error {
+			if err := m.Migrate(tx); err != nil {
+				return err
+			}
+			return tx.Create(&SchemaMigration{ID: m.ID, AppliedAt: time.Now()}).Error
+		})
+		if err != nil {
+			return done, fmt.Errorf("migración %s: %w", m.ID, err)
+		}
+		done = append(done, m.ID)
+	}
+	return done, nil
+}
+
+func appliedMigrations(db *gorm.DB) (map[string]time.Time, error) {
+	if err := db.AutoMigrate(&SchemaMigration{}); err != nil {
+		return nil, fmt.Errorf("error creando schema_migrations: %w", err)
+	}
+	var rows []SchemaMigration
+	if err := db.Find(&rows).Error; err != nil {
+		return nil, fmt.Errorf("error leyendo schema_migrations: %w", err)
+	}
+	applied := make(map[string]time.Time, len(rows))
+	for _, row := range rows {
+		applied[row.ID] = row.AppliedAt
+	}
+	return applied, nil
+}
@@ -0,0 +1,76 @@
+package config
+
+import (
+	"errors"
+	"testing"
+
+	"walkie-backend/internal/models"
+
+	"gorm.io/gorm"
+)
+
+func TestMigrate_AppliesOnceAndRecords(t *testing.T) {
+	db, err := OpenDB("file:migrate_once?mode=memory&cache=shared")
+	if err != nil {
+		t.Fatalf("OpenDB: %v", err)
+	}
+
+	applied, err := Migrate(db)
+	if err != nil {
+		t.Fatalf("Migrate: %v", err)
+	}
+	if len(applied) != len(migrations) {
+		t.Fatalf("expected %d migrations applied, got %v", len(migrations), applied)
+	}
+	if !db.Migrator().HasTable(&models.ChannelAlias{}) {
+		t.Error("expected schema to be created")
+	}
+
+	applied, err = Migrate(db)
+	if err != nil || len(applied) != 0 {
+		t.Fatalf("expected no pending migrations, got %v (%v)", applied, err)
+	}
+
+	statuses, err := Migrations(db)
+	if err != nil {
+		t.Fatalf("Migrations: %v", err)
+	}
+	for _, s := range statuses {
+		if !s.Applied || s.AppliedAt.IsZero() {
+			t.Errorf("expected %s applied, got %+v", s.ID, s)
+		}
+	}
+}
+
+func TestRunMigrations_StopsAtFailureAndRollsBack(t *testing.T) {
+	db, err := OpenDB("file:migrate_fail?mode=memory&cache=shared")
+	if err != nil {
+		t.Fatalf("OpenDB: %v", err)
+	}
+
+	type probe struct{ ID uint }
+	list := []Migration{
+		{ID: "a", Migrate: func(tx *gorm.DB) error { return nil }},
+		{ID: "b", Migrate: func(tx *gorm.DB) error {
+			if err := tx.AutoMigrate(&probe{}); err != nil {
+				return err
+			}
+			return errors.New("boom")
+		}},
+		{ID: "c", Migrate: func(tx *gorm.DB) error { return nil }},
+	}
+
+	applied, err := runMigrations(db, list)
+	if err == nil {
+		t.Fatal("expected error from migration b")
+	}
+	if len(applied) != 1 || applied[0] != "a" {
+		t.Fatalf("expected only a applied, got %v", applied)
+	}
+
+	var count int64
+	db.Model(&SchemaMigration{}).Where("id IN ?", []string{"b", "c"}).Count(&count)
+	if count != 0 {
+		t.Fatalf("failed migration must not be recorded, got %d rows", count)
+	}
+}
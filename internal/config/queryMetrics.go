@@ -0,0 +1,93 @@
+package config
+
+import (
+	"log"
+	"time"
+
+	"walkie-backend/internal/metrics"
+	"walkie-backend/internal/reqctx"
+
+	"gorm.io/gorm"
+)
+
+const (
+	queryStartKey             = "walkie:query_start"
+	defaultSlowQueryThreshold = 200 * time.Millisecond
+)
+
+// QueryMetrics es un plugin de GORM que mide cada consulta por operación y tabla
+// y registra en el log las que superan DB_SLOW_QUERY_THRESHOLD
+type QueryMetrics struct {
+	SlowThreshold time.Duration
+}
+
+func NewQueryMetrics() *QueryMetrics {
+	return &QueryMetrics{SlowThreshold: DurationFromEnv("DB_SLOW_QUERY_THRESHOLD", defaultSlowQueryThreshold)}
+}
+
+func (p *QueryMetrics) Name() string {
+	return "walkie:query_metrics"
+}
+
+func (p *QueryMetrics) Initialize(db *gorm.DB) error {
+	cb := db.Callback()
+	registrations := []struct {
+		op     string
+		before func(string) error
+		after  func(string) error
+	}{
+		{"create", func(n string) error { return cb.Create().Before("gorm:create").Register(n, p.before) }, func(n string) error { return cb.Create().After("gorm:create").Register(n, p.after("create")) }},
+		{"query", func(n string) error { return cb.Query().Before("gorm:query").Register(n, p.before) }, func(n string) error { return cb.Query().After("gorm:query").Register(n, p.after("query")) }},
+		{"update", func(n string) error { return cb.Update().Before("gorm:update").Register(n, p.before) }, func(n string) error { return cb.Update().After("gorm:update").Register(n, p.after("update")) }},
+		{"delete", func(n string) error { return cb.Delete().Before("gorm:delete").Register(n, p.before) }, func(n string) error { return cb.Delete().After("gorm:delete").Register(n, p.after("delete")) }},
+		{"row", func(n string) error { return cb.Row().Before("gorm:row").Register(n, p.before) }, func(n string) error { return cb.Row().After("gorm:row").Register(n, p.after("row")) }},
+		{"raw", func(n string) error { return cb.Raw().Before("gorm:raw").Register(n, p.before) }, func(n string) error { return cb.Raw().After("gorm:raw").Register(n, p.after("raw")) }},
+	}
+
+	for _, r := range registrations {
+		if err := r.before("walkie:before_" + r.op); err != nil {
+			return err
+		}
+		if err := r.after("walkie:after_" + r.op); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (p *QueryMetrics) before(db *gorm.DB) {
+	db.InstanceSet(queryStartKey, time.Now())
+}
+
+func (p *QueryMetrics) after(op string) func(*gorm.DB) {
+	return func(db *gorm.DB) {
+		raw, ok := db.InstanceGet(queryStartKey)
+		if !ok {
+			return
+		}
+		start, ok := raw.(time.Time)
+		if !ok {
+			return
+		}
+		elapsed := time.Since(start)
+
+		table := db.Statement.Table
+		if table == "" {
+			table = "unknown"
+		}
+		labels := map[string]string{"op": op, "table": table}
+		metrics.Observe("walkie_db_query", labels, elapsed)
+		if db.Error != nil && db.Error != gorm.ErrRecordNotFound {
+			metrics.Inc("walkie_db_query_errors_total", labels)
+		}
+
+		if p.SlowThreshold > 0 && elapsed >= p.SlowThreshold {
+			metrics.Inc("walkie_db_slow_queries_total", labels)
+			// Sólo la SQL con marcadores: los valores pueden ser tokens o hashes de PIN
+			log.Printf("[DB_LENTA] op=%s tabla=%s dur_ms=%.2f request_id=%s filas=%d params=%d sql=%q",
+				op, table, float64(elapsed)/float64(time.Millisecond),
+				reqctx.RequestID(db.Statement.Context), db.RowsAffected,
+				len(db.Statement.Vars), db.Statement.SQL.String())
+		}
+	}
+}
@@ -0,0 +1,64 @@
+package config
+
+import (
+	"bytes"
+	"context"
+	"log"
+	"strings"
+	"testing"
+
+	"walkie-backend/internal/metrics"
+	"walkie-backend/internal/models"
+	"walkie-backend/internal/reqctx"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func TestQueryMetrics_RecordsTimingAndSlowQueries(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open("file:query_metrics?mode=memory&cache=shared"), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	if err != nil {
+		t.Fatalf("failed to open sqlite: %v", err)
+	}
+	// Umbral mínimo para forzar el log de consulta lenta
+	if err := db.Use(&QueryMetrics{SlowThreshold: 1}); err != nil {
+		t.Fatalf("failed to register plugin: %v", err)
+	}
+	if err := db.AutoMigrate(&models.Channel{}); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+
+	var buf bytes.Buffer
+	orig := log.Writer()
+	log.SetOutput(&buf)
+	defer log.SetOutput(orig)
+
+	labels := map[string]string{"op": "query", "table": "channels"}
+	before, _ := metrics.Default().Timing("walkie_db_query", labels)
+
+	ctx := reqctx.WithRequestID(context.Background(), "req-123")
+	var channels []models.Channel
+	if err := db.WithContext(ctx).Find(&channels).Error; err != nil {
+		t.Fatalf("query: %v", err)
+	}
+
+	after, _ := metrics.Default().Timing("walkie_db_query", labels)
+	if after != before+1 {
+		t.Fatalf("expected one recorded query, got %d -> %d", before, after)
+	}
+	if out := buf.String(); !strings.Contains(out, "[DB_LENTA]") || !strings.Contains(out, "request_id=req-123") {
+		t.Fatalf("expected slow query log with request id, got %q", out)
+	}
+
+	buf.Reset()
+	var found []models.Channel
+	if err := db.WithContext(ctx).Where("code = ?", "secreto-en-claro").Find(&found).Error; err != nil {
+		t.Fatalf("query: %v", err)
+	}
+	if out := buf.String(); strings.Contains(out, "secreto-en-claro") || !strings.Contains(out, "params=1") {
+		t.Fatalf("expected slow query log without bound values, got %q", out)
+	}
+}
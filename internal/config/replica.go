@@ -0,0 +1,76 @@
+package config
+
+import (
+	"context"
+	"log"
+	"os"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+var (
+	// ReadDB es la réplica de lectura de DATABASE_READ_URL; nil si no hay
+	ReadDB      *gorm.DB
+	replicaDown atomic.Bool
+)
+
+// connectReadReplica abre DATABASE_READ_URL si está configurada. Un fallo no impide
+// arrancar: las lecturas siguen yendo a la base principal.
+func connectReadReplica() {
+	dsn := strings.TrimSpace(os.Getenv("DATABASE_READ_URL"))
+	if dsn == "" {
+		return
+	}
+	db, err := OpenDB(dsn)
+	if err != nil {
+		log.Printf("Réplica de lectura no disponible, se lee de la base principal: %v", err)
+		return
+	}
+	ReadDB = db
+	replicaDown.Store(false)
+	go monitorReplica(context.Background(), DurationFromEnv("DB_HEALTH_INTERVAL", defaultHealthInterval))
+	log.Println("Réplica de lectura conectada")
+}
+
+// ReadReplica devuelve la réplica de lectura si está configurada y responde; nil indica
+// que hay que leer de la base principal
+func ReadReplica() *gorm.DB {
+	if ReadDB == nil || replicaDown.Load() {
+		return nil
+	}
+	return ReadDB
+}
+
+// monitorReplica verifica la réplica periódicamente y la retira mientras no responda
+func monitorReplica(ctx context.Context, interval time.Duration) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(interval):
+		}
+		checkReplica(ctx, ReadDB)
+	}
+}
+
+func checkReplica(ctx context.Context, db *gorm.DB) {
+	pingCtx, cancel := context.WithTimeout(ctx, QueryTimeout())
+	defer cancel()
+
+	sqlDB, err := db.DB()
+	if err == nil {
+		err = sqlDB.PingContext(pingCtx)
+	}
+	wasDown := replicaDown.Load()
+	switch {
+	case err != nil && !wasDown:
+		log.Printf("Réplica de lectura no disponible, se lee de la base principal: %v", err)
+		replicaDown.Store(true)
+	case err == nil && wasDown:
+		log.Println("Réplica de lectura recuperada")
+		replicaDown.Store(false)
+	}
+}
@@ -0,0 +1,57 @@
+package config
+
+import (
+	"fmt"
+
+	"gorm.io/gorm"
+)
+
+// TranscriptSearchTable es la tabla FTS4 que indexa las transcripciones en SQLite
+const TranscriptSearchTable = "transcripts_fts"
+
+// EnsureTranscriptSearch crea el índice de texto completo de las transcripciones:
+// un índice GIN sobre to_tsvector en Postgres o una tabla FTS4 con triggers en SQLite
+func EnsureTranscriptSearch(db *gorm.DB) error {
+	switch db.Dialector.Name() {
+	case "postgres":
+		return db.Exec(`CREATE INDEX IF NOT EXISTS idx_transcripts_text_fts ON transcripts USING GIN (to_tsvector('spanish', text))`).Error
+	case "sqlite":
+		return ensureSQLiteTranscriptSearch(db)
+	default:
+		return nil
+	}
+}
+
+func ensureSQLiteTranscriptSearch(db *gorm.DB) error {
+	var existing int64
+	if err := db.Raw(`SELECT count(*) FROM sqlite_master WHERE type = 'table' AND name = ?`, TranscriptSearchTable).Scan(&existing).Error; err != nil {
+		return err
+	}
+
+	statements := []string{
+		`CREATE VIRTUAL TABLE IF NOT EXISTS transcripts_fts USING fts4(content="transcripts", text, tokenize=unicode61 "remove_diacritics=1")`,
+		`CREATE TRIGGER IF NOT EXISTS transcripts_fts_ai AFTER INSERT ON transcripts BEGIN
+			INSERT INTO transcripts_fts(docid, text) VALUES (new.id, new.text);
+		END`,
+		`CREATE TRIGGER IF NOT EXISTS transcripts_fts_bd BEFORE DELETE ON transcripts BEGIN
+			DELETE FROM transcripts_fts WHERE docid = old.id;
+		END`,
+		`CREATE TRIGGER IF NOT EXISTS transcripts_fts_bu BEFORE UPDATE ON transcripts BEGIN
+			DELETE FROM transcripts_fts WHERE docid = old.id;
+		END`,
+		`CREATE TRIGGER IF NOT EXISTS transcripts_fts_au AFTER UPDATE ON transcripts BEGIN
+			INSERT INTO transcripts_fts(docid, text) VALUES (new.id, new.text);
+		END`,
+	}
+	for _, stmt := range statements {
+		if err := db.Exec(stmt).Error; err != nil {
+			return fmt.Errorf("error creando índice de búsqueda: %w", err)
+		}
+	}
+
+	// Las transcripciones guardadas antes de crear el índice se indexan una vez
+	if existing == 0 {
+		return db.Exec(`INSERT INTO transcripts_fts(transcripts_fts) VALUES ('rebuild')`).Error
+	}
+	return nil
+}
@@ -0,0 +1,274 @@
+package handlers
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"walkie-backend/internal/ai"
+	"walkie-backend/internal/config"
+	"walkie-backend/internal/metrics"
+	"walkie-backend/internal/models"
+	"walkie-backend/internal/services"
+	"walkie-backend/pkg/qwen"
+)
+
+const (
+	abuseActionWarn = "warn"
+	abuseActionDrop = "drop"
+
+	defaultAbuseMuteAfter = 3
+	defaultAbuseWindow    = time.Hour
+	defaultAbuseMuteFor   = 15 * time.Minute
+
+	// ModerationWarningHeader avisa al hablante de que su mensaje se envió pero quedó anotado
+	ModerationWarningHeader = "X-Moderation-Warning"
+)
+
+type abuseConfig struct {
+	action    string
+	muteAfter int
+	window    time.Duration
+	muteFor   time.Duration
+}
+
+// loadAbuseConfig lee ABUSE_ACTION (warn o drop), ABUSE_MUTE_AFTER (0 desactiva el
+// silencio automático), ABUSE_WINDOW y ABUSE_MUTE_FOR
+func loadAbuseConfig() abuseConfig {
+	cfg := abuseConfig{
+		action:    abuseActionWarn,
+		muteAfter: config.IntFromEnv("ABUSE_MUTE_AFTER", defaultAbuseMuteAfter),
+		window:    config.DurationFromEnv("ABUSE_WINDOW", defaultAbuseWindow),
+		muteFor:   config.DurationFromEnv("ABUSE_MUTE_FOR", defaultAbuseMuteFor),
+	}
+	if strings.ToLower(strings.TrimSpace(os.Getenv("ABUSE_ACTION"))) == abuseActionDrop {
+		cfg.action = abuseActionDrop
+	}
+	return cfg
+}
+
+var abuseWordlist = struct {
+	sync.RWMutex
+	once  sync.Once
+	words []string
+}{}
+
+// loadAbuseWords junta las palabras de ABUSE_WORDS (separadas por comas) y las de
+// ABUSE_WORDS_FILE (una por línea, # para comentarios)
+func loadAbuseWords() []string {
+	var raw []string
+	raw = append(raw, strings.Split(os.Getenv("ABUSE_WORDS"), ",")...)
+	if path := strings.TrimSpace(os.Getenv("ABUSE_WORDS_FILE")); path != "" {
+		f, err := os.Open(path)
+		if err != nil {
+			log.Printf("[ABUSO] no se pudo leer %s: %v", path, err)
+		} else {
+			scanner := bufio.NewScanner(f)
+			for scanner.Scan() {
+				line := strings.TrimSpace(scanner.Text())
+				if line != "" && !strings.HasPrefix(line, "#") {
+					raw = append(raw, line)
+				}
+			}
+			_ = f.Close()
+		}
+	}
+
+	seen := make(map[string]bool)
+	words := make([]string, 0, len(raw))
+	for _, w := range raw {
+		w = qwen.NormalizePhrase(w)
+		if w == "" || seen[w] {
+			continue
+		}
+		seen[w] = true
+		words = append(words, w)
+	}
+	return words
+}
+
+func currentAbuseWords() []string {
+	abuseWordlist.once.Do(func() {
+		words := loadAbuseWords()
+		abuseWordlist.Lock()
+		abuseWordlist.words = words
+		abuseWordlist.Unlock()
+		if len(words) > 0 {
+			log.Printf("[ABUSO] %d palabras ofensivas configuradas", len(words))
+		}
+	})
+	abuseWordlist.RLock()
+	defer abuseWordlist.RUnlock()
+	return abuseWordlist.words
+}
+
+// setAbuseWords sustituye la lista de palabras (para tests)
+func setAbuseWords(words []string) {
+	abuseWordlist.once.Do(func() {})
+	normalized := make([]string, 0, len(words))
+	for _, w := range words {
+		if w = qwen.NormalizePhrase(w); w != "" {
+			normalized = append(normalized, w)
+		}
+	}
+	abuseWordlist.Lock()
+	abuseWordlist.words = normalized
+	abuseWordlist.Unlock()
+}
+
+// abusiveWords devuelve las palabras de la lista que aparecen enteras en la transcripción
+func abusiveWords(text string) []string {
+	words := currentAbuseWords()
+	if len(words) == 0 {
+		return nil
+	}
+	padded := " " + qwen.NormalizePhrase(text) + " "
+	var matched []string
+	for _, w := range words {
+		if strings.Contains(padded, " "+w+" ") {
+			matched = append(matched, w)
+		}
+	}
+	return matched
+}
+
+// abuseAIEnabled indica si además de la lista se consulta a la IA (ABUSE_AI=true)
+func abuseAIEnabled() bool {
+	return strings.ToLower(strings.TrimSpace(os.Getenv("ABUSE_AI"))) == "true"
+}
+
+// classifyAbuseWithAI pregunta al proveedor de IA si el mensaje es ofensivo; sin
+// ABUSE_AI o si el proveedor no sabe moderar, nada es ofensivo
+func classifyAbuseWithAI(ctx context.Context, text string) (bool, error) {
+	if !abuseAIEnabled() {
+		return false, nil
+	}
+	analyzer, err := EnsureAIClient()
+	if err != nil {
+		return false, err
+	}
+	classifier, ok := analyzer.(ai.AbuseClassifier)
+	if !ok {
+		return false, nil
+	}
+	return classifier.IsAbusive(ctx, text)
+}
+
+// abuseStage filtra los mensajes de conversación antes de difundirlos: quien está
+// silenciado no habla (salvo emergencias) y el lenguaje ofensivo se avisa o se descarta
+// según ABUSE_ACTION. Tras ABUSE_MUTE_AFTER avisos en ABUSE_WINDOW, el hablante queda
+// silenciado en el canal durante ABUSE_MUTE_FOR
+func abuseStage(ctx context.Context, w http.ResponseWriter, deps audioIngestDeps, user *models.User, text string, tracker *stageTimer) bool {
+	stageStart := time.Now()
+	channel := user.GetCurrentChannelCode()
+	now := time.Now()
+	dbReady := config.DB != nil && config.DBAvailable()
+
+	if dbReady && !hasEmergencyPrefix(text) {
+		until, err := services.SpeakerMutedUntil(config.DB, channel, user.ID, now)
+		if err != nil {
+			log.Printf("[ABUSO] error consultando silencio usuario=%d canal=%s: %v", user.ID, channel, err)
+		} else if until != nil {
+			tracker.LogStage("abuse", stageStart, map[string]any{"muted": true})
+			writeSpeakerMuted(w, channel, *until)
+			tracker.LogFinal("speaker_muted")
+			return false
+		}
+	}
+
+	matched := abusiveWords(text)
+	source := "wordlist"
+	if len(matched) == 0 {
+		abusive, err := deps.classifyAbuse(ctx, text)
+		if err != nil {
+			log.Printf("[ABUSO] error consultando a la IA usuario=%d: %v", user.ID, err)
+		}
+		if !abusive {
+			tracker.LogStage("abuse", stageStart, map[string]any{"abusive": false})
+			return true
+		}
+		source = "ai"
+	}
+	tracker.LogStage("abuse", stageStart, map[string]any{"abusive": true, "source": source})
+
+	cfg := loadAbuseConfig()
+	action := services.AbuseActionWarn
+	if cfg.action == abuseActionDrop {
+		action = services.AbuseActionDrop
+	}
+	metrics.Inc("walkie_abuse_detections_total", map[string]string{"action": cfg.action})
+	log.Printf("[ABUSO] usuario=%d canal=%s accion=%s fuente=%s texto=%q", user.ID, channel, cfg.action, source, text)
+	recordAbuseAudit(user.ID, channel, action, fmt.Sprintf("fuente=%s palabras=%s texto=%q", source, strings.Join(matched, ","), text))
+
+	if dbReady && cfg.muteAfter > 0 {
+		offences, err := services.CountAbuseOffences(config.DB, user.ID, channel, now.Add(-cfg.window))
+		if err != nil {
+			log.Printf("[ABUSO] error contando avisos usuario=%d: %v", user.ID, err)
+		} else if offences >= int64(cfg.muteAfter) {
+			until := now.Add(cfg.muteFor)
+			if err := services.MuteSpeaker(config.DB, channel, user.ID, until); err != nil {
+				log.Printf("[ABUSO] error silenciando usuario=%d canal=%s: %v", user.ID, channel, err)
+			} else {
+				metrics.Inc("walkie_abuse_mutes_total", nil)
+				recordAbuseAudit(user.ID, channel, services.AbuseActionMute,
+					fmt.Sprintf("avisos=%d hasta=%s", offences, until.UTC().Format(time.RFC3339)))
+				writeSpeakerMuted(w, channel, until)
+				tracker.LogFinal("speaker_muted")
+				return false
+			}
+		}
+	}
+
+	if cfg.action == abuseActionDrop {
+		writeAbuseDropped(w, channel)
+		tracker.LogFinal("abuse_dropped")
+		return false
+	}
+	w.Header().Set(ModerationWarningHeader, "abuse")
+	return true
+}
+
+func recordAbuseAudit(userID uint, channel, action, details string) {
+	services.RecordAudit(nil, models.AuditEntry{
+		Actor:   "system:moderation",
+		Action:  action,
+		UserID:  &userID,
+		Channel: channel,
+		Details: details,
+		Source:  models.EventSourceVoice,
+	})
+}
+
+// writeSpeakerMuted responde 403 cuando el hablante está silenciado en el canal
+func writeSpeakerMuted(w http.ResponseWriter, channelCode string, until time.Time) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(http.StatusForbidden)
+	_ = json.NewEncoder(w).Encode(CommandResponse{
+		Status:  "muted",
+		Intent:  "conversation",
+		Message: "Estás silenciado en este canal por lenguaje ofensivo",
+		Data: map[string]any{
+			"channel": channelCode,
+			"until":   until.UTC().Format(time.RFC3339),
+		},
+	})
+}
+
+// writeAbuseDropped responde 422 cuando el mensaje no se difunde por lenguaje ofensivo
+func writeAbuseDropped(w http.ResponseWriter, channelCode string) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(http.StatusUnprocessableEntity)
+	_ = json.NewEncoder(w).Encode(CommandResponse{
+		Status:  "dropped",
+		Intent:  "conversation",
+		Message: "Tu mensaje no se ha enviado por lenguaje ofensivo",
+		Data:    map[string]any{"channel": channelCode},
+	})
+}
@@ -0,0 +1,94 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"walkie-backend/internal/models"
+	"walkie-backend/internal/services"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadAbuseWords_EnvAndFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "palabras.txt")
+	require.NoError(t, os.WriteFile(path, []byte("# insultos\nImbécil\n\npedazo de idiota\n"), 0o600))
+	t.Setenv("ABUSE_WORDS", "Tonto, imbecil ,")
+	t.Setenv("ABUSE_WORDS_FILE", path)
+
+	assert.Equal(t, []string{"tonto", "imbecil", "pedazo de idiota"}, loadAbuseWords())
+}
+
+func TestAbusiveWords_MatchesWholeWords(t *testing.T) {
+	setAbuseWords([]string{"tonto", "pedazo de idiota"})
+	t.Cleanup(func() { setAbuseWords(nil) })
+
+	assert.Equal(t, []string{"tonto"}, abusiveWords("¡Eres un TONTO!"))
+	assert.Equal(t, []string{"pedazo de idiota"}, abusiveWords("qué pedazo de idiota, de verdad"))
+	assert.Empty(t, abusiveWords("el tontodromo está cerrado"))
+}
+
+func TestAbuseStage_WarnDropAndAutoMute(t *testing.T) {
+	db := setupTestDB(t)
+	require.NoError(t, db.AutoMigrate(&models.AuditEntry{}))
+	user := createTestUser(t, db, 668, "tok-668", "abuso-1")
+	require.NoError(t, db.Create(&models.ChannelMembership{UserID: user.ID, ChannelID: *user.CurrentChannelID, Active: true}).Error)
+	setAbuseWords([]string{"tonto"})
+	t.Cleanup(func() { setAbuseWords(nil) })
+	t.Setenv("ABUSE_MUTE_AFTER", "3")
+
+	aiCalls := 0
+	deps := newAudioIngestDeps()
+	deps.classifyAbuse = func(context.Context, string) (bool, error) {
+		aiCalls++
+		return false, nil
+	}
+	run := func(text string) (*httptest.ResponseRecorder, bool) {
+		rec := httptest.NewRecorder()
+		ok := abuseStage(context.Background(), rec, deps, user, text, newStageTimer(user.ID))
+		return rec, ok
+	}
+
+	rec, ok := run("nos vemos en la puerta")
+	assert.True(t, ok)
+	assert.Empty(t, rec.Header().Get(ModerationWarningHeader))
+	assert.Equal(t, 1, aiCalls)
+
+	rec, ok = run("eres un tonto")
+	assert.True(t, ok, "por defecto sólo se avisa")
+	assert.Equal(t, "abuse", rec.Header().Get(ModerationWarningHeader))
+	assert.Equal(t, 1, aiCalls, "si la lista ya lo detecta no se pregunta a la IA")
+
+	t.Setenv("ABUSE_ACTION", "drop")
+	rec, ok = run("tonto")
+	assert.False(t, ok)
+	assert.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+
+	// Tercer aviso en la ventana: queda silenciado
+	deps.classifyAbuse = func(context.Context, string) (bool, error) { return true, nil }
+	rec, ok = run("te voy a buscar")
+	assert.False(t, ok)
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+	assert.Contains(t, rec.Body.String(), `"status":"muted"`)
+
+	deps.classifyAbuse = func(context.Context, string) (bool, error) { return false, nil }
+	rec, ok = run("perdón, ya paro")
+	assert.False(t, ok, "silenciado no puede hablar")
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+	_, ok = run("emergencia, hay un herido")
+	assert.True(t, ok, "las emergencias pasan aunque esté silenciado")
+
+	var actions []string
+	db.Model(&models.AuditEntry{}).Where("user_id = ?", user.ID).Order("id").Pluck("action", &actions)
+	assert.Equal(t, []string{services.AbuseActionWarn, services.AbuseActionDrop, services.AbuseActionDrop, services.AbuseActionMute}, actions)
+
+	until, err := services.SpeakerMutedUntil(db, "abuso-1", user.ID, time.Now().Add(time.Hour))
+	require.NoError(t, err)
+	assert.Nil(t, until, "el silencio caduca tras ABUSE_MUTE_FOR")
+}
@@ -0,0 +1,154 @@
+package handlers
+
+import (
+	"crypto/subtle"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"io"
+	"mime"
+	"net/http"
+	"os"
+	"strings"
+
+	"walkie-backend/internal/config"
+	"walkie-backend/internal/models"
+	"walkie-backend/internal/response"
+	"walkie-backend/internal/services"
+)
+
+const maxBulkRows = 5000
+
+// requireAdmin valida el header X-Admin-Token contra ADMIN_TOKEN
+func requireAdmin(w http.ResponseWriter, r *http.Request) bool {
+	expected := strings.TrimSpace(os.Getenv("ADMIN_TOKEN"))
+	if expected == "" {
+		response.WriteErr(w, http.StatusForbidden, "API de administración deshabilitada")
+		return false
+	}
+	provided := strings.TrimSpace(r.Header.Get("X-Admin-Token"))
+	if subtle.ConstantTimeCompare([]byte(provided), []byte(expected)) != 1 {
+		response.WriteErr(w, http.StatusUnauthorized, "Token de administrador inválido")
+		return false
+	}
+	return true
+}
+
+// adminActor identifica al operador para la auditoría
+func adminActor(r *http.Request) string {
+	if actor := strings.TrimSpace(r.Header.Get("X-Admin-Actor")); actor != "" {
+		return "admin:" + actor
+	}
+	return "admin"
+}
+
+// POST /admin/memberships/bulk
+// Acepta JSON {"rows":[{"user":"Juan","channel":"canal-1","action":"add"}]}
+// o text/csv con columnas user,channel[,action].
+func BulkMemberships(w http.ResponseWriter, r *http.Request) {
+	if !requireAdmin(w, r) || !requireDB(w) {
+		return
+	}
+
+	rows, err := readBulkRows(r)
+	if err != nil {
+		response.WriteErr(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if len(rows) == 0 {
+		response.WriteErr(w, http.StatusBadRequest, "No se recibieron filas")
+		return
+	}
+	if len(rows) > maxBulkRows {
+		response.WriteErr(w, http.StatusRequestEntityTooLarge, "Demasiadas filas")
+		return
+	}
+
+	meta := services.EventMeta{
+		Actor:     adminActor(r),
+		Source:    models.EventSourceHTTP,
+		RequestID: requestID(w, r),
+	}
+	results := services.NewMembershipService(config.DB).ApplyBulk(rows, meta)
+
+	applied, failed := 0, 0
+	for _, res := range results {
+		if res.Status != "ok" {
+			failed++
+			continue
+		}
+		applied++
+		notifyMembershipChange(res)
+	}
+
+	status := http.StatusOK
+	if applied == 0 {
+		status = http.StatusUnprocessableEntity
+	}
+	response.WriteJSON(w, status, map[string]any{
+		"applied": applied,
+		"failed":  failed,
+		"results": results,
+	})
+}
+
+func readBulkRows(r *http.Request) ([]services.MembershipAssignment, error) {
+	defer r.Body.Close()
+	body := io.LimitReader(r.Body, 5<<20)
+
+	mt, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if mt == "text/csv" {
+		return parseBulkCSV(body)
+	}
+
+	var payload struct {
+		Rows []services.MembershipAssignment `json:"rows"`
+	}
+	if err := json.NewDecoder(body).Decode(&payload); err != nil {
+		return nil, errors.New("JSON inválido")
+	}
+	return payload.Rows, nil
+}
+
+func parseBulkCSV(body io.Reader) ([]services.MembershipAssignment, error) {
+	reader := csv.NewReader(body)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, errors.New("CSV inválido")
+	}
+
+	rows := make([]services.MembershipAssignment, 0, len(records))
+	for i, rec := range records {
+		if i == 0 && len(rec) > 0 && strings.EqualFold(strings.TrimSpace(rec[0]), "user") {
+			continue
+		}
+		row := services.MembershipAssignment{}
+		if len(rec) > 0 {
+			row.User = rec[0]
+		}
+		if len(rec) > 1 {
+			row.Channel = rec[1]
+		}
+		if len(rec) > 2 {
+			row.Action = rec[2]
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
+
+// notifyMembershipChange avisa por WebSocket a los usuarios conectados afectados
+func notifyMembershipChange(res services.MembershipResult) {
+	switch res.Action {
+	case services.MembershipActionAdd:
+		moveClientToChannel(res.UserID, res.Channel)
+	case services.MembershipActionRemove:
+		if currentWSChannel(res.UserID) == res.Channel {
+			moveClientToChannel(res.UserID, "")
+			ClearPendingAudio(res.UserID)
+		}
+	}
+}
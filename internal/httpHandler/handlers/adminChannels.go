@@ -0,0 +1,153 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+
+	"walkie-backend/internal/config"
+	"walkie-backend/internal/models"
+	"walkie-backend/internal/response"
+	"walkie-backend/internal/services"
+)
+
+type adminChannelView struct {
+	Code      string `json:"code"`
+	Name      string `json:"name"`
+	MaxUsers  int    `json:"maxUsers"`
+	IsPrivate bool   `json:"isPrivate"`
+	Kind      string `json:"kind"`
+	Assistant bool   `json:"assistant"`
+	// DialInCode sólo aparece en los canales que admiten llamadas
+	DialInCode string `json:"dialInCode,omitempty"`
+}
+
+func toAdminChannelView(ch *models.Channel) adminChannelView {
+	return adminChannelView{
+		Code:       ch.Code,
+		Name:       ch.Name,
+		MaxUsers:   ch.MaxUsers,
+		IsPrivate:  ch.IsPrivate,
+		Kind:       ch.Kind,
+		Assistant:  ch.Assistant,
+		DialInCode: ch.DialInCode,
+	}
+}
+
+// POST /admin/channels crea un canal
+func AdminChannels(w http.ResponseWriter, r *http.Request) {
+	if !requireAdmin(w, r) || !requireDB(w) {
+		return
+	}
+
+	in, ok := readChannelInput(w, r)
+	if !ok {
+		return
+	}
+
+	channel, err := services.NewChannelService(config.DB).Create(in)
+	if err != nil {
+		writeChannelServiceError(w, err)
+		return
+	}
+
+	services.RecordAudit(nil, models.AuditEntry{
+		Actor:   adminActor(r),
+		Action:  "channel_create",
+		Channel: channel.Code,
+		Details: fmt.Sprintf("name=%s maxUsers=%d private=%t kind=%s assistant=%t dialIn=%t", channel.Name, channel.MaxUsers, channel.IsPrivate, channel.Kind, channel.Assistant, channel.DialInCode != ""),
+		Source:  models.EventSourceHTTP,
+	})
+	response.WriteJSON(w, http.StatusCreated, toAdminChannelView(channel))
+}
+
+// PUT /admin/channels/{code} actualiza nombre, capacidad o visibilidad;
+// DELETE /admin/channels/{code} borra el canal expulsando a sus usuarios
+func AdminChannel(w http.ResponseWriter, r *http.Request) {
+	if !requireAdmin(w, r) || !requireDB(w) {
+		return
+	}
+
+	code := r.PathValue("code")
+	if code == "" {
+		response.WriteErr(w, http.StatusBadRequest, "Código de canal requerido")
+		return
+	}
+
+	svc := services.NewChannelService(config.DB)
+	if r.Method == http.MethodPut {
+		in, ok := readChannelInput(w, r)
+		if !ok {
+			return
+		}
+		channel, err := svc.Update(code, in)
+		if err != nil {
+			writeChannelServiceError(w, err)
+			return
+		}
+		services.RecordAudit(nil, models.AuditEntry{
+			Actor:   adminActor(r),
+			Action:  "channel_update",
+			Channel: channel.Code,
+			Details: fmt.Sprintf("name=%s maxUsers=%d private=%t kind=%s assistant=%t dialIn=%t", channel.Name, channel.MaxUsers, channel.IsPrivate, channel.Kind, channel.Assistant, channel.DialInCode != ""),
+			Source:  models.EventSourceHTTP,
+		})
+		response.WriteJSON(w, http.StatusOK, toAdminChannelView(channel))
+		return
+	}
+
+	meta := services.EventMeta{
+		Actor:     adminActor(r),
+		Source:    models.EventSourceHTTP,
+		RequestID: requestID(w, r),
+	}
+	affected, err := svc.Delete(code, meta)
+	if err != nil {
+		writeChannelServiceError(w, err)
+		return
+	}
+
+	for _, userID := range affected {
+		moveClientToChannel(userID, "")
+		ClearPendingAudio(userID)
+	}
+	services.RecordAudit(nil, models.AuditEntry{
+		Actor:   meta.Actor,
+		Action:  "channel_delete",
+		Channel: code,
+		Details: fmt.Sprintf("usuarios_desconectados=%d", len(affected)),
+		Source:  meta.Source,
+	})
+	response.WriteJSON(w, http.StatusOK, map[string]any{
+		"status":       "deleted",
+		"code":         code,
+		"disconnected": affected,
+	})
+}
+
+func readChannelInput(w http.ResponseWriter, r *http.Request) (services.ChannelInput, bool) {
+	var in services.ChannelInput
+	body, err := io.ReadAll(io.LimitReader(r.Body, 64<<10))
+	if err != nil || json.Unmarshal(body, &in) != nil {
+		response.WriteErr(w, http.StatusBadRequest, "JSON inválido")
+		return in, false
+	}
+	return in, true
+}
+
+func writeChannelServiceError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, services.ErrChannelNotFound):
+		response.WriteErr(w, http.StatusNotFound, "Canal no encontrado")
+	case errors.Is(err, services.ErrChannelExists):
+		response.WriteErr(w, http.StatusConflict, err.Error())
+	case errors.Is(err, services.ErrCapacityBelowMembers):
+		response.WriteErr(w, http.StatusConflict, err.Error())
+	case errors.Is(err, services.ErrInvalidChannel):
+		response.WriteErr(w, http.StatusBadRequest, err.Error())
+	default:
+		response.WriteErr(w, http.StatusInternalServerError, "No se pudo modificar el canal")
+	}
+}
@@ -0,0 +1,106 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"walkie-backend/internal/config"
+	"walkie-backend/internal/models"
+)
+
+// adminChannelRequest prepara la petición a /admin/channels/{code} como la deja el router
+func adminChannelRequest(method, code, body string) *http.Request {
+	req := adminRequest(method, "/admin/channels/"+code, body)
+	req.SetPathValue("code", code)
+	return req
+}
+
+func adminRequest(method, path, body string) *http.Request {
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	req.Header.Set("X-Admin-Token", "secreto")
+	req.Header.Set("Content-Type", "application/json")
+	return req
+}
+
+func TestAdminChannels_CreateUpdateDelete(t *testing.T) {
+	cleanup := setupChannelsTestDB(t)
+	defer cleanup()
+	t.Setenv("ADMIN_TOKEN", "secreto")
+	if err := config.DB.AutoMigrate(&models.AuditEntry{}, &models.ChannelEvent{}); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+
+	rec := httptest.NewRecorder()
+	AdminChannels(rec, adminRequest(http.MethodPost, "/admin/channels", `{"code":"canal-9","name":"Logística","maxUsers":2}`))
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	rec = httptest.NewRecorder()
+	AdminChannels(rec, adminRequest(http.MethodPost, "/admin/channels", `{"code":"canal-9"}`))
+	if rec.Code != http.StatusConflict {
+		t.Fatalf("expected 409 for duplicate code, got %d", rec.Code)
+	}
+
+	var channel models.Channel
+	config.DB.Where("code = ?", "canal-9").First(&channel)
+	user := models.User{DisplayName: "Ana", CurrentChannelID: &channel.ID}
+	other := models.User{DisplayName: "Beto", CurrentChannelID: &channel.ID}
+	config.DB.Create(&user)
+	config.DB.Create(&other)
+	config.DB.Create(&models.ChannelMembership{UserID: user.ID, ChannelID: channel.ID, Active: true})
+
+	rec = httptest.NewRecorder()
+	AdminChannel(rec, adminChannelRequest(http.MethodPut, "canal-9", `{"maxUsers":1}`))
+	if rec.Code != http.StatusConflict {
+		t.Fatalf("expected 409 when shrinking below connected users, got %d", rec.Code)
+	}
+
+	rec = httptest.NewRecorder()
+	AdminChannel(rec, adminChannelRequest(http.MethodPut, "canal-9", `{"name":"Bodega","maxUsers":5,"assistant":true}`))
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), "Bodega") || !strings.Contains(rec.Body.String(), `"assistant":true`) {
+		t.Fatalf("expected rename, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	rec = httptest.NewRecorder()
+	AdminChannel(rec, adminChannelRequest(http.MethodDelete, "canal-9", ""))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200 on delete, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	var reloaded models.User
+	config.DB.First(&reloaded, user.ID)
+	if reloaded.CurrentChannelID != nil {
+		t.Fatal("expected user to be disconnected from deleted channel")
+	}
+	var memberships int64
+	config.DB.Model(&models.ChannelMembership{}).Where("channel_id = ?", channel.ID).Count(&memberships)
+	if memberships != 0 {
+		t.Fatalf("expected memberships removed, got %d", memberships)
+	}
+	var kicks int64
+	config.DB.Model(&models.ChannelEvent{}).Where("type = ?", models.ChannelEventKick).Count(&kicks)
+	if kicks != 2 {
+		t.Fatalf("expected kick events for both users, got %d", kicks)
+	}
+
+	rec = httptest.NewRecorder()
+	AdminChannel(rec, adminChannelRequest(http.MethodDelete, "canal-9", ""))
+	if rec.Code != http.StatusNotFound {
+		t.Fatalf("expected 404 after delete, got %d", rec.Code)
+	}
+}
+
+func TestAdminChannels_RejectsInvalidCode(t *testing.T) {
+	cleanup := setupChannelsTestDB(t)
+	defer cleanup()
+	t.Setenv("ADMIN_TOKEN", "secreto")
+
+	rec := httptest.NewRecorder()
+	AdminChannels(rec, adminRequest(http.MethodPost, "/admin/channels", `{"code":"Canal Uno!"}`))
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400, got %d", rec.Code)
+	}
+}
@@ -0,0 +1,47 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"walkie-backend/internal/config"
+	"walkie-backend/internal/response"
+	"walkie-backend/internal/services"
+)
+
+// GET /admin/channel-events?user=ID&at=RFC3339
+// Reconstruye el canal del usuario en ese instante junto con los eventos que lo explican.
+func AdminChannelEvents(w http.ResponseWriter, r *http.Request) {
+	if !requireAdmin(w, r) || !requireDB(w) {
+		return
+	}
+
+	userID, err := strconv.ParseUint(r.URL.Query().Get("user"), 10, 64)
+	if err != nil || userID == 0 {
+		response.WriteErr(w, http.StatusBadRequest, "Parámetro user inválido")
+		return
+	}
+
+	at := time.Now()
+	if raw := r.URL.Query().Get("at"); raw != "" {
+		at, err = time.Parse(time.RFC3339, raw)
+		if err != nil {
+			response.WriteErr(w, http.StatusBadRequest, "Parámetro at inválido, se espera RFC3339")
+			return
+		}
+	}
+
+	channel, events, err := services.ReplayChannelState(config.DB, uint(userID), at)
+	if err != nil {
+		response.WriteErr(w, http.StatusInternalServerError, "No se pudieron leer los eventos")
+		return
+	}
+
+	response.WriteJSON(w, http.StatusOK, map[string]any{
+		"userId":  userID,
+		"at":      at.UTC().Format(time.RFC3339),
+		"channel": channel,
+		"events":  events,
+	})
+}
@@ -0,0 +1,81 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"walkie-backend/internal/keyring"
+	"walkie-backend/internal/models"
+	"walkie-backend/internal/response"
+	"walkie-backend/internal/services"
+)
+
+// GET /admin/keys lista las claves de firma, POST /admin/keys agrega una nueva clave primaria
+func AdminKeys(w http.ResponseWriter, r *http.Request) {
+	if !requireAdmin(w, r) || !requireDB(w) {
+		return
+	}
+
+	kr, err := keyring.Default()
+	if err != nil {
+		response.WriteErr(w, http.StatusServiceUnavailable, "Keyring no disponible")
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		response.WriteJSON(w, http.StatusOK, map[string]any{"keys": kr.Keys()})
+	case http.MethodPost:
+		info, err := kr.Rotate()
+		if err != nil {
+			response.WriteErr(w, http.StatusInternalServerError, "No se pudo crear la clave")
+			return
+		}
+		services.RecordAudit(nil, models.AuditEntry{
+			Actor:   adminActor(r),
+			Action:  "key_add",
+			Details: info.KID,
+			Source:  "http",
+		})
+		response.WriteJSON(w, http.StatusCreated, info)
+	}
+}
+
+// DELETE /admin/keys/{kid} retira una clave; los artefactos firmados con ella dejan de validarse
+func AdminKeyRetire(w http.ResponseWriter, r *http.Request) {
+	if !requireAdmin(w, r) || !requireDB(w) {
+		return
+	}
+
+	kid := r.PathValue("kid")
+	if kid == "" {
+		response.WriteErr(w, http.StatusBadRequest, "kid requerido")
+		return
+	}
+
+	kr, err := keyring.Default()
+	if err != nil {
+		response.WriteErr(w, http.StatusServiceUnavailable, "Keyring no disponible")
+		return
+	}
+
+	switch err := kr.Retire(kid); {
+	case errors.Is(err, keyring.ErrKeyNotFound):
+		response.WriteErr(w, http.StatusNotFound, "Clave no encontrada")
+		return
+	case errors.Is(err, keyring.ErrLastActiveKey):
+		response.WriteErr(w, http.StatusConflict, "No se puede retirar la única clave activa")
+		return
+	case err != nil:
+		response.WriteErr(w, http.StatusInternalServerError, "No se pudo retirar la clave")
+		return
+	}
+
+	services.RecordAudit(nil, models.AuditEntry{
+		Actor:   adminActor(r),
+		Action:  "key_retire",
+		Details: kid,
+		Source:  "http",
+	})
+	response.WriteJSON(w, http.StatusOK, map[string]string{"status": "retired", "kid": kid})
+}
@@ -0,0 +1,82 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"walkie-backend/internal/config"
+	"walkie-backend/internal/models"
+)
+
+func TestRequireAdmin(t *testing.T) {
+	t.Setenv("ADMIN_TOKEN", "")
+	rec := httptest.NewRecorder()
+	if requireAdmin(rec, httptest.NewRequest(http.MethodPost, "/", nil)) || rec.Code != http.StatusForbidden {
+		t.Fatalf("expected 403 when disabled, got %d", rec.Code)
+	}
+
+	t.Setenv("ADMIN_TOKEN", "secreto")
+	rec = httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/", nil)
+	req.Header.Set("X-Admin-Token", "otro")
+	if requireAdmin(rec, req) || rec.Code != http.StatusUnauthorized {
+		t.Fatalf("expected 401 for wrong token, got %d", rec.Code)
+	}
+
+	req.Header.Set("X-Admin-Token", "secreto")
+	if !requireAdmin(httptest.NewRecorder(), req) {
+		t.Fatal("expected valid admin token to pass")
+	}
+}
+
+func TestBulkMemberships_CSV(t *testing.T) {
+	cleanup := setupChannelsTestDB(t)
+	defer cleanup()
+	t.Setenv("ADMIN_TOKEN", "secreto")
+
+	if err := config.DB.AutoMigrate(&models.AuditEntry{}); err != nil {
+		t.Fatalf("migrate audit: %v", err)
+	}
+	config.DB.Create(&models.User{DisplayName: "Ana"})
+	config.DB.Create(&models.Channel{Code: "canal-1", Name: "Canal 1", MaxUsers: 10})
+
+	body := "user,channel,action\nAna,canal-1,add\nNadie,canal-1,add\n"
+	req := httptest.NewRequest(http.MethodPost, "/admin/memberships/bulk", strings.NewReader(body))
+	req.Header.Set("Content-Type", "text/csv")
+	req.Header.Set("X-Admin-Token", "secreto")
+	rec := httptest.NewRecorder()
+
+	BulkMemberships(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	var out struct {
+		Applied int `json:"applied"`
+		Failed  int `json:"failed"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &out); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if out.Applied != 1 || out.Failed != 1 {
+		t.Fatalf("expected 1 applied and 1 failed, got %+v", out)
+	}
+}
+
+func TestBulkMemberships_InvalidJSON(t *testing.T) {
+	t.Setenv("ADMIN_TOKEN", "secreto")
+	req := httptest.NewRequest(http.MethodPost, "/admin/memberships/bulk", strings.NewReader("{"))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Admin-Token", "secreto")
+	rec := httptest.NewRecorder()
+
+	BulkMemberships(rec, req)
+
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400, got %d", rec.Code)
+	}
+}
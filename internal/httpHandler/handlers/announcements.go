@@ -0,0 +1,279 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"walkie-backend/internal/config"
+	"walkie-backend/internal/metrics"
+	"walkie-backend/internal/models"
+	"walkie-backend/internal/response"
+	"walkie-backend/internal/services"
+)
+
+const (
+	// AnnouncementSenderID es el emisor de los anuncios; como el del asistente, no es
+	// ningún usuario
+	AnnouncementSenderID uint = 0
+
+	defaultAnnouncementTick  = 15 * time.Second
+	announcementDisplayName  = "Anuncio"
+	announcementSynthTimeout = 30 * time.Second
+)
+
+var announcementsOnce sync.Once
+
+// announcementRequest es el cuerpo de POST /admin/announcements; audio va en base64 y,
+// si falta, se sintetiza text
+type announcementRequest struct {
+	Channels []string   `json:"channels"`
+	Text     string     `json:"text"`
+	Audio    []byte     `json:"audio"`
+	Format   string     `json:"format"`
+	At       *time.Time `json:"at"`
+	Every    string     `json:"every"`
+	Priority string     `json:"priority"`
+}
+
+type announcementView struct {
+	ID        uint       `json:"id"`
+	Channels  []string   `json:"channels"`
+	Text      string     `json:"text,omitempty"`
+	Format    string     `json:"format"`
+	Priority  string     `json:"priority"`
+	NextRunAt *time.Time `json:"nextRunAt,omitempty"`
+	Every     string     `json:"every,omitempty"`
+	LastRunAt *time.Time `json:"lastRunAt,omitempty"`
+	Runs      int        `json:"runs"`
+	CreatedBy string     `json:"createdBy,omitempty"`
+}
+
+func toAnnouncementView(a *models.Announcement) announcementView {
+	v := announcementView{
+		ID:        a.ID,
+		Channels:  a.ChannelCodes(),
+		Text:      a.Text,
+		Format:    a.Format,
+		Priority:  a.Priority,
+		NextRunAt: a.NextRunAt,
+		LastRunAt: a.LastRunAt,
+		Runs:      a.Runs,
+		CreatedBy: a.CreatedBy,
+	}
+	if a.IntervalSeconds > 0 {
+		v.Every = a.Interval().String()
+	}
+	return v
+}
+
+// GET /admin/announcements lista los anuncios; POST /admin/announcements programa uno
+func AdminAnnouncements(w http.ResponseWriter, r *http.Request) {
+	if !requireAdmin(w, r) || !requireDB(w) {
+		return
+	}
+
+	svc := services.NewAnnouncementService(config.DB)
+	if r.Method == http.MethodGet {
+		items, err := svc.List()
+		if err != nil {
+			response.WriteErr(w, http.StatusInternalServerError, "No se pudieron obtener los anuncios")
+			return
+		}
+		out := make([]announcementView, 0, len(items))
+		for i := range items {
+			out = append(out, toAnnouncementView(&items[i]))
+		}
+		response.WriteJSON(w, http.StatusOK, out)
+		return
+	}
+
+	var in announcementRequest
+	// El audio llega en base64, así que el cuerpo puede ocupar algo más que el clip
+	body, err := io.ReadAll(io.LimitReader(r.Body, 2*maxAudioSize))
+	if err != nil || json.Unmarshal(body, &in) != nil {
+		response.WriteErr(w, http.StatusBadRequest, "JSON inválido")
+		return
+	}
+
+	a := models.Announcement{
+		Channels:  strings.Join(in.Channels, ","),
+		Text:      strings.TrimSpace(in.Text),
+		Audio:     in.Audio,
+		Format:    strings.TrimSpace(in.Format),
+		Priority:  PriorityNormal,
+		NextRunAt: in.At,
+		CreatedBy: adminActor(r),
+	}
+	switch p := strings.TrimSpace(in.Priority); p {
+	case "":
+	case PriorityNormal, PriorityUrgent, PriorityEmergency:
+		a.Priority = p
+	default:
+		response.WriteErr(w, http.StatusBadRequest, "priority debe ser normal, urgent o emergency")
+		return
+	}
+	if in.Every != "" {
+		every, err := time.ParseDuration(in.Every)
+		if err != nil || every <= 0 {
+			response.WriteErr(w, http.StatusBadRequest, "every debe ser una duración como 30m o 24h")
+			return
+		}
+		a.IntervalSeconds = int64(every / time.Second)
+	}
+
+	if len(a.Audio) == 0 {
+		if a.Text == "" {
+			response.WriteErr(w, http.StatusBadRequest, "Indica audio o text")
+			return
+		}
+		ctx, cancel := context.WithTimeout(r.Context(), announcementSynthTimeout)
+		a.Audio, err = synthesizeSpeech(ctx, a.Text)
+		cancel()
+		if err != nil {
+			log.Printf("[ANUNCIOS] error sintetizando anuncio: %v", err)
+			response.WriteErr(w, http.StatusServiceUnavailable, "Síntesis de voz no disponible")
+			return
+		}
+		a.Format = "audio/wav"
+	}
+	if a.Format == "" {
+		a.Format = "audio/wav"
+	}
+	if len(a.Audio) > maxAudioSize || !validateAudioFormat(a.Audio, a.Format) {
+		response.WriteErr(w, http.StatusBadRequest, "Audio inválido. Se requiere WAV, FLAC, Opus (Ogg) o WebM de hasta 10 MB")
+		return
+	}
+
+	if err := svc.Create(&a); err != nil {
+		writeAnnouncementError(w, err)
+		return
+	}
+	services.RecordAudit(nil, models.AuditEntry{
+		Actor:   a.CreatedBy,
+		Action:  "announcement_create",
+		Channel: a.Channels,
+		Details: fmt.Sprintf("id=%d at=%s every=%ds priority=%s bytes=%d", a.ID, a.NextRunAt.UTC().Format(time.RFC3339), a.IntervalSeconds, a.Priority, len(a.Audio)),
+		Source:  models.EventSourceHTTP,
+	})
+	response.WriteJSON(w, http.StatusCreated, toAnnouncementView(&a))
+}
+
+// DELETE /admin/announcements/{id} cancela un anuncio
+func AdminAnnouncement(w http.ResponseWriter, r *http.Request) {
+	if !requireAdmin(w, r) || !requireDB(w) {
+		return
+	}
+
+	id, err := strconv.ParseUint(r.PathValue("id"), 10, 64)
+	if err != nil || id == 0 {
+		response.WriteErr(w, http.StatusBadRequest, "ID de anuncio inválido")
+		return
+	}
+	if err := services.NewAnnouncementService(config.DB).Delete(uint(id)); err != nil {
+		writeAnnouncementError(w, err)
+		return
+	}
+	services.RecordAudit(nil, models.AuditEntry{
+		Actor:   adminActor(r),
+		Action:  "announcement_delete",
+		Details: fmt.Sprintf("id=%d", id),
+		Source:  models.EventSourceHTTP,
+	})
+	response.WriteJSON(w, http.StatusOK, map[string]any{"status": "deleted", "id": id})
+}
+
+func writeAnnouncementError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, services.ErrAnnouncementNotFound):
+		response.WriteErr(w, http.StatusNotFound, "Anuncio no encontrado")
+	case errors.Is(err, services.ErrInvalidAnnouncement):
+		response.WriteErr(w, http.StatusBadRequest, err.Error())
+	default:
+		response.WriteErr(w, http.StatusInternalServerError, "No se pudo guardar el anuncio")
+	}
+}
+
+// StartAnnouncementScheduler revisa cada ANNOUNCEMENTS_TICK (15 s) los anuncios pendientes.
+// Todas las réplicas lo ejecutan; cada emisión la reserva una sola en la base de datos
+func StartAnnouncementScheduler() {
+	announcementsOnce.Do(func() {
+		interval := config.DurationFromEnv("ANNOUNCEMENTS_TICK", defaultAnnouncementTick)
+		go func() {
+			ticker := time.NewTicker(interval)
+			defer ticker.Stop()
+			for range ticker.C {
+				runDueAnnouncements(time.Now())
+			}
+		}()
+		log.Printf("[ANUNCIOS] revisando cada %s", interval)
+	})
+}
+
+// runDueAnnouncements difunde los anuncios que ya tocan y devuelve cuántos emitió
+func runDueAnnouncements(now time.Time) int {
+	if config.DB == nil || !config.DBAvailable() {
+		return 0
+	}
+	svc := services.NewAnnouncementService(config.DB)
+	due, err := svc.Due(now)
+	if err != nil {
+		log.Printf("[ANUNCIOS] error buscando anuncios pendientes: %v", err)
+		return 0
+	}
+	sent := 0
+	for i := range due {
+		a := &due[i]
+		claimed, err := svc.Claim(a, now)
+		if err != nil {
+			log.Printf("[ANUNCIOS] error reservando anuncio %d: %v", a.ID, err)
+			continue
+		}
+		if !claimed {
+			continue
+		}
+		deliverAnnouncement(a)
+		sent++
+	}
+	return sent
+}
+
+// deliverAnnouncement difunde el anuncio en cada canal
+func deliverAnnouncement(a *models.Announcement) {
+	for _, channel := range a.ChannelCodes() {
+		recipients, err := announceOnChannel(channel, announcementDisplayName, a.Audio, a.Text, a.Priority)
+		if err != nil {
+			log.Printf("[ANUNCIOS] anuncio=%d canal=%s error obteniendo miembros: %v", a.ID, channel, err)
+			continue
+		}
+		metrics.Inc("walkie_announcements_sent_total", nil)
+		log.Printf("[ANUNCIOS] anuncio=%d emitido en canal=%s a %d usuarios", a.ID, channel, recipients)
+	}
+}
+
+// announceOnChannel difunde un clip que no envía ningún usuario por los mismos caminos
+// que un mensaje de voz (WebSocket, cola de audio y transcripciones) y devuelve a
+// cuántos miembros se encoló
+func announceOnChannel(channel, speaker string, audioData []byte, text, priority string) (int, error) {
+	members, err := services.NewUserService().GetChannelActiveUsers(channel)
+	if err != nil {
+		return 0, err
+	}
+	recipients := make([]uint, 0, len(members))
+	for _, m := range members {
+		recipients = append(recipients, m.ID)
+	}
+	duration := estimateAudioDuration(audioData)
+	broadcastAudio(channel, AnnouncementSenderID, audioData)
+	EnqueueAudioWithPriority(AnnouncementSenderID, channel, audioData, duration.Seconds(), recipients, priority)
+	recordChannelTranscript(&models.User{DisplayName: speaker}, channel, text, priority)
+	return len(recipients), nil
+}
@@ -0,0 +1,86 @@
+package handlers
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"walkie-backend/internal/config"
+	"walkie-backend/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+func TestAdminAnnouncements_ScheduleAndDeliver(t *testing.T) {
+	cleanup := setupChannelsTestDB(t)
+	defer cleanup()
+	t.Setenv("ADMIN_TOKEN", "secreto")
+	db := config.DB
+	require.NoError(t, db.AutoMigrate(&models.Announcement{}, &models.AuditEntry{}))
+
+	channel := models.Channel{Code: "anuncios-1", Name: "Almacén", MaxUsers: 10}
+	require.NoError(t, db.Create(&channel).Error)
+	listener := models.User{Model: gorm.Model{ID: 673}, DisplayName: "Nuria", IsActive: true, CurrentChannelID: &channel.ID}
+	require.NoError(t, db.Create(&listener).Error)
+	require.NoError(t, db.Create(&models.ChannelMembership{UserID: listener.ID, ChannelID: channel.ID, Active: true}).Error)
+	t.Cleanup(func() { ClearPendingAudio(listener.ID) })
+
+	origSynth := synthesizeSpeech
+	t.Cleanup(func() { synthesizeSpeech = origSynth })
+	wav := buildTestWAV(8000)
+	synthesizeSpeech = func(context.Context, string) ([]byte, error) { return wav, nil }
+
+	post := func(body string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		AdminAnnouncements(rec, adminRequest(http.MethodPost, "/admin/announcements", body))
+		return rec
+	}
+
+	assert.Equal(t, http.StatusBadRequest, post(`{"channels":["no-existe"],"text":"hola"}`).Code)
+	assert.Equal(t, http.StatusBadRequest, post(`{"channels":["anuncios-1"]}`).Code)
+	assert.Equal(t, http.StatusBadRequest, post(`{"channels":["anuncios-1"],"text":"hola","every":"10s"}`).Code)
+	assert.Equal(t, http.StatusBadRequest, post(`{"channels":["anuncios-1"],"audio":"`+base64.StdEncoding.EncodeToString([]byte("no es audio"))+`"}`).Code)
+
+	past := time.Now().Add(-time.Minute).UTC().Format(time.RFC3339)
+	rec := post(fmt.Sprintf(`{"channels":["anuncios-1"],"text":"Cierre del almacén en diez minutos","at":%q,"priority":"urgent"}`, past))
+	require.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+	var created announcementView
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &created))
+	assert.Equal(t, []string{"anuncios-1"}, created.Channels)
+
+	future := time.Now().Add(time.Hour).UTC().Format(time.RFC3339)
+	rec = post(fmt.Sprintf(`{"channels":["anuncios-1"],"audio":%q,"at":%q,"every":"24h"}`, base64.StdEncoding.EncodeToString(wav), future))
+	require.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+	var daily announcementView
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &daily))
+	assert.Equal(t, "24h0m0s", daily.Every)
+
+	assert.Equal(t, 1, runDueAnnouncements(time.Now()))
+	clip := DequeueAudio(listener.ID)
+	require.NotNil(t, clip)
+	assert.Equal(t, AnnouncementSenderID, clip.SenderID)
+	assert.Equal(t, PriorityUrgent, clip.Priority)
+	assert.Equal(t, 0, runDueAnnouncements(time.Now()), "un anuncio sin every se emite una sola vez")
+
+	req := adminRequest(http.MethodDelete, fmt.Sprintf("/admin/announcements/%d", daily.ID), "")
+	req.SetPathValue("id", fmt.Sprint(daily.ID))
+	rec = httptest.NewRecorder()
+	AdminAnnouncement(rec, req)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, 0, runDueAnnouncements(time.Now().Add(2*time.Hour)), "un anuncio cancelado no se emite")
+
+	rec = httptest.NewRecorder()
+	AdminAnnouncements(rec, adminRequest(http.MethodGet, "/admin/announcements", ""))
+	var listed []announcementView
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &listed))
+	require.Len(t, listed, 1)
+	assert.Equal(t, 1, listed[0].Runs)
+	assert.Nil(t, listed[0].NextRunAt)
+}
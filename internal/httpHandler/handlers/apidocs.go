@@ -0,0 +1,393 @@
+package handlers
+
+import (
+	"net/http"
+	"reflect"
+
+	"walkie-backend/internal/keyring"
+	"walkie-backend/internal/models"
+	"walkie-backend/internal/services"
+	"walkie-backend/pkg/openapi"
+)
+
+// APIDoc documenta un método de una ruta del router para /openapi.json. Route es el
+// patrón registrado; Operation.Path, si va vacío, es el mismo.
+type APIDoc struct {
+	Route     string
+	Operation openapi.Operation
+}
+
+var (
+	userAuth  = []string{"bearerAuth", "authToken"}
+	adminAuth = []string{"adminToken"}
+
+	audioTypes = []string{"audio/wav", "audio/flac", "audio/ogg", "audio/webm", "multipart/form-data"}
+)
+
+func typeOf[T any]() reflect.Type { return reflect.TypeFor[T]() }
+
+func ok(body any) openapi.Response { return openapi.Response{Status: http.StatusOK, Body: body} }
+
+func created(body any) openapi.Response {
+	return openapi.Response{Status: http.StatusCreated, Body: body}
+}
+
+func noContent(description string) openapi.Response {
+	return openapi.Response{Status: http.StatusNoContent, Description: description}
+}
+
+func query(name, description string, t reflect.Type) openapi.Param {
+	return openapi.Param{Name: name, In: "query", Description: description, Type: t}
+}
+
+func header(name, description string) openapi.Param {
+	return openapi.Param{Name: name, In: "header", Description: description}
+}
+
+func idParam(name string) openapi.Param {
+	return openapi.Param{Name: name, In: "path", Required: true, Type: typeOf[uint]()}
+}
+
+// audioDeliveryHeaders acompañan a cada clip entregado por /audio/poll
+var audioDeliveryHeaders = []openapi.Header{
+	{Name: "X-Audio-From", Description: "ID del usuario que habló"},
+	{Name: "X-Channel", Description: "Canal del clip"},
+	{Name: "X-Audio-Timestamp", Description: "Cuándo se envió (RFC3339Nano)"},
+	{Name: "X-Audio-Age-Seconds", Description: "Segundos que lleva en cola"},
+	{Name: "X-Audio-Priority", Description: "urgent o emergency; sin cabecera es normal"},
+	{Name: "X-Transmission-ID", Description: "ID para POST /audio/played/{id} y las confirmaciones"},
+	{Name: "X-Audio-Direct", Description: "true si es un mensaje directo"},
+	{Name: "X-Audio-Encrypted", Description: "true si el clip va cifrado de extremo a extremo"},
+	{Name: "X-Audio-Notice", Description: "Aviso hablado cuando el clip llega con retraso"},
+	{Name: "X-Delivery-ID", Description: "Con X-Audio-Ack: true, ID para POST /audio/ack/{id}"},
+	{Name: "X-Audio-Attempt", Description: "Con X-Audio-Ack: true, número de intento de entrega"},
+	{Name: "X-Degraded-Mode", Description: "true si la base de datos no está disponible"},
+}
+
+// audioIngestHeaders acompañan a la respuesta de un clip difundido al canal
+var audioIngestHeaders = []openapi.Header{
+	{Name: "X-Transmission-ID", Description: "ID de la transmisión para consultar sus confirmaciones"},
+	{Name: "X-Audio-Priority", Description: "Prioridad detectada si no es normal"},
+	{Name: "X-Speaker-Tip", Description: "Consejo hablado cuando el audio se entiende mal a menudo"},
+	{Name: "X-Degraded-Mode", Description: "true si la base de datos no está disponible"},
+}
+
+var ingestResponses = []openapi.Response{
+	{Status: http.StatusOK, Description: "Comando de voz ejecutado o respuesta del asistente", Body: typeOf[CommandResponse]()},
+	{Status: http.StatusNoContent, Description: "Audio difundido al canal", Headers: audioIngestHeaders},
+	{Status: http.StatusConflict, Description: "Otro usuario tiene la palabra en el canal"},
+	{Status: http.StatusRequestEntityTooLarge, Description: "Supera AUDIO_MAX_BYTES o AUDIO_MAX_DURATION", Body: typeOf[CommandResponse]()},
+	{Status: http.StatusTooManyRequests, Description: "Límite de peticiones; ver Retry-After"},
+}
+
+var statusID = openapi.Fields{"status": typeOf[string](), "id": typeOf[uint]()}
+
+// APIDocs lista la documentación de todas las rutas REST; un test comprueba que no
+// falte ninguna de las registradas en el router
+func APIDocs() []APIDoc {
+	return []APIDoc{
+		// Autenticación
+		{Route: "/auth", Operation: openapi.Operation{Method: http.MethodPost, Tag: "auth", Summary: "Registra o inicia sesión con nombre y PIN",
+			Request:   typeOf[AuthenticationRequest](),
+			Responses: []openapi.Response{ok(typeOf[AuthenticationResponse]()), {Status: http.StatusUnauthorized, Description: "Credenciales inválidas"}}}},
+		{Route: "/auth/refresh", Operation: openapi.Operation{Method: http.MethodPost, Tag: "auth", Summary: "Cambia un token de refresco por un par nuevo",
+			Request: typeOf[refreshRequest](), Responses: []openapi.Response{ok(typeOf[TokenPair]())}}},
+		{Route: "/auth/logout", Operation: openapi.Operation{Method: http.MethodPost, Tag: "auth", Summary: "Cierra la sesión en todos los dispositivos", Security: userAuth,
+			Responses: []openapi.Response{ok(openapi.Fields{"message": typeOf[string]()})}}},
+
+		// Audio
+		{Route: "/audio/ingest", Operation: openapi.Operation{Method: http.MethodPost, Tag: "audio", Security: userAuth,
+			Summary:        "Envía un clip de voz: se transcribe, se ejecuta si es un comando o se difunde al canal",
+			Request:        openapi.Raw{},
+			RequestContent: audioTypes,
+			Responses:      ingestResponses}},
+		{Route: "/audio/direct/{userID}", Operation: openapi.Operation{Method: http.MethodPost, Tag: "audio", Security: userAuth,
+			Summary: "Envía un clip a un único usuario", Params: []openapi.Param{idParam("userID")},
+			Request: openapi.Raw{}, RequestContent: audioTypes,
+			Responses: []openapi.Response{{Status: http.StatusNoContent, Description: "Encolado para el destinatario", Headers: []openapi.Header{{Name: "X-Audio-Direct", Description: "Siempre true"}}}}}},
+		{Route: "/audio/live", Operation: openapi.Operation{Method: http.MethodPost, Tag: "audio", Security: userAuth,
+			Summary: "Transmisión larga: WAV por partes (Transfer-Encoding: chunked) repartido al canal mientras se sube",
+			Request: openapi.Raw{}, RequestContent: []string{"audio/wav"}, Responses: []openapi.Response{ok(typeOf[CommandResponse]())}}},
+		{Route: "/audio/encrypted", Operation: openapi.Operation{Method: http.MethodPost, Tag: "audio", Security: userAuth,
+			Summary: "Reenvía al canal un clip cifrado por el cliente",
+			Params:  []openapi.Param{header("X-Audio-Duration", "Duración en segundos; el servidor no puede leer el audio")},
+			Request: openapi.Raw{}, RequestContent: []string{"application/octet-stream"},
+			Responses: []openapi.Response{noContent("Clip difundido")}}},
+		{Route: "/audio/poll", Operation: openapi.Operation{Method: http.MethodGet, Tag: "audio", Security: userAuth,
+			Summary: "Recoge el siguiente clip pendiente; el cuerpo es el audio y los metadatos van en cabeceras",
+			Params: []openapi.Param{
+				header("X-Audio-Ack", "true para que el clip se reentregue hasta confirmarlo con POST /audio/ack/{id}"),
+				query("batch", "true para recibir varios clips en JSON", typeOf[bool]()),
+				query("max", "Máximo de clips con batch=true", typeOf[int]()),
+			},
+			Responses: []openapi.Response{
+				{Status: http.StatusOK, Description: "Clip pendiente (o lista JSON con batch=true)", Content: "audio/wav", Body: openapi.Raw{}, Headers: audioDeliveryHeaders},
+				{Status: http.StatusSeeOther, Description: "El clip está en el almacenamiento de objetos; descargarlo de Location", Headers: append([]openapi.Header{{Name: "X-Audio-URL", Description: "URL firmada del clip"}}, audioDeliveryHeaders...)},
+				noContent("No hay audio pendiente"),
+			}}},
+		{Route: "/audio/ack/{id}", Operation: openapi.Operation{Method: http.MethodPost, Tag: "audio", Security: userAuth,
+			Summary: "Confirma la entrega del clip con ese X-Delivery-ID", Responses: []openapi.Response{noContent("Confirmado")}}},
+		{Route: "/audio/played/{id}", Operation: openapi.Operation{Method: http.MethodPost, Tag: "audio", Security: userAuth,
+			Summary: "Confirma que se reprodujo la transmisión; el emisor recibe played_by por WebSocket", Responses: []openapi.Response{noContent("Anotado")}}},
+		{Route: "/audio/{id}/receipts", Operation: openapi.Operation{Method: http.MethodGet, Tag: "audio", Security: userAuth,
+			Summary: "Quién recibió y quién escuchó una transmisión propia",
+			Responses: []openapi.Response{ok(openapi.Fields{
+				"transmissionId": typeOf[string](), "channel": typeOf[string](), "recipients": typeOf[int](),
+				"delivered": typeOf[int](), "played": typeOf[int](), "receipts": typeOf[[]receiptItem](),
+			})}}},
+		{Route: "/audio/stream", Operation: openapi.Operation{Method: http.MethodGet, Tag: "audio", Security: userAuth,
+			Summary:   "Server-Sent Events con los clips del canal en base64",
+			Responses: []openapi.Response{{Status: http.StatusOK, Content: "text/event-stream", Body: typeOf[audioFrame]()}}}},
+		{Route: "/audio/queue-status", Operation: openapi.Operation{Method: http.MethodGet, Tag: "audio", Security: userAuth,
+			Summary: "Estado de la cola pendiente del usuario",
+			Responses: []openapi.Response{ok(openapi.Fields{
+				"depth": typeOf[int](), "urgent": typeOf[int](), "bytes": typeOf[int64](), "max_clips": typeOf[int](), "max_bytes": typeOf[int64](),
+				"oldest_at": typeOf[string](), "oldest_age_seconds": typeOf[float64](),
+			})}}},
+
+		// Canales
+		{Route: "/channels/public", Operation: openapi.Operation{Method: http.MethodGet, Tag: "channels", Summary: "Lista los canales públicos",
+			Responses: []openapi.Response{ok(openapi.Array{Items: openapi.Fields{"code": typeOf[string](), "name": typeOf[string](), "maxUsers": typeOf[int]()}})}}},
+		{Route: "/channel-users", Operation: openapi.Operation{Method: http.MethodGet, Tag: "channels", Summary: "Miembros activos de un canal",
+			Params:    []openapi.Param{{Name: "channel", In: "query", Required: true}},
+			Responses: []openapi.Response{ok(openapi.Array{Items: openapi.Fields{"id": typeOf[uint](), "displayName": typeOf[string]()}})}}},
+		{Route: "/channels/{code}/history", Operation: openapi.Operation{Method: http.MethodGet, Tag: "channels", Security: userAuth,
+			Summary: "Últimas transmisiones del canal", Params: []openapi.Param{query("limit", "", typeOf[int]())},
+			Responses: []openapi.Response{ok(typeOf[[]historyItem]())}}},
+		{Route: "/channels/{code}/history/{id}/audio", Operation: openapi.Operation{Method: http.MethodGet, Tag: "channels", Security: userAuth,
+			Summary: "Audio de una transmisión del historial", Params: []openapi.Param{idParam("id")},
+			Responses: []openapi.Response{{Status: http.StatusOK, Content: "audio/wav", Body: openapi.Raw{}, Headers: audioDeliveryHeaders[:3]}}}},
+		{Route: "/channels/{code}/presence", Operation: openapi.Operation{Method: http.MethodGet, Tag: "channels", Security: userAuth,
+			Summary:   "Quién está conectado al canal",
+			Responses: []openapi.Response{ok(openapi.Fields{"channel": typeOf[string](), "users": typeOf[[]presenceUser]()})}}},
+		{Route: "/channels/{code}/messages", Operation: openapi.Operation{Method: http.MethodGet, Tag: "channels", Security: userAuth,
+			Summary:   "Mensajes de texto del canal",
+			Params:    []openapi.Param{query("limit", "", typeOf[int]()), query("before", "Paginación: ID del mensaje más antiguo recibido", typeOf[uint]())},
+			Responses: []openapi.Response{ok(openapi.Fields{"channel": typeOf[string](), "messages": typeOf[[]chatMessage](), "next_before": typeOf[uint]()})}}},
+		{Route: "/channels/{code}/transcripts", Operation: openapi.Operation{Method: http.MethodGet, Tag: "channels", Security: userAuth,
+			Summary:   "Transcripciones del canal",
+			Params:    []openapi.Param{query("since", "RFC3339", nil), query("limit", "", typeOf[int]())},
+			Responses: []openapi.Response{ok(typeOf[[]transcriptItem]())}}},
+		{Route: "/channels/{code}/summary", Operation: openapi.Operation{Method: http.MethodGet, Tag: "channels", Security: userAuth,
+			Summary: "Resumen de lo último hablado en el canal", Params: []openapi.Param{query("limit", "", typeOf[int]())},
+			Responses: []openapi.Response{ok(typeOf[channelSummary]())}}},
+		{Route: "/channels/{code}/recordings", Operation: openapi.Operation{Method: http.MethodGet, Tag: "channels", Security: userAuth,
+			Summary: "Grabaciones del canal", Responses: []openapi.Response{ok(typeOf[[]recordingItem]())}}},
+		{Route: "/channels/{code}/recordings/{id}/export", Operation: openapi.Operation{Method: http.MethodGet, Tag: "channels", Security: userAuth,
+			Summary: "Exporta una grabación como un único WAV", Params: []openapi.Param{idParam("id")},
+			Responses: []openapi.Response{{Status: http.StatusOK, Content: "audio/wav", Body: openapi.Raw{}, Headers: []openapi.Header{{Name: "X-Recording-Clips", Description: "Clips incluidos"}}}}}},
+		{Route: "/channels/{code}/alias", Operation: openapi.Operation{Method: http.MethodPatch, Tag: "channels", Security: userAuth,
+			Summary: "Pone o borra el alias personal del canal", Request: typeOf[channelAliasRequest](),
+			Responses: []openapi.Response{ok(openapi.Fields{"channel": typeOf[string](), "alias": typeOf[string]()})}}},
+		{Route: "/channels/{code}/roles/{userID}", Operation: openapi.Operation{Method: http.MethodPut, Tag: "channels", Security: userAuth,
+			Summary: "Nombra moderador a un miembro (sólo el propietario)", Params: []openapi.Param{idParam("userID")},
+			Request: typeOf[channelRoleRequest](), Responses: []openapi.Response{ok(channelRoleFields)}}},
+		{Route: "/channels/{code}/roles/{userID}", Operation: openapi.Operation{Method: http.MethodDelete, Tag: "channels", Security: userAuth,
+			Summary: "Devuelve a member a un moderador", Params: []openapi.Param{idParam("userID")},
+			Responses: []openapi.Response{ok(channelRoleFields)}}},
+		{Route: "/channels/{code}/kick/{userID}", Operation: openapi.Operation{Method: http.MethodPost, Tag: "channels", Security: userAuth,
+			Summary: "Expulsa a un usuario del canal (moderadores y propietario)", Params: []openapi.Param{idParam("userID")},
+			Responses: []openapi.Response{ok(openapi.Fields{"status": typeOf[string](), "channel": typeOf[string](), "user_id": typeOf[uint]()})}}},
+		{Route: "/channels/{code}/mute/{userID}", Operation: openapi.Operation{Method: http.MethodPost, Tag: "channels", Security: userAuth,
+			Summary: "Deja de recibir el audio de un usuario en el canal", Params: []openapi.Param{idParam("userID")},
+			Responses: []openapi.Response{ok(channelMuteFields)}}},
+		{Route: "/channels/{code}/mute/{userID}", Operation: openapi.Operation{Method: http.MethodDelete, Tag: "channels", Security: userAuth,
+			Summary: "Vuelve a recibir el audio de un usuario", Params: []openapi.Param{idParam("userID")},
+			Responses: []openapi.Response{ok(channelMuteFields)}}},
+		{Route: "/channels/{code}/listen", Operation: openapi.Operation{Method: http.MethodGet, Tag: "channels", Security: userAuth,
+			Summary:   "Escucha en vivo del canal como WAV continuo (propietarios y moderadores)",
+			Responses: []openapi.Response{{Status: http.StatusOK, Content: "audio/wav", Body: openapi.Raw{}}}}},
+
+		{Route: "/channels/{code}/connect", Operation: openapi.Operation{Method: http.MethodPost, Tag: "channels", Security: userAuth,
+			Summary: "Conecta al usuario al canal, como el comando de voz",
+			Responses: []openapi.Response{ok(typeOf[CommandResponse]()), {Status: http.StatusConflict, Description: "Canal lleno; Data sugiere canales con sitio", Body: typeOf[CommandResponse]()}}}},
+		{Route: "/channels/disconnect", Operation: openapi.Operation{Method: http.MethodPost, Tag: "channels", Security: userAuth,
+			Summary: "Saca al usuario de su canal actual", Responses: []openapi.Response{ok(typeOf[CommandResponse]())}}},
+
+		// Tiempo real
+		{Route: "/ws", Operation: openapi.Operation{Method: http.MethodGet, Tag: "realtime",
+			Summary:     "WebSocket de eventos y audio del canal",
+			Description: "El primer mensaje es el saludo {\"userId\":1,\"channel\":\"canal-1\",\"token\":\"...\",\"lastReceivedSeq\":0}.",
+			Responses:   []openapi.Response{{Status: http.StatusSwitchingProtocols, Description: "Conexión WebSocket"}}}},
+
+		// Usuario
+		{Route: "/me", Operation: openapi.Operation{Method: http.MethodGet, Tag: "me", Security: userAuth, Summary: "Perfil del usuario",
+			Responses: []openapi.Response{ok(typeOf[meProfile]())}}},
+		{Route: "/me", Operation: openapi.Operation{Method: http.MethodPatch, Tag: "me", Security: userAuth,
+			Summary: "Cambia nombre, idioma, notificaciones o no molestar", Request: typeOf[updateMeRequest](),
+			Responses: []openapi.Response{ok(typeOf[meProfile]())}}},
+		{Route: "/me/usage", Operation: openapi.Operation{Method: http.MethodGet, Tag: "me", Security: userAuth,
+			Summary: "Uso de hoy, cuota y últimos días", Params: []openapi.Param{query("days", "", typeOf[int]())},
+			Responses: []openapi.Response{ok(openapi.Fields{
+				"today": typeOf[usageDayItem](), "quota": typeOf[services.Quota](), "exceeded": typeOf[bool](),
+				"history": typeOf[[]usageDayItem](), "remaining": typeOf[map[string]float64](),
+			})}}},
+		{Route: "/me/devices", Operation: openapi.Operation{Method: http.MethodGet, Tag: "me", Security: userAuth, Summary: "Dispositivos con sesión abierta",
+			Responses: []openapi.Response{ok(openapi.Fields{"devices": typeOf[[]deviceItem]()})}}},
+		{Route: "/me/devices/{id}", Operation: openapi.Operation{Method: http.MethodDelete, Tag: "me", Security: userAuth,
+			Summary: "Cierra la sesión de un dispositivo", Params: []openapi.Param{idParam("id")},
+			Responses: []openapi.Response{ok(statusID)}}},
+		{Route: "/me/location", Operation: openapi.Operation{Method: http.MethodPost, Tag: "me", Security: userAuth,
+			Summary: "Guarda la ubicación del usuario", Request: typeOf[locationRequest](),
+			Responses: []openapi.Response{ok(openapi.Fields{"lat": typeOf[float64](), "lon": typeOf[float64](), "accuracy": typeOf[float64]()})}}},
+		{Route: "/me/location", Operation: openapi.Operation{Method: http.MethodDelete, Tag: "me", Security: userAuth, Summary: "Borra la ubicación",
+			Responses: []openapi.Response{ok(openapi.Fields{"status": typeOf[string]()})}}},
+		{Route: "/me/nearby", Operation: openapi.Operation{Method: http.MethodGet, Tag: "me", Security: userAuth,
+			Summary: "Usuarios y canales públicos cercanos", Params: []openapi.Param{query("radius", "Metros", typeOf[float64]())},
+			Responses: []openapi.Response{ok(openapi.Fields{
+				"radius": typeOf[float64](), "channel": typeOf[string](),
+				"members": typeOf[[]services.NearbyUser](), "channels": typeOf[[]services.ChannelSuggestion](),
+			})}}},
+		{Route: "/me/favorites", Operation: openapi.Operation{Method: http.MethodGet, Tag: "me", Security: userAuth, Summary: "Canales favoritos",
+			Responses: []openapi.Response{ok(openapi.Fields{"favorites": typeOf[[]string]()})}}},
+		{Route: "/me/favorites/{code}", Operation: openapi.Operation{Method: http.MethodPost, Tag: "me", Security: userAuth, Summary: "Marca un canal como favorito",
+			Responses: []openapi.Response{ok(favoriteFields)}}},
+		{Route: "/me/favorites/{code}", Operation: openapi.Operation{Method: http.MethodDelete, Tag: "me", Security: userAuth, Summary: "Quita un canal de favoritos",
+			Responses: []openapi.Response{ok(favoriteFields)}}},
+		{Route: "/devices", Operation: openapi.Operation{Method: http.MethodPost, Tag: "me", Security: userAuth,
+			Summary: "Registra el token FCM del dispositivo", Request: typeOf[registerDeviceRequest](),
+			Responses: []openapi.Response{created(openapi.Fields{"platform": typeOf[string](), "status": typeOf[string]()})}}},
+		{Route: "/e2ee/key", Operation: openapi.Operation{Method: http.MethodPut, Tag: "me", Security: userAuth,
+			Summary: "Registra la clave pública X25519", Request: typeOf[publicKeyRequest](),
+			Responses: []openapi.Response{ok(openapi.Fields{"algorithm": typeOf[string](), "status": typeOf[string]()})}}},
+		{Route: "/e2ee/key", Operation: openapi.Operation{Method: http.MethodDelete, Tag: "me", Security: userAuth, Summary: "Borra la clave pública",
+			Responses: []openapi.Response{noContent("Clave borrada")}}},
+		{Route: "/search", Operation: openapi.Operation{Method: http.MethodGet, Tag: "me", Security: userAuth,
+			Summary:   "Busca en las transcripciones de los canales del usuario",
+			Params:    []openapi.Param{{Name: "q", In: "query", Required: true}, query("limit", "", typeOf[int]())},
+			Responses: []openapi.Response{ok(openapi.Fields{"query": typeOf[string](), "results": typeOf[[]searchHit]()})}}},
+
+		// Administración
+		{Route: "/admin/memberships/bulk", Operation: openapi.Operation{Method: http.MethodPost, Tag: "admin", Security: adminAuth,
+			Summary: "Asigna miembros a canales en bloque (JSON o CSV user,channel[,action])",
+			Request: openapi.Fields{"rows": typeOf[[]services.MembershipAssignment]()}, RequestContent: []string{"application/json", "text/csv"},
+			Responses: []openapi.Response{ok(openapi.Raw{})}}},
+		{Route: "/admin/keys", Operation: openapi.Operation{Method: http.MethodGet, Tag: "admin", Security: adminAuth, Summary: "Claves de firma",
+			Responses: []openapi.Response{ok(openapi.Fields{"keys": typeOf[[]keyring.KeyInfo]()})}}},
+		{Route: "/admin/keys", Operation: openapi.Operation{Method: http.MethodPost, Tag: "admin", Security: adminAuth, Summary: "Rota la clave de firma primaria",
+			Responses: []openapi.Response{created(typeOf[keyring.KeyInfo]())}}},
+		{Route: "/admin/keys/{kid}", Operation: openapi.Operation{Method: http.MethodDelete, Tag: "admin", Security: adminAuth,
+			Summary: "Retira una clave de firma", Responses: []openapi.Response{ok(openapi.Fields{"status": typeOf[string](), "kid": typeOf[string]()})}}},
+		{Route: "/admin/channel-events", Operation: openapi.Operation{Method: http.MethodGet, Tag: "admin", Security: adminAuth,
+			Summary: "Reconstruye en qué canal estaba un usuario en un instante",
+			Params:  []openapi.Param{{Name: "user", In: "query", Required: true, Type: typeOf[uint]()}, query("at", "RFC3339", nil)},
+			Responses: []openapi.Response{ok(openapi.Fields{
+				"userId": typeOf[uint](), "at": typeOf[string](), "channel": typeOf[string](), "events": typeOf[[]models.ChannelEvent](),
+			})}}},
+		{Route: "/admin/ws-stats", Operation: openapi.Operation{Method: http.MethodGet, Tag: "admin", Security: adminAuth, Summary: "Estado de los WebSockets de la réplica",
+			Responses: []openapi.Response{ok(openapi.Raw{})}}},
+		{Route: "/admin/clients/reload", Operation: openapi.Operation{Method: http.MethodPost, Tag: "admin", Security: adminAuth,
+			Summary: "Vuelve a crear los clientes de STT, IA y TTS con la configuración actual",
+			Responses: []openapi.Response{ok(openapi.Fields{"status": typeOf[string](), "clients": typeOf[map[string]clientStatus]()})}}},
+		{Route: "/admin/overview", Operation: openapi.Operation{Method: http.MethodGet, Tag: "admin", Security: adminAuth, Summary: "Resumen en vivo para el panel de operaciones",
+			Responses: []openapi.Response{ok(openapi.Raw{})}}},
+		{Route: "/admin/audit", Operation: openapi.Operation{Method: http.MethodGet, Tag: "admin", Security: adminAuth, Summary: "Registro de auditoría",
+			Params: []openapi.Param{
+				query("user", "", typeOf[uint]()), query("channel", "", nil),
+				query("since", "RFC3339 o duración hacia atrás (24h)", nil), query("limit", "", typeOf[int]()),
+			},
+			Responses: []openapi.Response{ok(openapi.Fields{"count": typeOf[int](), "records": typeOf[[]services.AuditRecord]()})}}},
+		{Route: "/admin/channels", Operation: openapi.Operation{Method: http.MethodPost, Tag: "admin", Security: adminAuth, Summary: "Crea un canal",
+			Request: typeOf[services.ChannelInput](), Responses: []openapi.Response{created(typeOf[adminChannelView]())}}},
+		{Route: "/admin/channels/{code}", Operation: openapi.Operation{Method: http.MethodPut, Tag: "admin", Security: adminAuth,
+			Summary: "Actualiza nombre, capacidad o visibilidad de un canal",
+			Request: typeOf[services.ChannelInput](), Responses: []openapi.Response{ok(typeOf[adminChannelView]())}}},
+		{Route: "/admin/channels/{code}", Operation: openapi.Operation{Method: http.MethodDelete, Tag: "admin", Security: adminAuth,
+			Summary:   "Borra un canal expulsando a sus usuarios",
+			Responses: []openapi.Response{ok(openapi.Fields{"status": typeOf[string](), "code": typeOf[string](), "disconnected": typeOf[int]()})}}},
+		{Route: "/admin/channels/{code}/roles/{userID}", Operation: openapi.Operation{Method: http.MethodPut, Tag: "admin", Security: adminAuth,
+			Summary: "Asigna cualquier rol del canal, incluido owner", Params: []openapi.Param{idParam("userID")},
+			Request: typeOf[channelRoleRequest](), Responses: []openapi.Response{ok(channelRoleFields)}}},
+		{Route: "/admin/channels/{code}/roles/{userID}", Operation: openapi.Operation{Method: http.MethodDelete, Tag: "admin", Security: adminAuth,
+			Summary: "Devuelve a member a un usuario del canal", Params: []openapi.Param{idParam("userID")},
+			Responses: []openapi.Response{ok(channelRoleFields)}}},
+		{Route: "/admin/channels/{code}/recording", Operation: openapi.Operation{Method: http.MethodPost, Tag: "admin", Security: adminAuth,
+			Summary: "Inicia la grabación del canal", Responses: []openapi.Response{created(typeOf[recordingItem]()), {Status: http.StatusConflict, Description: "Ya se está grabando", Body: typeOf[recordingItem]()}}}},
+		{Route: "/admin/channels/{code}/recording", Operation: openapi.Operation{Method: http.MethodDelete, Tag: "admin", Security: adminAuth,
+			Summary: "Detiene la grabación del canal", Responses: []openapi.Response{ok(typeOf[recordingItem]())}}},
+		{Route: "/admin/intents", Operation: openapi.Operation{Method: http.MethodGet, Tag: "admin", Security: adminAuth, Summary: "Frases de los comandos de voz",
+			Responses: []openapi.Response{ok(typeOf[[]adminIntentPatternView]())}}},
+		{Route: "/admin/intents", Operation: openapi.Operation{Method: http.MethodPost, Tag: "admin", Security: adminAuth, Summary: "Crea una frase de comando",
+			Request: typeOf[services.IntentPatternInput](), Responses: []openapi.Response{created(typeOf[adminIntentPatternView]())}}},
+		{Route: "/admin/intents/{id}", Operation: openapi.Operation{Method: http.MethodPut, Tag: "admin", Security: adminAuth,
+			Summary: "Modifica una frase de comando", Params: []openapi.Param{idParam("id")},
+			Request: typeOf[services.IntentPatternInput](), Responses: []openapi.Response{ok(typeOf[adminIntentPatternView]())}}},
+		{Route: "/admin/intents/{id}", Operation: openapi.Operation{Method: http.MethodDelete, Tag: "admin", Security: adminAuth,
+			Summary: "Borra una frase de comando", Params: []openapi.Param{idParam("id")}, Responses: []openapi.Response{ok(statusID)}}},
+		{Route: "/admin/moderation-rules", Operation: openapi.Operation{Method: http.MethodGet, Tag: "admin", Security: adminAuth, Summary: "Reglas de moderación",
+			Responses: []openapi.Response{ok(typeOf[[]adminModerationRuleView]())}}},
+		{Route: "/admin/moderation-rules", Operation: openapi.Operation{Method: http.MethodPost, Tag: "admin", Security: adminAuth, Summary: "Crea una regla de moderación",
+			Request: typeOf[services.ModerationRuleInput](), Responses: []openapi.Response{created(typeOf[adminModerationRuleView]())}}},
+		{Route: "/admin/moderation-rules/{id}", Operation: openapi.Operation{Method: http.MethodPut, Tag: "admin", Security: adminAuth,
+			Summary: "Modifica una regla de moderación", Params: []openapi.Param{idParam("id")},
+			Request: typeOf[services.ModerationRuleInput](), Responses: []openapi.Response{ok(typeOf[adminModerationRuleView]())}}},
+		{Route: "/admin/moderation-rules/{id}", Operation: openapi.Operation{Method: http.MethodDelete, Tag: "admin", Security: adminAuth,
+			Summary: "Borra una regla de moderación", Params: []openapi.Param{idParam("id")}, Responses: []openapi.Response{ok(statusID)}}},
+		{Route: "/admin/announcements", Operation: openapi.Operation{Method: http.MethodGet, Tag: "admin", Security: adminAuth, Summary: "Anuncios programados",
+			Responses: []openapi.Response{ok(typeOf[[]announcementView]())}}},
+		{Route: "/admin/announcements", Operation: openapi.Operation{Method: http.MethodPost, Tag: "admin", Security: adminAuth, Summary: "Programa un anuncio",
+			Request: typeOf[announcementRequest](), Responses: []openapi.Response{created(typeOf[announcementView]())}}},
+		{Route: "/admin/announcements/{id}", Operation: openapi.Operation{Method: http.MethodDelete, Tag: "admin", Security: adminAuth,
+			Summary: "Cancela un anuncio", Params: []openapi.Param{idParam("id")}, Responses: []openapi.Response{ok(statusID)}}},
+		{Route: "/admin/webhooks", Operation: openapi.Operation{Method: http.MethodGet, Tag: "admin", Security: adminAuth, Summary: "Webhooks de entrada",
+			Responses: []openapi.Response{ok(typeOf[[]webhookView]())}}},
+		{Route: "/admin/webhooks", Operation: openapi.Operation{Method: http.MethodPost, Tag: "admin", Security: adminAuth,
+			Summary: "Crea un webhook de entrada; el token sólo se muestra ahora",
+			Request: typeOf[webhookRequest](), Responses: []openapi.Response{created(typeOf[webhookView]())}}},
+		{Route: "/admin/webhooks/{id}", Operation: openapi.Operation{Method: http.MethodDelete, Tag: "admin", Security: adminAuth,
+			Summary: "Revoca un webhook de entrada", Params: []openapi.Param{idParam("id")}, Responses: []openapi.Response{ok(statusID)}}},
+		{Route: "/admin/webhook-subscriptions", Operation: openapi.Operation{Method: http.MethodGet, Tag: "admin", Security: adminAuth, Summary: "Suscripciones de webhooks de salida",
+			Responses: []openapi.Response{ok(typeOf[[]webhookSubscriptionView]())}}},
+		{Route: "/admin/webhook-subscriptions", Operation: openapi.Operation{Method: http.MethodPost, Tag: "admin", Security: adminAuth,
+			Summary: "Suscribe una URL a los eventos de canal firmados con HMAC",
+			Request: typeOf[webhookSubscriptionRequest](), Responses: []openapi.Response{created(typeOf[webhookSubscriptionView]())}}},
+		{Route: "/admin/webhook-subscriptions/{id}", Operation: openapi.Operation{Method: http.MethodDelete, Tag: "admin", Security: adminAuth,
+			Summary: "Borra una suscripción", Params: []openapi.Param{idParam("id")}, Responses: []openapi.Response{ok(statusID)}}},
+		{Route: "/admin/webhook-dead-letters", Operation: openapi.Operation{Method: http.MethodGet, Tag: "admin", Security: adminAuth,
+			Summary:   "Envíos que agotaron los reintentos",
+			Params:    []openapi.Param{query("subscription", "", typeOf[uint]()), query("limit", "", typeOf[int]())},
+			Responses: []openapi.Response{ok(typeOf[[]map[string]any]())}}},
+		{Route: "/admin/webhook-dead-letters/{id}/retry", Operation: openapi.Operation{Method: http.MethodPost, Tag: "admin", Security: adminAuth,
+			Summary: "Reenvía un envío fallido", Params: []openapi.Param{idParam("id")}, Responses: []openapi.Response{ok(statusID)}}},
+
+		// Integraciones
+		{Route: "/integrations/webhooks/{token}", Operation: openapi.Operation{Method: http.MethodPost, Tag: "integrations",
+			Summary: "Publica un aviso en el canal del webhook, como texto o audio",
+			Params: []openapi.Param{
+				header("X-Alert-Text", "Transcripción del audio enviado"),
+				header("X-Alert-Priority", "normal, urgent o emergency"),
+			},
+			Request: typeOf[webhookAlert](), RequestContent: []string{"application/json", "audio/wav", "audio/ogg"},
+			Responses: []openapi.Response{{Status: http.StatusAccepted, Body: openapi.Fields{
+				"status": typeOf[string](), "channel": typeOf[string](), "priority": typeOf[string](), "recipients": typeOf[int](),
+			}}}}},
+		{Route: "/telephony/twilio/voice", Operation: openapi.Operation{Method: http.MethodPost, Tag: "integrations",
+			Summary: "Webhook de Twilio para llamadas entrantes", Params: []openapi.Param{header("X-Twilio-Signature", "Firma de Twilio")},
+			Request: openapi.Raw{}, RequestContent: []string{"application/x-www-form-urlencoded"},
+			Responses: []openapi.Response{{Status: http.StatusOK, Content: "text/xml", Body: openapi.Raw{}}}}},
+		{Route: "/telephony/twilio/gather", Operation: openapi.Operation{Method: http.MethodPost, Tag: "integrations",
+			Summary: "Webhook de Twilio con el código de canal marcado", Params: []openapi.Param{header("X-Twilio-Signature", "Firma de Twilio")},
+			Request: openapi.Raw{}, RequestContent: []string{"application/x-www-form-urlencoded"},
+			Responses: []openapi.Response{{Status: http.StatusOK, Content: "text/xml", Body: openapi.Raw{}}}}},
+		{Route: "/telephony/twilio/stream", Operation: openapi.Operation{Method: http.MethodGet, Tag: "integrations",
+			Summary:   "WebSocket de Twilio Media Streams",
+			Responses: []openapi.Response{{Status: http.StatusSwitchingProtocols, Description: "Conexión WebSocket"}}}},
+
+		// Operación
+		{Route: "/healthz", Operation: openapi.Operation{Method: http.MethodGet, Tag: "ops", Summary: "Proceso vivo",
+			Responses: []openapi.Response{ok(openapi.Fields{"status": typeOf[string](), "uptime_seconds": typeOf[int64]()})}}},
+		{Route: "/readyz", Operation: openapi.Operation{Method: http.MethodGet, Tag: "ops", Summary: "Dependencias listas",
+			Responses: []openapi.Response{
+				ok(openapi.Fields{"status": typeOf[string](), "checks": typeOf[map[string]dependencyStatus](), "clients": typeOf[map[string]clientStatus]()}),
+				{Status: http.StatusServiceUnavailable, Description: "Alguna dependencia falla", Body: openapi.Fields{"status": typeOf[string](), "checks": typeOf[map[string]dependencyStatus](), "clients": typeOf[map[string]clientStatus]()}},
+			}}},
+		{Route: "/metrics", Operation: openapi.Operation{Method: http.MethodGet, Tag: "ops", Summary: "Métricas en formato Prometheus",
+			Responses: []openapi.Response{{Status: http.StatusOK, Content: "text/plain", Body: openapi.Raw{}}}}},
+	}
+}
+
+var (
+	channelRoleFields = openapi.Fields{"channel": typeOf[string](), "user_id": typeOf[uint](), "role": typeOf[string]()}
+	channelMuteFields = openapi.Fields{"channel": typeOf[string](), "user_id": typeOf[uint](), "muted": typeOf[bool]()}
+	favoriteFields    = openapi.Fields{"channel": typeOf[string](), "favorite": typeOf[bool]()}
+)
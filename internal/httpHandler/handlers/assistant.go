@@ -0,0 +1,170 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"log"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"walkie-backend/internal/ai"
+	"walkie-backend/internal/config"
+	"walkie-backend/internal/metrics"
+	"walkie-backend/internal/models"
+	"walkie-backend/internal/services"
+	"walkie-backend/pkg/qwen"
+)
+
+const (
+	// AssistantSenderID es el emisor de los clips del asistente; no es ningún usuario
+	AssistantSenderID uint = 0
+
+	defaultAssistantNames    = "asistente"
+	defaultAssistantCooldown = 30 * time.Second
+	assistantTimeout         = 60 * time.Second
+	assistantContextLines    = 10
+	assistantDisplayName     = "Asistente"
+)
+
+var errAssistantUnsupported = errors.New("el proveedor de IA no permite responder como asistente")
+
+// assistantAnswer genera la respuesta del asistente; los tests lo sustituyen
+var assistantAnswer = answerWithAI
+
+// synthesizeSpeech convierte la respuesta en audio WAV; los tests lo sustituyen
+var synthesizeSpeech = func(ctx context.Context, text string) ([]byte, error) {
+	client, err := EnsureTTSClient()
+	if err != nil {
+		return nil, err
+	}
+	return client.Synthesize(ctx, text)
+}
+
+var assistantTurns = struct {
+	sync.Mutex
+	last map[string]time.Time
+}{
+	last: make(map[string]time.Time),
+}
+
+// assistantNames son los nombres con los que se llama al asistente (ASSISTANT_NAMES,
+// separados por comas; "asistente" por defecto)
+func assistantNames() []string {
+	raw := os.Getenv("ASSISTANT_NAMES")
+	if strings.TrimSpace(raw) == "" {
+		raw = defaultAssistantNames
+	}
+	var names []string
+	for _, n := range strings.Split(raw, ",") {
+		if n = qwen.NormalizePhrase(n); n != "" {
+			names = append(names, n)
+		}
+	}
+	return names
+}
+
+// assistantQuestion devuelve la pregunta si el canal tiene asistente y la transcripción
+// empieza por su nombre
+func assistantQuestion(user *models.User, text string) (string, bool) {
+	if !user.IsInChannel() || !user.CurrentChannel.Assistant {
+		return "", false
+	}
+	question, ok := stripWakeWord(text, assistantNames())
+	if !ok || question == "" {
+		return "", false
+	}
+	return question, true
+}
+
+// reserveAssistantTurn aplica ASSISTANT_COOLDOWN (30 s por defecto) entre respuestas de
+// un mismo canal para que no se pueda usar el asistente para inundarlo
+func reserveAssistantTurn(channel string, now time.Time) bool {
+	cooldown := config.DurationFromEnv("ASSISTANT_COOLDOWN", defaultAssistantCooldown)
+	assistantTurns.Lock()
+	defer assistantTurns.Unlock()
+	if last, ok := assistantTurns.last[channel]; ok && now.Sub(last) < cooldown {
+		return false
+	}
+	assistantTurns.last[channel] = now
+	return true
+}
+
+// maybeAskAssistant lanza en segundo plano la respuesta del asistente cuando se le
+// nombra; la pregunta ya se ha difundido como un mensaje más
+func maybeAskAssistant(user *models.User, text string) {
+	question, ok := assistantQuestion(user, text)
+	if !ok {
+		return
+	}
+	channel := user.GetCurrentChannelCode()
+	if !reserveAssistantTurn(channel, time.Now()) {
+		log.Printf("[ASISTENTE] canal=%s usuario=%d en espera, pregunta ignorada", channel, user.ID)
+		metrics.Inc("walkie_assistant_requests_total", map[string]string{"result": "cooldown"})
+		return
+	}
+	go answerInChannel(channel, user.ID, question)
+}
+
+// answerInChannel genera la respuesta, la sintetiza y la difunde en el canal como si
+// el asistente fuera otro miembro
+func answerInChannel(channel string, askerID uint, question string) {
+	ctx, cancel := context.WithTimeout(context.Background(), assistantTimeout)
+	defer cancel()
+
+	start := time.Now()
+	answer, err := assistantAnswer(ctx, channel, question)
+	if err != nil {
+		log.Printf("[ASISTENTE] canal=%s usuario=%d error_ia=%v", channel, askerID, err)
+		metrics.Inc("walkie_assistant_requests_total", map[string]string{"result": "ai_error"})
+		return
+	}
+	audio, err := synthesizeSpeech(ctx, answer)
+	if err != nil {
+		log.Printf("[ASISTENTE] canal=%s usuario=%d error_tts=%v", channel, askerID, err)
+		metrics.Inc("walkie_assistant_requests_total", map[string]string{"result": "tts_error"})
+		return
+	}
+
+	duration := estimateAudioDuration(audio)
+	recipients := []uint{}
+	if members, err := services.NewUserService().GetChannelActiveUsers(channel); err != nil {
+		log.Printf("[ASISTENTE] canal=%s error obteniendo miembros: %v", channel, err)
+	} else {
+		for _, m := range members {
+			recipients = append(recipients, m.ID)
+		}
+	}
+	broadcastAudio(channel, AssistantSenderID, audio)
+	EnqueueAudioWithPriority(AssistantSenderID, channel, audio, duration.Seconds(), recipients, PriorityNormal)
+	recordChannelTranscript(&models.User{DisplayName: assistantDisplayName}, channel, answer, PriorityNormal)
+
+	metrics.Inc("walkie_assistant_requests_total", map[string]string{"result": "ok"})
+	metrics.Observe("walkie_assistant_latency", nil, time.Since(start))
+	log.Printf("[ASISTENTE] canal=%s usuario=%d respuesta=%q audio_bytes=%d", channel, askerID, answer, len(audio))
+}
+
+// answerWithAI responde con el proveedor de IA usando la conversación reciente del canal
+func answerWithAI(ctx context.Context, channel, question string) (string, error) {
+	analyzer, err := EnsureAIClient()
+	if err != nil {
+		return "", err
+	}
+	assistant, ok := analyzer.(ai.Assistant)
+	if !ok {
+		return "", errAssistantUnsupported
+	}
+
+	var lines []string
+	if config.DB != nil && config.DBAvailable() {
+		items, err := services.RecentTranscripts(config.DB, channel, assistantContextLines)
+		if err != nil {
+			log.Printf("[ASISTENTE] canal=%s sin contexto: %v", channel, err)
+		}
+		for _, t := range items {
+			lines = append(lines, summaryLine(t))
+		}
+	}
+	return assistant.Answer(ctx, question, lines)
+}
@@ -0,0 +1,79 @@
+package handlers
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"walkie-backend/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+func TestAssistantQuestion_OnlyInChannelsWithAssistant(t *testing.T) {
+	channelID := uint(1)
+	user := &models.User{Model: gorm.Model{ID: 670}, CurrentChannelID: &channelID, CurrentChannel: &models.Channel{Code: "canal-1"}}
+
+	_, ok := assistantQuestion(user, "asistente, ¿cuál es el estado?")
+	assert.False(t, ok, "el asistente es opcional por canal")
+
+	user.CurrentChannel.Assistant = true
+	question, ok := assistantQuestion(user, "Asistente, ¿cuál es el estado?")
+	assert.True(t, ok)
+	assert.Equal(t, "cuál es el estado?", question)
+
+	_, ok = assistantQuestion(user, "el asistente no contesta")
+	assert.False(t, ok)
+	_, ok = assistantQuestion(user, "asistente")
+	assert.False(t, ok, "sin pregunta no hay respuesta")
+}
+
+func TestReserveAssistantTurn_Cooldown(t *testing.T) {
+	t.Setenv("ASSISTANT_COOLDOWN", "10s")
+	now := time.Now()
+	assert.True(t, reserveAssistantTurn("cooldown-1", now))
+	assert.False(t, reserveAssistantTurn("cooldown-1", now.Add(5*time.Second)))
+	assert.True(t, reserveAssistantTurn("cooldown-2", now.Add(5*time.Second)), "cada canal tiene su turno")
+	assert.True(t, reserveAssistantTurn("cooldown-1", now.Add(11*time.Second)))
+}
+
+func TestAnswerInChannel_BroadcastsSynthesizedAnswer(t *testing.T) {
+	db := setupTestDB(t)
+	asker := createTestUser(t, db, 671, "tok-671", "asist-1")
+	listener := &models.User{Model: gorm.Model{ID: 672}, DisplayName: "Lola", IsActive: true, CurrentChannelID: asker.CurrentChannelID}
+	require.NoError(t, db.Create(listener).Error)
+	for _, id := range []uint{asker.ID, listener.ID} {
+		require.NoError(t, db.Create(&models.ChannelMembership{UserID: id, ChannelID: *asker.CurrentChannelID, Active: true}).Error)
+	}
+	t.Cleanup(func() {
+		ClearPendingAudio(asker.ID)
+		ClearPendingAudio(listener.ID)
+	})
+
+	origAnswer, origSynth := assistantAnswer, synthesizeSpeech
+	t.Cleanup(func() { assistantAnswer, synthesizeSpeech = origAnswer, origSynth })
+	var question string
+	assistantAnswer = func(_ context.Context, channel, q string) (string, error) {
+		question = q
+		return "Todo en orden en " + channel, nil
+	}
+	wav := buildTestWAV(8000)
+	var spoken string
+	synthesizeSpeech = func(_ context.Context, text string) ([]byte, error) {
+		spoken = text
+		return wav, nil
+	}
+
+	answerInChannel("asist-1", asker.ID, "cuál es el estado?")
+
+	assert.Equal(t, "cuál es el estado?", question)
+	assert.Equal(t, "Todo en orden en asist-1", spoken)
+	for _, id := range []uint{asker.ID, listener.ID} {
+		clip := DequeueAudio(id)
+		require.NotNil(t, clip, "usuario %d debe oír la respuesta", id)
+		assert.Equal(t, AssistantSenderID, clip.SenderID)
+		assert.Equal(t, "asist-1", clip.Channel)
+	}
+}
@@ -0,0 +1,83 @@
+package handlers
+
+import (
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"walkie-backend/internal/config"
+	"walkie-backend/internal/metrics"
+	"walkie-backend/internal/response"
+)
+
+const (
+	defaultAudioAckTimeout    = 30 * time.Second
+	defaultAudioMaxDeliveries = 3
+	deliveryIDBytes           = 12
+)
+
+// ackRequested indica si el cliente confirma cada clip (X-Audio-Ack: true); sin esa
+// cabecera el polling sigue entregando cada clip una sola vez
+func ackRequested(r *http.Request) bool {
+	return strings.EqualFold(strings.TrimSpace(r.Header.Get("X-Audio-Ack")), "true")
+}
+
+// leaseAudio entrega el siguiente clip del usuario sin sacarlo de la cola. Si el ACK no
+// llega en AUDIO_ACK_TIMEOUT vuelve a ser visible, hasta AUDIO_MAX_DELIVERIES entregas.
+func leaseAudio(userID uint) *PendingAudio {
+	id, err := generateToken(deliveryIDBytes)
+	if err != nil {
+		log.Printf("[ACK] usuario=%d no se pudo generar el ID de entrega: %v", userID, err)
+		return nil
+	}
+	until := time.Now().Add(config.DurationFromEnv("AUDIO_ACK_TIMEOUT", defaultAudioAckTimeout))
+	maxAttempts := config.IntFromEnv("AUDIO_MAX_DELIVERIES", defaultAudioMaxDeliveries)
+	audio, err := audioStore().Lease(userID, id, until, maxAttempts)
+	if err != nil {
+		log.Printf("Error desencolando audio para usuario %d: %v", userID, err)
+		return nil
+	}
+	return audio
+}
+
+// dropUnackedAudio anota el clip que se descarta tras AUDIO_MAX_DELIVERIES entregas sin ACK
+func dropUnackedAudio(userID uint, audio *PendingAudio) {
+	log.Printf("[ACK] usuario=%d clip de usuario %d descartado tras %d entregas sin confirmar", userID, audio.SenderID, audio.Attempts)
+	metrics.Inc("walkie_audio_unacked_total", nil)
+}
+
+// ackDelivery confirma la entrega y borra el clip; sólo el destinatario puede confirmarla
+func ackDelivery(userID uint, id string) bool {
+	ok, err := audioStore().Ack(userID, id)
+	if err != nil {
+		log.Printf("[ACK] usuario=%d error confirmando entrega: %v", userID, err)
+		return false
+	}
+	return ok
+}
+
+// setDeliveryHeaders pone X-Delivery-ID y X-Audio-Attempt del clip entregado
+func setDeliveryHeaders(w http.ResponseWriter, audio *PendingAudio) {
+	w.Header().Set("X-Delivery-ID", audio.DeliveryID)
+	w.Header().Set("X-Audio-Attempt", strconv.Itoa(audio.Attempts))
+}
+
+// POST /audio/ack/{id}
+// Confirma que el cliente recibió y reprodujo el clip entregado con ese X-Delivery-ID
+func AudioAck(w http.ResponseWriter, r *http.Request) {
+	if !requireDB(w) {
+		return
+	}
+	user, err := resolveUserFromRequest(r)
+	if err != nil {
+		response.WriteErr(w, http.StatusUnauthorized, "Token inválido o expirado")
+		return
+	}
+	if !ackDelivery(user.ID, r.PathValue("id")) {
+		response.WriteErr(w, http.StatusNotFound, "Entrega no encontrada o caducada")
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,93 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+
+	"walkie-backend/internal/models"
+)
+
+func pollWithAck(t *testing.T, user *models.User) *httptest.ResponseRecorder {
+	t.Helper()
+	deps := newAudioPollDeps()
+	deps.resolveUser = func(*http.Request) (*models.User, error) { return user, nil }
+	deps.newUserService = func() userService { return &mockUserService{user: user} }
+	req := httptest.NewRequest(http.MethodGet, "/audio/poll", nil)
+	req.Header.Set("X-Audio-Ack", "true")
+	rec := httptest.NewRecorder()
+	runAudioPoll(rec, req, deps)
+	return rec
+}
+
+func TestAudioPoll_RedeliversUnackedAudio(t *testing.T) {
+	t.Setenv("AUDIO_ACK_TIMEOUT", "30ms")
+	user := &models.User{Model: gorm.Model{ID: 610}}
+	defer ClearPendingAudio(user.ID)
+	require.NoError(t, EnqueueDirectAudio(2, user.ID, buildTestWAV(320), 1, PriorityNormal))
+
+	rec := pollWithAck(t, user)
+	require.Equal(t, http.StatusOK, rec.Code)
+	first := rec.Header().Get("X-Delivery-ID")
+	assert.NotEmpty(t, first)
+	assert.Equal(t, "1", rec.Header().Get("X-Audio-Attempt"))
+
+	// Mientras espera el ACK no se vuelve a entregar, pero sigue en la cola
+	assert.Equal(t, http.StatusNoContent, pollWithAck(t, user).Code)
+	status, err := audioStore().Status(user.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 1, status.Depth)
+
+	time.Sleep(50 * time.Millisecond)
+	rec = pollWithAck(t, user)
+	require.Equal(t, http.StatusOK, rec.Code)
+	second := rec.Header().Get("X-Delivery-ID")
+	assert.NotEqual(t, first, second)
+	assert.Equal(t, "2", rec.Header().Get("X-Audio-Attempt"))
+
+	assert.False(t, ackDelivery(user.ID, first), "el ID de la primera entrega ya no vale")
+	assert.False(t, ackDelivery(611, second), "sólo el destinatario confirma")
+	assert.True(t, ackDelivery(user.ID, second))
+	time.Sleep(50 * time.Millisecond)
+	assert.Equal(t, http.StatusNoContent, pollWithAck(t, user).Code)
+}
+
+func TestAudioPoll_DropsAfterMaxDeliveries(t *testing.T) {
+	t.Setenv("AUDIO_ACK_TIMEOUT", "10ms")
+	t.Setenv("AUDIO_MAX_DELIVERIES", "1")
+	user := &models.User{Model: gorm.Model{ID: 612}}
+	defer ClearPendingAudio(user.ID)
+	require.NoError(t, EnqueueDirectAudio(2, user.ID, buildTestWAV(320), 1, PriorityNormal))
+
+	require.Equal(t, http.StatusOK, pollWithAck(t, user).Code)
+	time.Sleep(30 * time.Millisecond)
+	assert.Equal(t, http.StatusNoContent, pollWithAck(t, user).Code)
+	status, err := audioStore().Status(user.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 0, status.Depth)
+}
+
+func TestAudioAck_Handler(t *testing.T) {
+	db := setupTestDB(t)
+	user := createTestUser(t, db, 613, "token-ack", "")
+	defer ClearPendingAudio(user.ID)
+	require.NoError(t, EnqueueDirectAudio(2, user.ID, buildTestWAV(320), 1, PriorityNormal))
+	pending := leaseAudio(user.ID)
+	require.NotNil(t, pending)
+
+	ack := func(id string) int {
+		req := httptest.NewRequest(http.MethodPost, "/audio/ack/"+id, nil)
+		req.SetPathValue("id", id)
+		req = req.WithContext(withAuthUser(req.Context(), user))
+		rec := httptest.NewRecorder()
+		AudioAck(rec, req)
+		return rec.Code
+	}
+	assert.Equal(t, http.StatusNoContent, ack(pending.DeliveryID))
+	assert.Equal(t, http.StatusNotFound, ack(pending.DeliveryID))
+}
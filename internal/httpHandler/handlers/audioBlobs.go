@@ -0,0 +1,51 @@
+package handlers
+
+import (
+	"log"
+
+	"walkie-backend/internal/services"
+	"walkie-backend/pkg/objstore"
+)
+
+// offloadPendingAudio sube el clip al almacenamiento de objetos para que la cola sólo
+// guarde la clave. Todos los destinatarios comparten el mismo objeto. Si la subida
+// falla el audio se queda en la cola como siempre.
+func offloadPendingAudio(pending *PendingAudio) {
+	store := services.AudioBlobStore()
+	if store == nil || len(pending.AudioData) == 0 {
+		return
+	}
+	key := services.NewBlobKey("queue", pending.Format)
+	if err := services.PutBlob(store, key, pending.AudioData, clipMimeType(pending.Format)); err != nil {
+		log.Printf("[BLOBS] no se pudo subir el clip de usuario %d: %v", pending.SenderID, err)
+		return
+	}
+	pending.ObjectKey = key
+	pending.ObjectSize = len(pending.AudioData)
+	pending.AudioData = nil
+}
+
+// pendingAudioURL devuelve la URL prefirmada del clip, o "" si el audio viaja en la respuesta
+func pendingAudioURL(pending *PendingAudio) string {
+	if pending.ObjectKey == "" {
+		return ""
+	}
+	link, err := services.PresignBlob(pending.ObjectKey)
+	if err != nil {
+		log.Printf("[BLOBS] no se pudo firmar %s: %v", pending.ObjectKey, err)
+		return ""
+	}
+	return link
+}
+
+// pendingAudioBytes devuelve el audio del clip, descargándolo del bucket si hace falta
+func pendingAudioBytes(pending *PendingAudio) ([]byte, error) {
+	if pending.ObjectKey == "" {
+		return pending.AudioData, nil
+	}
+	store := services.AudioBlobStore()
+	if store == nil {
+		return nil, objstore.ErrNotConfigured
+	}
+	return services.GetBlob(store, pending.ObjectKey)
+}
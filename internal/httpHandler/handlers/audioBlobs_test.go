@@ -0,0 +1,116 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+
+	"walkie-backend/internal/models"
+	"walkie-backend/internal/services"
+	"walkie-backend/pkg/objstore"
+)
+
+type memoryBlobStore struct {
+	mu      sync.Mutex
+	objects map[string][]byte
+}
+
+func useMemoryBlobStore(t *testing.T) *memoryBlobStore {
+	store := &memoryBlobStore{objects: map[string][]byte{}}
+	services.SetAudioBlobStore(store)
+	t.Cleanup(func() { services.SetAudioBlobStore(nil) })
+	return store
+}
+
+func (m *memoryBlobStore) Put(_ context.Context, key string, data []byte, _ string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.objects[key] = data
+	return nil
+}
+
+func (m *memoryBlobStore) Get(_ context.Context, key string) ([]byte, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	data, ok := m.objects[key]
+	if !ok {
+		return nil, objstore.ErrNotFound
+	}
+	return data, nil
+}
+
+func (m *memoryBlobStore) Delete(_ context.Context, key string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.objects, key)
+	return nil
+}
+
+func (m *memoryBlobStore) PresignGet(key string, _ time.Duration) (string, error) {
+	return "https://bucket.example/" + key + "?X-Amz-Signature=abc", nil
+}
+
+func TestAudioPoll_ObjectStoreReturnsPresignedURL(t *testing.T) {
+	store := useMemoryBlobStore(t)
+	user := &models.User{Model: gorm.Model{ID: 659}}
+	defer ClearPendingAudio(user.ID)
+	clip := buildTestWAV(320)
+	require.NoError(t, EnqueueDirectAudio(4, user.ID, clip, 1, PriorityNormal))
+	require.NoError(t, EnqueueDirectAudio(5, user.ID, clip, 1, PriorityNormal))
+	require.Len(t, store.objects, 2)
+
+	status, err := audioStore().Status(user.ID)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2*len(clip)), status.Bytes)
+
+	deps := newAudioPollDeps()
+	deps.resolveUser = func(*http.Request) (*models.User, error) { return user, nil }
+	deps.newUserService = func() userService { return &mockUserService{user: user} }
+	rec := httptest.NewRecorder()
+	runAudioPoll(rec, httptest.NewRequest(http.MethodGet, "/audio/poll", nil), deps)
+	require.Equal(t, http.StatusSeeOther, rec.Code)
+	link := rec.Header().Get("Location")
+	assert.True(t, strings.HasPrefix(link, "https://bucket.example/queue/"), link)
+	assert.Equal(t, link, rec.Header().Get("X-Audio-URL"))
+	assert.Equal(t, "4", rec.Header().Get("X-Audio-From"))
+	assert.Zero(t, rec.Body.Len())
+
+	rec = pollBatch(t, user, "", false)
+	require.Equal(t, http.StatusOK, rec.Code)
+	var body batchBody
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+	require.Equal(t, 1, body.Count)
+	assert.Empty(t, body.Audios[0].AudioBase64)
+	assert.Contains(t, body.Audios[0].AudioURL, "https://bucket.example/queue/")
+}
+
+func TestDBAudioStore_KeepsObjectKeys(t *testing.T) {
+	db := setupTestDB(t)
+	require.NoError(t, db.AutoMigrate(&models.QueuedAudio{}))
+	useMemoryBlobStore(t)
+	store := NewDBAudioStore(db)
+
+	pending := newPendingAudio(6, "canal-2", buildTestWAV(320), 1, PriorityNormal)
+	require.NotEmpty(t, pending.ObjectKey)
+	require.NoError(t, store.Enqueue(660, pending))
+
+	status, err := store.Status(660)
+	require.NoError(t, err)
+	assert.Equal(t, int64(44+320), status.Bytes)
+
+	got, err := store.Dequeue(660)
+	require.NoError(t, err)
+	assert.Equal(t, pending.ObjectKey, got.ObjectKey)
+	data, err := pendingAudioBytes(got)
+	require.NoError(t, err)
+	assert.Equal(t, buildTestWAV(320), data)
+}
@@ -1,6 +1,7 @@
 package handlers
 
 import (
+	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
@@ -14,12 +15,17 @@ import (
 	"time"
 	"unicode"
 
+	"walkie-backend/internal/ai"
 	"walkie-backend/internal/config"
+	"walkie-backend/internal/keyring"
 	"walkie-backend/internal/models"
 	"walkie-backend/internal/services"
-	"walkie-backend/pkg/qwen"
+	"walkie-backend/pkg/audio"
 )
 
+// opusBytesPerSecond aproxima 24 kbps, el bitrate de voz habitual en clientes móviles
+const opusBytesPerSecond = 3000.0
+
 var (
 	tokenTTLOnce sync.Once
 	tokenTTL     time.Duration
@@ -43,17 +49,26 @@ type CommandResponse struct {
 }
 
 // executeCommand ejecuta un comando específico
-func executeCommand(user *models.User, userService *services.UserService, result qwen.CommandResult) (CommandResponse, error) {
+func executeCommand(user *models.User, svc userService, result ai.CommandResult) (CommandResponse, error) {
+	if svc == nil {
+		return CommandResponse{}, fmt.Errorf("servicio de usuarios no disponible")
+	}
 	switch result.Intent {
 	case "request_channel_list":
-		return handleChannelListCommand(userService)
+		return handleChannelListCommand(user, svc)
 	case "request_channel_connect":
 		if len(result.Channels) == 0 {
-			return CommandResponse{}, fmt.Errorf("no se especificó canal para conectar")
+			return askForChannel(user, svc), nil
 		}
-		return handleChannelConnectCommand(user, userService, result.Channels[0])
+		return handleChannelConnectCommand(user, svc, result.Channels[0])
 	case "request_channel_disconnect":
-		return handleChannelDisconnectCommand(user, userService)
+		return handleChannelDisconnectCommand(user, svc)
+	case "request_user_list":
+		return handleUserListCommand(user, svc)
+	case "request_current_channel":
+		return handleCurrentChannelCommand(user, svc)
+	case intentFavoriteChannel:
+		return handleFavoriteChannelCommand(user, svc)
 	default:
 		return CommandResponse{
 			Status:  "ok",
@@ -63,22 +78,44 @@ func executeCommand(user *models.User, userService *services.UserService, result
 	}
 }
 
-// handleChannelListCommand maneja el comando de listar canales
-func handleChannelListCommand(userService *services.UserService) (CommandResponse, error) {
-	channels, err := userService.GetAvailableChannels()
+// handleChannelListCommand maneja el comando de listar canales; los favoritos del usuario
+// van primero
+func handleChannelListCommand(user *models.User, svc userService) (CommandResponse, error) {
+	channels, err := svc.GetAvailableChannels()
 	if err != nil {
 		return CommandResponse{}, fmt.Errorf("error obteniendo canales: %w", err)
 	}
 
-	channelNames := make([]string, 0, len(channels))
-	channelCodes := make([]string, 0, len(channels))
+	available := make(map[string]bool, len(channels))
+	for _, ch := range channels {
+		available[ch.Code] = true
+	}
+	favorites := make([]string, 0)
+	isFavorite := make(map[string]bool)
+	for _, code := range loadFavoriteChannels(user.ID) {
+		if available[code] {
+			favorites = append(favorites, code)
+			isFavorite[code] = true
+		}
+	}
+
+	channelCodes := append(make([]string, 0, len(channels)), favorites...)
+	var others []string
 	for _, ch := range channels {
-		channelCodes = append(channelCodes, ch.Code)
-		channelNames = append(channelNames, strings.TrimPrefix(ch.Code, "canal-"))
+		if !isFavorite[ch.Code] {
+			channelCodes = append(channelCodes, ch.Code)
+			others = append(others, channelLabel(ch.Code))
+		}
 	}
+	channelNames := channelLabels(channelCodes)
 
 	message := "No hay canales disponibles"
-	if len(channelNames) > 0 {
+	switch {
+	case len(favorites) > 0 && len(others) > 0:
+		message = fmt.Sprintf("Tus favoritos: %s. %s", joinSpokenList(channelLabels(favorites)), buildChannelListPhrase(others))
+	case len(favorites) > 0:
+		message = fmt.Sprintf("Tus favoritos: %s", joinSpokenList(channelLabels(favorites)))
+	case len(channelNames) > 0:
 		message = buildChannelListPhrase(channelNames)
 	}
 
@@ -89,6 +126,7 @@ func handleChannelListCommand(userService *services.UserService) (CommandRespons
 		Data: map[string]any{
 			"channels":      channelCodes,
 			"channel_names": channelNames,
+			"favorites":     favorites,
 		},
 	}, nil
 }
@@ -116,14 +154,119 @@ func buildChannelListPhrase(names []string) string {
 	}
 }
 
+// handleUserListCommand responde quién está conectado al canal actual del usuario
+func handleUserListCommand(user *models.User, svc userService) (CommandResponse, error) {
+	if !user.IsInChannel() {
+		return CommandResponse{
+			Status:  "ok",
+			Intent:  "request_user_list",
+			Message: "No estás conectado a ningún canal",
+		}, nil
+	}
+
+	channelCode := user.GetCurrentChannelCode()
+	users, err := svc.GetChannelActiveUsers(channelCode)
+	if err != nil {
+		return CommandResponse{}, fmt.Errorf("error obteniendo usuarios del canal: %w", err)
+	}
+
+	channelNum := channelLabel(channelCode)
+	names := make([]string, 0, len(users))
+	ids := make([]uint, 0, len(users))
+	for _, u := range users {
+		ids = append(ids, u.ID)
+		if u.ID != user.ID {
+			names = append(names, u.DisplayName)
+		}
+	}
+
+	var message string
+	switch len(names) {
+	case 0:
+		message = fmt.Sprintf("En el canal %s solo estás tú", channelNum)
+	case 1:
+		message = fmt.Sprintf("En el canal %s está %s", channelNum, names[0])
+	default:
+		message = fmt.Sprintf("En el canal %s están %s", channelNum, joinSpokenList(names))
+	}
+
+	return CommandResponse{
+		Status:  "ok",
+		Intent:  "request_user_list",
+		Message: message,
+		Data: map[string]any{
+			"channel":  channelCode,
+			"users":    names,
+			"user_ids": ids,
+		},
+	}, nil
+}
+
+// handleCurrentChannelCommand responde en qué canal está el usuario y cuántos hay conectados
+func handleCurrentChannelCommand(user *models.User, svc userService) (CommandResponse, error) {
+	if !user.IsInChannel() {
+		return CommandResponse{
+			Status:  "ok",
+			Intent:  "request_current_channel",
+			Message: "No estás conectado a ningún canal",
+		}, nil
+	}
+
+	channelCode := user.GetCurrentChannelCode()
+	users, err := svc.GetChannelActiveUsers(channelCode)
+	if err != nil {
+		return CommandResponse{}, fmt.Errorf("error obteniendo usuarios del canal: %w", err)
+	}
+
+	channelNum := channelLabel(channelCode)
+	count := len(users)
+	message := fmt.Sprintf("Estás en el canal %s con %d personas conectadas", channelNum, count)
+	if count <= 1 {
+		message = fmt.Sprintf("Estás en el canal %s y no hay nadie más conectado", channelNum)
+	} else if count == 2 {
+		message = fmt.Sprintf("Estás en el canal %s con otra persona conectada", channelNum)
+	}
+
+	return CommandResponse{
+		Status:  "ok",
+		Intent:  "request_current_channel",
+		Message: message,
+		Data: map[string]any{
+			"channel":       channelCode,
+			"channel_label": channelNum,
+			"member_count":  count,
+		},
+	}, nil
+}
+
+// joinSpokenList une nombres como se diría en voz alta: "a, b y c"
+func joinSpokenList(items []string) string {
+	switch len(items) {
+	case 0:
+		return ""
+	case 1:
+		return items[0]
+	default:
+		return strings.Join(items[:len(items)-1], ", ") + " y " + items[len(items)-1]
+	}
+}
+
 // handleChannelConnectCommand maneja el comando de conectar a canal
-func handleChannelConnectCommand(user *models.User, userService *services.UserService, channelCode string) (CommandResponse, error) {
-	if err := userService.ConnectUserToChannel(user.ID, channelCode); err != nil {
+func handleChannelConnectCommand(user *models.User, svc userService, channelCode string) (CommandResponse, error) {
+	previous := user.GetCurrentChannelCode()
+	if err := svc.ConnectUserToChannel(user.ID, channelCode); err != nil {
+		if errors.Is(err, services.ErrChannelFull) {
+			return channelFullResponse(user, svc, channelCode), nil
+		}
 		return CommandResponse{}, fmt.Errorf("no se pudo conectar al canal %s: %w", channelCode, err)
 	}
 
 	moveClientToChannel(user.ID, channelCode)
-	channelNum := strings.TrimPrefix(channelCode, "canal-")
+	if previous != channelCode {
+		announceMembership(svc, user, previous, membershipLeft)
+		announceMembership(svc, user, channelCode, membershipJoined)
+	}
+	channelNum := channelLabel(channelCode)
 
 	return CommandResponse{
 		Status:  "ok",
@@ -136,8 +279,134 @@ func handleChannelConnectCommand(user *models.User, userService *services.UserSe
 	}, nil
 }
 
+// channelFullResponse responde a un canal lleno sugiriendo los canales públicos con sitio,
+// para que el asistente pueda proponer una alternativa en lugar de un error
+func channelFullResponse(user *models.User, svc userService, channelCode string) CommandResponse {
+	channelNum := channelLabel(channelCode)
+	available := channelsWithRoom(svc, channelCode)
+	names := channelLabels(available)
+
+	message := fmt.Sprintf("El canal %s está lleno y no hay otros canales con sitio", channelNum)
+	switch len(names) {
+	case 0:
+	case 1:
+		message = fmt.Sprintf("El canal %s está lleno. Hay sitio en el canal %s", channelNum, names[0])
+	default:
+		message = fmt.Sprintf("El canal %s está lleno. Hay sitio en los canales %s", channelNum, joinSpokenList(names))
+	}
+
+	data := map[string]any{
+		"channel":         channelCode,
+		"channel_label":   channelNum,
+		"available":       available,
+		"available_names": names,
+	}
+	if len(available) > 0 {
+		// Se propone el primero: un "sí" conecta a él y "el dos" a cualquier otro
+		proposed := available[0]
+		message += fmt.Sprintf(". ¿Quieres ir al %s?", names[0])
+		data["pending_channel"] = proposed
+		setPendingConfirmation(user.ID, &pendingConfirmation{
+			Action:  "connect",
+			Channel: proposed,
+			OnConfirm: func() (CommandResponse, error) {
+				return handleChannelConnectCommand(user, svc, proposed)
+			},
+		}, 0)
+	}
+
+	return CommandResponse{
+		Status:  "channel_full",
+		Intent:  "request_channel_connect",
+		Message: message,
+		Data:    data,
+	}
+}
+
+// askForChannel responde a "cambia de canal" sin número preguntando a cuál; la respuesta
+// ("el dos") se interpreta con el canal pendiente
+func askForChannel(user *models.User, svc userService) CommandResponse {
+	available := channelsWithRoom(svc, user.GetCurrentChannelCode())
+	if len(available) == 0 {
+		return CommandResponse{
+			Status:  "error",
+			Intent:  "request_channel_connect",
+			Message: "No hay canales con sitio",
+		}
+	}
+
+	names := channelLabels(available)
+	message := fmt.Sprintf("¿A qué canal? Hay sitio en el %s", names[0])
+	if len(names) > 1 {
+		message = fmt.Sprintf("¿A qué canal? Hay sitio en los canales %s", joinSpokenList(names))
+	}
+	setPendingConfirmation(user.ID, &pendingConfirmation{
+		Action:  "connect",
+		Channel: ai.PendingAnyChannel,
+		// Un "sí" no dice el canal: se vuelve a preguntar
+		OnConfirm: func() (CommandResponse, error) {
+			return askForChannel(user, svc), nil
+		},
+	}, 0)
+
+	return CommandResponse{
+		Status:  "pending",
+		Intent:  "request_channel_connect",
+		Message: message,
+		Data: map[string]any{
+			"available":       available,
+			"available_names": names,
+			"pending_channel": ai.PendingAnyChannel,
+		},
+	}
+}
+
+// channelLabel es el canal tal como se lee en voz alta: el número de los "canal-N" y el
+// código con espacios en los demás ("obra-norte" -> "obra norte")
+func channelLabel(code string) string {
+	if num, ok := strings.CutPrefix(code, "canal-"); ok && num != "" {
+		return num
+	}
+	return strings.ReplaceAll(code, "-", " ")
+}
+
+// channelLabels aplica channelLabel a cada canal
+func channelLabels(codes []string) []string {
+	names := make([]string, 0, len(codes))
+	for _, code := range codes {
+		names = append(names, channelLabel(code))
+	}
+	return names
+}
+
+// channelsWithRoom devuelve los canales públicos (salvo exclude) con menos miembros
+// activos que su capacidad; si no se pueden consultar devuelve una lista vacía
+func channelsWithRoom(svc userService, exclude string) []string {
+	channels, err := svc.GetAvailableChannels()
+	if err != nil {
+		log.Printf("[COMANDO] no se pudieron listar canales con sitio: %v", err)
+		return []string{}
+	}
+
+	available := make([]string, 0, len(channels))
+	for _, ch := range channels {
+		if ch.Code == exclude {
+			continue
+		}
+		members, err := svc.GetChannelActiveUsers(ch.Code)
+		if err != nil {
+			log.Printf("[COMANDO] no se pudo contar miembros de %s: %v", ch.Code, err)
+			continue
+		}
+		if len(members) < ch.MaxUsers {
+			available = append(available, ch.Code)
+		}
+	}
+	return available
+}
+
 // handleChannelDisconnectCommand maneja el comando de desconectar del canal
-func handleChannelDisconnectCommand(user *models.User, userService *services.UserService) (CommandResponse, error) {
+func handleChannelDisconnectCommand(user *models.User, svc userService) (CommandResponse, error) {
 	if !user.IsInChannel() {
 		return CommandResponse{
 			Status:  "ok",
@@ -147,14 +416,15 @@ func handleChannelDisconnectCommand(user *models.User, userService *services.Use
 	}
 
 	currentChannel := user.GetCurrentChannelCode()
-	if err := userService.DisconnectUserFromCurrentChannel(user.ID); err != nil {
+	if err := svc.DisconnectUserFromCurrentChannel(user.ID); err != nil {
 		return CommandResponse{}, fmt.Errorf("no se pudo desconectar del canal: %w", err)
 	}
 
 	moveClientToChannel(user.ID, "")
 	ClearPendingAudio(user.ID)
+	announceMembership(svc, user, currentChannel, membershipLeft)
 
-	channelNum := strings.TrimPrefix(currentChannel, "canal-")
+	channelNum := channelLabel(currentChannel)
 
 	return CommandResponse{
 		Status:  "ok",
@@ -167,28 +437,35 @@ func handleChannelDisconnectCommand(user *models.User, userService *services.Use
 	}, nil
 }
 
-// handleAsConversation maneja el audio como conversación
-func handleAsConversation(w http.ResponseWriter, user *models.User, audioData []byte) {
+// handleAsConversation maneja el audio como conversación.
+// En canales de eco el clip vuelve al mismo usuario en lugar de difundirse.
+// emergency indica que la petición pidió prioridad de emergencia por cabecera.
+func handleAsConversation(w http.ResponseWriter, user *models.User, audioData []byte, transcript string, emergency bool) {
 	channelCode := user.GetCurrentChannelCode()
 	if channelCode == "" {
 		w.WriteHeader(http.StatusNoContent)
 		return
 	}
 
-	log.Printf("Procesando audio de usuario %d en canal %s", user.ID, channelCode)
+	if user.CurrentChannel.IsEcho() {
+		handleEchoClip(w, user, audioData, transcript)
+		return
+	}
 
-	startTransmission(channelCode, user.ID)
+	priority := messagePriority(user.ID, transcript, emergency)
+	log.Printf("Procesando audio de usuario %d en canal %s (prioridad %s)", user.ID, channelCode, priority)
+
+	if holder, ok := startTransmission(channelCode, user.ID, priority); !ok {
+		writeChannelBusy(w, channelCode, holder)
+		return
+	}
 	broadcastAudio(channelCode, user.ID, audioData)
 
 	duration := estimateAudioDuration(audioData)
+	scheduleStopTransmission(channelCode, user.ID, duration)
 
-	go func() {
-		time.Sleep(duration)
-		stopTransmission(channelCode, user.ID)
-	}()
-
-	userService := services.NewUserService()
-	channelUsers, err := userService.GetChannelActiveUsers(channelCode)
+	svc := services.NewUserService()
+	channelUsers, err := svc.GetChannelActiveUsers(channelCode)
 	if err != nil {
 		log.Printf("Error obteniendo usuarios del canal %s: %v", channelCode, err)
 		w.WriteHeader(http.StatusNoContent)
@@ -202,11 +479,37 @@ func handleAsConversation(w http.ResponseWriter, user *models.User, audioData []
 		}
 	}
 
-	EnqueueAudio(user.ID, channelCode, audioData, duration.Seconds(), recipients)
+	if transmissionID := EnqueueAudioWithPriority(user.ID, channelCode, audioData, duration.Seconds(), recipients, priority); transmissionID != "" {
+		w.Header().Set("X-Transmission-ID", transmissionID)
+	}
+	if priority != PriorityEmergency {
+		setDNDHeader(w, dndRecipients(recipients))
+	}
+	recordChannelTransmission(user.ID, channelCode, audioData, duration.Seconds(), priority)
+	appendRecordingClip(user.ID, channelCode, audioData, duration.Seconds())
+	recordChannelTranscript(user, channelCode, transcript, priority)
 
+	if priority != PriorityNormal {
+		w.Header().Set("X-Audio-Priority", priority)
+	}
 	w.WriteHeader(http.StatusNoContent)
 }
 
+// writeChannelBusy responde 409 cuando otro usuario tiene la palabra en el canal
+func writeChannelBusy(w http.ResponseWriter, channelCode string, holder uint) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(http.StatusConflict)
+	_ = json.NewEncoder(w).Encode(CommandResponse{
+		Status:  "busy",
+		Intent:  "conversation",
+		Message: "Canal ocupado, espera tu turno",
+		Data: map[string]any{
+			"channel": channelCode,
+			"speaker": holder,
+		},
+	})
+}
+
 // --------------------------- helpers ---------------------------
 
 func readUserIDHeader(r *http.Request) (uint, error) {
@@ -218,12 +521,17 @@ func readUserIDHeader(r *http.Request) (uint, error) {
 }
 
 func resolveUserFromRequest(r *http.Request) (*models.User, error) {
-	token := strings.TrimSpace(r.Header.Get("X-Auth-Token"))
+	if user, ok := authUserFromContext(r.Context()); ok {
+		return user, nil
+	}
+	token := requestToken(r)
 	user, err := findUserByToken(token)
 	if err != nil {
 		return nil, err
 	}
 	refreshUserActivity(user.ID)
+	presence.Touch(user.ID, user.DisplayName, user.GetCurrentChannelCode())
+	rememberSession(token, user)
 	return user, nil
 }
 
@@ -231,6 +539,15 @@ func findUserByToken(token string) (*models.User, error) {
 	if token == "" {
 		return nil, errors.New("token vacío")
 	}
+	if keyring.LooksLikeJWT(token) {
+		return findUserByAccessToken(token)
+	}
+	if !verifyAuthTokenSignature(token) {
+		return nil, errors.New("firma de token inválida")
+	}
+	if session, ok := findSessionByToken(token); ok {
+		return findUserBySession(session)
+	}
 
 	var user models.User
 	if err := config.DB.
@@ -258,18 +575,8 @@ func refreshUserActivity(userID uint) {
 
 func authTokenTTL() time.Duration {
 	tokenTTLOnce.Do(func() {
-		value := strings.TrimSpace(os.Getenv("AUTH_TOKEN_TTL"))
-		if value == "" {
-			tokenTTL = 24 * time.Hour
-			return
-		}
-		duration, err := time.ParseDuration(value)
-		if err != nil {
-			log.Printf("AUTH_TOKEN_TTL inválido (%s), usando 24h: %v", value, err)
-			tokenTTL = 24 * time.Hour
-			return
-		}
-		tokenTTL = duration
+		// 0 desactiva la caducidad por inactividad
+		tokenTTL = config.OptionalDurationFromEnv("AUTH_TOKEN_TTL", 24*time.Hour)
 	})
 	return tokenTTL
 }
@@ -289,20 +596,30 @@ func readAudioFromRequest(r *http.Request) ([]byte, string, error) {
 		}
 		defer part.Close()
 
-		data, err := io.ReadAll(io.LimitReader(part, 20<<20))
+		data, err := readLimitedAudio(part)
 		return data, part.Header.Get("Content-Type"), err
 	}
 
 	defer r.Body.Close()
-	data, err := io.ReadAll(io.LimitReader(r.Body, 20<<20))
+	data, err := readLimitedAudio(r.Body)
 	return data, mt, err
 }
 
-func isValidWAVFormat(data []byte) bool {
-	if len(data) < 44 {
-		return false
+// readLimitedAudio lee hasta AUDIO_MAX_BYTES; si el audio sigue devuelve errAudioTooLarge
+// con lo leído, que ya pasa del límite
+func readLimitedAudio(body io.Reader) ([]byte, error) {
+	maxBytes, _ := audioLimits()
+	data, err := io.ReadAll(io.LimitReader(body, int64(maxBytes)+1))
+	if err == nil && len(data) > maxBytes {
+		err = errAudioTooLarge
 	}
-	return string(data[0:4]) == "RIFF" && string(data[8:12]) == "WAVE"
+	return data, err
+}
+
+// isValidWAVFormat exige una cabecera RIFF con chunks fmt y data coherentes
+func isValidWAVFormat(data []byte) bool {
+	_, err := audio.ParseWAV(data)
+	return err == nil
 }
 
 func isLikelyCoherent(s string) bool {
@@ -348,14 +665,46 @@ func isLikelyCoherent(s string) bool {
 }
 
 func estimateAudioDuration(audioData []byte) time.Duration {
+	if d, ok := compressedAudioDuration(audioData); ok {
+		return clampAudioDuration(d.Seconds())
+	}
+
+	if f, err := audio.ParseWAV(audioData); err == nil {
+		return clampAudioDuration(f.Duration().Seconds())
+	}
+
+	// Sin cabecera legible se asume PCM 16 bits mono a 16 kHz
 	dataSize := len(audioData)
 
 	if dataSize > 44 && string(audioData[:4]) == "RIFF" && string(audioData[8:12]) == "WAVE" {
 		dataSize -= 44
 	}
 
-	seconds := float64(dataSize) / 32000.0
+	return clampAudioDuration(float64(dataSize) / 32000.0)
+}
+
+// compressedAudioDuration lee la duración declarada por los contenedores Ogg/WebM.
+// MediaRecorder suele omitirla en WebM, así que se estima con un bitrate típico de Opus.
+func compressedAudioDuration(data []byte) (time.Duration, bool) {
+	var (
+		d  time.Duration
+		ok bool
+	)
+	switch audio.Detect(data) {
+	case audio.FormatOggOpus:
+		d, ok = audio.OggOpusDuration(data)
+	case audio.FormatWebM:
+		d, ok = audio.WebMDuration(data)
+	default:
+		return 0, false
+	}
+	if !ok {
+		d = time.Duration(float64(len(data)) / opusBytesPerSecond * float64(time.Second))
+	}
+	return d, true
+}
 
+func clampAudioDuration(seconds float64) time.Duration {
 	if seconds < 0.5 {
 		seconds = 0.5
 	}
@@ -365,3 +714,25 @@ func estimateAudioDuration(audioData []byte) time.Duration {
 
 	return time.Duration(seconds * float64(time.Second))
 }
+
+// resampleTargetRate es la frecuencia que esperan el detector de voz y el STT
+const resampleTargetRate = 16000
+
+// wavResampleEnabled indica si los WAV se convierten a 16 kHz mono (WAV_RESAMPLE=true)
+func wavResampleEnabled() bool {
+	return strings.TrimSpace(strings.ToLower(os.Getenv("WAV_RESAMPLE"))) == "true"
+}
+
+// normalizeWAV convierte a PCM 16 bits mono a 16 kHz los WAV grabados a otra frecuencia o
+// en estéreo; si no se puede convertir se deja el clip como llegó
+func normalizeWAV(data []byte, userID uint) []byte {
+	if !wavResampleEnabled() || !audio.IsWAV(data) {
+		return data
+	}
+	out, err := audio.ResampleWAV(data, resampleTargetRate)
+	if err != nil {
+		log.Printf("[AUDIO] usuario=%d no se pudo convertir el WAV: %v", userID, err)
+		return data
+	}
+	return out
+}
@@ -2,6 +2,7 @@ package handlers
 
 import (
 	"bytes"
+	"encoding/binary"
 	"fmt"
 	"io"
 	"mime/multipart"
@@ -14,10 +15,11 @@ import (
 	"testing"
 	"time"
 
+	"walkie-backend/internal/ai"
 	"walkie-backend/internal/config"
 	"walkie-backend/internal/models"
 	"walkie-backend/internal/services"
-	"walkie-backend/pkg/qwen"
+	pkgaudio "walkie-backend/pkg/audio"
 
 	"github.com/stretchr/testify/assert"
 	"gorm.io/driver/sqlite"
@@ -111,12 +113,12 @@ func createUser(t *testing.T, db *gorm.DB, opts ...func(*models.User)) *models.U
 // TestHandleChannelListCommand_ReturnsList verifica el comando de listar canales
 func TestHandleChannelListCommand_ReturnsList(t *testing.T) {
 	withTestDB(t, func(db *gorm.DB) {
-		svc := services.NewUserService()
+		svc := newDefaultUserService()
 		createChannel(t, db, "canal-1")
 		createChannel(t, db, "canal-2")
 		createChannel(t, db, "canal-3")
 
-		resp, err := handleChannelListCommand(svc)
+		resp, err := handleChannelListCommand(&models.User{}, svc)
 		if err != nil {
 			t.Fatalf("unexpected error: %v", err)
 		}
@@ -132,7 +134,7 @@ func TestHandleChannelListCommand_ReturnsList(t *testing.T) {
 // TestHandleChannelConnectCommand_Success verifica la conexión exitosa a un canal
 func TestHandleChannelConnectCommand_Success(t *testing.T) {
 	withTestDB(t, func(db *gorm.DB) {
-		svc := services.NewUserService()
+		svc := newDefaultUserService()
 		createChannel(t, db, "canal-1")
 		user := createUser(t, db)
 
@@ -162,10 +164,66 @@ func TestHandleChannelConnectCommand_Success(t *testing.T) {
 	})
 }
 
+func TestHandleChannelConnectCommand_ChannelFullSuggestsAlternatives(t *testing.T) {
+	svc := &mockUserService{
+		connectErr: fmt.Errorf("%w: canal-1", services.ErrChannelFull),
+		channels: []models.Channel{
+			{Code: "canal-1", MaxUsers: 2},
+			{Code: "canal-2", MaxUsers: 2},
+			{Code: "canal-3", MaxUsers: 2},
+			{Code: "canal-4", MaxUsers: 2},
+		},
+		members: map[string][]models.User{
+			"canal-1": make([]models.User, 2),
+			"canal-2": make([]models.User, 1),
+			"canal-3": make([]models.User, 2),
+		},
+	}
+
+	user := &models.User{Model: gorm.Model{ID: 301}}
+	resp, err := handleChannelConnectCommand(user, svc, "canal-1")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	assert.Equal(t, "channel_full", resp.Status)
+	assert.Equal(t, "request_channel_connect", resp.Intent)
+	assert.Equal(t, "El canal 1 está lleno. Hay sitio en los canales 2 y 4. ¿Quieres ir al 2?", resp.Message)
+	assert.Equal(t, "canal-1", resp.Data["channel"])
+	assert.Equal(t, []string{"canal-2", "canal-4"}, resp.Data["available"])
+
+	// Un "sí" conecta al canal propuesto
+	assert.Equal(t, "canal-2", pendingChannelFor(user.ID))
+	svc.connectErr = nil
+	pc := takePendingConfirmation(user.ID, time.Now())
+	if assert.NotNil(t, pc) {
+		resp, err = pc.OnConfirm()
+		assert.NoError(t, err)
+		assert.Equal(t, "Conectado al canal 2", resp.Message)
+		assert.Equal(t, []string{"canal-2"}, svc.connected)
+	}
+}
+
+func TestHandleChannelConnectCommand_ChannelFullWithoutAlternatives(t *testing.T) {
+	svc := &mockUserService{
+		connectErr: fmt.Errorf("%w: canal-1", services.ErrChannelFull),
+		channels:   []models.Channel{{Code: "canal-1", MaxUsers: 1}},
+		members:    map[string][]models.User{"canal-1": make([]models.User, 1)},
+	}
+
+	resp, err := handleChannelConnectCommand(&models.User{}, svc, "canal-1")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	assert.Equal(t, "channel_full", resp.Status)
+	assert.Equal(t, "El canal 1 está lleno y no hay otros canales con sitio", resp.Message)
+	assert.Empty(t, resp.Data["available"])
+}
+
 // TestHandleChannelDisconnectCommand_NotInChannel verifica el intento de desconexión cuando no se está en un canal
 func TestHandleChannelDisconnectCommand_NotInChannel(t *testing.T) {
 	withTestDB(t, func(db *gorm.DB) {
-		svc := services.NewUserService()
+		svc := newDefaultUserService()
 		user := createUser(t, db)
 
 		resp, err := handleChannelDisconnectCommand(user, svc)
@@ -182,12 +240,12 @@ func TestHandleChannelDisconnectCommand_NotInChannel(t *testing.T) {
 // TestExecuteCommand_ChannelList verifica el comando de lista de canales a través de executeCommand
 func TestExecuteCommand_ChannelList(t *testing.T) {
 	withTestDB(t, func(db *gorm.DB) {
-		svc := services.NewUserService()
+		svc := newDefaultUserService()
 		createChannel(t, db, "canal-1")
 		createChannel(t, db, "canal-2")
 		user := createUser(t, db)
 
-		resp, err := executeCommand(user, svc, qwen.CommandResult{
+		resp, err := executeCommand(user, svc, ai.CommandResult{
 			IsCommand: true,
 			Intent:    "request_channel_list",
 		})
@@ -204,10 +262,10 @@ func TestExecuteCommand_ChannelList(t *testing.T) {
 // TestExecuteCommand_UnknownCommand verifica el manejo de comandos desconocidos
 func TestExecuteCommand_UnknownCommand(t *testing.T) {
 	withTestDB(t, func(db *gorm.DB) {
-		svc := services.NewUserService()
+		svc := newDefaultUserService()
 		user := createUser(t, db)
 
-		resp, err := executeCommand(user, svc, qwen.CommandResult{
+		resp, err := executeCommand(user, svc, ai.CommandResult{
 			IsCommand: true,
 			Intent:    "unknown_command",
 			Reply:     "Respuesta predeterminada",
@@ -243,9 +301,16 @@ func TestBuildChannelListPhrase(t *testing.T) {
 	}
 }
 
+func TestChannelLabel(t *testing.T) {
+	assert.Equal(t, "3", channelLabel("canal-3"))
+	assert.Equal(t, "obra norte", channelLabel("obra-norte"))
+	assert.Equal(t, "eco", channelLabel("eco"))
+	assert.Equal(t, []string{"1", "bodega a"}, channelLabels([]string{"canal-1", "bodega-a"}))
+}
+
 func TestHandleChannelDisconnectCommand_Success(t *testing.T) {
 	withTestDB(t, func(db *gorm.DB) {
-		svc := services.NewUserService()
+		svc := newDefaultUserService()
 		ch := createChannel(t, db, "canal-1")
 		user := createUser(t, db, func(u *models.User) {
 			u.CurrentChannelID = &ch.ID
@@ -267,12 +332,12 @@ func TestHandleChannelDisconnectCommand_Success(t *testing.T) {
 
 func TestExecuteCommand_ConnectAndDisconnect(t *testing.T) {
 	withTestDB(t, func(db *gorm.DB) {
-		svc := services.NewUserService()
+		svc := newDefaultUserService()
 		createChannel(t, db, "canal-5")
 		user := createUser(t, db)
 
 		// Connect
-		connectResp, err := executeCommand(user, svc, qwen.CommandResult{
+		connectResp, err := executeCommand(user, svc, ai.CommandResult{
 			IsCommand: true,
 			Intent:    "request_channel_connect",
 			Channels:  []string{"canal-5"},
@@ -284,7 +349,7 @@ func TestExecuteCommand_ConnectAndDisconnect(t *testing.T) {
 		db.Preload("CurrentChannel").First(user, user.ID)
 
 		// Disconnect
-		disconnectResp, err := executeCommand(user, svc, qwen.CommandResult{
+		disconnectResp, err := executeCommand(user, svc, ai.CommandResult{
 			IsCommand: true,
 			Intent:    "request_channel_disconnect",
 		})
@@ -293,6 +358,56 @@ func TestExecuteCommand_ConnectAndDisconnect(t *testing.T) {
 	})
 }
 
+func TestExecuteCommand_UserList(t *testing.T) {
+	withTestDB(t, func(db *gorm.DB) {
+		svc := newDefaultUserService()
+		createChannel(t, db, "canal-2")
+		speaker := createUser(t, db, func(u *models.User) { u.DisplayName = "Pedro" })
+		juan := createUser(t, db, func(u *models.User) { u.DisplayName = "Juan" })
+		maria := createUser(t, db, func(u *models.User) { u.DisplayName = "María" })
+
+		resp, err := executeCommand(speaker, svc, ai.CommandResult{IsCommand: true, Intent: "request_user_list"})
+		assert.NoError(t, err)
+		assert.Equal(t, "No estás conectado a ningún canal", resp.Message)
+
+		for _, u := range []*models.User{speaker, juan, maria} {
+			if err := svc.ConnectUserToChannel(u.ID, "canal-2"); err != nil {
+				t.Fatalf("connect: %v", err)
+			}
+		}
+		db.Preload("CurrentChannel").First(speaker, speaker.ID)
+
+		resp, err = executeCommand(speaker, svc, ai.CommandResult{IsCommand: true, Intent: "request_user_list"})
+		assert.NoError(t, err)
+		assert.Equal(t, "request_user_list", resp.Intent)
+		assert.Equal(t, "En el canal 2 están Juan y María", resp.Message)
+		assert.Len(t, resp.Data["user_ids"].([]uint), 3)
+	})
+}
+
+func TestExecuteCommand_CurrentChannel(t *testing.T) {
+	withTestDB(t, func(db *gorm.DB) {
+		svc := newDefaultUserService()
+		createChannel(t, db, "canal-4")
+		user := createUser(t, db)
+		other := createUser(t, db)
+
+		resp, err := executeCommand(user, svc, ai.CommandResult{IsCommand: true, Intent: "request_current_channel"})
+		assert.NoError(t, err)
+		assert.Equal(t, "No estás conectado a ningún canal", resp.Message)
+
+		_ = svc.ConnectUserToChannel(user.ID, "canal-4")
+		_ = svc.ConnectUserToChannel(other.ID, "canal-4")
+		db.Preload("CurrentChannel").First(user, user.ID)
+
+		resp, err = executeCommand(user, svc, ai.CommandResult{IsCommand: true, Intent: "request_current_channel"})
+		assert.NoError(t, err)
+		assert.Equal(t, "Estás en el canal 4 con otra persona conectada", resp.Message)
+		assert.Equal(t, "canal-4", resp.Data["channel"])
+		assert.Equal(t, 2, resp.Data["member_count"])
+	})
+}
+
 func TestFindUserByToken(t *testing.T) {
 	withTestDB(t, func(db *gorm.DB) {
 		t.Setenv("AUTH_TOKEN_TTL", "1h")
@@ -326,9 +441,12 @@ func TestFindUserByToken(t *testing.T) {
 
 func TestIsValidWAVFormat(t *testing.T) {
 	t.Run("valid wav", func(t *testing.T) {
-		validHeader := []byte("RIFFxxxxWAVEfmt ")
-		validFile := append(validHeader, make([]byte, 44-len(validHeader))...)
-		assert.True(t, isValidWAVFormat(validFile))
+		assert.True(t, isValidWAVFormat(buildTestWAV(320)))
+	})
+
+	t.Run("empty fmt chunk", func(t *testing.T) {
+		header := []byte("RIFFxxxxWAVEfmt ")
+		assert.False(t, isValidWAVFormat(append(header, make([]byte, 44-len(header))...)))
 	})
 
 	t.Run("invalid format", func(t *testing.T) {
@@ -379,6 +497,15 @@ func TestEstimateAudioDuration(t *testing.T) {
 		assert.InDelta(t, 1*time.Second, duration, float64(50*time.Millisecond))
 	})
 
+	t.Run("reads the fmt chunk", func(t *testing.T) {
+		// 1 segundo a 44,1 kHz estéreo (176400 bytes) ya no cuenta como 5,5 s
+		wav := pkgaudio.EncodeWAV(make([]int16, 88200), 44100)
+		binary.LittleEndian.PutUint16(wav[22:24], 2)
+		binary.LittleEndian.PutUint32(wav[28:32], 176400)
+		binary.LittleEndian.PutUint16(wav[32:34], 4)
+		assert.InDelta(t, 1*time.Second, estimateAudioDuration(wav), float64(10*time.Millisecond))
+	})
+
 	t.Run("min duration", func(t *testing.T) {
 		audio := make([]byte, 100)
 		duration := estimateAudioDuration(audio)
@@ -471,7 +598,7 @@ func TestResolveUserFromRequest(t *testing.T) {
 func TestHandleAsConversation(t *testing.T) {
 	withTestDB(t, func(db *gorm.DB) {
 		ch := createChannel(t, db, "conv-test")
-		svc := services.NewUserService()
+		svc := newDefaultUserService()
 		sender := createUser(t, db)
 		receiver := createUser(t, db)
 
@@ -489,7 +616,7 @@ func TestHandleAsConversation(t *testing.T) {
 		t.Run("successful conversation", func(t *testing.T) {
 			w := httptest.NewRecorder()
 			audioData := []byte("test audio")
-			handleAsConversation(w, sender, audioData)
+			handleAsConversation(w, sender, audioData, "", false)
 
 			assert.Equal(t, http.StatusNoContent, w.Code)
 
@@ -504,7 +631,7 @@ func TestHandleAsConversation(t *testing.T) {
 		t.Run("user not in channel", func(t *testing.T) {
 			userNotInChannel := createUser(t, db)
 			w := httptest.NewRecorder()
-			handleAsConversation(w, userNotInChannel, []byte("audio"))
+			handleAsConversation(w, userNotInChannel, []byte("audio"), "", false)
 			assert.Equal(t, http.StatusNoContent, w.Code)
 		})
 
@@ -516,7 +643,7 @@ func TestHandleAsConversation(t *testing.T) {
 			db.Preload("CurrentChannel").First(soloUser, soloUser.ID)
 
 			w := httptest.NewRecorder()
-			handleAsConversation(w, soloUser, []byte("audio"))
+			handleAsConversation(w, soloUser, []byte("audio"), "", false)
 			assert.Equal(t, http.StatusNoContent, w.Code)
 
 			// Ensure no audio was queued for anyone
@@ -581,3 +708,19 @@ func TestResolveUserFromRequest_UpdatesActivity(t *testing.T) {
 		assert.True(t, updatedUser.LastActiveAt.After(initialActivity))
 	})
 }
+
+func TestNormalizeWAV(t *testing.T) {
+	wav := pkgaudio.EncodeWAV(make([]int16, 8000), 8000)
+
+	assert.Equal(t, wav, normalizeWAV(wav, 1), "sin WAV_RESAMPLE no se toca el clip")
+
+	t.Setenv("WAV_RESAMPLE", "true")
+	f, err := pkgaudio.ParseWAV(normalizeWAV(wav, 1))
+	assert.NoError(t, err)
+	assert.Equal(t, 16000, f.SampleRate)
+	assert.Equal(t, 1, f.Channels)
+	assert.Equal(t, 32000, f.DataSize)
+
+	ogg := []byte("OggS-no-es-wav")
+	assert.Equal(t, ogg, normalizeWAV(ogg, 1))
+}
@@ -0,0 +1,105 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"time"
+
+	"walkie-backend/internal/config"
+	"walkie-backend/internal/metrics"
+	"walkie-backend/internal/response"
+	"walkie-backend/pkg/audio"
+)
+
+const defaultAudioMaxDuration = 60 * time.Second
+
+// errAudioTooLarge lo devuelve la lectura del cuerpo al pasar de AUDIO_MAX_BYTES
+var errAudioTooLarge = errors.New("audio demasiado grande")
+
+// audioLimits lee AUDIO_MAX_BYTES (10 MB) y AUDIO_MAX_DURATION (60s); una duración de 0
+// desactiva ese límite, el de tamaño siempre se aplica
+func audioLimits() (maxBytes int, maxDuration time.Duration) {
+	maxBytes = config.IntFromEnv("AUDIO_MAX_BYTES", maxAudioSize)
+	if maxBytes <= 0 {
+		maxBytes = maxAudioSize
+	}
+	return maxBytes, config.DurationFromEnv("AUDIO_MAX_DURATION", defaultAudioMaxDuration)
+}
+
+// declaredAudioDuration es la duración que declara el propio audio: la cabecera WAV o el
+// contenedor Ogg/WebM. Sin ella (FLAC, WebM sin duración) no se comprueba el límite.
+func declaredAudioDuration(data []byte) (time.Duration, bool) {
+	switch audio.Detect(data) {
+	case audio.FormatWAV:
+		f, err := audio.ParseWAV(data)
+		return f.Duration(), err == nil
+	case audio.FormatOggOpus:
+		return audio.OggOpusDuration(data)
+	case audio.FormatWebM:
+		return audio.WebMDuration(data)
+	}
+	return 0, false
+}
+
+// enforceAudioLimits rechaza con 413 el audio que supera el tamaño o la duración máximos.
+// size es el Content-Length o lo leído; data puede ir vacío si aún no se leyó el cuerpo.
+func enforceAudioLimits(w http.ResponseWriter, userID uint, size int64, data []byte) bool {
+	maxBytes, maxDuration := audioLimits()
+	if size > int64(maxBytes) {
+		log.Printf("Audio de usuario %d rechazado: %d bytes (max: %d)", userID, size, maxBytes)
+		writeAudioLimitError(w, "audio_too_large", fmt.Sprintf("El audio pesa demasiado, el máximo es de %s", spokenSize(maxBytes)), maxBytes, maxDuration, map[string]any{
+			"sizeBytes": size,
+		})
+		return false
+	}
+	if maxDuration <= 0 || len(data) == 0 {
+		return true
+	}
+	if d, ok := declaredAudioDuration(data); ok && d > maxDuration {
+		log.Printf("Audio de usuario %d rechazado: %.1fs (max: %s)", userID, d.Seconds(), maxDuration)
+		writeAudioLimitError(w, "audio_too_long", fmt.Sprintf("El mensaje es demasiado largo, el máximo es de %s. Divídelo en partes más cortas", spokenDuration(maxDuration)), maxBytes, maxDuration, map[string]any{
+			"durationSeconds": math.Round(d.Seconds()*10) / 10,
+		})
+		return false
+	}
+	return true
+}
+
+func writeAudioLimitError(w http.ResponseWriter, intent, message string, maxBytes int, maxDuration time.Duration, data map[string]any) {
+	metrics.Inc("walkie_audio_rejected_total", map[string]string{"reason": intent})
+	data["maxBytes"] = maxBytes
+	if maxDuration > 0 {
+		data["maxDurationSeconds"] = maxDuration.Seconds()
+	}
+	response.WriteJSON(w, http.StatusRequestEntityTooLarge, CommandResponse{
+		Status:  "error",
+		Intent:  intent,
+		Message: message,
+		Data:    data,
+	})
+}
+
+// spokenSize escribe el tamaño como se diría en voz alta
+func spokenSize(n int) string {
+	if n >= 1<<20 {
+		return fmt.Sprintf("%d megas", n>>20)
+	}
+	return fmt.Sprintf("%d kilobytes", max(1, n>>10))
+}
+
+// spokenDuration escribe la duración como se diría en voz alta
+func spokenDuration(d time.Duration) string {
+	if d >= time.Minute && d%time.Minute == 0 {
+		if d == time.Minute {
+			return "1 minuto"
+		}
+		return fmt.Sprintf("%d minutos", int(d/time.Minute))
+	}
+	if seconds := int(math.Ceil(d.Seconds())); seconds != 1 {
+		return fmt.Sprintf("%d segundos", seconds)
+	}
+	return "1 segundo"
+}
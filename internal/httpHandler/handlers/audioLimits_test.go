@@ -0,0 +1,86 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func ingestWithLimits(t *testing.T, req *http.Request, deps audioIngestDeps) (*httptest.ResponseRecorder, CommandResponse) {
+	t.Helper()
+	deps.readUserID = func(*http.Request) (uint, error) { return 1, nil }
+	rec := httptest.NewRecorder()
+	runAudioIngest(rec, req, deps)
+	var body CommandResponse
+	if rec.Code == http.StatusRequestEntityTooLarge {
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+	}
+	return rec, body
+}
+
+func TestReadAndValidateAudio_RejectsLongClipsFromWAVHeader(t *testing.T) {
+	t.Setenv("AUDIO_MAX_DURATION", "1s")
+
+	// Dos segundos de PCM 16 bits mono a 16 kHz
+	req := httptest.NewRequest(http.MethodPost, "/audio/ingest", bytes.NewReader(buildTestWAV(64000)))
+	req.Header.Set("Content-Type", "audio/wav")
+	rec, body := ingestWithLimits(t, req, newAudioIngestDeps())
+
+	require.Equal(t, http.StatusRequestEntityTooLarge, rec.Code, rec.Body.String())
+	assert.Equal(t, "audio_too_long", body.Intent)
+	assert.Equal(t, "El mensaje es demasiado largo, el máximo es de 1 segundo. Divídelo en partes más cortas", body.Message)
+	assert.EqualValues(t, 1, body.Data["maxDurationSeconds"])
+	assert.EqualValues(t, 2, body.Data["durationSeconds"])
+	assert.EqualValues(t, maxAudioSize, body.Data["maxBytes"])
+}
+
+func TestReadAndValidateAudio_RejectsLargeBodiesBeforeReading(t *testing.T) {
+	t.Setenv("AUDIO_MAX_BYTES", "2048")
+
+	deps := newAudioIngestDeps()
+	read := false
+	deps.readAudio = func(r *http.Request) ([]byte, string, error) {
+		read = true
+		return readAudioFromRequest(r)
+	}
+	req := httptest.NewRequest(http.MethodPost, "/audio/ingest", bytes.NewReader(buildTestWAV(4096)))
+	req.Header.Set("Content-Type", "audio/wav")
+	rec, body := ingestWithLimits(t, req, deps)
+
+	require.Equal(t, http.StatusRequestEntityTooLarge, rec.Code)
+	assert.False(t, read, "con Content-Length no se lee el cuerpo")
+	assert.Equal(t, "audio_too_large", body.Intent)
+	assert.Equal(t, "El audio pesa demasiado, el máximo es de 2 kilobytes", body.Message)
+	assert.EqualValues(t, 2048, body.Data["maxBytes"])
+	assert.EqualValues(t, 60, body.Data["maxDurationSeconds"])
+
+	// En multipart se lee la parte, pero nunca más allá del límite
+	var buf bytes.Buffer
+	mw := multipart.NewWriter(&buf)
+	part, err := mw.CreateFormFile("audio", "clip.wav")
+	require.NoError(t, err)
+	_, _ = part.Write(buildTestWAV(4096))
+	require.NoError(t, mw.Close())
+	req = httptest.NewRequest(http.MethodPost, "/audio/ingest", &buf)
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+	rec, body = ingestWithLimits(t, req, newAudioIngestDeps())
+
+	require.Equal(t, http.StatusRequestEntityTooLarge, rec.Code)
+	assert.Equal(t, "audio_too_large", body.Intent)
+	assert.EqualValues(t, 2049, body.Data["sizeBytes"])
+}
+
+func TestSpokenDuration(t *testing.T) {
+	assert.Equal(t, "1 segundo", spokenDuration(time.Second))
+	assert.Equal(t, "45 segundos", spokenDuration(45*time.Second))
+	assert.Equal(t, "1 minuto", spokenDuration(time.Minute))
+	assert.Equal(t, "2 minutos", spokenDuration(2*time.Minute))
+	assert.Equal(t, "90 segundos", spokenDuration(90*time.Second))
+}
@@ -0,0 +1,69 @@
+package handlers
+
+import (
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"walkie-backend/internal/config"
+	"walkie-backend/internal/response"
+)
+
+const defaultAudioPollBatchMax = 10
+
+// pollBatchItem es un clip dentro de la respuesta de /audio/poll?batch=true
+type pollBatchItem struct {
+	audioFrame
+	DeliveryID string `json:"deliveryId,omitempty"`
+	Attempt    int    `json:"attempt,omitempty"`
+}
+
+// batchRequested indica si el cliente pide varios clips en una sola respuesta
+func batchRequested(r *http.Request) bool {
+	return strings.EqualFold(strings.TrimSpace(r.URL.Query().Get("batch")), "true")
+}
+
+// pollBatchLimit devuelve cuántos clips entregar: ?max=N, acotado por AUDIO_POLL_BATCH_MAX
+func pollBatchLimit(r *http.Request) int {
+	limit := config.IntFromEnv("AUDIO_POLL_BATCH_MAX", defaultAudioPollBatchMax)
+	if limit < 1 {
+		limit = 1
+	}
+	if n, err := strconv.Atoi(r.URL.Query().Get("max")); err == nil && n > 0 && n < limit {
+		return n
+	}
+	return limit
+}
+
+// writeAudioBatch desencola hasta pollBatchLimit clips y los devuelve como array JSON
+// con el audio en base64; responde 204 si no hay nada pendiente
+func writeAudioBatch(w http.ResponseWriter, r *http.Request, userID uint, userSvc userService, next func(uint) *PendingAudio, ackMode bool) {
+	limit := pollBatchLimit(r)
+	now := time.Now()
+	items := make([]pollBatchItem, 0, limit)
+	for len(items) < limit {
+		pending := nextDeliverableAudio(userID, userSvc, next, "AudioPoll")
+		if pending == nil {
+			break
+		}
+		item := pollBatchItem{}
+		if ackMode {
+			item.DeliveryID = pending.DeliveryID
+			item.Attempt = pending.Attempts
+		}
+		item.audioFrame = newAudioFrame(pending, now)
+		items = append(items, item)
+	}
+
+	if len(items) == 0 {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	log.Printf("Usuario %d recibe %d audios pendientes via polling por lotes", userID, len(items))
+	response.WriteJSON(w, http.StatusOK, map[string]any{
+		"count":  len(items),
+		"audios": items,
+	})
+}
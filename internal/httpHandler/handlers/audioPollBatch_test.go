@@ -0,0 +1,94 @@
+package handlers
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+
+	"walkie-backend/internal/models"
+)
+
+func pollBatch(t *testing.T, user *models.User, query string, ack bool) *httptest.ResponseRecorder {
+	t.Helper()
+	deps := newAudioPollDeps()
+	deps.resolveUser = func(*http.Request) (*models.User, error) { return user, nil }
+	deps.newUserService = func() userService { return &mockUserService{user: user} }
+	req := httptest.NewRequest(http.MethodGet, "/audio/poll?batch=true"+query, nil)
+	if ack {
+		req.Header.Set("X-Audio-Ack", "true")
+	}
+	rec := httptest.NewRecorder()
+	runAudioPoll(rec, req, deps)
+	return rec
+}
+
+type batchBody struct {
+	Count  int             `json:"count"`
+	Audios []pollBatchItem `json:"audios"`
+}
+
+func TestAudioPoll_BatchReturnsSeveralClips(t *testing.T) {
+	user := &models.User{Model: gorm.Model{ID: 620}}
+	defer ClearPendingAudio(user.ID)
+	clip := buildTestWAV(320)
+	for i := 0; i < 3; i++ {
+		require.NoError(t, EnqueueDirectAudio(uint(2+i), user.ID, clip, 1, PriorityNormal))
+	}
+
+	rec := pollBatch(t, user, "&max=2", false)
+	require.Equal(t, http.StatusOK, rec.Code)
+	var body batchBody
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+	require.Equal(t, 2, body.Count)
+	assert.Equal(t, uint(2), body.Audios[0].From)
+	assert.Equal(t, uint(3), body.Audios[1].From)
+	assert.True(t, body.Audios[0].Direct)
+	assert.Empty(t, body.Audios[0].DeliveryID)
+	raw, err := base64.StdEncoding.DecodeString(body.Audios[0].AudioBase64)
+	require.NoError(t, err)
+	assert.Equal(t, clip, raw)
+
+	rec = pollBatch(t, user, "", false)
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+	assert.Equal(t, 1, body.Count)
+
+	assert.Equal(t, http.StatusNoContent, pollBatch(t, user, "", false).Code)
+}
+
+func TestAudioPoll_BatchWithAck(t *testing.T) {
+	t.Setenv("AUDIO_POLL_BATCH_MAX", "5")
+	user := &models.User{Model: gorm.Model{ID: 621}}
+	defer ClearPendingAudio(user.ID)
+	for i := 0; i < 2; i++ {
+		require.NoError(t, EnqueueDirectAudio(2, user.ID, buildTestWAV(320), 1, PriorityNormal))
+	}
+
+	rec := pollBatch(t, user, "&max=50", true)
+	require.Equal(t, http.StatusOK, rec.Code)
+	var body batchBody
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+	require.Equal(t, 2, body.Count)
+	for _, item := range body.Audios {
+		assert.NotEmpty(t, item.DeliveryID)
+		assert.Equal(t, 1, item.Attempt)
+		assert.True(t, ackDelivery(user.ID, item.DeliveryID))
+	}
+}
+
+func TestPollBatchLimit(t *testing.T) {
+	t.Setenv("AUDIO_POLL_BATCH_MAX", "4")
+	limit := func(query string) int {
+		return pollBatchLimit(httptest.NewRequest(http.MethodGet, "/audio/poll?batch=true"+query, nil))
+	}
+	assert.Equal(t, 4, limit(""))
+	assert.Equal(t, 2, limit("&max=2"))
+	assert.Equal(t, 4, limit("&max=9"))
+	assert.Equal(t, 4, limit("&max=abc"))
+}
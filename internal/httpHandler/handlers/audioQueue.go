@@ -1,11 +1,21 @@
 package handlers
 
 import (
+	"context"
+	"fmt"
 	"log"
+	"os"
+	"strings"
 	"sync"
 	"time"
+
+	"walkie-backend/internal/config"
+	"walkie-backend/internal/metrics"
+	"walkie-backend/pkg/audio"
 )
 
+const defaultAgeNoticeThreshold = time.Minute
+
 // PendingAudio representa un audio pendiente de ser entregado
 type PendingAudio struct {
 	SenderID   uint
@@ -15,9 +25,97 @@ type PendingAudio struct {
 	Duration   float64
 	SampleRate int
 	Format     string
+	Priority   string
+	// Direct indica un mensaje para un único usuario, ajeno a su canal actual
+	Direct bool
+	// Attempts cuenta las entregas por polling con ACK que no se confirmaron
+	Attempts int
+	// ObjectKey apunta al audio en el almacenamiento de objetos; entonces AudioData va vacío
+	ObjectKey  string
+	ObjectSize int
+	// TransmissionID identifica el envío en las confirmaciones de escucha
+	TransmissionID string
+	// DeliveryID identifica la entrega por polling con ACK en curso
+	DeliveryID string
+	// leaseUntil oculta el clip en la cola en memoria mientras se espera su ACK
+	leaseUntil time.Time
+	// shared es el audio común a todos los destinatarios de un envío a canal
+	shared *sharedAudio
+}
+
+// Size es el tamaño del audio, esté en la cola o en el almacenamiento de objetos
+func (a *PendingAudio) Size() int64 {
+	if a.ObjectKey != "" {
+		return int64(a.ObjectSize)
+	}
+	return int64(len(a.AudioData))
+}
+
+// IsUrgent indica si el audio fue marcado como urgente o emergencia por el hablante
+func (a *PendingAudio) IsUrgent() bool {
+	return a != nil && (a.Priority == PriorityUrgent || a.Priority == PriorityEmergency)
+}
+
+// IsEmergency indica si el audio es una emergencia que debe reproducirse de inmediato
+func (a *PendingAudio) IsEmergency() bool {
+	return a != nil && a.Priority == PriorityEmergency
+}
+
+// Age devuelve cuánto tiempo lleva el audio en la cola
+func (a *PendingAudio) Age(now time.Time) time.Duration {
+	if a == nil || a.Timestamp.IsZero() {
+		return 0
+	}
+	age := now.Sub(a.Timestamp)
+	if age < 0 {
+		return 0
+	}
+	return age
+}
+
+// ageNoticeThreshold lee AUDIO_AGE_NOTICE_AFTER; "0" desactiva el aviso
+func ageNoticeThreshold() time.Duration {
+	return config.OptionalDurationFromEnv("AUDIO_AGE_NOTICE_AFTER", defaultAgeNoticeThreshold)
+}
+
+// audioAgeNotice devuelve la frase a anunciar antes de un audio antiguo, o "" si es reciente
+func audioAgeNotice(age time.Duration) string {
+	threshold := ageNoticeThreshold()
+	if threshold <= 0 || age < threshold {
+		return ""
+	}
+	minutes := int(age / time.Minute)
+	switch {
+	case minutes < 1:
+		return fmt.Sprintf("mensaje de hace %d segundos", int(age/time.Second))
+	case minutes == 1:
+		return "mensaje de hace 1 minuto"
+	default:
+		return fmt.Sprintf("mensaje de hace %d minutos", minutes)
+	}
 }
 
-// AudioQueue maneja la cola de audios pendientes por usuario
+// PendingAudioStore guarda los audios pendientes por destinatario. La implementación
+// en memoria sirve para una sola réplica; la de base de datos sobrevive a reinicios
+// y se comparte entre réplicas.
+type PendingAudioStore interface {
+	Enqueue(recipientID uint, audio *PendingAudio) error
+	Dequeue(userID uint) (*PendingAudio, error)
+	// Lease entrega el siguiente clip sin sacarlo de la cola: queda oculto hasta until con
+	// ese deliveryID. Los clips que ya llevan maxAttempts entregas se descartan.
+	Lease(userID uint, deliveryID string, until time.Time, maxAttempts int) (*PendingAudio, error)
+	// Ack borra el clip entregado con deliveryID; false si no existe o no es de userID
+	Ack(userID uint, deliveryID string) (bool, error)
+	Clear(userID uint) error
+	PurgeOlderThan(cutoff time.Time) error
+	// EvictOverflow descarta clips antiguos hasta cumplir los límites y devuelve cuántos sacó
+	EvictOverflow(userID uint, maxClips int, maxBytes int64) (int, error)
+	Status(userID uint) (QueueStatus, error)
+	// Ping comprueba que el backend responde, para /readyz
+	Ping(ctx context.Context) error
+}
+
+// AudioQueue maneja la cola de audios pendientes por usuario en memoria
 type AudioQueue struct {
 	mu     sync.RWMutex
 	queues map[uint][]*PendingAudio
@@ -27,79 +125,394 @@ var globalAudioQueue = &AudioQueue{
 	queues: make(map[uint][]*PendingAudio),
 }
 
-// EnqueueAudio agrega un audio a la cola de cada usuario del canal (excepto el sender)
+var pendingStore = struct {
+	sync.RWMutex
+	store PendingAudioStore
+	once  sync.Once
+}{}
+
+// SetPendingAudioStore reemplaza el backend de la cola; nil vuelve a la cola en memoria
+func SetPendingAudioStore(store PendingAudioStore) {
+	pendingStore.once.Do(func() {})
+	pendingStore.Lock()
+	pendingStore.store = store
+	pendingStore.Unlock()
+}
+
+// audioStore devuelve el backend configurado. AUDIO_QUEUE_BACKEND=db usa la base de datos.
+func audioStore() PendingAudioStore {
+	pendingStore.once.Do(func() {
+		backend := strings.ToLower(strings.TrimSpace(os.Getenv("AUDIO_QUEUE_BACKEND")))
+		switch backend {
+		case "", "memory":
+		case "db", "postgres":
+			pendingStore.Lock()
+			pendingStore.store = NewDBAudioStore(nil)
+			pendingStore.Unlock()
+			log.Printf("Cola de audio persistente en base de datos")
+		default:
+			log.Printf("AUDIO_QUEUE_BACKEND desconocido (%s), usando memoria", backend)
+		}
+	})
+
+	pendingStore.RLock()
+	defer pendingStore.RUnlock()
+	if pendingStore.store == nil {
+		return globalAudioQueue
+	}
+	return pendingStore.store
+}
+
+// EnqueueAudio agrega un audio a la cola de cada usuario del canal (excepto el sender y
+// quienes lo silenciaron)
 func EnqueueAudio(senderID uint, channel string, audioData []byte, duration float64, recipients []uint) {
-	globalAudioQueue.mu.Lock()
-	defer globalAudioQueue.mu.Unlock()
+	EnqueueAudioWithPriority(senderID, channel, audioData, duration, recipients, PriorityNormal)
+}
 
-	audio := &PendingAudio{
+// EnqueueAudioWithPriority encola el audio con la prioridad indicada; los urgentes
+// se entregan antes que los normales pendientes. A quien tiene no molestar se le encola
+// sin avisarle, salvo emergencias. Devuelve el ID de la transmisión para las
+// confirmaciones de escucha (vacío en los mensajes del sistema).
+func EnqueueAudioWithPriority(senderID uint, channel string, audioData []byte, duration float64, recipients []uint, priority string) string {
+	audio := newPendingAudio(senderID, channel, audioData, duration, priority)
+	if senderID != 0 {
+		audio.TransmissionID = newTransmissionID()
+	}
+	shareAudio(audio)
+	store := audioStore()
+	muted := mutedRecipients(channel, senderID)
+	var dnd map[uint]bool
+	if priority != PriorityEmergency {
+		dnd = dndRecipients(recipients)
+	}
+
+	queued := make([]uint, 0, len(recipients))
+	for _, recipientID := range recipients {
+		if recipientID == senderID || muted[recipientID] {
+			continue
+		}
+		pending := audio.forRecipient()
+		if err := enqueuePending(store, recipientID, pending); err != nil {
+			log.Printf("Error encolando audio para usuario %d: %v", recipientID, err)
+			continue
+		}
+		queued = append(queued, recipientID)
+		log.Printf("Audio encolado para usuario %d (de usuario %d, canal %s, prioridad %s)", recipientID, senderID, channel, priority)
+		if dnd[recipientID] {
+			continue
+		}
+		notifyAudioAvailable(recipientID)
+		notifyPendingAudio(recipientID, pending)
+	}
+	if audio.shared != nil && len(queued) > 1 {
+		metrics.Default().Add("walkie_audio_dedup_bytes_total", nil, float64(audio.shared.size*int64(len(queued)-1)))
+	}
+	recordReceipts(audio, queued)
+	return audio.TransmissionID
+}
+
+// EnqueueDirectAudio encola un mensaje directo para un único destinatario; se entrega
+// aunque el destinatario no comparta canal con el emisor
+func EnqueueDirectAudio(senderID, recipientID uint, audioData []byte, duration float64, priority string) error {
+	audio := newPendingAudio(senderID, "", audioData, duration, priority)
+	audio.Direct = true
+	if err := enqueuePending(audioStore(), recipientID, audio); err != nil {
+		return err
+	}
+	log.Printf("Mensaje directo encolado para usuario %d (de usuario %d, prioridad %s)", recipientID, senderID, priority)
+	if priority == PriorityEmergency || !dndRecipients([]uint{recipientID})[recipientID] {
+		notifyAudioAvailable(recipientID)
+		notifyPendingAudio(recipientID, audio)
+	}
+	return nil
+}
+
+// enqueueForUser agrega un audio a la cola de un único destinatario, aunque sea el propio emisor
+func enqueueForUser(recipientID, senderID uint, channel string, audioData []byte, duration float64) {
+	audio := newPendingAudio(senderID, channel, audioData, duration, PriorityNormal)
+	if err := enqueuePending(audioStore(), recipientID, audio); err != nil {
+		log.Printf("Error encolando audio para usuario %d: %v", recipientID, err)
+		return
+	}
+	notifyAudioAvailable(recipientID)
+}
+
+func newPendingAudio(senderID uint, channel string, audioData []byte, duration float64, priority string) *PendingAudio {
+	pending := &PendingAudio{
 		SenderID:   senderID,
 		Channel:    channel,
 		AudioData:  audioData,
 		Timestamp:  time.Now(),
 		Duration:   duration,
-		SampleRate: 16000,
-		Format:     "wav",
+		SampleRate: queuedSampleRate(audioData),
+		Format:     queuedFormat(audioData),
+		Priority:   priority,
 	}
+	offloadPendingAudio(pending)
+	return pending
+}
 
-	for _, recipientID := range recipients {
-		if recipientID == senderID {
-			continue
-		}
+// queuedSampleRate lee la frecuencia de los WAV; el resto se anuncia a 16 kHz como siempre
+func queuedSampleRate(data []byte) int {
+	if f, err := audio.ParseWAV(data); err == nil {
+		return f.SampleRate
+	}
+	return 16000
+}
 
-		if globalAudioQueue.queues[recipientID] == nil {
-			globalAudioQueue.queues[recipientID] = make([]*PendingAudio, 0, 10)
-		}
+// queuedFormat identifica el contenedor del clip para servirlo con su Content-Type
+func queuedFormat(data []byte) string {
+	if isEncryptedAudio(data) {
+		return formatEncrypted
+	}
+	if format := audio.Detect(data); format != "" {
+		return format
+	}
+	return audio.FormatWAV
+}
 
-		globalAudioQueue.queues[recipientID] = append(globalAudioQueue.queues[recipientID], audio)
-		log.Printf("Audio encolado para usuario %d (de usuario %d, canal %s)", recipientID, senderID, channel)
+// clipMimeType es el Content-Type con el que se sirve un clip de la cola
+func clipMimeType(format string) string {
+	if format == formatEncrypted {
+		return "application/octet-stream"
 	}
+	return audio.MimeType(format)
+}
 
-	go cleanOldAudios()
+// insertByPriority coloca las emergencias delante de todo, los urgentes detrás del
+// último urgente pendiente y los normales al final, conservando el orden de llegada
+// dentro de cada prioridad
+func insertByPriority(queue []*PendingAudio, audio *PendingAudio) []*PendingAudio {
+	if !audio.IsUrgent() {
+		return append(queue, audio)
+	}
+	pos := 0
+	for pos < len(queue) && queue[pos].IsUrgent() && (queue[pos].IsEmergency() || !audio.IsEmergency()) {
+		pos++
+	}
+	queue = append(queue, nil)
+	copy(queue[pos+1:], queue[pos:])
+	queue[pos] = audio
+	return queue
 }
 
 // DequeueAudio obtiene el siguiente audio pendiente para un usuario
 func DequeueAudio(userID uint) *PendingAudio {
-	globalAudioQueue.mu.Lock()
-	defer globalAudioQueue.mu.Unlock()
-
-	queue := globalAudioQueue.queues[userID]
-	if len(queue) == 0 {
+	audio, err := audioStore().Dequeue(userID)
+	if err != nil {
+		log.Printf("Error desencolando audio para usuario %d: %v", userID, err)
+		return nil
+	}
+	if audio == nil {
 		return nil
 	}
-
-	audio := queue[0]
-	globalAudioQueue.queues[userID] = queue[1:]
 
 	log.Printf("Audio desencolado para usuario %d (de usuario %d, canal %s)", userID, audio.SenderID, audio.Channel)
 	return audio
 }
 
-// cleanOldAudios elimina audios más antiguos de 5 minutos
+// cleanOldAudios elimina audios más antiguos de 5 minutos; lo llama runMaintenance en
+// cada pasada, no cada encolado
 func cleanOldAudios() {
-	globalAudioQueue.mu.Lock()
-	defer globalAudioQueue.mu.Unlock()
+	if err := audioStore().PurgeOlderThan(time.Now().Add(-5 * time.Minute)); err != nil {
+		log.Printf("Error limpiando audios antiguos: %v", err)
+	}
+}
+
+// ClearPendingAudio elimina la cola completa de un usuario
+func ClearPendingAudio(userID uint) {
+	if err := audioStore().Clear(userID); err != nil {
+		log.Printf("Error limpiando cola de usuario %d: %v", userID, err)
+	}
+}
+
+func (q *AudioQueue) Enqueue(recipientID uint, audio *PendingAudio) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if q.queues[recipientID] == nil {
+		q.queues[recipientID] = make([]*PendingAudio, 0, 10)
+	}
+	q.queues[recipientID] = insertByPriority(q.queues[recipientID], audio)
+	audio.shared.retain()
+	return nil
+}
+
+func (q *AudioQueue) Dequeue(userID uint) (*PendingAudio, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	i := q.nextVisible(userID, time.Now())
+	if i < 0 {
+		return nil, nil
+	}
+	return q.removeAt(userID, i), nil
+}
+
+// nextVisible es la posición del primer clip sin una entrega pendiente de ACK; q.mu
+// debe estar tomado
+func (q *AudioQueue) nextVisible(userID uint, now time.Time) int {
+	for i, audio := range q.queues[userID] {
+		if !now.Before(audio.leaseUntil) {
+			return i
+		}
+	}
+	return -1
+}
+
+// removeAt saca el clip de la cola y suelta su referencia; q.mu debe estar tomado
+func (q *AudioQueue) removeAt(userID uint, i int) *PendingAudio {
+	queue := q.queues[userID]
+	audio := queue[i]
+	q.queues[userID] = append(queue[:i:i], queue[i+1:]...)
+	if len(q.queues[userID]) == 0 {
+		delete(q.queues, userID)
+	}
+	audio.shared.release()
+	return audio
+}
+
+func (q *AudioQueue) Lease(userID uint, deliveryID string, until time.Time, maxAttempts int) (*PendingAudio, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	now := time.Now()
+	for {
+		i := q.nextVisible(userID, now)
+		if i < 0 {
+			return nil, nil
+		}
+		audio := q.queues[userID][i]
+		if audio.Attempts >= maxAttempts {
+			q.removeAt(userID, i)
+			dropUnackedAudio(userID, audio)
+			continue
+		}
+		redelivered := audio.Attempts > 0
+		audio.Attempts++
+		audio.DeliveryID = deliveryID
+		audio.leaseUntil = until
+		if redelivered {
+			metrics.Inc("walkie_audio_redelivered_total", nil)
+		}
+		leased := *audio
+		return &leased, nil
+	}
+}
+
+func (q *AudioQueue) Ack(userID uint, deliveryID string) (bool, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
 
-	cutoff := time.Now().Add(-5 * time.Minute)
+	for i, audio := range q.queues[userID] {
+		if audio.DeliveryID == deliveryID && deliveryID != "" {
+			q.removeAt(userID, i)
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (q *AudioQueue) Clear(userID uint) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	releaseAll(q.queues[userID])
+	delete(q.queues, userID)
+	return nil
+}
 
-	for userID, queue := range globalAudioQueue.queues {
+func (q *AudioQueue) PurgeOlderThan(cutoff time.Time) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	for userID, queue := range q.queues {
 		filtered := make([]*PendingAudio, 0, len(queue))
 		for _, audio := range queue {
 			if audio.Timestamp.After(cutoff) {
 				filtered = append(filtered, audio)
+			} else {
+				audio.shared.release()
 			}
 		}
-		globalAudioQueue.queues[userID] = filtered
+		q.queues[userID] = filtered
 
 		if len(filtered) == 0 {
-			delete(globalAudioQueue.queues, userID)
+			delete(q.queues, userID)
 		}
 	}
+	return nil
 }
 
-// ClearPendingAudio elimina la cola completa de un usuario
-func ClearPendingAudio(userID uint) {
-	globalAudioQueue.mu.Lock()
-	defer globalAudioQueue.mu.Unlock()
-	delete(globalAudioQueue.queues, userID)
+func (q *AudioQueue) EvictOverflow(userID uint, maxClips int, maxBytes int64) (int, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	queue := q.queues[userID]
+	items := make([]queueItem, len(queue))
+	for i, audio := range queue {
+		items[i] = queueItem{urgent: audio.IsUrgent(), at: audio.Timestamp, size: audio.Size()}
+	}
+	victims := evictionVictims(items, maxClips, maxBytes)
+	if len(victims) == 0 {
+		return 0, nil
+	}
+
+	drop := make(map[int]bool, len(victims))
+	for _, i := range victims {
+		drop[i] = true
+	}
+	kept := make([]*PendingAudio, 0, len(queue)-len(victims))
+	for i, audio := range queue {
+		if drop[i] {
+			audio.shared.release()
+			continue
+		}
+		kept = append(kept, audio)
+	}
+	q.queues[userID] = kept
+	return len(victims), nil
+}
+
+func (q *AudioQueue) Status(userID uint) (QueueStatus, error) {
+	q.mu.RLock()
+	defer q.mu.RUnlock()
+
+	var status QueueStatus
+	for _, audio := range q.queues[userID] {
+		status.Depth++
+		status.Bytes += audio.Size()
+		if audio.IsUrgent() {
+			status.Urgent++
+		}
+		if status.OldestAt.IsZero() || audio.Timestamp.Before(status.OldestAt) {
+			status.OldestAt = audio.Timestamp
+		}
+	}
+	return status, nil
+}
+
+func (q *AudioQueue) Ping(ctx context.Context) error {
+	return nil
+}
+
+func (q *AudioQueue) Totals() (QueueTotals, error) {
+	q.mu.RLock()
+	defer q.mu.RUnlock()
+
+	var totals QueueTotals
+	for _, queue := range q.queues {
+		if len(queue) == 0 {
+			continue
+		}
+		totals.Users++
+		totals.Clips += len(queue)
+		totals.MaxDepth = max(totals.MaxDepth, len(queue))
+		for _, audio := range queue {
+			totals.Bytes += audio.Size()
+			if audio.IsUrgent() {
+				totals.Urgent++
+			}
+		}
+	}
+	return totals, nil
 }
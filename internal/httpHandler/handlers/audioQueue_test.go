@@ -208,3 +208,38 @@ func TestClearPendingAudio(t *testing.T) {
 		t.Errorf("Queue for user %d should have been deleted, but it exists with %d items.", userID, len(queue))
 	}
 }
+
+func TestPendingAudioAge(t *testing.T) {
+	now := time.Now()
+	audio := &PendingAudio{Timestamp: now.Add(-90 * time.Second)}
+	if got := audio.Age(now); got != 90*time.Second {
+		t.Fatalf("expected 90s, got %s", got)
+	}
+
+	var nilAudio *PendingAudio
+	if nilAudio.Age(now) != 0 {
+		t.Fatal("expected zero age for nil audio")
+	}
+}
+
+func TestAudioAgeNotice(t *testing.T) {
+	t.Setenv("AUDIO_AGE_NOTICE_AFTER", "30s")
+
+	if got := audioAgeNotice(10 * time.Second); got != "" {
+		t.Fatalf("expected no notice, got %q", got)
+	}
+	if got := audioAgeNotice(45 * time.Second); got != "mensaje de hace 45 segundos" {
+		t.Fatalf("unexpected notice %q", got)
+	}
+	if got := audioAgeNotice(70 * time.Second); got != "mensaje de hace 1 minuto" {
+		t.Fatalf("unexpected notice %q", got)
+	}
+	if got := audioAgeNotice(4 * time.Minute); got != "mensaje de hace 4 minutos" {
+		t.Fatalf("unexpected notice %q", got)
+	}
+
+	t.Setenv("AUDIO_AGE_NOTICE_AFTER", "0")
+	if got := audioAgeNotice(4 * time.Minute); got != "" {
+		t.Fatalf("expected notice disabled, got %q", got)
+	}
+}
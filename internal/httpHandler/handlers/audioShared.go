@@ -0,0 +1,76 @@
+package handlers
+
+import (
+	"log"
+	"sync/atomic"
+
+	"walkie-backend/internal/metrics"
+)
+
+const sharedAudioKeyBytes = 16
+
+// sharedAudio es el audio de un clip repartido a todo un canal. Se guarda una sola vez y
+// cada destinatario recibe una copia ligera de los metadatos que apunta a él; refs cuenta
+// las colas que todavía lo contienen.
+type sharedAudio struct {
+	key  string
+	size int64
+	refs atomic.Int64
+}
+
+// sharedAudioBytes suma el audio compartido que sigue en alguna cola en memoria
+var sharedAudioBytes atomic.Int64
+
+// shareAudio prepara el clip para repartirlo sin copiar el audio. Los clips subidos al
+// almacenamiento de objetos ya se comparten por su clave y se dejan como están.
+func shareAudio(pending *PendingAudio) {
+	if pending.shared != nil || len(pending.AudioData) == 0 {
+		return
+	}
+	key, err := generateToken(sharedAudioKeyBytes)
+	if err != nil {
+		log.Printf("[COLA] no se pudo generar la clave del audio compartido: %v", err)
+		return
+	}
+	pending.shared = &sharedAudio{key: key, size: int64(len(pending.AudioData))}
+}
+
+// forRecipient copia los metadatos del clip para un destinatario; el audio no se copia y
+// los intentos de entrega de cada uno quedan separados
+func (a *PendingAudio) forRecipient() *PendingAudio {
+	copied := *a
+	return &copied
+}
+
+// retain anota que una cola más contiene el audio
+func (s *sharedAudio) retain() {
+	if s == nil {
+		return
+	}
+	if s.refs.Add(1) == 1 {
+		updateSharedAudioBytes(s.size)
+	}
+}
+
+// release anota que una cola ya no contiene el audio; con la última referencia deja de
+// contarse y el recolector lo libera en cuanto se termine de entregar
+func (s *sharedAudio) release() {
+	if s == nil {
+		return
+	}
+	if s.refs.Add(-1) == 0 {
+		updateSharedAudioBytes(-s.size)
+	}
+}
+
+func updateSharedAudioBytes(delta int64) {
+	total := sharedAudioBytes.Add(delta)
+	metrics.SetGauge("walkie_audio_shared_bytes", nil, float64(total))
+}
+
+// releaseAll suelta las referencias de los clips que salen de una cola
+func releaseAll(queue []*PendingAudio) {
+	for _, audio := range queue {
+		audio.shared.release()
+	}
+}
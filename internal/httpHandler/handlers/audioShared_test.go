@@ -0,0 +1,74 @@
+package handlers
+
+import (
+	"testing"
+	"time"
+
+	"walkie-backend/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEnqueueAudio_SharesBufferAcrossRecipients(t *testing.T) {
+	queue := &AudioQueue{queues: make(map[uint][]*PendingAudio)}
+	SetPendingAudioStore(queue)
+	defer SetPendingAudioStore(nil)
+
+	data := []byte("RIFF clip compartido")
+	before := sharedAudioBytes.Load()
+	EnqueueAudio(1, "canal-shared", data, 1, []uint{686, 687, 688})
+	assert.Equal(t, before+int64(len(data)), sharedAudioBytes.Load(), "el audio se cuenta una sola vez")
+
+	first, err := queue.Dequeue(686)
+	require.NoError(t, err)
+	second, err := queue.Dequeue(687)
+	require.NoError(t, err)
+	require.NotNil(t, first)
+	require.NotNil(t, second)
+
+	assert.Same(t, &data[0], &first.AudioData[0])
+	assert.Same(t, &data[0], &second.AudioData[0])
+	assert.Same(t, first.shared, second.shared)
+
+	// Los intentos de entrega de cada destinatario son independientes
+	first.Attempts++
+	assert.Zero(t, second.Attempts)
+
+	require.NoError(t, queue.Clear(688))
+	assert.Equal(t, before, sharedAudioBytes.Load(), "sin colas que lo contengan deja de contarse")
+}
+
+func TestDBAudioStore_StoresSharedAudioOnce(t *testing.T) {
+	store := newTestAudioStore(t)
+	SetPendingAudioStore(store)
+	defer SetPendingAudioStore(nil)
+
+	data := []byte("RIFF clip de canal")
+	EnqueueAudio(1, "canal-shared", data, 1, []uint{686, 687, 689})
+
+	var blobs int64
+	require.NoError(t, store.db.Model(&models.QueuedAudioBlob{}).Count(&blobs).Error)
+	assert.Equal(t, int64(1), blobs)
+
+	status, err := store.Status(687)
+	require.NoError(t, err)
+	assert.Equal(t, int64(len(data)), status.Bytes)
+
+	for _, userID := range []uint{686, 687} {
+		got, err := store.Dequeue(userID)
+		require.NoError(t, err)
+		require.NotNil(t, got)
+		assert.Equal(t, data, got.AudioData)
+	}
+
+	// El audio compartido se conserva mientras alguna cola lo referencie
+	require.NoError(t, store.PurgeOlderThan(time.Time{}))
+	require.NoError(t, store.db.Model(&models.QueuedAudioBlob{}).Count(&blobs).Error)
+	assert.Equal(t, int64(1), blobs)
+
+	require.NoError(t, store.Clear(689))
+	require.NoError(t, store.PurgeOlderThan(time.Time{}))
+	require.NoError(t, store.db.Model(&models.QueuedAudioBlob{}).Count(&blobs).Error)
+	assert.Zero(t, blobs)
+}
@@ -0,0 +1,361 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"walkie-backend/internal/config"
+	"walkie-backend/internal/metrics"
+	"walkie-backend/internal/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// queuedSizeSQL es el tamaño de cada clip en cola sin leer el audio
+const queuedSizeSQL = "CASE WHEN object_key <> '' OR blob_key <> '' THEN size_bytes ELSE LENGTH(audio_data) END"
+
+// DBAudioStore persiste la cola de audios en la tabla queued_audios. El audio de un envío
+// a canal se guarda una vez en queued_audio_blobs y cada destinatario lo referencia.
+type DBAudioStore struct {
+	db *gorm.DB
+}
+
+// NewDBAudioStore crea el backend persistente; con db nil usa config.DB
+func NewDBAudioStore(db *gorm.DB) *DBAudioStore {
+	return &DBAudioStore{db: db}
+}
+
+func (s *DBAudioStore) conn() *gorm.DB {
+	if s.db != nil {
+		return s.db
+	}
+	return config.DB
+}
+
+func (s *DBAudioStore) Enqueue(recipientID uint, audio *PendingAudio) error {
+	row := models.QueuedAudio{
+		RecipientID: recipientID,
+		Urgent:      audio.IsUrgent(),
+		SenderID:    audio.SenderID,
+		Channel:     audio.Channel,
+		AudioData:   audio.AudioData,
+		Duration:    audio.Duration,
+		SampleRate:  audio.SampleRate,
+		Format:      audio.Format,
+		Priority:    audio.Priority,
+		Direct:      audio.Direct,
+		EnqueuedAt:  audio.Timestamp,
+		Attempts:    audio.Attempts,
+		ObjectKey:   audio.ObjectKey,
+		SizeBytes:   audio.ObjectSize,
+		// TransmissionID se conserva para las confirmaciones de escucha
+		TransmissionID: audio.TransmissionID,
+	}
+	if audio.shared == nil {
+		if row.AudioData == nil {
+			row.AudioData = []byte{}
+		}
+		return s.conn().Create(&row).Error
+	}
+
+	row.AudioData = []byte{}
+	row.BlobKey = audio.shared.key
+	row.SizeBytes = int(audio.shared.size)
+	blob := models.QueuedAudioBlob{BlobKey: audio.shared.key, Data: audio.AudioData}
+	return s.conn().Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&blob).Error; err != nil {
+			return err
+		}
+		return tx.Create(&row).Error
+	})
+}
+
+// nextVisible busca el siguiente clip del usuario que no espera un ACK y carga su audio
+// compartido. En Postgres usa SKIP LOCKED para que dos réplicas no entreguen el mismo
+// audio. missing indica que el audio compartido ya no existe.
+func nextVisible(tx *gorm.DB, userID uint, now time.Time) (row models.QueuedAudio, blob models.QueuedAudioBlob, missing bool, err error) {
+	query := tx.Where("recipient_id = ?", userID).
+		Where("lease_until IS NULL OR lease_until <= ?", now).
+		Order("priority = '" + PriorityEmergency + "' DESC").
+		Order("urgent DESC").
+		Order("id ASC")
+	if tx.Dialector.Name() == "postgres" {
+		query = query.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"})
+	}
+	if err = query.First(&row).Error; err != nil {
+		return row, blob, false, err
+	}
+	if row.BlobKey != "" {
+		err = tx.First(&blob, "blob_key = ?", row.BlobKey).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			// Sin el audio compartido el clip no se puede entregar; se descarta para no
+			// bloquear la cola
+			log.Printf("[COLA] usuario=%d audio compartido %s no encontrado, clip descartado", userID, row.BlobKey)
+			return row, blob, true, nil
+		}
+	}
+	return row, blob, false, err
+}
+
+// Dequeue toma y borra el siguiente clip en una transacción
+func (s *DBAudioStore) Dequeue(userID uint) (*PendingAudio, error) {
+	var row models.QueuedAudio
+	var blob models.QueuedAudioBlob
+	missing := false
+	err := s.conn().Transaction(func(tx *gorm.DB) error {
+		var err error
+		row, blob, missing, err = nextVisible(tx, userID, time.Now())
+		if err != nil {
+			return err
+		}
+		return deleteRow(tx, row)
+	})
+
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if missing {
+		return s.Dequeue(userID)
+	}
+	return pendingFromRow(row, blob), nil
+}
+
+// Lease marca el siguiente clip con el ID de entrega y lo oculta hasta until; el clip
+// sigue en la tabla hasta el ACK, así que sobrevive a un reinicio y cualquier réplica
+// puede confirmarlo o volver a entregarlo
+func (s *DBAudioStore) Lease(userID uint, deliveryID string, until time.Time, maxAttempts int) (*PendingAudio, error) {
+	for {
+		var row models.QueuedAudio
+		var blob models.QueuedAudioBlob
+		missing, exhausted := false, false
+		err := s.conn().Transaction(func(tx *gorm.DB) error {
+			var err error
+			row, blob, missing, err = nextVisible(tx, userID, time.Now())
+			if err != nil {
+				return err
+			}
+			if missing || row.Attempts >= maxAttempts {
+				exhausted = !missing
+				return deleteRow(tx, row)
+			}
+			row.Attempts++
+			row.DeliveryID = deliveryID
+			row.LeaseUntil = &until
+			return tx.Model(&models.QueuedAudio{}).Where("id = ?", row.ID).Updates(map[string]any{
+				"attempts":    row.Attempts,
+				"delivery_id": deliveryID,
+				"lease_until": until,
+			}).Error
+		})
+
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		if missing {
+			continue
+		}
+		pending := pendingFromRow(row, blob)
+		if exhausted {
+			dropUnackedAudio(userID, pending)
+			continue
+		}
+		if pending.Attempts > 1 {
+			metrics.Inc("walkie_audio_redelivered_total", nil)
+		}
+		return pending, nil
+	}
+}
+
+// Ack borra el clip entregado con deliveryID
+func (s *DBAudioStore) Ack(userID uint, deliveryID string) (bool, error) {
+	if deliveryID == "" {
+		return false, nil
+	}
+	n, err := deleteClips(s.conn(), "recipient_id = ? AND delivery_id = ?", userID, deliveryID)
+	return n > 0, err
+}
+
+// deleteRow borra un clip ya leído y, si era el último que lo usaba, su audio compartido
+func deleteRow(tx *gorm.DB, row models.QueuedAudio) error {
+	if err := tx.Delete(&models.QueuedAudio{}, row.ID).Error; err != nil {
+		return err
+	}
+	if row.BlobKey == "" {
+		return nil
+	}
+	return releaseBlobs(tx, []string{row.BlobKey})
+}
+
+// deleteClips borra los clips que cumplen la condición y, en la misma transacción, el
+// audio compartido que se queda sin ninguna cola que lo referencie
+func deleteClips(db *gorm.DB, query string, args ...any) (int64, error) {
+	var deleted int64
+	err := db.Transaction(func(tx *gorm.DB) error {
+		var keys []string
+		if err := tx.Model(&models.QueuedAudio{}).Where(query, args...).Where("blob_key <> ''").
+			Distinct("blob_key").Pluck("blob_key", &keys).Error; err != nil {
+			return err
+		}
+		res := tx.Where(query, args...).Delete(&models.QueuedAudio{})
+		if res.Error != nil {
+			return res.Error
+		}
+		deleted = res.RowsAffected
+		return releaseBlobs(tx, keys)
+	})
+	return deleted, err
+}
+
+// releaseBlobs borra de keys el audio compartido que ya no referencia ningún clip
+func releaseBlobs(tx *gorm.DB, keys []string) error {
+	if len(keys) == 0 {
+		return nil
+	}
+	referenced := tx.Model(&models.QueuedAudio{}).Select("blob_key").Where("blob_key IN ?", keys)
+	return tx.Where("blob_key IN ? AND blob_key NOT IN (?)", keys, referenced).Delete(&models.QueuedAudioBlob{}).Error
+}
+
+// pendingFromRow convierte la fila de la cola en el clip que se entrega
+func pendingFromRow(row models.QueuedAudio, blob models.QueuedAudioBlob) *PendingAudio {
+	pending := &PendingAudio{
+		SenderID:   row.SenderID,
+		Channel:    row.Channel,
+		AudioData:  row.AudioData,
+		Timestamp:  row.EnqueuedAt,
+		Duration:   row.Duration,
+		SampleRate: row.SampleRate,
+		Format:     row.Format,
+		Priority:   row.Priority,
+		Direct:     row.Direct,
+		Attempts:   row.Attempts,
+		ObjectKey:  row.ObjectKey,
+		ObjectSize: row.SizeBytes,
+
+		TransmissionID: row.TransmissionID,
+		DeliveryID:     row.DeliveryID,
+	}
+	if row.BlobKey != "" {
+		pending.AudioData = blob.Data
+		pending.ObjectSize = 0
+		pending.shared = &sharedAudio{key: row.BlobKey, size: int64(row.SizeBytes)}
+	}
+	return pending
+}
+
+func (s *DBAudioStore) Clear(userID uint) error {
+	_, err := deleteClips(s.conn(), "recipient_id = ?", userID)
+	return err
+}
+
+// PurgeOlderThan borra los clips caducados y el audio compartido que ya no referencia
+// ninguna cola, también el que hubiera quedado huérfano por una carrera con un encolado
+func (s *DBAudioStore) PurgeOlderThan(cutoff time.Time) error {
+	if _, err := deleteClips(s.conn(), "enqueued_at < ?", cutoff); err != nil {
+		return err
+	}
+	referenced := s.conn().Model(&models.QueuedAudio{}).Select("blob_key").Where("blob_key <> ''")
+	return s.conn().Where("blob_key NOT IN (?)", referenced).Delete(&models.QueuedAudioBlob{}).Error
+}
+
+// queuedRow son los metadatos de un clip en cola, sin el audio
+type queuedRow struct {
+	ID         uint
+	Urgent     bool
+	EnqueuedAt time.Time
+	Size       int64
+}
+
+func (s *DBAudioStore) queuedRows(userID uint) ([]queuedRow, error) {
+	var rows []queuedRow
+	err := s.conn().Model(&models.QueuedAudio{}).
+		Select("id, urgent, enqueued_at, "+queuedSizeSQL+" AS size").
+		Where("recipient_id = ?", userID).
+		Order("id ASC").
+		Scan(&rows).Error
+	return rows, err
+}
+
+func (s *DBAudioStore) EvictOverflow(userID uint, maxClips int, maxBytes int64) (int, error) {
+	rows, err := s.queuedRows(userID)
+	if err != nil {
+		return 0, err
+	}
+	items := make([]queueItem, len(rows))
+	for i, row := range rows {
+		items[i] = queueItem{urgent: row.Urgent, at: row.EnqueuedAt, size: row.Size}
+	}
+	victims := evictionVictims(items, maxClips, maxBytes)
+	if len(victims) == 0 {
+		return 0, nil
+	}
+
+	ids := make([]uint, len(victims))
+	for i, v := range victims {
+		ids[i] = rows[v].ID
+	}
+	n, err := deleteClips(s.conn(), "recipient_id = ? AND id IN ?", userID, ids)
+	return int(n), err
+}
+
+func (s *DBAudioStore) Status(userID uint) (QueueStatus, error) {
+	rows, err := s.queuedRows(userID)
+	if err != nil {
+		return QueueStatus{}, err
+	}
+	var status QueueStatus
+	for _, row := range rows {
+		status.Depth++
+		status.Bytes += row.Size
+		if row.Urgent {
+			status.Urgent++
+		}
+		if status.OldestAt.IsZero() || row.EnqueuedAt.Before(status.OldestAt) {
+			status.OldestAt = row.EnqueuedAt
+		}
+	}
+	return status, nil
+}
+
+func (s *DBAudioStore) Ping(ctx context.Context) error {
+	db := s.conn()
+	if db == nil {
+		return config.ErrDBUnavailable
+	}
+	var ids []uint
+	return db.WithContext(ctx).Model(&models.QueuedAudio{}).Limit(1).Pluck("id", &ids).Error
+}
+
+func (s *DBAudioStore) Totals() (QueueTotals, error) {
+	var rows []struct {
+		RecipientID uint
+		Clips       int
+		Urgent      int
+		Size        int64
+	}
+	err := s.conn().Model(&models.QueuedAudio{}).
+		Select("recipient_id, COUNT(*) AS clips, SUM(CASE WHEN urgent THEN 1 ELSE 0 END) AS urgent, " +
+			"SUM(" + queuedSizeSQL + ") AS size").
+		Group("recipient_id").
+		Scan(&rows).Error
+	if err != nil {
+		return QueueTotals{}, err
+	}
+	var totals QueueTotals
+	for _, row := range rows {
+		totals.Users++
+		totals.Clips += row.Clips
+		totals.Urgent += row.Urgent
+		totals.Bytes += row.Size
+		totals.MaxDepth = max(totals.MaxDepth, row.Clips)
+	}
+	return totals, nil
+}
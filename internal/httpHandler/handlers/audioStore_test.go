@@ -0,0 +1,188 @@
+package handlers
+
+import (
+	"testing"
+	"time"
+
+	"walkie-backend/internal/models"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func newTestAudioStore(t *testing.T) *DBAudioStore {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open("file:audio_store?mode=memory&cache=shared"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("sqlite open: %v", err)
+	}
+	if err := db.Migrator().DropTable(&models.QueuedAudio{}, &models.QueuedAudioBlob{}); err != nil {
+		t.Fatalf("drop: %v", err)
+	}
+	if err := db.AutoMigrate(&models.QueuedAudio{}, &models.QueuedAudioBlob{}); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	return NewDBAudioStore(db)
+}
+
+func TestDBAudioStore_DeliversUrgentFirstAndSurvivesNewInstance(t *testing.T) {
+	store := newTestAudioStore(t)
+
+	_ = store.Enqueue(7, newPendingAudio(1, "canal-1", []byte("normal"), 1, PriorityNormal))
+	_ = store.Enqueue(7, newPendingAudio(2, "canal-1", []byte("urgente"), 1, PriorityUrgent))
+
+	// Otra instancia sobre la misma base simula un reinicio u otra réplica
+	restarted := NewDBAudioStore(store.db)
+
+	first, err := restarted.Dequeue(7)
+	if err != nil || first == nil || string(first.AudioData) != "urgente" || !first.IsUrgent() {
+		t.Fatalf("expected urgent clip first, got %+v (err=%v)", first, err)
+	}
+	second, _ := restarted.Dequeue(7)
+	if second == nil || string(second.AudioData) != "normal" || second.Channel != "canal-1" {
+		t.Fatalf("expected normal clip second, got %+v", second)
+	}
+	if empty, err := restarted.Dequeue(7); empty != nil || err != nil {
+		t.Fatalf("expected empty queue, got %+v (err=%v)", empty, err)
+	}
+}
+
+func TestDBAudioStore_PurgeAndClear(t *testing.T) {
+	store := newTestAudioStore(t)
+
+	old := newPendingAudio(1, "canal-1", []byte("viejo"), 1, PriorityNormal)
+	old.Timestamp = time.Now().Add(-10 * time.Minute)
+	_ = store.Enqueue(8, old)
+	_ = store.Enqueue(8, newPendingAudio(1, "canal-1", []byte("nuevo"), 1, PriorityNormal))
+	_ = store.Enqueue(9, newPendingAudio(1, "canal-1", []byte("otro"), 1, PriorityNormal))
+
+	if err := store.PurgeOlderThan(time.Now().Add(-5 * time.Minute)); err != nil {
+		t.Fatalf("purge: %v", err)
+	}
+	if got, _ := store.Dequeue(8); got == nil || string(got.AudioData) != "nuevo" {
+		t.Fatalf("expected only recent clip to remain, got %+v", got)
+	}
+
+	if err := store.Clear(9); err != nil {
+		t.Fatalf("clear: %v", err)
+	}
+	if got, _ := store.Dequeue(9); got != nil {
+		t.Fatalf("expected cleared queue, got %+v", got)
+	}
+}
+
+func TestDBAudioStore_ReleasesSharedAudioWithLastClip(t *testing.T) {
+	store := newTestAudioStore(t)
+	blobs := func() int64 {
+		var n int64
+		store.db.Model(&models.QueuedAudioBlob{}).Count(&n)
+		return n
+	}
+
+	clip := newPendingAudio(1, "canal-3", []byte("compartido"), 1, PriorityNormal)
+	shareAudio(clip)
+	for _, userID := range []uint{21, 22, 23} {
+		if err := store.Enqueue(userID, clip.forRecipient()); err != nil {
+			t.Fatalf("enqueue: %v", err)
+		}
+	}
+
+	if got, _ := store.Dequeue(21); got == nil || string(got.AudioData) != "compartido" {
+		t.Fatalf("expected shared clip, got %+v", got)
+	}
+	if err := store.Clear(22); err != nil {
+		t.Fatalf("clear: %v", err)
+	}
+	if n := blobs(); n != 1 {
+		t.Fatalf("expected shared audio kept while a queue uses it, got %d blobs", n)
+	}
+
+	leased, err := store.Lease(23, "entrega-1", time.Now().Add(time.Minute), 3)
+	if err != nil || leased == nil {
+		t.Fatalf("lease: %+v (err=%v)", leased, err)
+	}
+	if ok, err := store.Ack(23, "entrega-1"); !ok || err != nil {
+		t.Fatalf("ack: %v %v", ok, err)
+	}
+	if n := blobs(); n != 0 {
+		t.Fatalf("expected shared audio released with the last clip, got %d blobs", n)
+	}
+}
+
+func TestSetPendingAudioStore_RoutesPackageFunctions(t *testing.T) {
+	store := newTestAudioStore(t)
+	SetPendingAudioStore(store)
+	defer SetPendingAudioStore(nil)
+
+	EnqueueAudio(1, "canal-2", []byte("clip"), 1, []uint{1, 5})
+	if got := DequeueAudio(5); got == nil || string(got.AudioData) != "clip" {
+		t.Fatalf("expected clip through configured store, got %+v", got)
+	}
+}
+
+func TestDBAudioStore_EmergencyAndDirectRoundTrip(t *testing.T) {
+	store := newTestAudioStore(t)
+
+	_ = store.Enqueue(8, newPendingAudio(1, "canal-1", []byte("urgente"), 1, PriorityUrgent))
+	direct := newPendingAudio(2, "", []byte("emergencia"), 1, PriorityEmergency)
+	direct.Direct = true
+	_ = store.Enqueue(8, direct)
+
+	first, err := store.Dequeue(8)
+	if err != nil || first == nil || string(first.AudioData) != "emergencia" || !first.Direct || !first.IsEmergency() {
+		t.Fatalf("expected direct emergency clip first, got %+v (err=%v)", first, err)
+	}
+}
+
+func TestDBAudioStore_LeaseSurvivesRestartUntilAck(t *testing.T) {
+	store := newTestAudioStore(t)
+	_ = store.Enqueue(11, newPendingAudio(1, "canal-1", []byte("clip"), 1, PriorityNormal))
+
+	leased, err := store.Lease(11, "entrega-1", time.Now().Add(time.Minute), 3)
+	if err != nil || leased == nil || leased.DeliveryID != "entrega-1" || leased.Attempts != 1 {
+		t.Fatalf("expected leased clip, got %+v (err=%v)", leased, err)
+	}
+	// Con la entrega pendiente de ACK el clip no se ve, pero sigue en la tabla
+	restarted := NewDBAudioStore(store.db)
+	if hidden, _ := restarted.Lease(11, "entrega-2", time.Now().Add(time.Minute), 3); hidden != nil {
+		t.Fatalf("leased clip delivered twice: %+v", hidden)
+	}
+	if status, _ := restarted.Status(11); status.Depth != 1 {
+		t.Fatalf("expected leased clip to stay queued, got depth %d", status.Depth)
+	}
+
+	// Otra réplica confirma la entrega y el clip se borra
+	if ok, err := restarted.Ack(12, "entrega-1"); ok || err != nil {
+		t.Fatalf("ack by another user must fail (ok=%v err=%v)", ok, err)
+	}
+	if ok, err := restarted.Ack(11, "entrega-1"); !ok || err != nil {
+		t.Fatalf("expected ack to succeed (ok=%v err=%v)", ok, err)
+	}
+	if status, _ := store.Status(11); status.Depth != 0 {
+		t.Fatalf("expected empty queue after ack, got depth %d", status.Depth)
+	}
+}
+
+func TestDBAudioStore_ExpiredLeaseIsRedelivered(t *testing.T) {
+	store := newTestAudioStore(t)
+	_ = store.Enqueue(13, newPendingAudio(1, "canal-1", []byte("clip"), 1, PriorityNormal))
+
+	if first, _ := store.Lease(13, "entrega-1", time.Now().Add(-time.Second), 2); first == nil {
+		t.Fatal("expected first delivery")
+	}
+	second, err := store.Lease(13, "entrega-2", time.Now().Add(-time.Second), 2)
+	if err != nil || second == nil || second.DeliveryID != "entrega-2" || second.Attempts != 2 {
+		t.Fatalf("expected redelivery, got %+v (err=%v)", second, err)
+	}
+	if ok, _ := store.Ack(13, "entrega-1"); ok {
+		t.Fatal("stale delivery ID must not ack the clip")
+	}
+
+	// Agotadas AUDIO_MAX_DELIVERIES entregas el clip se descarta
+	if third, err := store.Lease(13, "entrega-3", time.Now().Add(time.Minute), 2); third != nil || err != nil {
+		t.Fatalf("expected clip to be dropped, got %+v (err=%v)", third, err)
+	}
+	if status, _ := store.Status(13); status.Depth != 0 {
+		t.Fatalf("expected dropped clip, got depth %d", status.Depth)
+	}
+}
@@ -0,0 +1,202 @@
+package handlers
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+)
+
+const (
+	streamPollInterval = 2 * time.Second
+	streamHeartbeat    = 15 * time.Second
+)
+
+// audioFrame es el JSON que se envía en cada evento "audio" del stream SSE
+type audioFrame struct {
+	From        uint    `json:"from"`
+	Channel     string  `json:"channel"`
+	Format      string  `json:"format"`
+	MimeType    string  `json:"mimeType"`
+	Timestamp   string  `json:"timestamp"`
+	AgeSeconds  float64 `json:"ageSeconds"`
+	Priority    string  `json:"priority,omitempty"`
+	Direct      bool    `json:"direct,omitempty"`
+	Notice      string  `json:"notice,omitempty"`
+	Duration    float64 `json:"duration"`
+	SampleRate  int     `json:"sampleRate"`
+	AudioBase64 string  `json:"audioBase64"`
+	// AudioURL sustituye al audio en línea cuando está en el almacenamiento de objetos
+	AudioURL string `json:"audioUrl,omitempty"`
+	// TransmissionID es el ID para POST /audio/played/{id}
+	TransmissionID string `json:"transmissionId,omitempty"`
+}
+
+// audioWaiters despierta a los streams abiertos cuando llega audio para su usuario.
+// Con la cola en base de datos las réplicas no se avisan entre sí; el sondeo
+// periódico del stream cubre ese caso.
+var audioWaiters = struct {
+	sync.Mutex
+	byUser map[uint]map[chan struct{}]struct{}
+}{
+	byUser: make(map[uint]map[chan struct{}]struct{}),
+}
+
+func subscribeAudio(userID uint) (<-chan struct{}, func()) {
+	ch := make(chan struct{}, 1)
+
+	audioWaiters.Lock()
+	if audioWaiters.byUser[userID] == nil {
+		audioWaiters.byUser[userID] = make(map[chan struct{}]struct{})
+	}
+	audioWaiters.byUser[userID][ch] = struct{}{}
+	audioWaiters.Unlock()
+
+	return ch, func() {
+		audioWaiters.Lock()
+		delete(audioWaiters.byUser[userID], ch)
+		if len(audioWaiters.byUser[userID]) == 0 {
+			delete(audioWaiters.byUser, userID)
+		}
+		audioWaiters.Unlock()
+	}
+}
+
+func notifyAudioAvailable(userID uint) {
+	audioWaiters.Lock()
+	defer audioWaiters.Unlock()
+	for ch := range audioWaiters.byUser[userID] {
+		select {
+		case ch <- struct{}{}:
+		default:
+		}
+	}
+}
+
+type audioStreamDeps struct {
+	audioPollDeps
+	subscribe    func(uint) (<-chan struct{}, func())
+	pollInterval time.Duration
+	heartbeat    time.Duration
+}
+
+func newAudioStreamDeps() audioStreamDeps {
+	return audioStreamDeps{
+		audioPollDeps: newAudioPollDeps(),
+		subscribe:     subscribeAudio,
+		pollInterval:  streamPollInterval,
+		heartbeat:     streamHeartbeat,
+	}
+}
+
+// GET /audio/stream
+func AudioStream(w http.ResponseWriter, r *http.Request) {
+	runAudioStream(w, r, newAudioStreamDeps())
+}
+
+func runAudioStream(w http.ResponseWriter, r *http.Request, deps audioStreamDeps) {
+	if !requireDB(w) {
+		return
+	}
+
+	user, err := deps.resolveUser(r)
+	if err != nil {
+		http.Error(w, "X-Auth-Token inválido o expirado", http.StatusUnauthorized)
+		return
+	}
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "Streaming no soportado", http.StatusInternalServerError)
+		return
+	}
+
+	userID := user.ID
+	userSvc := deps.newUserService()
+	wake, unsubscribe := deps.subscribe(userID)
+	defer unsubscribe()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	fmt.Fprintf(w, "retry: %d\n\n", (3 * time.Second).Milliseconds())
+	flusher.Flush()
+
+	log.Printf("AudioStream: usuario %d conectado", userID)
+	defer log.Printf("AudioStream: usuario %d desconectado", userID)
+
+	poll := time.NewTicker(deps.pollInterval)
+	defer poll.Stop()
+	heartbeat := time.NewTicker(deps.heartbeat)
+	defer heartbeat.Stop()
+
+	for {
+		for {
+			pending := nextDeliverableAudio(userID, userSvc, deps.dequeueAudio, "AudioStream")
+			if pending == nil {
+				break
+			}
+			if err := writeAudioEvent(w, pending); err != nil {
+				log.Printf("AudioStream: error enviando audio a usuario %d: %v", userID, err)
+				return
+			}
+			flusher.Flush()
+		}
+
+		select {
+		case <-r.Context().Done():
+			return
+		case <-wake:
+		case <-poll.C:
+		case <-heartbeat.C:
+			if _, err := fmt.Fprint(w, ": ping\n\n"); err != nil {
+				return
+			}
+			flusher.Flush()
+		}
+	}
+}
+
+// newAudioFrame arma la representación JSON de un clip pendiente
+func newAudioFrame(pending *PendingAudio, now time.Time) audioFrame {
+	age := pending.Age(now)
+	frame := audioFrame{
+		From:       pending.SenderID,
+		Channel:    pending.Channel,
+		Format:     pending.Format,
+		MimeType:   clipMimeType(pending.Format),
+		Timestamp:  pending.Timestamp.UTC().Format(time.RFC3339Nano),
+		AgeSeconds: age.Seconds(),
+		Priority:   pending.Priority,
+		Direct:     pending.Direct,
+		Notice:     audioAgeNotice(age),
+		Duration:   pending.Duration,
+		SampleRate: pending.SampleRate,
+
+		TransmissionID: pending.TransmissionID,
+	}
+	if link := pendingAudioURL(pending); link != "" {
+		frame.AudioURL = link
+		return frame
+	}
+	data, err := pendingAudioBytes(pending)
+	if err != nil {
+		log.Printf("[BLOBS] no se pudo leer %s: %v", pending.ObjectKey, err)
+	}
+	frame.AudioBase64 = base64.StdEncoding.EncodeToString(data)
+	return frame
+}
+
+func writeAudioEvent(w http.ResponseWriter, pending *PendingAudio) error {
+	payload, err := json.Marshal(newAudioFrame(pending, time.Now()))
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "id: %d\nevent: audio\ndata: %s\n\n", pending.Timestamp.UnixNano(), payload)
+	return err
+}
@@ -0,0 +1,81 @@
+package handlers
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"walkie-backend/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+func TestAudioStream_Unauthorized(t *testing.T) {
+	deps := newAudioStreamDeps()
+	deps.resolveUser = func(*http.Request) (*models.User, error) { return nil, gorm.ErrRecordNotFound }
+
+	rec := httptest.NewRecorder()
+	runAudioStream(rec, httptest.NewRequest(http.MethodGet, "/audio/stream", nil), deps)
+
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+}
+
+func TestAudioStream_PushesQueuedAudioForCurrentChannel(t *testing.T) {
+	mockUser := &models.User{Model: gorm.Model{ID: 3}, CurrentChannel: &models.Channel{Code: "canal-1"}}
+	queue := []*PendingAudio{
+		{SenderID: 8, Channel: "canal-viejo", AudioData: []byte("descartado"), Timestamp: time.Now()},
+		{SenderID: 9, Channel: "canal-1", AudioData: []byte("hola"), Timestamp: time.Now(), Format: "wav", Priority: PriorityUrgent},
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	deps := newAudioStreamDeps()
+	deps.resolveUser = func(*http.Request) (*models.User, error) { return mockUser, nil }
+	deps.newUserService = func() userService { return &mockUserService{user: mockUser} }
+	deps.dequeueAudio = func(uint) *PendingAudio {
+		if len(queue) == 0 {
+			cancel()
+			return nil
+		}
+		next := queue[0]
+		queue = queue[1:]
+		return next
+	}
+
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/audio/stream", nil).WithContext(ctx)
+	runAudioStream(rec, req, deps)
+
+	assert.Equal(t, "text/event-stream", rec.Header().Get("Content-Type"))
+	body := rec.Body.String()
+	assert.Equal(t, 1, strings.Count(body, "event: audio"))
+
+	dataLine := body[strings.Index(body, "data: ")+len("data: "):]
+	dataLine = dataLine[:strings.Index(dataLine, "\n")]
+	var frame audioFrame
+	if err := json.Unmarshal([]byte(dataLine), &frame); err != nil {
+		t.Fatalf("invalid frame %q: %v", dataLine, err)
+	}
+	decoded, _ := base64.StdEncoding.DecodeString(frame.AudioBase64)
+	assert.Equal(t, "hola", string(decoded))
+	assert.Equal(t, uint(9), frame.From)
+	assert.Equal(t, PriorityUrgent, frame.Priority)
+	assert.Equal(t, "audio/wav", frame.MimeType)
+}
+
+func TestNotifyAudioAvailable_WakesSubscribers(t *testing.T) {
+	wake, unsubscribe := subscribeAudio(77)
+	defer unsubscribe()
+
+	notifyAudioAvailable(77)
+	select {
+	case <-wake:
+	case <-time.After(time.Second):
+		t.Fatal("expected subscriber to be woken")
+	}
+}
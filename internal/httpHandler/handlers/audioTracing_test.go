@@ -0,0 +1,80 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"walkie-backend/internal/ai"
+	"walkie-backend/internal/models"
+	"walkie-backend/pkg/tracing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+func TestRunAudioIngest_ExportsStageSpans(t *testing.T) {
+	var mu sync.Mutex
+	names := map[string]bool{}
+	collector := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			ResourceSpans []struct {
+				ScopeSpans []struct {
+					Spans []struct {
+						Name string `json:"name"`
+					} `json:"spans"`
+				} `json:"scopeSpans"`
+			} `json:"resourceSpans"`
+		}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		mu.Lock()
+		defer mu.Unlock()
+		for _, rs := range body.ResourceSpans {
+			for _, ss := range rs.ScopeSpans {
+				for _, s := range ss.Spans {
+					names[s.Name] = true
+				}
+			}
+		}
+	}))
+	defer collector.Close()
+
+	tracer := tracing.NewTracer(collector.URL+"/v1/traces", "walkie-test", nil)
+	tracing.SetGlobal(tracer)
+	defer tracing.SetGlobal(nil)
+
+	mockUser := &models.User{Model: gorm.Model{ID: 61}, DisplayName: "traza"}
+	deps := newAudioIngestDeps()
+	deps.readUserID = func(*http.Request) (uint, error) { return 61, nil }
+	deps.newUserService = func() userService { return &mockUserService{user: mockUser} }
+	deps.localSTT = func() sttClient { return nil }
+	deps.ensureSTT = func() (sttClient, error) { return &mockSTT{text: "dame la lista de canales"}, nil }
+	deps.ensureAI = func() (ai.Analyzer, error) {
+		return &mockQwen{result: ai.CommandResult{IsCommand: true, Intent: "request_channel_list"}}, nil
+	}
+	deps.validateAudio = func([]byte, string) bool { return true }
+	deps.readAudio = func(*http.Request) ([]byte, string, error) { return []byte("audio"), "audio/wav", nil }
+	deps.executeCommand = func(*models.User, userService, ai.CommandResult) (CommandResponse, error) {
+		return CommandResponse{Status: "ok", Intent: "request_channel_list"}, nil
+	}
+
+	rec := httptest.NewRecorder()
+	runAudioIngest(rec, httptest.NewRequest(http.MethodPost, "/audio/ingest", bytes.NewReader(nil)), deps)
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	tracer.Shutdown(ctx)
+
+	mu.Lock()
+	defer mu.Unlock()
+	for _, name := range []string{"audio.ingest", "audio.received", "audio.stt", "audio.ai", "audio.execute_command"} {
+		assert.True(t, names[name], "falta el span %s", name)
+	}
+}
@@ -8,21 +8,26 @@ import (
 	"net/http/httptest"
 	"testing"
 
+	"walkie-backend/internal/ai"
 	"walkie-backend/internal/models"
-	"walkie-backend/pkg/qwen"
+	"walkie-backend/internal/services"
 
 	"github.com/stretchr/testify/assert"
 	"gorm.io/gorm"
 )
 
-// mockUserService es un mock para la interfaz userService.
-// No puede implementar services.UserService directamente debido a la conversión de tipo en el código original,
-// pero puede satisfacer la interfaz userService para la mayoría de las dependencias.
+// mockUserService es un mock para la interfaz userService; registra las conexiones y
+// desconexiones para que los tests puedan ejecutar comandos sin base de datos.
 type mockUserService struct {
 	user        *models.User
 	userErr     error
 	channels    []models.Channel
 	channelsErr error
+	members     map[string][]models.User
+	connectErr  error
+	connected   []string
+	disconnects int
+	meta        services.EventMeta
 }
 
 func (m *mockUserService) GetUserWithChannel(id uint) (*models.User, error) {
@@ -42,6 +47,28 @@ func (m *mockUserService) GetAvailableChannels() ([]models.Channel, error) {
 	return m.channels, nil
 }
 
+func (m *mockUserService) ConnectUserToChannel(userID uint, channelCode string) error {
+	if m.connectErr != nil {
+		return m.connectErr
+	}
+	m.connected = append(m.connected, channelCode)
+	return nil
+}
+
+func (m *mockUserService) DisconnectUserFromCurrentChannel(userID uint) error {
+	m.disconnects++
+	return nil
+}
+
+func (m *mockUserService) GetChannelActiveUsers(channelCode string) ([]models.User, error) {
+	return m.members[channelCode], nil
+}
+
+func (m *mockUserService) WithEventMeta(meta services.EventMeta) userService {
+	m.meta = meta
+	return m
+}
+
 // mockSTT es un mock para la interfaz sttClient.
 type mockSTT struct {
 	text   string
@@ -54,28 +81,18 @@ func (m *mockSTT) TranscribeAudio(ctx context.Context, audio []byte, format stri
 	return m.text, m.err
 }
 
-// mockQwen es un mock para la interfaz qwenClient.
+// mockQwen es un mock para la interfaz ai.Analyzer.
 type mockQwen struct {
-	result qwen.CommandResult
-	err    error
+	result  ai.CommandResult
+	err     error
+	pending string
 }
 
-func (m *mockQwen) AnalyzeTranscript(ctx context.Context, text string, channels []string, state string, pendingChannel string) (qwen.CommandResult, error) {
+func (m *mockQwen) AnalyzeTranscript(ctx context.Context, text string, channels []string, state string, pendingChannel string) (ai.CommandResult, error) {
+	m.pending = pendingChannel
 	return m.result, m.err
 }
 
-func TestAudioIngest_MethodNotAllowed(t *testing.T) {
-	methods := []string{http.MethodGet, http.MethodPut, http.MethodDelete}
-	for _, method := range methods {
-		t.Run(method, func(t *testing.T) {
-			req := httptest.NewRequest(method, "/audio/ingest", nil)
-			rec := httptest.NewRecorder()
-			AudioIngest(rec, req)
-			assert.Equal(t, http.StatusMethodNotAllowed, rec.Code)
-		})
-	}
-}
-
 func TestRunAudioIngest_InvalidInput(t *testing.T) {
 	// Caso 1: Falla en la lectura del ID de usuario (token faltante)
 	t.Run("missing_user_id", func(t *testing.T) {
@@ -135,14 +152,14 @@ func TestRunAudioIngest_CommandFlow(t *testing.T) {
 		return &mockUserService{user: mockUser}
 	}
 	deps.ensureSTT = func() (sttClient, error) { return &mockSTT{text: "dame la lista de canales"}, nil }
-	deps.ensureAI = func() (qwenClient, error) {
-		return &mockQwen{result: qwen.CommandResult{IsCommand: true, Intent: "request_channel_list"}}, nil
+	deps.ensureAI = func() (ai.Analyzer, error) {
+		return &mockQwen{result: ai.CommandResult{IsCommand: true, Intent: "request_channel_list"}}, nil
 	}
 	deps.validateAudio = func([]byte, string) bool { return true }
 	deps.readAudio = func(*http.Request) ([]byte, string, error) { return []byte("audio data"), "audio/wav", nil }
 
-	// Mock para executeCommand para evitar la conversión de tipo
-	deps.executeCommand = func(user *models.User, svc userService, result qwen.CommandResult) (CommandResponse, error) {
+	// executeCommand simulado: sólo se comprueba que llega el intent
+	deps.executeCommand = func(user *models.User, svc userService, result ai.CommandResult) (CommandResponse, error) {
 		assert.Equal(t, "request_channel_list", result.Intent)
 		return CommandResponse{Status: "ok", Intent: "request_channel_list", Message: "Canales: 1, 2"}, nil
 	}
@@ -158,6 +175,33 @@ func TestRunAudioIngest_CommandFlow(t *testing.T) {
 
 
 
+func TestRunAudioIngest_ConnectCommandWithMockService(t *testing.T) {
+	mockUser := &models.User{Model: gorm.Model{ID: 1}, DisplayName: "Ana"}
+	svc := &mockUserService{
+		user:     mockUser,
+		channels: []models.Channel{{Code: "canal-1"}, {Code: "canal-2"}},
+	}
+
+	deps := newAudioIngestDeps()
+	deps.readUserID = func(*http.Request) (uint, error) { return 1, nil }
+	deps.newUserService = func() userService { return svc }
+	deps.ensureSTT = func() (sttClient, error) { return &mockSTT{text: "conéctame al canal dos"}, nil }
+	deps.ensureAI = func() (ai.Analyzer, error) {
+		return &mockQwen{result: ai.CommandResult{IsCommand: true, Intent: "request_channel_connect", Channels: []string{"canal-2"}}}, nil
+	}
+	deps.validateAudio = func([]byte, string) bool { return true }
+	deps.readAudio = func(*http.Request) ([]byte, string, error) { return buildTestWAV(4000), "audio/wav", nil }
+
+	rec := httptest.NewRecorder()
+	runAudioIngest(rec, httptest.NewRequest(http.MethodPost, "/audio/ingest", nil), deps)
+
+	assert.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	assert.Contains(t, rec.Body.String(), "Conectado al canal 2")
+	assert.Equal(t, []string{"canal-2"}, svc.connected)
+	assert.Equal(t, "user:1", svc.meta.Actor)
+	assert.Equal(t, models.EventSourceVoice, svc.meta.Source)
+}
+
 func TestAudioPoll_Unauthorized(t *testing.T) {
 	deps := newAudioPollDeps()
 	deps.resolveUser = func(r *http.Request) (*models.User, error) {
@@ -228,6 +272,8 @@ func TestAudioPoll_AudioAvailable(t *testing.T) {
 
 func TestAudioPoll_UserChangedChannel(t *testing.T) {
 	mockUser := &models.User{Model: gorm.Model{ID: 1}, CurrentChannel: &models.Channel{Code: "other"}}
+	// Otros tests dejan en caché que el usuario 1 está en "general"
+	forgetMembership(1)
 
 	deps := newAudioPollDeps()
 	deps.resolveUser = func(r *http.Request) (*models.User, error) {
@@ -0,0 +1,105 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"walkie-backend/internal/config"
+	"walkie-backend/internal/models"
+	"walkie-backend/internal/response"
+	"walkie-backend/internal/services"
+)
+
+const auditActionCommand = "command"
+
+// recordCommandAudit guarda el comando de voz ejecutado con su resultado y la latencia
+// total de la petición. El resultado es el motivo con el que terminó /audio/ingest.
+func recordCommandAudit(userID uint, intent, channel, result string, latency time.Duration) {
+	if config.DB == nil || !config.DBAvailable() {
+		return
+	}
+	outcome := "ok"
+	if strings.HasSuffix(result, "_error") {
+		outcome = "error"
+	}
+	services.RecordAudit(nil, models.AuditEntry{
+		Actor:     fmt.Sprintf("user:%d", userID),
+		Action:    auditActionCommand,
+		UserID:    &userID,
+		Channel:   channel,
+		Details:   "resultado=" + result,
+		Source:    models.EventSourceVoice,
+		Outcome:   outcome,
+		Intent:    intent,
+		LatencyMs: latency.Milliseconds(),
+	})
+	services.EmitWebhookEvent(services.WebhookEventCommandExecuted, channel, map[string]any{
+		"userId":    userID,
+		"intent":    intent,
+		"result":    result,
+		"outcome":   outcome,
+		"latencyMs": latency.Milliseconds(),
+	})
+}
+
+// GET /admin/audit?user=ID&channel=CODE&since=RFC3339|24h&limit=N
+// Registro unificado de comandos, cambios administrativos y conexiones/desconexiones,
+// del más reciente al más antiguo. Sirve para responder "¿quién me sacó del canal?".
+func AdminAudit(w http.ResponseWriter, r *http.Request) {
+	if !requireAdmin(w, r) || !requireDB(w) {
+		return
+	}
+
+	q := r.URL.Query()
+	var filter services.AuditFilter
+	if raw := q.Get("user"); raw != "" {
+		userID, err := strconv.ParseUint(raw, 10, 64)
+		if err != nil || userID == 0 {
+			response.WriteErr(w, http.StatusBadRequest, "Parámetro user inválido")
+			return
+		}
+		filter.UserID = uint(userID)
+	}
+	filter.Channel = strings.TrimSpace(q.Get("channel"))
+	if raw := q.Get("since"); raw != "" {
+		since, err := parseAuditSince(raw, time.Now())
+		if err != nil {
+			response.WriteErr(w, http.StatusBadRequest, "Parámetro since inválido, se espera RFC3339 o una duración como 24h")
+			return
+		}
+		filter.Since = since
+	}
+	if raw := q.Get("limit"); raw != "" {
+		limit, err := strconv.Atoi(raw)
+		if err != nil || limit <= 0 || limit > services.MaxAuditLimit {
+			response.WriteErr(w, http.StatusBadRequest, "limit debe estar entre 1 y "+strconv.Itoa(services.MaxAuditLimit))
+			return
+		}
+		filter.Limit = limit
+	}
+
+	records, err := services.AuditLog(config.DB, filter)
+	if err != nil {
+		response.WriteErr(w, http.StatusInternalServerError, "No se pudo leer la auditoría")
+		return
+	}
+	response.WriteJSON(w, http.StatusOK, map[string]any{
+		"count":   len(records),
+		"records": records,
+	})
+}
+
+// parseAuditSince acepta un instante RFC3339 o una duración hacia atrás desde now
+func parseAuditSince(raw string, now time.Time) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, raw); err == nil {
+		return t, nil
+	}
+	d, err := time.ParseDuration(raw)
+	if err != nil || d <= 0 {
+		return time.Time{}, fmt.Errorf("since inválido: %q", raw)
+	}
+	return now.Add(-d), nil
+}
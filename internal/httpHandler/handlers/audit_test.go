@@ -0,0 +1,88 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"walkie-backend/internal/config"
+	"walkie-backend/internal/models"
+	"walkie-backend/internal/services"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAdminAudit_MergesCommandsAndChannelEvents(t *testing.T) {
+	cleanup := setupChannelsTestDB(t)
+	defer cleanup()
+	t.Setenv("ADMIN_TOKEN", "secreto")
+	require.NoError(t, config.DB.AutoMigrate(&models.AuditEntry{}, &models.ChannelEvent{}))
+
+	require.NoError(t, config.DB.Create(&models.Channel{Code: "canal-9", Name: "Nueve", MaxUsers: 10}).Error)
+	luis := &models.User{DisplayName: "Luis", IsActive: true}
+	require.NoError(t, config.DB.Create(luis).Error)
+	require.NoError(t, services.NewUserService().ConnectUserToChannel(luis.ID, "canal-9"))
+
+	recordCommandAudit(luis.ID, "request_channel_list", "canal-9", "command_response", 350*time.Millisecond)
+	recordCommandAudit(664, "request_channel_list", "canal-9", "command_error", time.Second)
+
+	ops := services.NewUserService().WithEventMeta(services.EventMeta{Actor: "admin:ops", Source: models.EventSourceHTTP})
+	require.NoError(t, ops.DisconnectUserFromCurrentChannel(luis.ID))
+
+	req := httptest.NewRequest(http.MethodGet, fmt.Sprintf("/admin/audit?channel=canal-9&user=%d", luis.ID), nil)
+	rec := httptest.NewRecorder()
+	AdminAudit(rec, req)
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+
+	req.Header.Set("X-Admin-Token", "secreto")
+	rec = httptest.NewRecorder()
+	AdminAudit(rec, req)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+	var body struct {
+		Count   int                    `json:"count"`
+		Records []services.AuditRecord `json:"records"`
+	}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+	require.Equal(t, 3, body.Count)
+
+	byAction := map[string]services.AuditRecord{}
+	for _, r := range body.Records {
+		byAction[r.Action] = r
+	}
+	cmd := byAction[auditActionCommand]
+	assert.Equal(t, "request_channel_list", cmd.Intent)
+	assert.Equal(t, "ok", cmd.Outcome)
+	assert.Equal(t, int64(350), cmd.LatencyMs)
+
+	kick := byAction[models.ChannelEventDisconnect]
+	assert.Equal(t, services.AuditKindChannelEvent, kick.Kind)
+	assert.Equal(t, "admin:ops", kick.Actor)
+	assert.Equal(t, "canal-9", kick.From)
+	assert.Contains(t, byAction, models.ChannelEventConnect)
+
+	req = httptest.NewRequest(http.MethodGet, "/admin/audit?since=nunca", nil)
+	req.Header.Set("X-Admin-Token", "secreto")
+	rec = httptest.NewRecorder()
+	AdminAudit(rec, req)
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+}
+
+func TestParseAuditSince(t *testing.T) {
+	now := time.Date(2026, 5, 10, 12, 0, 0, 0, time.UTC)
+
+	got, err := parseAuditSince("2h", now)
+	require.NoError(t, err)
+	assert.Equal(t, now.Add(-2*time.Hour), got)
+
+	got, err = parseAuditSince("2026-05-09T08:00:00Z", now)
+	require.NoError(t, err)
+	assert.Equal(t, time.Date(2026, 5, 9, 8, 0, 0, 0, time.UTC), got)
+
+	_, err = parseAuditSince("-1h", now)
+	assert.Error(t, err)
+}
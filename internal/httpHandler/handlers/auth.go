@@ -5,36 +5,42 @@ import (
 	"encoding/hex"
 	"encoding/json"
 	"fmt"
+	"log"
 	"net/http"
 	"regexp"
 	"strings"
 	"time"
 
 	"walkie-backend/internal/config"
+	"walkie-backend/internal/keyring"
 	"walkie-backend/internal/models"
 
 	"golang.org/x/crypto/bcrypt"
 )
 
-// {"nombre":"...","pin":1234}  // pin int
+// {"nombre":"...","pin":1234,"dispositivo":"Pixel de Juan","plataforma":"android"}  // pin int
 type AuthenticationRequest struct {
-	Nombre string `json:"nombre"`
-	Pin    int    `json:"pin"`
+	Nombre      string `json:"nombre"`
+	Pin         int    `json:"pin"`
+	Dispositivo string `json:"dispositivo,omitempty"`
+	Plataforma  string `json:"plataforma,omitempty"`
 }
 
 // AuthenticationResponse is the JSON response
-// {"message":"usuario registrado exitosamente","token":"..."}
+// {"message":"usuario registrado exitosamente","token":"...","access_token":"...","refresh_token":"..."}
 type AuthenticationResponse struct {
 	Message string `json:"message"`
 	Token   string `json:"token"`
+	// SessionID identifica este dispositivo en GET /me/devices
+	SessionID uint `json:"session_id,omitempty"`
+	*TokenPair
 }
 
 // Authenticate handles POST /auth
 // - On success: 200, Content-Type: application/json, body: {"message":"usuario registrado exitosamente","token":"..."}
 // - On invalid: 401 application/json {"message":"credenciales inválidas"}
 func Authenticate(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodPost {
-		http.Error(w, `{"message":"método no permitido"}`, http.StatusMethodNotAllowed)
+	if !requireDB(w) {
 		return
 	}
 
@@ -85,6 +91,7 @@ func Authenticate(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, `{"message":"no se pudo generar token"}`, http.StatusInternalServerError)
 		return
 	}
+	token = signAuthToken(token)
 	user.AuthToken = token
 	user.LastActiveAt = time.Now()
 	if err := config.DB.Save(&user).Error; err != nil {
@@ -92,12 +99,27 @@ func Authenticate(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusOK)
-	_ = json.NewEncoder(w).Encode(AuthenticationResponse{
+	resp := AuthenticationResponse{
 		Message: "usuario ingresado exitosamente",
 		Token:   token,
-	})
+	}
+	// Cada inicio de sesión abre una sesión propia para no cerrar la de otros dispositivos.
+	// users.auth_token guarda el último token para los clientes anteriores a las sesiones.
+	if session, err := newSession(config.DB, user.ID, token, req.Dispositivo, req.Plataforma, r.UserAgent()); err != nil {
+		log.Printf("[AUTH] usuario=%d sin sesión de dispositivo: %v", user.ID, err)
+	} else {
+		resp.SessionID = session.ID
+	}
+	// El JWT es opcional: si el keyring no está disponible se sigue usando el token opaco
+	if pair, err := issueTokenPair(config.DB, &user, resp.SessionID); err != nil {
+		log.Printf("[AUTH] usuario=%d sin JWT: %v", user.ID, err)
+	} else {
+		resp.TokenPair = &pair
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(resp)
 }
 
 func generateToken(n int) (string, error) {
@@ -108,6 +130,35 @@ func generateToken(n int) (string, error) {
 	return hex.EncodeToString(b), nil
 }
 
+// signAuthToken firma el token con la clave primaria del keyring para poder
+// rechazar tokens falsificados sin consultar la base de datos
+func signAuthToken(token string) string {
+	kr, err := keyring.Default()
+	if err != nil {
+		log.Printf("keyring no disponible, se emite token sin firma: %v", err)
+		return token
+	}
+	signed, err := kr.SignToken(token)
+	if err != nil {
+		log.Printf("no se pudo firmar token: %v", err)
+		return token
+	}
+	return signed
+}
+
+// verifyAuthTokenSignature acepta tokens antiguos sin firma y valida los firmados
+func verifyAuthTokenSignature(token string) bool {
+	if !strings.Contains(token, ".") {
+		return true
+	}
+	kr, err := keyring.Default()
+	if err != nil {
+		return false
+	}
+	_, ok := kr.VerifyToken(token)
+	return ok
+}
+
 var nonAlnum = regexp.MustCompile(`[^a-z0-9\.]+`)
 
 func slugify(name string) string {
@@ -42,24 +42,6 @@ func setupAuthTestDB(t *testing.T) func() {
 	}
 }
 
-func TestAuthenticate_MethodNotAllowed(t *testing.T) {
-	cleanup := setupAuthTestDB(t)
-	defer cleanup()
-
-	req := httptest.NewRequest(http.MethodGet, "/auth", nil)
-	resp := httptest.NewRecorder()
-
-	Authenticate(resp, req)
-
-	if resp.Code != http.StatusMethodNotAllowed {
-		t.Fatalf("expected status %d, got %d", http.StatusMethodNotAllowed, resp.Code)
-	}
-
-	if !strings.Contains(resp.Body.String(), `"método no permitido"`) {
-		t.Errorf("unexpected body: %s", resp.Body.String())
-	}
-}
-
 func TestAuthenticate_InvalidJSON(t *testing.T) {
 	cleanup := setupAuthTestDB(t)
 	defer cleanup()
@@ -0,0 +1,125 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+
+	"walkie-backend/internal/ai"
+	"walkie-backend/internal/config"
+	"walkie-backend/internal/models"
+	"walkie-backend/internal/response"
+	"walkie-backend/internal/services"
+)
+
+const intentChannelAlias = "request_channel_alias"
+
+type channelAliasRequest struct {
+	Alias *string `json:"alias"`
+}
+
+// loadChannelAliases lee los alias del usuario para el análisis; sin base de datos no hay alias
+func loadChannelAliases(userID uint) map[string]string {
+	if config.DB == nil || !config.DBAvailable() {
+		return nil
+	}
+	aliases, err := services.ChannelAliases(config.DB, userID)
+	if err != nil {
+		log.Printf("[ALIAS] usuario=%d error=%v", userID, err)
+		return nil
+	}
+	return aliases
+}
+
+// aliasErrorMessage traduce los errores de alias a una respuesta que se pueda leer en voz alta
+func aliasErrorMessage(err error) string {
+	switch {
+	case errors.Is(err, services.ErrAliasTaken):
+		return "Ya usas ese nombre para otro canal"
+	case errors.Is(err, services.ErrInvalidAlias):
+		return "Ese nombre no sirve para un canal"
+	case errors.Is(err, services.ErrChannelNotFound):
+		return "Ese canal no existe"
+	default:
+		return "No pude guardar el nombre del canal"
+	}
+}
+
+// handleChannelAliasStage responde al comando de voz "llámalo obra norte"; sin número de
+// canal se nombra el canal actual
+func handleChannelAliasStage(w http.ResponseWriter, user *models.User, result ai.CommandResult, tracker *stageTimer) {
+	channel := user.GetCurrentChannelCode()
+	if len(result.Channels) > 0 {
+		channel = result.Channels[0]
+	}
+	if channel == "" || strings.TrimSpace(result.Alias) == "" {
+		response.WriteJSON(w, http.StatusOK, CommandResponse{
+			Status:  "error",
+			Intent:  intentChannelAlias,
+			Message: "Dime qué canal quieres nombrar y cómo",
+		})
+		tracker.LogFinal("alias_missing")
+		return
+	}
+
+	alias, err := services.SetChannelAlias(config.DB, user.ID, channel, result.Alias)
+	if err != nil {
+		log.Printf("[ALIAS] usuario=%d canal=%s alias=%q error=%v", user.ID, channel, result.Alias, err)
+		response.WriteJSON(w, http.StatusOK, CommandResponse{
+			Status:  "error",
+			Intent:  intentChannelAlias,
+			Message: aliasErrorMessage(err),
+		})
+		tracker.LogFinal("alias_error")
+		return
+	}
+
+	log.Printf("[ALIAS] usuario=%d canal=%s alias=%q", user.ID, channel, alias)
+	response.WriteJSON(w, http.StatusOK, CommandResponse{
+		Status:  "ok",
+		Intent:  intentChannelAlias,
+		Message: "Listo, el canal " + channelLabel(channel) + " ahora se llama " + alias,
+		Data: map[string]any{
+			"channel": channel,
+			"alias":   alias,
+		},
+	})
+	tracker.LogFinal("alias_saved")
+}
+
+// PATCH /channels/{code}/alias con {"alias":"obra norte"}; un alias vacío lo borra
+func ChannelAlias(w http.ResponseWriter, r *http.Request) {
+	code := r.PathValue("code")
+	if !requireDB(w) {
+		return
+	}
+
+	user, err := resolveUserFromRequest(r)
+	if err != nil {
+		response.WriteErr(w, http.StatusUnauthorized, "X-Auth-Token inválido o expirado")
+		return
+	}
+
+	var req channelAliasRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 4096)).Decode(&req); err != nil || req.Alias == nil {
+		response.WriteErr(w, http.StatusBadRequest, "Se requiere alias")
+		return
+	}
+
+	alias, err := services.SetChannelAlias(config.DB, user.ID, code, *req.Alias)
+	switch {
+	case errors.Is(err, services.ErrChannelNotFound):
+		response.WriteErr(w, http.StatusNotFound, "Canal no encontrado")
+	case errors.Is(err, services.ErrAliasTaken):
+		response.WriteErr(w, http.StatusConflict, err.Error())
+	case errors.Is(err, services.ErrInvalidAlias):
+		response.WriteErr(w, http.StatusBadRequest, err.Error())
+	case err != nil:
+		log.Printf("[ALIAS] usuario=%d canal=%s error=%v", user.ID, code, err)
+		response.WriteErr(w, http.StatusInternalServerError, "No se pudo guardar el alias")
+	default:
+		response.WriteJSON(w, http.StatusOK, map[string]any{"channel": code, "alias": alias})
+	}
+}
@@ -0,0 +1,89 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"walkie-backend/internal/ai"
+	"walkie-backend/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestChannelAlias_PatchRoute(t *testing.T) {
+	db := setupTestDB(t)
+	require.NoError(t, db.AutoMigrate(&models.ChannelAlias{}))
+	user := createTestUser(t, db, 181, "token-alias", "canal-3")
+
+	patch := func(path, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPatch, path, strings.NewReader(body))
+		req.SetPathValue("code", strings.Split(strings.TrimPrefix(path, "/channels/"), "/")[0])
+		req = req.WithContext(withAuthUser(req.Context(), user))
+		rec := httptest.NewRecorder()
+		ChannelAlias(rec, req)
+		return rec
+	}
+
+	rec := patch("/channels/canal-3/alias", `{"alias":"Obra Norte"}`)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	assert.JSONEq(t, `{"channel":"canal-3","alias":"obra norte"}`, rec.Body.String())
+	assert.Equal(t, map[string]string{"obra norte": "canal-3"}, loadChannelAliases(user.ID))
+
+	assert.Equal(t, http.StatusNotFound, patch("/channels/canal-77/alias", `{"alias":"bodega"}`).Code)
+	assert.Equal(t, http.StatusBadRequest, patch("/channels/canal-3/alias", `{}`).Code)
+
+	rec = patch("/channels/canal-3/alias", `{"alias":""}`)
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Empty(t, loadChannelAliases(user.ID))
+}
+
+func TestRunAudioIngest_ChannelAliasVoiceCommands(t *testing.T) {
+	db := setupTestDB(t)
+	require.NoError(t, db.AutoMigrate(&models.ChannelAlias{}))
+	require.NoError(t, db.Create(&models.Channel{Code: "canal-2", Name: "Dos", MaxUsers: 10}).Error)
+	user := createTestUser(t, db, 182, "token-alias-voz", "canal-3")
+	user.CurrentChannel = &models.Channel{Code: "canal-3"}
+
+	ingest := func(text string, deps audioIngestDeps) *httptest.ResponseRecorder {
+		deps.readUserID = func(*http.Request) (uint, error) { return user.ID, nil }
+		deps.newUserService = func() userService {
+			return &mockUserService{user: user, channels: []models.Channel{{Code: "canal-2"}, {Code: "canal-3"}}}
+		}
+		deps.localSTT = func() sttClient { return nil }
+		deps.ensureSTT = func() (sttClient, error) { return &mockSTT{text: text}, nil }
+		req := httptest.NewRequest(http.MethodPost, "/audio/ingest", strings.NewReader(string(buildTestWAV(3200))))
+		req.Header.Set("Content-Type", "audio/wav")
+		rec := httptest.NewRecorder()
+		runAudioIngest(rec, req, deps)
+		return rec
+	}
+
+	deps := newAudioIngestDeps()
+	deps.ensureAI = func() (ai.Analyzer, error) {
+		return &mockQwen{result: ai.CommandResult{IsCommand: true, Intent: intentChannelAlias, Alias: "obra norte"}}, nil
+	}
+	rec := ingest("llámalo obra norte", deps)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	var resp CommandResponse
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+	assert.Equal(t, "Listo, el canal 3 ahora se llama obra norte", resp.Message)
+
+	// El modelo no reconoce el alias, pero la heurística local lo resuelve a partir de los alias guardados
+	t.Setenv("AI_PROVIDER", "ollama")
+	t.Setenv("OLLAMA_URL", conversationAIURL(t))
+	var executed ai.CommandResult
+	deps = newAudioIngestDeps()
+	deps.ensureAI = ai.New
+	deps.executeCommand = func(_ *models.User, _ userService, result ai.CommandResult) (CommandResponse, error) {
+		executed = result
+		return CommandResponse{Status: "ok", Intent: result.Intent}, nil
+	}
+	rec = ingest("conéctame a obra norte", deps)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	assert.Equal(t, "request_channel_connect", executed.Intent)
+	assert.Equal(t, []string{"canal-3"}, executed.Channels)
+}
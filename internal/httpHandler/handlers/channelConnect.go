@@ -0,0 +1,74 @@
+package handlers
+
+import (
+	"log"
+	"net/http"
+
+	"walkie-backend/internal/config"
+	"walkie-backend/internal/models"
+	"walkie-backend/internal/response"
+)
+
+// POST /channels/{code}/connect
+// Conecta al usuario al canal sin pasar por un comando de voz, igual que ConnectChannel
+// de gRPC; responde como el comando y con 409 si el canal está lleno
+func ChannelConnect(w http.ResponseWriter, r *http.Request) {
+	if !requireDB(w) {
+		return
+	}
+	user, svc, ok := channelCommandUser(w, r)
+	if !ok {
+		return
+	}
+	code := r.PathValue("code")
+	if err := config.DB.Where("code = ?", code).First(&models.Channel{}).Error; err != nil {
+		response.WriteErr(w, http.StatusNotFound, "Canal no encontrado")
+		return
+	}
+
+	resp, err := handleChannelConnectCommand(user, svc, code)
+	if err != nil {
+		log.Printf("[CANALES] usuario=%d error conectando a %s: %v", user.ID, code, err)
+		response.WriteErr(w, http.StatusInternalServerError, "No se pudo conectar al canal")
+		return
+	}
+	status := http.StatusOK
+	if resp.Status == "channel_full" {
+		status = http.StatusConflict
+	}
+	response.WriteJSON(w, status, resp)
+}
+
+// POST /channels/disconnect saca al usuario de su canal actual
+func ChannelDisconnect(w http.ResponseWriter, r *http.Request) {
+	if !requireDB(w) {
+		return
+	}
+	user, svc, ok := channelCommandUser(w, r)
+	if !ok {
+		return
+	}
+	resp, err := handleChannelDisconnectCommand(user, svc)
+	if err != nil {
+		log.Printf("[CANALES] usuario=%d error desconectando: %v", user.ID, err)
+		response.WriteErr(w, http.StatusInternalServerError, "No se pudo desconectar del canal")
+		return
+	}
+	response.WriteJSON(w, http.StatusOK, resp)
+}
+
+// channelCommandUser carga el usuario autenticado con su canal actual
+func channelCommandUser(w http.ResponseWriter, r *http.Request) (*models.User, userService, bool) {
+	authUser, err := resolveUserFromRequest(r)
+	if err != nil {
+		response.WriteErr(w, http.StatusUnauthorized, "X-Auth-Token inválido o expirado")
+		return nil, nil, false
+	}
+	svc := newDefaultUserService()
+	user, err := svc.GetUserWithChannel(authUser.ID)
+	if err != nil {
+		response.WriteErr(w, http.StatusInternalServerError, "No se pudo cargar el usuario")
+		return nil, nil, false
+	}
+	return user, svc, true
+}
@@ -0,0 +1,54 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"walkie-backend/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestChannelConnect_JoinsFullAndMissingChannels(t *testing.T) {
+	db := setupTestDB(t)
+	require.NoError(t, db.AutoMigrate(&models.ChannelEvent{}))
+	user := createTestUser(t, db, 695, "token-conectar-1", "")
+	require.NoError(t, db.Create(&models.Channel{Code: "conectar-1", Name: "Conectar", MaxUsers: 5}).Error)
+	full := &models.Channel{Code: "conectar-lleno", Name: "Lleno", MaxUsers: 1}
+	require.NoError(t, db.Create(full).Error)
+	require.NoError(t, db.Create(&models.ChannelMembership{UserID: 696, ChannelID: full.ID, Active: true}).Error)
+
+	call := func(handler http.HandlerFunc, path, code string) (*httptest.ResponseRecorder, CommandResponse) {
+		req := httptest.NewRequest(http.MethodPost, path, nil)
+		req.SetPathValue("code", code)
+		rec := httptest.NewRecorder()
+		handler(rec, req.WithContext(withAuthUser(req.Context(), user)))
+		var body CommandResponse
+		_ = json.Unmarshal(rec.Body.Bytes(), &body)
+		return rec, body
+	}
+
+	rec, _ := call(ChannelConnect, "/channels/no-existe/connect", "no-existe")
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+
+	rec, body := call(ChannelConnect, "/channels/conectar-lleno/connect", "conectar-lleno")
+	assert.Equal(t, http.StatusConflict, rec.Code)
+	assert.Equal(t, "channel_full", body.Status)
+
+	rec, body = call(ChannelConnect, "/channels/conectar-1/connect", "conectar-1")
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	assert.Equal(t, "request_channel_connect", body.Intent)
+	var fresh models.User
+	require.NoError(t, db.Preload("CurrentChannel").First(&fresh, user.ID).Error)
+	require.NotNil(t, fresh.CurrentChannel)
+	assert.Equal(t, "conectar-1", fresh.CurrentChannel.Code)
+
+	rec, body = call(ChannelDisconnect, "/channels/disconnect", "")
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	assert.Equal(t, "request_channel_disconnect", body.Intent)
+	require.NoError(t, db.First(&fresh, user.ID).Error)
+	assert.Nil(t, fresh.CurrentChannelID)
+}
@@ -0,0 +1,255 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"walkie-backend/internal/config"
+	"walkie-backend/internal/models"
+	"walkie-backend/internal/response"
+	"walkie-backend/internal/services"
+	"walkie-backend/pkg/audio"
+)
+
+type historyItem struct {
+	ID         uint    `json:"id"`
+	SenderID   uint    `json:"senderId"`
+	Timestamp  string  `json:"timestamp"`
+	Duration   float64 `json:"duration"`
+	Format     string  `json:"format"`
+	Priority   string  `json:"priority,omitempty"`
+	SizeBytes  int     `json:"sizeBytes"`
+	AudioURL   string  `json:"audioUrl,omitempty"`
+	AgeSeconds float64 `json:"ageSeconds"`
+}
+
+// recordChannelTransmission guarda la transmisión en segundo plano para no retrasar la difusión
+func recordChannelTransmission(senderID uint, channel string, audioData []byte, duration float64, priority string) {
+	db := config.DB
+	if db == nil || !config.DBAvailable() {
+		return
+	}
+	tx := models.ChannelTransmission{
+		CreatedAt:   time.Now(),
+		ChannelCode: channel,
+		SenderID:    senderID,
+		Duration:    duration,
+		Format:      queuedFormat(audioData),
+		Priority:    priority,
+	}
+	go func() {
+		if err := services.RecordTransmission(db, tx, audioData); err != nil {
+			log.Printf("[HISTORIAL] canal=%s usuario=%d error=%v", channel, senderID, err)
+		}
+	}()
+}
+
+// recordChannelTranscript guarda en segundo plano lo que dijo el usuario en el canal
+func recordChannelTranscript(user *models.User, channel, text, priority string) {
+	db := config.DB
+	if db == nil || !config.DBAvailable() || !services.TranscriptsEnabled() || strings.TrimSpace(text) == "" {
+		return
+	}
+	t := models.Transcript{
+		CreatedAt:   time.Now(),
+		ChannelCode: channel,
+		SenderID:    user.ID,
+		SenderName:  user.DisplayName,
+		Text:        text,
+		Priority:    priority,
+	}
+	go func() {
+		if err := services.RecordTranscript(db, t); err != nil {
+			log.Printf("[TRANSCRIPCION] canal=%s usuario=%d error=%v", channel, user.ID, err)
+			return
+		}
+		services.EmitWebhookEvent(services.WebhookEventTranscriptAvailable, channel, map[string]any{
+			"userId":     t.SenderID,
+			"senderName": t.SenderName,
+			"text":       t.Text,
+			"priority":   t.Priority,
+		})
+	}()
+}
+
+// channelMember carga al usuario y comprueba que está conectado al canal {code}; si
+// no, ya ha respondido el error
+func channelMember(w http.ResponseWriter, r *http.Request) (*models.User, string, bool) {
+	if !requireDB(w) {
+		return nil, "", false
+	}
+	user, err := resolveUserFromRequest(r)
+	if err != nil {
+		response.WriteErr(w, http.StatusUnauthorized, "X-Auth-Token inválido o expirado")
+		return nil, "", false
+	}
+	code := r.PathValue("code")
+	if user.GetCurrentChannelCode() != code {
+		response.WriteErr(w, http.StatusForbidden, "Debes estar conectado al canal")
+		return nil, "", false
+	}
+	return user, code, true
+}
+
+// GET /channels/{code}/history?limit=N
+func ChannelHistory(w http.ResponseWriter, r *http.Request) {
+	if _, code, ok := channelMember(w, r); ok {
+		writeChannelHistory(w, r, code)
+	}
+}
+
+// GET /channels/{code}/history/{id}/audio
+func ChannelTransmissionAudio(w http.ResponseWriter, r *http.Request) {
+	_, code, ok := channelMember(w, r)
+	if !ok {
+		return
+	}
+	id, err := strconv.ParseUint(r.PathValue("id"), 10, 64)
+	if err != nil {
+		response.WriteErr(w, http.StatusBadRequest, "ID de transmisión inválido")
+		return
+	}
+	writeTransmissionAudio(w, r, code, uint(id))
+}
+
+// GET /channels/{code}/transcripts?since=RFC3339&limit=N
+func ChannelTranscripts(w http.ResponseWriter, r *http.Request) {
+	if _, code, ok := channelMember(w, r); ok {
+		writeChannelTranscripts(w, r, code)
+	}
+}
+
+func writeChannelHistory(w http.ResponseWriter, r *http.Request, code string) {
+	limit := 0
+	if raw := r.URL.Query().Get("limit"); raw != "" {
+		v, err := strconv.Atoi(raw)
+		if err != nil || v <= 0 {
+			response.WriteErr(w, http.StatusBadRequest, "limit inválido")
+			return
+		}
+		limit = v
+	}
+	if keep := services.HistoryKeep(); limit > keep {
+		limit = keep
+	}
+
+	items, err := services.ChannelHistory(config.DB, code, limit)
+	if err != nil {
+		response.WriteErr(w, http.StatusInternalServerError, "No se pudo obtener el historial")
+		return
+	}
+
+	now := time.Now()
+	out := make([]historyItem, 0, len(items))
+	for _, t := range items {
+		item := historyItem{
+			ID:         t.ID,
+			SenderID:   t.SenderID,
+			Timestamp:  t.CreatedAt.UTC().Format(time.RFC3339Nano),
+			Duration:   t.Duration,
+			Format:     t.Format,
+			Priority:   t.Priority,
+			SizeBytes:  t.SizeBytes,
+			AgeSeconds: now.Sub(t.CreatedAt).Seconds(),
+		}
+		if t.HasAudio() {
+			item.AudioURL = transmissionAudioURL(code, &t)
+		}
+		out = append(out, item)
+	}
+	response.WriteJSON(w, http.StatusOK, out)
+}
+
+type transcriptItem struct {
+	ID         uint   `json:"id"`
+	SenderID   uint   `json:"sender_id"`
+	SenderName string `json:"sender_name,omitempty"`
+	Text       string `json:"text"`
+	Priority   string `json:"priority,omitempty"`
+	Timestamp  string `json:"timestamp"`
+}
+
+func writeChannelTranscripts(w http.ResponseWriter, r *http.Request, code string) {
+	q := r.URL.Query()
+	var since time.Time
+	if raw := q.Get("since"); raw != "" {
+		t, err := time.Parse(time.RFC3339, raw)
+		if err != nil {
+			response.WriteErr(w, http.StatusBadRequest, "since debe ser una fecha RFC3339")
+			return
+		}
+		since = t
+	}
+	limit := 0
+	if raw := q.Get("limit"); raw != "" {
+		v, err := strconv.Atoi(raw)
+		if err != nil || v <= 0 {
+			response.WriteErr(w, http.StatusBadRequest, "limit inválido")
+			return
+		}
+		limit = v
+	}
+
+	items, err := services.ChannelTranscripts(config.DB, code, since, limit)
+	if err != nil {
+		response.WriteErr(w, http.StatusInternalServerError, "No se pudieron obtener las transcripciones")
+		return
+	}
+
+	out := make([]transcriptItem, 0, len(items))
+	for _, t := range items {
+		out = append(out, transcriptItem{
+			ID:         t.ID,
+			SenderID:   t.SenderID,
+			SenderName: t.SenderName,
+			Text:       t.Text,
+			Priority:   t.Priority,
+			Timestamp:  t.CreatedAt.UTC().Format(time.RFC3339Nano),
+		})
+	}
+	response.WriteJSON(w, http.StatusOK, out)
+}
+
+// transmissionAudioURL es la URL prefirmada del bucket si el audio está allí, o la
+// ruta versionada del servidor que lo sirve
+func transmissionAudioURL(code string, t *models.ChannelTransmission) string {
+	if t.AudioKey != "" {
+		if link, err := services.PresignBlob(t.AudioKey); err == nil {
+			return link
+		}
+	}
+	return fmt.Sprintf("/v1/channels/%s/history/%d/audio", code, t.ID)
+}
+
+func writeTransmissionAudio(w http.ResponseWriter, r *http.Request, code string, id uint) {
+	if tx, err := services.FindTransmission(config.DB, code, id); err == nil && tx.AudioKey != "" {
+		if link, err := services.PresignBlob(tx.AudioKey); err == nil {
+			http.Redirect(w, r, link, http.StatusFound)
+			return
+		}
+	}
+
+	tx, data, err := services.TransmissionAudio(config.DB, code, id)
+	if errors.Is(err, services.ErrTransmissionNotFound) {
+		response.WriteErr(w, http.StatusNotFound, "Audio no disponible")
+		return
+	}
+	if err != nil {
+		response.WriteErr(w, http.StatusInternalServerError, "No se pudo leer el audio")
+		return
+	}
+
+	w.Header().Set("Content-Type", audio.MimeType(tx.Format))
+	w.Header().Set("X-Audio-From", fmt.Sprintf("%d", tx.SenderID))
+	w.Header().Set("X-Channel", tx.ChannelCode)
+	w.Header().Set("X-Audio-Timestamp", tx.CreatedAt.UTC().Format(time.RFC3339Nano))
+	w.WriteHeader(http.StatusOK)
+	if _, err := w.Write(data); err != nil {
+		log.Printf("Error enviando audio del historial %d: %v", id, err)
+	}
+}
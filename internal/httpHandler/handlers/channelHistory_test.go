@@ -0,0 +1,93 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"walkie-backend/internal/config"
+	"walkie-backend/internal/models"
+	"walkie-backend/internal/services"
+)
+
+// serveChannelRoute sirve las subrutas de /channels/{code}/ como las registra el router
+func serveChannelRoute(w http.ResponseWriter, r *http.Request) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /channels/{code}/history", ChannelHistory)
+	mux.HandleFunc("GET /channels/{code}/history/{id}/audio", ChannelTransmissionAudio)
+	mux.HandleFunc("GET /channels/{code}/presence", ChannelPresence)
+	mux.HandleFunc("GET /channels/{code}/messages", ChannelMessages)
+	mux.HandleFunc("GET /channels/{code}/transcripts", ChannelTranscripts)
+	mux.HandleFunc("GET /channels/{code}/summary", ChannelSummary)
+	mux.HandleFunc("GET /channels/{code}/recordings", ChannelRecordings)
+	mux.HandleFunc("GET /channels/{code}/recordings/{id}/export", ChannelRecordingExport)
+	mux.ServeHTTP(w, r)
+}
+
+func TestChannelHistory_ListsAndServesAudioForMembers(t *testing.T) {
+	cleanup := setupChannelsTestDB(t)
+	defer cleanup()
+	if err := config.DB.AutoMigrate(&models.ChannelTransmission{}, &models.TransmissionBlob{}); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+
+	channel := models.Channel{Code: "canal-1", Name: "Canal 1", MaxUsers: 10}
+	config.DB.Create(&channel)
+	member := models.User{DisplayName: "Ana", AuthToken: "tok-ana", LastActiveAt: time.Now(), CurrentChannelID: &channel.ID}
+	outsider := models.User{DisplayName: "Luis", AuthToken: "tok-luis", LastActiveAt: time.Now()}
+	config.DB.Create(&member)
+	config.DB.Create(&outsider)
+
+	if err := services.RecordTransmission(config.DB, models.ChannelTransmission{ChannelCode: "canal-1", SenderID: 7, Format: "wav", Duration: 1.5}, []byte("clip")); err != nil {
+		t.Fatalf("record: %v", err)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/channels/canal-1/history", nil)
+	req.Header.Set("X-Auth-Token", "tok-luis")
+	rec := httptest.NewRecorder()
+	serveChannelRoute(rec, req)
+	if rec.Code != http.StatusForbidden {
+		t.Fatalf("expected 403 for non-member, got %d", rec.Code)
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/channels/canal-1/history?limit=5", nil)
+	req.Header.Set("X-Auth-Token", "tok-ana")
+	rec = httptest.NewRecorder()
+	serveChannelRoute(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var items []historyItem
+	if err := json.Unmarshal(rec.Body.Bytes(), &items); err != nil || len(items) != 1 {
+		t.Fatalf("unexpected history %s (err=%v)", rec.Body.String(), err)
+	}
+	if items[0].SenderID != 7 || items[0].AudioURL == "" {
+		t.Fatalf("unexpected item %+v", items[0])
+	}
+
+	if !strings.HasPrefix(items[0].AudioURL, "/v1/channels/canal-1/history/") {
+		t.Fatalf("expected versioned audio URL, got %q", items[0].AudioURL)
+	}
+	// El router quita /v1 antes de llegar al handler
+	req = httptest.NewRequest(http.MethodGet, strings.TrimPrefix(items[0].AudioURL, "/v1"), nil)
+	req.Header.Set("X-Auth-Token", "tok-ana")
+	rec = httptest.NewRecorder()
+	serveChannelRoute(rec, req)
+	if rec.Code != http.StatusOK || rec.Body.String() != "clip" {
+		t.Fatalf("expected clip audio, got %d %q", rec.Code, rec.Body.String())
+	}
+	if rec.Header().Get("X-Audio-From") != "7" {
+		t.Fatalf("expected sender header, got %q", rec.Header().Get("X-Audio-From"))
+	}
+}
+
+func TestChannelHistory_UnknownRoute(t *testing.T) {
+	rec := httptest.NewRecorder()
+	serveChannelRoute(rec, httptest.NewRequest(http.MethodGet, "/channels/canal-1/otra-cosa", nil))
+	if rec.Code != http.StatusNotFound {
+		t.Fatalf("expected 404, got %d", rec.Code)
+	}
+}
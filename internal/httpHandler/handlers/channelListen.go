@@ -0,0 +1,163 @@
+package handlers
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"sync"
+	"time"
+
+	"walkie-backend/internal/config"
+	"walkie-backend/internal/metrics"
+	"walkie-backend/internal/models"
+	"walkie-backend/internal/response"
+	"walkie-backend/internal/services"
+	"walkie-backend/pkg/audio"
+)
+
+const (
+	// listenSampleRate es la frecuencia del WAV continuo que recibe el supervisor
+	listenSampleRate = 16000
+	// listenChunk es cada cuánto se escribe un trozo; sin audio en el canal va silencio
+	listenChunk = 100 * time.Millisecond
+	// listenQueueSize son los clips que puede acumular una escucha lenta antes de perderlos
+	listenQueueSize = 32
+)
+
+// channelListeners son las escuchas abiertas por canal. No son clientes del canal: no
+// aparecen en la presencia ni cuentan para el aforo.
+var channelListeners = struct {
+	sync.Mutex
+	byChannel map[string]map[chan []byte]struct{}
+}{
+	byChannel: make(map[string]map[chan []byte]struct{}),
+}
+
+func subscribeChannelListen(channel string) (<-chan []byte, func()) {
+	ch := make(chan []byte, listenQueueSize)
+
+	channelListeners.Lock()
+	if channelListeners.byChannel[channel] == nil {
+		channelListeners.byChannel[channel] = make(map[chan []byte]struct{})
+	}
+	channelListeners.byChannel[channel][ch] = struct{}{}
+	channelListeners.Unlock()
+
+	return ch, func() {
+		channelListeners.Lock()
+		delete(channelListeners.byChannel[channel], ch)
+		if len(channelListeners.byChannel[channel]) == 0 {
+			delete(channelListeners.byChannel, channel)
+		}
+		channelListeners.Unlock()
+	}
+}
+
+// feedChannelListeners pasa el clip difundido a las escuchas del canal sin bloquear
+// la difusión; si una escucha va atrasada el clip se pierde para ella
+func feedChannelListeners(channel string, clip []byte) {
+	channelListeners.Lock()
+	defer channelListeners.Unlock()
+	for ch := range channelListeners.byChannel[channel] {
+		select {
+		case ch <- clip:
+		default:
+			metrics.Inc("walkie_listen_dropped_clips_total", nil)
+		}
+	}
+}
+
+// GET /channels/{code}/listen
+// Escucha silenciosa para supervisores: un WAV continuo (PCM 16 bits mono a 16 kHz) con
+// todo lo que se difunde en el canal, mezclando los clips que se solapan y rellenando
+// con silencio. Sólo propietarios y moderadores del canal.
+func ChannelListen(w http.ResponseWriter, r *http.Request) {
+	if !requireDB(w) {
+		return
+	}
+	user, err := resolveUserFromRequest(r)
+	if err != nil {
+		response.WriteErr(w, http.StatusUnauthorized, "X-Auth-Token inválido o expirado")
+		return
+	}
+	code := r.PathValue("code")
+
+	role, err := services.ChannelRole(config.DB, user.ID, code)
+	switch {
+	case errors.Is(err, services.ErrChannelNotFound):
+		response.WriteErr(w, http.StatusNotFound, "Canal no encontrado")
+		return
+	case err != nil:
+		response.WriteErr(w, http.StatusInternalServerError, "No se pudo comprobar el rol")
+		return
+	case !(&models.ChannelMembership{Role: role}).CanModerate():
+		response.WriteErr(w, http.StatusForbidden, "Sólo los moderadores pueden escuchar el canal")
+		return
+	}
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		response.WriteErr(w, http.StatusInternalServerError, "Streaming no soportado")
+		return
+	}
+
+	clips, unsubscribe := subscribeChannelListen(code)
+	defer unsubscribe()
+
+	services.RecordAudit(nil, models.AuditEntry{
+		Actor:   fmt.Sprintf("user:%d", user.ID),
+		Action:  "channel_listen",
+		UserID:  &user.ID,
+		Channel: code,
+		Source:  models.EventSourceHTTP,
+	})
+	metrics.Inc("walkie_listen_sessions_total", nil)
+	log.Printf("[ESCUCHA] usuario=%d escucha el canal %s", user.ID, code)
+	defer log.Printf("[ESCUCHA] usuario=%d deja de escuchar el canal %s", user.ID, code)
+
+	w.Header().Set("Content-Type", "audio/wav")
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	// El stream no tiene fin: la cabecera declara el tamaño máximo de data
+	f := audio.WAVFormat{AudioFormat: 1 /* PCM */, Channels: 1, SampleRate: listenSampleRate, BitsPerSample: 16, ByteRate: listenSampleRate * 2, BlockAlign: 2}
+	if _, err := w.Write(audio.WAVHeader(f, math.MaxUint32-36)); err != nil {
+		return
+	}
+	flusher.Flush()
+
+	var mixer callMixer
+	chunkSamples := int(listenChunk.Seconds() * listenSampleRate)
+	chunk := make([]byte, chunkSamples*2)
+	ticker := time.NewTicker(listenChunk)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case clip := <-clips:
+			if !audio.IsWAV(clip) {
+				metrics.Inc("walkie_listen_skipped_clips_total", map[string]string{"format": audio.Detect(clip)})
+				continue
+			}
+			samples, err := audio.Samples16(clip, listenSampleRate)
+			if err != nil {
+				metrics.Inc("walkie_listen_skipped_clips_total", map[string]string{"format": "wav_unsupported"})
+				continue
+			}
+			mixer.add(samples)
+		case <-ticker.C:
+			clear(chunk)
+			for i, s := range mixer.next(chunkSamples) {
+				binary.LittleEndian.PutUint16(chunk[i*2:], uint16(s))
+			}
+			if _, err := w.Write(chunk); err != nil {
+				return
+			}
+			flusher.Flush()
+		}
+	}
+}
@@ -0,0 +1,94 @@
+package handlers
+
+import (
+	"encoding/binary"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"walkie-backend/internal/config"
+	"walkie-backend/internal/models"
+	"walkie-backend/internal/services"
+	"walkie-backend/pkg/audio"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestChannelListen_StreamsChannelAudioToModerators(t *testing.T) {
+	cleanup := setupChannelsTestDB(t)
+	defer cleanup()
+	require.NoError(t, config.DB.AutoMigrate(&models.AuditEntry{}))
+	require.NoError(t, config.DB.Create(&models.Channel{Code: "canal-escucha", Name: "Escucha", MaxUsers: 10}).Error)
+	supervisor := &models.User{DisplayName: "Supervisora", IsActive: true}
+	member := &models.User{DisplayName: "Miembro", IsActive: true}
+	require.NoError(t, config.DB.Create(supervisor).Error)
+	require.NoError(t, config.DB.Create(member).Error)
+	require.NoError(t, services.SetChannelRole(config.DB, "canal-escucha", supervisor.ID, models.ChannelRoleModerator))
+
+	listen := func(w http.ResponseWriter, r *http.Request, user *models.User) {
+		r.SetPathValue("code", "canal-escucha")
+		ChannelListen(w, r.WithContext(withAuthUser(r.Context(), user)))
+	}
+
+	rec := httptest.NewRecorder()
+	listen(rec, httptest.NewRequest(http.MethodGet, "/channels/canal-escucha/listen", nil), member)
+	assert.Equal(t, http.StatusForbidden, rec.Code, "los miembros no pueden escuchar")
+
+	handlerDone := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		defer close(handlerDone)
+		listen(w, r, supervisor)
+	}))
+	defer srv.Close()
+
+	resp, err := http.Get(srv.URL)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "audio/wav", resp.Header.Get("Content-Type"))
+
+	header := make([]byte, 44)
+	_, err = io.ReadFull(resp.Body, header)
+	require.NoError(t, err)
+	f, err := audio.ParseWAV(append(header, 0, 0))
+	require.NoError(t, err)
+	assert.Equal(t, listenSampleRate, f.SampleRate)
+
+	// Antes de que nadie hable llega silencio
+	chunk := make([]byte, 3200)
+	_, err = io.ReadFull(resp.Body, chunk)
+	require.NoError(t, err)
+	assert.Equal(t, make([]byte, len(chunk)), chunk)
+
+	loud := make([]int16, 1600)
+	for i := range loud {
+		loud[i] = 5000
+	}
+	broadcastAudio("canal-escucha", member.ID, audio.EncodeWAV(loud, listenSampleRate))
+
+	heard := false
+	deadline := time.Now().Add(2 * time.Second)
+	for !heard && time.Now().Before(deadline) {
+		_, err = io.ReadFull(resp.Body, chunk)
+		require.NoError(t, err)
+		for i := 0; i+1 < len(chunk); i += 2 {
+			if int16(binary.LittleEndian.Uint16(chunk[i:])) == 5000 {
+				heard = true
+				break
+			}
+		}
+	}
+	assert.True(t, heard, "el audio del canal llega a la escucha")
+
+	require.NoError(t, resp.Body.Close())
+	select {
+	case <-handlerDone:
+	case <-time.After(2 * time.Second):
+		t.Fatal("la escucha no terminó al cerrar la conexión")
+	}
+	channelListeners.Lock()
+	assert.Empty(t, channelListeners.byChannel["canal-escucha"])
+	channelListeners.Unlock()
+}
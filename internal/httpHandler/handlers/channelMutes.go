@@ -0,0 +1,127 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+
+	"walkie-backend/internal/config"
+	"walkie-backend/internal/models"
+	"walkie-backend/internal/response"
+	"walkie-backend/internal/services"
+)
+
+const intentUserMute = "request_user_mute"
+
+// mutedRecipients devuelve quién silenció a senderID en el canal; sin base de datos no
+// se filtra a nadie
+func mutedRecipients(channel string, senderID uint) map[uint]bool {
+	if channel == "" || config.DB == nil || !config.DBAvailable() {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), config.QueryTimeout())
+	defer cancel()
+
+	ids, err := services.MutedBy(config.DB.WithContext(ctx), channel, senderID)
+	if err != nil {
+		log.Printf("[SILENCIO] canal=%s emisor=%d error=%v", channel, senderID, err)
+		return nil
+	}
+	if len(ids) == 0 {
+		return nil
+	}
+	muted := make(map[uint]bool, len(ids))
+	for _, id := range ids {
+		muted[id] = true
+	}
+	return muted
+}
+
+// POST /channels/{code}/mute/{userID} deja de entregar al usuario el audio de userID en
+// el canal; DELETE lo vuelve a entregar
+func ChannelMute(w http.ResponseWriter, r *http.Request) {
+	if !requireDB(w) {
+		return
+	}
+	user, err := resolveUserFromRequest(r)
+	if err != nil {
+		response.WriteErr(w, http.StatusUnauthorized, "X-Auth-Token inválido o expirado")
+		return
+	}
+	code := r.PathValue("code")
+	targetID, ok := pathUserID(w, r)
+	if !ok {
+		return
+	}
+
+	muted := r.Method != http.MethodDelete
+	if muted {
+		err = services.MuteUser(config.DB, user.ID, code, targetID)
+	} else {
+		err = services.UnmuteUser(config.DB, user.ID, code, targetID)
+	}
+	switch {
+	case errors.Is(err, services.ErrChannelNotFound):
+		response.WriteErr(w, http.StatusNotFound, "Canal no encontrado")
+	case errors.Is(err, services.ErrUserNotFound):
+		response.WriteErr(w, http.StatusNotFound, err.Error())
+	case errors.Is(err, services.ErrCannotMuteSelf):
+		response.WriteErr(w, http.StatusBadRequest, err.Error())
+	case err != nil:
+		log.Printf("[SILENCIO] usuario=%d canal=%s objetivo=%d error=%v", user.ID, code, targetID, err)
+		response.WriteErr(w, http.StatusInternalServerError, "No se pudo guardar el silencio")
+	default:
+		log.Printf("[SILENCIO] usuario=%d canal=%s objetivo=%d silenciado=%t", user.ID, code, targetID, muted)
+		response.WriteJSON(w, http.StatusOK, map[string]any{"channel": code, "user_id": targetID, "muted": muted})
+	}
+}
+
+// handleUserMuteStage responde al comando de voz "silencia a Juan" en el canal actual
+func handleUserMuteStage(w http.ResponseWriter, user *models.User, name string, deps audioIngestDeps, tracker *stageTimer) {
+	channel := user.GetCurrentChannelCode()
+	if channel == "" {
+		response.WriteJSON(w, http.StatusOK, CommandResponse{
+			Status:  "error",
+			Intent:  intentUserMute,
+			Message: "Conéctate a un canal para silenciar a alguien",
+		})
+		tracker.LogFinal("mute_no_channel")
+		return
+	}
+
+	target, err := deps.findRecipient(name)
+	if err != nil || target.ID == user.ID {
+		response.WriteJSON(w, http.StatusOK, CommandResponse{
+			Status:  "error",
+			Intent:  intentUserMute,
+			Message: fmt.Sprintf("No encontré a %s", name),
+		})
+		tracker.LogFinal("mute_unknown_user")
+		return
+	}
+
+	if err := services.MuteUser(config.DB, user.ID, channel, target.ID); err != nil {
+		log.Printf("[SILENCIO] usuario=%d canal=%s objetivo=%d error=%v", user.ID, channel, target.ID, err)
+		response.WriteJSON(w, http.StatusOK, CommandResponse{
+			Status:  "error",
+			Intent:  intentUserMute,
+			Message: fmt.Sprintf("No pude silenciar a %s", target.DisplayName),
+		})
+		tracker.LogFinal("mute_error")
+		return
+	}
+
+	response.WriteJSON(w, http.StatusOK, CommandResponse{
+		Status:  "ok",
+		Intent:  intentUserMute,
+		Message: fmt.Sprintf("Ya no oirás a %s en el canal %s", target.DisplayName, channelLabel(channel)),
+		Data: map[string]any{
+			"channel":    channel,
+			"muted_id":   target.ID,
+			"muted_name": target.DisplayName,
+		},
+	})
+	tracker.LogFinal("mute_saved")
+}
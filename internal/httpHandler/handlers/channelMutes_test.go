@@ -0,0 +1,87 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"walkie-backend/internal/ai"
+	"walkie-backend/internal/config"
+	"walkie-backend/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestChannelMute_SkipsMutedSender(t *testing.T) {
+	db := setupTestDB(t)
+	require.NoError(t, db.AutoMigrate(&models.ChannelMute{}))
+	listener := createTestUser(t, db, 191, "token-mute", "canal-6")
+	sender := &models.User{DisplayName: "Juan", IsActive: true}
+	require.NoError(t, db.Create(sender).Error)
+
+	mute := func(method string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, fmt.Sprintf("/channels/canal-6/mute/%d", sender.ID), nil)
+		req.SetPathValue("code", "canal-6")
+		req.SetPathValue("userID", fmt.Sprint(sender.ID))
+		req = req.WithContext(withAuthUser(req.Context(), listener))
+		rec := httptest.NewRecorder()
+		ChannelMute(rec, req)
+		return rec
+	}
+	rec := mute(http.MethodPost)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+	muted := &wsClient{userID: listener.ID, channel: "canal-6", send: make(chan []byte, 4)}
+	other := &wsClient{userID: 590, channel: "canal-6", send: make(chan []byte, 4)}
+	registerClient(muted)
+	registerClient(other)
+	broadcastAudio("canal-6", sender.ID, []byte("hola"))
+	removeClient(muted)
+	removeClient(other)
+	assert.Len(t, muted.send, 0, "muted sender must not reach the listener")
+	assert.Len(t, other.send, 1)
+
+	EnqueueAudio(sender.ID, "canal-6", []byte("hola"), 1, []uint{listener.ID, 590})
+	assert.Nil(t, DequeueAudio(listener.ID))
+	assert.NotNil(t, DequeueAudio(590))
+
+	rec = mute(http.MethodDelete)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	EnqueueAudio(sender.ID, "canal-6", []byte("hola"), 1, []uint{listener.ID})
+	assert.NotNil(t, DequeueAudio(listener.ID), "unmuted sender is delivered again")
+}
+
+func TestRunAudioIngest_MuteVoiceCommand(t *testing.T) {
+	db := setupTestDB(t)
+	require.NoError(t, db.AutoMigrate(&models.ChannelMute{}))
+	user := createTestUser(t, db, 193, "token-mute-voz", "canal-7")
+	juan := &models.User{DisplayName: "Juan", IsActive: true}
+	require.NoError(t, db.Create(juan).Error)
+
+	deps := newAudioIngestDeps()
+	deps.readUserID = func(*http.Request) (uint, error) { return user.ID, nil }
+	deps.newUserService = func() userService { return &mockUserService{user: user} }
+	deps.localSTT = func() sttClient { return nil }
+	deps.ensureSTT = func() (sttClient, error) { return &mockSTT{text: "silencia a Juan"}, nil }
+	deps.ensureAI = func() (ai.Analyzer, error) {
+		return &mockQwen{result: ai.CommandResult{IsCommand: true, Intent: intentUserMute, Recipient: "juan"}}, nil
+	}
+	req := httptest.NewRequest(http.MethodPost, "/audio/ingest", strings.NewReader(string(buildTestWAV(3200))))
+	req.Header.Set("Content-Type", "audio/wav")
+	rec := httptest.NewRecorder()
+	runAudioIngest(rec, req, deps)
+
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	var resp CommandResponse
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+	assert.Equal(t, "ok", resp.Status)
+	assert.Equal(t, "Ya no oirás a Juan en el canal 7", resp.Message)
+
+	var mutes int64
+	config.DB.Model(&models.ChannelMute{}).Where("user_id = ? AND muted_user_id = ? AND channel_code = ?", user.ID, juan.ID, "canal-7").Count(&mutes)
+	assert.Equal(t, int64(1), mutes)
+}
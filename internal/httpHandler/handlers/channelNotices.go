@@ -0,0 +1,94 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+
+	"walkie-backend/internal/metrics"
+	"walkie-backend/internal/models"
+)
+
+const (
+	membershipJoined = "user_joined"
+	membershipLeft   = "user_left"
+
+	// ChannelNoticeSenderID es el emisor de los avisos hablados de entrada y salida
+	ChannelNoticeSenderID uint = 0
+)
+
+// membershipEvent es el JSON que reciben los miembros cuando alguien entra o sale del
+// canal por comando de voz
+type membershipEvent struct {
+	Type        string `json:"type"`
+	Event       string `json:"event"`
+	UserID      uint   `json:"user_id"`
+	DisplayName string `json:"displayName"`
+	Channel     string `json:"channel"`
+	MemberCount int    `json:"memberCount"`
+}
+
+// channelNoticeTTSEnabled indica si además se encola un aviso hablado (CHANNEL_NOTICE_TTS=true)
+func channelNoticeTTSEnabled() bool {
+	return strings.ToLower(strings.TrimSpace(os.Getenv("CHANNEL_NOTICE_TTS"))) == "true"
+}
+
+// announceMembership avisa al resto del canal de que user entró o salió y, con
+// CHANNEL_NOTICE_TTS, les encola un aviso hablado corto
+func announceMembership(svc userService, user *models.User, channel, event string) {
+	if channel == "" {
+		return
+	}
+	members, err := svc.GetChannelActiveUsers(channel)
+	if err != nil {
+		log.Printf("[AVISOS] canal=%s error obteniendo miembros: %v", channel, err)
+		return
+	}
+	recipients := make([]uint, 0, len(members))
+	count := 0
+	for _, m := range members {
+		if m.ID == user.ID {
+			// Tras conectarse el usuario ya cuenta como miembro, pero no se avisa a sí mismo
+			if event == membershipJoined {
+				count++
+			}
+			continue
+		}
+		count++
+		recipients = append(recipients, m.ID)
+	}
+
+	msg, _ := json.Marshal(membershipEvent{
+		Type:        "membership",
+		Event:       event,
+		UserID:      user.ID,
+		DisplayName: user.DisplayName,
+		Channel:     channel,
+		MemberCount: count,
+	})
+	broadcastToChannel(channel, user.ID, msg)
+	metrics.Inc("walkie_channel_notices_total", map[string]string{"event": event})
+
+	if channelNoticeTTSEnabled() && len(recipients) > 0 {
+		go speakMembershipNotice(user.DisplayName, channel, event, recipients)
+	}
+}
+
+// speakMembershipNotice sintetiza "Ana entró al canal" y lo encola a los miembros
+func speakMembershipNotice(name, channel, event string, recipients []uint) {
+	text := fmt.Sprintf("%s entró al canal", name)
+	if event == membershipLeft {
+		text = fmt.Sprintf("%s salió del canal", name)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), announcementSynthTimeout)
+	defer cancel()
+	audioData, err := synthesizeSpeech(ctx, text)
+	if err != nil {
+		log.Printf("[AVISOS] canal=%s error sintetizando aviso: %v", channel, err)
+		return
+	}
+	EnqueueAudioWithPriority(ChannelNoticeSenderID, channel, audioData, estimateAudioDuration(audioData).Seconds(), recipients, PriorityNormal)
+}
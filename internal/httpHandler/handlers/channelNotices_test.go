@@ -0,0 +1,73 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"walkie-backend/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+func TestChannelConnectAndDisconnect_NotifyMembers(t *testing.T) {
+	nuria := models.User{Model: gorm.Model{ID: 674}, DisplayName: "Nuria"}
+	pablo := models.User{Model: gorm.Model{ID: 675}, DisplayName: "Pablo"}
+	svc := &mockUserService{members: map[string][]models.User{"canal-1": {nuria, pablo}}}
+
+	listener := &wsClient{userID: pablo.ID, channel: "canal-1", send: make(chan []byte, 8)}
+	registerClient(listener)
+	defer removeClient(listener)
+
+	next := func() membershipEvent {
+		t.Helper()
+		select {
+		case raw := <-listener.send:
+			var ev membershipEvent
+			require.NoError(t, json.Unmarshal(raw, &ev))
+			return ev
+		case <-time.After(time.Second):
+			t.Fatal("expected membership event")
+			return membershipEvent{}
+		}
+	}
+
+	_, err := handleChannelConnectCommand(&nuria, svc, "canal-1")
+	require.NoError(t, err)
+	ev := next()
+	assert.Equal(t, "membership", ev.Type)
+	assert.Equal(t, membershipJoined, ev.Event)
+	assert.Equal(t, "Nuria", ev.DisplayName)
+	assert.Equal(t, 2, ev.MemberCount)
+
+	channel := models.Channel{Model: gorm.Model{ID: 1}, Code: "canal-1"}
+	nuria.CurrentChannelID, nuria.CurrentChannel = &channel.ID, &channel
+	svc.members["canal-1"] = []models.User{pablo}
+	_, err = handleChannelDisconnectCommand(&nuria, svc)
+	require.NoError(t, err)
+	ev = next()
+	assert.Equal(t, membershipLeft, ev.Event)
+	assert.Equal(t, 1, ev.MemberCount)
+}
+
+func TestSpeakMembershipNotice_EnqueuesToMembers(t *testing.T) {
+	orig := synthesizeSpeech
+	t.Cleanup(func() { synthesizeSpeech = orig })
+	var spoken string
+	synthesizeSpeech = func(_ context.Context, text string) ([]byte, error) {
+		spoken = text
+		return buildTestWAV(1600), nil
+	}
+	t.Cleanup(func() { ClearPendingAudio(676) })
+
+	speakMembershipNotice("Nuria", "canal-1", membershipLeft, []uint{676})
+
+	assert.Equal(t, "Nuria salió del canal", spoken)
+	clip := DequeueAudio(676)
+	require.NotNil(t, clip)
+	assert.Equal(t, ChannelNoticeSenderID, clip.SenderID)
+	assert.Equal(t, "canal-1", clip.Channel)
+}
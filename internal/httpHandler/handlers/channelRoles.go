@@ -0,0 +1,161 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+
+	"walkie-backend/internal/config"
+	"walkie-backend/internal/models"
+	"walkie-backend/internal/response"
+	"walkie-backend/internal/services"
+)
+
+type channelRoleRequest struct {
+	Role string `json:"role"`
+}
+
+// PUT /channels/{code}/roles/{userID} con {"role":"moderator"} nombra moderador;
+// DELETE lo devuelve a member. Sólo el propietario del canal puede hacerlo.
+func ChannelRole(w http.ResponseWriter, r *http.Request) {
+	if !requireDB(w) {
+		return
+	}
+	user, err := resolveUserFromRequest(r)
+	if err != nil {
+		response.WriteErr(w, http.StatusUnauthorized, "X-Auth-Token inválido o expirado")
+		return
+	}
+	code := r.PathValue("code")
+	targetID, ok := pathUserID(w, r)
+	if !ok {
+		return
+	}
+	role, ok := readRoleChange(w, r)
+	if !ok {
+		return
+	}
+
+	if err := services.ManageChannelRole(config.DB, user.ID, code, targetID, role); err != nil {
+		writeChannelRoleError(w, err)
+		return
+	}
+	recordRoleChange(fmt.Sprintf("user:%d", user.ID), code, targetID, role)
+	response.WriteJSON(w, http.StatusOK, map[string]any{"channel": code, "user_id": targetID, "role": role})
+}
+
+// PUT /admin/channels/{code}/roles/{userID} asigna cualquier rol, incluido owner;
+// DELETE lo devuelve a member
+func AdminChannelRole(w http.ResponseWriter, r *http.Request) {
+	if !requireAdmin(w, r) || !requireDB(w) {
+		return
+	}
+	code := r.PathValue("code")
+	targetID, ok := pathUserID(w, r)
+	if !ok {
+		return
+	}
+	role, ok := readRoleChange(w, r)
+	if !ok {
+		return
+	}
+
+	if err := services.SetChannelRole(config.DB, code, targetID, role); err != nil {
+		writeChannelRoleError(w, err)
+		return
+	}
+	recordRoleChange(adminActor(r), code, targetID, role)
+	response.WriteJSON(w, http.StatusOK, map[string]any{"channel": code, "user_id": targetID, "role": role})
+}
+
+// POST /channels/{code}/kick/{userID} expulsa a un usuario; sólo moderadores y propietarios
+func KickChannelUser(w http.ResponseWriter, r *http.Request) {
+	if !requireDB(w) {
+		return
+	}
+	user, err := resolveUserFromRequest(r)
+	if err != nil {
+		response.WriteErr(w, http.StatusUnauthorized, "X-Auth-Token inválido o expirado")
+		return
+	}
+	code := r.PathValue("code")
+	targetID, ok := pathUserID(w, r)
+	if !ok {
+		return
+	}
+
+	meta := services.EventMeta{
+		Actor:     fmt.Sprintf("user:%d", user.ID),
+		Source:    models.EventSourceHTTP,
+		RequestID: requestID(w, r),
+	}
+	if err := services.KickFromChannel(config.DB, meta, code, user.ID, targetID); err != nil {
+		writeChannelRoleError(w, err)
+		return
+	}
+
+	moveClientToChannel(targetID, "")
+	ClearPendingAudio(targetID)
+	services.RecordAudit(nil, models.AuditEntry{
+		Actor:   meta.Actor,
+		Action:  "channel_kick",
+		UserID:  &targetID,
+		Channel: code,
+		Source:  meta.Source,
+	})
+	log.Printf("[ROLES] usuario=%d expulsado de %s por usuario=%d", targetID, code, user.ID)
+	response.WriteJSON(w, http.StatusOK, map[string]any{"status": "kicked", "channel": code, "user_id": targetID})
+}
+
+func pathUserID(w http.ResponseWriter, r *http.Request) (uint, bool) {
+	id, err := strconv.ParseUint(r.PathValue("userID"), 10, 64)
+	if err != nil || id == 0 {
+		response.WriteErr(w, http.StatusBadRequest, "ID de usuario inválido")
+		return 0, false
+	}
+	return uint(id), true
+}
+
+// readRoleChange lee el rol del cuerpo en PUT; DELETE equivale a volver a member
+func readRoleChange(w http.ResponseWriter, r *http.Request) (string, bool) {
+	if r.Method == http.MethodDelete {
+		return models.ChannelRoleMember, true
+	}
+	var req channelRoleRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 4096)).Decode(&req); err != nil || req.Role == "" {
+		response.WriteErr(w, http.StatusBadRequest, "Se requiere role")
+		return "", false
+	}
+	return req.Role, true
+}
+
+func recordRoleChange(actor, code string, targetID uint, role string) {
+	services.RecordAudit(nil, models.AuditEntry{
+		Actor:   actor,
+		Action:  "channel_role",
+		UserID:  &targetID,
+		Channel: code,
+		Details: "role=" + role,
+		Source:  models.EventSourceHTTP,
+	})
+	log.Printf("[ROLES] usuario=%d canal=%s rol=%s por %s", targetID, code, role, actor)
+}
+
+func writeChannelRoleError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, services.ErrChannelNotFound):
+		response.WriteErr(w, http.StatusNotFound, "Canal no encontrado")
+	case errors.Is(err, services.ErrUserNotFound), errors.Is(err, services.ErrUserNotInChannel):
+		response.WriteErr(w, http.StatusNotFound, err.Error())
+	case errors.Is(err, services.ErrInvalidRole):
+		response.WriteErr(w, http.StatusBadRequest, err.Error())
+	case errors.Is(err, services.ErrRoleForbidden), errors.Is(err, services.ErrNotModerator), errors.Is(err, services.ErrCannotKick):
+		response.WriteErr(w, http.StatusForbidden, err.Error())
+	default:
+		log.Printf("[ROLES] error=%v", err)
+		response.WriteErr(w, http.StatusInternalServerError, "No se pudo completar la operación")
+	}
+}
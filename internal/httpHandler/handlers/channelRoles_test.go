@@ -0,0 +1,93 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"walkie-backend/internal/config"
+	"walkie-backend/internal/models"
+	"walkie-backend/internal/services"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func roleRequest(method, code string, target uint, body string, actor *models.User) *http.Request {
+	path := fmt.Sprintf("/channels/%s/roles/%d", code, target)
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	req.SetPathValue("code", code)
+	req.SetPathValue("userID", fmt.Sprint(target))
+	if actor != nil {
+		req = req.WithContext(withAuthUser(req.Context(), actor))
+	}
+	return req
+}
+
+func TestChannelRoles_GrantAndKick(t *testing.T) {
+	cleanup := setupChannelsTestDB(t)
+	defer cleanup()
+	t.Setenv("ADMIN_TOKEN", "secreto")
+	require.NoError(t, config.DB.AutoMigrate(&models.AuditEntry{}, &models.ChannelEvent{}))
+
+	config.DB.Create(&models.Channel{Code: "canal-5", Name: "Cinco", MaxUsers: 10})
+	ana := &models.User{DisplayName: "Ana", IsActive: true}
+	beto := &models.User{DisplayName: "Beto", IsActive: true}
+	carla := &models.User{DisplayName: "Carla", IsActive: true}
+	for _, u := range []*models.User{ana, beto, carla} {
+		require.NoError(t, config.DB.Create(u).Error)
+		require.NoError(t, services.NewUserService().ConnectUserToChannel(u.ID, "canal-5"))
+	}
+
+	// Sólo un administrador puede nombrar al propietario
+	req := roleRequest(http.MethodPut, "canal-5", ana.ID, `{"role":"owner"}`, nil)
+	req.Header.Set("X-Admin-Token", "secreto")
+	rec := httptest.NewRecorder()
+	AdminChannelRole(rec, req)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+	rec = httptest.NewRecorder()
+	ChannelRole(rec, roleRequest(http.MethodPut, "canal-5", beto.ID, `{"role":"moderator"}`, ana))
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+	rec = httptest.NewRecorder()
+	ChannelRole(rec, roleRequest(http.MethodPut, "canal-5", carla.ID, `{"role":"moderator"}`, beto))
+	assert.Equal(t, http.StatusForbidden, rec.Code, "a moderator cannot grant roles")
+
+	kick := func(actor *models.User, target uint) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, fmt.Sprintf("/channels/canal-5/kick/%d", target), nil)
+		req.SetPathValue("code", "canal-5")
+		req.SetPathValue("userID", fmt.Sprint(target))
+		req = req.WithContext(withAuthUser(req.Context(), actor))
+		rec := httptest.NewRecorder()
+		KickChannelUser(rec, req)
+		return rec
+	}
+
+	assert.Equal(t, http.StatusForbidden, kick(carla, beto.ID).Code, "members cannot kick")
+	assert.Equal(t, http.StatusForbidden, kick(beto, ana.ID).Code, "the owner cannot be kicked")
+
+	client := &wsClient{userID: carla.ID, channel: "canal-5", send: make(chan []byte, 4)}
+	registerClient(client)
+	defer removeClient(client)
+	EnqueueAudio(beto.ID, "canal-5", []byte("audio"), 1, []uint{carla.ID})
+
+	rec = kick(beto, carla.ID)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	assert.Equal(t, "", currentWSChannel(carla.ID))
+	assert.Nil(t, DequeueAudio(carla.ID))
+
+	var reloaded models.User
+	config.DB.First(&reloaded, carla.ID)
+	assert.Nil(t, reloaded.CurrentChannelID)
+	assert.Equal(t, http.StatusNotFound, kick(beto, carla.ID).Code, "already out of the channel")
+
+	rec = httptest.NewRecorder()
+	ChannelRole(rec, roleRequest(http.MethodDelete, "canal-5", beto.ID, "", ana))
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	role, err := services.ChannelRole(config.DB, beto.ID, "canal-5")
+	require.NoError(t, err)
+	assert.Equal(t, models.ChannelRoleMember, role)
+}
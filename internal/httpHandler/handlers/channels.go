@@ -11,6 +11,10 @@ import (
 const PublicMaxUsers = 100
 
 func ListPublicChannels(w http.ResponseWriter, _ *http.Request) {
+	if !requireDB(w) {
+		return
+	}
+
 	var channels []models.Channel
 	if err := config.DB.Where("is_private = ?", false).Find(&channels).Error; err != nil {
 		response.WriteErr(w, http.StatusInternalServerError, "No se pudo listar canales")
@@ -35,6 +39,10 @@ func ListPublicChannels(w http.ResponseWriter, _ *http.Request) {
 }
 
 func ChannelUsers(w http.ResponseWriter, r *http.Request) {
+	if !requireDB(w) {
+		return
+	}
+
 	code := r.URL.Query().Get("channel")
 	if code == "" {
 		response.WriteErr(w, http.StatusBadRequest, "Canal inválido")
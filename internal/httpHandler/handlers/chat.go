@@ -0,0 +1,146 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"walkie-backend/internal/config"
+	"walkie-backend/internal/models"
+	"walkie-backend/internal/response"
+	"walkie-backend/internal/services"
+)
+
+// ChatLimiter limita los mensajes de texto por usuario
+var ChatLimiter = rateLimitFromEnv("chat", 30, 10)
+
+// wsChatIn es un mensaje de texto enviado por el cliente: {"type":"chat","text":"..."}
+type wsChatIn struct {
+	Type string `json:"type"`
+	Text string `json:"text"`
+}
+
+// chatMessage es como se entrega un mensaje de texto, por WebSocket o por REST
+type chatMessage struct {
+	Type    string `json:"type,omitempty"`
+	ID      uint   `json:"id"`
+	From    uint   `json:"from"`
+	Name    string `json:"name,omitempty"`
+	Channel string `json:"channel"`
+	Text    string `json:"text"`
+	SentAt  string `json:"sent_at"`
+}
+
+func toChatMessage(m models.ChannelMessage) chatMessage {
+	return chatMessage{
+		ID:      m.ID,
+		From:    m.SenderID,
+		Name:    m.SenderName,
+		Channel: m.ChannelCode,
+		Text:    m.Text,
+		SentAt:  m.CreatedAt.UTC().Format(time.RFC3339Nano),
+	}
+}
+
+// handleTextFrame atiende los frames de texto que llegan después del handshake
+func (c *wsClient) handleTextFrame(data []byte) {
+	var in wsChatIn
+	if err := json.Unmarshal(data, &in); err != nil || in.Type != "chat" {
+		c.writeJSON(map[string]string{"type": "error", "error": "Mensaje no reconocido"})
+		return
+	}
+	c.postChat(in.Text)
+}
+
+func (c *wsClient) postChat(text string) {
+	registry.RLock()
+	channel := c.channel
+	registry.RUnlock()
+	if channel == "" {
+		c.writeJSON(map[string]string{"type": "chat_error", "error": "No estás en ningún canal"})
+		return
+	}
+	if allowed, _ := ChatLimiter.Allow(fmt.Sprintf("user:%d", c.userID)); !allowed {
+		c.writeJSON(map[string]string{"type": "chat_error", "error": "Demasiados mensajes, espera un momento"})
+		return
+	}
+	if !config.DBAvailable() {
+		c.writeJSON(map[string]string{"type": "chat_error", "error": "Servicio temporalmente no disponible"})
+		return
+	}
+
+	sender := &models.User{DisplayName: c.name}
+	sender.ID = c.userID
+	msg, err := services.PostChannelMessage(config.DB, channel, sender, text)
+	switch {
+	case errors.Is(err, services.ErrEmptyMessage), errors.Is(err, services.ErrMessageTooLong):
+		c.writeJSON(map[string]string{"type": "chat_error", "error": err.Error()})
+		return
+	case err != nil:
+		log.Printf("[CHAT] usuario=%d canal=%s error=%v", c.userID, channel, err)
+		c.writeJSON(map[string]string{"type": "chat_error", "error": "No se pudo enviar el mensaje"})
+		return
+	}
+
+	log.Printf("[CHAT] usuario=%d canal=%s mensaje=%d", c.userID, channel, msg.ID)
+	broadcastChat(*msg)
+}
+
+// broadcastChat envía el mensaje a todos los clientes del canal, incluido el emisor,
+// por la misma conexión que el audio para que se reproduzcan en orden
+func broadcastChat(m models.ChannelMessage) {
+	out := toChatMessage(m)
+	out.Type = "chat"
+	payload, _ := json.Marshal(out)
+	broadcastToChannel(m.ChannelCode, 0, payload)
+}
+
+// GET /channels/{code}/messages?limit=N&before=ID
+func ChannelMessages(w http.ResponseWriter, r *http.Request) {
+	if _, code, ok := channelMember(w, r); ok {
+		writeChannelMessages(w, r, code)
+	}
+}
+
+// writeChannelMessages responde GET /channels/{code}/messages?limit=N&before=ID
+func writeChannelMessages(w http.ResponseWriter, r *http.Request, code string) {
+	q := r.URL.Query()
+	limit := 0
+	if raw := q.Get("limit"); raw != "" {
+		v, err := strconv.Atoi(raw)
+		if err != nil || v <= 0 {
+			response.WriteErr(w, http.StatusBadRequest, "limit inválido")
+			return
+		}
+		limit = v
+	}
+	var before uint
+	if raw := q.Get("before"); raw != "" {
+		v, err := strconv.ParseUint(raw, 10, 64)
+		if err != nil {
+			response.WriteErr(w, http.StatusBadRequest, "before inválido")
+			return
+		}
+		before = uint(v)
+	}
+
+	items, err := services.ChannelMessages(config.DB, code, before, limit)
+	if err != nil {
+		response.WriteErr(w, http.StatusInternalServerError, "No se pudieron obtener los mensajes")
+		return
+	}
+
+	out := make([]chatMessage, 0, len(items))
+	for _, m := range items {
+		out = append(out, toChatMessage(m))
+	}
+	body := map[string]any{"channel": code, "messages": out}
+	if len(items) > 0 {
+		body["next_before"] = items[len(items)-1].ID
+	}
+	response.WriteJSON(w, http.StatusOK, body)
+}
@@ -0,0 +1,84 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"walkie-backend/internal/models"
+
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHandleWebSocket_ChatIsPersistedAndBroadcast(t *testing.T) {
+	db := setupTestDB(t)
+	require.NoError(t, db.AutoMigrate(&models.ChannelMessage{}))
+	user := createTestUser(t, db, 131, "token-chat", "canal-chat")
+
+	s := httptest.NewServer(http.HandlerFunc(HandleWebSocket))
+	defer s.Close()
+
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(s.URL, "http"), nil)
+	require.NoError(t, err)
+	defer conn.Close()
+
+	handshake, _ := json.Marshal(map[string]any{"userId": user.ID, "token": user.AuthToken})
+	require.NoError(t, conn.WriteMessage(websocket.TextMessage, handshake))
+	_, _, err = conn.ReadMessage()
+	require.NoError(t, err)
+
+	require.NoError(t, conn.WriteMessage(websocket.TextMessage, []byte(`{"type":"chat","text":"  llego en 5  "}`)))
+
+	_ = conn.SetReadDeadline(time.Now().Add(3 * time.Second))
+	var got chatMessage
+	for got.Type != "chat" {
+		require.NoError(t, conn.ReadJSON(&got))
+	}
+	assert.Equal(t, "llego en 5", got.Text)
+	assert.Equal(t, user.ID, got.From)
+	assert.Equal(t, "canal-chat", got.Channel)
+
+	var stored models.ChannelMessage
+	require.NoError(t, db.First(&stored, got.ID).Error)
+	assert.Equal(t, "llego en 5", stored.Text)
+}
+
+func TestChannelHistory_MessagesRoute(t *testing.T) {
+	db := setupTestDB(t)
+	require.NoError(t, db.AutoMigrate(&models.ChannelMessage{}))
+	user := createTestUser(t, db, 132, "token-msgs", "canal-m")
+	for i, text := range []string{"a", "b", "c"} {
+		require.NoError(t, db.Create(&models.ChannelMessage{ChannelCode: "canal-m", SenderID: 132, Text: text, CreatedAt: time.Now().Add(time.Duration(i) * time.Second)}).Error)
+	}
+
+	get := func(query string) (int, map[string]json.RawMessage) {
+		req := httptest.NewRequest(http.MethodGet, "/channels/canal-m/messages"+query, nil)
+		req = req.WithContext(withAuthUser(req.Context(), user))
+		rec := httptest.NewRecorder()
+		serveChannelRoute(rec, req)
+		var body map[string]json.RawMessage
+		_ = json.Unmarshal(rec.Body.Bytes(), &body)
+		return rec.Code, body
+	}
+
+	code, body := get("?limit=2")
+	require.Equal(t, http.StatusOK, code)
+	var page []chatMessage
+	require.NoError(t, json.Unmarshal(body["messages"], &page))
+	require.Len(t, page, 2)
+	assert.Equal(t, "c", page[0].Text)
+
+	code, body = get("?limit=2&before=" + string(body["next_before"]))
+	require.Equal(t, http.StatusOK, code)
+	require.NoError(t, json.Unmarshal(body["messages"], &page))
+	require.Len(t, page, 1)
+	assert.Equal(t, "a", page[0].Text)
+
+	code, _ = get("?before=x")
+	assert.Equal(t, http.StatusBadRequest, code)
+}
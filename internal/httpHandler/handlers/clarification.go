@@ -0,0 +1,81 @@
+package handlers
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+
+	"walkie-backend/internal/ai"
+	"walkie-backend/internal/config"
+	"walkie-backend/internal/metrics"
+	"walkie-backend/internal/models"
+	"walkie-backend/internal/response"
+)
+
+const defaultIntentConfidenceThreshold = 0.6
+
+// clarificationQuestions son las preguntas para los comandos dudosos que se ejecutan con
+// executeCommand; el resto de intents no se aclara
+var clarificationQuestions = map[string]string{
+	"request_channel_list":       "¿Quieres escuchar la lista de canales?",
+	"request_channel_disconnect": "¿Quieres salir del canal?",
+	"request_user_list":          "¿Quieres saber quién está en el canal?",
+	"request_current_channel":    "¿Quieres saber en qué canal estás?",
+	intentFavoriteChannel:        "¿Quieres volver a tu canal favorito?",
+}
+
+// intentConfidenceThreshold lee INTENT_CONFIDENCE_THRESHOLD (0-1); 0 desactiva las aclaraciones
+func intentConfidenceThreshold() float64 {
+	return config.FloatFromEnv("INTENT_CONFIDENCE_THRESHOLD", defaultIntentConfidenceThreshold, 0, 1)
+}
+
+// clarificationFor devuelve la pregunta para confirmar result y el canal propuesto, si lo hay
+func clarificationFor(result ai.CommandResult) (question, channel string) {
+	if result.Intent == "request_channel_connect" {
+		if len(result.Channels) == 0 {
+			// "cambia de canal" sin número ya se responde con una pregunta
+			return "", ""
+		}
+		channel = result.Channels[0]
+		return fmt.Sprintf("¿Quieres conectarte al canal %s?", channelLabel(channel)), channel
+	}
+	return clarificationQuestions[result.Intent], ""
+}
+
+// clarifyStage pregunta antes de ejecutar un comando que la IA clasificó con poca
+// confianza; un "sí" lo ejecuta por el flujo de confirmaciones pendientes. Devuelve true
+// si respondió la petición.
+func clarifyStage(w http.ResponseWriter, user *models.User, svc userService, result ai.CommandResult, deps audioIngestDeps, tracker *stageTimer) bool {
+	// Confidence 0 significa que el proveedor o la heurística local no la indicaron
+	threshold := intentConfidenceThreshold()
+	if result.Confidence <= 0 || result.Confidence >= threshold {
+		return false
+	}
+	question, channel := clarificationFor(result)
+	if question == "" {
+		return false
+	}
+
+	setPendingConfirmation(user.ID, &pendingConfirmation{
+		Action:  result.Intent,
+		Channel: channel,
+		OnConfirm: func() (CommandResponse, error) {
+			return deps.executeCommand(user, svc, result)
+		},
+	}, 0)
+	metrics.Inc("walkie_intent_clarifications_total", map[string]string{"intent": result.Intent})
+	log.Printf("[ACLARACION] usuario=%d intent=%s confianza=%.2f umbral=%.2f", user.ID, result.Intent, result.Confidence, threshold)
+
+	data := map[string]any{"confidence": result.Confidence}
+	if channel != "" {
+		data["pending_channel"] = channel
+	}
+	response.WriteJSON(w, http.StatusOK, CommandResponse{
+		Status:  "pending",
+		Intent:  result.Intent,
+		Message: question,
+		Data:    data,
+	})
+	tracker.LogFinal("clarification")
+	return true
+}
@@ -0,0 +1,61 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"walkie-backend/internal/ai"
+	"walkie-backend/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+func TestClarifyStage_AsksThenConfirms(t *testing.T) {
+	user := &models.User{Model: gorm.Model{ID: 680}, DisplayName: "Irene"}
+	svc := &mockUserService{}
+	deps := audioIngestDeps{executeCommand: executeCommand}
+	t.Cleanup(func() { takePendingConfirmation(user.ID, time.Now()) })
+
+	result := ai.CommandResult{IsCommand: true, Intent: "request_channel_connect", Channels: []string{"canal-2"}, Confidence: 0.4}
+	rec := httptest.NewRecorder()
+	require.True(t, clarifyStage(rec, user, svc, result, deps, newStageTimer(user.ID)))
+
+	var resp CommandResponse
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+	assert.Equal(t, "pending", resp.Status)
+	assert.Equal(t, "¿Quieres conectarte al canal 2?", resp.Message)
+	assert.Equal(t, "canal-2", resp.Data["pending_channel"])
+	assert.Empty(t, svc.connected, "no debe ejecutarse antes de confirmar")
+	assert.Equal(t, "canal-2", pendingChannelFor(user.ID))
+
+	rec = httptest.NewRecorder()
+	require.True(t, resolveConfirmation(rec, user, "sí", "stt", newStageTimer(user.ID)))
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+	assert.Equal(t, "Conectado al canal 2", resp.Message)
+	assert.Equal(t, []string{"canal-2"}, svc.connected)
+}
+
+func TestClarifyStage_SkipsConfidentOrUnknown(t *testing.T) {
+	user := &models.User{Model: gorm.Model{ID: 681}}
+	deps := audioIngestDeps{executeCommand: executeCommand}
+	t.Cleanup(func() { takePendingConfirmation(user.ID, time.Now()) })
+
+	for _, result := range []ai.CommandResult{
+		{IsCommand: true, Intent: "request_channel_disconnect", Confidence: 0.9},
+		// Las heurísticas locales no indican confianza
+		{IsCommand: true, Intent: "request_channel_disconnect"},
+		// Sin pregunta para el intent se ejecuta directamente
+		{IsCommand: true, Intent: intentDNDOn, Confidence: 0.3},
+	} {
+		assert.False(t, clarifyStage(httptest.NewRecorder(), user, &mockUserService{}, result, deps, newStageTimer(user.ID)), result.Intent)
+	}
+
+	t.Setenv("INTENT_CONFIDENCE_THRESHOLD", "0")
+	result := ai.CommandResult{IsCommand: true, Intent: "request_channel_disconnect", Confidence: 0.2}
+	assert.False(t, clarifyStage(httptest.NewRecorder(), user, &mockUserService{}, result, deps, newStageTimer(user.ID)))
+	assert.Nil(t, peekPendingConfirmation(user.ID, time.Now()))
+}
@@ -0,0 +1,225 @@
+package handlers
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"walkie-backend/internal/ai"
+	"walkie-backend/internal/config"
+	"walkie-backend/internal/models"
+	"walkie-backend/internal/response"
+	"walkie-backend/internal/services"
+)
+
+const (
+	// clientRetryInterval es lo que se espera antes de volver a crear un cliente que falló
+	clientRetryInterval = 30 * time.Second
+
+	defaultClientProbeTTL = 30 * time.Second
+)
+
+// Variables de entorno de las que depende cada cliente; si alguna cambia se vuelve a crear
+var (
+	sttEnvKeys = []string{"ASSEMBLYAI_API_KEY", "ASSEMBLYAI_API_URL", "ASSEMBLYAI_STREAMING_URL", "ASSEMBLYAI_STREAMING_MODEL"}
+	aiEnvKeys  = []string{
+		"AI_PROVIDER", "AI_API_URL", "AI_MODEL", "DO_AI_ACCESS_KEY",
+		"DEEPSEEK_API_KEY", "DEEPSEEK_API_URL", "DEEPSEEK_MODEL", "OLLAMA_URL", "OLLAMA_MODEL",
+	}
+	ttsEnvKeys = []string{"TTS_API_KEY", "TTS_API_URL", "TTS_MODEL", "TTS_VOICE"}
+)
+
+// clientGen describe la generación actual de un cliente externo; lo protege clientsMu
+type clientGen struct {
+	name     string
+	keys     []string
+	config   string
+	initAt   time.Time
+	reinits  int
+	probedAt time.Time
+	probeErr error
+}
+
+func envFingerprint(keys []string) string {
+	var b strings.Builder
+	for _, k := range keys {
+		b.WriteString(k)
+		b.WriteByte('=')
+		b.WriteString(strings.TrimSpace(os.Getenv(k)))
+		b.WriteByte('\n')
+	}
+	return b.String()
+}
+
+func (g *clientGen) initialized() {
+	g.config = envFingerprint(g.keys)
+	g.initAt = time.Now()
+}
+
+// stale dice si hay que volver a crear el cliente: cambió su configuración o falló al
+// crearse hace más de clientRetryInterval
+func (g *clientGen) stale(initErr error) bool {
+	if g.initAt.IsZero() {
+		return false
+	}
+	if g.config != envFingerprint(g.keys) {
+		log.Printf("[CLIENTES] la configuración de %s cambió, se vuelve a crear el cliente", g.name)
+		return true
+	}
+	return initErr != nil && time.Since(g.initAt) > clientRetryInterval
+}
+
+// reset descarta la generación actual; forgetProbe borra también el último sondeo, que
+// ya no dice nada del cliente nuevo
+func (g *clientGen) reset(forgetProbe bool) {
+	if !g.initAt.IsZero() {
+		g.reinits++
+	}
+	g.initAt = time.Time{}
+	if forgetProbe {
+		g.probedAt, g.probeErr = time.Time{}, nil
+	}
+}
+
+func resetAILocked(forgetProbe bool) {
+	onceAI, aiClient, aiErr = sync.Once{}, nil, nil
+	aiGen.reset(forgetProbe)
+}
+
+func resetSTTLocked(forgetProbe bool) {
+	onceSTT, sClient, sErr = sync.Once{}, nil, nil
+	sttGen.reset(forgetProbe)
+}
+
+func resetTTSLocked(forgetProbe bool) {
+	onceTTS, tClient, tErr = sync.Once{}, nil, nil
+	ttsGen.reset(forgetProbe)
+}
+
+// ResetExternalClients descarta los clientes de STT, IA y TTS para que la siguiente
+// petición los cree con la configuración actual; lo usan SIGHUP y /admin/clients/reload
+func ResetExternalClients(reason string) {
+	clientsMu.Lock()
+	resetAILocked(true)
+	resetSTTLocked(true)
+	resetTTSLocked(true)
+	clientsMu.Unlock()
+	log.Printf("[CLIENTES] clientes de STT, IA y TTS reiniciados (%s)", reason)
+}
+
+func probeClientsEnabled() bool {
+	return strings.EqualFold(strings.TrimSpace(os.Getenv("READYZ_PROBE_CLIENTS")), "true")
+}
+
+// probeClient llama a la API con el cliente en caché, como mucho una vez cada
+// CLIENT_PROBE_TTL. Si falla se descarta el cliente para que la siguiente petición lo
+// vuelva a crear en vez de arrastrar uno roto.
+func probeClient(ctx context.Context, g *clientGen, ping func(context.Context) error, reset func(bool)) error {
+	if !probeClientsEnabled() {
+		return nil
+	}
+	ttl := config.DurationFromEnv("CLIENT_PROBE_TTL", defaultClientProbeTTL)
+	clientsMu.Lock()
+	if !g.probedAt.IsZero() && time.Since(g.probedAt) < ttl {
+		err := g.probeErr
+		clientsMu.Unlock()
+		return err
+	}
+	clientsMu.Unlock()
+
+	err := ping(ctx)
+
+	clientsMu.Lock()
+	defer clientsMu.Unlock()
+	g.probedAt, g.probeErr = time.Now(), err
+	if err != nil {
+		log.Printf("[CLIENTES] el sondeo de %s falló, se volverá a crear el cliente: %v", g.name, err)
+		reset(false)
+	}
+	return err
+}
+
+// checkSTTClient es la comprobación de /readyz para el STT
+func checkSTTClient(ctx context.Context) error {
+	client, err := EnsureSTTClient()
+	if err != nil {
+		return err
+	}
+	return probeClient(ctx, &sttGen, client.Ping, resetSTTLocked)
+}
+
+// checkAIClient es la comprobación de /readyz para la IA; los proveedores sin Ping sólo
+// se comprueban al crearse
+func checkAIClient(ctx context.Context) error {
+	analyzer, err := EnsureAIClient()
+	if err != nil {
+		return err
+	}
+	pinger, ok := analyzer.(ai.Pinger)
+	if !ok {
+		return nil
+	}
+	return probeClient(ctx, &aiGen, pinger.Ping, resetAILocked)
+}
+
+// clientStatus es el estado de un cliente externo en /readyz
+type clientStatus struct {
+	Status        string     `json:"status"`
+	Error         string     `json:"error,omitempty"`
+	InitializedAt *time.Time `json:"initialized_at,omitempty"`
+	ProbedAt      *time.Time `json:"probed_at,omitempty"`
+	Reinits       int        `json:"reinits"`
+}
+
+func (g *clientGen) status(initErr error) clientStatus {
+	st := clientStatus{Status: "uninitialized", Reinits: g.reinits}
+	if !g.initAt.IsZero() {
+		at := g.initAt
+		st.InitializedAt = &at
+		st.Status = "ok"
+	}
+	if !g.probedAt.IsZero() {
+		at := g.probedAt
+		st.ProbedAt = &at
+	}
+	switch {
+	case st.InitializedAt != nil && initErr != nil:
+		st.Status, st.Error = "error", initErr.Error()
+	case g.probeErr != nil:
+		st.Status, st.Error = "error", g.probeErr.Error()
+	}
+	return st
+}
+
+// externalClientStatus resume los clientes de STT, IA y TTS
+func externalClientStatus() map[string]clientStatus {
+	clientsMu.Lock()
+	defer clientsMu.Unlock()
+	return map[string]clientStatus{
+		sttGen.name: sttGen.status(sErr),
+		aiGen.name:  aiGen.status(aiErr),
+		ttsGen.name: ttsGen.status(tErr),
+	}
+}
+
+// POST /admin/clients/reload descarta los clientes de STT, IA y TTS para que se creen de
+// nuevo con la configuración actual, p. ej. tras rotar una clave
+func AdminReloadClients(w http.ResponseWriter, r *http.Request) {
+	if !requireAdmin(w, r) {
+		return
+	}
+	ResetExternalClients("admin")
+	services.RecordAudit(nil, models.AuditEntry{
+		Actor:  adminActor(r),
+		Action: "clients_reload",
+		Source: models.EventSourceHTTP,
+	})
+	response.WriteJSON(w, http.StatusOK, map[string]any{
+		"status":  "ok",
+		"clients": externalClientStatus(),
+	})
+}
@@ -0,0 +1,112 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEnsureSTTClient_RecreatesWhenConfigChanges(t *testing.T) {
+	ResetExternalClients("test")
+	t.Cleanup(func() { ResetExternalClients("test") })
+
+	t.Setenv("ASSEMBLYAI_API_KEY", "clave-1")
+	first, err := EnsureSTTClient()
+	require.NoError(t, err)
+	again, _ := EnsureSTTClient()
+	assert.Same(t, first, again)
+
+	t.Setenv("ASSEMBLYAI_API_KEY", "clave-2")
+	second, err := EnsureSTTClient()
+	require.NoError(t, err)
+	assert.NotSame(t, first, second)
+
+	t.Setenv("ASSEMBLYAI_API_KEY", "")
+	_, err = EnsureSTTClient()
+	require.Error(t, err)
+
+	t.Setenv("ASSEMBLYAI_API_KEY", "clave-3")
+	_, err = EnsureSTTClient()
+	require.NoError(t, err)
+	assert.Equal(t, 3, externalClientStatus()["stt"].Reinits)
+}
+
+func TestReadyz_ProbesClientsAndRecovers(t *testing.T) {
+	setupTestDB(t)
+	ResetExternalClients("test")
+	t.Cleanup(func() { ResetExternalClients("test") })
+
+	var calls atomic.Int32
+	var keyOK atomic.Bool
+	stt := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		assert.Equal(t, "/v2/transcript", r.URL.Path)
+		if !keyOK.Load() {
+			http.Error(w, `{"error":"Authentication error"}`, http.StatusUnauthorized)
+			return
+		}
+		_, _ = w.Write([]byte(`{"transcripts":[]}`))
+	}))
+	defer stt.Close()
+	llm := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/v1/models", r.URL.Path)
+		_, _ = w.Write([]byte(`{"data":[]}`))
+	}))
+	defer llm.Close()
+
+	t.Setenv("READYZ_PROBE_CLIENTS", "true")
+	t.Setenv("ASSEMBLYAI_API_KEY", "clave-mala")
+	t.Setenv("ASSEMBLYAI_API_URL", stt.URL+"/v2")
+	t.Setenv("AI_PROVIDER", "ollama")
+	t.Setenv("OLLAMA_URL", llm.URL+"/v1")
+
+	type readyzBody struct {
+		Status  string                      `json:"status"`
+		Checks  map[string]dependencyStatus `json:"checks"`
+		Clients map[string]clientStatus     `json:"clients"`
+	}
+	readyz := func() (int, readyzBody) {
+		rec := httptest.NewRecorder()
+		Readyz(rec, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+		var body readyzBody
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+		return rec.Code, body
+	}
+
+	code, body := readyz()
+	require.Equal(t, http.StatusServiceUnavailable, code)
+	assert.Contains(t, body.Checks["stt"].Error, "HTTP 401")
+	assert.Equal(t, "ok", body.Checks["ai"].Status)
+	assert.Equal(t, "error", body.Clients["stt"].Status)
+	assert.NotNil(t, body.Clients["stt"].ProbedAt)
+	assert.Equal(t, "ok", body.Clients["ai"].Status)
+
+	// El sondeo fallido se recuerda durante CLIENT_PROBE_TTL sin volver a llamar a la API
+	code, _ = readyz()
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+	assert.Equal(t, int32(1), calls.Load())
+
+	// Tras rotar la clave, /admin/clients/reload recrea el cliente y olvida el sondeo
+	keyOK.Store(true)
+	t.Setenv("ADMIN_TOKEN", "secreto")
+	rec := httptest.NewRecorder()
+	AdminReloadClients(rec, httptest.NewRequest(http.MethodPost, "/admin/clients/reload", nil))
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+
+	req := httptest.NewRequest(http.MethodPost, "/admin/clients/reload", nil)
+	req.Header.Set("X-Admin-Token", "secreto")
+	rec = httptest.NewRecorder()
+	AdminReloadClients(rec, req)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	assert.Contains(t, rec.Body.String(), `"stt":{"status":"uninitialized"`)
+
+	code, body = readyz()
+	require.Equal(t, http.StatusOK, code)
+	assert.Equal(t, "ok", body.Clients["stt"].Status)
+	assert.Equal(t, int32(2), calls.Load())
+}
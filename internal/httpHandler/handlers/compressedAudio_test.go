@@ -0,0 +1,71 @@
+package handlers
+
+import (
+	"encoding/binary"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"walkie-backend/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+// buildTestOggOpus arma un Ogg mínimo con OpusHead y una página final con la granule indicada
+func buildTestOggOpus(finalGranule uint64) []byte {
+	page := func(granule uint64, body []byte) []byte {
+		p := make([]byte, 27)
+		copy(p[:4], "OggS")
+		binary.LittleEndian.PutUint64(p[6:14], granule)
+		p[26] = 1
+		p = append(p, byte(len(body)))
+		return append(p, body...)
+	}
+	head := make([]byte, 19)
+	copy(head, "OpusHead")
+	head[8] = 1
+	head[9] = 1
+
+	data := page(0, head)
+	return append(data, page(finalGranule, []byte{0xFC, 0x00})...)
+}
+
+func TestValidateAudioFormat_CompressedContainers(t *testing.T) {
+	ogg := buildTestOggOpus(48000)
+	assert.True(t, validateAudioFormat(ogg, "audio/ogg"))
+	assert.True(t, validateAudioFormat(ogg, "audio/opus"))
+	assert.False(t, validateAudioFormat([]byte("OggS-no-es-opus"), "audio/ogg"))
+
+	webm := []byte{0x1A, 0x45, 0xDF, 0xA3, 0x87, 0x42, 0x82, 0x84, 'w', 'e', 'b', 'm'}
+	assert.True(t, validateAudioFormat(webm, "audio/webm"))
+	assert.False(t, validateAudioFormat(webm, "audio/mpeg"))
+}
+
+func TestEstimateAudioDuration_OggOpus(t *testing.T) {
+	assert.Equal(t, 3*time.Second, estimateAudioDuration(buildTestOggOpus(3*48000)))
+}
+
+func TestAudioPoll_ServesCompressedContentType(t *testing.T) {
+	mockUser := &models.User{Model: gorm.Model{ID: 1}, CurrentChannel: &models.Channel{Code: "general"}}
+	clip := buildTestOggOpus(48000)
+
+	deps := newAudioPollDeps()
+	deps.resolveUser = func(*http.Request) (*models.User, error) { return mockUser, nil }
+	deps.newUserService = func() userService { return &mockUserService{user: mockUser} }
+	served := false
+	deps.dequeueAudio = func(uint) *PendingAudio {
+		if served {
+			return nil
+		}
+		served = true
+		return &PendingAudio{SenderID: 2, Channel: "general", AudioData: clip, Timestamp: time.Now(), Format: queuedFormat(clip)}
+	}
+
+	rec := httptest.NewRecorder()
+	runAudioPoll(rec, httptest.NewRequest(http.MethodGet, "/audio/poll", nil), deps)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "audio/ogg", rec.Header().Get("Content-Type"))
+}
@@ -0,0 +1,205 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	"walkie-backend/internal/config"
+	"walkie-backend/internal/models"
+)
+
+const (
+	defaultConfirmFastPathMax = 1500 * time.Millisecond
+	defaultConfirmationTTL    = 30 * time.Second
+)
+
+// pendingConfirmation es una acción que espera un "sí" o "no" del usuario
+type pendingConfirmation struct {
+	Action string
+	// Channel es el canal propuesto (o ai.PendingAnyChannel) que se pasa al análisis para
+	// entender respuestas como "el dos"
+	Channel   string
+	ExpiresAt time.Time
+	OnConfirm func() (CommandResponse, error)
+	OnCancel  func() (CommandResponse, error)
+}
+
+var confirmations = struct {
+	sync.Mutex
+	byUser map[uint]*pendingConfirmation
+}{
+	byUser: make(map[uint]*pendingConfirmation),
+}
+
+var (
+	yesWords = []string{"si", "sí", "claro", "dale", "confirmo", "confirmado", "correcto", "afirmativo", "ok", "okay", "vale", "de acuerdo", "hazlo"}
+	noWords  = []string{"no", "negativo", "cancela", "cancelar", "cancelado", "mejor no", "para nada"}
+)
+
+// setPendingConfirmation registra una acción pendiente de confirmación; ttl<=0 usa el valor por defecto
+func setPendingConfirmation(userID uint, pc *pendingConfirmation, ttl time.Duration) {
+	if ttl <= 0 {
+		ttl = defaultConfirmationTTL
+	}
+	pc.ExpiresAt = time.Now().Add(ttl)
+
+	confirmations.Lock()
+	confirmations.byUser[userID] = pc
+	confirmations.Unlock()
+}
+
+// peekPendingConfirmation devuelve la confirmación vigente sin consumirla
+func peekPendingConfirmation(userID uint, now time.Time) *pendingConfirmation {
+	confirmations.Lock()
+	defer confirmations.Unlock()
+
+	pc := confirmations.byUser[userID]
+	if pc == nil {
+		return nil
+	}
+	if now.After(pc.ExpiresAt) {
+		delete(confirmations.byUser, userID)
+		return nil
+	}
+	return pc
+}
+
+// takePendingConfirmation consume la confirmación vigente del usuario
+func takePendingConfirmation(userID uint, now time.Time) *pendingConfirmation {
+	pc := peekPendingConfirmation(userID, now)
+	if pc == nil {
+		return nil
+	}
+	confirmations.Lock()
+	if confirmations.byUser[userID] == pc {
+		delete(confirmations.byUser, userID)
+	}
+	confirmations.Unlock()
+	return pc
+}
+
+// pendingChannelFor devuelve el canal pendiente del usuario, o "" si no espera respuesta
+func pendingChannelFor(userID uint) string {
+	if pc := peekPendingConfirmation(userID, time.Now()); pc != nil {
+		return pc.Channel
+	}
+	return ""
+}
+
+// matchConfirmation reconoce respuestas de sí/no con una gramática mínima local
+func matchConfirmation(text string) (confirmed bool, ok bool) {
+	normalized := strings.ToLower(strings.TrimSpace(text))
+	normalized = strings.TrimFunc(normalized, func(r rune) bool {
+		return unicode.IsPunct(r) || unicode.IsSpace(r)
+	})
+	normalized = strings.Join(strings.Fields(normalized), " ")
+	if normalized == "" {
+		return false, false
+	}
+
+	for _, word := range noWords {
+		if normalized == word {
+			return false, true
+		}
+	}
+	for _, word := range yesWords {
+		if normalized == word {
+			return true, true
+		}
+	}
+	return false, false
+}
+
+func confirmFastPathMax() time.Duration {
+	return config.OptionalDurationFromEnv("CONFIRM_FAST_PATH_MAX", defaultConfirmFastPathMax)
+}
+
+// localSTT es un reconocedor local opcional; nil significa que no hay uno instalado
+var localSTT struct {
+	sync.RWMutex
+	client sttClient
+}
+
+// RegisterLocalSTT instala un reconocedor local usado por la vía rápida de confirmaciones
+func RegisterLocalSTT(client sttClient) {
+	localSTT.Lock()
+	localSTT.client = client
+	localSTT.Unlock()
+}
+
+func localSTTClient() sttClient {
+	localSTT.RLock()
+	defer localSTT.RUnlock()
+	return localSTT.client
+}
+
+// confirmationFastPathStage resuelve clips cortos de sí/no cuando hay una acción pendiente
+// sin pasar por el STT remoto ni el LLM. Devuelve true si respondió la petición.
+func confirmationFastPathStage(ctx context.Context, w http.ResponseWriter, deps audioIngestDeps, user *models.User, audio []byte, format string, tracker *stageTimer) bool {
+	if deps.localSTT == nil {
+		return false
+	}
+	max := confirmFastPathMax()
+	if max <= 0 || estimateAudioDuration(audio) > max {
+		return false
+	}
+	if peekPendingConfirmation(user.ID, time.Now()) == nil {
+		return false
+	}
+	recognizer := deps.localSTT()
+	if recognizer == nil {
+		return false
+	}
+
+	stageStart := time.Now()
+	text, err := recognizer.TranscribeAudio(ctx, audio, format)
+	_, matched := matchConfirmation(text)
+	tracker.LogStage("confirm_fast_path", stageStart, map[string]any{
+		"matched": matched,
+	})
+	if err != nil || !matched {
+		return false
+	}
+	return resolveConfirmation(w, user, text, "local", tracker)
+}
+
+// resolveConfirmation ejecuta la acción pendiente si el texto es un sí/no reconocible.
+// También se usa tras el STT remoto para ahorrar la llamada al LLM.
+func resolveConfirmation(w http.ResponseWriter, user *models.User, text, via string, tracker *stageTimer) bool {
+	confirmed, matched := matchConfirmation(text)
+	if !matched {
+		return false
+	}
+	pc := takePendingConfirmation(user.ID, time.Now())
+	if pc == nil {
+		return false
+	}
+
+	handler := pc.OnCancel
+	response := CommandResponse{Status: "ok", Intent: "confirmation", Message: "Cancelado"}
+	if confirmed {
+		handler = pc.OnConfirm
+		response.Message = "Confirmado"
+	}
+	if handler != nil {
+		var err error
+		if response, err = handler(); err != nil {
+			log.Printf("[CONFIRMACION] usuario=%d accion=%s error=%v", user.ID, pc.Action, err)
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			tracker.LogFinal("confirmation_error")
+			return true
+		}
+	}
+
+	log.Printf("[CONFIRMACION] usuario=%d accion=%s confirmado=%t via=%s", user.ID, pc.Action, confirmed, via)
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	_ = json.NewEncoder(w).Encode(response)
+	tracker.LogFinal("confirmation_" + via)
+	return true
+}
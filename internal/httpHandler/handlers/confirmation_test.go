@@ -0,0 +1,133 @@
+package handlers
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"walkie-backend/internal/ai"
+	"walkie-backend/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+func TestMatchConfirmation(t *testing.T) {
+	cases := []struct {
+		text      string
+		confirmed bool
+		ok        bool
+	}{
+		{"Sí.", true, true},
+		{"¡dale!", true, true},
+		{"de acuerdo", true, true},
+		{"No", false, true},
+		{"mejor no", false, true},
+		{"sí pero más tarde", false, false},
+		{"", false, false},
+	}
+	for _, tc := range cases {
+		confirmed, ok := matchConfirmation(tc.text)
+		if confirmed != tc.confirmed || ok != tc.ok {
+			t.Errorf("matchConfirmation(%q) = (%v, %v), want (%v, %v)", tc.text, confirmed, ok, tc.confirmed, tc.ok)
+		}
+	}
+}
+
+func TestPendingConfirmation_Expires(t *testing.T) {
+	setPendingConfirmation(40, &pendingConfirmation{Action: "prueba"}, time.Second)
+	if peekPendingConfirmation(40, time.Now()) == nil {
+		t.Fatal("expected pending confirmation")
+	}
+	if takePendingConfirmation(40, time.Now().Add(2*time.Second)) != nil {
+		t.Fatal("expected expired confirmation to be discarded")
+	}
+}
+
+func TestRunAudioIngest_ConfirmationFastPathSkipsRemoteProviders(t *testing.T) {
+	mockUser := &models.User{Model: gorm.Model{ID: 41}}
+	confirmedAction := false
+	setPendingConfirmation(41, &pendingConfirmation{
+		Action: "desconectar",
+		OnConfirm: func() (CommandResponse, error) {
+			confirmedAction = true
+			return CommandResponse{Status: "ok", Intent: "confirmation", Message: "Hecho"}, nil
+		},
+	}, time.Minute)
+
+	deps := newAudioIngestDeps()
+	deps.readUserID = func(*http.Request) (uint, error) { return 41, nil }
+	deps.newUserService = func() userService { return &mockUserService{user: mockUser} }
+	deps.validateAudio = func([]byte, string) bool { return true }
+	deps.readAudio = func(*http.Request) ([]byte, string, error) { return buildTestWAV(8000), "audio/wav", nil }
+	deps.localSTT = func() sttClient { return &mockSTT{text: "sí"} }
+	deps.ensureSTT = func() (sttClient, error) { return nil, errors.New("no debería llamarse") }
+	deps.ensureAI = func() (ai.Analyzer, error) { return nil, errors.New("no debería llamarse") }
+
+	rec := httptest.NewRecorder()
+	runAudioIngest(rec, httptest.NewRequest(http.MethodPost, "/audio/ingest", bytes.NewReader(nil)), deps)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), "Hecho")
+	assert.True(t, confirmedAction)
+	assert.Nil(t, peekPendingConfirmation(41, time.Now()))
+}
+
+func TestRunAudioIngest_ConfirmationAfterRemoteSTTSkipsAI(t *testing.T) {
+	mockUser := &models.User{Model: gorm.Model{ID: 42}}
+	setPendingConfirmation(42, &pendingConfirmation{Action: "desconectar"}, time.Minute)
+
+	deps := newAudioIngestDeps()
+	deps.readUserID = func(*http.Request) (uint, error) { return 42, nil }
+	deps.newUserService = func() userService { return &mockUserService{user: mockUser} }
+	deps.validateAudio = func([]byte, string) bool { return true }
+	deps.readAudio = func(*http.Request) ([]byte, string, error) { return buildTestWAV(8000), "audio/wav", nil }
+	deps.localSTT = func() sttClient { return nil }
+	deps.ensureSTT = func() (sttClient, error) { return &mockSTT{text: "no"}, nil }
+	deps.ensureAI = func() (ai.Analyzer, error) { return nil, errors.New("no debería llamarse") }
+
+	rec := httptest.NewRecorder()
+	runAudioIngest(rec, httptest.NewRequest(http.MethodPost, "/audio/ingest", bytes.NewReader(nil)), deps)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), "Cancelado")
+}
+
+func TestRunAudioIngest_AsksForChannelAndUsesPendingState(t *testing.T) {
+	mockUser := &models.User{Model: gorm.Model{ID: 43}}
+	svc := &mockUserService{
+		user:     mockUser,
+		channels: []models.Channel{{Code: "canal-1", MaxUsers: 5}, {Code: "canal-2", MaxUsers: 5}},
+	}
+	ingest := func(text string, analyzer *mockQwen) *httptest.ResponseRecorder {
+		deps := newAudioIngestDeps()
+		deps.readUserID = func(*http.Request) (uint, error) { return 43, nil }
+		deps.newUserService = func() userService { return svc }
+		deps.validateAudio = func([]byte, string) bool { return true }
+		deps.readAudio = func(*http.Request) ([]byte, string, error) { return buildTestWAV(8000), "audio/wav", nil }
+		deps.localSTT = func() sttClient { return nil }
+		deps.ensureSTT = func() (sttClient, error) { return &mockSTT{text: text}, nil }
+		deps.ensureAI = func() (ai.Analyzer, error) { return analyzer, nil }
+		rec := httptest.NewRecorder()
+		runAudioIngest(rec, httptest.NewRequest(http.MethodPost, "/audio/ingest", bytes.NewReader(nil)), deps)
+		return rec
+	}
+
+	first := &mockQwen{result: ai.CommandResult{IsCommand: true, Intent: "request_channel_connect"}}
+	rec := ingest("cambia de canal", first)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), "¿A qué canal? Hay sitio en los canales 1 y 2")
+	assert.Equal(t, "", first.pending)
+	assert.Equal(t, ai.PendingAnyChannel, pendingChannelFor(43))
+
+	second := &mockQwen{result: ai.CommandResult{IsCommand: true, Intent: "request_channel_connect", Channels: []string{"canal-2"}}}
+	rec = ingest("el dos", second)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, ai.PendingAnyChannel, second.pending, "the pending state reaches the analyzer")
+	assert.Contains(t, rec.Body.String(), "Conectado al canal 2")
+	assert.Equal(t, []string{"canal-2"}, svc.connected)
+	assert.Equal(t, "", pendingChannelFor(43))
+}
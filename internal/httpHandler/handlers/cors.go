@@ -0,0 +1,86 @@
+package handlers
+
+import (
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+)
+
+const (
+	corsAllowMethods  = "GET, POST, PUT, PATCH, DELETE, OPTIONS"
+	corsAllowHeaders  = "Authorization, Content-Type, X-Auth-Token, X-Admin-Token, X-Admin-Actor, X-Request-ID, X-Audio-Ack, API-Version"
+	corsExposeHeaders = "Retry-After, X-Request-ID, X-Channel, X-Audio-From, X-Audio-Timestamp, X-Audio-Priority, X-Audio-Direct, X-Audio-Age-Seconds, X-Audio-Notice, X-Speaker-Tip, X-Degraded-Mode, X-Delivery-ID, X-Audio-Attempt, X-Transmission-ID, API-Version, API-Supported-Versions, Deprecation, Sunset, Link"
+	corsMaxAge        = "600"
+)
+
+var (
+	corsOriginsOnce sync.Once
+	corsOrigins     []string
+)
+
+// parseOrigins separa una lista de orígenes por comas, ignorando vacíos
+func parseOrigins(raw string) []string {
+	origins := []string{}
+	for _, part := range strings.Split(raw, ",") {
+		if trimmed := strings.TrimSpace(part); trimmed != "" {
+			origins = append(origins, trimmed)
+		}
+	}
+	return origins
+}
+
+// getAllowedOrigins lee ALLOWED_ORIGINS; "*" admite cualquier origen
+func getAllowedOrigins() []string {
+	corsOriginsOnce.Do(func() {
+		corsOrigins = parseOrigins(os.Getenv("ALLOWED_ORIGINS"))
+	})
+	return corsOrigins
+}
+
+func corsOriginAllowed(origin string) bool {
+	for _, allowed := range getAllowedOrigins() {
+		if allowed == "*" || allowed == origin {
+			return true
+		}
+	}
+	return false
+}
+
+// CORS añade las cabeceras CORS para los orígenes de ALLOWED_ORIGINS y responde las
+// peticiones preflight (OPTIONS) sin llegar al handler. Sin la variable no se añade nada
+// y los navegadores sólo aceptan peticiones del mismo origen.
+func CORS(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		origin := strings.TrimSpace(r.Header.Get("Origin"))
+		if origin == "" {
+			next(w, r)
+			return
+		}
+
+		w.Header().Add("Vary", "Origin")
+		allowed := corsOriginAllowed(origin)
+		preflight := r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != ""
+
+		if allowed {
+			h := w.Header()
+			h.Set("Access-Control-Allow-Origin", origin)
+			h.Set("Access-Control-Expose-Headers", corsExposeHeaders)
+			if preflight {
+				h.Set("Access-Control-Allow-Methods", corsAllowMethods)
+				h.Set("Access-Control-Allow-Headers", corsAllowHeaders)
+				h.Set("Access-Control-Max-Age", corsMaxAge)
+			}
+		}
+
+		if preflight {
+			if allowed {
+				w.WriteHeader(http.StatusNoContent)
+			} else {
+				w.WriteHeader(http.StatusForbidden)
+			}
+			return
+		}
+		next(w, r)
+	}
+}
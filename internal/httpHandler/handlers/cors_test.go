@@ -0,0 +1,75 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func setAllowedOrigins(t *testing.T, value string) {
+	t.Helper()
+	t.Setenv("ALLOWED_ORIGINS", value)
+	corsOriginsOnce = sync.Once{}
+	t.Cleanup(func() { corsOriginsOnce = sync.Once{} })
+}
+
+func TestCORS_AllowedOriginGetsHeaders(t *testing.T) {
+	setAllowedOrigins(t, "https://app.walkie.dev, https://admin.walkie.dev")
+
+	called := false
+	h := CORS(func(w http.ResponseWriter, r *http.Request) { called = true })
+
+	req := httptest.NewRequest(http.MethodGet, "/channels/public", nil)
+	req.Header.Set("Origin", "https://admin.walkie.dev")
+	rec := httptest.NewRecorder()
+	h(rec, req)
+
+	assert.True(t, called)
+	assert.Equal(t, "https://admin.walkie.dev", rec.Header().Get("Access-Control-Allow-Origin"))
+	assert.Contains(t, rec.Header().Get("Access-Control-Expose-Headers"), "X-Audio-From")
+	assert.Equal(t, "Origin", rec.Header().Get("Vary"))
+}
+
+func TestCORS_UnknownOriginGetsNoHeaders(t *testing.T) {
+	setAllowedOrigins(t, "https://app.walkie.dev")
+
+	h := CORS(func(w http.ResponseWriter, r *http.Request) {})
+	req := httptest.NewRequest(http.MethodGet, "/channels/public", nil)
+	req.Header.Set("Origin", "https://evil.example")
+	rec := httptest.NewRecorder()
+	h(rec, req)
+
+	assert.Empty(t, rec.Header().Get("Access-Control-Allow-Origin"))
+}
+
+func TestCORS_Preflight(t *testing.T) {
+	setAllowedOrigins(t, "*")
+
+	called := false
+	h := CORS(func(w http.ResponseWriter, r *http.Request) { called = true })
+
+	req := httptest.NewRequest(http.MethodOptions, "/audio/ingest", nil)
+	req.Header.Set("Origin", "https://app.walkie.dev")
+	req.Header.Set("Access-Control-Request-Method", http.MethodPost)
+	rec := httptest.NewRecorder()
+	h(rec, req)
+
+	assert.False(t, called, "preflight must not reach the handler")
+	assert.Equal(t, http.StatusNoContent, rec.Code)
+	assert.Equal(t, "https://app.walkie.dev", rec.Header().Get("Access-Control-Allow-Origin"))
+	assert.Contains(t, rec.Header().Get("Access-Control-Allow-Headers"), "X-Auth-Token")
+	assert.Contains(t, rec.Header().Get("Access-Control-Allow-Methods"), http.MethodPost)
+
+	setAllowedOrigins(t, "")
+	rec = httptest.NewRecorder()
+	h(rec, req)
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+}
+
+func TestParseOrigins(t *testing.T) {
+	assert.Equal(t, []string{"http://a", "http://b"}, parseOrigins(" http://a ,, http://b"))
+	assert.Empty(t, parseOrigins(""))
+}
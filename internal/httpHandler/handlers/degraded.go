@@ -0,0 +1,183 @@
+package handlers
+
+import (
+	"container/list"
+	"errors"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	"walkie-backend/internal/config"
+	"walkie-backend/internal/models"
+)
+
+// cachedSession guarda lo mínimo necesario para seguir retransmitiendo audio sin base de datos
+type cachedSession struct {
+	userID    uint
+	channel   string
+	sessionID uint
+	// expiresAt es el exp del JWT; los tokens opacos caducan a los JWT_ACCESS_TTL de verse
+	expiresAt time.Time
+}
+
+type sessionCacheEntry struct {
+	token   string
+	session cachedSession
+}
+
+// defaultSessionCacheSize limita las sesiones recordadas; al llenarse se descarta la que
+// lleva más tiempo sin usarse (DEGRADED_SESSION_CACHE_SIZE)
+const defaultSessionCacheSize = 10000
+
+var sessionCache = struct {
+	sync.Mutex
+	byToken map[string]*list.Element
+	// order va de la sesión usada más recientemente a la más antigua
+	order *list.List
+}{
+	byToken: make(map[string]*list.Element),
+	order:   list.New(),
+}
+
+func rememberSession(token string, user *models.User) {
+	if token == "" || user == nil {
+		return
+	}
+	sessionCache.Lock()
+	defer sessionCache.Unlock()
+	if el, ok := sessionCache.byToken[token]; ok {
+		el.Value.(*sessionCacheEntry).session.channel = user.GetCurrentChannelCode()
+		sessionCache.order.MoveToFront(el)
+		return
+	}
+
+	session := cachedSession{
+		userID:    user.ID,
+		channel:   user.GetCurrentChannelCode(),
+		expiresAt: time.Now().Add(accessTokenTTL()),
+	}
+	if claims, err := parseAccessToken(token); err == nil {
+		session.expiresAt = time.Unix(claims.ExpiresAt, 0)
+		session.sessionID = claims.SessionID
+	}
+	sessionCache.byToken[token] = sessionCache.order.PushFront(&sessionCacheEntry{token: token, session: session})
+
+	limit := config.IntFromEnv("DEGRADED_SESSION_CACHE_SIZE", defaultSessionCacheSize)
+	for limit > 0 && sessionCache.order.Len() > limit {
+		removeCachedSessionLocked(sessionCache.order.Back())
+	}
+}
+
+func removeCachedSessionLocked(el *list.Element) {
+	delete(sessionCache.byToken, el.Value.(*sessionCacheEntry).token)
+	sessionCache.order.Remove(el)
+}
+
+// forgetCachedSessions descarta las sesiones en memoria que cumplan match
+func forgetCachedSessions(match func(cachedSession) bool) {
+	sessionCache.Lock()
+	defer sessionCache.Unlock()
+	for el := sessionCache.order.Front(); el != nil; {
+		next := el.Next()
+		if match(el.Value.(*sessionCacheEntry).session) {
+			removeCachedSessionLocked(el)
+		}
+		el = next
+	}
+}
+
+// forgetUserSessions descarta las sesiones en memoria del usuario para que el modo
+// degradado no acepte sus tokens tras cerrar sesión
+func forgetUserSessions(userID uint) {
+	forgetCachedSessions(func(s cachedSession) bool { return s.userID == userID })
+}
+
+// forgetDeviceSession descarta los tokens de una sesión de dispositivo; sessionID 0 son
+// los tokens emitidos sin sesión
+func forgetDeviceSession(userID, sessionID uint) {
+	forgetCachedSessions(func(s cachedSession) bool { return s.userID == userID && s.sessionID == sessionID })
+}
+
+// forgetCachedToken descarta un token concreto; los opacos se guardan sin sesión y
+// forgetDeviceSession no los encuentra
+func forgetCachedToken(token string) {
+	sessionCache.Lock()
+	defer sessionCache.Unlock()
+	if el, ok := sessionCache.byToken[token]; ok {
+		removeCachedSessionLocked(el)
+	}
+}
+
+// lookupCachedSession devuelve la sesión del token si no ha caducado
+func lookupCachedSession(token string) (cachedSession, bool) {
+	sessionCache.Lock()
+	defer sessionCache.Unlock()
+	el, ok := sessionCache.byToken[token]
+	if !ok {
+		return cachedSession{}, false
+	}
+	session := el.Value.(*sessionCacheEntry).session
+	if !time.Now().Before(session.expiresAt) {
+		removeCachedSessionLocked(el)
+		return cachedSession{}, false
+	}
+	sessionCache.order.MoveToFront(el)
+	return session, true
+}
+
+// currentWSChannel devuelve el canal registrado del cliente WebSocket del usuario
+func currentWSChannel(userID uint) string {
+	registry.RLock()
+	defer registry.RUnlock()
+	if c, ok := registry.byUser[userID]; ok {
+		return c.channel
+	}
+	return ""
+}
+
+// relayWithoutDB retransmite el audio por WebSocket usando la sesión en memoria.
+// Los comandos de voz y la cola de polling requieren la base de datos y se omiten.
+func relayWithoutDB(w http.ResponseWriter, r *http.Request) {
+	token := requestToken(r)
+	session, ok := lookupCachedSession(token)
+	if !ok {
+		requireDB(w)
+		return
+	}
+
+	channel := currentWSChannel(session.userID)
+	if channel == "" {
+		channel = session.channel
+	}
+	if channel == "" {
+		requireDB(w)
+		return
+	}
+
+	audioData, format, err := readAudioFromRequest(r)
+	if (err == nil || errors.Is(err, errAudioTooLarge)) && !enforceAudioLimits(w, session.userID, int64(len(audioData)), audioData) {
+		return
+	}
+	if err != nil || len(audioData) == 0 || !validateAudioFormat(audioData, format) {
+		http.Error(w, "Audio requerido", http.StatusBadRequest)
+		return
+	}
+
+	log.Printf("[DEGRADADO] usuario=%d canal=%s retransmitiendo audio sin base de datos bytes=%d", session.userID, channel, len(audioData))
+
+	priority := PriorityNormal
+	if emergencyRequested(r) {
+		priority = PriorityEmergency
+	}
+	if holder, ok := startTransmission(channel, session.userID, priority); !ok {
+		writeChannelBusy(w, channel, holder)
+		return
+	}
+	broadcastAudio(channel, session.userID, audioData)
+
+	scheduleStopTransmission(channel, session.userID, estimateAudioDuration(audioData))
+
+	w.Header().Set("X-Degraded-Mode", "true")
+	w.WriteHeader(http.StatusNoContent)
+}
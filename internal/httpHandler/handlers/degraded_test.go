@@ -0,0 +1,154 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/binary"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"walkie-backend/internal/config"
+	"walkie-backend/internal/models"
+)
+
+func TestRequireDB_ReturnsServiceUnavailable(t *testing.T) {
+	config.SetDBAvailable(false)
+	defer config.SetDBAvailable(true)
+
+	rec := httptest.NewRecorder()
+	ListPublicChannels(rec, httptest.NewRequest(http.MethodGet, "/channels/public", nil))
+
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Fatalf("expected 503, got %d", rec.Code)
+	}
+	if rec.Header().Get("Retry-After") == "" {
+		t.Fatal("expected Retry-After header")
+	}
+}
+
+func TestAudioIngest_DegradedWithoutCachedSession(t *testing.T) {
+	config.SetDBAvailable(false)
+	defer config.SetDBAvailable(true)
+
+	req := httptest.NewRequest(http.MethodPost, "/audio/ingest", bytes.NewReader(buildTestWAV(100)))
+	req.Header.Set("Content-Type", "audio/wav")
+	req.Header.Set("X-Auth-Token", "desconocido")
+	rec := httptest.NewRecorder()
+
+	AudioIngest(rec, req)
+
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Fatalf("expected 503, got %d", rec.Code)
+	}
+}
+
+func TestAudioIngest_DegradedRelaysWithCachedSession(t *testing.T) {
+	config.SetDBAvailable(false)
+	defer config.SetDBAvailable(true)
+
+	putCachedSession(t, "tok-degradado", cachedSession{userID: 77, channel: "canal-9", expiresAt: time.Now().Add(time.Minute)})
+
+	req := httptest.NewRequest(http.MethodPost, "/audio/ingest", bytes.NewReader(buildTestWAV(100)))
+	req.Header.Set("Content-Type", "audio/wav")
+	req.Header.Set("X-Auth-Token", "tok-degradado")
+	rec := httptest.NewRecorder()
+
+	AudioIngest(rec, req)
+
+	if rec.Code != http.StatusNoContent {
+		t.Fatalf("expected 204, got %d", rec.Code)
+	}
+	if rec.Header().Get("X-Degraded-Mode") != "true" {
+		t.Fatal("expected degraded header")
+	}
+}
+
+func TestAudioIngest_DegradedRejectsExpiredSession(t *testing.T) {
+	config.SetDBAvailable(false)
+	defer config.SetDBAvailable(true)
+
+	putCachedSession(t, "tok-caducado", cachedSession{userID: 78, channel: "canal-9", expiresAt: time.Now().Add(-time.Second)})
+
+	req := httptest.NewRequest(http.MethodPost, "/audio/ingest", bytes.NewReader(buildTestWAV(100)))
+	req.Header.Set("Content-Type", "audio/wav")
+	req.Header.Set("X-Auth-Token", "tok-caducado")
+	rec := httptest.NewRecorder()
+
+	AudioIngest(rec, req)
+
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Fatalf("expected 503, got %d", rec.Code)
+	}
+	sessionCache.Lock()
+	_, kept := sessionCache.byToken["tok-caducado"]
+	sessionCache.Unlock()
+	if kept {
+		t.Fatal("expected expired session to be evicted")
+	}
+}
+
+func TestRememberSession_EvictsLeastRecentlyUsed(t *testing.T) {
+	t.Setenv("DEGRADED_SESSION_CACHE_SIZE", "2")
+	t.Cleanup(func() {
+		forgetCachedSessions(func(s cachedSession) bool { return s.userID >= 91 && s.userID <= 93 })
+	})
+
+	rememberSession("lru-1", userWithID(91))
+	rememberSession("lru-2", userWithID(92))
+	if _, ok := lookupCachedSession("lru-1"); !ok {
+		t.Fatal("expected lru-1 cached")
+	}
+	rememberSession("lru-3", userWithID(93))
+
+	if _, ok := lookupCachedSession("lru-2"); ok {
+		t.Fatal("expected lru-2, the least recently used, to be evicted")
+	}
+	for _, tok := range []string{"lru-1", "lru-3"} {
+		if _, ok := lookupCachedSession(tok); !ok {
+			t.Fatalf("expected %s cached", tok)
+		}
+	}
+}
+
+func userWithID(id uint) *models.User {
+	user := &models.User{}
+	user.ID = id
+	return user
+}
+
+func putCachedSession(t *testing.T, token string, session cachedSession) {
+	t.Helper()
+	sessionCache.Lock()
+	sessionCache.byToken[token] = sessionCache.order.PushFront(&sessionCacheEntry{token: token, session: session})
+	sessionCache.Unlock()
+	t.Cleanup(func() {
+		forgetCachedSessions(func(s cachedSession) bool { return s.userID == session.userID })
+	})
+}
+
+func buildTestWAV(payload int) []byte {
+	data := make([]byte, 44+payload)
+	copy(data[0:4], "RIFF")
+	binary.LittleEndian.PutUint32(data[4:8], uint32(36+payload))
+	copy(data[8:12], "WAVE")
+	copy(data[12:16], "fmt ")
+	binary.LittleEndian.PutUint32(data[16:20], 16)
+	binary.LittleEndian.PutUint16(data[20:22], 1)
+	binary.LittleEndian.PutUint16(data[22:24], 1)
+	binary.LittleEndian.PutUint32(data[24:28], 16000)
+	binary.LittleEndian.PutUint32(data[28:32], 32000)
+	binary.LittleEndian.PutUint16(data[32:34], 2)
+	binary.LittleEndian.PutUint16(data[34:36], 16)
+	copy(data[36:40], "data")
+	binary.LittleEndian.PutUint32(data[40:44], uint32(payload))
+	// Onda cuadrada audible para que el clip supere el detector de voz
+	for i := 44; i+1 < len(data); i += 2 {
+		sample := int16(1000)
+		if (i/40)%2 == 0 {
+			sample = -1000
+		}
+		binary.LittleEndian.PutUint16(data[i:i+2], uint16(sample))
+	}
+	return data
+}
@@ -0,0 +1,141 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"walkie-backend/internal/config"
+	"walkie-backend/internal/metrics"
+	"walkie-backend/internal/models"
+	"walkie-backend/internal/response"
+	"walkie-backend/internal/services"
+
+	"gorm.io/gorm"
+)
+
+const intentDirectMessage = "request_direct_message"
+
+// deliverDirectAudio encola el clip sólo para el destinatario, sin pasar por el canal
+func deliverDirectAudio(senderID, recipientID uint, audioData []byte, priority string) error {
+	duration := estimateAudioDuration(audioData)
+	if err := EnqueueDirectAudio(senderID, recipientID, audioData, duration.Seconds(), priority); err != nil {
+		return err
+	}
+	metrics.Inc("walkie_direct_clips_total", map[string]string{"priority": priority})
+	return nil
+}
+
+// handleDirectMessageStage entrega por voz el clip a la persona nombrada en el comando
+func handleDirectMessageStage(w http.ResponseWriter, user *models.User, audioData []byte, transcript, recipient string, deps audioIngestDeps, tracker *stageTimer) {
+	stageStart := time.Now()
+	target, err := deps.findRecipient(recipient)
+	if err != nil || target.ID == user.ID {
+		log.Printf("[DIRECTO] usuario=%d destinatario=%q no encontrado: %v", user.ID, recipient, err)
+		response.WriteJSON(w, http.StatusOK, CommandResponse{
+			Status:  "error",
+			Intent:  intentDirectMessage,
+			Message: fmt.Sprintf("No encontré a %s", recipient),
+		})
+		tracker.LogFinal("direct_unknown_recipient")
+		return
+	}
+
+	priority := messagePriority(user.ID, transcript, false)
+	if err := deliverDirectAudio(user.ID, target.ID, audioData, priority); err != nil {
+		log.Printf("[DIRECTO] usuario=%d destinatario=%d error=%v", user.ID, target.ID, err)
+		http.Error(w, "No se pudo enviar el mensaje", http.StatusInternalServerError)
+		tracker.LogFinal("direct_error")
+		return
+	}
+	tracker.LogStage("direct", stageStart, map[string]any{"destinatario": target.ID})
+
+	message := fmt.Sprintf("Mensaje enviado a %s", target.DisplayName)
+	dnd := priority != PriorityEmergency && dndRecipients([]uint{target.ID})[target.ID]
+	if dnd {
+		message += ", que tiene activado no molestar"
+	}
+	response.WriteJSON(w, http.StatusOK, CommandResponse{
+		Status:  "ok",
+		Intent:  intentDirectMessage,
+		Message: message,
+		Data: map[string]any{
+			"recipient_id":   target.ID,
+			"recipient_name": target.DisplayName,
+			"recipient_dnd":  dnd,
+		},
+	})
+	tracker.LogFinal("direct_sent")
+}
+
+// AudioDirect: POST /audio/direct/{userID} envía el audio a un único usuario,
+// esté o no en el mismo canal que el emisor
+func AudioDirect(w http.ResponseWriter, r *http.Request) {
+	if !requireDB(w) {
+		return
+	}
+
+	sender, err := resolveUserFromRequest(r)
+	if err != nil {
+		response.WriteErr(w, http.StatusUnauthorized, "Token inválido o expirado")
+		return
+	}
+
+	recipientID, err := strconv.ParseUint(r.PathValue("userID"), 10, 64)
+	if err != nil || recipientID == 0 {
+		response.WriteErr(w, http.StatusBadRequest, "userID inválido")
+		return
+	}
+	if uint(recipientID) == sender.ID {
+		response.WriteErr(w, http.StatusBadRequest, "No puedes enviarte un mensaje directo")
+		return
+	}
+
+	var recipient models.User
+	if err := config.DB.First(&recipient, recipientID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			response.WriteErr(w, http.StatusNotFound, "Usuario no encontrado")
+			return
+		}
+		response.WriteErr(w, http.StatusInternalServerError, "Error buscando usuario")
+		return
+	}
+
+	audioData, format, err := readAudioFromRequest(r)
+	if (err == nil || errors.Is(err, errAudioTooLarge)) && !enforceAudioLimits(w, sender.ID, int64(len(audioData)), audioData) {
+		return
+	}
+	if err != nil || len(audioData) == 0 || !validateAudioFormat(audioData, format) {
+		response.WriteErr(w, http.StatusBadRequest, "Audio requerido")
+		return
+	}
+
+	priority := PriorityNormal
+	if emergencyRequested(r) {
+		priority = messagePriority(sender.ID, "", true)
+	}
+	if err := deliverDirectAudio(sender.ID, recipient.ID, audioData, priority); err != nil {
+		log.Printf("[DIRECTO] usuario=%d destinatario=%d error=%v", sender.ID, recipient.ID, err)
+		response.WriteErr(w, http.StatusInternalServerError, "No se pudo enviar el mensaje")
+		return
+	}
+
+	log.Printf("[DIRECTO] usuario=%d envía mensaje directo a usuario=%d bytes=%d", sender.ID, recipient.ID, len(audioData))
+	w.Header().Set("X-Audio-Direct", "true")
+	if recipient.DoNotDisturb && priority != PriorityEmergency {
+		setDNDHeader(w, map[uint]bool{recipient.ID: true})
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// findRecipientByName resuelve el nombre dicho por voz a un usuario
+func findRecipientByName(name string) (*models.User, error) {
+	if strings.TrimSpace(name) == "" {
+		return nil, errors.New("destinatario vacío")
+	}
+	return services.NewUserService().FindUserByDisplayName(name)
+}
@@ -0,0 +1,104 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"walkie-backend/internal/ai"
+	"walkie-backend/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+func TestAudioDirect_DeliversOutsideChannel(t *testing.T) {
+	db := setupTestDB(t)
+	sender := &models.User{Model: gorm.Model{ID: 101}, DisplayName: "emisor", IsActive: true, LastActiveAt: time.Now()}
+	recipient := &models.User{Model: gorm.Model{ID: 102}, DisplayName: "juan", IsActive: true, LastActiveAt: time.Now()}
+	require.NoError(t, db.Create(sender).Error)
+	require.NoError(t, db.Create(recipient).Error)
+	defer ClearPendingAudio(recipient.ID)
+
+	req := httptest.NewRequest(http.MethodPost, "/audio/direct/102", strings.NewReader(string(buildTestWAV(3200))))
+	req.Header.Set("Content-Type", "audio/wav")
+	req.SetPathValue("userID", "102")
+	req = req.WithContext(withAuthUser(req.Context(), sender))
+	rec := httptest.NewRecorder()
+	AudioDirect(rec, req)
+	require.Equal(t, http.StatusNoContent, rec.Code, rec.Body.String())
+
+	// El destinatario está en otro canal y aun así recibe el mensaje
+	inOther := &models.User{Model: gorm.Model{ID: 102}, CurrentChannel: &models.Channel{Code: "canal-9"}}
+	deps := newAudioPollDeps()
+	deps.resolveUser = func(*http.Request) (*models.User, error) { return inOther, nil }
+	deps.newUserService = func() userService { return &mockUserService{user: inOther} }
+
+	poll := httptest.NewRecorder()
+	runAudioPoll(poll, httptest.NewRequest(http.MethodGet, "/audio/poll", nil), deps)
+	assert.Equal(t, http.StatusOK, poll.Code)
+	assert.Equal(t, "true", poll.Header().Get("X-Audio-Direct"))
+	assert.Equal(t, "101", poll.Header().Get("X-Audio-From"))
+}
+
+func TestAudioDirect_RejectsSelfAndUnknown(t *testing.T) {
+	db := setupTestDB(t)
+	sender := &models.User{Model: gorm.Model{ID: 103}, DisplayName: "solo", IsActive: true, LastActiveAt: time.Now()}
+	require.NoError(t, db.Create(sender).Error)
+
+	for path, want := range map[string]int{
+		"/audio/direct/103": http.StatusBadRequest,
+		"/audio/direct/999": http.StatusNotFound,
+		"/audio/direct/abc": http.StatusBadRequest,
+	} {
+		req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(string(buildTestWAV(3200))))
+		req.SetPathValue("userID", strings.TrimPrefix(path, "/audio/direct/"))
+		req = req.WithContext(withAuthUser(req.Context(), sender))
+		rec := httptest.NewRecorder()
+		AudioDirect(rec, req)
+		assert.Equal(t, want, rec.Code, path)
+	}
+}
+
+func TestRunAudioIngest_DirectMessageIntent(t *testing.T) {
+	setupTestDB(t)
+	sender := &models.User{Model: gorm.Model{ID: 104}, DisplayName: "emisor"}
+	target := &models.User{Model: gorm.Model{ID: 105}, DisplayName: "Juan"}
+	defer ClearPendingAudio(target.ID)
+
+	deps := newAudioIngestDeps()
+	deps.readUserID = func(*http.Request) (uint, error) { return sender.ID, nil }
+	deps.newUserService = func() userService { return &mockUserService{user: sender} }
+	deps.localSTT = func() sttClient { return nil }
+	deps.ensureSTT = func() (sttClient, error) { return &mockSTT{text: "mándaselo a Juan"}, nil }
+	deps.ensureAI = func() (ai.Analyzer, error) {
+		return &mockQwen{result: ai.CommandResult{IsCommand: true, Intent: intentDirectMessage, Recipient: "juan"}}, nil
+	}
+	deps.findRecipient = func(name string) (*models.User, error) {
+		if strings.EqualFold(name, "juan") {
+			return target, nil
+		}
+		return nil, errors.New("no existe")
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "/audio/ingest", strings.NewReader(string(buildTestWAV(3200))))
+	req.Header.Set("Content-Type", "audio/wav")
+	rec := httptest.NewRecorder()
+	runAudioIngest(rec, req, deps)
+
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	var resp CommandResponse
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+	assert.Equal(t, "ok", resp.Status)
+	assert.Equal(t, "Mensaje enviado a Juan", resp.Message)
+
+	pending := DequeueAudio(target.ID)
+	require.NotNil(t, pending)
+	assert.True(t, pending.Direct)
+	assert.Equal(t, sender.ID, pending.SenderID)
+}
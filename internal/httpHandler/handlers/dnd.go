@@ -0,0 +1,120 @@
+package handlers
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"walkie-backend/internal/config"
+	"walkie-backend/internal/models"
+	"walkie-backend/internal/response"
+	"walkie-backend/internal/services"
+)
+
+const (
+	intentDNDOn  = "request_dnd_on"
+	intentDNDOff = "request_dnd_off"
+
+	// DNDRecipientsHeader indica al emisor qué destinatarios tienen activado no molestar
+	DNDRecipientsHeader = "X-Recipients-DND"
+)
+
+// dndRecipients devuelve cuáles de ids tienen activado no molestar; sin base de datos
+// nadie lo tiene
+func dndRecipients(ids []uint) map[uint]bool {
+	if len(ids) == 0 || config.DB == nil || !config.DBAvailable() {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), config.QueryTimeout())
+	defer cancel()
+	dnd, err := services.DoNotDisturbAmong(config.DB.WithContext(ctx), ids)
+	if err != nil {
+		log.Printf("[NO MOLESTAR] error consultando destinatarios: %v", err)
+		return nil
+	}
+	return idSet(dnd)
+}
+
+// dndInChannel devuelve los usuarios del canal con no molestar activado
+func dndInChannel(channel string) map[uint]bool {
+	if channel == "" || config.DB == nil || !config.DBAvailable() {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), config.QueryTimeout())
+	defer cancel()
+	dnd, err := services.DoNotDisturbInChannel(config.DB.WithContext(ctx), channel)
+	if err != nil {
+		log.Printf("[NO MOLESTAR] canal=%s error=%v", channel, err)
+		return nil
+	}
+	return idSet(dnd)
+}
+
+func idSet(ids []uint) map[uint]bool {
+	if len(ids) == 0 {
+		return nil
+	}
+	set := make(map[uint]bool, len(ids))
+	for _, id := range ids {
+		set[id] = true
+	}
+	return set
+}
+
+// setDNDHeader avisa al emisor de los destinatarios que no recibirán aviso del clip
+func setDNDHeader(w http.ResponseWriter, dnd map[uint]bool) {
+	if len(dnd) == 0 {
+		return
+	}
+	ids := make([]uint, 0, len(dnd))
+	for id := range dnd {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	parts := make([]string, 0, len(ids))
+	for _, id := range ids {
+		parts = append(parts, strconv.FormatUint(uint64(id), 10))
+	}
+	w.Header().Set(DNDRecipientsHeader, strings.Join(parts, ","))
+}
+
+// handleDNDStage activa o quita el modo no molestar por voz ("no me molestes")
+func handleDNDStage(w http.ResponseWriter, user *models.User, intent string, tracker *stageTimer) {
+	on := intent == intentDNDOn
+	if config.DB == nil || !config.DBAvailable() {
+		response.WriteJSON(w, http.StatusOK, CommandResponse{
+			Status:  "error",
+			Intent:  intent,
+			Message: "No puedo cambiar el modo no molestar ahora",
+		})
+		tracker.LogFinal("dnd_no_db")
+		return
+	}
+	if err := services.NewUserService().UpdateProfile(user.ID, services.ProfileUpdate{DoNotDisturb: &on}); err != nil {
+		log.Printf("[NO MOLESTAR] usuario=%d error=%v", user.ID, err)
+		response.WriteJSON(w, http.StatusOK, CommandResponse{
+			Status:  "error",
+			Intent:  intent,
+			Message: "No pude cambiar el modo no molestar",
+		})
+		tracker.LogFinal("dnd_error")
+		return
+	}
+	user.DoNotDisturb = on
+	log.Printf("[NO MOLESTAR] usuario=%d activado=%t", user.ID, on)
+
+	message := "Modo no molestar desactivado"
+	if on {
+		message = "Modo no molestar activado. Guardaré tus mensajes hasta que lo quites"
+	}
+	response.WriteJSON(w, http.StatusOK, CommandResponse{
+		Status:  "ok",
+		Intent:  intent,
+		Message: message,
+		Data:    map[string]any{"doNotDisturb": on},
+	})
+	tracker.LogFinal("dnd_updated")
+}
@@ -0,0 +1,84 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"walkie-backend/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDoNotDisturb_QueuesWithoutLiveAudio(t *testing.T) {
+	db := setupTestDB(t)
+	quiet := createTestUser(t, db, 677, "tok-dnd-677", "canal-dnd")
+	other := &models.User{DisplayName: "Rita", IsActive: true, CurrentChannelID: quiet.CurrentChannelID}
+	require.NoError(t, db.Create(other).Error)
+	t.Cleanup(func() {
+		ClearPendingAudio(quiet.ID)
+		ClearPendingAudio(other.ID)
+	})
+
+	// "no me molestes" por voz
+	rec := httptest.NewRecorder()
+	handleDNDStage(rec, quiet, intentDNDOn, newStageTimer(quiet.ID))
+	var resp CommandResponse
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+	assert.Equal(t, "ok", resp.Status)
+	var stored models.User
+	require.NoError(t, db.First(&stored, quiet.ID).Error)
+	assert.True(t, stored.DoNotDisturb)
+
+	quietWS := &wsClient{userID: quiet.ID, channel: "canal-dnd", send: make(chan []byte, 4)}
+	otherWS := &wsClient{userID: other.ID, channel: "canal-dnd", send: make(chan []byte, 4)}
+	registerClient(quietWS)
+	registerClient(otherWS)
+	defer removeClient(quietWS)
+	defer removeClient(otherWS)
+
+	broadcastAudio("canal-dnd", 9999, []byte("hola"))
+	assert.Len(t, quietWS.send, 0, "DND user must not get live audio")
+	assert.Len(t, otherWS.send, 1)
+
+	EnqueueAudio(9999, "canal-dnd", []byte("hola"), 1, []uint{quiet.ID})
+	assert.NotNil(t, DequeueAudio(quiet.ID), "audio is still queued for DND users")
+
+	w := httptest.NewRecorder()
+	setDNDHeader(w, dndRecipients([]uint{quiet.ID, other.ID}))
+	assert.Equal(t, "677", w.Header().Get(DNDRecipientsHeader))
+
+	// Las emergencias sí llegan en directo
+	_, ok := startTransmission("canal-dnd", 9999, PriorityEmergency)
+	require.True(t, ok)
+	defer stopTransmission("canal-dnd", 9999)
+	for len(quietWS.send) > 0 {
+		<-quietWS.send
+	}
+	broadcastAudio("canal-dnd", 9999, []byte("socorro"))
+	require.Len(t, quietWS.send, 1)
+	assert.Equal(t, "socorro", string(<-quietWS.send))
+
+	rec = httptest.NewRecorder()
+	handleDNDStage(rec, quiet, intentDNDOff, newStageTimer(quiet.ID))
+	require.NoError(t, db.First(&stored, quiet.ID).Error)
+	assert.False(t, stored.DoNotDisturb)
+	assert.Empty(t, dndRecipients([]uint{quiet.ID}))
+}
+
+func TestMe_PatchDoNotDisturb(t *testing.T) {
+	db := setupTestDB(t)
+	user := createTestUser(t, db, 678, "tok-dnd-678", "")
+
+	req := httptest.NewRequest(http.MethodPatch, "/me", strings.NewReader(`{"doNotDisturb":true}`))
+	req = req.WithContext(withAuthUser(req.Context(), user))
+	rec := httptest.NewRecorder()
+	Me(rec, req)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	var profile meProfile
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &profile))
+	assert.True(t, profile.DoNotDisturb)
+}
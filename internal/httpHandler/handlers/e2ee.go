@@ -0,0 +1,351 @@
+package handlers
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ecdh"
+	"crypto/hkdf"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"walkie-backend/internal/config"
+	"walkie-backend/internal/metrics"
+	"walkie-backend/internal/models"
+	"walkie-backend/internal/response"
+)
+
+const (
+	// e2eeAlgorithm describe cómo se envuelve la clave de canal para cada miembro
+	e2eeAlgorithm = "x25519-hkdf-sha256-aes256gcm"
+	// e2eeMagic abre el sobre de un clip cifrado: magic | época (uint64 BE) | nonce | cifrado
+	e2eeMagic      = "WTE1"
+	e2eeNonceSize  = 12
+	e2eeHeaderSize = len(e2eeMagic) + 8 + e2eeNonceSize
+	channelKeySize = 32
+	// formatEncrypted marca en la cola los clips cifrados, que se sirven tal cual
+	formatEncrypted = "e2ee"
+)
+
+// channelKeys guarda sólo la época de la clave vigente de cada canal; la clave en claro
+// se descarta en cuanto se envuelve para los miembros
+var channelKeys = struct {
+	sync.Mutex
+	epochs map[string]uint64
+}{
+	epochs: make(map[string]uint64),
+}
+
+// lookupPublicKeys devuelve las claves públicas registradas de los usuarios indicados
+var lookupPublicKeys = func(userIDs []uint) map[uint]*ecdh.PublicKey {
+	if config.DB == nil || !config.DBAvailable() || len(userIDs) == 0 {
+		return nil
+	}
+	var users []models.User
+	if err := config.DB.Select("id", "public_key").Where("id IN ? AND public_key <> ''", userIDs).Find(&users).Error; err != nil {
+		log.Printf("[E2EE] error leyendo claves públicas: %v", err)
+		return nil
+	}
+	keys := make(map[uint]*ecdh.PublicKey, len(users))
+	for _, u := range users {
+		if pub, err := parsePublicKey(u.PublicKey); err == nil {
+			keys[u.ID] = pub
+		}
+	}
+	return keys
+}
+
+func e2eeEnabled() bool {
+	return strings.EqualFold(strings.TrimSpace(os.Getenv("E2EE_ENABLED")), "true")
+}
+
+func parsePublicKey(encoded string) (*ecdh.PublicKey, error) {
+	raw, err := base64.StdEncoding.DecodeString(strings.TrimSpace(encoded))
+	if err != nil {
+		return nil, err
+	}
+	return ecdh.X25519().NewPublicKey(raw)
+}
+
+// channelKeyMessage es el mensaje "channel_key" que recibe cada miembro por WebSocket
+type channelKeyMessage struct {
+	Type         string `json:"type"`
+	Channel      string `json:"channel"`
+	Epoch        uint64 `json:"epoch"`
+	Algorithm    string `json:"algorithm"`
+	EphemeralKey string `json:"ephemeralKey"`
+	Nonce        string `json:"nonce"`
+	WrappedKey   string `json:"wrappedKey"`
+}
+
+// channelKeyInfo liga la clave derivada al canal y la época
+func channelKeyInfo(channel string, epoch uint64) string {
+	return fmt.Sprintf("walkie-e2ee|%s|%d", channel, epoch)
+}
+
+// wrapChannelKey cifra la clave de canal para un miembro: ECDH con una clave efímera,
+// HKDF-SHA256 y AES-256-GCM con el canal y la época como datos adicionales
+func wrapChannelKey(pub *ecdh.PublicKey, key []byte, channel string, epoch uint64) (channelKeyMessage, error) {
+	ephemeral, err := ecdh.X25519().GenerateKey(rand.Reader)
+	if err != nil {
+		return channelKeyMessage{}, err
+	}
+	shared, err := ephemeral.ECDH(pub)
+	if err != nil {
+		return channelKeyMessage{}, err
+	}
+	info := channelKeyInfo(channel, epoch)
+	kek, err := hkdf.Key(sha256.New, shared, nil, info, channelKeySize)
+	if err != nil {
+		return channelKeyMessage{}, err
+	}
+	block, err := aes.NewCipher(kek)
+	if err != nil {
+		return channelKeyMessage{}, err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return channelKeyMessage{}, err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return channelKeyMessage{}, err
+	}
+
+	return channelKeyMessage{
+		Type:         "channel_key",
+		Channel:      channel,
+		Epoch:        epoch,
+		Algorithm:    e2eeAlgorithm,
+		EphemeralKey: base64.StdEncoding.EncodeToString(ephemeral.PublicKey().Bytes()),
+		Nonce:        base64.StdEncoding.EncodeToString(nonce),
+		WrappedKey:   base64.StdEncoding.EncodeToString(gcm.Seal(nil, nonce, key, []byte(info))),
+	}, nil
+}
+
+// rotateChannelKey genera una clave nueva para el canal y la envía envuelta a cada miembro
+// conectado por WebSocket que registró su clave pública. Devuelve la nueva época.
+func rotateChannelKey(channel string) uint64 {
+	if channel == "" {
+		return 0
+	}
+
+	channelKeys.Lock()
+	defer channelKeys.Unlock()
+
+	registry.RLock()
+	members := make(map[uint]*wsClient, len(registry.byChannel[channel]))
+	ids := make([]uint, 0, len(registry.byChannel[channel]))
+	for id, c := range registry.byChannel[channel] {
+		members[id] = c
+		ids = append(ids, id)
+	}
+	registry.RUnlock()
+
+	key := make([]byte, channelKeySize)
+	if _, err := io.ReadFull(rand.Reader, key); err != nil {
+		log.Printf("[E2EE] canal=%s no se pudo generar la clave: %v", channel, err)
+		return channelKeys.epochs[channel]
+	}
+	channelKeys.epochs[channel]++
+	epoch := channelKeys.epochs[channel]
+
+	sent := 0
+	for id, pub := range lookupPublicKeys(ids) {
+		c := members[id]
+		if c == nil {
+			continue
+		}
+		msg, err := wrapChannelKey(pub, key, channel, epoch)
+		if err != nil {
+			log.Printf("[E2EE] canal=%s usuario=%d error envolviendo la clave: %v", channel, id, err)
+			continue
+		}
+		payload, _ := json.Marshal(msg)
+		deliverControl(c, payload)
+		sent++
+	}
+	clear(key)
+
+	metrics.Inc("walkie_e2ee_rotations_total", nil)
+	log.Printf("[E2EE] canal=%s época=%d clave enviada a %d de %d miembros", channel, epoch, sent, len(ids))
+	return epoch
+}
+
+// rotateOnMembershipChange rota la clave del canal cuando alguien entra o sale
+func rotateOnMembershipChange(ev presenceEvent) {
+	if !e2eeEnabled() || ev.Channel == "" {
+		return
+	}
+	if ev.Event == presenceJoined || ev.Event == presenceLeft {
+		go rotateChannelKey(ev.Channel)
+	}
+}
+
+func currentKeyEpoch(channel string) uint64 {
+	channelKeys.Lock()
+	defer channelKeys.Unlock()
+	return channelKeys.epochs[channel]
+}
+
+// encryptedEpoch lee la época del sobre de un clip cifrado
+func encryptedEpoch(data []byte) (uint64, bool) {
+	if len(data) <= e2eeHeaderSize || string(data[:len(e2eeMagic)]) != e2eeMagic {
+		return 0, false
+	}
+	return binary.BigEndian.Uint64(data[len(e2eeMagic):]), true
+}
+
+func isEncryptedAudio(data []byte) bool {
+	_, ok := encryptedEpoch(data)
+	return ok
+}
+
+// POST /audio/encrypted
+// Reenvía al canal un clip cifrado por el cliente; el servidor no puede leerlo, así que
+// no pasa por STT ni por los comandos de voz
+func AudioEncrypted(w http.ResponseWriter, r *http.Request) {
+	if !requireDB(w) {
+		return
+	}
+	user, err := resolveUserFromRequest(r)
+	if err != nil {
+		response.WriteErr(w, http.StatusUnauthorized, "Token inválido o expirado")
+		return
+	}
+	runAudioEncrypted(w, r, user, newDefaultUserService())
+}
+
+func runAudioEncrypted(w http.ResponseWriter, r *http.Request, user *models.User, svc userService) {
+	if !e2eeEnabled() {
+		response.WriteErr(w, http.StatusNotFound, "Cifrado de extremo a extremo deshabilitado")
+		return
+	}
+	if !user.IsInChannel() {
+		response.WriteErr(w, http.StatusConflict, "No estás conectado a ningún canal")
+		return
+	}
+
+	data, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxAudioSize))
+	if err != nil {
+		response.WriteErr(w, http.StatusRequestEntityTooLarge, "Clip demasiado grande")
+		return
+	}
+	epoch, ok := encryptedEpoch(data)
+	if !ok {
+		response.WriteErr(w, http.StatusBadRequest, "Sobre cifrado inválido")
+		return
+	}
+
+	channel := user.GetCurrentChannelCode()
+	// Se acepta la época anterior para no perder clips enviados durante una rotación
+	current := currentKeyEpoch(channel)
+	if current == 0 || epoch > current || epoch+1 < current {
+		response.WriteJSON(w, http.StatusConflict, CommandResponse{
+			Status:  "error",
+			Message: "Clave de canal caducada",
+			Data:    map[string]any{"channel": channel, "epoch": current},
+		})
+		return
+	}
+
+	priority := PriorityNormal
+	if emergencyRequested(r) {
+		priority = messagePriority(user.ID, "", true)
+	}
+	if holder, ok := startTransmission(channel, user.ID, priority); !ok {
+		writeChannelBusy(w, channel, holder)
+		return
+	}
+	broadcastAudio(channel, user.ID, data)
+
+	duration := encryptedDuration(r, data)
+	scheduleStopTransmission(channel, user.ID, duration)
+
+	members, err := svc.GetChannelActiveUsers(channel)
+	if err != nil {
+		log.Printf("[E2EE] canal=%s error obteniendo miembros: %v", channel, err)
+	}
+	recipients := make([]uint, 0, len(members))
+	for _, u := range members {
+		recipients = append(recipients, u.ID)
+	}
+	EnqueueAudioWithPriority(user.ID, channel, data, duration.Seconds(), recipients, priority)
+
+	log.Printf("[E2EE] usuario=%d canal=%s época=%d bytes=%d", user.ID, channel, epoch, len(data))
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// encryptedDuration usa X-Audio-Duration (segundos) porque el servidor no puede leer el
+// audio; sin cabecera lo estima por el tamaño
+func encryptedDuration(r *http.Request, data []byte) time.Duration {
+	if secs, err := strconv.ParseFloat(r.Header.Get("X-Audio-Duration"), 64); err == nil && secs > 0 && secs <= floorMaxHold.Seconds() {
+		return time.Duration(secs * float64(time.Second))
+	}
+	return estimateAudioDuration(data)
+}
+
+type publicKeyRequest struct {
+	PublicKey string `json:"publicKey"`
+}
+
+// PUT|DELETE /e2ee/key
+// Registra o borra la clave pública X25519 con la que se envían las claves de canal
+func PublicKey(w http.ResponseWriter, r *http.Request) {
+	if !requireDB(w) {
+		return
+	}
+	user, err := resolveUserFromRequest(r)
+	if err != nil {
+		response.WriteErr(w, http.StatusUnauthorized, "Token inválido o expirado")
+		return
+	}
+
+	if r.Method == http.MethodDelete {
+		if err := config.DB.Model(&models.User{}).Where("id = ?", user.ID).Update("public_key", "").Error; err != nil {
+			response.WriteErr(w, http.StatusInternalServerError, "No se pudo borrar la clave")
+			return
+		}
+		log.Printf("[E2EE] usuario=%d borra su clave pública", user.ID)
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	var req publicKeyRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 4096)).Decode(&req); err != nil {
+		response.WriteErr(w, http.StatusBadRequest, "JSON inválido")
+		return
+	}
+	pub, err := parsePublicKey(req.PublicKey)
+	if err != nil {
+		response.WriteErr(w, http.StatusBadRequest, "publicKey debe ser una clave X25519 en base64")
+		return
+	}
+	encoded := base64.StdEncoding.EncodeToString(pub.Bytes())
+	if err := config.DB.Model(&models.User{}).Where("id = ?", user.ID).Update("public_key", encoded).Error; err != nil {
+		log.Printf("[E2EE] usuario=%d error guardando clave pública: %v", user.ID, err)
+		response.WriteErr(w, http.StatusInternalServerError, "No se pudo guardar la clave")
+		return
+	}
+	log.Printf("[E2EE] usuario=%d registra clave pública", user.ID)
+
+	// Si ya está en un canal, recibe enseguida una clave con la que leerlo
+	if e2eeEnabled() && user.IsInChannel() {
+		go rotateChannelKey(user.GetCurrentChannelCode())
+	}
+	response.WriteJSON(w, http.StatusOK, map[string]any{
+		"algorithm": e2eeAlgorithm,
+		"status":    "registered",
+	})
+}
@@ -0,0 +1,191 @@
+package handlers
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ecdh"
+	"crypto/hkdf"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+
+	"walkie-backend/internal/models"
+)
+
+// unwrapChannelKey hace lo que haría el cliente con el mensaje channel_key
+func unwrapChannelKey(t *testing.T, priv *ecdh.PrivateKey, msg channelKeyMessage) []byte {
+	t.Helper()
+	decode := func(s string) []byte {
+		b, err := base64.StdEncoding.DecodeString(s)
+		require.NoError(t, err)
+		return b
+	}
+	ephemeral, err := ecdh.X25519().NewPublicKey(decode(msg.EphemeralKey))
+	require.NoError(t, err)
+	shared, err := priv.ECDH(ephemeral)
+	require.NoError(t, err)
+	info := channelKeyInfo(msg.Channel, msg.Epoch)
+	kek, err := hkdf.Key(sha256.New, shared, nil, info, channelKeySize)
+	require.NoError(t, err)
+	block, err := aes.NewCipher(kek)
+	require.NoError(t, err)
+	gcm, err := cipher.NewGCM(block)
+	require.NoError(t, err)
+	key, err := gcm.Open(nil, decode(msg.Nonce), decode(msg.WrappedKey), []byte(info))
+	require.NoError(t, err)
+	return key
+}
+
+func stubPublicKeys(t *testing.T, keys map[uint]*ecdh.PublicKey) {
+	t.Helper()
+	old := lookupPublicKeys
+	lookupPublicKeys = func([]uint) map[uint]*ecdh.PublicKey { return keys }
+	t.Cleanup(func() { lookupPublicKeys = old })
+}
+
+func encryptedClip(epoch uint64) []byte {
+	data := []byte(e2eeMagic)
+	data = binary.BigEndian.AppendUint64(data, epoch)
+	data = append(data, make([]byte, e2eeNonceSize)...)
+	return append(data, "cifrado opaco"...)
+}
+
+func TestRotateChannelKey_WrapsKeyForMembersWithPublicKey(t *testing.T) {
+	const channel = "e2ee-1"
+	priv, err := ecdh.X25519().GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	stubPublicKeys(t, map[uint]*ecdh.PublicKey{650: priv.PublicKey()})
+
+	withKey := &wsClient{userID: 650, channel: channel, send: make(chan []byte, 4)}
+	withoutKey := &wsClient{userID: 651, channel: channel, send: make(chan []byte, 4)}
+	registerClient(withKey)
+	registerClient(withoutKey)
+	defer removeClient(withKey)
+	defer removeClient(withoutKey)
+
+	first := rotateChannelKey(channel)
+	second := rotateChannelKey(channel)
+	assert.Equal(t, first+1, second)
+	assert.Equal(t, second, currentKeyEpoch(channel))
+	assert.Empty(t, withoutKey.send, "sin clave pública no recibe nada")
+
+	var keys [][]byte
+	for len(withKey.send) > 0 {
+		var msg channelKeyMessage
+		require.NoError(t, json.Unmarshal(<-withKey.send, &msg))
+		assert.Equal(t, "channel_key", msg.Type)
+		assert.Equal(t, e2eeAlgorithm, msg.Algorithm)
+		keys = append(keys, unwrapChannelKey(t, priv, msg))
+	}
+	require.Len(t, keys, 2)
+	assert.Len(t, keys[0], channelKeySize)
+	assert.NotEqual(t, keys[0], keys[1], "cada rotación genera una clave nueva")
+}
+
+func TestPresence_RotatesKeyOnMembershipChange(t *testing.T) {
+	t.Setenv("E2EE_ENABLED", "true")
+	const channel = "e2ee-2"
+	stubPublicKeys(t, nil)
+	before := currentKeyEpoch(channel)
+
+	presence.Connect(652, "e2ee", channel)
+	require.Eventually(t, func() bool { return currentKeyEpoch(channel) > before }, time.Second, 10*time.Millisecond)
+
+	joined := currentKeyEpoch(channel)
+	presence.Disconnect(652)
+	require.Eventually(t, func() bool { return currentKeyEpoch(channel) > joined }, time.Second, 10*time.Millisecond, "al salir también se rota")
+}
+
+func TestRunAudioEncrypted_RelaysCiphertext(t *testing.T) {
+	t.Setenv("E2EE_ENABLED", "true")
+	const channel = "e2ee-3"
+	stubPublicKeys(t, nil)
+	channelKeys.Lock()
+	delete(channelKeys.epochs, channel)
+	channelKeys.Unlock()
+	sender := liveTestUser(653, channel)
+	recipient := &models.User{Model: gorm.Model{ID: 654}}
+	defer ClearPendingAudio(recipient.ID)
+	listener := &wsClient{userID: 655, channel: channel, send: make(chan []byte, 8)}
+	registerClient(listener)
+	defer removeClient(listener)
+	svc := &mockUserService{members: map[string][]models.User{channel: {*sender, *recipient}}}
+
+	send := func(body []byte) int {
+		req := httptest.NewRequest(http.MethodPost, "/audio/encrypted", bytes.NewReader(body))
+		req.Header.Set("X-Audio-Duration", "0.01")
+		rec := httptest.NewRecorder()
+		runAudioEncrypted(rec, req, sender, svc)
+		return rec.Code
+	}
+
+	assert.Equal(t, http.StatusConflict, send(encryptedClip(1)), "sin clave distribuida")
+	epoch := rotateChannelKey(channel)
+	assert.Equal(t, http.StatusBadRequest, send(buildTestWAV(320)), "audio en claro")
+	assert.Equal(t, http.StatusConflict, send(encryptedClip(epoch+1)))
+
+	clip := encryptedClip(epoch)
+	require.Equal(t, http.StatusNoContent, send(clip))
+	var relayed [][]byte
+	for len(listener.send) > 0 {
+		relayed = append(relayed, <-listener.send)
+	}
+	assert.Contains(t, relayed, clip, "el oyente recibe el cifrado tal cual")
+
+	user := recipient
+	deps := newAudioPollDeps()
+	deps.resolveUser = func(*http.Request) (*models.User, error) { return user, nil }
+	deps.newUserService = func() userService { return &mockUserService{user: liveTestUser(654, channel)} }
+	rec := httptest.NewRecorder()
+	runAudioPoll(rec, httptest.NewRequest(http.MethodGet, "/audio/poll", nil), deps)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	assert.Equal(t, "application/octet-stream", rec.Header().Get("Content-Type"))
+	assert.Equal(t, "true", rec.Header().Get("X-Audio-Encrypted"))
+	assert.Equal(t, clip, rec.Body.Bytes())
+}
+
+func TestRunAudioEncrypted_Disabled(t *testing.T) {
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/audio/encrypted", bytes.NewReader(encryptedClip(1)))
+	runAudioEncrypted(rec, req, liveTestUser(656, "e2ee-4"), &mockUserService{})
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+}
+
+func TestPublicKey_RegisterAndDelete(t *testing.T) {
+	db := setupTestDB(t)
+	user := createTestUser(t, db, 657, "token-e2ee", "")
+	priv, err := ecdh.X25519().GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	encoded := base64.StdEncoding.EncodeToString(priv.PublicKey().Bytes())
+
+	call := func(method, body string) int {
+		req := httptest.NewRequest(method, "/e2ee/key", strings.NewReader(body))
+		req = req.WithContext(withAuthUser(req.Context(), user))
+		rec := httptest.NewRecorder()
+		PublicKey(rec, req)
+		return rec.Code
+	}
+	stored := func() string {
+		var u models.User
+		require.NoError(t, db.First(&u, user.ID).Error)
+		return u.PublicKey
+	}
+
+	assert.Equal(t, http.StatusBadRequest, call(http.MethodPut, `{"publicKey":"corta"}`))
+	assert.Equal(t, http.StatusOK, call(http.MethodPut, `{"publicKey":"`+encoded+`"}`))
+	assert.Equal(t, encoded, stored())
+	assert.Equal(t, http.StatusNoContent, call(http.MethodDelete, ""))
+	assert.Empty(t, stored())
+}
@@ -0,0 +1,56 @@
+package handlers
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"time"
+
+	"walkie-backend/internal/config"
+	"walkie-backend/internal/models"
+)
+
+const defaultEchoDelay = 2 * time.Second
+
+func echoDelay() time.Duration {
+	return config.OptionalDurationFromEnv("ECHO_DELAY", defaultEchoDelay)
+}
+
+// handleEchoClip devuelve el clip al mismo usuario tras una pausa corta y responde
+// con la transcripción, para verificar micrófono, red y reproducción sin otra persona
+func handleEchoClip(w http.ResponseWriter, user *models.User, audioData []byte, transcript string) {
+	channelCode := user.GetCurrentChannelCode()
+	delay := echoDelay()
+	duration := estimateAudioDuration(audioData)
+
+	log.Printf("[ECO] usuario=%d canal=%s bytes=%d retardo=%s", user.ID, channelCode, len(audioData), delay)
+
+	time.AfterFunc(delay, func() {
+		enqueueForUser(user.ID, user.ID, channelCode, audioData, duration.Seconds())
+		sendAudioToUser(user.ID, audioData)
+	})
+
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	_ = json.NewEncoder(w).Encode(CommandResponse{
+		Status:  "ok",
+		Intent:  "echo",
+		Message: transcript,
+		Data: map[string]any{
+			"channel":  channelCode,
+			"delay_ms": delay.Milliseconds(),
+		},
+	})
+}
+
+// sendAudioToUser envía audio binario sólo al WebSocket del usuario indicado
+func sendAudioToUser(userID uint, audio []byte) {
+	registry.RLock()
+	c, ok := registry.byUser[userID]
+	registry.RUnlock()
+	if !ok || c == nil {
+		return
+	}
+
+	recordResumeFrame(userID, audio)
+	c.enqueue(audio)
+}
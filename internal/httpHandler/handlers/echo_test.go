@@ -0,0 +1,60 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"walkie-backend/internal/models"
+)
+
+func TestHandleAsConversation_EchoChannel(t *testing.T) {
+	t.Setenv("ECHO_DELAY", "0s")
+	ClearPendingAudio(42)
+	defer ClearPendingAudio(42)
+
+	user := &models.User{
+		CurrentChannelID: new(uint),
+		CurrentChannel:   &models.Channel{Code: "eco", Kind: models.ChannelKindEcho},
+	}
+	user.ID = 42
+
+	rec := httptest.NewRecorder()
+	handleAsConversation(rec, user, buildTestWAV(3200), "probando uno dos", false)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", rec.Code)
+	}
+	var resp CommandResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if resp.Intent != "echo" || resp.Message != "probando uno dos" {
+		t.Fatalf("unexpected response %+v", resp)
+	}
+
+	deadline := time.Now().Add(time.Second)
+	for time.Now().Before(deadline) {
+		if pending := DequeueAudio(42); pending != nil {
+			if pending.SenderID != 42 || pending.Channel != "eco" {
+				t.Fatalf("unexpected echoed audio %+v", pending)
+			}
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatal("expected clip to be echoed back to the sender")
+}
+
+func TestEchoDelay_FromEnv(t *testing.T) {
+	t.Setenv("ECHO_DELAY", "500ms")
+	if got := echoDelay(); got != 500*time.Millisecond {
+		t.Fatalf("expected 500ms, got %s", got)
+	}
+	t.Setenv("ECHO_DELAY", "rápido")
+	if got := echoDelay(); got != defaultEchoDelay {
+		t.Fatalf("expected default, got %s", got)
+	}
+}
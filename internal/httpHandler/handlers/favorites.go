@@ -0,0 +1,107 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+
+	"walkie-backend/internal/config"
+	"walkie-backend/internal/models"
+	"walkie-backend/internal/response"
+	"walkie-backend/internal/services"
+)
+
+const intentFavoriteChannel = "request_favorite_channel"
+
+// loadFavoriteChannels lee los canales favoritos del usuario; sin base de datos no hay
+func loadFavoriteChannels(userID uint) []string {
+	if config.DB == nil || !config.DBAvailable() {
+		return nil
+	}
+	favorites, err := services.FavoriteChannels(config.DB, userID)
+	if err != nil {
+		log.Printf("[FAVORITOS] usuario=%d error=%v", userID, err)
+		return nil
+	}
+	return favorites
+}
+
+// GET /me/favorites devuelve los canales favoritos del usuario
+func MeFavorites(w http.ResponseWriter, r *http.Request) {
+	if !requireDB(w) {
+		return
+	}
+	user, err := resolveUserFromRequest(r)
+	if err != nil {
+		response.WriteErr(w, http.StatusUnauthorized, "Token inválido o expirado")
+		return
+	}
+	favorites, err := services.FavoriteChannels(config.DB, user.ID)
+	if err != nil {
+		response.WriteErr(w, http.StatusInternalServerError, "No se pudieron leer los favoritos")
+		return
+	}
+	if favorites == nil {
+		favorites = []string{}
+	}
+	response.WriteJSON(w, http.StatusOK, map[string]any{"favorites": favorites})
+}
+
+// POST /me/favorites/{code} marca el canal como favorito; DELETE lo quita
+func MeFavorite(w http.ResponseWriter, r *http.Request) {
+	if !requireDB(w) {
+		return
+	}
+	user, err := resolveUserFromRequest(r)
+	if err != nil {
+		response.WriteErr(w, http.StatusUnauthorized, "Token inválido o expirado")
+		return
+	}
+	code := r.PathValue("code")
+
+	favorite := r.Method != http.MethodDelete
+	if favorite {
+		err = services.AddFavoriteChannel(config.DB, user.ID, code)
+	} else {
+		err = services.RemoveFavoriteChannel(config.DB, user.ID, code)
+	}
+	switch {
+	case errors.Is(err, services.ErrChannelNotFound):
+		response.WriteErr(w, http.StatusNotFound, "Canal no encontrado")
+	case err != nil:
+		log.Printf("[FAVORITOS] usuario=%d canal=%s error=%v", user.ID, code, err)
+		response.WriteErr(w, http.StatusInternalServerError, "No se pudo guardar el favorito")
+	default:
+		log.Printf("[FAVORITOS] usuario=%d canal=%s favorito=%t", user.ID, code, favorite)
+		response.WriteJSON(w, http.StatusOK, map[string]any{"channel": code, "favorite": favorite})
+	}
+}
+
+// handleFavoriteChannelCommand responde a "vuelve a mi canal favorito": conecta al primer
+// canal que el usuario marcó como favorito
+func handleFavoriteChannelCommand(user *models.User, svc userService) (CommandResponse, error) {
+	favorites := loadFavoriteChannels(user.ID)
+	if len(favorites) == 0 {
+		return CommandResponse{
+			Status:  "error",
+			Intent:  intentFavoriteChannel,
+			Message: "No tienes canales favoritos. Marca uno desde la aplicación",
+		}, nil
+	}
+
+	favorite := favorites[0]
+	if favorite == user.GetCurrentChannelCode() {
+		return CommandResponse{
+			Status:  "ok",
+			Intent:  intentFavoriteChannel,
+			Message: fmt.Sprintf("Ya estás en tu canal favorito, el %s", channelLabel(favorite)),
+			Data:    map[string]any{"channel": favorite},
+		}, nil
+	}
+	resp, err := handleChannelConnectCommand(user, svc, favorite)
+	if err == nil && resp.Status == "ok" {
+		resp.Intent = intentFavoriteChannel
+	}
+	return resp, err
+}
@@ -0,0 +1,57 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"walkie-backend/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFavorites_ListFirstAndQuickSwitch(t *testing.T) {
+	db := setupTestDB(t)
+	require.NoError(t, db.AutoMigrate(&models.FavoriteChannel{}))
+	user := createTestUser(t, db, 679, "tok-fav-679", "canal-21")
+	for _, code := range []string{"canal-22", "canal-23"} {
+		require.NoError(t, db.Create(&models.Channel{Code: code, Name: code, MaxUsers: 10}).Error)
+	}
+
+	favorite := func(method, code string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, "/me/favorites/"+code, nil)
+		req.SetPathValue("code", code)
+		req = req.WithContext(withAuthUser(req.Context(), user))
+		rec := httptest.NewRecorder()
+		MeFavorite(rec, req)
+		return rec
+	}
+	assert.Equal(t, http.StatusNotFound, favorite(http.MethodPost, "canal-nada").Code)
+	require.Equal(t, http.StatusOK, favorite(http.MethodPost, "canal-23").Code)
+
+	req := httptest.NewRequest(http.MethodGet, "/me/favorites", nil)
+	req = req.WithContext(withAuthUser(req.Context(), user))
+	rec := httptest.NewRecorder()
+	MeFavorites(rec, req)
+	assert.JSONEq(t, `{"favorites":["canal-23"]}`, rec.Body.String())
+
+	svc := &mockUserService{channels: []models.Channel{{Code: "canal-21"}, {Code: "canal-22"}, {Code: "canal-23"}}}
+	resp, err := handleChannelListCommand(user, svc)
+	require.NoError(t, err)
+	assert.Equal(t, "Tus favoritos: 23. Canales disponibles: 21 y 22", resp.Message)
+	assert.Equal(t, []string{"canal-23", "canal-21", "canal-22"}, resp.Data["channels"])
+	assert.Equal(t, []string{"canal-23"}, resp.Data["favorites"])
+
+	resp, err = handleFavoriteChannelCommand(user, svc)
+	require.NoError(t, err)
+	assert.Equal(t, intentFavoriteChannel, resp.Intent)
+	assert.Equal(t, "Conectado al canal 23", resp.Message)
+	assert.Equal(t, []string{"canal-23"}, svc.connected)
+
+	require.Equal(t, http.StatusOK, favorite(http.MethodDelete, "canal-23").Code)
+	resp, err = handleFavoriteChannelCommand(user, svc)
+	require.NoError(t, err)
+	assert.Equal(t, "error", resp.Status)
+	assert.Contains(t, resp.Message, "No tienes canales favoritos")
+}
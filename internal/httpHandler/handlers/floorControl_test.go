@@ -0,0 +1,115 @@
+package handlers
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func drainSignals(c *wsClient) []map[string]any {
+	var out []map[string]any
+	for {
+		select {
+		case msg := <-c.send:
+			var m map[string]any
+			_ = json.Unmarshal(msg, &m)
+			out = append(out, m)
+		default:
+			return out
+		}
+	}
+}
+
+func TestStartTransmission_FloorControl(t *testing.T) {
+	const channel = "piso"
+	speaker := &wsClient{userID: 81, channel: channel, send: make(chan []byte, 8)}
+	other := &wsClient{userID: 82, channel: channel, send: make(chan []byte, 8)}
+	registerClient(speaker)
+	registerClient(other)
+	defer func() {
+		removeClient(speaker)
+		removeClient(other)
+		registry.Lock()
+		delete(registry.floor, channel)
+		registry.Unlock()
+	}()
+
+	_, ok := startTransmission(channel, 81, PriorityNormal)
+	assert.True(t, ok)
+	drainSignals(speaker)
+	drainSignals(other)
+
+	holder, ok := startTransmission(channel, 82, PriorityNormal)
+	assert.False(t, ok)
+	assert.Equal(t, uint(81), holder)
+	signals := drainSignals(other)
+	if assert.Len(t, signals, 1) {
+		assert.Equal(t, "BUSY", signals[0]["signal"])
+	}
+	assert.Empty(t, drainSignals(speaker), "el hablante actual no recibe nada")
+
+	// Sólo quien tiene la palabra puede liberarla
+	stopTransmission(channel, 82)
+	_, ok = startTransmission(channel, 82, PriorityNormal)
+	assert.False(t, ok)
+
+	stopTransmission(channel, 81)
+	_, ok = startTransmission(channel, 82, PriorityNormal)
+	assert.True(t, ok)
+}
+
+func TestStartTransmission_FloorExpires(t *testing.T) {
+	const channel = "piso-caducado"
+	defer func() {
+		registry.Lock()
+		delete(registry.floor, channel)
+		registry.Unlock()
+	}()
+
+	registry.Lock()
+	registry.floor[channel] = floorHold{speakerID: 91, since: time.Now().Add(-2 * floorMaxHold)}
+	registry.Unlock()
+
+	_, ok := startTransmission(channel, 92, PriorityNormal)
+	assert.True(t, ok)
+}
+
+func TestStartTransmission_EmergencyInterrupts(t *testing.T) {
+	const channel = "piso-emergencia"
+	speaker := &wsClient{userID: 83, channel: channel, send: make(chan []byte, 8)}
+	other := &wsClient{userID: 84, channel: channel, send: make(chan []byte, 8)}
+	registerClient(speaker)
+	registerClient(other)
+	defer func() {
+		removeClient(speaker)
+		removeClient(other)
+		registry.Lock()
+		delete(registry.floor, channel)
+		registry.Unlock()
+	}()
+
+	_, ok := startTransmission(channel, 83, PriorityNormal)
+	assert.True(t, ok)
+	drainSignals(speaker)
+	drainSignals(other)
+
+	_, ok = startTransmission(channel, 84, PriorityEmergency)
+	assert.True(t, ok)
+	signals := drainSignals(speaker)
+	if assert.Len(t, signals, 2) {
+		assert.Equal(t, "interrupt", signals[0]["action"])
+		assert.Equal(t, float64(83), signals[0]["interrupted"])
+		assert.Equal(t, "start", signals[1]["action"])
+		assert.Equal(t, PriorityEmergency, signals[1]["priority"])
+	}
+	drainSignals(other)
+
+	// El STOP tardío del hablante interrumpido no corta la emergencia
+	stopTransmission(channel, 83)
+	assert.Empty(t, drainSignals(other))
+	holder, ok := startTransmission(channel, 83, PriorityEmergency)
+	assert.False(t, ok, "una emergencia no interrumpe a otra")
+	assert.Equal(t, uint(84), holder)
+}
@@ -0,0 +1,384 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"math"
+	"net/http"
+	"strings"
+	"time"
+
+	"walkie-backend/internal/config"
+	"walkie-backend/internal/metrics"
+	"walkie-backend/internal/models"
+	"walkie-backend/pkg/walkiepb"
+
+	"github.com/gorilla/websocket"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// grpcService implementa la API gRPC sobre los mismos handlers que HTTP y el WebSocket
+type grpcService struct {
+	walkiepb.UnimplementedWalkieServer
+	newUserService func() userService
+	newIngestDeps  func() audioIngestDeps
+}
+
+func newGRPCService() *grpcService {
+	return &grpcService{
+		newUserService: newDefaultUserService,
+		newIngestDeps:  newAudioIngestDeps,
+	}
+}
+
+// NewGRPCServer crea el servidor gRPC con la autenticación por token en los metadatos
+func NewGRPCServer(opts ...grpc.ServerOption) *grpc.Server {
+	return newGRPCServer(newGRPCService(), opts...)
+}
+
+func newGRPCServer(svc *grpcService, opts ...grpc.ServerOption) *grpc.Server {
+	opts = append(opts,
+		grpc.MaxRecvMsgSize(maxMessageSize),
+		grpc.ChainUnaryInterceptor(grpcUnaryAuth),
+		grpc.ChainStreamInterceptor(grpcStreamAuth),
+	)
+	srv := grpc.NewServer(opts...)
+	walkiepb.RegisterWalkieServer(srv, svc)
+	return srv
+}
+
+// grpcUser valida el token de "authorization: Bearer" (o "x-auth-token") de los metadatos
+func grpcUser(ctx context.Context) (*models.User, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	token := ""
+	if values := md.Get("x-auth-token"); len(values) > 0 {
+		token = strings.TrimSpace(values[0])
+	}
+	if values := md.Get("authorization"); token == "" && len(values) > 0 {
+		auth := strings.TrimSpace(values[0])
+		if len(auth) > 7 && strings.EqualFold(auth[:7], "bearer ") {
+			token = strings.TrimSpace(auth[7:])
+		}
+	}
+	if token == "" {
+		return nil, status.Error(codes.Unauthenticated, "Token requerido")
+	}
+	if !config.DBAvailable() {
+		return nil, status.Error(codes.Unavailable, "Servicio temporalmente no disponible")
+	}
+	user, err := findUserByToken(token)
+	if err != nil {
+		return nil, status.Error(codes.Unauthenticated, "Token inválido o expirado")
+	}
+	return user, nil
+}
+
+func grpcUnaryAuth(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	resp, err := grpcUnaryAuthed(ctx, req, info, handler)
+	metrics.Inc("walkie_grpc_requests_total", map[string]string{"method": info.FullMethod, "code": status.Code(err).String()})
+	return resp, err
+}
+
+func grpcUnaryAuthed(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	if info.FullMethod == walkiepb.Walkie_Authenticate_FullMethodName {
+		return handler(ctx, req)
+	}
+	user, err := grpcUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+	refreshUserActivity(user.ID)
+	return handler(withAuthUser(ctx, user), req)
+}
+
+// authedStream sustituye el contexto del stream por el que lleva el usuario
+type authedStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *authedStream) Context() context.Context { return s.ctx }
+
+func grpcStreamAuth(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	user, err := grpcUser(ss.Context())
+	if err == nil {
+		refreshUserActivity(user.ID)
+		err = handler(srv, &authedStream{ServerStream: ss, ctx: withAuthUser(ss.Context(), user)})
+	}
+	metrics.Inc("walkie_grpc_requests_total", map[string]string{"method": info.FullMethod, "code": status.Code(err).String()})
+	return err
+}
+
+// Authenticate reutiliza POST /auth para que ambas APIs creen las mismas sesiones
+func (s *grpcService) Authenticate(ctx context.Context, in *walkiepb.AuthenticateRequest) (*walkiepb.AuthenticateResponse, error) {
+	body, _ := json.Marshal(AuthenticationRequest{
+		Nombre:      in.GetNombre(),
+		Pin:         int(in.GetPin()),
+		Dispositivo: in.GetDispositivo(),
+		Plataforma:  in.GetPlataforma(),
+	})
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, "/auth", bytes.NewReader(body))
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	if md, ok := metadata.FromIncomingContext(ctx); ok && len(md.Get("user-agent")) > 0 {
+		req.Header.Set("User-Agent", md.Get("user-agent")[0])
+	}
+
+	rw := newBufferedResponse()
+	Authenticate(rw, req)
+	if rw.status != http.StatusOK {
+		return nil, grpcErrorFromHTTP(rw.status, rw.body.Bytes())
+	}
+
+	var resp AuthenticationResponse
+	if err := json.Unmarshal(rw.body.Bytes(), &resp); err != nil {
+		return nil, status.Error(codes.Internal, "respuesta de autenticación inválida")
+	}
+	out := &walkiepb.AuthenticateResponse{
+		Message:   resp.Message,
+		Token:     resp.Token,
+		SessionId: uint64(resp.SessionID),
+	}
+	if resp.TokenPair != nil {
+		out.AccessToken = resp.AccessToken
+		out.RefreshToken = resp.RefreshToken
+		out.ExpiresIn = resp.ExpiresIn
+	}
+	return out, nil
+}
+
+// grpcErrorFromHTTP traduce una respuesta de error HTTP a un estado gRPC
+func grpcErrorFromHTTP(httpStatus int, body []byte) error {
+	var payload struct {
+		Message string `json:"message"`
+		Error   string `json:"error"`
+	}
+	message := strings.TrimSpace(string(body))
+	if json.Unmarshal(body, &payload) == nil {
+		message = payload.Message
+		if payload.Error != "" {
+			message = payload.Error
+		}
+	}
+
+	code := codes.Internal
+	switch httpStatus {
+	case http.StatusBadRequest:
+		code = codes.InvalidArgument
+	case http.StatusUnauthorized:
+		code = codes.Unauthenticated
+	case http.StatusForbidden:
+		code = codes.PermissionDenied
+	case http.StatusNotFound:
+		code = codes.NotFound
+	case http.StatusTooManyRequests:
+		code = codes.ResourceExhausted
+	case http.StatusServiceUnavailable:
+		code = codes.Unavailable
+	}
+	return status.Error(code, message)
+}
+
+// grpcCurrentUser devuelve el usuario autenticado con su canal actual cargado
+func (s *grpcService) grpcCurrentUser(ctx context.Context) (*models.User, userService, error) {
+	authUser, ok := authUserFromContext(ctx)
+	if !ok {
+		return nil, nil, status.Error(codes.Unauthenticated, "Token requerido")
+	}
+	svc := s.newUserService()
+	user, err := svc.GetUserWithChannel(authUser.ID)
+	if err != nil {
+		return nil, nil, status.Error(codes.Internal, "No se pudo cargar el usuario")
+	}
+	return user, svc, nil
+}
+
+func (s *grpcService) ConnectChannel(ctx context.Context, in *walkiepb.ConnectChannelRequest) (*walkiepb.CommandResponse, error) {
+	channel := strings.TrimSpace(in.GetChannel())
+	if channel == "" {
+		return nil, status.Error(codes.InvalidArgument, "Canal inválido")
+	}
+	user, svc, err := s.grpcCurrentUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := handleChannelConnectCommand(user, svc, channel)
+	if err != nil {
+		log.Printf("[GRPC] usuario=%d error conectando a %s: %v", user.ID, channel, err)
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	return commandResponseProto(resp), nil
+}
+
+func (s *grpcService) DisconnectChannel(ctx context.Context, _ *walkiepb.DisconnectChannelRequest) (*walkiepb.CommandResponse, error) {
+	user, svc, err := s.grpcCurrentUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := handleChannelDisconnectCommand(user, svc)
+	if err != nil {
+		log.Printf("[GRPC] usuario=%d error desconectando: %v", user.ID, err)
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	return commandResponseProto(resp), nil
+}
+
+func commandResponseProto(resp CommandResponse) *walkiepb.CommandResponse {
+	out := &walkiepb.CommandResponse{Status: resp.Status, Intent: resp.Intent, Message: resp.Message}
+	if len(resp.Data) > 0 {
+		data, _ := json.Marshal(resp.Data)
+		out.DataJson = string(data)
+	}
+	return out
+}
+
+func (s *grpcService) SendAudio(ctx context.Context, in *walkiepb.SendAudioRequest) (*walkiepb.IngestResult, error) {
+	user, ok := authUserFromContext(ctx)
+	if !ok {
+		return nil, status.Error(codes.Unauthenticated, "Token requerido")
+	}
+	if len(in.GetAudio()) == 0 {
+		return nil, status.Error(codes.InvalidArgument, "Audio vacío")
+	}
+	return s.ingest(user.ID, in.GetAudio()), nil
+}
+
+// ingest aplica el mismo límite que POST /audio/ingest y pasa el clip por el pipeline
+func (s *grpcService) ingest(userID uint, data []byte) *walkiepb.IngestResult {
+	if allowed, wait := IngestLimiter.Allow(fmt.Sprintf("user:%d", userID)); !allowed {
+		return &walkiepb.IngestResult{
+			Status:     http.StatusTooManyRequests,
+			Error:      "Demasiadas peticiones, inténtalo más tarde",
+			RetryAfter: int32(math.Max(1, math.Ceil(wait.Seconds()))),
+		}
+	}
+	result := runWSIngest(userID, data, s.newIngestDeps())
+	return &walkiepb.IngestResult{
+		Status:     int32(result.Status),
+		DataJson:   string(result.Data),
+		Error:      result.Error,
+		RetryAfter: int32(result.RetryAfter),
+	}
+}
+
+// Stream registra la llamada como un cliente más del canal: recibe lo mismo que un
+// WebSocket y sus clips pasan por el pipeline de uno en uno
+func (s *grpcService) Stream(stream walkiepb.Walkie_StreamServer) error {
+	ctx := stream.Context()
+	user, _, err := s.grpcCurrentUser(ctx)
+	if err != nil {
+		return err
+	}
+
+	client := &wsClient{
+		userID:  user.ID,
+		name:    user.DisplayName,
+		channel: user.GetCurrentChannelCode(),
+		send:    make(chan []byte, wsSendQueueSize()),
+	}
+	registerClient(client)
+	presence.Connect(user.ID, user.DisplayName, client.channel)
+	defer func() {
+		removeClient(client)
+		presence.Disconnect(client.userID)
+		client.closeSend()
+	}()
+	log.Printf("[GRPC] stream conectado: usuario=%d, canal=%s", user.ID, client.channel)
+
+	welcome, _ := json.Marshal(map[string]string{
+		"message": "Conexión establecida",
+		"channel": client.channel,
+	})
+	client.enqueue(welcome)
+
+	results := make(chan *walkiepb.IngestResult, wsUploadQueue+1)
+	recvErr := make(chan error, 1)
+	go s.receiveClips(ctx, stream, client, results, recvErr)
+
+	ticker := time.NewTicker(pingInterval)
+	defer ticker.Stop()
+	for {
+		var event *walkiepb.ServerEvent
+		select {
+		case <-ctx.Done():
+			return nil
+		case err := <-recvErr:
+			if errors.Is(err, io.EOF) || status.Code(err) == codes.Canceled {
+				return nil
+			}
+			return err
+		case msg, ok := <-client.send:
+			if !ok {
+				// El usuario se desconectó del canal, igual que se cierra el WebSocket
+				return nil
+			}
+			event = serverEventFor(msg)
+		case result := <-results:
+			event = &walkiepb.ServerEvent{Event: &walkiepb.ServerEvent_IngestResult{IngestResult: result}}
+		case <-ticker.C:
+			// La llamada sigue abierta: el supervisor no debe echarla por falta de pongs
+			client.touch()
+			continue
+		}
+		if err := stream.Send(event); err != nil {
+			return err
+		}
+	}
+}
+
+// receiveClips lee los clips del stream y los procesa en orden; si ya hay demasiados en
+// espera responde de inmediato, como el WebSocket
+func (s *grpcService) receiveClips(ctx context.Context, stream walkiepb.Walkie_StreamServer, client *wsClient, results chan<- *walkiepb.IngestResult, recvErr chan<- error) {
+	uploads := make(chan wsUpload, wsUploadQueue)
+	defer close(uploads)
+	go func() {
+		for upload := range uploads {
+			result := s.ingest(client.userID, upload.data)
+			result.Seq = upload.seq
+			select {
+			case results <- result:
+			case <-ctx.Done():
+			}
+		}
+	}()
+
+	var seq uint64
+	for {
+		frame, err := stream.Recv()
+		if err != nil {
+			recvErr <- err
+			return
+		}
+		client.touch()
+		if len(frame.GetAudio()) == 0 {
+			continue
+		}
+		seq++
+		select {
+		case uploads <- wsUpload{seq: seq, data: frame.GetAudio()}:
+		default:
+			select {
+			case results <- &walkiepb.IngestResult{Seq: seq, Status: http.StatusServiceUnavailable, Error: "Hay otro audio en proceso"}:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}
+}
+
+// serverEventFor convierte un mensaje de la cola del cliente en evento gRPC
+func serverEventFor(msg []byte) *walkiepb.ServerEvent {
+	if frameType(msg) == websocket.TextMessage {
+		return &walkiepb.ServerEvent{Event: &walkiepb.ServerEvent_ControlJson{ControlJson: string(msg)}}
+	}
+	return &walkiepb.ServerEvent{Event: &walkiepb.ServerEvent_Audio{Audio: msg}}
+}
@@ -0,0 +1,93 @@
+package handlers
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	"walkie-backend/pkg/walkiepb"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// newTestGRPCClient arranca el servicio en memoria y devuelve un cliente conectado
+func newTestGRPCClient(t *testing.T, svc *grpcService) walkiepb.WalkieClient {
+	t.Helper()
+	lis := bufconn.Listen(1 << 20)
+	srv := newGRPCServer(svc)
+	go func() { _ = srv.Serve(lis) }()
+	t.Cleanup(srv.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(context.Context, string) (net.Conn, error) { return lis.Dial() }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = conn.Close() })
+	return walkiepb.NewWalkieClient(conn)
+}
+
+func TestGRPC_RequiresToken(t *testing.T) {
+	setupTestDB(t)
+	client := newTestGRPCClient(t, newGRPCService())
+
+	_, err := client.ConnectChannel(context.Background(), &walkiepb.ConnectChannelRequest{Channel: "canal-1"})
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+
+	ctx := metadata.AppendToOutgoingContext(context.Background(), "authorization", "Bearer no-existe")
+	_, err = client.SendAudio(ctx, &walkiepb.SendAudioRequest{Audio: []byte("RIFF")})
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+}
+
+func TestGRPC_StreamDeliversChannelAndRunsIngest(t *testing.T) {
+	db := setupTestDB(t)
+	user := createTestUser(t, db, 690, "token-grpc", "canal-grpc")
+	users := &mockUserService{user: user}
+	svc := newGRPCService()
+	svc.newUserService = func() userService { return users }
+	client := newTestGRPCClient(t, svc)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	ctx = metadata.AppendToOutgoingContext(ctx, "authorization", "Bearer "+user.AuthToken)
+	stream, err := client.Stream(ctx)
+	require.NoError(t, err)
+
+	welcome, err := stream.Recv()
+	require.NoError(t, err)
+	assert.Contains(t, welcome.GetControlJson(), `"channel":"canal-grpc"`)
+
+	broadcastAudio("canal-grpc", 1, []byte("RIFF audio"))
+	event, err := stream.Recv()
+	require.NoError(t, err)
+	assert.Equal(t, []byte("RIFF audio"), event.GetAudio())
+
+	// No es audio: el pipeline lo rechaza y el resultado vuelve por el stream
+	require.NoError(t, stream.Send(&walkiepb.ClientFrame{Audio: []byte("esto no es audio")}))
+	event, err = stream.Recv()
+	require.NoError(t, err)
+	result := event.GetIngestResult()
+	require.NotNil(t, result)
+	assert.Equal(t, uint64(1), result.Seq)
+	assert.Equal(t, int32(http.StatusBadRequest), result.Status)
+
+	resp, err := client.ConnectChannel(ctx, &walkiepb.ConnectChannelRequest{Channel: "canal-otro"})
+	require.NoError(t, err)
+	assert.Equal(t, "ok", resp.Status)
+	assert.Contains(t, resp.DataJson, `"channel":"canal-otro"`)
+	assert.Equal(t, []string{"canal-otro"}, users.connected)
+
+	event, err = stream.Recv()
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"type":"channel_changed","channel":"canal-otro"}`, event.GetControlJson())
+	require.NoError(t, stream.CloseSend())
+}
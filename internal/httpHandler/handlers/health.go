@@ -0,0 +1,69 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"walkie-backend/internal/config"
+	"walkie-backend/internal/response"
+)
+
+const readinessTimeout = 2 * time.Second
+
+var processStart = time.Now()
+
+// dependencyStatus es el resultado de comprobar una dependencia en /readyz
+type dependencyStatus struct {
+	Status    string `json:"status"`
+	Error     string `json:"error,omitempty"`
+	LatencyMS int64  `json:"latency_ms"`
+}
+
+// readinessChecks son las dependencias que deben responder para recibir tráfico
+var readinessChecks = map[string]func(context.Context) error{
+	"database":    config.PingDB,
+	"audio_queue": func(ctx context.Context) error { return audioStore().Ping(ctx) },
+	"stt":         checkSTTClient,
+	"ai":          checkAIClient,
+}
+
+// GET /healthz responde mientras el proceso esté vivo, sin mirar dependencias
+func Healthz(w http.ResponseWriter, r *http.Request) {
+	response.WriteJSON(w, http.StatusOK, map[string]any{
+		"status":         "ok",
+		"uptime_seconds": int64(time.Since(processStart).Seconds()),
+	})
+}
+
+// GET /readyz comprueba base de datos, cola de audio y los clientes de STT e IA (con
+// READYZ_PROBE_CLIENTS=true también llama a sus APIs); responde 503 si alguna falla para
+// que el balanceador deje de enviar tráfico. "clients" detalla el estado de cada cliente.
+func Readyz(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), readinessTimeout)
+	defer cancel()
+
+	ready := true
+	checks := make(map[string]dependencyStatus, len(readinessChecks))
+	for name, check := range readinessChecks {
+		start := time.Now()
+		status := dependencyStatus{Status: "ok"}
+		if err := check(ctx); err != nil {
+			status.Status = "error"
+			status.Error = err.Error()
+			ready = false
+		}
+		status.LatencyMS = time.Since(start).Milliseconds()
+		checks[name] = status
+	}
+
+	code, overall := http.StatusOK, "ready"
+	if !ready {
+		code, overall = http.StatusServiceUnavailable, "not_ready"
+	}
+	response.WriteJSON(w, code, map[string]any{
+		"status":  overall,
+		"checks":  checks,
+		"clients": externalClientStatus(),
+	})
+}
@@ -0,0 +1,49 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHealthz(t *testing.T) {
+	rec := httptest.NewRecorder()
+	Healthz(rec, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), `"status":"ok"`)
+}
+
+func TestReadyz_ReportsEachDependency(t *testing.T) {
+	setupTestDB(t)
+	t.Setenv("AI_PROVIDER", "ollama")
+	t.Setenv("ASSEMBLYAI_API_KEY", "test-key")
+
+	rec := httptest.NewRecorder()
+	Readyz(rec, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+	var body struct {
+		Status string                      `json:"status"`
+		Checks map[string]dependencyStatus `json:"checks"`
+	}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+	assert.Equal(t, "ready", body.Status)
+	for _, name := range []string{"database", "audio_queue", "stt", "ai"} {
+		assert.Equal(t, "ok", body.Checks[name].Status, name)
+	}
+
+	t.Setenv("ASSEMBLYAI_API_KEY", "")
+	rec = httptest.NewRecorder()
+	Readyz(rec, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+	require.Equal(t, http.StatusServiceUnavailable, rec.Code)
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+	assert.Equal(t, "not_ready", body.Status)
+	assert.Equal(t, "error", body.Checks["stt"].Status)
+	assert.Contains(t, body.Checks["stt"].Error, "ASSEMBLYAI_API_KEY")
+	assert.Equal(t, "ok", body.Checks["database"].Status)
+}
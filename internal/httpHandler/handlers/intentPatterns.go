@@ -0,0 +1,177 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"walkie-backend/internal/config"
+	"walkie-backend/internal/models"
+	"walkie-backend/internal/response"
+	"walkie-backend/internal/services"
+	"walkie-backend/pkg/qwen"
+)
+
+const defaultIntentPatternsReload = time.Minute
+
+var intentPatternsOnce sync.Once
+
+type adminIntentPatternView struct {
+	ID       uint   `json:"id"`
+	Intent   string `json:"intent"`
+	Phrase   string `json:"phrase"`
+	Language string `json:"language"`
+}
+
+func toAdminIntentPatternView(p *models.IntentPattern) adminIntentPatternView {
+	return adminIntentPatternView{ID: p.ID, Intent: p.Intent, Phrase: p.Phrase, Language: p.Language}
+}
+
+// reloadIntentPatterns carga los patrones de la base de datos en el detector de comandos
+func reloadIntentPatterns() error {
+	if config.DB == nil || !config.DBAvailable() {
+		return config.ErrDBUnavailable
+	}
+	stored, err := services.NewIntentPatternService(config.DB).List()
+	if err != nil {
+		return err
+	}
+	patterns := make([]qwen.Pattern, 0, len(stored))
+	for _, p := range stored {
+		patterns = append(patterns, qwen.Pattern{Intent: p.Intent, Phrase: p.Phrase, Language: p.Language})
+	}
+	qwen.SetPatterns(patterns)
+	return nil
+}
+
+// StartIntentPatternReloader carga los patrones al arrancar y los recarga cada
+// INTENT_PATTERNS_RELOAD (1 min por defecto) para recoger cambios de otras instancias
+func StartIntentPatternReloader() {
+	intentPatternsOnce.Do(func() {
+		if err := reloadIntentPatterns(); err != nil {
+			log.Printf("[INTENTS] no se pudieron cargar los patrones: %v", err)
+		}
+		interval := config.DurationFromEnv("INTENT_PATTERNS_RELOAD", defaultIntentPatternsReload)
+		go func() {
+			ticker := time.NewTicker(interval)
+			defer ticker.Stop()
+			for range ticker.C {
+				if err := reloadIntentPatterns(); err != nil && !errors.Is(err, config.ErrDBUnavailable) {
+					log.Printf("[INTENTS] error recargando patrones: %v", err)
+				}
+			}
+		}()
+	})
+}
+
+// GET /admin/intents lista los patrones; POST /admin/intents crea uno
+func AdminIntentPatterns(w http.ResponseWriter, r *http.Request) {
+	if !requireAdmin(w, r) || !requireDB(w) {
+		return
+	}
+
+	svc := services.NewIntentPatternService(config.DB)
+	if r.Method == http.MethodGet {
+		patterns, err := svc.List()
+		if err != nil {
+			response.WriteErr(w, http.StatusInternalServerError, "No se pudieron obtener los patrones")
+			return
+		}
+		out := make([]adminIntentPatternView, 0, len(patterns))
+		for i := range patterns {
+			out = append(out, toAdminIntentPatternView(&patterns[i]))
+		}
+		response.WriteJSON(w, http.StatusOK, out)
+		return
+	}
+
+	in, ok := readIntentPatternInput(w, r)
+	if !ok {
+		return
+	}
+	pattern, err := svc.Create(in)
+	if err != nil {
+		writeIntentPatternError(w, err)
+		return
+	}
+	afterIntentPatternChange(r, "intent_pattern_create", pattern)
+	response.WriteJSON(w, http.StatusCreated, toAdminIntentPatternView(pattern))
+}
+
+// PUT /admin/intents/{id} modifica un patrón; DELETE /admin/intents/{id} lo borra
+func AdminIntentPattern(w http.ResponseWriter, r *http.Request) {
+	if !requireAdmin(w, r) || !requireDB(w) {
+		return
+	}
+
+	id, err := strconv.ParseUint(r.PathValue("id"), 10, 64)
+	if err != nil || id == 0 {
+		response.WriteErr(w, http.StatusBadRequest, "ID de patrón inválido")
+		return
+	}
+
+	svc := services.NewIntentPatternService(config.DB)
+	if r.Method == http.MethodPut {
+		in, ok := readIntentPatternInput(w, r)
+		if !ok {
+			return
+		}
+		pattern, err := svc.Update(uint(id), in)
+		if err != nil {
+			writeIntentPatternError(w, err)
+			return
+		}
+		afterIntentPatternChange(r, "intent_pattern_update", pattern)
+		response.WriteJSON(w, http.StatusOK, toAdminIntentPatternView(pattern))
+		return
+	}
+
+	if err := svc.Delete(uint(id)); err != nil {
+		writeIntentPatternError(w, err)
+		return
+	}
+	afterIntentPatternChange(r, "intent_pattern_delete", &models.IntentPattern{ID: uint(id)})
+	response.WriteJSON(w, http.StatusOK, map[string]any{"status": "deleted", "id": id})
+}
+
+// afterIntentPatternChange audita el cambio y recarga los patrones en esta instancia
+func afterIntentPatternChange(r *http.Request, action string, p *models.IntentPattern) {
+	services.RecordAudit(nil, models.AuditEntry{
+		Actor:   adminActor(r),
+		Action:  action,
+		Details: fmt.Sprintf("id=%d intent=%s phrase=%q language=%s", p.ID, p.Intent, p.Phrase, p.Language),
+		Source:  models.EventSourceHTTP,
+	})
+	if err := reloadIntentPatterns(); err != nil {
+		log.Printf("[INTENTS] error recargando patrones: %v", err)
+	}
+}
+
+func readIntentPatternInput(w http.ResponseWriter, r *http.Request) (services.IntentPatternInput, bool) {
+	var in services.IntentPatternInput
+	body, err := io.ReadAll(io.LimitReader(r.Body, 16<<10))
+	if err != nil || json.Unmarshal(body, &in) != nil {
+		response.WriteErr(w, http.StatusBadRequest, "JSON inválido")
+		return in, false
+	}
+	return in, true
+}
+
+func writeIntentPatternError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, services.ErrIntentPatternNotFound):
+		response.WriteErr(w, http.StatusNotFound, "Patrón no encontrado")
+	case errors.Is(err, services.ErrIntentPatternExists):
+		response.WriteErr(w, http.StatusConflict, err.Error())
+	case errors.Is(err, services.ErrInvalidIntentPattern):
+		response.WriteErr(w, http.StatusBadRequest, err.Error())
+	default:
+		response.WriteErr(w, http.StatusInternalServerError, "No se pudo modificar el patrón")
+	}
+}
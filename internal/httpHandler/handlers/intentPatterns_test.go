@@ -0,0 +1,104 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"walkie-backend/internal/config"
+	"walkie-backend/internal/models"
+	"walkie-backend/pkg/qwen"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// conversationAIURL levanta un modelo que nunca reconoce comandos, para forzar la heurística local
+func conversationAIURL(t *testing.T) string {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		content := `{"is_command":false,"intent":"conversation","reply":"","state":"sin_canal"}`
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"choices": []map[string]any{{"message": map[string]string{"role": "assistant", "content": content}}},
+		})
+	}))
+	t.Cleanup(server.Close)
+	return server.URL
+}
+
+func conversationAI(t *testing.T) *qwen.Client {
+	return qwen.NewClientWithConfig(qwen.Config{BaseURL: conversationAIURL(t), Model: "test"})
+}
+
+func TestAdminIntentPatterns_CRUDReloadsDetector(t *testing.T) {
+	cleanup := setupChannelsTestDB(t)
+	defer cleanup()
+	t.Setenv("ADMIN_TOKEN", "secreto")
+	require.NoError(t, config.DB.AutoMigrate(&models.IntentPattern{}, &models.AuditEntry{}))
+	t.Cleanup(func() { qwen.SetPatterns(nil) })
+
+	client := conversationAI(t)
+	analyze := func(text string) qwen.CommandResult {
+		result, err := client.AnalyzeTranscript(context.Background(), text, []string{"canal-1", "canal-2"}, "sin_canal", "")
+		require.NoError(t, err)
+		return result
+	}
+	require.False(t, analyze("subeme al dos").IsCommand)
+
+	rec := httptest.NewRecorder()
+	AdminIntentPatterns(rec, adminRequest(http.MethodPost, "/admin/intents", `{"intent":"request_channel_connect","phrase":"Súbeme al"}`))
+	require.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+	var created adminIntentPatternView
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &created))
+	assert.Equal(t, "subeme al", created.Phrase)
+
+	result := analyze("subeme al dos")
+	assert.True(t, result.IsCommand)
+	assert.Equal(t, "request_channel_connect", result.Intent)
+	assert.Equal(t, []string{"canal-2"}, result.Channels)
+
+	rec = httptest.NewRecorder()
+	AdminIntentPatterns(rec, adminRequest(http.MethodPost, "/admin/intents", `{"intent":"request_user_list","phrase":"subeme al"}`))
+	assert.Equal(t, http.StatusConflict, rec.Code)
+
+	rec = httptest.NewRecorder()
+	AdminIntentPatterns(rec, adminRequest(http.MethodPost, "/admin/intents", `{"intent":"volar","phrase":"despega"}`))
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+	rec = httptest.NewRecorder()
+	AdminIntentPatterns(rec, adminRequest(http.MethodGet, "/admin/intents", ""))
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), `"intent":"request_channel_connect"`)
+
+	patternRequest := func(method, body string) *http.Request {
+		req := adminRequest(method, fmt.Sprintf("/admin/intents/%d", created.ID), body)
+		req.SetPathValue("id", fmt.Sprint(created.ID))
+		return req
+	}
+	rec = httptest.NewRecorder()
+	AdminIntentPattern(rec, patternRequest(http.MethodPut, `{"intent":"request_user_list","phrase":"quien anda por aqui"}`))
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	assert.Equal(t, "request_user_list", analyze("¿quién anda por aquí?").Intent)
+
+	rec = httptest.NewRecorder()
+	AdminIntentPattern(rec, patternRequest(http.MethodDelete, ""))
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.False(t, analyze("oye quien anda por aqui").IsCommand)
+
+	rec = httptest.NewRecorder()
+	AdminIntentPattern(rec, patternRequest(http.MethodDelete, ""))
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+
+	var audits int64
+	config.DB.Model(&models.AuditEntry{}).Where("action LIKE ?", "intent_pattern_%").Count(&audits)
+	assert.Equal(t, int64(3), audits)
+
+	rec = httptest.NewRecorder()
+	req := adminRequest(http.MethodGet, "/admin/intents", "")
+	req.Header.Del("X-Admin-Token")
+	AdminIntentPatterns(rec, req)
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+}
@@ -0,0 +1,210 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"log"
+	"mime"
+	"net/http"
+	"time"
+
+	"walkie-backend/internal/config"
+	"walkie-backend/internal/models"
+	"walkie-backend/internal/response"
+	"walkie-backend/pkg/audio"
+)
+
+const (
+	defaultLiveFrameDuration = 200 * time.Millisecond
+	defaultLiveMaxDuration   = 5 * time.Minute
+	// liveTranscriptWait es lo que se espera a la transcripción al cerrar la transmisión
+	liveTranscriptWait = 10 * time.Second
+	// maxLiveHeaderBytes limita lo que se lee buscando el chunk data de la cabecera
+	maxLiveHeaderBytes = 4096
+)
+
+// POST /audio/live
+// Transmisión larga: el WAV llega por partes (Transfer-Encoding: chunked) y se reparte al
+// canal en clips de LIVE_FRAME_DURATION mientras se sube, sin guardarlo entero en memoria.
+func AudioLive(w http.ResponseWriter, r *http.Request) {
+	if !requireDB(w) {
+		return
+	}
+	user, err := resolveUserFromRequest(r)
+	if err != nil {
+		response.WriteErr(w, http.StatusUnauthorized, "Token inválido o expirado")
+		return
+	}
+	runAudioLive(w, r, user, defaultStreamingSTT())
+}
+
+func runAudioLive(w http.ResponseWriter, r *http.Request, user *models.User, stt streamingSTTClient) {
+	if !user.IsInChannel() {
+		response.WriteErr(w, http.StatusConflict, "No estás conectado a ningún canal")
+		return
+	}
+	mt, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if err != nil || audio.FormatForMime(mt) != audio.FormatWAV {
+		response.WriteErr(w, http.StatusUnsupportedMediaType, "La transmisión en directo requiere audio/wav")
+		return
+	}
+	defer r.Body.Close()
+
+	format, leftover, err := readLiveWAVHeader(r.Body)
+	if err != nil {
+		log.Printf("[EN_VIVO] usuario=%d cabecera inválida: %v", user.ID, err)
+		response.WriteErr(w, http.StatusBadRequest, "Cabecera WAV inválida")
+		return
+	}
+
+	channel := user.GetCurrentChannelCode()
+	priority := PriorityNormal
+	if emergencyRequested(r) {
+		priority = messagePriority(user.ID, "", true)
+	}
+	if holder, ok := startTransmission(channel, user.ID, priority); !ok {
+		writeChannelBusy(w, channel, holder)
+		return
+	}
+	defer stopTransmission(channel, user.ID)
+
+	transcript := startLiveTranscript(r.Context(), stt, format)
+	body := io.MultiReader(bytes.NewReader(leftover), r.Body)
+	frames, sent, truncated := relayLiveFrames(body, format, channel, user.ID, transcript)
+	text := transcript.finish()
+
+	duration := time.Duration(float64(sent) / float64(format.ByteRate) * float64(time.Second))
+	log.Printf("[EN_VIVO] usuario=%d canal=%s clips=%d bytes=%d dur=%s cortada=%t", user.ID, channel, frames, sent, duration, truncated)
+	recordChannelTranscript(user, channel, text, priority)
+
+	response.WriteJSON(w, http.StatusOK, CommandResponse{
+		Status:  "ok",
+		Intent:  "conversation",
+		Message: "Transmisión terminada",
+		Data: map[string]any{
+			"channel":          channel,
+			"frames":           frames,
+			"duration_seconds": duration.Seconds(),
+			"truncated":        truncated,
+			"transcript":       text,
+		},
+	})
+}
+
+// readLiveWAVHeader lee hasta encontrar el chunk data y devuelve el formato y los primeros
+// bytes de audio que se hayan leído de más
+func readLiveWAVHeader(r io.Reader) (audio.WAVFormat, []byte, error) {
+	buf := make([]byte, 0, 512)
+	chunk := make([]byte, 256)
+	for len(buf) < maxLiveHeaderBytes {
+		n, err := r.Read(chunk)
+		buf = append(buf, chunk[:n]...)
+		if f, perr := audio.ParseWAV(buf); perr == nil {
+			return f, buf[f.DataOffset:], nil
+		}
+		if len(buf) >= 12 && (string(buf[0:4]) != "RIFF" || string(buf[8:12]) != "WAVE") {
+			return audio.WAVFormat{}, nil, audio.ErrInvalidWAV
+		}
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return audio.WAVFormat{}, nil, audio.ErrMissingWAVChunk
+			}
+			return audio.WAVFormat{}, nil, err
+		}
+	}
+	return audio.WAVFormat{}, nil, audio.ErrMissingWAVChunk
+}
+
+// relayLiveFrames reparte el audio en clips WAV de LIVE_FRAME_DURATION y corta al llegar a
+// LIVE_MAX_DURATION; devuelve clips enviados, bytes de audio y si se cortó
+func relayLiveFrames(body io.Reader, f audio.WAVFormat, channel string, speakerID uint, transcript *liveTranscript) (int, int, bool) {
+	frameDuration := config.DurationFromEnv("LIVE_FRAME_DURATION", defaultLiveFrameDuration)
+	maxBytes := int(float64(f.ByteRate) * config.DurationFromEnv("LIVE_MAX_DURATION", defaultLiveMaxDuration).Seconds())
+
+	frameBytes := int(float64(f.ByteRate) * frameDuration.Seconds())
+	frameBytes -= frameBytes % f.BlockAlign
+	if frameBytes < f.BlockAlign {
+		frameBytes = f.BlockAlign
+	}
+
+	buf := make([]byte, frameBytes)
+	frames, sent := 0, 0
+	for sent < maxBytes {
+		if remaining := maxBytes - sent; remaining < len(buf) {
+			buf = buf[:remaining-remaining%f.BlockAlign]
+			if len(buf) == 0 {
+				break
+			}
+		}
+		n, err := io.ReadFull(body, buf)
+		n -= n % f.BlockAlign
+		if n > 0 {
+			pcm := buf[:n]
+			broadcastAudio(channel, speakerID, append(audio.WAVHeader(f, n), pcm...))
+			transcript.write(pcm)
+			refreshTransmission(channel, speakerID)
+			frames++
+			sent += n
+		}
+		if err != nil {
+			if !errors.Is(err, io.EOF) && !errors.Is(err, io.ErrUnexpectedEOF) {
+				log.Printf("[EN_VIVO] usuario=%d subida interrumpida: %v", speakerID, err)
+			}
+			return frames, sent, false
+		}
+	}
+
+	// Se alcanzó el máximo: lo que quede del cuerpo se descarta
+	extra, _ := io.Copy(io.Discard, io.LimitReader(body, 1))
+	return frames, sent, extra > 0
+}
+
+// liveTranscript transcribe en streaming el audio que se va reenviando al canal
+type liveTranscript struct {
+	pw     *io.PipeWriter
+	result chan streamResult
+}
+
+// startLiveTranscript abre la sesión de STT en streaming si está activa y el WAV es PCM
+// 16 bits mono; si no, devuelve una transcripción vacía que ignora el audio
+func startLiveTranscript(ctx context.Context, stt streamingSTTClient, f audio.WAVFormat) *liveTranscript {
+	if stt == nil || !f.IsPCM16() || f.Channels != 1 {
+		return nil
+	}
+	pr, pw := io.Pipe()
+	t := &liveTranscript{pw: pw, result: make(chan streamResult, 1)}
+	go func() {
+		text, err := stt.TranscribeWAVStream(ctx, pr)
+		// Seguir vaciando la tubería para no frenar el reenvío al canal
+		_, _ = io.Copy(io.Discard, pr)
+		t.result <- streamResult{text: text, err: err}
+	}()
+	t.write(audio.WAVHeader(f, 0))
+	return t
+}
+
+func (t *liveTranscript) write(p []byte) {
+	if t == nil {
+		return
+	}
+	_, _ = t.pw.Write(p)
+}
+
+// finish cierra la subida y espera el texto como mucho liveTranscriptWait
+func (t *liveTranscript) finish() string {
+	if t == nil {
+		return ""
+	}
+	_ = t.pw.Close()
+	select {
+	case res := <-t.result:
+		if res.err != nil {
+			log.Printf("[EN_VIVO] error de transcripción: %v", res.err)
+		}
+		return res.text
+	case <-time.After(liveTranscriptWait):
+		return ""
+	}
+}
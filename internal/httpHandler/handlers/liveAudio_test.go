@@ -0,0 +1,97 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"testing/iotest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+
+	"walkie-backend/internal/models"
+	"walkie-backend/pkg/audio"
+)
+
+func liveTestUser(id uint, channel string) *models.User {
+	channelID := uint(1)
+	return &models.User{
+		Model:            gorm.Model{ID: id},
+		CurrentChannelID: &channelID,
+		CurrentChannel:   &models.Channel{Code: channel},
+	}
+}
+
+func TestRunAudioLive_RelaysFramesWhileUploading(t *testing.T) {
+	t.Setenv("LIVE_FRAME_DURATION", "250ms")
+	const channel = "vivo-1"
+	listener := &wsClient{userID: 596, channel: channel, send: make(chan []byte, 16)}
+	registerClient(listener)
+	defer removeClient(listener)
+
+	stt := &mockStreamingSTT{text: "llegamos al muelle"}
+	// 1 s a 16 kHz mono leído a trozos, como llega una subida chunked
+	body := iotest.HalfReader(bytes.NewReader(buildTestWAV(32000)))
+	req := httptest.NewRequest(http.MethodPost, "/audio/live", body)
+	req.Header.Set("Content-Type", "audio/wav")
+	rec := httptest.NewRecorder()
+	runAudioLive(rec, req, liveTestUser(597, channel), stt)
+
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	var resp CommandResponse
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+	assert.Equal(t, float64(4), resp.Data["frames"])
+	assert.Equal(t, float64(1), resp.Data["duration_seconds"])
+	assert.Equal(t, false, resp.Data["truncated"])
+	assert.Equal(t, "llegamos al muelle", resp.Data["transcript"])
+	assert.Equal(t, 44+32000, stt.received, "el STT recibe la cabecera y todo el audio")
+
+	clips := 0
+	for len(listener.send) > 0 {
+		msg := <-listener.send
+		if f, err := audio.ParseWAV(msg); err == nil {
+			assert.Equal(t, 8000, f.DataSize)
+			clips++
+		}
+	}
+	assert.Equal(t, 4, clips)
+
+	registry.RLock()
+	_, held := registry.floor[channel]
+	registry.RUnlock()
+	assert.False(t, held, "la palabra se libera al terminar")
+}
+
+func TestRunAudioLive_CutsAtMaxDuration(t *testing.T) {
+	t.Setenv("LIVE_FRAME_DURATION", "250ms")
+	t.Setenv("LIVE_MAX_DURATION", "500ms")
+
+	req := httptest.NewRequest(http.MethodPost, "/audio/live", bytes.NewReader(buildTestWAV(32000)))
+	req.Header.Set("Content-Type", "audio/wav")
+	rec := httptest.NewRecorder()
+	runAudioLive(rec, req, liveTestUser(598, "vivo-2"), nil)
+
+	require.Equal(t, http.StatusOK, rec.Code)
+	var resp CommandResponse
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+	assert.Equal(t, float64(2), resp.Data["frames"])
+	assert.Equal(t, true, resp.Data["truncated"])
+}
+
+func TestRunAudioLive_RejectsInvalidUploads(t *testing.T) {
+	send := func(user *models.User, contentType string, body []byte) int {
+		req := httptest.NewRequest(http.MethodPost, "/audio/live", bytes.NewReader(body))
+		req.Header.Set("Content-Type", contentType)
+		rec := httptest.NewRecorder()
+		runAudioLive(rec, req, user, nil)
+		return rec.Code
+	}
+
+	user := liveTestUser(599, "vivo-3")
+	assert.Equal(t, http.StatusUnsupportedMediaType, send(user, "audio/ogg", buildTestWAV(100)))
+	assert.Equal(t, http.StatusBadRequest, send(user, "audio/wav", []byte("no es un wav con cabecera")))
+	assert.Equal(t, http.StatusConflict, send(&models.User{Model: gorm.Model{ID: 600}}, "audio/wav", buildTestWAV(100)))
+}
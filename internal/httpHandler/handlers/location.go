@@ -0,0 +1,247 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"walkie-backend/internal/config"
+	"walkie-backend/internal/models"
+	"walkie-backend/internal/response"
+	"walkie-backend/internal/services"
+)
+
+const (
+	intentNearbyUsers = "request_nearby_users"
+
+	defaultNearbyRadius = 1000
+	maxNearbyRadius     = 50000
+	defaultLocationTTL  = 15 * time.Minute
+)
+
+type locationRequest struct {
+	Lat      *float64 `json:"lat"`
+	Lon      *float64 `json:"lon"`
+	Accuracy float64  `json:"accuracy"`
+}
+
+// nearbyRadius es el radio en metros de NEARBY_RADIUS (1000 por defecto)
+func nearbyRadius() float64 {
+	radius := config.IntFromEnv("NEARBY_RADIUS", defaultNearbyRadius)
+	if radius <= 0 {
+		radius = defaultNearbyRadius
+	}
+	return float64(radius)
+}
+
+// locationCutoff marca desde cuándo una ubicación cuenta como actual (LOCATION_TTL, 15 min)
+func locationCutoff() time.Time {
+	return time.Now().Add(-config.DurationFromEnv("LOCATION_TTL", defaultLocationTTL))
+}
+
+// POST /me/location guarda la ubicación del usuario; DELETE /me/location la borra
+func MeLocation(w http.ResponseWriter, r *http.Request) {
+	if !requireDB(w) {
+		return
+	}
+	user, err := resolveUserFromRequest(r)
+	if err != nil {
+		response.WriteErr(w, http.StatusUnauthorized, "Token inválido o expirado")
+		return
+	}
+
+	if r.Method == http.MethodDelete {
+		if err := services.ClearLocation(config.DB, user.ID); err != nil {
+			log.Printf("[UBICACION] usuario=%d error borrando ubicación: %v", user.ID, err)
+			response.WriteErr(w, http.StatusInternalServerError, "No se pudo borrar la ubicación")
+			return
+		}
+		log.Printf("[UBICACION] usuario=%d dejó de compartir su ubicación", user.ID)
+		response.WriteJSON(w, http.StatusOK, map[string]any{"status": "deleted"})
+		return
+	}
+
+	var req locationRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1024)).Decode(&req); err != nil {
+		response.WriteErr(w, http.StatusBadRequest, "JSON inválido")
+		return
+	}
+	if req.Lat == nil || req.Lon == nil {
+		response.WriteErr(w, http.StatusBadRequest, "lat y lon son obligatorios")
+		return
+	}
+	if err := services.UpdateLocation(config.DB, user.ID, *req.Lat, *req.Lon, req.Accuracy); err != nil {
+		if errors.Is(err, services.ErrInvalidLocation) {
+			response.WriteErr(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		log.Printf("[UBICACION] usuario=%d error guardando ubicación: %v", user.ID, err)
+		response.WriteErr(w, http.StatusInternalServerError, "No se pudo guardar la ubicación")
+		return
+	}
+	response.WriteJSON(w, http.StatusOK, map[string]any{
+		"lat":      *req.Lat,
+		"lon":      *req.Lon,
+		"accuracy": req.Accuracy,
+	})
+}
+
+// GET /me/nearby?radius=metros devuelve quién del canal actual está cerca y los canales
+// públicos con gente cerca
+func MeNearby(w http.ResponseWriter, r *http.Request) {
+	if !requireDB(w) {
+		return
+	}
+	user, err := resolveUserFromRequest(r)
+	if err != nil {
+		response.WriteErr(w, http.StatusUnauthorized, "Token inválido o expirado")
+		return
+	}
+
+	radius := nearbyRadius()
+	if raw := r.URL.Query().Get("radius"); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n <= 0 || n > maxNearbyRadius {
+			response.WriteErr(w, http.StatusBadRequest, fmt.Sprintf("radius debe ser un número de metros entre 1 y %d", maxNearbyRadius))
+			return
+		}
+		radius = float64(n)
+	}
+
+	origin, err := services.FreshLocation(config.DB, user.ID, locationCutoff())
+	if err != nil {
+		response.WriteErr(w, http.StatusInternalServerError, "No se pudo leer la ubicación")
+		return
+	}
+	if origin == nil {
+		response.WriteErr(w, http.StatusConflict, "Comparte tu ubicación con POST /me/location")
+		return
+	}
+
+	members, suggestions, err := nearbyFor(user, origin, radius)
+	if err != nil {
+		log.Printf("[UBICACION] usuario=%d error buscando cercanos: %v", user.ID, err)
+		response.WriteErr(w, http.StatusInternalServerError, "No se pudo buscar quién está cerca")
+		return
+	}
+	response.WriteJSON(w, http.StatusOK, map[string]any{
+		"radius":   radius,
+		"channel":  user.GetCurrentChannelCode(),
+		"members":  members,
+		"channels": suggestions,
+	})
+}
+
+// nearbyFor busca los miembros cercanos del canal actual (si lo hay) y los canales
+// sugeridos por cercanía
+func nearbyFor(user *models.User, origin *models.UserLocation, radius float64) ([]services.NearbyUser, []services.ChannelSuggestion, error) {
+	since := locationCutoff()
+	channel := user.GetCurrentChannelCode()
+	members := []services.NearbyUser{}
+	if channel != "" {
+		found, err := services.NearbyUsers(config.DB, origin, channel, radius, since)
+		if err != nil {
+			return nil, nil, err
+		}
+		members = append(members, found...)
+	}
+	suggestions, err := services.NearbyChannels(config.DB, origin, channel, radius, since)
+	if err != nil {
+		return nil, nil, err
+	}
+	if suggestions == nil {
+		suggestions = []services.ChannelSuggestion{}
+	}
+	return members, suggestions, nil
+}
+
+// handleNearbyStage responde al comando de voz "¿quién está cerca?": en un canal dice
+// quién de él está cerca; fuera de canal sugiere canales con gente cerca
+func handleNearbyStage(w http.ResponseWriter, user *models.User, tracker *stageTimer) {
+	reply := func(status, message string, data map[string]any) {
+		response.WriteJSON(w, http.StatusOK, CommandResponse{
+			Status:  status,
+			Intent:  intentNearbyUsers,
+			Message: message,
+			Data:    data,
+		})
+	}
+	if config.DB == nil || !config.DBAvailable() {
+		reply("error", "No puedo consultar ubicaciones ahora", nil)
+		tracker.LogFinal("nearby_no_db")
+		return
+	}
+
+	origin, err := services.FreshLocation(config.DB, user.ID, locationCutoff())
+	if err != nil {
+		log.Printf("[UBICACION] usuario=%d error leyendo ubicación: %v", user.ID, err)
+	}
+	if origin == nil {
+		reply("error", "Comparte tu ubicación para saber quién está cerca", nil)
+		tracker.LogFinal("nearby_no_location")
+		return
+	}
+
+	radius := nearbyRadius()
+	members, suggestions, err := nearbyFor(user, origin, radius)
+	if err != nil {
+		log.Printf("[UBICACION] usuario=%d error buscando cercanos: %v", user.ID, err)
+		reply("error", "No pude buscar quién está cerca", nil)
+		tracker.LogFinal("nearby_error")
+		return
+	}
+	data := map[string]any{"radius": radius, "members": members, "channels": suggestions}
+
+	channel := user.GetCurrentChannelCode()
+	if channel != "" {
+		data["channel"] = channel
+		if len(members) == 0 {
+			reply("ok", fmt.Sprintf("No hay nadie del canal %s a menos de %s", channelLabel(channel), spokenDistance(radius)), data)
+		} else {
+			parts := make([]string, 0, len(members))
+			for _, m := range members {
+				parts = append(parts, fmt.Sprintf("%s a %s", m.Name, spokenDistance(m.DistanceMeters)))
+			}
+			reply("ok", fmt.Sprintf("Cerca de ti en el canal %s: %s", channelLabel(channel), strings.Join(parts, ", ")), data)
+		}
+		tracker.LogFinal("nearby_members")
+		return
+	}
+
+	if len(suggestions) == 0 {
+		reply("ok", fmt.Sprintf("No hay canales con gente a menos de %s", spokenDistance(radius)), data)
+	} else {
+		parts := make([]string, 0, len(suggestions))
+		for _, s := range suggestions {
+			name := s.Name
+			if name == "" {
+				name = channelLabel(s.Code)
+			}
+			people := "personas"
+			if s.NearbyUsers == 1 {
+				people = "persona"
+			}
+			parts = append(parts, fmt.Sprintf("%s con %d %s", name, s.NearbyUsers, people))
+		}
+		reply("ok", "Hay gente cerca en los canales "+strings.Join(parts, ", ")+". Pide conectarte a uno para unirte", data)
+	}
+	tracker.LogFinal("nearby_channels")
+}
+
+// spokenDistance da la distancia como se diría por radio: metros o kilómetros con coma
+func spokenDistance(meters float64) string {
+	if meters < 1000 {
+		return fmt.Sprintf("%.0f metros", meters)
+	}
+	km := strconv.FormatFloat(meters/1000, 'f', 1, 64)
+	km = strings.TrimSuffix(km, ".0")
+	if km == "1" {
+		return "1 kilómetro"
+	}
+	return strings.Replace(km, ".", ",", 1) + " kilómetros"
+}
@@ -0,0 +1,95 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"walkie-backend/internal/config"
+	"walkie-backend/internal/models"
+	"walkie-backend/internal/services"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMeLocationAndNearby(t *testing.T) {
+	cleanup := setupChannelsTestDB(t)
+	defer cleanup()
+	require.NoError(t, config.DB.AutoMigrate(&models.UserLocation{}))
+
+	obra := models.Channel{Code: "canal-4", Name: "Obra Norte", MaxUsers: 10}
+	bodega := models.Channel{Code: "canal-5", Name: "Bodega", MaxUsers: 10}
+	require.NoError(t, config.DB.Create(&obra).Error)
+	require.NoError(t, config.DB.Create(&bodega).Error)
+	ana := models.User{DisplayName: "Ana", AuthToken: "tok-loc-ana", IsActive: true, LastActiveAt: time.Now(), CurrentChannelID: &obra.ID, CurrentChannel: &obra}
+	luis := models.User{DisplayName: "Luis", AuthToken: "tok-loc-luis", IsActive: true, LastActiveAt: time.Now(), CurrentChannelID: &obra.ID}
+	marta := models.User{DisplayName: "Marta", AuthToken: "tok-loc-marta", IsActive: true, LastActiveAt: time.Now(), CurrentChannelID: &bodega.ID}
+	for _, u := range []*models.User{&ana, &luis, &marta} {
+		require.NoError(t, config.DB.Create(u).Error)
+	}
+
+	call := func(h http.HandlerFunc, method, path, token, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("X-Auth-Token", token)
+		rec := httptest.NewRecorder()
+		h(rec, req)
+		return rec
+	}
+
+	// Sin ubicación compartida no hay a quién comparar
+	assert.Equal(t, http.StatusConflict, call(MeNearby, http.MethodGet, "/me/nearby", "tok-loc-ana", "").Code)
+	assert.Equal(t, http.StatusBadRequest, call(MeLocation, http.MethodPost, "/me/location", "tok-loc-ana", `{"lat":40.4}`).Code)
+	assert.Equal(t, http.StatusBadRequest, call(MeLocation, http.MethodPost, "/me/location", "tok-loc-ana", `{"lat":120,"lon":0}`).Code)
+
+	for token, lat := range map[string]float64{"tok-loc-ana": 40.4168, "tok-loc-luis": 40.4178, "tok-loc-marta": 40.4188} {
+		rec := call(MeLocation, http.MethodPost, "/me/location", token, fmt.Sprintf(`{"lat":%g,"lon":-3.7038,"accuracy":8}`, lat))
+		require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	}
+
+	rec := call(MeNearby, http.MethodGet, "/me/nearby?radius=500", "tok-loc-ana", "")
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	var out struct {
+		Members  []services.NearbyUser        `json:"members"`
+		Channels []services.ChannelSuggestion `json:"channels"`
+	}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &out))
+	require.Len(t, out.Members, 1)
+	assert.Equal(t, "Luis", out.Members[0].Name)
+	require.Len(t, out.Channels, 1)
+	assert.Equal(t, "canal-5", out.Channels[0].Code)
+	assert.Equal(t, http.StatusBadRequest, call(MeNearby, http.MethodGet, "/me/nearby?radius=abc", "tok-loc-ana", "").Code)
+
+	// Por voz, en el canal: quién del canal está cerca
+	rec = httptest.NewRecorder()
+	handleNearbyStage(rec, &ana, newStageTimer(ana.ID))
+	var resp CommandResponse
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+	assert.Equal(t, intentNearbyUsers, resp.Intent)
+	assert.Equal(t, "Cerca de ti en el canal 4: Luis a 111 metros", resp.Message)
+
+	// Fuera de canal: canales con gente cerca
+	loner := models.User{Model: ana.Model, DisplayName: "Ana"}
+	rec = httptest.NewRecorder()
+	handleNearbyStage(rec, &loner, newStageTimer(ana.ID))
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+	assert.Equal(t, "Hay gente cerca en los canales Obra Norte con 1 persona, Bodega con 1 persona. Pide conectarte a uno para unirte", resp.Message)
+
+	rec = call(MeLocation, http.MethodDelete, "/me/location", "tok-loc-ana", "")
+	require.Equal(t, http.StatusOK, rec.Code)
+	rec = httptest.NewRecorder()
+	handleNearbyStage(rec, &ana, newStageTimer(ana.ID))
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+	assert.Equal(t, "error", resp.Status)
+	assert.Equal(t, "Comparte tu ubicación para saber quién está cerca", resp.Message)
+}
+
+func TestSpokenDistance(t *testing.T) {
+	assert.Equal(t, "250 metros", spokenDistance(250))
+	assert.Equal(t, "1 kilómetro", spokenDistance(1000))
+	assert.Equal(t, "2,5 kilómetros", spokenDistance(2500))
+}
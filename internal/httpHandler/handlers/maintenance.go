@@ -0,0 +1,73 @@
+package handlers
+
+import (
+	"log"
+	"sync"
+	"time"
+
+	"walkie-backend/internal/config"
+	"walkie-backend/internal/services"
+)
+
+const (
+	defaultMaintenanceInterval = time.Minute
+	defaultMembershipIdleAfter = 30 * time.Minute
+)
+
+var maintenanceOnce sync.Once
+
+// StartMaintenance arranca el mantenimiento periódico cada MAINTENANCE_INTERVAL (1 min):
+// purga audios viejos, caduca usuarios tras AUTH_TOKEN_TTL, saca de su canal a quien
+// lleve más de MEMBERSHIP_IDLE_AFTER (30 min) sin actividad y borra las confirmaciones de
+// escucha de más de AUDIO_RECEIPTS_TTL (7 días)
+func StartMaintenance() {
+	maintenanceOnce.Do(func() {
+		interval := config.DurationFromEnv("MAINTENANCE_INTERVAL", defaultMaintenanceInterval)
+		go func() {
+			ticker := time.NewTicker(interval)
+			defer ticker.Stop()
+			for range ticker.C {
+				runMaintenance()
+			}
+		}()
+		log.Printf("[MANTENIMIENTO] cada %s", interval)
+	})
+}
+
+// runMaintenance ejecuta una pasada; sin base de datos sólo se limpian la cola de audio y
+// el contexto de conversación
+func runMaintenance() {
+	cleanOldAudios()
+	pruneSessionContexts(time.Now())
+	pruneResumeBuffers(time.Now())
+
+	if config.DB == nil || !config.DBAvailable() {
+		return
+	}
+
+	expired, err := services.DeactivateExpiredUsers(config.DB, authTokenTTL())
+	if err != nil {
+		log.Printf("[MANTENIMIENTO] error caducando usuarios: %v", err)
+	} else if expired > 0 {
+		log.Printf("[MANTENIMIENTO] %d usuarios marcados inactivos", expired)
+	}
+
+	idle := config.DurationFromEnv("MEMBERSHIP_IDLE_AFTER", defaultMembershipIdleAfter)
+	disconnected, err := services.DisconnectIdleUsers(config.DB, idle)
+	if err != nil {
+		log.Printf("[MANTENIMIENTO] error desconectando usuarios inactivos: %v", err)
+	}
+	for _, userID := range disconnected {
+		presence.Move(userID, "")
+	}
+	if len(disconnected) > 0 {
+		log.Printf("[MANTENIMIENTO] %d usuarios desconectados por inactividad", len(disconnected))
+	}
+
+	receiptsTTL := config.DurationFromEnv("AUDIO_RECEIPTS_TTL", defaultReceiptsTTL)
+	if purged, err := services.PurgeReceipts(config.DB, time.Now().Add(-receiptsTTL)); err != nil {
+		log.Printf("[MANTENIMIENTO] error purgando confirmaciones de escucha: %v", err)
+	} else if purged > 0 {
+		log.Printf("[MANTENIMIENTO] %d confirmaciones de escucha purgadas", purged)
+	}
+}
@@ -0,0 +1,160 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"walkie-backend/internal/models"
+	"walkie-backend/internal/response"
+	"walkie-backend/internal/services"
+	"walkie-backend/pkg/lang"
+)
+
+const (
+	minDisplayNameLen = 2
+	maxDisplayNameLen = 50
+)
+
+type notificationPrefs struct {
+	Push       *bool `json:"push"`
+	DirectOnly *bool `json:"directOnly"`
+}
+
+type updateMeRequest struct {
+	DisplayName   *string            `json:"displayName"`
+	Language      *string            `json:"language"`
+	Notifications *notificationPrefs `json:"notifications"`
+	DoNotDisturb  *bool              `json:"doNotDisturb"`
+}
+
+type meProfile struct {
+	ID             uint   `json:"id"`
+	DisplayName    string `json:"displayName"`
+	CurrentChannel string `json:"currentChannel,omitempty"`
+	Language       string `json:"language"`
+	LastActiveAt   string `json:"lastActiveAt,omitempty"`
+	DoNotDisturb   bool   `json:"doNotDisturb"`
+	Notifications  struct {
+		Push       bool `json:"push"`
+		DirectOnly bool `json:"directOnly"`
+	} `json:"notifications"`
+}
+
+func newMeProfile(user *models.User) meProfile {
+	p := meProfile{
+		ID:             user.ID,
+		DisplayName:    user.DisplayName,
+		CurrentChannel: user.GetCurrentChannelCode(),
+		Language:       user.GetLanguage(),
+	}
+	if !user.LastActiveAt.IsZero() {
+		p.LastActiveAt = user.LastActiveAt.UTC().Format(time.RFC3339)
+	}
+	p.Notifications.Push = !user.PushMuted
+	p.Notifications.DirectOnly = user.PushDirectOnly
+	p.DoNotDisturb = user.DoNotDisturb
+	return p
+}
+
+// MeProfile: GET /me devuelve el perfil del usuario autenticado
+func MeProfile(w http.ResponseWriter, r *http.Request) {
+	if !requireDB(w) {
+		return
+	}
+	user, err := resolveUserFromRequest(r)
+	if err != nil {
+		response.WriteErr(w, http.StatusUnauthorized, "Token inválido o expirado")
+		return
+	}
+	fresh, err := services.NewUserService().GetUserWithChannel(user.ID)
+	if err != nil {
+		response.WriteErr(w, http.StatusInternalServerError, "No se pudo leer el perfil")
+		return
+	}
+	response.WriteJSON(w, http.StatusOK, newMeProfile(fresh))
+}
+
+// Me: PATCH /me actualiza el nombre, el idioma, las notificaciones y el modo no molestar
+// del usuario autenticado
+func Me(w http.ResponseWriter, r *http.Request) {
+	if !requireDB(w) {
+		return
+	}
+
+	user, err := resolveUserFromRequest(r)
+	if err != nil {
+		response.WriteErr(w, http.StatusUnauthorized, "Token inválido o expirado")
+		return
+	}
+
+	var req updateMeRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 4096)).Decode(&req); err != nil {
+		response.WriteErr(w, http.StatusBadRequest, "JSON inválido")
+		return
+	}
+
+	update, msg := req.toProfileUpdate()
+	if msg != "" {
+		response.WriteErr(w, http.StatusBadRequest, msg)
+		return
+	}
+
+	svc := services.NewUserService()
+	if err := svc.UpdateProfile(user.ID, update); err != nil {
+		if errors.Is(err, services.ErrDisplayNameTaken) {
+			response.WriteErr(w, http.StatusConflict, "Ese nombre ya está en uso")
+			return
+		}
+		log.Printf("[PERFIL] usuario=%d error actualizando perfil: %v", user.ID, err)
+		response.WriteErr(w, http.StatusInternalServerError, "No se pudo actualizar el perfil")
+		return
+	}
+
+	fresh, err := svc.GetUserWithChannel(user.ID)
+	if err != nil {
+		response.WriteErr(w, http.StatusInternalServerError, "No se pudo leer el perfil")
+		return
+	}
+	if update.DisplayName != nil {
+		presence.Touch(fresh.ID, fresh.DisplayName, fresh.GetCurrentChannelCode())
+	}
+
+	log.Printf("[PERFIL] usuario=%d nombre=%q idioma=%s push=%t solo_directos=%t no_molestar=%t", fresh.ID, fresh.DisplayName, fresh.GetLanguage(), !fresh.PushMuted, fresh.PushDirectOnly, fresh.DoNotDisturb)
+	response.WriteJSON(w, http.StatusOK, newMeProfile(fresh))
+}
+
+// toProfileUpdate valida la petición; devuelve el mensaje de error si no es válida
+func (req updateMeRequest) toProfileUpdate() (services.ProfileUpdate, string) {
+	var update services.ProfileUpdate
+	if req.DisplayName != nil {
+		name := strings.Join(strings.Fields(*req.DisplayName), " ")
+		if n := utf8.RuneCountInString(name); n < minDisplayNameLen || n > maxDisplayNameLen {
+			return update, "displayName debe tener entre 2 y 50 caracteres"
+		}
+		update.DisplayName = &name
+	}
+	if req.Language != nil {
+		language, ok := lang.Normalize(*req.Language)
+		if !ok {
+			return update, "language debe ser " + strings.Join(lang.Supported(), " o ")
+		}
+		update.Language = &language
+	}
+	if req.Notifications != nil {
+		if req.Notifications.Push != nil {
+			muted := !*req.Notifications.Push
+			update.PushMuted = &muted
+		}
+		update.PushDirectOnly = req.Notifications.DirectOnly
+	}
+	update.DoNotDisturb = req.DoNotDisturb
+	if update == (services.ProfileUpdate{}) {
+		return update, "No hay nada que actualizar"
+	}
+	return update, ""
+}
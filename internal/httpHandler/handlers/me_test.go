@@ -0,0 +1,114 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"walkie-backend/internal/ai"
+	"walkie-backend/internal/models"
+	"walkie-backend/pkg/lang"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+func TestMe_UpdatesLanguage(t *testing.T) {
+	db := setupTestDB(t)
+	user := createTestUser(t, db, 171, "token-me", "")
+
+	patch := func(body string) int {
+		req := httptest.NewRequest(http.MethodPatch, "/me", strings.NewReader(body))
+		req = req.WithContext(withAuthUser(req.Context(), user))
+		rec := httptest.NewRecorder()
+		Me(rec, req)
+		return rec.Code
+	}
+
+	var stored models.User
+	require.NoError(t, db.First(&stored, user.ID).Error)
+	assert.Equal(t, "es", stored.GetLanguage())
+
+	assert.Equal(t, http.StatusOK, patch(`{"language":"en-US"}`))
+	require.NoError(t, db.First(&stored, user.ID).Error)
+	assert.Equal(t, "en", stored.Language)
+
+	assert.Equal(t, http.StatusBadRequest, patch(`{"language":"fr"}`))
+	assert.Equal(t, http.StatusBadRequest, patch(`{}`))
+	assert.Equal(t, http.StatusBadRequest, patch(`no json`))
+}
+
+func TestMe_ProfileReadAndUpdate(t *testing.T) {
+	db := setupTestDB(t)
+	user := createTestUser(t, db, 665, "token-perfil", "canal-1")
+	other := models.User{Model: gorm.Model{ID: 666}, DisplayName: "Marta", IsActive: true}
+	require.NoError(t, db.Create(&other).Error)
+
+	call := func(method, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, "/me", strings.NewReader(body))
+		req = req.WithContext(withAuthUser(req.Context(), user))
+		rec := httptest.NewRecorder()
+		if method == http.MethodGet {
+			MeProfile(rec, req)
+		} else {
+			Me(rec, req)
+		}
+		return rec
+	}
+
+	rec := call(http.MethodGet, "")
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	var profile meProfile
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &profile))
+	assert.Equal(t, "canal-1", profile.CurrentChannel)
+	assert.Equal(t, "es", profile.Language)
+	assert.True(t, profile.Notifications.Push)
+
+	rec = call(http.MethodPatch, `{"displayName":"  Capitán   Ruiz ","notifications":{"push":false,"directOnly":true}}`)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &profile))
+	assert.Equal(t, "Capitán Ruiz", profile.DisplayName)
+	assert.False(t, profile.Notifications.Push)
+	assert.True(t, profile.Notifications.DirectOnly)
+
+	var stored models.User
+	require.NoError(t, db.First(&stored, user.ID).Error)
+	assert.True(t, stored.PushMuted)
+	assert.True(t, stored.PushDirectOnly)
+
+	assert.Equal(t, http.StatusConflict, call(http.MethodPatch, `{"displayName":"`+strings.ToUpper(other.DisplayName)+`"}`).Code)
+	assert.Equal(t, http.StatusBadRequest, call(http.MethodPatch, `{"displayName":"x"}`).Code)
+	assert.Equal(t, http.StatusBadRequest, call(http.MethodPatch, `{"notifications":{}}`).Code)
+}
+
+type languageSTT struct{ language string }
+
+func (s *languageSTT) TranscribeAudio(ctx context.Context, _ []byte, _ string) (string, error) {
+	s.language = lang.FromContext(ctx)
+	return "hello everyone", nil
+}
+
+func TestRunAudioIngest_PassesUserLanguageToSTT(t *testing.T) {
+	setupTestDB(t)
+	user := &models.User{Model: gorm.Model{ID: 172}, DisplayName: "john", Language: "en"}
+	stt := &languageSTT{}
+
+	deps := newAudioIngestDeps()
+	deps.readUserID = func(*http.Request) (uint, error) { return user.ID, nil }
+	deps.newUserService = func() userService { return &mockUserService{user: user} }
+	deps.localSTT = func() sttClient { return nil }
+	deps.ensureSTT = func() (sttClient, error) { return stt, nil }
+	deps.ensureAI = func() (ai.Analyzer, error) {
+		return &mockQwen{result: ai.CommandResult{Intent: "conversation"}}, nil
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "/audio/ingest", strings.NewReader(string(buildTestWAV(3200))))
+	req.Header.Set("Content-Type", "audio/wav")
+	runAudioIngest(httptest.NewRecorder(), req, deps)
+
+	assert.Equal(t, lang.English, stt.language)
+}
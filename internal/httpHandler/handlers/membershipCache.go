@@ -0,0 +1,79 @@
+package handlers
+
+import (
+	"sync"
+	"time"
+
+	"walkie-backend/internal/config"
+	"walkie-backend/internal/metrics"
+)
+
+const defaultMembershipCacheTTL = 5 * time.Second
+
+// membershipCache guarda el canal actual de cada usuario durante unos segundos para que el
+// sondeo de audio no consulte la base de datos por cada clip. Los cambios hechos en esta
+// réplica lo actualizan al momento; los de otras réplicas se ven al caducar.
+var membershipCache = struct {
+	sync.Mutex
+	byUser map[uint]membershipEntry
+	ttl    time.Duration
+	once   sync.Once
+}{
+	byUser: make(map[uint]membershipEntry),
+}
+
+type membershipEntry struct {
+	channel   string
+	expiresAt time.Time
+}
+
+func membershipCacheTTL() time.Duration {
+	membershipCache.once.Do(func() {
+		membershipCache.ttl = config.DurationFromEnv("MEMBERSHIP_CACHE_TTL", defaultMembershipCacheTTL)
+	})
+	return membershipCache.ttl
+}
+
+// rememberMembership anota el canal actual del usuario ("" si no está en ninguno)
+func rememberMembership(userID uint, channel string) {
+	ttl := membershipCacheTTL()
+	membershipCache.Lock()
+	membershipCache.byUser[userID] = membershipEntry{channel: channel, expiresAt: time.Now().Add(ttl)}
+	membershipCache.Unlock()
+}
+
+// forgetMembership descarta el canal guardado para el usuario
+func forgetMembership(userID uint) {
+	membershipCache.Lock()
+	delete(membershipCache.byUser, userID)
+	membershipCache.Unlock()
+}
+
+// currentChannelFor devuelve el canal actual del usuario, de la caché si está vigente o de
+// la base de datos si no
+func currentChannelFor(userID uint, svc userService) (string, error) {
+	membershipCache.Lock()
+	entry, ok := membershipCache.byUser[userID]
+	membershipCache.Unlock()
+	if ok && time.Now().Before(entry.expiresAt) {
+		metrics.Inc("walkie_membership_cache_total", map[string]string{"result": "hit"})
+		return entry.channel, nil
+	}
+	metrics.Inc("walkie_membership_cache_total", map[string]string{"result": "miss"})
+	return refreshMembership(userID, svc)
+}
+
+// refreshMembership lee el canal actual de la base de datos y lo guarda en la caché
+func refreshMembership(userID uint, svc userService) (string, error) {
+	current, err := svc.GetUserWithChannel(userID)
+	if err != nil {
+		forgetMembership(userID)
+		return "", err
+	}
+	channel := ""
+	if current.CurrentChannel != nil {
+		channel = current.CurrentChannel.Code
+	}
+	rememberMembership(userID, channel)
+	return channel, nil
+}
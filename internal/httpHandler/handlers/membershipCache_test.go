@@ -0,0 +1,62 @@
+package handlers
+
+import (
+	"testing"
+
+	"walkie-backend/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+// countingUserService cuenta las consultas del canal actual
+type countingUserService struct {
+	*mockUserService
+	lookups int
+}
+
+func (c *countingUserService) GetUserWithChannel(id uint) (*models.User, error) {
+	c.lookups++
+	return c.mockUserService.GetUserWithChannel(id)
+}
+
+func TestNextDeliverableAudio_UsesMembershipCache(t *testing.T) {
+	const userID = 682
+	t.Cleanup(func() { forgetMembership(userID) })
+	user := &models.User{Model: gorm.Model{ID: userID}, CurrentChannel: &models.Channel{Code: "canal-1"}}
+	svc := &countingUserService{mockUserService: &mockUserService{user: user}}
+
+	queue := []*PendingAudio{{Channel: "canal-1"}, {Channel: "canal-1"}, {Channel: "canal-1"}}
+	dequeue := func(uint) *PendingAudio {
+		if len(queue) == 0 {
+			return nil
+		}
+		next := queue[0]
+		queue = queue[1:]
+		return next
+	}
+
+	for i := 0; i < 3; i++ {
+		require.NotNil(t, nextDeliverableAudio(userID, svc, dequeue, "test"))
+	}
+	assert.Equal(t, 1, svc.lookups, "sólo la primera entrega consulta la base de datos")
+
+	// Un clip de otro canal se confirma con la base de datos antes de descartarlo
+	queue = []*PendingAudio{{Channel: "canal-9"}}
+	assert.Nil(t, nextDeliverableAudio(userID, svc, dequeue, "test"))
+	assert.Equal(t, 2, svc.lookups)
+
+	// Si el usuario cambió de canal en otra réplica, el clip nuevo no se pierde
+	user.CurrentChannel = &models.Channel{Code: "canal-9"}
+	queue = []*PendingAudio{{Channel: "canal-9"}}
+	require.NotNil(t, nextDeliverableAudio(userID, svc, dequeue, "test"))
+	assert.Equal(t, 3, svc.lookups)
+
+	// Los cambios de esta réplica actualizan la caché sin consultar
+	moveClientToChannel(userID, "canal-5")
+	channel, err := currentChannelFor(userID, svc)
+	require.NoError(t, err)
+	assert.Equal(t, "canal-5", channel)
+	assert.Equal(t, 3, svc.lookups)
+}
@@ -0,0 +1,282 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"walkie-backend/internal/config"
+	"walkie-backend/internal/metrics"
+	"walkie-backend/internal/models"
+	"walkie-backend/internal/response"
+	"walkie-backend/internal/services"
+)
+
+const defaultModerationReload = time.Minute
+
+// compiledRule es una regla lista para evaluar
+type compiledRule struct {
+	id      uint
+	pattern string
+	re      *regexp.Regexp
+	action  string
+}
+
+func (c compiledRule) matches(normalized string) bool {
+	if c.re != nil {
+		return c.re.MatchString(normalized)
+	}
+	return strings.Contains(normalized, c.pattern)
+}
+
+var moderationState = struct {
+	sync.RWMutex
+	once  sync.Once
+	rules []compiledRule
+}{
+	rules: defaultModerationRules(),
+}
+
+// defaultModerationRules son las frases por defecto; se usan hasta que se cargan las
+// de la base de datos, o si no hay base de datos
+func defaultModerationRules() []compiledRule {
+	rules := make([]compiledRule, 0, len(models.DefaultModerationPhrases))
+	for _, phrase := range models.DefaultModerationPhrases {
+		rules = append(rules, compiledRule{pattern: models.NormalizeModerationText(phrase), action: models.ModerationActionBlock})
+	}
+	return rules
+}
+
+func compileModerationRules(stored []models.ModerationRule) []compiledRule {
+	rules := make([]compiledRule, 0, len(stored))
+	for _, r := range stored {
+		rule := compiledRule{id: r.ID, pattern: r.Pattern, action: r.Action}
+		if r.IsRegex {
+			re, err := regexp.Compile(r.Pattern)
+			if err != nil {
+				log.Printf("[MODERACION] regla %d con expresión inválida, se ignora: %v", r.ID, err)
+				continue
+			}
+			rule.re = re
+		}
+		rules = append(rules, rule)
+	}
+	return rules
+}
+
+func setModerationRules(rules []compiledRule) {
+	moderationState.Lock()
+	moderationState.rules = rules
+	moderationState.Unlock()
+}
+
+// reloadModerationRules carga las reglas de la base de datos
+func reloadModerationRules() error {
+	if config.DB == nil || !config.DBAvailable() {
+		return config.ErrDBUnavailable
+	}
+	stored, err := services.NewModerationRuleService(config.DB).List()
+	if err != nil {
+		return err
+	}
+	setModerationRules(compileModerationRules(stored))
+	return nil
+}
+
+// StartModerationReloader carga las reglas al arrancar y las recarga cada
+// MODERATION_RULES_RELOAD (1 min por defecto) para recoger cambios de otras instancias
+func StartModerationReloader() {
+	moderationState.once.Do(func() {
+		if err := reloadModerationRules(); err != nil {
+			log.Printf("[MODERACION] no se pudieron cargar las reglas, se usan las de serie: %v", err)
+		}
+		interval := config.DurationFromEnv("MODERATION_RULES_RELOAD", defaultModerationReload)
+		go func() {
+			ticker := time.NewTicker(interval)
+			defer ticker.Stop()
+			for range ticker.C {
+				if err := reloadModerationRules(); err != nil && !errors.Is(err, config.ErrDBUnavailable) {
+					log.Printf("[MODERACION] error recargando reglas: %v", err)
+				}
+			}
+		}()
+	})
+}
+
+// moderationVerdict resume qué reglas saltaron con un texto
+type moderationVerdict struct {
+	blocked bool
+	matched []compiledRule
+}
+
+func moderateText(text string) moderationVerdict {
+	normalized := models.NormalizeModerationText(text)
+	moderationState.RLock()
+	rules := moderationState.rules
+	moderationState.RUnlock()
+
+	var v moderationVerdict
+	for _, rule := range rules {
+		if !rule.matches(normalized) {
+			continue
+		}
+		v.matched = append(v.matched, rule)
+		if rule.action == models.ModerationActionBlock {
+			v.blocked = true
+		}
+	}
+	return v
+}
+
+// moderationStage aplica las reglas a la transcripción: las de bloqueo cortan la
+// petición, las de marca dejan una entrada de auditoría y las de log sólo se registran
+func moderationStage(w http.ResponseWriter, user *models.User, text string, tracker *stageTimer) bool {
+	v := moderateText(text)
+	for _, rule := range v.matched {
+		metrics.Inc("walkie_moderation_matches_total", map[string]string{"action": rule.action})
+		log.Printf("[MODERACION] usuario=%d regla=%d accion=%s texto=%q", user.ID, rule.id, rule.action, text)
+		if rule.action == models.ModerationActionFlag {
+			userID := user.ID
+			services.RecordAudit(nil, models.AuditEntry{
+				Actor:   fmt.Sprintf("user:%d", user.ID),
+				Action:  "moderation_flag",
+				UserID:  &userID,
+				Channel: user.GetCurrentChannelCode(),
+				Details: fmt.Sprintf("regla=%d texto=%q", rule.id, text),
+				Source:  models.EventSourceVoice,
+			})
+		}
+	}
+	if !v.blocked {
+		return true
+	}
+	tracker.LogFinal("prompt_injection_detected")
+	writeUnintelligibleResponse(w)
+	return false
+}
+
+type adminModerationRuleView struct {
+	ID          uint   `json:"id"`
+	Pattern     string `json:"pattern"`
+	Regex       bool   `json:"regex"`
+	Action      string `json:"action"`
+	Description string `json:"description,omitempty"`
+}
+
+func toAdminModerationRuleView(r *models.ModerationRule) adminModerationRuleView {
+	return adminModerationRuleView{ID: r.ID, Pattern: r.Pattern, Regex: r.IsRegex, Action: r.Action, Description: r.Description}
+}
+
+// GET /admin/moderation-rules lista las reglas; POST /admin/moderation-rules crea una
+func AdminModerationRules(w http.ResponseWriter, r *http.Request) {
+	if !requireAdmin(w, r) || !requireDB(w) {
+		return
+	}
+
+	svc := services.NewModerationRuleService(config.DB)
+	if r.Method == http.MethodGet {
+		rules, err := svc.List()
+		if err != nil {
+			response.WriteErr(w, http.StatusInternalServerError, "No se pudieron obtener las reglas")
+			return
+		}
+		out := make([]adminModerationRuleView, 0, len(rules))
+		for i := range rules {
+			out = append(out, toAdminModerationRuleView(&rules[i]))
+		}
+		response.WriteJSON(w, http.StatusOK, out)
+		return
+	}
+
+	in, ok := readModerationRuleInput(w, r)
+	if !ok {
+		return
+	}
+	rule, err := svc.Create(in)
+	if err != nil {
+		writeModerationRuleError(w, err)
+		return
+	}
+	afterModerationRuleChange(r, "moderation_rule_create", rule)
+	response.WriteJSON(w, http.StatusCreated, toAdminModerationRuleView(rule))
+}
+
+// PUT /admin/moderation-rules/{id} modifica una regla; DELETE la borra
+func AdminModerationRule(w http.ResponseWriter, r *http.Request) {
+	if !requireAdmin(w, r) || !requireDB(w) {
+		return
+	}
+
+	id, err := strconv.ParseUint(r.PathValue("id"), 10, 64)
+	if err != nil || id == 0 {
+		response.WriteErr(w, http.StatusBadRequest, "ID de regla inválido")
+		return
+	}
+
+	svc := services.NewModerationRuleService(config.DB)
+	if r.Method == http.MethodPut {
+		in, ok := readModerationRuleInput(w, r)
+		if !ok {
+			return
+		}
+		rule, err := svc.Update(uint(id), in)
+		if err != nil {
+			writeModerationRuleError(w, err)
+			return
+		}
+		afterModerationRuleChange(r, "moderation_rule_update", rule)
+		response.WriteJSON(w, http.StatusOK, toAdminModerationRuleView(rule))
+		return
+	}
+
+	if err := svc.Delete(uint(id)); err != nil {
+		writeModerationRuleError(w, err)
+		return
+	}
+	afterModerationRuleChange(r, "moderation_rule_delete", &models.ModerationRule{ID: uint(id)})
+	response.WriteJSON(w, http.StatusOK, map[string]any{"status": "deleted", "id": id})
+}
+
+// afterModerationRuleChange audita el cambio y recarga las reglas en esta instancia
+func afterModerationRuleChange(r *http.Request, action string, rule *models.ModerationRule) {
+	services.RecordAudit(nil, models.AuditEntry{
+		Actor:   adminActor(r),
+		Action:  action,
+		Details: fmt.Sprintf("id=%d pattern=%q regex=%t action=%s", rule.ID, rule.Pattern, rule.IsRegex, rule.Action),
+		Source:  models.EventSourceHTTP,
+	})
+	if err := reloadModerationRules(); err != nil {
+		log.Printf("[MODERACION] error recargando reglas: %v", err)
+	}
+}
+
+func readModerationRuleInput(w http.ResponseWriter, r *http.Request) (services.ModerationRuleInput, bool) {
+	var in services.ModerationRuleInput
+	body, err := io.ReadAll(io.LimitReader(r.Body, 16<<10))
+	if err != nil || json.Unmarshal(body, &in) != nil {
+		response.WriteErr(w, http.StatusBadRequest, "JSON inválido")
+		return in, false
+	}
+	return in, true
+}
+
+func writeModerationRuleError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, services.ErrModerationRuleNotFound):
+		response.WriteErr(w, http.StatusNotFound, "Regla no encontrada")
+	case errors.Is(err, services.ErrModerationRuleExists):
+		response.WriteErr(w, http.StatusConflict, err.Error())
+	case errors.Is(err, services.ErrInvalidModerationRule):
+		response.WriteErr(w, http.StatusBadRequest, err.Error())
+	default:
+		response.WriteErr(w, http.StatusInternalServerError, "No se pudo modificar la regla")
+	}
+}
@@ -0,0 +1,252 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"walkie-backend/internal/config"
+	"walkie-backend/internal/metrics"
+	"walkie-backend/internal/models"
+	"walkie-backend/internal/response"
+	"walkie-backend/pkg/fcm"
+
+	"gorm.io/gorm/clause"
+)
+
+const (
+	// pollOnlineWindow considera conectado a quien hizo polling hace menos de este tiempo
+	pollOnlineWindow = 30 * time.Second
+	// pushCooldown evita enviar una notificación por cada clip; las emergencias no esperan
+	pushCooldown = 30 * time.Second
+	pushTimeout  = 10 * time.Second
+	maxFCMToken  = 512
+)
+
+type pushSender interface {
+	Send(ctx context.Context, deviceToken string, data map[string]string) error
+}
+
+var pushState = struct {
+	sync.Mutex
+	once     sync.Once
+	sender   pushSender
+	lastPoll map[uint]time.Time
+	lastPush map[uint]time.Time
+}{
+	lastPoll: make(map[uint]time.Time),
+	lastPush: make(map[uint]time.Time),
+}
+
+// pushClient crea una vez el cliente de FCM; sin credenciales las notificaciones quedan desactivadas
+func pushClient() pushSender {
+	pushState.once.Do(func() {
+		client, err := fcm.NewClientFromEnv()
+		if err != nil {
+			if !errors.Is(err, fcm.ErrNotConfigured) {
+				log.Printf("[PUSH] no se pudo crear el cliente FCM: %v", err)
+			} else {
+				log.Printf("[PUSH] FCM sin configurar, notificaciones push desactivadas")
+			}
+			return
+		}
+		pushState.Lock()
+		pushState.sender = client
+		pushState.Unlock()
+	})
+
+	pushState.Lock()
+	defer pushState.Unlock()
+	return pushState.sender
+}
+
+// setPushSender reemplaza el cliente de notificaciones (nil las desactiva)
+func setPushSender(s pushSender) {
+	pushState.once.Do(func() {})
+	pushState.Lock()
+	pushState.sender = s
+	pushState.lastPush = make(map[uint]time.Time)
+	pushState.Unlock()
+}
+
+// markPolled registra que el usuario está consultando /audio/poll
+func markPolled(userID uint) {
+	pushState.Lock()
+	pushState.lastPoll[userID] = time.Now()
+	pushState.Unlock()
+}
+
+// isUserOnline indica si el usuario recibirá el audio sin push: WebSocket, stream
+// abierto o polling reciente
+func isUserOnline(userID uint, now time.Time) bool {
+	registry.RLock()
+	_, onWS := registry.byUser[userID]
+	registry.RUnlock()
+	if onWS {
+		return true
+	}
+
+	audioWaiters.Lock()
+	streaming := len(audioWaiters.byUser[userID]) > 0
+	audioWaiters.Unlock()
+	if streaming {
+		return true
+	}
+
+	pushState.Lock()
+	defer pushState.Unlock()
+	last, ok := pushState.lastPoll[userID]
+	return ok && now.Sub(last) < pollOnlineWindow
+}
+
+// allowPush aplica el cooldown por usuario y lo reinicia si se envía
+func allowPush(userID uint, priority string, now time.Time) bool {
+	pushState.Lock()
+	defer pushState.Unlock()
+	if last, ok := pushState.lastPush[userID]; ok && now.Sub(last) < pushCooldown && priority != PriorityEmergency {
+		return false
+	}
+	pushState.lastPush[userID] = now
+	return true
+}
+
+// notifyPendingAudio avisa por push al destinatario si no está conectado
+func notifyPendingAudio(recipientID uint, audio *PendingAudio) {
+	if pushClient() == nil || !config.DBAvailable() {
+		return
+	}
+	now := time.Now()
+	if isUserOnline(recipientID, now) || !allowPush(recipientID, audio.Priority, now) {
+		return
+	}
+	go sendPendingAudioPush(recipientID, audio)
+}
+
+// sendPendingAudioPush envía la notificación a todos los dispositivos del usuario y
+// borra los tokens que FCM ya no reconoce
+func sendPendingAudioPush(recipientID uint, audio *PendingAudio) {
+	sender := pushClient()
+	if sender == nil {
+		return
+	}
+
+	var recipient models.User
+	if err := config.DB.Select("id", "push_muted", "push_direct_only", "do_not_disturb").First(&recipient, recipientID).Error; err == nil && !wantsPush(&recipient, audio) {
+		metrics.Inc("walkie_push_skipped_total", map[string]string{"reason": "preferences"})
+		return
+	}
+
+	var devices []models.Device
+	if err := config.DB.Where("user_id = ?", recipientID).Find(&devices).Error; err != nil {
+		log.Printf("[PUSH] error leyendo dispositivos de usuario %d: %v", recipientID, err)
+		return
+	}
+	if len(devices) == 0 {
+		return
+	}
+
+	var from models.User
+	_ = config.DB.Select("id", "display_name").First(&from, audio.SenderID).Error
+
+	data := map[string]string{
+		"type":        "audio_pending",
+		"sender_id":   strconv.FormatUint(uint64(audio.SenderID), 10),
+		"sender_name": from.DisplayName,
+		"channel":     audio.Channel,
+		"priority":    audio.Priority,
+		"direct":      strconv.FormatBool(audio.Direct),
+		"timestamp":   audio.Timestamp.UTC().Format(time.RFC3339),
+	}
+
+	for _, device := range devices {
+		ctx, cancel := context.WithTimeout(context.Background(), pushTimeout)
+		err := sender.Send(ctx, device.Token, data)
+		cancel()
+
+		switch {
+		case errors.Is(err, fcm.ErrUnregistered):
+			log.Printf("[PUSH] token caducado, borrando dispositivo %d de usuario %d", device.ID, recipientID)
+			config.DB.Delete(&models.Device{}, device.ID)
+			metrics.Inc("walkie_push_failed_total", map[string]string{"reason": "unregistered"})
+		case err != nil:
+			log.Printf("[PUSH] error enviando a dispositivo %d de usuario %d: %v", device.ID, recipientID, err)
+			metrics.Inc("walkie_push_failed_total", map[string]string{"reason": "error"})
+		default:
+			metrics.Inc("walkie_push_sent_total", map[string]string{"platform": device.Platform})
+		}
+	}
+}
+
+// wantsPush aplica las preferencias de PATCH /me y el modo no molestar; las emergencias
+// siempre se notifican
+func wantsPush(user *models.User, audio *PendingAudio) bool {
+	if audio.Priority == PriorityEmergency {
+		return true
+	}
+	if user.PushMuted || user.DoNotDisturb {
+		return false
+	}
+	return !user.PushDirectOnly || audio.Direct
+}
+
+type registerDeviceRequest struct {
+	Token    string `json:"token"`
+	Platform string `json:"platform"`
+}
+
+// RegisterDevice: POST /devices guarda el token FCM del dispositivo del usuario.
+// Si el token ya existía pasa al usuario actual (p. ej. tras cambiar de cuenta).
+func RegisterDevice(w http.ResponseWriter, r *http.Request) {
+	if !requireDB(w) {
+		return
+	}
+
+	user, err := resolveUserFromRequest(r)
+	if err != nil {
+		response.WriteErr(w, http.StatusUnauthorized, "Token inválido o expirado")
+		return
+	}
+
+	var req registerDeviceRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 4096)).Decode(&req); err != nil {
+		response.WriteErr(w, http.StatusBadRequest, "JSON inválido")
+		return
+	}
+	req.Token = strings.TrimSpace(req.Token)
+	req.Platform = strings.ToLower(strings.TrimSpace(req.Platform))
+	if req.Token == "" || len(req.Token) > maxFCMToken {
+		response.WriteErr(w, http.StatusBadRequest, "token requerido")
+		return
+	}
+	switch req.Platform {
+	case "":
+		req.Platform = models.DevicePlatformAndroid
+	case models.DevicePlatformAndroid, models.DevicePlatformIOS, models.DevicePlatformWeb:
+	default:
+		response.WriteErr(w, http.StatusBadRequest, "platform debe ser android, ios o web")
+		return
+	}
+
+	device := models.Device{UserID: user.ID, Token: req.Token, Platform: req.Platform}
+	err = config.DB.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "token"}},
+		DoUpdates: clause.AssignmentColumns([]string{"user_id", "platform", "updated_at"}),
+	}).Create(&device).Error
+	if err != nil {
+		log.Printf("[PUSH] error registrando dispositivo de usuario %d: %v", user.ID, err)
+		response.WriteErr(w, http.StatusInternalServerError, "No se pudo registrar el dispositivo")
+		return
+	}
+
+	log.Printf("[PUSH] usuario=%d registra dispositivo plataforma=%s", user.ID, req.Platform)
+	response.WriteJSON(w, http.StatusCreated, map[string]any{
+		"platform": device.Platform,
+		"status":   "registered",
+	})
+}
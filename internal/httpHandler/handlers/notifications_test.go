@@ -0,0 +1,123 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"walkie-backend/internal/models"
+	"walkie-backend/pkg/fcm"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+type fakePush struct {
+	mu    sync.Mutex
+	sent  map[string]map[string]string
+	stale map[string]bool
+}
+
+func (f *fakePush) Send(_ context.Context, token string, data map[string]string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.stale[token] {
+		return fcm.ErrUnregistered
+	}
+	if f.sent == nil {
+		f.sent = make(map[string]map[string]string)
+	}
+	f.sent[token] = data
+	return nil
+}
+
+func setupDeviceTestDB(t *testing.T) *gorm.DB {
+	db := setupTestDB(t)
+	require.NoError(t, db.AutoMigrate(&models.Device{}))
+	return db
+}
+
+func registerDevice(t *testing.T, user *models.User, body string) int {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodPost, "/devices", strings.NewReader(body))
+	req = req.WithContext(withAuthUser(req.Context(), user))
+	rec := httptest.NewRecorder()
+	RegisterDevice(rec, req)
+	return rec.Code
+}
+
+func TestRegisterDevice_UpsertsByToken(t *testing.T) {
+	db := setupDeviceTestDB(t)
+	first := &models.User{Model: gorm.Model{ID: 111}}
+	second := &models.User{Model: gorm.Model{ID: 112}}
+
+	assert.Equal(t, http.StatusCreated, registerDevice(t, first, `{"token":"fcm-abc","platform":"ios"}`))
+	assert.Equal(t, http.StatusCreated, registerDevice(t, second, `{"token":"fcm-abc"}`))
+	assert.Equal(t, http.StatusBadRequest, registerDevice(t, first, `{"token":""}`))
+	assert.Equal(t, http.StatusBadRequest, registerDevice(t, first, `{"token":"x","platform":"symbian"}`))
+
+	var devices []models.Device
+	require.NoError(t, db.Find(&devices).Error)
+	require.Len(t, devices, 1)
+	assert.Equal(t, uint(112), devices[0].UserID)
+	assert.Equal(t, models.DevicePlatformAndroid, devices[0].Platform)
+}
+
+func TestSendPendingAudioPush_SendsMetadataAndDropsStaleTokens(t *testing.T) {
+	db := setupDeviceTestDB(t)
+	require.NoError(t, db.Create(&models.User{Model: gorm.Model{ID: 113}, DisplayName: "ana", IsActive: true}).Error)
+	require.NoError(t, db.Create(&models.Device{UserID: 114, Token: "vivo", Platform: "android"}).Error)
+	require.NoError(t, db.Create(&models.Device{UserID: 114, Token: "caducado", Platform: "ios"}).Error)
+
+	push := &fakePush{stale: map[string]bool{"caducado": true}}
+	setPushSender(push)
+	defer setPushSender(nil)
+
+	sendPendingAudioPush(114, newPendingAudio(113, "canal-2", buildTestWAV(320), 1, PriorityUrgent))
+
+	data := push.sent["vivo"]
+	require.NotNil(t, data)
+	assert.Equal(t, "audio_pending", data["type"])
+	assert.Equal(t, "113", data["sender_id"])
+	assert.Equal(t, "ana", data["sender_name"])
+	assert.Equal(t, "canal-2", data["channel"])
+	assert.Equal(t, PriorityUrgent, data["priority"])
+
+	var remaining int64
+	db.Model(&models.Device{}).Where("user_id = ?", 114).Count(&remaining)
+	assert.Equal(t, int64(1), remaining)
+}
+
+func TestNotifyPendingAudio_SkipsOnlineAndCooldown(t *testing.T) {
+	setPushSender(&fakePush{})
+	defer setPushSender(nil)
+	now := time.Now()
+
+	markPolled(115)
+	assert.True(t, isUserOnline(115, now))
+	assert.False(t, isUserOnline(116, now))
+
+	assert.True(t, allowPush(116, PriorityNormal, now))
+	assert.False(t, allowPush(116, PriorityNormal, now.Add(time.Second)))
+	assert.True(t, allowPush(116, PriorityEmergency, now.Add(2*time.Second)), "las emergencias ignoran el cooldown")
+	assert.True(t, allowPush(116, PriorityNormal, now.Add(pushCooldown+3*time.Second)))
+}
+
+func TestWantsPush_RespectsPreferences(t *testing.T) {
+	group := &PendingAudio{Priority: PriorityNormal}
+	direct := &PendingAudio{Priority: PriorityNormal, Direct: true}
+	emergency := &PendingAudio{Priority: PriorityEmergency}
+
+	assert.True(t, wantsPush(&models.User{}, group))
+	assert.False(t, wantsPush(&models.User{PushDirectOnly: true}, group))
+	assert.True(t, wantsPush(&models.User{PushDirectOnly: true}, direct))
+	assert.False(t, wantsPush(&models.User{PushMuted: true}, direct))
+	assert.True(t, wantsPush(&models.User{PushMuted: true}, emergency))
+	assert.False(t, wantsPush(&models.User{DoNotDisturb: true}, direct))
+	assert.True(t, wantsPush(&models.User{DoNotDisturb: true}, emergency))
+}
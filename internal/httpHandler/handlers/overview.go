@@ -0,0 +1,143 @@
+package handlers
+
+import (
+	"log"
+	"net/http"
+	"sort"
+	"time"
+
+	"walkie-backend/internal/config"
+	"walkie-backend/internal/metrics"
+	"walkie-backend/internal/response"
+	"walkie-backend/internal/services"
+)
+
+type overviewChannel struct {
+	Channel   string `json:"channel"`
+	Members   int    `json:"members"`
+	WSClients int    `json:"wsClients"`
+	Speaker   uint   `json:"speaker,omitempty"`
+	// SpeakingSeconds es cuánto lleva hablando el hablante actual
+	SpeakingSeconds float64 `json:"speakingSeconds,omitempty"`
+}
+
+type overviewSpeaker struct {
+	Channel  string  `json:"channel"`
+	UserID   uint    `json:"userId"`
+	Priority string  `json:"priority,omitempty"`
+	Seconds  float64 `json:"seconds"`
+}
+
+type overviewRate struct {
+	Requests  float64 `json:"requests"`
+	Errors    float64 `json:"errors"`
+	ErrorRate float64 `json:"errorRate"`
+}
+
+type overviewStage struct {
+	Count      uint64  `json:"count"`
+	AvgSeconds float64 `json:"avgSeconds"`
+	MaxSeconds float64 `json:"maxSeconds"`
+}
+
+// requestResult es la etiqueta de resultado de las llamadas a STT e IA
+func requestResult(err error) string {
+	if err != nil {
+		return "error"
+	}
+	return "ok"
+}
+
+func newOverviewRate(counters map[string]float64) overviewRate {
+	rate := overviewRate{Errors: counters["error"]}
+	for _, v := range counters {
+		rate.Requests += v
+	}
+	if rate.Requests > 0 {
+		rate.ErrorRate = rate.Errors / rate.Requests
+	}
+	return rate
+}
+
+// GET /admin/overview
+// Resumen en vivo para el panel de operaciones: canales con gente, quién habla, colas,
+// errores de STT e IA y latencia media de cada etapa de /audio/ingest. Los contadores
+// son de esta réplica desde que arrancó.
+func AdminOverview(w http.ResponseWriter, r *http.Request) {
+	if !requireAdmin(w, r) {
+		return
+	}
+
+	now := time.Now()
+	channels := map[string]*overviewChannel{}
+	channelFor := func(code string) *overviewChannel {
+		c, ok := channels[code]
+		if !ok {
+			c = &overviewChannel{Channel: code}
+			channels[code] = c
+		}
+		return c
+	}
+
+	if config.DB != nil && config.DBAvailable() {
+		counts, err := services.ConnectedCounts(config.DB)
+		if err != nil {
+			log.Printf("[PANEL] no se pudieron contar los miembros: %v", err)
+		}
+		for code, n := range counts {
+			channelFor(code).Members = n
+		}
+	}
+
+	speakers := []overviewSpeaker{}
+	registry.RLock()
+	wsClients := len(registry.byUser)
+	for code, clients := range registry.byChannel {
+		channelFor(code).WSClients = len(clients)
+	}
+	for code, hold := range registry.floor {
+		held := now.Sub(hold.since)
+		if held >= floorMaxHold {
+			continue
+		}
+		c := channelFor(code)
+		c.Speaker = hold.speakerID
+		c.SpeakingSeconds = held.Seconds()
+		speakers = append(speakers, overviewSpeaker{Channel: code, UserID: hold.speakerID, Priority: hold.priority, Seconds: held.Seconds()})
+	}
+	registry.RUnlock()
+
+	active := make([]overviewChannel, 0, len(channels))
+	for _, c := range channels {
+		active = append(active, *c)
+	}
+	sort.Slice(active, func(i, j int) bool { return active[i].Channel < active[j].Channel })
+	sort.Slice(speakers, func(i, j int) bool { return speakers[i].Channel < speakers[j].Channel })
+
+	var queues any
+	if totaler, ok := audioStore().(queueTotaler); ok {
+		if totals, err := totaler.Totals(); err == nil {
+			queues = totals
+		} else {
+			log.Printf("[PANEL] no se pudieron leer las colas: %v", err)
+		}
+	}
+
+	reg := metrics.Default()
+	stages := map[string]overviewStage{}
+	for stage, t := range reg.TimingsByLabel("walkie_ingest_stage", "stage") {
+		stages[stage] = overviewStage{Count: t.Count, AvgSeconds: t.Avg(), MaxSeconds: t.Max}
+	}
+
+	response.WriteJSON(w, http.StatusOK, map[string]any{
+		"generatedAt": now.UTC().Format(time.RFC3339),
+		"channels":    active,
+		"speakers":    speakers,
+		"wsClients":   wsClients,
+		"queues":      queues,
+		"stt":         newOverviewRate(reg.CountersByLabel("walkie_stt_requests_total", "result")),
+		"ai":          newOverviewRate(reg.CountersByLabel("walkie_ai_requests_total", "result")),
+		"stages":      stages,
+		"outcomes":    reg.CountersByLabel("walkie_ingest_outcome_total", "outcome"),
+	})
+}
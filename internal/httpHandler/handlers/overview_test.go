@@ -0,0 +1,88 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"walkie-backend/internal/config"
+	"walkie-backend/internal/metrics"
+	"walkie-backend/internal/models"
+	"walkie-backend/internal/services"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAdminOverview_ReportsChannelsSpeakersAndStages(t *testing.T) {
+	cleanup := setupChannelsTestDB(t)
+	defer cleanup()
+	t.Setenv("ADMIN_TOKEN", "secreto")
+
+	require.NoError(t, config.DB.Create(&models.Channel{Code: "canal-8", Name: "Ocho", MaxUsers: 10}).Error)
+	ana := &models.User{DisplayName: "Ana", IsActive: true}
+	require.NoError(t, config.DB.Create(ana).Error)
+	require.NoError(t, services.NewUserService().ConnectUserToChannel(ana.ID, "canal-8"))
+
+	client := &wsClient{userID: 662, channel: "canal-8", send: make(chan []byte, 8)}
+	registerClient(client)
+	defer removeClient(client)
+	_, ok := startTransmission("canal-8", 662, PriorityNormal)
+	require.True(t, ok)
+	defer stopTransmission("canal-8", 662)
+
+	EnqueueAudio(662, "canal-8", buildTestWAV(100), 1, []uint{663})
+	defer ClearPendingAudio(663)
+	metrics.Observe("walkie_ingest_stage", map[string]string{"stage": "stt"}, 2*time.Second)
+
+	req := httptest.NewRequest(http.MethodGet, "/admin/overview", nil)
+	rec := httptest.NewRecorder()
+	AdminOverview(rec, req)
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+
+	req.Header.Set("X-Admin-Token", "secreto")
+	rec = httptest.NewRecorder()
+	AdminOverview(rec, req)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+	var body struct {
+		Channels []overviewChannel        `json:"channels"`
+		Speakers []overviewSpeaker        `json:"speakers"`
+		Queues   QueueTotals              `json:"queues"`
+		Stages   map[string]overviewStage `json:"stages"`
+		STT      overviewRate             `json:"stt"`
+	}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+	var found *overviewChannel
+	for i := range body.Channels {
+		if body.Channels[i].Channel == "canal-8" {
+			found = &body.Channels[i]
+		}
+	}
+	require.NotNil(t, found, rec.Body.String())
+	assert.Equal(t, 1, found.Members)
+	assert.Equal(t, 1, found.WSClients)
+	assert.Equal(t, uint(662), found.Speaker)
+	speaking := map[string]uint{}
+	for _, sp := range body.Speakers {
+		speaking[sp.Channel] = sp.UserID
+	}
+	assert.Equal(t, uint(662), speaking["canal-8"])
+	assert.GreaterOrEqual(t, body.Queues.Clips, 1)
+	assert.GreaterOrEqual(t, body.Stages["stt"].Count, uint64(1))
+}
+
+func TestDBAudioStore_Totals(t *testing.T) {
+	db := setupTestDB(t)
+	require.NoError(t, db.AutoMigrate(&models.QueuedAudio{}))
+	store := NewDBAudioStore(db)
+	require.NoError(t, store.Enqueue(1, &PendingAudio{AudioData: []byte("abc"), Timestamp: time.Now(), Priority: PriorityUrgent}))
+	require.NoError(t, store.Enqueue(1, &PendingAudio{AudioData: []byte("de"), Timestamp: time.Now()}))
+	require.NoError(t, store.Enqueue(2, &PendingAudio{ObjectKey: "queue/x", ObjectSize: 10, Timestamp: time.Now()}))
+
+	totals, err := store.Totals()
+	require.NoError(t, err)
+	assert.Equal(t, QueueTotals{Users: 2, Clips: 3, Urgent: 1, Bytes: 15, MaxDepth: 2}, totals)
+}
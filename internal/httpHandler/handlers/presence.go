@@ -0,0 +1,275 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"walkie-backend/internal/config"
+	"walkie-backend/internal/response"
+)
+
+const (
+	PresenceOnline = "online"
+	PresenceIdle   = "idle"
+
+	presenceJoined = "user_joined"
+	presenceLeft   = "user_left"
+	presenceIdle   = "user_idle"
+	presenceActive = "user_active"
+
+	defaultPresenceIdleAfter    = 5 * time.Minute
+	defaultPresenceOfflineAfter = 10 * time.Minute
+	presenceSweepInterval       = 30 * time.Second
+)
+
+// presenceEntry es el estado de un usuario en un canal. viaWS indica que lo mantiene
+// un WebSocket abierto; los que sólo hacen polling caducan por inactividad.
+type presenceEntry struct {
+	name       string
+	channel    string
+	status     string
+	lastActive time.Time
+	viaWS      bool
+}
+
+// presenceEvent es el JSON que reciben los miembros del canal
+type presenceEvent struct {
+	Type       string `json:"type"`
+	Event      string `json:"event"`
+	UserID     uint   `json:"user_id"`
+	Name       string `json:"name,omitempty"`
+	Channel    string `json:"channel"`
+	Status     string `json:"status"`
+	LastActive string `json:"last_active_at"`
+}
+
+// presenceTracker sigue quién está en cada canal y avisa de los cambios
+type presenceTracker struct {
+	mu           sync.Mutex
+	users        map[uint]*presenceEntry
+	idleAfter    time.Duration
+	offlineAfter time.Duration
+	now          func() time.Time
+	// broadcast envía el evento a los miembros del canal salvo al propio usuario
+	broadcast func(channel string, exclude uint, msg []byte)
+	sweepOnce sync.Once
+}
+
+func newPresenceTracker() *presenceTracker {
+	return &presenceTracker{
+		users:        make(map[uint]*presenceEntry),
+		idleAfter:    config.DurationFromEnv("PRESENCE_IDLE_AFTER", defaultPresenceIdleAfter),
+		offlineAfter: config.DurationFromEnv("PRESENCE_OFFLINE_AFTER", defaultPresenceOfflineAfter),
+		now:          time.Now,
+		broadcast:    broadcastToChannel,
+	}
+}
+
+var presence = newPresenceTracker()
+
+// broadcastToChannel envía un mensaje de control a los clientes WebSocket del canal
+func broadcastToChannel(channel string, exclude uint, msg []byte) {
+	registry.RLock()
+	defer registry.RUnlock()
+	for id, c := range registry.byChannel[channel] {
+		if id != exclude {
+			deliverControl(c, msg)
+		}
+	}
+}
+
+// presenceOutbox acumula los eventos para enviarlos cuando se suelta el lock
+type presenceOutbox []presenceEvent
+
+func (o *presenceOutbox) add(event string, userID uint, e *presenceEntry, channel string) {
+	*o = append(*o, presenceEvent{
+		Type:       "presence",
+		Event:      event,
+		UserID:     userID,
+		Name:       e.name,
+		Channel:    channel,
+		Status:     e.status,
+		LastActive: e.lastActive.UTC().Format(time.RFC3339),
+	})
+}
+
+func (p *presenceTracker) flush(out presenceOutbox) {
+	for _, ev := range out {
+		if ev.Channel == "" {
+			continue
+		}
+		msg, _ := json.Marshal(ev)
+		p.broadcast(ev.Channel, ev.UserID, msg)
+		rotateOnMembershipChange(ev)
+	}
+}
+
+// startSweeper lanza una vez el barrido que marca inactivos y elimina caducados
+func (p *presenceTracker) startSweeper() {
+	p.sweepOnce.Do(func() {
+		go func() {
+			ticker := time.NewTicker(presenceSweepInterval)
+			defer ticker.Stop()
+			for range ticker.C {
+				p.sweep()
+			}
+		}()
+	})
+}
+
+// upsert actualiza al usuario y genera los eventos de entrada, salida o regreso
+func (p *presenceTracker) upsert(userID uint, name, channel string, viaWS bool, out *presenceOutbox) {
+	now := p.now()
+	e, ok := p.users[userID]
+	if !ok {
+		e = &presenceEntry{name: name, channel: channel, status: PresenceOnline, lastActive: now, viaWS: viaWS}
+		p.users[userID] = e
+		out.add(presenceJoined, userID, e, channel)
+		return
+	}
+
+	if name != "" {
+		e.name = name
+	}
+	e.viaWS = e.viaWS || viaWS
+	e.lastActive = now
+	wasIdle := e.status == PresenceIdle
+	e.status = PresenceOnline
+
+	if e.channel != channel {
+		out.add(presenceLeft, userID, e, e.channel)
+		e.channel = channel
+		out.add(presenceJoined, userID, e, channel)
+		return
+	}
+	if wasIdle {
+		out.add(presenceActive, userID, e, channel)
+	}
+}
+
+// Connect registra la apertura del WebSocket del usuario
+func (p *presenceTracker) Connect(userID uint, name, channel string) {
+	p.startSweeper()
+	var out presenceOutbox
+	p.mu.Lock()
+	p.upsert(userID, name, channel, true, &out)
+	p.mu.Unlock()
+	p.flush(out)
+}
+
+// Disconnect registra el cierre del WebSocket; el usuario sale de su canal
+func (p *presenceTracker) Disconnect(userID uint) {
+	var out presenceOutbox
+	p.mu.Lock()
+	if e, ok := p.users[userID]; ok {
+		delete(p.users, userID)
+		out.add(presenceLeft, userID, e, e.channel)
+	}
+	p.mu.Unlock()
+	p.flush(out)
+}
+
+// Move cambia el canal del usuario (comando de voz, logout o admin)
+func (p *presenceTracker) Move(userID uint, channel string) {
+	var out presenceOutbox
+	p.mu.Lock()
+	if e, ok := p.users[userID]; ok {
+		if channel == "" {
+			delete(p.users, userID)
+			out.add(presenceLeft, userID, e, e.channel)
+		} else {
+			p.upsert(userID, "", channel, false, &out)
+		}
+	}
+	p.mu.Unlock()
+	p.flush(out)
+}
+
+// Touch registra actividad de un usuario autenticado (también los que sólo hacen polling)
+func (p *presenceTracker) Touch(userID uint, name, channel string) {
+	p.startSweeper()
+	var out presenceOutbox
+	p.mu.Lock()
+	e, ok := p.users[userID]
+	switch {
+	case channel != "":
+		p.upsert(userID, name, channel, false, &out)
+	case !ok:
+	case e.viaWS:
+		// El WebSocket conserva el canal del handshake
+		p.upsert(userID, name, e.channel, false, &out)
+	default:
+		delete(p.users, userID)
+		out.add(presenceLeft, userID, e, e.channel)
+	}
+	p.mu.Unlock()
+	p.flush(out)
+}
+
+// sweep marca como inactivos a quienes no hablan desde idleAfter y retira a los que
+// sólo hacían polling y llevan offlineAfter sin aparecer
+func (p *presenceTracker) sweep() {
+	now := p.now()
+	var out presenceOutbox
+	p.mu.Lock()
+	for id, e := range p.users {
+		inactive := now.Sub(e.lastActive)
+		if !e.viaWS && inactive >= p.offlineAfter {
+			delete(p.users, id)
+			out.add(presenceLeft, id, e, e.channel)
+			continue
+		}
+		if e.status == PresenceOnline && inactive >= p.idleAfter {
+			e.status = PresenceIdle
+			out.add(presenceIdle, id, e, e.channel)
+		}
+	}
+	p.mu.Unlock()
+	p.flush(out)
+}
+
+type presenceUser struct {
+	UserID     uint   `json:"user_id"`
+	Name       string `json:"name,omitempty"`
+	Status     string `json:"status"`
+	LastActive string `json:"last_active_at"`
+}
+
+// Snapshot devuelve los usuarios presentes en el canal ordenados por ID
+func (p *presenceTracker) Snapshot(channel string) []presenceUser {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	out := make([]presenceUser, 0)
+	for id, e := range p.users {
+		if e.channel != channel {
+			continue
+		}
+		out = append(out, presenceUser{
+			UserID:     id,
+			Name:       e.name,
+			Status:     e.status,
+			LastActive: e.lastActive.UTC().Format(time.RFC3339),
+		})
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].UserID < out[j].UserID })
+	return out
+}
+
+// GET /channels/{code}/presence
+func ChannelPresence(w http.ResponseWriter, r *http.Request) {
+	if _, code, ok := channelMember(w, r); ok {
+		writeChannelPresence(w, code)
+	}
+}
+
+// writeChannelPresence responde GET /channels/{code}/presence
+func writeChannelPresence(w http.ResponseWriter, code string) {
+	response.WriteJSON(w, http.StatusOK, map[string]any{
+		"channel": code,
+		"users":   presence.Snapshot(code),
+	})
+}
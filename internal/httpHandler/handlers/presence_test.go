@@ -0,0 +1,117 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"walkie-backend/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+type capturedPresence struct {
+	channel string
+	event   presenceEvent
+}
+
+func newTestPresence(now *time.Time) (*presenceTracker, *[]capturedPresence) {
+	var events []capturedPresence
+	p := newPresenceTracker()
+	p.idleAfter = time.Minute
+	p.offlineAfter = 3 * time.Minute
+	p.now = func() time.Time { return *now }
+	p.broadcast = func(channel string, _ uint, msg []byte) {
+		var ev presenceEvent
+		_ = json.Unmarshal(msg, &ev)
+		events = append(events, capturedPresence{channel: channel, event: ev})
+	}
+	p.sweepOnce.Do(func() {})
+	return p, &events
+}
+
+func presenceEvents(events []capturedPresence) []string {
+	out := make([]string, 0, len(events))
+	for _, e := range events {
+		out = append(out, e.event.Event+"@"+e.channel)
+	}
+	return out
+}
+
+func TestPresenceTracker_WebSocketLifecycle(t *testing.T) {
+	now := time.Now()
+	p, events := newTestPresence(&now)
+
+	p.Connect(1, "ana", "canal-1")
+	p.Move(1, "canal-2")
+	now = now.Add(2 * time.Minute)
+	p.sweep()
+	p.Touch(1, "", "")
+	p.Disconnect(1)
+
+	assert.Equal(t, []string{
+		"user_joined@canal-1",
+		"user_left@canal-1",
+		"user_joined@canal-2",
+		"user_idle@canal-2",
+		"user_active@canal-2",
+		"user_left@canal-2",
+	}, presenceEvents(*events))
+	assert.Empty(t, p.Snapshot("canal-2"))
+}
+
+func TestPresenceTracker_PollingUserExpires(t *testing.T) {
+	now := time.Now()
+	p, events := newTestPresence(&now)
+
+	p.Touch(2, "luis", "canal-1")
+	now = now.Add(4 * time.Minute)
+	p.sweep()
+
+	assert.Equal(t, []string{"user_joined@canal-1", "user_left@canal-1"}, presenceEvents(*events))
+	assert.Empty(t, p.Snapshot("canal-1"))
+}
+
+func TestPresenceTracker_SnapshotStatus(t *testing.T) {
+	now := time.Now()
+	p, _ := newTestPresence(&now)
+
+	p.Connect(5, "eva", "canal-3")
+	p.Connect(4, "raul", "canal-3")
+	now = now.Add(90 * time.Second)
+	p.Touch(5, "eva", "canal-3")
+	p.sweep()
+
+	snap := p.Snapshot("canal-3")
+	require.Len(t, snap, 2)
+	assert.Equal(t, uint(4), snap[0].UserID)
+	assert.Equal(t, PresenceIdle, snap[0].Status)
+	assert.Equal(t, PresenceOnline, snap[1].Status)
+}
+
+func TestChannelHistory_PresenceRoute(t *testing.T) {
+	setupTestDB(t)
+	user := &models.User{Model: gorm.Model{ID: 121}, CurrentChannel: &models.Channel{Code: "canal-p"}}
+
+	presence.Connect(121, "pilar", "canal-p")
+	defer presence.Disconnect(121)
+
+	req := httptest.NewRequest(http.MethodGet, "/channels/canal-p/presence", nil)
+	req = req.WithContext(withAuthUser(req.Context(), user))
+	rec := httptest.NewRecorder()
+	serveChannelRoute(rec, req)
+
+	require.Equal(t, http.StatusOK, rec.Code)
+	var body struct {
+		Channel string         `json:"channel"`
+		Users   []presenceUser `json:"users"`
+	}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+	assert.Equal(t, "canal-p", body.Channel)
+	require.Len(t, body.Users, 1)
+	assert.Equal(t, "pilar", body.Users[0].Name)
+}
@@ -0,0 +1,141 @@
+package handlers
+
+import (
+	"log"
+	"net/http"
+	"time"
+
+	"walkie-backend/internal/config"
+	"walkie-backend/internal/metrics"
+	"walkie-backend/internal/response"
+)
+
+const (
+	defaultQueueMaxClips = 50
+	defaultQueueMaxBytes = 20 << 20
+)
+
+// QueueStatus resume la cola pendiente de un usuario
+type QueueStatus struct {
+	Depth    int
+	Urgent   int
+	Bytes    int64
+	OldestAt time.Time
+}
+
+// QueueTotals resume las colas de todos los usuarios, para el panel de administración
+type QueueTotals struct {
+	Users  int   `json:"users"`
+	Clips  int   `json:"clips"`
+	Urgent int   `json:"urgent"`
+	Bytes  int64 `json:"bytes"`
+	// MaxDepth es la cola más larga de un usuario
+	MaxDepth int `json:"maxDepth"`
+}
+
+// queueTotaler lo implementan los backends que pueden resumir todas sus colas
+type queueTotaler interface {
+	Totals() (QueueTotals, error)
+}
+
+// queueItem es lo mínimo que necesita la política de desalojo de cada clip en cola
+type queueItem struct {
+	urgent bool
+	at     time.Time
+	size   int64
+}
+
+// queueLimits lee AUDIO_QUEUE_MAX_CLIPS (50) y AUDIO_QUEUE_MAX_BYTES (20 MB); 0 desactiva cada límite
+func queueLimits() (maxClips int, maxBytes int64) {
+	return config.IntFromEnv("AUDIO_QUEUE_MAX_CLIPS", defaultQueueMaxClips), int64(config.IntFromEnv("AUDIO_QUEUE_MAX_BYTES", defaultQueueMaxBytes))
+}
+
+// evictionVictims elige qué clips sacar para volver a los límites: primero el normal más
+// antiguo y, si sólo quedan urgentes, el urgente más antiguo. Siempre se conserva al
+// menos un clip para que un audio grande no se desaloje a sí mismo.
+func evictionVictims(items []queueItem, maxClips int, maxBytes int64) []int {
+	var total int64
+	for _, it := range items {
+		total += it.size
+	}
+	removed := make([]bool, len(items))
+	remaining := len(items)
+	var victims []int
+
+	over := func() bool {
+		return (maxClips > 0 && remaining > maxClips) || (maxBytes > 0 && total > maxBytes)
+	}
+	for remaining > 1 && over() {
+		victim := -1
+		for i, it := range items {
+			if removed[i] {
+				continue
+			}
+			if victim < 0 ||
+				(items[victim].urgent && !it.urgent) ||
+				(items[victim].urgent == it.urgent && it.at.Before(items[victim].at)) {
+				victim = i
+			}
+		}
+		removed[victim] = true
+		remaining--
+		total -= items[victim].size
+		victims = append(victims, victim)
+	}
+	return victims
+}
+
+// enqueuePending encola el clip y aplica los límites de la cola del destinatario
+func enqueuePending(store PendingAudioStore, recipientID uint, audio *PendingAudio) error {
+	if err := store.Enqueue(recipientID, audio); err != nil {
+		return err
+	}
+	maxClips, maxBytes := queueLimits()
+	if maxClips <= 0 && maxBytes <= 0 {
+		return nil
+	}
+	evicted, err := store.EvictOverflow(recipientID, maxClips, maxBytes)
+	if err != nil {
+		log.Printf("Error aplicando límites de cola de usuario %d: %v", recipientID, err)
+		return nil
+	}
+	if evicted > 0 {
+		log.Printf("[COLA] usuario=%d cola llena, descartados %d audios antiguos", recipientID, evicted)
+		metrics.Default().Add("walkie_audio_evicted_total", nil, float64(evicted))
+	}
+	return nil
+}
+
+// GET /audio/queue-status muestra la cola pendiente del usuario para depurar clientes
+func AudioQueueStatus(w http.ResponseWriter, r *http.Request) {
+	if !requireDB(w) {
+		return
+	}
+
+	user, err := resolveUserFromRequest(r)
+	if err != nil {
+		response.WriteErr(w, http.StatusUnauthorized, "X-Auth-Token inválido o expirado")
+		return
+	}
+
+	status, err := audioStore().Status(user.ID)
+	if err != nil {
+		log.Printf("Error leyendo cola de usuario %d: %v", user.ID, err)
+		response.WriteErr(w, http.StatusInternalServerError, "No se pudo leer la cola")
+		return
+	}
+
+	maxClips, maxBytes := queueLimits()
+	out := map[string]any{
+		"depth":     status.Depth,
+		"urgent":    status.Urgent,
+		"bytes":     status.Bytes,
+		"max_clips": maxClips,
+		"max_bytes": maxBytes,
+	}
+	if status.Depth > 0 {
+		out["oldest_at"] = status.OldestAt.UTC().Format(time.RFC3339)
+		out["oldest_age_seconds"] = time.Since(status.OldestAt).Seconds()
+	}
+	response.WriteJSON(w, http.StatusOK, out)
+}
@@ -0,0 +1,93 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"walkie-backend/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+func TestEvictionVictims_DropsOldestNormalFirst(t *testing.T) {
+	base := time.Now()
+	items := []queueItem{
+		{urgent: true, at: base, size: 10},
+		{at: base.Add(time.Second), size: 10},
+		{at: base.Add(2 * time.Second), size: 10},
+		{urgent: true, at: base.Add(3 * time.Second), size: 10},
+	}
+	assert.Equal(t, []int{1, 2}, evictionVictims(items, 2, 0))
+	assert.Equal(t, []int{1, 2, 0}, evictionVictims(items, 0, 15))
+	assert.Empty(t, evictionVictims(items, 0, 0))
+	// Un único clip que supera el límite de bytes se conserva
+	assert.Empty(t, evictionVictims(items[:1], 0, 5))
+}
+
+func TestEnqueueAudio_CapsQueuePerUser(t *testing.T) {
+	t.Setenv("AUDIO_QUEUE_MAX_CLIPS", "2")
+	const recipient = uint(201)
+	defer ClearPendingAudio(recipient)
+
+	for _, clip := range []string{"uno", "dos", "tres"} {
+		EnqueueAudio(1, "canal-1", []byte(clip), 1, []uint{recipient})
+	}
+	status, err := audioStore().Status(recipient)
+	require.NoError(t, err)
+	assert.Equal(t, 2, status.Depth)
+	assert.Equal(t, "dos", string(DequeueAudio(recipient).AudioData))
+	assert.Equal(t, "tres", string(DequeueAudio(recipient).AudioData))
+}
+
+func TestDBAudioStore_EvictOverflowAndStatus(t *testing.T) {
+	store := newTestAudioStore(t)
+
+	old := newPendingAudio(1, "canal-1", []byte("viejo-normal"), 1, PriorityNormal)
+	old.Timestamp = time.Now().Add(-time.Minute)
+	_ = store.Enqueue(10, old)
+	_ = store.Enqueue(10, newPendingAudio(1, "canal-1", []byte("urgente"), 1, PriorityUrgent))
+	_ = store.Enqueue(10, newPendingAudio(1, "canal-1", []byte("nuevo"), 1, PriorityNormal))
+
+	evicted, err := store.EvictOverflow(10, 0, 13)
+	require.NoError(t, err)
+	assert.Equal(t, 1, evicted)
+
+	status, err := store.Status(10)
+	require.NoError(t, err)
+	assert.Equal(t, 2, status.Depth)
+	assert.Equal(t, 1, status.Urgent)
+	assert.Equal(t, int64(len("urgente")+len("nuevo")), status.Bytes)
+
+	first, _ := store.Dequeue(10)
+	second, _ := store.Dequeue(10)
+	assert.Equal(t, "urgente", string(first.AudioData))
+	assert.Equal(t, "nuevo", string(second.AudioData))
+}
+
+func TestAudioQueueStatus_ReportsDepthAndAge(t *testing.T) {
+	setupTestDB(t)
+	user := &models.User{Model: gorm.Model{ID: 202}}
+	defer ClearPendingAudio(user.ID)
+
+	old := newPendingAudio(1, "canal-1", []byte("hola"), 1, PriorityUrgent)
+	old.Timestamp = time.Now().Add(-30 * time.Second)
+	require.NoError(t, audioStore().Enqueue(user.ID, old))
+
+	req := httptest.NewRequest(http.MethodGet, "/audio/queue-status", nil)
+	req = req.WithContext(withAuthUser(req.Context(), user))
+	rec := httptest.NewRecorder()
+	AudioQueueStatus(rec, req)
+
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	var body map[string]any
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+	assert.EqualValues(t, 1, body["depth"])
+	assert.EqualValues(t, 1, body["urgent"])
+	assert.EqualValues(t, 4, body["bytes"])
+	assert.GreaterOrEqual(t, body["oldest_age_seconds"].(float64), 29.0)
+}
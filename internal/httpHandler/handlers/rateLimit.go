@@ -0,0 +1,143 @@
+package handlers
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"walkie-backend/internal/config"
+	"walkie-backend/internal/metrics"
+	"walkie-backend/internal/response"
+)
+
+const bucketIdleTTL = 10 * time.Minute
+
+// RateLimiter es un token bucket por usuario: cada usuario acumula hasta burst
+// peticiones y recupera perMinute por minuto
+type RateLimiter struct {
+	name      string
+	perMinute float64
+	burst     float64
+
+	mu        sync.Mutex
+	buckets   map[string]*tokenBucket
+	lastSweep time.Time
+	now       func() time.Time
+}
+
+type tokenBucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// NewRateLimiter crea un limitador; perMinute <= 0 lo desactiva
+func NewRateLimiter(name string, perMinute, burst int) *RateLimiter {
+	if burst < 1 {
+		burst = 1
+	}
+	return &RateLimiter{
+		name:      name,
+		perMinute: float64(perMinute),
+		burst:     float64(burst),
+		buckets:   make(map[string]*tokenBucket),
+		now:       time.Now,
+	}
+}
+
+func rateLimitFromEnv(name string, defaultPerMinute, defaultBurst int) *RateLimiter {
+	prefix := "RATE_LIMIT_" + strings.ToUpper(name)
+	return NewRateLimiter(name,
+		config.IntFromEnv(prefix+"_PER_MIN", defaultPerMinute),
+		config.IntFromEnv(prefix+"_BURST", defaultBurst))
+}
+
+var (
+	// IngestLimiter protege la cuota de STT/LLM frente a clientes que suben audio sin parar
+	IngestLimiter = rateLimitFromEnv("ingest", 20, 5)
+	// PollLimiter deja margen para sondeos cada medio segundo
+	PollLimiter = rateLimitFromEnv("poll", 150, 20)
+)
+
+// Allow consume un token de key y, si no queda ninguno, indica cuánto esperar
+func (l *RateLimiter) Allow(key string) (bool, time.Duration) {
+	if l == nil || l.perMinute <= 0 {
+		return true, 0
+	}
+	now := l.now()
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.sweepLocked(now)
+	b, ok := l.buckets[key]
+	if !ok {
+		b = &tokenBucket{tokens: l.burst, last: now}
+		l.buckets[key] = b
+	}
+
+	perSecond := l.perMinute / 60
+	b.tokens = math.Min(l.burst, b.tokens+now.Sub(b.last).Seconds()*perSecond)
+	b.last = now
+	if b.tokens >= 1 {
+		b.tokens--
+		return true, 0
+	}
+	wait := time.Duration((1 - b.tokens) / perSecond * float64(time.Second))
+	return false, wait
+}
+
+// sweepLocked descarta los buckets inactivos para que el mapa no crezca sin límite
+func (l *RateLimiter) sweepLocked(now time.Time) {
+	if now.Sub(l.lastSweep) < bucketIdleTTL {
+		return
+	}
+	l.lastSweep = now
+	for key, b := range l.buckets {
+		if now.Sub(b.last) > bucketIdleTTL {
+			delete(l.buckets, key)
+		}
+	}
+}
+
+// Middleware limita por usuario autenticado; debe ir detrás de RequireAuth. Sin usuario
+// en el contexto (modo degradado) se usa el hash del token y, sin token, la IP del cliente.
+func (l *RateLimiter) Middleware(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		key, who := rateLimitKey(r)
+		allowed, wait := l.Allow(key)
+		if !allowed {
+			seconds := int(math.Ceil(wait.Seconds()))
+			if seconds < 1 {
+				seconds = 1
+			}
+			metrics.Inc("walkie_rate_limited_total", map[string]string{"route": l.name})
+			log.Printf("[LIMITE] ruta=%s %s reintentar_en=%ds", l.name, who, seconds)
+			w.Header().Set("Retry-After", strconv.Itoa(seconds))
+			response.WriteErr(w, http.StatusTooManyRequests, "Demasiadas peticiones, inténtalo más tarde")
+			return
+		}
+		next(w, r)
+	}
+}
+
+// rateLimitKey devuelve la clave del bucket y cómo identificar al cliente en el log sin
+// escribir su token
+func rateLimitKey(r *http.Request) (key, who string) {
+	if user, ok := authUserFromContext(r.Context()); ok {
+		return fmt.Sprintf("user:%d", user.ID), fmt.Sprintf("usuario=%d", user.ID)
+	}
+	if token := requestToken(r); token != "" {
+		return "token:" + hashToken(token), "cliente=token"
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	return "ip:" + host, "ip=" + host
+}
@@ -0,0 +1,95 @@
+package handlers
+
+import (
+	"bytes"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"walkie-backend/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+func TestRateLimiter_BucketRefills(t *testing.T) {
+	now := time.Unix(1_700_000_000, 0)
+	l := NewRateLimiter("test", 60, 2)
+	l.now = func() time.Time { return now }
+
+	ok, _ := l.Allow("user:1")
+	assert.True(t, ok)
+	ok, _ = l.Allow("user:1")
+	assert.True(t, ok)
+	ok, wait := l.Allow("user:1")
+	assert.False(t, ok)
+	assert.Equal(t, time.Second, wait)
+
+	ok, _ = l.Allow("user:2")
+	assert.True(t, ok, "cada usuario tiene su propio bucket")
+
+	now = now.Add(time.Second)
+	ok, _ = l.Allow("user:1")
+	assert.True(t, ok)
+}
+
+func TestRateLimiter_Disabled(t *testing.T) {
+	l := NewRateLimiter("test", 0, 1)
+	for i := 0; i < 10; i++ {
+		ok, _ := l.Allow("user:1")
+		assert.True(t, ok)
+	}
+}
+
+func TestRateLimiter_MiddlewareReturns429(t *testing.T) {
+	l := NewRateLimiter("ingest", 1, 1)
+	handler := l.Middleware(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	})
+	user := &models.User{Model: gorm.Model{ID: 9}}
+
+	call := func() *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/audio/ingest", nil)
+		req = req.WithContext(withAuthUser(req.Context(), user))
+		rec := httptest.NewRecorder()
+		handler(rec, req)
+		return rec
+	}
+
+	assert.Equal(t, http.StatusNoContent, call().Code)
+	rec := call()
+	assert.Equal(t, http.StatusTooManyRequests, rec.Code)
+	assert.Equal(t, "60", rec.Header().Get("Retry-After"))
+}
+
+func TestRateLimiter_MiddlewareKeysWithoutUser(t *testing.T) {
+	l := NewRateLimiter("ingest", 1, 1)
+	handler := l.Middleware(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	})
+	call := func(token, remote string) int {
+		req := httptest.NewRequest(http.MethodPost, "/audio/ingest", nil)
+		req.RemoteAddr = remote
+		if token != "" {
+			req.Header.Set("X-Auth-Token", token)
+		}
+		rec := httptest.NewRecorder()
+		handler(rec, req)
+		return rec.Code
+	}
+
+	var buf bytes.Buffer
+	orig := log.Writer()
+	log.SetOutput(&buf)
+	defer log.SetOutput(orig)
+
+	assert.Equal(t, http.StatusNoContent, call("tok-secreto", "10.0.0.1:1000"))
+	assert.Equal(t, http.StatusTooManyRequests, call("tok-secreto", "10.0.0.2:1000"))
+	assert.NotContains(t, buf.String(), "tok-secreto")
+
+	assert.Equal(t, http.StatusNoContent, call("", "10.0.0.3:1000"))
+	assert.Equal(t, http.StatusTooManyRequests, call("", "10.0.0.3:2000"))
+	assert.Equal(t, http.StatusNoContent, call("", "10.0.0.4:1000"), "cada IP anónima tiene su propio bucket")
+}
@@ -0,0 +1,160 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	"walkie-backend/internal/config"
+	"walkie-backend/internal/metrics"
+	"walkie-backend/internal/models"
+	"walkie-backend/internal/response"
+	"walkie-backend/internal/services"
+)
+
+const (
+	transmissionIDBytes = 8
+	defaultReceiptsTTL  = 7 * 24 * time.Hour
+)
+
+type receiptItem struct {
+	UserID      uint       `json:"userId"`
+	Name        string     `json:"name,omitempty"`
+	DeliveredAt *time.Time `json:"deliveredAt,omitempty"`
+	PlayedAt    *time.Time `json:"playedAt,omitempty"`
+}
+
+// newTransmissionID genera el ID de un envío; sin él el envío no lleva confirmaciones
+func newTransmissionID() string {
+	id, err := generateToken(transmissionIDBytes)
+	if err != nil {
+		log.Printf("[RECIBOS] no se pudo generar el ID de transmisión: %v", err)
+		return ""
+	}
+	return id
+}
+
+// recordReceipts abre las confirmaciones de los destinatarios a los que se encoló el clip
+func recordReceipts(pending *PendingAudio, recipients []uint) {
+	db := config.DB
+	if pending.TransmissionID == "" || len(recipients) == 0 || db == nil || !config.DBAvailable() {
+		return
+	}
+	if err := services.CreateReceipts(db, pending.TransmissionID, pending.SenderID, pending.Channel, recipients, pending.Timestamp); err != nil {
+		log.Printf("[RECIBOS] transmisión %s: error guardando confirmaciones: %v", pending.TransmissionID, err)
+	}
+}
+
+// markAudioDelivered anota en segundo plano que el clip salió hacia el destinatario
+func markAudioDelivered(userID uint, pending *PendingAudio) {
+	db := config.DB
+	if pending.TransmissionID == "" || db == nil || !config.DBAvailable() {
+		return
+	}
+	id, at := pending.TransmissionID, time.Now()
+	go func() {
+		if err := services.MarkDelivered(db, id, userID, at); err != nil {
+			log.Printf("[RECIBOS] transmisión %s usuario=%d error=%v", id, userID, err)
+		}
+	}()
+}
+
+// POST /audio/played/{id}
+// El cliente confirma que terminó de reproducir la transmisión; el emisor recibe
+// "played_by" por WebSocket la primera vez
+func AudioPlayed(w http.ResponseWriter, r *http.Request) {
+	if !requireDB(w) {
+		return
+	}
+	user, err := resolveUserFromRequest(r)
+	if err != nil {
+		response.WriteErr(w, http.StatusUnauthorized, "Token inválido o expirado")
+		return
+	}
+
+	receipt, played, err := services.MarkPlayed(config.DB, r.PathValue("id"), user.ID, time.Now())
+	switch {
+	case errors.Is(err, services.ErrReceiptNotFound):
+		response.WriteErr(w, http.StatusNotFound, "Transmisión no encontrada")
+		return
+	case err != nil:
+		log.Printf("[RECIBOS] usuario=%d error confirmando escucha: %v", user.ID, err)
+		response.WriteErr(w, http.StatusInternalServerError, "No se pudo guardar la confirmación")
+		return
+	}
+
+	if played {
+		metrics.Inc("walkie_audio_played_total", nil)
+		sendJSONToUser(receipt.SenderID, map[string]any{
+			"type":            "played_by",
+			"transmission_id": receipt.TransmissionID,
+			"channel":         receipt.Channel,
+			"user_id":         user.ID,
+			"name":            user.DisplayName,
+			"played_at":       receipt.PlayedAt.UTC().Format(time.RFC3339),
+		})
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// GET /audio/{id}/receipts
+// Quién recibió y quién escuchó la transmisión; sólo para quien la envió
+func AudioReceipts(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	if !requireDB(w) {
+		return
+	}
+	user, err := resolveUserFromRequest(r)
+	if err != nil {
+		response.WriteErr(w, http.StatusUnauthorized, "Token inválido o expirado")
+		return
+	}
+
+	rows, err := services.TransmissionReceipts(config.DB, id, user.ID)
+	switch {
+	case errors.Is(err, services.ErrReceiptNotFound):
+		response.WriteErr(w, http.StatusNotFound, "Transmisión no encontrada")
+		return
+	case err != nil:
+		response.WriteErr(w, http.StatusInternalServerError, "No se pudieron obtener las confirmaciones")
+		return
+	}
+
+	ids := make([]uint, 0, len(rows))
+	for _, row := range rows {
+		ids = append(ids, row.RecipientID)
+	}
+	names := make(map[uint]string, len(ids))
+	var users []models.User
+	if err := config.DB.Select("id", "display_name").Where("id IN ?", ids).Find(&users).Error; err == nil {
+		for _, u := range users {
+			names[u.ID] = u.DisplayName
+		}
+	}
+
+	items := make([]receiptItem, 0, len(rows))
+	delivered, played := 0, 0
+	for _, row := range rows {
+		if row.DeliveredAt != nil {
+			delivered++
+		}
+		if row.PlayedAt != nil {
+			played++
+		}
+		items = append(items, receiptItem{
+			UserID:      row.RecipientID,
+			Name:        names[row.RecipientID],
+			DeliveredAt: row.DeliveredAt,
+			PlayedAt:    row.PlayedAt,
+		})
+	}
+	response.WriteJSON(w, http.StatusOK, map[string]any{
+		"transmissionId": id,
+		"channel":        rows[0].Channel,
+		"recipients":     len(rows),
+		"delivered":      delivered,
+		"played":         played,
+		"receipts":       items,
+	})
+}
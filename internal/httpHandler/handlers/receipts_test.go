@@ -0,0 +1,89 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"walkie-backend/internal/models"
+	"walkie-backend/internal/services"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAudioReceipts_DeliveredPlayedAndNotifiesSender(t *testing.T) {
+	db := setupTestDB(t)
+	require.NoError(t, db.AutoMigrate(&models.AudioReceipt{}))
+	sender := createTestUser(t, db, 693, "token-recibos-1", "recibos-1")
+	listener := &models.User{DisplayName: "Oyente", IsActive: true, CurrentChannelID: sender.CurrentChannelID, CurrentChannel: sender.CurrentChannel}
+	listener.ID = 694
+	require.NoError(t, db.Create(listener).Error)
+	defer ClearPendingAudio(listener.ID)
+
+	senderClient := &wsClient{userID: sender.ID, channel: "recibos-1", send: make(chan []byte, 8)}
+	registerClient(senderClient)
+	t.Cleanup(func() { removeClient(senderClient) })
+
+	id := EnqueueAudioWithPriority(sender.ID, "recibos-1", buildTestWAV(320), 1, []uint{listener.ID}, PriorityNormal)
+	require.NotEmpty(t, id)
+
+	rec := pollWithAck(t, listener)
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, id, rec.Header().Get("X-Transmission-ID"))
+	require.Eventually(t, func() bool {
+		var r models.AudioReceipt
+		return db.Where("transmission_id = ?", id).First(&r).Error == nil && r.DeliveredAt != nil
+	}, 2*time.Second, 10*time.Millisecond, "la entrega queda anotada")
+
+	played := func(user *models.User) int {
+		req := httptest.NewRequest(http.MethodPost, "/audio/played/"+id, nil)
+		req.SetPathValue("id", id)
+		rec := httptest.NewRecorder()
+		AudioPlayed(rec, req.WithContext(withAuthUser(req.Context(), user)))
+		return rec.Code
+	}
+	assert.Equal(t, http.StatusNotFound, played(sender), "el emisor no es destinatario")
+	assert.Equal(t, http.StatusNoContent, played(listener))
+	assert.Equal(t, http.StatusNoContent, played(listener), "confirmar dos veces no falla")
+
+	var event map[string]any
+	require.NoError(t, json.Unmarshal(<-senderClient.send, &event))
+	assert.Equal(t, "played_by", event["type"])
+	assert.Equal(t, id, event["transmission_id"])
+	assert.EqualValues(t, listener.ID, event["user_id"])
+	assert.Empty(t, senderClient.send, "sólo se avisa la primera escucha")
+
+	receipts := func(user *models.User) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/audio/"+id+"/receipts", nil)
+		req.SetPathValue("id", id)
+		rec := httptest.NewRecorder()
+		AudioReceipts(rec, req.WithContext(withAuthUser(req.Context(), user)))
+		return rec
+	}
+	assert.Equal(t, http.StatusNotFound, receipts(listener).Code, "sólo el emisor ve las confirmaciones")
+	rec = receipts(sender)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	var body struct {
+		Recipients int `json:"recipients"`
+		Delivered  int `json:"delivered"`
+		Played     int `json:"played"`
+		Receipts   []struct {
+			UserID   uint       `json:"userId"`
+			PlayedAt *time.Time `json:"playedAt"`
+		} `json:"receipts"`
+	}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+	assert.Equal(t, 1, body.Recipients)
+	assert.Equal(t, 1, body.Delivered)
+	assert.Equal(t, 1, body.Played)
+	require.Len(t, body.Receipts, 1)
+	assert.Equal(t, listener.ID, body.Receipts[0].UserID)
+	assert.NotNil(t, body.Receipts[0].PlayedAt)
+
+	purged, err := services.PurgeReceipts(db, time.Now().Add(time.Minute))
+	require.NoError(t, err)
+	assert.EqualValues(t, 1, purged)
+}
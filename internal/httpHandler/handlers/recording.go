@@ -0,0 +1,243 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"walkie-backend/internal/config"
+	"walkie-backend/internal/models"
+	"walkie-backend/internal/response"
+	"walkie-backend/internal/services"
+	"walkie-backend/pkg/audio"
+)
+
+const (
+	intentRecordingStart = "request_recording_start"
+	intentRecordingStop  = "request_recording_stop"
+
+	// recordingSampleRate es la frecuencia del WAV exportado
+	recordingSampleRate    = 16000
+	defaultRecordingMaxGap = 3 * time.Second
+)
+
+type recordingItem struct {
+	ID        uint   `json:"id"`
+	Channel   string `json:"channel"`
+	StartedAt string `json:"startedAt"`
+	StoppedAt string `json:"stoppedAt,omitempty"`
+	StartedBy string `json:"startedBy,omitempty"`
+	Active    bool   `json:"active"`
+	ExportURL string `json:"exportUrl"`
+}
+
+func newRecordingItem(rec *models.ChannelRecording) recordingItem {
+	item := recordingItem{
+		ID:        rec.ID,
+		Channel:   rec.ChannelCode,
+		StartedAt: rec.StartedAt.UTC().Format(time.RFC3339),
+		StartedBy: rec.StartedBy,
+		Active:    rec.IsActive(),
+		ExportURL: fmt.Sprintf("/v1/channels/%s/recordings/%d/export", rec.ChannelCode, rec.ID),
+	}
+	if rec.StoppedAt != nil {
+		item.StoppedAt = rec.StoppedAt.UTC().Format(time.RFC3339)
+	}
+	return item
+}
+
+// appendRecordingClip añade la transmisión a la grabación en curso del canal. Sólo se
+// guardan los WAV, que son los que se pueden unir al exportar.
+func appendRecordingClip(senderID uint, channel string, audioData []byte, duration float64) {
+	db := config.DB
+	if db == nil || !config.DBAvailable() || audio.Detect(audioData) != audio.FormatWAV {
+		return
+	}
+	clip := models.RecordingClip{CreatedAt: time.Now(), SenderID: senderID, Duration: duration, Data: audioData}
+	go func() {
+		if _, err := services.AppendRecordingClip(db, channel, clip); err != nil {
+			log.Printf("[GRABACION] canal=%s usuario=%d error=%v", channel, senderID, err)
+		}
+	}()
+}
+
+// setChannelRecording inicia o detiene la grabación y avisa al canal de que se graba
+func setChannelRecording(channel, actor string, start bool) (*models.ChannelRecording, error) {
+	var rec *models.ChannelRecording
+	var err error
+	if start {
+		rec, err = services.StartRecording(config.DB, channel, actor)
+	} else {
+		rec, err = services.StopRecording(config.DB, channel)
+	}
+	if err != nil {
+		return rec, err
+	}
+
+	action := "stop"
+	if start {
+		action = "start"
+	}
+	log.Printf("[GRABACION] canal=%s accion=%s por=%s grabacion=%d", channel, action, actor, rec.ID)
+	msg, _ := json.Marshal(map[string]any{
+		"type":         "recording",
+		"action":       action,
+		"channel":      channel,
+		"recording_id": rec.ID,
+	})
+	broadcastToChannel(channel, 0, msg)
+	return rec, nil
+}
+
+// handleRecordingStage responde a los comandos de voz "graba el canal" y "deja de grabar"
+func handleRecordingStage(w http.ResponseWriter, user *models.User, intent string, tracker *stageTimer) {
+	if !user.IsInChannel() {
+		response.WriteJSON(w, http.StatusOK, CommandResponse{
+			Status:  "error",
+			Intent:  intent,
+			Message: "No estás en ningún canal",
+		})
+		tracker.LogFinal("recording_no_channel")
+		return
+	}
+
+	channel := user.GetCurrentChannelCode()
+	label := channelLabel(channel)
+	start := intent == intentRecordingStart
+	rec, err := setChannelRecording(channel, fmt.Sprintf("user:%d", user.ID), start)
+
+	var message string
+	switch {
+	case errors.Is(err, services.ErrRecordingActive):
+		message = "El canal " + label + " ya se está grabando"
+	case errors.Is(err, services.ErrNotRecording):
+		message = "El canal " + label + " no se está grabando"
+	case err != nil:
+		log.Printf("[GRABACION] usuario=%d canal=%s error=%v", user.ID, channel, err)
+		response.WriteJSON(w, http.StatusOK, CommandResponse{
+			Status:  "error",
+			Intent:  intent,
+			Message: "No pude cambiar la grabación",
+		})
+		tracker.LogFinal("recording_error")
+		return
+	case start:
+		message = "Grabando el canal " + label
+	default:
+		message = "Grabación del canal " + label + " detenida"
+	}
+
+	data := map[string]any{"channel": channel}
+	if rec != nil {
+		data["recording_id"] = rec.ID
+	}
+	response.WriteJSON(w, http.StatusOK, CommandResponse{
+		Status:  "ok",
+		Intent:  intent,
+		Message: message,
+		Data:    data,
+	})
+	tracker.LogFinal("recording_toggled")
+}
+
+// POST|DELETE /admin/channels/{code}/recording
+// Inicia (POST) o detiene (DELETE) la grabación del canal
+func AdminChannelRecording(w http.ResponseWriter, r *http.Request) {
+	if !requireAdmin(w, r) || !requireDB(w) {
+		return
+	}
+	code := r.PathValue("code")
+	start := r.Method == http.MethodPost
+
+	rec, err := setChannelRecording(code, adminActor(r), start)
+	switch {
+	case errors.Is(err, services.ErrChannelNotFound):
+		response.WriteErr(w, http.StatusNotFound, "Canal no encontrado")
+	case errors.Is(err, services.ErrRecordingActive):
+		response.WriteJSON(w, http.StatusConflict, newRecordingItem(rec))
+	case errors.Is(err, services.ErrNotRecording):
+		response.WriteErr(w, http.StatusConflict, "El canal no se está grabando")
+	case err != nil:
+		response.WriteErr(w, http.StatusInternalServerError, "No se pudo cambiar la grabación")
+	case start:
+		response.WriteJSON(w, http.StatusCreated, newRecordingItem(rec))
+	default:
+		response.WriteJSON(w, http.StatusOK, newRecordingItem(rec))
+	}
+}
+
+// GET /channels/{code}/recordings
+func ChannelRecordings(w http.ResponseWriter, r *http.Request) {
+	if _, code, ok := channelMember(w, r); ok {
+		writeChannelRecordings(w, code)
+	}
+}
+
+// GET /channels/{code}/recordings/{id}/export
+func ChannelRecordingExport(w http.ResponseWriter, r *http.Request) {
+	if _, code, ok := channelMember(w, r); ok {
+		writeRecordingExport(w, code, r.PathValue("id"))
+	}
+}
+
+// writeChannelRecordings responde GET /channels/{code}/recordings
+func writeChannelRecordings(w http.ResponseWriter, code string) {
+	items, err := services.ChannelRecordings(config.DB, code)
+	if err != nil {
+		response.WriteErr(w, http.StatusInternalServerError, "No se pudieron obtener las grabaciones")
+		return
+	}
+	out := make([]recordingItem, 0, len(items))
+	for i := range items {
+		out = append(out, newRecordingItem(&items[i]))
+	}
+	response.WriteJSON(w, http.StatusOK, out)
+}
+
+// writeRecordingExport responde GET /channels/{code}/recordings/{id}/export con un único
+// WAV: los clips en orden y, entre ellos, el silencio real hasta RECORDING_MAX_GAP (3s)
+func writeRecordingExport(w http.ResponseWriter, code, rawID string) {
+	id, err := strconv.ParseUint(rawID, 10, 64)
+	if err != nil {
+		response.WriteErr(w, http.StatusBadRequest, "ID de grabación inválido")
+		return
+	}
+	rec, clips, err := services.RecordingClips(config.DB, code, uint(id))
+	if errors.Is(err, services.ErrRecordingNotFound) {
+		response.WriteErr(w, http.StatusNotFound, "Grabación no encontrada")
+		return
+	}
+	if err != nil {
+		response.WriteErr(w, http.StatusInternalServerError, "No se pudo leer la grabación")
+		return
+	}
+
+	segments := make([]audio.Segment, 0, len(clips))
+	for _, c := range clips {
+		segments = append(segments, audio.Segment{Start: c.CreatedAt, Data: c.Data})
+	}
+	maxGap := config.DurationFromEnv("RECORDING_MAX_GAP", defaultRecordingMaxGap)
+	out, used, err := audio.StitchWAV(segments, recordingSampleRate, maxGap)
+	if errors.Is(err, audio.ErrNoSegments) {
+		response.WriteErr(w, http.StatusNotFound, "La grabación no tiene audio")
+		return
+	}
+	if err != nil {
+		response.WriteErr(w, http.StatusInternalServerError, "No se pudo exportar la grabación")
+		return
+	}
+
+	log.Printf("[GRABACION] canal=%s grabacion=%d exportada clips=%d bytes=%d", code, rec.ID, used, len(out))
+	filename := fmt.Sprintf("%s-%s.wav", code, rec.StartedAt.UTC().Format("20060102-150405"))
+	w.Header().Set("Content-Type", "audio/wav")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+	w.Header().Set("X-Recording-Clips", strconv.Itoa(used))
+	w.WriteHeader(http.StatusOK)
+	if _, err := w.Write(out); err != nil {
+		log.Printf("Error enviando grabación %d: %v", rec.ID, err)
+	}
+}
@@ -0,0 +1,112 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"walkie-backend/internal/config"
+	"walkie-backend/internal/models"
+	"walkie-backend/internal/services"
+	"walkie-backend/pkg/audio"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestChannelRecording_AdminVoiceAndExport(t *testing.T) {
+	cleanup := setupChannelsTestDB(t)
+	defer cleanup()
+	t.Setenv("ADMIN_TOKEN", "secreto")
+	require.NoError(t, config.DB.AutoMigrate(&models.ChannelRecording{}, &models.RecordingClip{}))
+
+	channel := models.Channel{Code: "canal-7", Name: "Siete", MaxUsers: 10}
+	require.NoError(t, config.DB.Create(&channel).Error)
+	ana := models.User{DisplayName: "Ana", AuthToken: "tok-rec-ana", IsActive: true, LastActiveAt: time.Now(), CurrentChannelID: &channel.ID, CurrentChannel: &channel}
+	require.NoError(t, config.DB.Create(&ana).Error)
+
+	listener := &wsClient{userID: 658, channel: "canal-7", send: make(chan []byte, 8)}
+	registerClient(listener)
+	defer removeClient(listener)
+
+	admin := func(method, code string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, "/admin/channels/"+code+"/recording", nil)
+		req.SetPathValue("code", code)
+		req.Header.Set("X-Admin-Token", "secreto")
+		rec := httptest.NewRecorder()
+		AdminChannelRecording(rec, req)
+		return rec
+	}
+
+	assert.Equal(t, http.StatusNotFound, admin(http.MethodPost, "canal-99").Code)
+	assert.Equal(t, http.StatusConflict, admin(http.MethodDelete, "canal-7").Code)
+
+	rec := admin(http.MethodPost, "canal-7")
+	require.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+	var started recordingItem
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &started))
+	assert.True(t, started.Active)
+	assert.Equal(t, http.StatusConflict, admin(http.MethodPost, "canal-7").Code)
+
+	select {
+	case raw := <-listener.send:
+		assert.Contains(t, string(raw), `"type":"recording"`)
+		assert.Contains(t, string(raw), `"action":"start"`)
+	case <-time.After(time.Second):
+		t.Fatal("expected recording notice on the channel")
+	}
+
+	// Los clips que no son WAV no se pueden unir y no se guardan
+	base := time.Now()
+	for i, data := range [][]byte{buildTestWAV(3200), []byte("opus"), buildTestWAV(3200)} {
+		_, err := services.AppendRecordingClip(config.DB, "canal-7", models.RecordingClip{
+			CreatedAt: base.Add(time.Duration(i) * time.Second), SenderID: ana.ID, Data: data,
+		})
+		require.NoError(t, err)
+	}
+
+	// Ana detiene la grabación por voz
+	rec = httptest.NewRecorder()
+	handleRecordingStage(rec, &ana, intentRecordingStop, newStageTimer(ana.ID))
+	var resp CommandResponse
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+	assert.Equal(t, "ok", resp.Status)
+	assert.Equal(t, "Grabación del canal 7 detenida", resp.Message)
+
+	rec = httptest.NewRecorder()
+	handleRecordingStage(rec, &ana, intentRecordingStop, newStageTimer(ana.ID))
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+	assert.Equal(t, "El canal 7 no se está grabando", resp.Message)
+
+	get := func(path string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		req.Header.Set("X-Auth-Token", "tok-rec-ana")
+		rec := httptest.NewRecorder()
+		serveChannelRoute(rec, req)
+		return rec
+	}
+
+	rec = get("/channels/canal-7/recordings")
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	var items []recordingItem
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &items))
+	require.Len(t, items, 1)
+	assert.False(t, items[0].Active)
+
+	require.Equal(t, fmt.Sprintf("/v1/channels/canal-7/recordings/%d/export", items[0].ID), items[0].ExportURL)
+	// El router quita /v1 antes de llegar al handler
+	rec = get(strings.TrimPrefix(items[0].ExportURL, "/v1"))
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	assert.Equal(t, "audio/wav", rec.Header().Get("Content-Type"))
+	assert.Equal(t, "2", rec.Header().Get("X-Recording-Clips"))
+	f, err := audio.ParseWAV(rec.Body.Bytes())
+	require.NoError(t, err)
+	// 0,1 s + 1,9 s de silencio + 0,1 s (el segundo clip guardado empezó 2 s después)
+	assert.Equal(t, 2100*time.Millisecond, f.Duration())
+
+	assert.Equal(t, http.StatusNotFound, get("/channels/canal-7/recordings/999/export").Code)
+}
@@ -0,0 +1,93 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"walkie-backend/internal/config"
+	"walkie-backend/internal/response"
+	"walkie-backend/internal/services"
+)
+
+const maxSearchQueryLength = 200
+
+type searchHit struct {
+	ID         uint   `json:"id"`
+	Channel    string `json:"channel"`
+	SenderID   uint   `json:"sender_id"`
+	SenderName string `json:"sender_name,omitempty"`
+	Text       string `json:"text"`
+	Timestamp  string `json:"timestamp"`
+}
+
+// Search: GET /search?q=...&limit=N busca en las transcripciones de los canales
+// en los que ha estado el usuario
+func Search(w http.ResponseWriter, r *http.Request) {
+	if !requireDB(w) {
+		return
+	}
+
+	user, err := resolveUserFromRequest(r)
+	if err != nil {
+		response.WriteErr(w, http.StatusUnauthorized, "Token inválido o expirado")
+		return
+	}
+
+	q := r.URL.Query()
+	query := strings.TrimSpace(q.Get("q"))
+	if query == "" || len(query) > maxSearchQueryLength {
+		response.WriteErr(w, http.StatusBadRequest, "q es obligatorio (máximo 200 caracteres)")
+		return
+	}
+	limit := 0
+	if raw := q.Get("limit"); raw != "" {
+		v, err := strconv.Atoi(raw)
+		if err != nil || v <= 0 {
+			response.WriteErr(w, http.StatusBadRequest, "limit inválido")
+			return
+		}
+		limit = v
+	}
+
+	channels, err := services.AccessibleChannelCodes(config.DB, user.ID)
+	if err != nil {
+		log.Printf("[BUSQUEDA] usuario=%d error=%v", user.ID, err)
+		response.WriteErr(w, http.StatusInternalServerError, "No se pudo realizar la búsqueda")
+		return
+	}
+	if current := user.GetCurrentChannelCode(); current != "" && !slices.Contains(channels, current) {
+		channels = append(channels, current)
+	}
+
+	items, err := services.SearchTranscripts(config.DB, channels, query, limit)
+	if errors.Is(err, services.ErrEmptyQuery) {
+		response.WriteErr(w, http.StatusBadRequest, "La búsqueda no contiene palabras")
+		return
+	}
+	if err != nil {
+		log.Printf("[BUSQUEDA] usuario=%d error=%v", user.ID, err)
+		response.WriteErr(w, http.StatusInternalServerError, "No se pudo realizar la búsqueda")
+		return
+	}
+
+	out := make([]searchHit, 0, len(items))
+	for _, t := range items {
+		out = append(out, searchHit{
+			ID:         t.ID,
+			Channel:    t.ChannelCode,
+			SenderID:   t.SenderID,
+			SenderName: t.SenderName,
+			Text:       t.Text,
+			Timestamp:  t.CreatedAt.UTC().Format(time.RFC3339Nano),
+		})
+	}
+	response.WriteJSON(w, http.StatusOK, map[string]any{
+		"query":   query,
+		"results": out,
+	})
+}
@@ -0,0 +1,47 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"walkie-backend/internal/config"
+	"walkie-backend/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSearch_OnlyAccessibleChannels(t *testing.T) {
+	db := setupTestDB(t)
+	require.NoError(t, db.AutoMigrate(&models.Transcript{}))
+	require.NoError(t, config.EnsureTranscriptSearch(db))
+	user := createTestUser(t, db, 151, "token-search", "canal-s")
+
+	require.NoError(t, db.Create(&models.Transcript{ChannelCode: "canal-s", SenderID: 151, SenderName: "testuser", Text: "la ambulancia está en la entrada"}).Error)
+	require.NoError(t, db.Create(&models.Transcript{ChannelCode: "canal-ajeno", SenderID: 9, Text: "ambulancia en la salida"}).Error)
+
+	search := func(query string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/search?q="+url.QueryEscape(query), nil)
+		req = req.WithContext(withAuthUser(req.Context(), user))
+		rec := httptest.NewRecorder()
+		Search(rec, req)
+		return rec
+	}
+
+	rec := search("ambulancia")
+	require.Equal(t, http.StatusOK, rec.Code)
+	var body struct {
+		Results []searchHit `json:"results"`
+	}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+	require.Len(t, body.Results, 1)
+	assert.Equal(t, "canal-s", body.Results[0].Channel)
+	assert.Equal(t, "testuser", body.Results[0].SenderName)
+	assert.NotEmpty(t, body.Results[0].Timestamp)
+
+	assert.Equal(t, http.StatusBadRequest, search("").Code)
+	assert.Equal(t, http.StatusBadRequest, search("¿?").Code)
+}
@@ -0,0 +1,84 @@
+package handlers
+
+import (
+	"sync"
+	"time"
+
+	"walkie-backend/internal/config"
+	"walkie-backend/pkg/qwen"
+)
+
+const (
+	defaultSessionContextTTL = 5 * time.Minute
+	// sessionContextSize es cuántas frases recientes se pasan al análisis
+	sessionContextSize = 3
+)
+
+// sessionEntry es la conversación reciente de un usuario con el asistente
+type sessionEntry struct {
+	transcripts []string
+	lastIntent  string
+	updatedAt   time.Time
+}
+
+var sessions = struct {
+	sync.Mutex
+	byUser map[uint]*sessionEntry
+}{
+	byUser: make(map[uint]*sessionEntry),
+}
+
+// sessionContextTTL es cuánto se recuerda la conversación tras la última frase (SESSION_CONTEXT_TTL)
+func sessionContextTTL() time.Duration {
+	return config.DurationFromEnv("SESSION_CONTEXT_TTL", defaultSessionContextTTL)
+}
+
+// sessionContextFor devuelve las últimas frases y el último intent del usuario si siguen vigentes
+func sessionContextFor(userID uint, now time.Time) qwen.SessionContext {
+	sessions.Lock()
+	defer sessions.Unlock()
+
+	entry := sessions.byUser[userID]
+	if entry == nil {
+		return qwen.SessionContext{}
+	}
+	if now.Sub(entry.updatedAt) > sessionContextTTL() {
+		delete(sessions.byUser, userID)
+		return qwen.SessionContext{}
+	}
+	return qwen.SessionContext{
+		Transcripts: append([]string(nil), entry.transcripts...),
+		LastIntent:  entry.lastIntent,
+	}
+}
+
+// recordSessionContext añade la frase analizada y su intent, conservando sólo las últimas
+func recordSessionContext(userID uint, transcript, intent string, now time.Time) {
+	sessions.Lock()
+	defer sessions.Unlock()
+
+	entry := sessions.byUser[userID]
+	if entry == nil || now.Sub(entry.updatedAt) > sessionContextTTL() {
+		entry = &sessionEntry{}
+		sessions.byUser[userID] = entry
+	}
+	entry.transcripts = append(entry.transcripts, transcript)
+	if len(entry.transcripts) > sessionContextSize {
+		entry.transcripts = entry.transcripts[len(entry.transcripts)-sessionContextSize:]
+	}
+	entry.lastIntent = intent
+	entry.updatedAt = now
+}
+
+// pruneSessionContexts olvida las conversaciones caducadas
+func pruneSessionContexts(now time.Time) {
+	ttl := sessionContextTTL()
+
+	sessions.Lock()
+	defer sessions.Unlock()
+	for userID, entry := range sessions.byUser {
+		if now.Sub(entry.updatedAt) > ttl {
+			delete(sessions.byUser, userID)
+		}
+	}
+}
@@ -0,0 +1,41 @@
+package handlers
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSessionContext_KeepsLastTranscriptsAndIntent(t *testing.T) {
+	now := time.Now()
+	defer pruneSessionContexts(now.Add(time.Hour))
+
+	recordSessionContext(71, "hola", "conversation", now)
+	recordSessionContext(71, "lista de canales", "request_channel_list", now)
+	recordSessionContext(71, "conéctame al uno", "request_channel_connect", now)
+	recordSessionContext(71, "ahora al tres", "request_channel_connect", now.Add(time.Second))
+
+	sc := sessionContextFor(71, now.Add(2*time.Second))
+	assert.Equal(t, []string{"lista de canales", "conéctame al uno", "ahora al tres"}, sc.Transcripts)
+	assert.Equal(t, "request_channel_connect", sc.LastIntent)
+	assert.Empty(t, sessionContextFor(72, now).Transcripts)
+}
+
+func TestSessionContext_Expires(t *testing.T) {
+	t.Setenv("SESSION_CONTEXT_TTL", "1m")
+	now := time.Now()
+
+	recordSessionContext(73, "conéctame al uno", "request_channel_connect", now)
+	assert.Empty(t, sessionContextFor(73, now.Add(2*time.Minute)).LastIntent)
+
+	// Una frase tras la caducidad empieza una conversación nueva
+	recordSessionContext(74, "hola", "conversation", now)
+	recordSessionContext(74, "el dos", "request_channel_connect", now.Add(2*time.Minute))
+	assert.Equal(t, []string{"el dos"}, sessionContextFor(74, now.Add(2*time.Minute)).Transcripts)
+
+	pruneSessionContexts(now.Add(10 * time.Minute))
+	sessions.Lock()
+	assert.Empty(t, sessions.byUser)
+	sessions.Unlock()
+}
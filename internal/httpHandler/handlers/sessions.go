@@ -0,0 +1,242 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"walkie-backend/internal/config"
+	"walkie-backend/internal/keyring"
+	"walkie-backend/internal/models"
+	"walkie-backend/internal/response"
+
+	"gorm.io/gorm"
+)
+
+const (
+	// sessionTouchInterval limita cuántas veces se escribe LastSeenAt de una sesión
+	sessionTouchInterval = time.Minute
+	maxDeviceNameLen     = 100
+	maxUserAgentLen      = 255
+)
+
+// newSession registra el inicio de sesión de un dispositivo con el hash de su token
+func newSession(db *gorm.DB, userID uint, token, deviceName, platform, userAgent string) (*models.Session, error) {
+	now := time.Now()
+	session := models.Session{
+		UserID:     userID,
+		TokenHash:  hashToken(token),
+		DeviceName: truncateRunes(strings.TrimSpace(deviceName), maxDeviceNameLen),
+		Platform:   sessionPlatform(platform),
+		UserAgent:  truncateRunes(userAgent, maxUserAgentLen),
+		CreatedAt:  now,
+		LastSeenAt: now,
+	}
+	if err := db.Create(&session).Error; err != nil {
+		return nil, fmt.Errorf("guardar sesión: %w", err)
+	}
+	return &session, nil
+}
+
+// sessionPlatform acepta las mismas plataformas que POST /devices; otra cosa se ignora
+func sessionPlatform(platform string) string {
+	switch p := strings.ToLower(strings.TrimSpace(platform)); p {
+	case models.DevicePlatformAndroid, models.DevicePlatformIOS, models.DevicePlatformWeb:
+		return p
+	}
+	return ""
+}
+
+func truncateRunes(s string, n int) string {
+	if utf8.RuneCountInString(s) <= n {
+		return s
+	}
+	return string([]rune(s)[:n])
+}
+
+// findSessionByToken busca la sesión del token opaco. Los tokens emitidos antes de
+// las sesiones no tienen fila y se siguen validando con users.auth_token.
+func findSessionByToken(token string) (*models.Session, bool) {
+	var session models.Session
+	if err := config.DB.Where("token_hash = ?", hashToken(token)).First(&session).Error; err != nil {
+		return nil, false
+	}
+	return &session, true
+}
+
+// findUserBySession valida la sesión (revocada o caducada por inactividad) y devuelve su usuario
+func findUserBySession(session *models.Session) (*models.User, error) {
+	if !session.IsActive() {
+		return nil, errTokenRevoked
+	}
+	ttl := authTokenTTL()
+	if ttl > 0 && session.LastSeenAt.Add(ttl).Before(time.Now()) {
+		return nil, errTokenExpired
+	}
+
+	var user models.User
+	if err := config.DB.Preload("CurrentChannel").First(&user, session.UserID).Error; err != nil {
+		return nil, err
+	}
+	touchSession(session)
+	return &user, nil
+}
+
+func touchSession(session *models.Session) {
+	now := time.Now()
+	if now.Sub(session.LastSeenAt) < sessionTouchInterval {
+		return
+	}
+	if err := config.DB.Model(&models.Session{}).Where("id = ?", session.ID).
+		Update("last_seen_at", now).Error; err != nil {
+		log.Printf("[SESIONES] no se pudo actualizar la sesión %d: %v", session.ID, err)
+	}
+}
+
+// sessionIsActive comprueba que la sesión de un JWT o token de refresco siga abierta
+func sessionIsActive(db *gorm.DB, sessionID uint) bool {
+	var count int64
+	err := db.Model(&models.Session{}).
+		Where("id = ? AND revoked_at IS NULL", sessionID).
+		Count(&count).Error
+	return err == nil && count > 0
+}
+
+// hasActiveSessions indica si al usuario le queda algún dispositivo con la sesión abierta
+func hasActiveSessions(db *gorm.DB, userID uint) bool {
+	var count int64
+	err := db.Model(&models.Session{}).
+		Where("user_id = ? AND revoked_at IS NULL", userID).
+		Count(&count).Error
+	return err == nil && count > 0
+}
+
+// currentSessionID devuelve la sesión del token de la petición, o 0 si no tiene
+func currentSessionID(r *http.Request) uint {
+	token := requestToken(r)
+	if token == "" {
+		return 0
+	}
+	if keyring.LooksLikeJWT(token) {
+		claims, err := parseAccessToken(token)
+		if err != nil {
+			return 0
+		}
+		return claims.SessionID
+	}
+	if session, ok := findSessionByToken(token); ok {
+		return session.ID
+	}
+	return 0
+}
+
+// revokeSession cierra una sesión del usuario y los tokens de refresco que emitió
+func revokeSession(db *gorm.DB, userID, sessionID uint) error {
+	now := time.Now()
+	return db.Transaction(func(tx *gorm.DB) error {
+		res := tx.Model(&models.Session{}).
+			Where("id = ? AND user_id = ? AND revoked_at IS NULL", sessionID, userID).
+			Update("revoked_at", now)
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		return tx.Model(&models.RefreshToken{}).
+			Where("session_id = ? AND revoked_at IS NULL", sessionID).
+			Update("revoked_at", now).Error
+	})
+}
+
+// revokeUserSessions cierra todas las sesiones del usuario
+func revokeUserSessions(db *gorm.DB, userID uint) error {
+	return db.Model(&models.Session{}).
+		Where("user_id = ? AND revoked_at IS NULL", userID).
+		Update("revoked_at", time.Now()).Error
+}
+
+type deviceItem struct {
+	ID         uint   `json:"id"`
+	Name       string `json:"name,omitempty"`
+	Platform   string `json:"platform,omitempty"`
+	UserAgent  string `json:"userAgent,omitempty"`
+	CreatedAt  string `json:"createdAt"`
+	LastSeenAt string `json:"lastSeenAt"`
+	Current    bool   `json:"current"`
+}
+
+// GET /me/devices
+// Lista los dispositivos con la sesión abierta, del más reciente al más antiguo
+func MeDevices(w http.ResponseWriter, r *http.Request) {
+	if !requireDB(w) {
+		return
+	}
+	user, err := resolveUserFromRequest(r)
+	if err != nil {
+		response.WriteErr(w, http.StatusUnauthorized, "Token inválido o expirado")
+		return
+	}
+
+	var sessions []models.Session
+	if err := config.DB.Where("user_id = ? AND revoked_at IS NULL", user.ID).
+		Order("last_seen_at DESC, id DESC").
+		Find(&sessions).Error; err != nil {
+		log.Printf("[SESIONES] usuario=%d error listando sesiones: %v", user.ID, err)
+		response.WriteErr(w, http.StatusInternalServerError, "No se pudieron listar los dispositivos")
+		return
+	}
+
+	current := currentSessionID(r)
+	items := make([]deviceItem, 0, len(sessions))
+	for _, s := range sessions {
+		items = append(items, deviceItem{
+			ID:         s.ID,
+			Name:       s.DeviceName,
+			Platform:   s.Platform,
+			UserAgent:  s.UserAgent,
+			CreatedAt:  s.CreatedAt.UTC().Format(time.RFC3339),
+			LastSeenAt: s.LastSeenAt.UTC().Format(time.RFC3339),
+			Current:    s.ID == current,
+		})
+	}
+	response.WriteJSON(w, http.StatusOK, map[string]any{"devices": items})
+}
+
+// DELETE /me/devices/{id}
+// Cierra la sesión de un dispositivo; los demás siguen conectados
+func RevokeMeDevice(w http.ResponseWriter, r *http.Request) {
+	if !requireDB(w) {
+		return
+	}
+	user, err := resolveUserFromRequest(r)
+	if err != nil {
+		response.WriteErr(w, http.StatusUnauthorized, "Token inválido o expirado")
+		return
+	}
+
+	id, err := strconv.ParseUint(r.PathValue("id"), 10, 64)
+	if err != nil || id == 0 {
+		response.WriteErr(w, http.StatusBadRequest, "id de dispositivo inválido")
+		return
+	}
+
+	if err := revokeSession(config.DB, user.ID, uint(id)); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			response.WriteErr(w, http.StatusNotFound, "Dispositivo no encontrado")
+			return
+		}
+		log.Printf("[SESIONES] usuario=%d error revocando sesión %d: %v", user.ID, id, err)
+		response.WriteErr(w, http.StatusInternalServerError, "No se pudo cerrar la sesión del dispositivo")
+		return
+	}
+	forgetDeviceSession(user.ID, uint(id))
+
+	log.Printf("[SESIONES] usuario=%d sesión %d cerrada", user.ID, id)
+	response.WriteJSON(w, http.StatusOK, map[string]any{"id": id, "status": "revoked"})
+}
@@ -0,0 +1,91 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func loginFromDevice(t *testing.T, device string) AuthenticationResponse {
+	t.Helper()
+	body := fmt.Sprintf(`{"nombre":"multi","pin":2468,"dispositivo":%q,"plataforma":"android"}`, device)
+	req := httptest.NewRequest(http.MethodPost, "/auth", bytes.NewBufferString(body))
+	rec := httptest.NewRecorder()
+	Authenticate(rec, req)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+	var resp AuthenticationResponse
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+	require.NotZero(t, resp.SessionID)
+	return resp
+}
+
+func TestSessions_MultipleDevicesAndRevoke(t *testing.T) {
+	setupTokenTestDB(t)
+	phone := loginFromDevice(t, "Teléfono")
+	tablet := loginFromDevice(t, "Tablet")
+
+	// Iniciar sesión en la tablet no cierra la del teléfono
+	for _, token := range []string{phone.Token, tablet.Token, phone.AccessToken} {
+		_, err := findUserByToken(token)
+		require.NoError(t, err)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/me/devices", nil)
+	req.Header.Set("X-Auth-Token", tablet.Token)
+	rec := httptest.NewRecorder()
+	MeDevices(rec, req)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	var listed struct {
+		Devices []deviceItem `json:"devices"`
+	}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &listed))
+	require.Len(t, listed.Devices, 2)
+	byID := map[uint]deviceItem{}
+	for _, d := range listed.Devices {
+		byID[d.ID] = d
+	}
+	assert.True(t, byID[tablet.SessionID].Current)
+	assert.Equal(t, "Teléfono", byID[phone.SessionID].Name)
+	assert.Equal(t, "android", byID[phone.SessionID].Platform)
+
+	revoke := func(id string) int {
+		req := httptest.NewRequest(http.MethodDelete, "/me/devices/"+id, nil)
+		req.Header.Set("X-Auth-Token", tablet.Token)
+		req.SetPathValue("id", id)
+		rec := httptest.NewRecorder()
+		RevokeMeDevice(rec, req)
+		return rec.Code
+	}
+	owner, err := findUserByToken(tablet.Token)
+	require.NoError(t, err)
+	expires := time.Now().Add(time.Minute)
+	putCachedSession(t, phone.AccessToken, cachedSession{userID: owner.ID, sessionID: phone.SessionID, expiresAt: expires})
+	putCachedSession(t, tablet.AccessToken, cachedSession{userID: owner.ID, sessionID: tablet.SessionID, expiresAt: expires})
+
+	assert.Equal(t, http.StatusOK, revoke(fmt.Sprint(phone.SessionID)))
+	// El modo degradado olvida sólo el token del dispositivo revocado
+	_, cached := lookupCachedSession(phone.AccessToken)
+	assert.False(t, cached)
+	_, cached = lookupCachedSession(tablet.AccessToken)
+	assert.True(t, cached)
+	assert.Equal(t, http.StatusNotFound, revoke(fmt.Sprint(phone.SessionID)))
+	assert.Equal(t, http.StatusBadRequest, revoke("abc"))
+
+	_, err = findUserByToken(phone.Token)
+	assert.ErrorIs(t, err, errTokenRevoked)
+	_, err = findUserByToken(phone.AccessToken)
+	assert.ErrorIs(t, err, errTokenRevoked)
+	assert.Equal(t, http.StatusUnauthorized, refreshWith(phone.RefreshToken).Code)
+
+	_, err = findUserByToken(tablet.Token)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, refreshWith(tablet.RefreshToken).Code)
+}
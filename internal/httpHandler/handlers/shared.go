@@ -1,32 +1,96 @@
 package handlers
 
 import (
+	"net/http"
+	"strings"
 	"sync"
 
-	"walkie-backend/pkg/qwen"
+	"walkie-backend/internal/ai"
+	"walkie-backend/internal/config"
+	"walkie-backend/internal/response"
 	"walkie-backend/pkg/stt"
+	"walkie-backend/pkg/tts"
 )
 
 var (
+	// clientsMu protege los clientes externos, que se vuelven a crear cuando cambia su
+	// configuración, tras un fallo o con ResetExternalClients
+	clientsMu sync.Mutex
+
 	onceAI   sync.Once
-	aiClient *qwen.Client
+	aiClient ai.Analyzer
 	aiErr    error
+	aiGen    = clientGen{name: "ai", keys: aiEnvKeys}
 
 	onceSTT sync.Once
 	sClient *stt.Client
 	sErr    error
+	sttGen  = clientGen{name: "stt", keys: sttEnvKeys}
+
+	onceTTS sync.Once
+	tClient *tts.Client
+	tErr    error
+	ttsGen  = clientGen{name: "tts", keys: ttsEnvKeys}
 )
 
-func EnsureAIClient() (*qwen.Client, error) {
+// EnsureAIClient crea el analizador del proveedor elegido en AI_PROVIDER y lo reutiliza
+// mientras no cambie su configuración
+func EnsureAIClient() (ai.Analyzer, error) {
+	clientsMu.Lock()
+	defer clientsMu.Unlock()
+	if aiGen.stale(aiErr) {
+		resetAILocked(true)
+	}
 	onceAI.Do(func() {
-		aiClient, aiErr = qwen.NewClient()
+		aiClient, aiErr = ai.New()
+		aiGen.initialized()
 	})
 	return aiClient, aiErr
 }
 
 func EnsureSTTClient() (*stt.Client, error) {
+	clientsMu.Lock()
+	defer clientsMu.Unlock()
+	if sttGen.stale(sErr) {
+		resetSTTLocked(true)
+	}
 	onceSTT.Do(func() {
 		sClient, sErr = stt.NewClient()
+		sttGen.initialized()
 	})
 	return sClient, sErr
 }
+
+// EnsureTTSClient crea el cliente de síntesis de voz del asistente
+func EnsureTTSClient() (*tts.Client, error) {
+	clientsMu.Lock()
+	defer clientsMu.Unlock()
+	if ttsGen.stale(tErr) {
+		resetTTSLocked(true)
+	}
+	onceTTS.Do(func() {
+		tClient, tErr = tts.NewClient()
+		ttsGen.initialized()
+	})
+	return tClient, tErr
+}
+
+// requireDB responde 503 cuando la base de datos está caída
+func requireDB(w http.ResponseWriter) bool {
+	if config.DBAvailable() {
+		return true
+	}
+	w.Header().Set("Retry-After", "5")
+	response.WriteErr(w, http.StatusServiceUnavailable, "Servicio temporalmente no disponible")
+	return false
+}
+
+// requestID reutiliza X-Request-ID del cliente o genera uno, y lo devuelve en la respuesta
+func requestID(w http.ResponseWriter, r *http.Request) string {
+	id := strings.TrimSpace(r.Header.Get("X-Request-ID"))
+	if id == "" {
+		id, _ = generateToken(8)
+	}
+	w.Header().Set("X-Request-ID", id)
+	return id
+}
@@ -0,0 +1,140 @@
+package handlers
+
+import (
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	"walkie-backend/internal/config"
+	"walkie-backend/pkg/audio"
+)
+
+const (
+	speakerWindowSize   = 20
+	tipLowVolume        = "Habla más cerca del micrófono"
+	tipClipping         = "Aléjate un poco del micrófono, el audio llega saturado"
+	tipTooShort         = "Mantén presionado el botón mientras terminas de hablar"
+	tipGeneric          = "Habla más despacio y con claridad"
+	lowVolumeRMS        = 400
+	clippedRatioLimit   = 0.02
+	shortClipDuration   = 700 * time.Millisecond
+	defaultTipFailRatio = 0.5
+	defaultTipMinClips  = 5
+	defaultTipCooldown  = 10 * time.Minute
+)
+
+// clipOutcome guarda el resultado de STT y la calidad medida de un clip
+type clipOutcome struct {
+	failed    bool
+	lowVolume bool
+	clipped   bool
+	tooShort  bool
+}
+
+type speakerStats struct {
+	outcomes []clipOutcome
+	lastTip  time.Time
+}
+
+type speakerFeedbackConfig struct {
+	failRatio float64
+	minClips  int
+	cooldown  time.Duration
+}
+
+var speakerTracker = struct {
+	sync.Mutex
+	byUser map[uint]*speakerStats
+}{
+	byUser: make(map[uint]*speakerStats),
+}
+
+func loadSpeakerFeedbackConfig() speakerFeedbackConfig {
+	return speakerFeedbackConfig{
+		failRatio: config.FloatFromEnv("SPEAKER_TIP_FAIL_RATIO", defaultTipFailRatio, 0, 1),
+		minClips:  config.PositiveIntFromEnv("SPEAKER_TIP_MIN_CLIPS", defaultTipMinClips),
+		cooldown:  config.DurationFromEnv("SPEAKER_TIP_COOLDOWN", defaultTipCooldown),
+	}
+}
+
+func measureClip(data []byte, failed bool) clipOutcome {
+	out := clipOutcome{failed: failed}
+	if q, ok := audio.AnalyzeWAV(data); ok {
+		out.lowVolume = q.RMS < lowVolumeRMS
+		out.clipped = q.ClippedRatio > clippedRatioLimit
+		out.tooShort = q.Duration < shortClipDuration
+	}
+	return out
+}
+
+// recordSpeakerOutcome actualiza las estadísticas del usuario y devuelve un consejo
+// cuando la proporción de fallos supera el umbral y no se ha enviado otro recientemente
+func recordSpeakerOutcome(userID uint, outcome clipOutcome, cfg speakerFeedbackConfig, now time.Time) string {
+	speakerTracker.Lock()
+	defer speakerTracker.Unlock()
+
+	stats := speakerTracker.byUser[userID]
+	if stats == nil {
+		stats = &speakerStats{}
+		speakerTracker.byUser[userID] = stats
+	}
+	stats.outcomes = append(stats.outcomes, outcome)
+	if len(stats.outcomes) > speakerWindowSize {
+		stats.outcomes = stats.outcomes[len(stats.outcomes)-speakerWindowSize:]
+	}
+
+	if len(stats.outcomes) < cfg.minClips || now.Sub(stats.lastTip) < cfg.cooldown {
+		return ""
+	}
+
+	failures, lowVolume, clipped, short := 0, 0, 0, 0
+	for _, o := range stats.outcomes {
+		if !o.failed {
+			continue
+		}
+		failures++
+		if o.lowVolume {
+			lowVolume++
+		}
+		if o.clipped {
+			clipped++
+		}
+		if o.tooShort {
+			short++
+		}
+	}
+	if float64(failures)/float64(len(stats.outcomes)) < cfg.failRatio {
+		return ""
+	}
+
+	stats.lastTip = now
+	switch {
+	case lowVolume*2 >= failures:
+		return tipLowVolume
+	case clipped*2 >= failures:
+		return tipClipping
+	case short*2 >= failures:
+		return tipTooShort
+	default:
+		return tipGeneric
+	}
+}
+
+// noteSpeakerOutcome registra el clip y, si corresponde, envía el consejo por
+// WebSocket y en el header X-Speaker-Tip para que el cliente lo reproduzca con voz
+func noteSpeakerOutcome(w http.ResponseWriter, userID uint, data []byte, failed bool) {
+	tip := recordSpeakerOutcome(userID, measureClip(data, failed), loadSpeakerFeedbackConfig(), time.Now())
+	if tip == "" {
+		return
+	}
+
+	log.Printf("[CALIDAD] usuario=%d consejo=%q", userID, tip)
+	if w != nil {
+		w.Header().Set("X-Speaker-Tip", tip)
+	}
+	sendJSONToUser(userID, map[string]string{
+		"type":    "speaker_tip",
+		"message": tip,
+	})
+}
@@ -0,0 +1,69 @@
+package handlers
+
+import (
+	"testing"
+	"time"
+)
+
+func resetSpeakerTracker() {
+	speakerTracker.Lock()
+	speakerTracker.byUser = make(map[uint]*speakerStats)
+	speakerTracker.Unlock()
+}
+
+func TestRecordSpeakerOutcome_TipsAfterRepeatedFailures(t *testing.T) {
+	resetSpeakerTracker()
+	cfg := speakerFeedbackConfig{failRatio: 0.5, minClips: 3, cooldown: time.Hour}
+	now := time.Now()
+
+	failed := clipOutcome{failed: true, lowVolume: true}
+	if tip := recordSpeakerOutcome(1, failed, cfg, now); tip != "" {
+		t.Fatalf("expected no tip before minClips, got %q", tip)
+	}
+	recordSpeakerOutcome(1, failed, cfg, now)
+	if tip := recordSpeakerOutcome(1, failed, cfg, now); tip != tipLowVolume {
+		t.Fatalf("expected low volume tip, got %q", tip)
+	}
+
+	if tip := recordSpeakerOutcome(1, failed, cfg, now.Add(time.Minute)); tip != "" {
+		t.Fatalf("expected cooldown to suppress tip, got %q", tip)
+	}
+	if tip := recordSpeakerOutcome(1, failed, cfg, now.Add(2*time.Hour)); tip == "" {
+		t.Fatal("expected tip after cooldown")
+	}
+}
+
+func TestRecordSpeakerOutcome_NoTipWhenMostlySuccessful(t *testing.T) {
+	resetSpeakerTracker()
+	cfg := speakerFeedbackConfig{failRatio: 0.5, minClips: 3, cooldown: time.Hour}
+	now := time.Now()
+
+	recordSpeakerOutcome(2, clipOutcome{failed: true}, cfg, now)
+	recordSpeakerOutcome(2, clipOutcome{}, cfg, now)
+	recordSpeakerOutcome(2, clipOutcome{}, cfg, now)
+	if tip := recordSpeakerOutcome(2, clipOutcome{}, cfg, now); tip != "" {
+		t.Fatalf("expected no tip, got %q", tip)
+	}
+}
+
+func TestRecordSpeakerOutcome_PicksDominantIssue(t *testing.T) {
+	resetSpeakerTracker()
+	cfg := speakerFeedbackConfig{failRatio: 0.5, minClips: 2, cooldown: time.Hour}
+	now := time.Now()
+
+	recordSpeakerOutcome(3, clipOutcome{failed: true, clipped: true}, cfg, now)
+	if tip := recordSpeakerOutcome(3, clipOutcome{failed: true, clipped: true}, cfg, now); tip != tipClipping {
+		t.Fatalf("expected clipping tip, got %q", tip)
+	}
+}
+
+func TestLoadSpeakerFeedbackConfig_FromEnv(t *testing.T) {
+	t.Setenv("SPEAKER_TIP_FAIL_RATIO", "0.8")
+	t.Setenv("SPEAKER_TIP_MIN_CLIPS", "10")
+	t.Setenv("SPEAKER_TIP_COOLDOWN", "1h")
+
+	cfg := loadSpeakerFeedbackConfig()
+	if cfg.failRatio != 0.8 || cfg.minClips != 10 || cfg.cooldown != time.Hour {
+		t.Fatalf("unexpected config %+v", cfg)
+	}
+}
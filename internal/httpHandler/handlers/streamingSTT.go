@@ -0,0 +1,126 @@
+package handlers
+
+import (
+	"context"
+	"io"
+	"log"
+	"mime"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"walkie-backend/pkg/audio"
+)
+
+type streamingSTTClient interface {
+	TranscribeWAVStream(ctx context.Context, r io.Reader) (string, error)
+}
+
+// streamingSTTEnabled activa la transcripción en streaming con STT_STREAMING=true
+func streamingSTTEnabled() bool {
+	return strings.EqualFold(strings.TrimSpace(os.Getenv("STT_STREAMING")), "true")
+}
+
+// defaultStreamingSTT devuelve el cliente de streaming o nil si está desactivado
+func defaultStreamingSTT() streamingSTTClient {
+	if !streamingSTTEnabled() {
+		return nil
+	}
+	client, err := EnsureSTTClient()
+	if err != nil {
+		return nil
+	}
+	return client
+}
+
+type streamResult struct {
+	text string
+	err  error
+}
+
+// streamingTranscript es una transcripción que avanza mientras llega la subida
+type streamingTranscript struct {
+	result    chan streamResult
+	cancel    context.CancelFunc
+	pw        *io.PipeWriter
+	closeOnce sync.Once
+	startedAt time.Time
+}
+
+// teeBody copia el cuerpo de la petición a la transcripción en streaming según se lee
+type teeBody struct {
+	io.Reader
+	body   io.Closer
+	stream *streamingTranscript
+}
+
+func (b *teeBody) Close() error {
+	b.stream.uploadDone()
+	return b.body.Close()
+}
+
+// startStreamingStage empieza a transcribir el cuerpo de la petición antes de que termine
+// la subida. Sólo aplica a WAV enviado directamente (sin multipart); devuelve nil si no.
+func startStreamingStage(ctx context.Context, r *http.Request, deps audioIngestDeps, userID uint) *streamingTranscript {
+	if deps.streamingSTT == nil || r.Body == nil {
+		return nil
+	}
+	mt, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if err != nil || audio.FormatForMime(mt) != audio.FormatWAV {
+		return nil
+	}
+	client := deps.streamingSTT()
+	if client == nil {
+		return nil
+	}
+
+	streamCtx, cancel := context.WithCancel(ctx)
+	pr, pw := io.Pipe()
+	stream := &streamingTranscript{
+		result:    make(chan streamResult, 1),
+		cancel:    cancel,
+		pw:        pw,
+		startedAt: time.Now(),
+	}
+	r.Body = &teeBody{Reader: io.TeeReader(r.Body, pw), body: r.Body, stream: stream}
+
+	go func() {
+		text, err := client.TranscribeWAVStream(streamCtx, pr)
+		// Seguir vaciando la tubería para no bloquear la lectura de la subida
+		_, _ = io.Copy(io.Discard, pr)
+		stream.result <- streamResult{text: text, err: err}
+	}()
+
+	log.Printf("[STT] usuario=%d transcripcion_streaming=iniciada", userID)
+	return stream
+}
+
+// uploadDone marca el final de la subida; es seguro llamarlo varias veces
+func (s *streamingTranscript) uploadDone() {
+	if s == nil {
+		return
+	}
+	s.closeOnce.Do(func() { _ = s.pw.Close() })
+}
+
+// stop cierra la subida y cancela la sesión si nadie va a usar el resultado
+func (s *streamingTranscript) stop() {
+	if s == nil {
+		return
+	}
+	s.uploadDone()
+	s.cancel()
+}
+
+// wait espera el texto de la sesión de streaming
+func (s *streamingTranscript) wait(ctx context.Context) (string, error) {
+	s.uploadDone()
+	select {
+	case res := <-s.result:
+		return res.text, res.err
+	case <-ctx.Done():
+		return "", ctx.Err()
+	}
+}
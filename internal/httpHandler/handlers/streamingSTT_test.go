@@ -0,0 +1,86 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"walkie-backend/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+type mockStreamingSTT struct {
+	text     string
+	err      error
+	received int
+}
+
+func (m *mockStreamingSTT) TranscribeWAVStream(ctx context.Context, r io.Reader) (string, error) {
+	n, _ := io.Copy(io.Discard, r)
+	m.received = int(n)
+	return m.text, m.err
+}
+
+func streamingTestDeps(stream *mockStreamingSTT, batch *mockSTT) audioIngestDeps {
+	mockUser := &models.User{Model: gorm.Model{ID: 51}, DisplayName: "stream"}
+	deps := newAudioIngestDeps()
+	deps.readUserID = func(*http.Request) (uint, error) { return 51, nil }
+	deps.newUserService = func() userService { return &mockUserService{user: mockUser} }
+	deps.localSTT = func() sttClient { return nil }
+	deps.ensureSTT = func() (sttClient, error) { return batch, nil }
+	deps.streamingSTT = func() streamingSTTClient { return stream }
+	deps.isCoherent = func(string) bool { return false }
+	return deps
+}
+
+func TestAudioIngest_StreamingTranscription(t *testing.T) {
+	stream := &mockStreamingSTT{text: "hola"}
+	batch := &mockSTT{err: errors.New("no debería llamarse")}
+	deps := streamingTestDeps(stream, batch)
+	var seen string
+	deps.isCoherent = func(s string) bool { seen = s; return false }
+
+	wav := buildTestWAV(8000)
+	req := httptest.NewRequest(http.MethodPost, "/audio/ingest", bytes.NewReader(wav))
+	req.Header.Set("Content-Type", "audio/wav")
+	rec := httptest.NewRecorder()
+	runAudioIngest(rec, req, deps)
+
+	assert.Equal(t, "hola", seen)
+	assert.Equal(t, len(wav), stream.received)
+	assert.Empty(t, batch.format)
+}
+
+func TestAudioIngest_StreamingFallsBackToBatch(t *testing.T) {
+	stream := &mockStreamingSTT{err: errors.New("socket cerrado")}
+	batch := &mockSTT{text: "adiós"}
+	deps := streamingTestDeps(stream, batch)
+	var seen string
+	deps.isCoherent = func(s string) bool { seen = s; return false }
+
+	req := httptest.NewRequest(http.MethodPost, "/audio/ingest", bytes.NewReader(buildTestWAV(8000)))
+	req.Header.Set("Content-Type", "audio/wav")
+	runAudioIngest(httptest.NewRecorder(), req, deps)
+
+	assert.Equal(t, "adiós", seen)
+	assert.Equal(t, "audio/wav", batch.format)
+}
+
+func TestStartStreamingStage_SkipsNonWAV(t *testing.T) {
+	deps := newAudioIngestDeps()
+	deps.streamingSTT = func() streamingSTTClient { return &mockStreamingSTT{} }
+
+	req := httptest.NewRequest(http.MethodPost, "/audio/ingest", bytes.NewReader([]byte("x")))
+	req.Header.Set("Content-Type", "multipart/form-data; boundary=abc")
+	assert.Nil(t, startStreamingStage(context.Background(), req, deps, 1))
+
+	deps.streamingSTT = func() streamingSTTClient { return nil }
+	req.Header.Set("Content-Type", "audio/wav")
+	assert.Nil(t, startStreamingStage(context.Background(), req, deps, 1))
+}
@@ -0,0 +1,175 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"walkie-backend/internal/ai"
+	"walkie-backend/internal/config"
+	"walkie-backend/internal/models"
+	"walkie-backend/internal/response"
+	"walkie-backend/internal/services"
+	"walkie-backend/pkg/lang"
+)
+
+const (
+	intentChannelSummary = "request_channel_summary"
+
+	maxSummaryTranscripts = 100
+	summaryTimeout        = 60 * time.Second
+	noRecentConversation  = "No hay conversación reciente en el canal"
+)
+
+var errSummaryUnsupported = errors.New("el proveedor de IA no permite resumir")
+
+// summaryAnalyzer devuelve el cliente de IA que genera los resúmenes
+var summaryAnalyzer = EnsureAIClient
+
+type channelSummary struct {
+	Channel     string `json:"channel"`
+	Summary     string `json:"summary"`
+	Transcripts int    `json:"transcripts"`
+	Since       string `json:"since,omitempty"`
+	Until       string `json:"until,omitempty"`
+}
+
+// summaryTranscripts es cuántas transcripciones se resumen (SUMMARY_TRANSCRIPTS, 30 por defecto)
+func summaryTranscripts() int {
+	n := config.IntFromEnv("SUMMARY_TRANSCRIPTS", 30)
+	if n > maxSummaryTranscripts {
+		return maxSummaryTranscripts
+	}
+	return n
+}
+
+// summaryLine da a cada transcripción el formato "[hh:mm] nombre: texto"
+func summaryLine(t models.Transcript) string {
+	name := t.SenderName
+	if name == "" {
+		name = fmt.Sprintf("usuario %d", t.SenderID)
+	}
+	return fmt.Sprintf("[%s] %s: %s", t.CreatedAt.Format("15:04"), name, t.Text)
+}
+
+// summarizeChannel resume las últimas limit transcripciones del canal con la IA
+func summarizeChannel(ctx context.Context, analyzer ai.Analyzer, channel string, limit int) (channelSummary, error) {
+	out := channelSummary{Channel: channel}
+
+	items, err := services.RecentTranscripts(config.DB, channel, limit)
+	if err != nil {
+		return out, err
+	}
+	if len(items) == 0 {
+		out.Summary = noRecentConversation
+		return out, nil
+	}
+
+	summarizer, ok := analyzer.(ai.Summarizer)
+	if !ok {
+		return out, errSummaryUnsupported
+	}
+
+	lines := make([]string, 0, len(items))
+	for _, t := range items {
+		lines = append(lines, summaryLine(t))
+	}
+	summary, err := summarizer.Summarize(ctx, lines)
+	if err != nil {
+		return out, err
+	}
+
+	out.Summary = summary
+	out.Transcripts = len(items)
+	out.Since = items[0].CreatedAt.UTC().Format(time.RFC3339)
+	out.Until = items[len(items)-1].CreatedAt.UTC().Format(time.RFC3339)
+	return out, nil
+}
+
+// handleChannelSummaryStage responde al comando de voz "resumen del canal"
+func handleChannelSummaryStage(ctx context.Context, w http.ResponseWriter, analyzer ai.Analyzer, user *models.User, deps audioIngestDeps, tracker *stageTimer) {
+	if !user.IsInChannel() {
+		response.WriteJSON(w, http.StatusOK, CommandResponse{
+			Status:  "error",
+			Intent:  intentChannelSummary,
+			Message: "No estás en ningún canal",
+		})
+		tracker.LogFinal("summary_no_channel")
+		return
+	}
+
+	stageStart := time.Now()
+	channel := user.GetCurrentChannelCode()
+	summary, err := deps.summarizeChannel(ctx, analyzer, channel, summaryTranscripts())
+	tracker.LogStage("summary", stageStart, map[string]any{
+		"canal":       channel,
+		"transcripts": summary.Transcripts,
+		"error":       err != nil,
+	})
+	if err != nil {
+		log.Printf("[RESUMEN] usuario=%d canal=%s error=%v", user.ID, channel, err)
+		response.WriteJSON(w, http.StatusOK, CommandResponse{
+			Status:  "error",
+			Intent:  intentChannelSummary,
+			Message: "No pude resumir el canal",
+		})
+		tracker.LogFinal("summary_error")
+		return
+	}
+
+	response.WriteJSON(w, http.StatusOK, CommandResponse{
+		Status:  "ok",
+		Intent:  intentChannelSummary,
+		Message: summary.Summary,
+		Data: map[string]any{
+			"channel":     channel,
+			"transcripts": summary.Transcripts,
+		},
+	})
+	tracker.LogFinal("summary_response")
+}
+
+// GET /channels/{code}/summary?limit=N
+func ChannelSummary(w http.ResponseWriter, r *http.Request) {
+	if user, code, ok := channelMember(w, r); ok {
+		writeChannelSummary(w, r, code, user.GetLanguage())
+	}
+}
+
+// writeChannelSummary responde GET /channels/{code}/summary?limit=N
+func writeChannelSummary(w http.ResponseWriter, r *http.Request, code, language string) {
+	limit := summaryTranscripts()
+	if raw := r.URL.Query().Get("limit"); raw != "" {
+		v, err := strconv.Atoi(raw)
+		if err != nil || v <= 0 || v > maxSummaryTranscripts {
+			response.WriteErr(w, http.StatusBadRequest, "limit debe estar entre 1 y 100")
+			return
+		}
+		limit = v
+	}
+
+	analyzer, err := summaryAnalyzer()
+	if err != nil {
+		log.Printf("[RESUMEN] IA no disponible: %v", err)
+		response.WriteErr(w, http.StatusServiceUnavailable, "Resumen no disponible")
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(lang.WithLanguage(r.Context(), language), summaryTimeout)
+	defer cancel()
+
+	summary, err := summarizeChannel(ctx, analyzer, code, limit)
+	switch {
+	case errors.Is(err, errSummaryUnsupported):
+		response.WriteErr(w, http.StatusServiceUnavailable, "Resumen no disponible")
+	case err != nil:
+		log.Printf("[RESUMEN] canal=%s error=%v", code, err)
+		response.WriteErr(w, http.StatusBadGateway, "No se pudo generar el resumen")
+	default:
+		response.WriteJSON(w, http.StatusOK, summary)
+	}
+}
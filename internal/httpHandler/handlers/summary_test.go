@@ -0,0 +1,103 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"walkie-backend/internal/ai"
+	"walkie-backend/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+type mockSummarizer struct {
+	mockQwen
+	lines   []string
+	summary string
+}
+
+func (m *mockSummarizer) Summarize(_ context.Context, lines []string) (string, error) {
+	m.lines = lines
+	return m.summary, nil
+}
+
+func TestChannelHistory_SummaryRoute(t *testing.T) {
+	db := setupTestDB(t)
+	require.NoError(t, db.AutoMigrate(&models.Transcript{}))
+	user := createTestUser(t, db, 161, "token-summary", "canal-r")
+
+	summarizer := &mockSummarizer{summary: "Ana pidió refuerzos en la entrada."}
+	old := summaryAnalyzer
+	summaryAnalyzer = func() (ai.Analyzer, error) { return summarizer, nil }
+	defer func() { summaryAnalyzer = old }()
+
+	get := func(query string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/channels/canal-r/summary"+query, nil)
+		req = req.WithContext(withAuthUser(req.Context(), user))
+		rec := httptest.NewRecorder()
+		serveChannelRoute(rec, req)
+		return rec
+	}
+
+	rec := get("")
+	require.Equal(t, http.StatusOK, rec.Code)
+	var body channelSummary
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+	assert.Equal(t, noRecentConversation, body.Summary)
+	assert.Nil(t, summarizer.lines, "sin transcripciones no se llama a la IA")
+
+	base := time.Date(2025, 3, 1, 9, 30, 0, 0, time.Local)
+	for i, text := range []string{"necesito refuerzos en la entrada", "voy para allá"} {
+		require.NoError(t, db.Create(&models.Transcript{ChannelCode: "canal-r", SenderID: 161, SenderName: "ana", Text: text, CreatedAt: base.Add(time.Duration(i) * time.Minute)}).Error)
+	}
+
+	rec = get("?limit=5")
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+	assert.Equal(t, "Ana pidió refuerzos en la entrada.", body.Summary)
+	assert.Equal(t, 2, body.Transcripts)
+	assert.Equal(t, []string{"[09:30] ana: necesito refuerzos en la entrada", "[09:31] ana: voy para allá"}, summarizer.lines)
+
+	assert.Equal(t, http.StatusBadRequest, get("?limit=0").Code)
+}
+
+func TestRunAudioIngest_ChannelSummaryIntent(t *testing.T) {
+	setupTestDB(t)
+	user := &models.User{Model: gorm.Model{ID: 162}, DisplayName: "ana", CurrentChannel: &models.Channel{Code: "canal-r"}}
+	channelID := uint(1)
+	user.CurrentChannelID = &channelID
+
+	deps := newAudioIngestDeps()
+	deps.readUserID = func(*http.Request) (uint, error) { return user.ID, nil }
+	deps.newUserService = func() userService { return &mockUserService{user: user} }
+	deps.localSTT = func() sttClient { return nil }
+	deps.ensureSTT = func() (sttClient, error) { return &mockSTT{text: "resumen del canal"}, nil }
+	deps.ensureAI = func() (ai.Analyzer, error) {
+		return &mockQwen{result: ai.CommandResult{IsCommand: true, Intent: intentChannelSummary}}, nil
+	}
+	var gotChannel string
+	deps.summarizeChannel = func(_ context.Context, _ ai.Analyzer, channel string, _ int) (channelSummary, error) {
+		gotChannel = channel
+		return channelSummary{Channel: channel, Summary: "Todo tranquilo en la entrada.", Transcripts: 4}, nil
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "/audio/ingest", strings.NewReader(string(buildTestWAV(3200))))
+	req.Header.Set("Content-Type", "audio/wav")
+	rec := httptest.NewRecorder()
+	runAudioIngest(rec, req, deps)
+
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	var resp CommandResponse
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+	assert.Equal(t, "ok", resp.Status)
+	assert.Equal(t, intentChannelSummary, resp.Intent)
+	assert.Equal(t, "Todo tranquilo en la entrada.", resp.Message)
+	assert.Equal(t, "canal-r", gotChannel)
+}
@@ -0,0 +1,578 @@
+package handlers
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"walkie-backend/internal/config"
+	"walkie-backend/internal/metrics"
+	"walkie-backend/internal/models"
+	"walkie-backend/internal/response"
+	"walkie-backend/internal/services"
+	"walkie-backend/pkg/audio"
+	"walkie-backend/pkg/twilio"
+
+	"github.com/gorilla/websocket"
+)
+
+const (
+	// La telefonía usa μ-law a 8 kHz en trozos de 20 ms
+	telephonySampleRate   = 8000
+	telephonyFrameSamples = telephonySampleRate / 50
+	telephonyLanguage     = "es-ES"
+
+	defaultVoxRMS      = 600
+	defaultVoxHangover = 800 * time.Millisecond
+	// voxBusyBackoff evita reintentar la palabra en cada trozo mientras otro habla
+	voxBusyBackoff = 500 * time.Millisecond
+
+	dialInTokenTTL    = 2 * time.Minute
+	maxDialInAttempts = 3
+	streamStartWait   = 15 * time.Second
+
+	// maxCallMixSamples limita el audio del canal pendiente de sonar en la llamada
+	maxCallMixSamples = 30 * telephonySampleRate
+	// mixAppendWindow: si queda menos audio pendiente, el clip nuevo es la continuación del
+	// mismo hablante (p. ej. /audio/live) y se pone detrás en vez de mezclarse
+	mixAppendWindow = 3 * telephonyFrameSamples
+)
+
+var (
+	activeCalls atomic.Int64
+	// phoneWAVFormat es el formato en que el audio de la llamada entra al canal
+	phoneWAVFormat, _ = audio.ParseWAV(audio.EncodeWAV(nil, telephonySampleRate))
+)
+
+type telephonySettings struct {
+	authToken string
+	publicURL string
+}
+
+// telephonyConfig lee TWILIO_AUTH_TOKEN y TELEPHONY_PUBLIC_URL (la URL con la que Twilio
+// llega al servidor, p. ej. https://walkie.example.com); sin ambas no se atienden llamadas
+func telephonyConfig() (telephonySettings, bool) {
+	s := telephonySettings{
+		authToken: strings.TrimSpace(os.Getenv("TWILIO_AUTH_TOKEN")),
+		publicURL: strings.TrimRight(strings.TrimSpace(os.Getenv("TELEPHONY_PUBLIC_URL")), "/"),
+	}
+	return s, s.authToken != "" && s.publicURL != ""
+}
+
+func requireTelephony(w http.ResponseWriter) (telephonySettings, bool) {
+	settings, ok := telephonyConfig()
+	if !ok {
+		response.WriteErr(w, http.StatusNotFound, "Telefonía deshabilitada")
+	}
+	return settings, ok
+}
+
+// verifyTwilioRequest comprueba que el webhook viene de Twilio; la firma cubre la URL
+// pública completa y los parámetros del formulario
+func verifyTwilioRequest(w http.ResponseWriter, r *http.Request, settings telephonySettings) bool {
+	if err := r.ParseForm(); err != nil {
+		response.WriteErr(w, http.StatusBadRequest, "Formulario inválido")
+		return false
+	}
+	// RequestURI es la ruta tal como llegó, con /v1 si Twilio llama a la ruta versionada
+	uri := r.RequestURI
+	if uri == "" {
+		uri = r.URL.RequestURI()
+	}
+	fullURL := settings.publicURL + uri
+	if !twilio.ValidateSignature(settings.authToken, fullURL, r.PostForm, r.Header.Get("X-Twilio-Signature")) {
+		log.Printf("[TELEFONIA] firma de Twilio inválida en %s", r.URL.Path)
+		response.WriteErr(w, http.StatusForbidden, "Firma inválida")
+		return false
+	}
+	return true
+}
+
+func writeTwiML(w http.ResponseWriter, verbs ...any) {
+	w.Header().Set("Content-Type", "text/xml; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(twilio.Response{Verbs: verbs}.Marshal())
+}
+
+func say(text string) twilio.Say {
+	return twilio.Say{Language: telephonyLanguage, Text: text}
+}
+
+func dialInAttempt(r *http.Request) int {
+	if n, err := strconv.Atoi(r.URL.Query().Get("attempt")); err == nil && n > 0 {
+		return n
+	}
+	return 1
+}
+
+// POST /telephony/twilio/voice
+// Webhook de llamada entrante: pide el código del canal por DTMF
+func TwilioVoice(w http.ResponseWriter, r *http.Request) {
+	settings, ok := requireTelephony(w)
+	if !ok || !verifyTwilioRequest(w, r, settings) {
+		return
+	}
+	attempt := dialInAttempt(r)
+	if attempt == 1 {
+		metrics.Inc("walkie_telephony_calls_total", map[string]string{"result": "incoming"})
+	}
+	writeTwiML(w,
+		twilio.Gather{
+			Input:       "dtmf",
+			Action:      fmt.Sprintf("/v1/telephony/twilio/gather?attempt=%d", attempt),
+			Method:      http.MethodPost,
+			FinishOnKey: "#",
+			Timeout:     10,
+			Prompt:      &twilio.Say{Language: telephonyLanguage, Text: "Marca el código del canal y pulsa almohadilla."},
+		},
+		say("No se ha recibido ningún código. Hasta luego."),
+		twilio.Hangup{},
+	)
+}
+
+// POST /telephony/twilio/gather
+// Recibe los dígitos; si el canal admite llamadas une la llamada al Media Stream
+func TwilioGather(w http.ResponseWriter, r *http.Request) {
+	settings, ok := requireTelephony(w)
+	if !ok || !verifyTwilioRequest(w, r, settings) || !requireDB(w) {
+		return
+	}
+
+	attempt := dialInAttempt(r)
+	channel, err := services.NewChannelService(config.DB).FindByDialInCode(r.PostForm.Get("Digits"))
+	if err != nil {
+		if !errors.Is(err, services.ErrChannelNotFound) {
+			log.Printf("[TELEFONIA] error buscando canal por código: %v", err)
+		}
+		if attempt >= maxDialInAttempts {
+			metrics.Inc("walkie_telephony_calls_total", map[string]string{"result": "rejected"})
+			writeTwiML(w, say("Código no válido. Hasta luego."), twilio.Hangup{})
+			return
+		}
+		writeTwiML(w,
+			say("Código no válido."),
+			twilio.Redirect{Method: http.MethodPost, URL: fmt.Sprintf("/v1/telephony/twilio/voice?attempt=%d", attempt+1)},
+		)
+		return
+	}
+
+	token := signDialInToken(settings.authToken, dialInClaims{
+		Channel: channel.Code,
+		Caller:  r.PostForm.Get("From"),
+		CallSid: r.PostForm.Get("CallSid"),
+		Expires: time.Now().Add(dialInTokenTTL).Unix(),
+	})
+	writeTwiML(w,
+		say(fmt.Sprintf("Conectando con el canal %s. Tu voz se transmite al hablar.", channel.Name)),
+		twilio.Connect{Stream: twilio.Stream{
+			URL:        streamURL(settings.publicURL),
+			Parameters: []twilio.Parameter{{Name: "token", Value: token}},
+		}},
+	)
+}
+
+// streamURL pasa la URL pública a ws:// o wss://. Las URL que se devuelven a Twilio
+// llevan /v1 para no depender de los alias sin versión (API_LEGACY_ROUTES)
+func streamURL(publicURL string) string {
+	u := publicURL + "/v1/telephony/twilio/stream"
+	if rest, ok := strings.CutPrefix(u, "https://"); ok {
+		return "wss://" + rest
+	}
+	if rest, ok := strings.CutPrefix(u, "http://"); ok {
+		return "ws://" + rest
+	}
+	return u
+}
+
+// dialInClaims viaja firmado en el parámetro del Media Stream: así cualquier réplica puede
+// aceptar el WebSocket sin compartir estado con la que atendió el webhook
+type dialInClaims struct {
+	Channel string `json:"ch"`
+	Caller  string `json:"from"`
+	CallSid string `json:"sid"`
+	Expires int64  `json:"exp"`
+}
+
+func signDialInToken(key string, claims dialInClaims) string {
+	payload, _ := json.Marshal(claims)
+	mac := hmac.New(sha256.New, []byte(key))
+	mac.Write(payload)
+	return base64.RawURLEncoding.EncodeToString(payload) + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+func verifyDialInToken(key, token string, now time.Time) (dialInClaims, error) {
+	var claims dialInClaims
+	rawPayload, rawSig, ok := strings.Cut(token, ".")
+	if !ok {
+		return claims, errors.New("token mal formado")
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(rawPayload)
+	if err != nil {
+		return claims, errors.New("token mal formado")
+	}
+	sig, err := base64.RawURLEncoding.DecodeString(rawSig)
+	if err != nil {
+		return claims, errors.New("token mal formado")
+	}
+	mac := hmac.New(sha256.New, []byte(key))
+	mac.Write(payload)
+	if !hmac.Equal(sig, mac.Sum(nil)) {
+		return claims, errors.New("firma inválida")
+	}
+	if err := json.Unmarshal(payload, &claims); err != nil {
+		return claims, errors.New("token mal formado")
+	}
+	if now.Unix() > claims.Expires {
+		return claims, errors.New("token caducado")
+	}
+	return claims, nil
+}
+
+// GET /telephony/twilio/stream
+// WebSocket de Twilio Media Streams: el llamante entra al canal como un miembro más
+func TwilioStream(w http.ResponseWriter, r *http.Request) {
+	settings, ok := requireTelephony(w)
+	if !ok || !requireDB(w) {
+		return
+	}
+	conn, err := upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		log.Printf("[TELEFONIA] error actualizando a WebSocket: %v", err)
+		return
+	}
+	defer conn.Close()
+	conn.SetReadLimit(64 * 1024)
+
+	start, err := readStreamStart(conn)
+	if err != nil {
+		log.Printf("[TELEFONIA] stream sin start: %v", err)
+		return
+	}
+	claims, err := verifyDialInToken(settings.authToken, start.CustomParameters["token"], time.Now())
+	if err != nil {
+		log.Printf("[TELEFONIA] stream rechazado: %v", err)
+		metrics.Inc("walkie_telephony_calls_total", map[string]string{"result": "rejected"})
+		return
+	}
+
+	user, err := services.PhoneCallerUser(config.DB, claims.Caller)
+	if err != nil {
+		log.Printf("[TELEFONIA] llamada=%s error creando usuario: %v", claims.CallSid, err)
+		return
+	}
+	users := services.NewUserService().WithEventMeta(services.EventMeta{
+		Actor:  "telephony:" + claims.CallSid,
+		Source: models.EventSourceSystem,
+	})
+	if err := users.ConnectUserToChannel(user.ID, claims.Channel); err != nil {
+		log.Printf("[TELEFONIA] llamada=%s no se pudo conectar a %s: %v", claims.CallSid, claims.Channel, err)
+		metrics.Inc("walkie_telephony_calls_total", map[string]string{"result": "channel_error"})
+		return
+	}
+	defer func() {
+		if err := users.DisconnectUserFromCurrentChannel(user.ID); err != nil {
+			log.Printf("[TELEFONIA] llamada=%s error desconectando usuario %d: %v", claims.CallSid, user.ID, err)
+		}
+	}()
+
+	metrics.Inc("walkie_telephony_calls_total", map[string]string{"result": "connected"})
+	metrics.SetGauge("walkie_telephony_calls_active", nil, float64(activeCalls.Add(1)))
+	defer func() {
+		metrics.SetGauge("walkie_telephony_calls_active", nil, float64(activeCalls.Add(-1)))
+	}()
+
+	log.Printf("[TELEFONIA] llamada=%s usuario=%d conectada a canal=%s", claims.CallSid, user.ID, claims.Channel)
+	call := newPhoneCall(conn, start.StreamSid, user, claims.Channel)
+	call.run()
+	log.Printf("[TELEFONIA] llamada=%s usuario=%d terminada", claims.CallSid, user.ID)
+}
+
+// readStreamStart espera el mensaje start, que llega tras connected
+func readStreamStart(conn *websocket.Conn) (*twilio.StreamStart, error) {
+	_ = conn.SetReadDeadline(time.Now().Add(streamStartWait))
+	defer conn.SetReadDeadline(time.Time{})
+	for {
+		var msg twilio.StreamMessage
+		if err := conn.ReadJSON(&msg); err != nil {
+			return nil, err
+		}
+		if msg.Event == "start" && msg.Start != nil {
+			if msg.Start.StreamSid == "" {
+				msg.Start.StreamSid = msg.StreamSid
+			}
+			return msg.Start, nil
+		}
+	}
+}
+
+// phoneCall une el Media Stream con el canal: lo que dice el llamante sale por la ruta de
+// broadcast y el audio del canal se mezcla hacia la llamada
+type phoneCall struct {
+	conn      *websocket.Conn
+	streamSid string
+	user      *models.User
+	channel   string
+	client    *wsClient
+	mixer     callMixer
+	vox       *phoneVox
+	// talking indica que el llamante tiene la palabra; mientras tanto no se le devuelve
+	// el audio del canal, que sería su propia voz
+	talking atomic.Bool
+}
+
+func newPhoneCall(conn *websocket.Conn, streamSid string, user *models.User, channel string) *phoneCall {
+	c := &phoneCall{
+		conn:      conn,
+		streamSid: streamSid,
+		user:      user,
+		channel:   channel,
+		client: &wsClient{
+			userID:  user.ID,
+			name:    user.DisplayName,
+			channel: channel,
+			send:    make(chan []byte, wsSendQueueSize()),
+		},
+	}
+	c.vox = &phoneVox{
+		call:         c,
+		threshold:    float64(config.IntFromEnv("TELEPHONY_VOX_RMS", defaultVoxRMS)),
+		hangover:     config.DurationFromEnv("TELEPHONY_VOX_HANGOVER", defaultVoxHangover),
+		frameSamples: int(config.DurationFromEnv("LIVE_FRAME_DURATION", defaultLiveFrameDuration).Seconds() * telephonySampleRate),
+		stt:          defaultStreamingSTT(),
+	}
+	return c
+}
+
+func (c *phoneCall) run() {
+	registerClient(c.client)
+	presence.Connect(c.user.ID, c.user.DisplayName, c.channel)
+	defer func() {
+		removeClient(c.client)
+		presence.Disconnect(c.user.ID)
+		c.client.closeSend()
+	}()
+
+	done := make(chan struct{})
+	go c.writeLoop(done)
+	c.readLoop()
+	close(done)
+	c.vox.close()
+}
+
+// readLoop pasa el audio del llamante al detector de voz hasta que cuelga
+func (c *phoneCall) readLoop() {
+	for {
+		var msg twilio.StreamMessage
+		if err := c.conn.ReadJSON(&msg); err != nil {
+			if !websocket.IsCloseError(err, websocket.CloseNormalClosure, websocket.CloseGoingAway) && !errors.Is(err, net.ErrClosed) {
+				log.Printf("[TELEFONIA] usuario=%d error leyendo stream: %v", c.user.ID, err)
+			}
+			return
+		}
+		switch msg.Event {
+		case "media":
+			if msg.Media == nil || (msg.Media.Track != "" && msg.Media.Track != "inbound") {
+				continue
+			}
+			payload, err := msg.Media.Audio()
+			if err != nil {
+				continue
+			}
+			c.client.touch()
+			c.vox.write(audio.DecodeMuLaw(payload))
+		case "stop":
+			return
+		}
+	}
+}
+
+// writeLoop es el único que escribe en el WebSocket: cada 20 ms envía lo que toque de la
+// mezcla. Si el usuario sale del canal (expulsión, borrado) cierra la llamada.
+func (c *phoneCall) writeLoop(done <-chan struct{}) {
+	ticker := time.NewTicker(time.Second / 50)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-done:
+			return
+		case msg, ok := <-c.client.send:
+			if !ok {
+				_ = c.conn.Close()
+				return
+			}
+			if frameType(msg) == websocket.BinaryMessage && !c.talking.Load() {
+				c.playClip(msg)
+			}
+		case <-ticker.C:
+			frame := c.mixer.next(telephonyFrameSamples)
+			if frame == nil {
+				continue
+			}
+			_ = c.conn.SetWriteDeadline(time.Now().Add(writeWait))
+			if err := c.conn.WriteMessage(websocket.TextMessage, twilio.MediaMessage(c.streamSid, audio.EncodeMuLaw(frame))); err != nil {
+				_ = c.conn.Close()
+				return
+			}
+		}
+	}
+}
+
+// playClip añade un clip del canal a la mezcla; sólo se pueden reproducir los WAV
+func (c *phoneCall) playClip(clip []byte) {
+	if !audio.IsWAV(clip) {
+		metrics.Inc("walkie_telephony_skipped_clips_total", map[string]string{"format": audio.Detect(clip)})
+		return
+	}
+	samples, err := audio.Samples16(clip, telephonySampleRate)
+	if err != nil {
+		metrics.Inc("walkie_telephony_skipped_clips_total", map[string]string{"format": "wav_unsupported"})
+		return
+	}
+	c.mixer.add(samples)
+}
+
+// callMixer suma los clips del canal que se solapan (un anuncio sobre una transmisión,
+// una emergencia...) para que la llamada los oiga a la vez
+type callMixer struct {
+	pending []int32
+}
+
+func (m *callMixer) add(samples []int16) {
+	if room := maxCallMixSamples - len(m.pending); len(samples) > room {
+		if room <= 0 {
+			return
+		}
+		samples = samples[:room]
+	}
+	offset := 0
+	if len(m.pending) < mixAppendWindow {
+		offset = len(m.pending)
+	}
+	for i, s := range samples {
+		if pos := offset + i; pos < len(m.pending) {
+			m.pending[pos] += int32(s)
+		} else {
+			m.pending = append(m.pending, int32(s))
+		}
+	}
+}
+
+// next saca hasta n muestras de la mezcla recortando a 16 bits; nil si no hay nada
+func (m *callMixer) next(n int) []int16 {
+	if len(m.pending) == 0 {
+		return nil
+	}
+	n = min(n, len(m.pending))
+	out := make([]int16, n)
+	for i, v := range m.pending[:n] {
+		out[i] = int16(max(math.MinInt16, min(math.MaxInt16, v)))
+	}
+	m.pending = m.pending[n:]
+	if len(m.pending) == 0 {
+		m.pending = nil
+	}
+	return out
+}
+
+// phoneVox detecta cuándo habla el llamante (no puede pulsar PTT): al superar el umbral
+// toma la palabra y reenvía el audio al canal en clips de LIVE_FRAME_DURATION, y la suelta
+// tras TELEPHONY_VOX_HANGOVER de silencio
+type phoneVox struct {
+	call         *phoneCall
+	threshold    float64
+	hangover     time.Duration
+	frameSamples int
+	stt          streamingSTTClient
+
+	pending    []int16
+	silence    time.Duration
+	busyUntil  time.Time
+	transcript *liveTranscript
+}
+
+func (v *phoneVox) write(samples []int16) {
+	c := v.call
+	loud := rmsLevel(samples) >= v.threshold
+	if !c.talking.Load() {
+		if !loud || time.Now().Before(v.busyUntil) {
+			return
+		}
+		if _, ok := startTransmission(c.channel, c.user.ID, PriorityNormal); !ok {
+			v.busyUntil = time.Now().Add(voxBusyBackoff)
+			return
+		}
+		c.talking.Store(true)
+		v.silence = 0
+		v.transcript = startLiveTranscript(context.Background(), v.stt, phoneWAVFormat)
+	}
+
+	v.pending = append(v.pending, samples...)
+	if loud {
+		v.silence = 0
+	} else {
+		v.silence += time.Duration(len(samples)) * time.Second / telephonySampleRate
+	}
+	if len(v.pending) >= v.frameSamples {
+		v.flush()
+	}
+	if v.silence >= v.hangover {
+		v.end()
+	}
+}
+
+func (v *phoneVox) flush() {
+	if len(v.pending) == 0 {
+		return
+	}
+	c := v.call
+	wav := audio.EncodeWAV(v.pending, telephonySampleRate)
+	broadcastAudio(c.channel, c.user.ID, wav)
+	v.transcript.write(wav[len(wav)-len(v.pending)*2:])
+	refreshTransmission(c.channel, c.user.ID)
+	v.pending = v.pending[:0]
+}
+
+// end suelta la palabra y guarda la transcripción en segundo plano
+func (v *phoneVox) end() {
+	c := v.call
+	v.flush()
+	stopTransmission(c.channel, c.user.ID)
+	c.talking.Store(false)
+	metrics.Inc("walkie_telephony_transmissions_total", nil)
+
+	transcript := v.transcript
+	v.transcript = nil
+	go func() {
+		recordChannelTranscript(c.user, c.channel, transcript.finish(), PriorityNormal)
+	}()
+}
+
+func (v *phoneVox) close() {
+	if v.call.talking.Load() {
+		v.end()
+	}
+}
+
+func rmsLevel(samples []int16) float64 {
+	if len(samples) == 0 {
+		return 0
+	}
+	var sum float64
+	for _, s := range samples {
+		sum += float64(s) * float64(s)
+	}
+	return math.Sqrt(sum / float64(len(samples)))
+}
@@ -0,0 +1,199 @@
+package handlers
+
+import (
+	"crypto/hmac"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"walkie-backend/internal/models"
+	"walkie-backend/pkg/audio"
+	"walkie-backend/pkg/twilio"
+
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testTelephonyURL = "https://walkie.example.com"
+
+// twilioRequest firma el formulario como lo hace Twilio
+func twilioRequest(t *testing.T, path string, form url.Values) *http.Request {
+	t.Helper()
+	keys := make([]string, 0, len(form))
+	for k := range form {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	data := testTelephonyURL + path
+	for _, k := range keys {
+		data += k + form.Get(k)
+	}
+	mac := hmac.New(sha1.New, []byte("twilio-secreto"))
+	mac.Write([]byte(data))
+
+	req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("X-Twilio-Signature", base64.StdEncoding.EncodeToString(mac.Sum(nil)))
+	return req
+}
+
+func TestTwilioWebhooks_GatherDialInCode(t *testing.T) {
+	db := setupTestDB(t)
+	t.Setenv("TWILIO_AUTH_TOKEN", "twilio-secreto")
+	t.Setenv("TELEPHONY_PUBLIC_URL", testTelephonyURL+"/")
+	require.NoError(t, db.Create(&models.Channel{Code: "obra-norte", Name: "Obra norte", MaxUsers: 10, DialInCode: "4321"}).Error)
+
+	rec := httptest.NewRecorder()
+	TwilioVoice(rec, twilioRequest(t, "/telephony/twilio/voice", url.Values{"CallSid": {"CA1"}}))
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), `<Gather input="dtmf" action="/v1/telephony/twilio/gather?attempt=1"`)
+
+	forged := twilioRequest(t, "/telephony/twilio/gather", url.Values{"Digits": {"4321"}})
+	forged.Header.Set("X-Twilio-Signature", "falsa")
+	rec = httptest.NewRecorder()
+	TwilioGather(rec, forged)
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+
+	rec = httptest.NewRecorder()
+	TwilioGather(rec, twilioRequest(t, "/telephony/twilio/gather?attempt=1", url.Values{"Digits": {"9999"}}))
+	assert.Contains(t, rec.Body.String(), "<Redirect method=\"POST\">/v1/telephony/twilio/voice?attempt=2</Redirect>")
+
+	rec = httptest.NewRecorder()
+	TwilioGather(rec, twilioRequest(t, "/telephony/twilio/gather?attempt=3", url.Values{"Digits": {"9999"}}))
+	assert.Contains(t, rec.Body.String(), "<Hangup></Hangup>", "al tercer intento se cuelga")
+
+	rec = httptest.NewRecorder()
+	TwilioGather(rec, twilioRequest(t, "/telephony/twilio/gather", url.Values{"Digits": {"4321"}, "From": {"+34600111222"}, "CallSid": {"CA1"}}))
+	require.Equal(t, http.StatusOK, rec.Code)
+	body := rec.Body.String()
+	assert.Contains(t, body, `<Stream url="wss://walkie.example.com/v1/telephony/twilio/stream">`)
+
+	start := strings.Index(body, `name="token" value="`) + len(`name="token" value="`)
+	token := body[start : start+strings.Index(body[start:], `"`)]
+	claims, err := verifyDialInToken("twilio-secreto", token, time.Now())
+	require.NoError(t, err)
+	assert.Equal(t, dialInClaims{Channel: "obra-norte", Caller: "+34600111222", CallSid: "CA1", Expires: claims.Expires}, claims)
+
+	_, err = verifyDialInToken("otro", token, time.Now())
+	assert.Error(t, err)
+	_, err = verifyDialInToken("twilio-secreto", token, time.Now().Add(dialInTokenTTL+time.Minute))
+	assert.Error(t, err, "el token caduca")
+}
+
+func TestCallMixer_MixesOverlapsAndAppendsContinuations(t *testing.T) {
+	var m callMixer
+	m.add([]int16{100, 100})
+	m.add([]int16{1, 1, 1})
+	assert.Equal(t, []int16{100, 100, 1}, append(m.next(2), m.next(5)...)[:3], "con poco pendiente el clip va detrás")
+
+	long := make([]int16, mixAppendWindow+10)
+	for i := range long {
+		long[i] = 30000
+	}
+	m = callMixer{}
+	m.add(long)
+	m.add([]int16{10000})
+	assert.Equal(t, []int16{32767, 30000}, m.next(2), "los clips solapados se suman con recorte")
+	assert.Nil(t, (&callMixer{}).next(10))
+}
+
+func TestTwilioStream_BridgesCallerAndChannel(t *testing.T) {
+	db := setupTestDB(t)
+	t.Setenv("TWILIO_AUTH_TOKEN", "twilio-secreto")
+	t.Setenv("TELEPHONY_PUBLIC_URL", testTelephonyURL)
+	t.Setenv("TELEPHONY_VOX_HANGOVER", "60ms")
+	require.NoError(t, db.AutoMigrate(&models.ChannelEvent{}))
+	listener := createTestUser(t, db, 692, "token-tel", "tel-1")
+	require.NoError(t, db.Model(&models.Channel{}).Where("code = ?", "tel-1").Update("dial_in_code", "555").Error)
+
+	// El oyente está en el canal por WebSocket
+	listenerClient := &wsClient{userID: listener.ID, channel: "tel-1", send: make(chan []byte, 64)}
+	registerClient(listenerClient)
+	t.Cleanup(func() { removeClient(listenerClient) })
+
+	// El WebSocket se secuestra: httptest no espera al handler al cerrar
+	handlerDone := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		defer close(handlerDone)
+		TwilioStream(w, r)
+	}))
+	defer srv.Close()
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(srv.URL, "http"), nil)
+	require.NoError(t, err)
+	defer conn.Close()
+
+	token := signDialInToken("twilio-secreto", dialInClaims{Channel: "tel-1", Caller: "+34600999888", CallSid: "CA9", Expires: time.Now().Add(time.Minute).Unix()})
+	require.NoError(t, conn.WriteJSON(map[string]any{"event": "connected"}))
+	require.NoError(t, conn.WriteJSON(map[string]any{
+		"event": "start", "streamSid": "MZ1",
+		"start": map[string]any{"streamSid": "MZ1", "callSid": "CA9", "customParameters": map[string]string{"token": token}},
+	}))
+
+	var caller models.User
+	require.Eventually(t, func() bool {
+		return db.Where("display_name = ?", "Teléfono +34600999888").First(&caller).Error == nil && caller.CurrentChannelID != nil
+	}, 2*time.Second, 10*time.Millisecond, "el llamante entra al canal")
+
+	// Voz del llamante: llega al oyente como WAV a 8 kHz
+	loud := make([]int16, telephonyFrameSamples)
+	for i := range loud {
+		loud[i] = 4000
+	}
+	media := map[string]any{"event": "media", "media": map[string]string{"track": "inbound", "payload": base64.StdEncoding.EncodeToString(audio.EncodeMuLaw(loud))}}
+	for i := 0; i < 12; i++ {
+		require.NoError(t, conn.WriteJSON(media))
+	}
+	var clip []byte
+	require.Eventually(t, func() bool {
+		select {
+		case msg := <-listenerClient.send:
+			if audio.IsWAV(msg) {
+				clip = msg
+				return true
+			}
+		default:
+		}
+		return false
+	}, 2*time.Second, 5*time.Millisecond)
+	f, err := audio.ParseWAV(clip)
+	require.NoError(t, err)
+	assert.Equal(t, telephonySampleRate, f.SampleRate)
+
+	// Silencio: suelta la palabra y el audio del canal vuelve a sonar en la llamada
+	silence := map[string]any{"event": "media", "media": map[string]string{"payload": base64.StdEncoding.EncodeToString(audio.EncodeMuLaw(make([]int16, telephonyFrameSamples)))}}
+	for i := 0; i < 5; i++ {
+		require.NoError(t, conn.WriteJSON(silence))
+	}
+	require.Eventually(t, func() bool {
+		registry.RLock()
+		defer registry.RUnlock()
+		_, held := registry.floor["tel-1"]
+		return !held
+	}, 2*time.Second, 5*time.Millisecond)
+
+	broadcastAudio("tel-1", listener.ID, audio.EncodeWAV(loud, 16000))
+	_ = conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	var out twilio.StreamMessage
+	_, raw, err := conn.ReadMessage()
+	require.NoError(t, err)
+	require.NoError(t, json.Unmarshal(raw, &out))
+	assert.Equal(t, "media", out.Event)
+	assert.Equal(t, "MZ1", out.StreamSid)
+
+	require.NoError(t, conn.WriteJSON(map[string]any{"event": "stop"}))
+	select {
+	case <-handlerDone:
+	case <-time.After(2 * time.Second):
+		t.Fatal("la llamada no terminó")
+	}
+	require.NoError(t, db.First(&caller, caller.ID).Error)
+	assert.Nil(t, caller.CurrentChannelID, "al colgar sale del canal")
+}
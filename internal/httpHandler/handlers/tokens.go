@@ -0,0 +1,379 @@
+package handlers
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"walkie-backend/internal/config"
+	"walkie-backend/internal/keyring"
+	"walkie-backend/internal/models"
+	"walkie-backend/internal/response"
+	"walkie-backend/internal/services"
+
+	"gorm.io/gorm"
+)
+
+const (
+	defaultAccessTokenTTL  = 15 * time.Minute
+	defaultRefreshTokenTTL = 30 * 24 * time.Hour
+	accessTokenType        = "access"
+)
+
+var (
+	errTokenExpired = errors.New("token expirado")
+	errTokenRevoked = errors.New("token revocado")
+)
+
+// accessClaims son los claims del JWT de acceso. Ver se compara con User.TokenVersion
+// para poder revocar todos los tokens de un usuario sin lista negra.
+type accessClaims struct {
+	Subject   string `json:"sub"`
+	Type      string `json:"typ"`
+	Version   uint   `json:"ver"`
+	IssuedAt  int64  `json:"iat"`
+	ExpiresAt int64  `json:"exp"`
+	// SessionID es la sesión del dispositivo; revocarla invalida el JWT
+	SessionID uint `json:"sid,omitempty"`
+}
+
+// TokenPair se devuelve en /auth y /auth/refresh
+type TokenPair struct {
+	AccessToken  string `json:"access_token"`
+	RefreshToken string `json:"refresh_token"`
+	ExpiresIn    int64  `json:"expires_in"`
+	TokenType    string `json:"token_type"`
+}
+
+type refreshRequest struct {
+	RefreshToken string `json:"refresh_token"`
+}
+
+func accessTokenTTL() time.Duration {
+	return config.DurationFromEnv("JWT_ACCESS_TTL", defaultAccessTokenTTL)
+}
+
+func refreshTokenTTL() time.Duration {
+	return config.DurationFromEnv("JWT_REFRESH_TTL", defaultRefreshTokenTTL)
+}
+
+// hashToken es el SHA-256 con el que se guardan los tokens de refresco y de sesión
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// issueTokenPair firma un JWT de acceso y guarda un token de refresco nuevo para el
+// usuario, ligados a la sesión del dispositivo (0 si no tiene)
+func issueTokenPair(db *gorm.DB, user *models.User, sessionID uint) (TokenPair, error) {
+	kr, err := keyring.Default()
+	if err != nil {
+		return TokenPair{}, fmt.Errorf("keyring no disponible: %w", err)
+	}
+
+	now := time.Now()
+	ttl := accessTokenTTL()
+	access, err := kr.SignJWT(accessClaims{
+		Subject:   strconv.FormatUint(uint64(user.ID), 10),
+		Type:      accessTokenType,
+		Version:   user.TokenVersion,
+		IssuedAt:  now.Unix(),
+		ExpiresAt: now.Add(ttl).Unix(),
+		SessionID: sessionID,
+	})
+	if err != nil {
+		return TokenPair{}, fmt.Errorf("firmar token de acceso: %w", err)
+	}
+
+	refresh, err := generateToken(32)
+	if err != nil {
+		return TokenPair{}, err
+	}
+	stored := models.RefreshToken{
+		UserID:    user.ID,
+		TokenHash: hashToken(refresh),
+		ExpiresAt: now.Add(refreshTokenTTL()),
+	}
+	if sessionID != 0 {
+		stored.SessionID = &sessionID
+	}
+	if err := db.Create(&stored).Error; err != nil {
+		return TokenPair{}, fmt.Errorf("guardar token de refresco: %w", err)
+	}
+
+	return TokenPair{
+		AccessToken:  access,
+		RefreshToken: refresh,
+		ExpiresIn:    int64(ttl / time.Second),
+		TokenType:    "Bearer",
+	}, nil
+}
+
+// parseAccessToken valida firma, tipo y caducidad del JWT y devuelve sus claims
+func parseAccessToken(token string) (accessClaims, error) {
+	var claims accessClaims
+	kr, err := keyring.Default()
+	if err != nil {
+		return claims, err
+	}
+	if err := kr.VerifyJWT(token, &claims); err != nil {
+		return claims, err
+	}
+	if claims.Type != accessTokenType {
+		return claims, keyring.ErrInvalidJWT
+	}
+	if time.Now().Unix() >= claims.ExpiresAt {
+		return claims, errTokenExpired
+	}
+	return claims, nil
+}
+
+// findUserByAccessToken resuelve el usuario de un JWT de acceso comprobando que no se
+// haya revocado desde que se emitió
+func findUserByAccessToken(token string) (*models.User, error) {
+	claims, err := parseAccessToken(token)
+	if err != nil {
+		return nil, err
+	}
+	id, err := strconv.ParseUint(claims.Subject, 10, 64)
+	if err != nil {
+		return nil, keyring.ErrInvalidJWT
+	}
+
+	var user models.User
+	if err := config.DB.Preload("CurrentChannel").First(&user, uint(id)).Error; err != nil {
+		return nil, err
+	}
+	if user.TokenVersion != claims.Version {
+		return nil, errTokenRevoked
+	}
+	if claims.SessionID != 0 && !sessionIsActive(config.DB, claims.SessionID) {
+		return nil, errTokenRevoked
+	}
+	return &user, nil
+}
+
+// logoutDevice revoca solo la sesión sid. users.auth_token se limpia si guardaba el token
+// opaco de esa sesión, para que los clientes antiguos no lo sigan aceptando.
+func logoutDevice(w http.ResponseWriter, user *models.User, sid uint) bool {
+	var session models.Session
+	if err := config.DB.Select("token_hash").Where("id = ? AND user_id = ?", sid, user.ID).
+		First(&session).Error; err == nil && user.AuthToken != "" && hashToken(user.AuthToken) == session.TokenHash {
+		if err := config.DB.Model(&models.User{}).Where("id = ? AND auth_token = ?", user.ID, user.AuthToken).
+			Update("auth_token", "").Error; err != nil {
+			log.Printf("[AUTH] usuario=%d error limpiando token: %v", user.ID, err)
+			response.WriteErr(w, http.StatusInternalServerError, "No se pudo cerrar la sesión")
+			return false
+		}
+	}
+	if err := revokeSession(config.DB, user.ID, sid); err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		log.Printf("[AUTH] usuario=%d error revocando sesión %d: %v", user.ID, sid, err)
+		response.WriteErr(w, http.StatusInternalServerError, "No se pudo cerrar la sesión")
+		return false
+	}
+	forgetDeviceSession(user.ID, sid)
+	return true
+}
+
+// logoutAllDevices es el logout de los tokens sin sesión: no hay forma de saber qué
+// dispositivo los usa, así que se invalidan todos
+func logoutAllDevices(w http.ResponseWriter, user *models.User) bool {
+	if err := config.DB.Model(&models.User{}).Where("id = ?", user.ID).Update("auth_token", "").Error; err != nil {
+		log.Printf("[AUTH] usuario=%d error limpiando token: %v", user.ID, err)
+		response.WriteErr(w, http.StatusInternalServerError, "No se pudo cerrar la sesión")
+		return false
+	}
+	if err := revokeUserTokens(config.DB, user.ID); err != nil {
+		log.Printf("[AUTH] usuario=%d error revocando tokens: %v", user.ID, err)
+		response.WriteErr(w, http.StatusInternalServerError, "No se pudo cerrar la sesión")
+		return false
+	}
+	if err := revokeUserSessions(config.DB, user.ID); err != nil {
+		log.Printf("[AUTH] usuario=%d error cerrando sesiones: %v", user.ID, err)
+	}
+	return true
+}
+
+// revokeUserTokens invalida todos los JWT de acceso y tokens de refresco del usuario
+func revokeUserTokens(db *gorm.DB, userID uint) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.User{}).Where("id = ?", userID).
+			UpdateColumn("token_version", gorm.Expr("token_version + 1")).Error; err != nil {
+			return err
+		}
+		return tx.Model(&models.RefreshToken{}).
+			Where("user_id = ? AND revoked_at IS NULL", userID).
+			Update("revoked_at", time.Now()).Error
+	})
+}
+
+// requestToken lee el token de X-Auth-Token o de Authorization: Bearer
+func requestToken(r *http.Request) string {
+	if token := strings.TrimSpace(r.Header.Get("X-Auth-Token")); token != "" {
+		return token
+	}
+	auth := strings.TrimSpace(r.Header.Get("Authorization"))
+	if len(auth) > 7 && strings.EqualFold(auth[:7], "bearer ") {
+		return strings.TrimSpace(auth[7:])
+	}
+	return ""
+}
+
+type authUserKey struct{}
+
+func withAuthUser(ctx context.Context, user *models.User) context.Context {
+	return context.WithValue(ctx, authUserKey{}, user)
+}
+
+func authUserFromContext(ctx context.Context) (*models.User, bool) {
+	user, ok := ctx.Value(authUserKey{}).(*models.User)
+	return user, ok && user != nil
+}
+
+// RequireAuth valida el token (JWT o token opaco) antes de llamar al handler y deja el
+// usuario en el contexto para que el handler no repita la consulta. Sin base de datos
+// deja pasar la petición para que el handler aplique su modo degradado.
+func RequireAuth(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		token := requestToken(r)
+		if token == "" {
+			w.Header().Set("WWW-Authenticate", "Bearer")
+			response.WriteErr(w, http.StatusUnauthorized, "Token requerido")
+			return
+		}
+		if !config.DBAvailable() {
+			next(w, r)
+			return
+		}
+		user, err := resolveUserFromRequest(r)
+		if err != nil {
+			w.Header().Set("WWW-Authenticate", `Bearer error="invalid_token"`)
+			response.WriteErr(w, http.StatusUnauthorized, "Token inválido o expirado")
+			return
+		}
+		next(w, r.WithContext(withAuthUser(r.Context(), user)))
+	}
+}
+
+// POST /auth/refresh
+// Cambia un token de refresco válido por un par nuevo; el usado queda revocado.
+func RefreshToken(w http.ResponseWriter, r *http.Request) {
+	if !requireDB(w) {
+		return
+	}
+
+	var req refreshRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || strings.TrimSpace(req.RefreshToken) == "" {
+		response.WriteErr(w, http.StatusBadRequest, "refresh_token requerido")
+		return
+	}
+
+	var pair TokenPair
+	var rotatedUser, rotatedSession uint
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		var stored models.RefreshToken
+		if err := tx.Where("token_hash = ?", hashToken(strings.TrimSpace(req.RefreshToken))).
+			First(&stored).Error; err != nil {
+			return errTokenRevoked
+		}
+		if !stored.IsUsable(time.Now()) {
+			return errTokenRevoked
+		}
+		if stored.SessionID != nil && !sessionIsActive(tx, *stored.SessionID) {
+			return errTokenRevoked
+		}
+
+		now := time.Now()
+		res := tx.Model(&models.RefreshToken{}).
+			Where("id = ? AND revoked_at IS NULL", stored.ID).
+			Update("revoked_at", now)
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			// Otra petición ya lo consumió
+			return errTokenRevoked
+		}
+
+		var user models.User
+		if err := tx.First(&user, stored.UserID).Error; err != nil {
+			return errTokenRevoked
+		}
+		var sessionID uint
+		if stored.SessionID != nil {
+			sessionID = *stored.SessionID
+		}
+		var err error
+		pair, err = issueTokenPair(tx, &user, sessionID)
+		rotatedUser, rotatedSession = user.ID, sessionID
+		return err
+	})
+	if errors.Is(err, errTokenRevoked) {
+		response.WriteErr(w, http.StatusUnauthorized, "Token de refresco inválido o expirado")
+		return
+	}
+	if err != nil {
+		log.Printf("[AUTH] error renovando token: %v", err)
+		response.WriteErr(w, http.StatusInternalServerError, "No se pudo renovar el token")
+		return
+	}
+	// El token de acceso anterior de la sesión deja de valer en el modo degradado
+	forgetDeviceSession(rotatedUser, rotatedSession)
+
+	response.WriteJSON(w, http.StatusOK, pair)
+}
+
+// POST /auth/logout
+// Cierra la sesión del dispositivo que hace la petición; los demás siguen conectados.
+// Al cerrar la última sesión (o con un token sin sesión, que las cierra todas) saca al
+// usuario de su canal, cierra su WebSocket y descarta el audio que tuviera pendiente.
+func Logout(w http.ResponseWriter, r *http.Request) {
+	if !requireDB(w) {
+		return
+	}
+
+	user, err := resolveUserFromRequest(r)
+	if err != nil {
+		response.WriteErr(w, http.StatusUnauthorized, "Token inválido o expirado")
+		return
+	}
+
+	if sid := currentSessionID(r); sid != 0 {
+		if !logoutDevice(w, user, sid) {
+			return
+		}
+		forgetCachedToken(requestToken(r))
+		if hasActiveSessions(config.DB, user.ID) {
+			log.Printf("[AUTH] usuario=%d sesión %d cerrada", user.ID, sid)
+			response.WriteJSON(w, http.StatusOK, map[string]string{"message": "sesión cerrada"})
+			return
+		}
+	} else if !logoutAllDevices(w, user) {
+		return
+	}
+
+	if user.IsInChannel() {
+		svc := services.NewUserService().WithEventMeta(services.EventMeta{
+			Actor:     fmt.Sprintf("user:%d", user.ID),
+			Source:    models.EventSourceHTTP,
+			RequestID: requestID(w, r),
+		})
+		if err := svc.DisconnectUserFromCurrentChannel(user.ID); err != nil {
+			log.Printf("[AUTH] usuario=%d error desconectando en logout: %v", user.ID, err)
+		}
+	}
+	forgetUserSessions(user.ID)
+	moveClientToChannel(user.ID, "")
+	ClearPendingAudio(user.ID)
+
+	log.Printf("[AUTH] usuario=%d sesión cerrada", user.ID)
+	response.WriteJSON(w, http.StatusOK, map[string]string{"message": "sesión cerrada"})
+}
@@ -0,0 +1,226 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"walkie-backend/internal/config"
+	"walkie-backend/internal/keyring"
+	"walkie-backend/internal/models"
+	"walkie-backend/internal/services"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func setupTokenTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open("file:tokens_test?mode=memory&cache=shared"), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&models.User{}, &models.Channel{}, &models.ChannelMembership{},
+		&models.ChannelEvent{}, &models.RefreshToken{}, &models.SigningKey{}, &models.Session{}))
+
+	kr := keyring.New(keyring.GormStore{DB: db})
+	require.NoError(t, kr.Load())
+	keyring.SetDefault(kr)
+
+	original := config.DB
+	config.DB = db
+	t.Cleanup(func() {
+		config.DB = original
+		keyring.SetDefault(nil)
+		sqlDB, _ := db.DB()
+		_ = sqlDB.Close()
+	})
+	return db
+}
+
+func loginForTokens(t *testing.T) AuthenticationResponse {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodPost, "/auth", bytes.NewBufferString(`{"nombre":"jwt","pin":4321}`))
+	rec := httptest.NewRecorder()
+	Authenticate(rec, req)
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	var resp AuthenticationResponse
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+	require.NotNil(t, resp.TokenPair)
+	return resp
+}
+
+func refreshWith(token string) *httptest.ResponseRecorder {
+	body, _ := json.Marshal(refreshRequest{RefreshToken: token})
+	rec := httptest.NewRecorder()
+	RefreshToken(rec, httptest.NewRequest(http.MethodPost, "/auth/refresh", bytes.NewReader(body)))
+	return rec
+}
+
+func TestAuthenticate_IssuesJWTAndRefreshToken(t *testing.T) {
+	setupTokenTestDB(t)
+	resp := loginForTokens(t)
+
+	assert.NotEmpty(t, resp.Token, "el token opaco se mantiene por compatibilidad")
+	assert.True(t, keyring.LooksLikeJWT(resp.AccessToken))
+	assert.Equal(t, "Bearer", resp.TokenType)
+	assert.Positive(t, resp.ExpiresIn)
+
+	req := httptest.NewRequest(http.MethodGet, "/audio/poll", nil)
+	req.Header.Set("Authorization", "Bearer "+resp.AccessToken)
+	user, err := resolveUserFromRequest(req)
+	require.NoError(t, err)
+	assert.Equal(t, "jwt", user.DisplayName)
+}
+
+func TestRefreshToken_RotatesAndRejectsReuse(t *testing.T) {
+	setupTokenTestDB(t)
+	resp := loginForTokens(t)
+
+	rec := refreshWith(resp.RefreshToken)
+	require.Equal(t, http.StatusOK, rec.Code)
+	var pair TokenPair
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &pair))
+	assert.NotEqual(t, resp.RefreshToken, pair.RefreshToken)
+
+	assert.Equal(t, http.StatusUnauthorized, refreshWith(resp.RefreshToken).Code)
+	assert.Equal(t, http.StatusOK, refreshWith(pair.RefreshToken).Code)
+	assert.Equal(t, http.StatusBadRequest, refreshWith("").Code)
+}
+
+func TestRefreshToken_ForgetsRotatedAccessTokenInDegradedCache(t *testing.T) {
+	setupTokenTestDB(t)
+	resp := loginForTokens(t)
+
+	user, err := findUserByToken(resp.AccessToken)
+	require.NoError(t, err)
+	rememberSession(resp.AccessToken, user)
+	t.Cleanup(func() { forgetUserSessions(user.ID) })
+	cached, ok := lookupCachedSession(resp.AccessToken)
+	require.True(t, ok)
+	claims, err := parseAccessToken(resp.AccessToken)
+	require.NoError(t, err)
+	assert.Equal(t, claims.ExpiresAt, cached.expiresAt.Unix())
+
+	require.Equal(t, http.StatusOK, refreshWith(resp.RefreshToken).Code)
+	_, ok = lookupCachedSession(resp.AccessToken)
+	assert.False(t, ok)
+}
+
+func TestRevokeUserTokens_InvalidatesAccessAndRefresh(t *testing.T) {
+	db := setupTokenTestDB(t)
+	resp := loginForTokens(t)
+
+	user, err := findUserByToken(resp.AccessToken)
+	require.NoError(t, err)
+	require.NoError(t, revokeUserTokens(db, user.ID))
+
+	_, err = findUserByToken(resp.AccessToken)
+	assert.ErrorIs(t, err, errTokenRevoked)
+	assert.Equal(t, http.StatusUnauthorized, refreshWith(resp.RefreshToken).Code)
+}
+
+func TestRequireAuth(t *testing.T) {
+	setupTokenTestDB(t)
+	resp := loginForTokens(t)
+
+	var seen *models.User
+	handler := RequireAuth(func(w http.ResponseWriter, r *http.Request) {
+		seen, _ = authUserFromContext(r.Context())
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	rec := httptest.NewRecorder()
+	handler(rec, httptest.NewRequest(http.MethodGet, "/audio/poll", nil))
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+
+	req := httptest.NewRequest(http.MethodGet, "/audio/poll", nil)
+	req.Header.Set("X-Auth-Token", "eyJhbGciOiJub25lIn0.e30.firma")
+	rec = httptest.NewRecorder()
+	handler(rec, req)
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+
+	req = httptest.NewRequest(http.MethodGet, "/audio/poll", nil)
+	req.Header.Set("X-Auth-Token", resp.AccessToken)
+	rec = httptest.NewRecorder()
+	handler(rec, req)
+	assert.Equal(t, http.StatusNoContent, rec.Code)
+	require.NotNil(t, seen)
+	assert.Equal(t, "jwt", seen.DisplayName)
+}
+
+func TestLogout_InvalidatesSessionAndCleansUp(t *testing.T) {
+	db := setupTokenTestDB(t)
+	resp := loginForTokens(t)
+	require.NoError(t, db.Create(&models.Channel{Code: "canal-logout", Name: "Logout", MaxUsers: 5}).Error)
+
+	user, err := findUserByToken(resp.AccessToken)
+	require.NoError(t, err)
+	require.NoError(t, services.NewUserService().ConnectUserToChannel(user.ID, "canal-logout"))
+	registerClient(&wsClient{userID: user.ID, channel: "canal-logout"})
+	enqueueForUser(user.ID, 999, "canal-logout", buildTestWAV(100), 0.1)
+	rememberSession(resp.Token, user)
+
+	req := httptest.NewRequest(http.MethodPost, "/auth/logout", nil)
+	req.Header.Set("Authorization", "Bearer "+resp.AccessToken)
+	rec := httptest.NewRecorder()
+	Logout(rec, req)
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	var stored models.User
+	require.NoError(t, db.First(&stored, user.ID).Error)
+	assert.Empty(t, stored.AuthToken)
+	assert.Nil(t, stored.CurrentChannelID)
+
+	registry.RLock()
+	_, connected := registry.byUser[user.ID]
+	registry.RUnlock()
+	assert.False(t, connected)
+	assert.Nil(t, DequeueAudio(user.ID))
+	_, cached := lookupCachedSession(resp.Token)
+	assert.False(t, cached)
+
+	_, err = findUserByToken(resp.AccessToken)
+	assert.Error(t, err)
+	_, err = findUserByToken(resp.Token)
+	assert.Error(t, err)
+}
+
+func TestLogout_OnlyClosesCallingDevice(t *testing.T) {
+	setupTokenTestDB(t)
+	phone := loginFromDevice(t, "Teléfono")
+	tablet := loginFromDevice(t, "Tablet")
+
+	user, err := findUserByToken(tablet.AccessToken)
+	require.NoError(t, err)
+	rememberSession(phone.Token, user)
+	rememberSession(tablet.Token, user)
+
+	req := httptest.NewRequest(http.MethodPost, "/auth/logout", nil)
+	req.Header.Set("X-Auth-Token", phone.Token)
+	rec := httptest.NewRecorder()
+	Logout(rec, req)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+	for _, token := range []string{phone.Token, phone.AccessToken} {
+		_, err := findUserByToken(token)
+		assert.ErrorIs(t, err, errTokenRevoked)
+	}
+	assert.Equal(t, http.StatusUnauthorized, refreshWith(phone.RefreshToken).Code)
+	_, cached := lookupCachedSession(phone.Token)
+	assert.False(t, cached)
+
+	for _, token := range []string{tablet.Token, tablet.AccessToken} {
+		_, err := findUserByToken(token)
+		assert.NoError(t, err)
+	}
+	assert.Equal(t, http.StatusOK, refreshWith(tablet.RefreshToken).Code)
+	_, cached = lookupCachedSession(tablet.Token)
+	assert.True(t, cached)
+}
@@ -0,0 +1,50 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"walkie-backend/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestChannelHistory_TranscriptsRoute(t *testing.T) {
+	db := setupTestDB(t)
+	require.NoError(t, db.AutoMigrate(&models.Transcript{}))
+	user := createTestUser(t, db, 141, "token-transcripts", "canal-t")
+	base := time.Date(2025, 3, 1, 10, 0, 0, 0, time.UTC)
+	for i, text := range []string{"salgo ya", "llegando a la puerta"} {
+		require.NoError(t, db.Create(&models.Transcript{ChannelCode: "canal-t", SenderID: 141, SenderName: "testuser", Text: text, CreatedAt: base.Add(time.Duration(i) * time.Minute)}).Error)
+	}
+	require.NoError(t, db.Create(&models.Transcript{ChannelCode: "canal-otro", SenderID: 141, Text: "no", CreatedAt: base}).Error)
+
+	get := func(path string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		req = req.WithContext(withAuthUser(req.Context(), user))
+		rec := httptest.NewRecorder()
+		serveChannelRoute(rec, req)
+		return rec
+	}
+
+	rec := get("/channels/canal-t/transcripts")
+	require.Equal(t, http.StatusOK, rec.Code)
+	var items []transcriptItem
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &items))
+	require.Len(t, items, 2)
+	assert.Equal(t, "salgo ya", items[0].Text)
+	assert.Equal(t, "testuser", items[0].SenderName)
+
+	rec = get("/channels/canal-t/transcripts?since=2025-03-01T10:00:30Z")
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &items))
+	require.Len(t, items, 1)
+	assert.Equal(t, "llegando a la puerta", items[0].Text)
+
+	assert.Equal(t, http.StatusBadRequest, get("/channels/canal-t/transcripts?since=ayer").Code)
+	assert.Equal(t, http.StatusForbidden, get("/channels/canal-otro/transcripts").Code)
+}
@@ -0,0 +1,147 @@
+package handlers
+
+import (
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"walkie-backend/internal/config"
+	"walkie-backend/internal/metrics"
+	"walkie-backend/internal/models"
+)
+
+const (
+	PriorityNormal    = "normal"
+	PriorityUrgent    = "urgent"
+	PriorityEmergency = "emergency"
+
+	// EmergencyHeader marca un clip como emergencia sin depender de la transcripción
+	EmergencyHeader = "X-Audio-Emergency"
+
+	defaultUrgentMaxPerWindow = 5
+	defaultUrgentWindow       = 10 * time.Minute
+)
+
+// urgentPrefixes son las frases con las que el hablante marca un mensaje como urgente
+var urgentPrefixes = []string{
+	"esto es urgente",
+	"es urgente",
+	"mensaje urgente",
+	"urgente",
+}
+
+// emergencyPrefixes son las frases con las que el hablante declara una emergencia
+var emergencyPrefixes = []string{
+	"esto es una emergencia",
+	"tenemos una emergencia",
+	"emergencia",
+}
+
+type urgencyConfig struct {
+	maxPerWindow int
+	window       time.Duration
+}
+
+var urgencyTracker = struct {
+	sync.Mutex
+	byUser map[uint][]time.Time
+}{
+	byUser: make(map[uint][]time.Time),
+}
+
+func loadUrgencyConfig() urgencyConfig {
+	return urgencyConfig{
+		maxPerWindow: config.PositiveIntFromEnv("URGENT_MAX_PER_WINDOW", defaultUrgentMaxPerWindow),
+		window:       config.DurationFromEnv("URGENT_WINDOW", defaultUrgentWindow),
+	}
+}
+
+// hasUrgentPrefix indica si la transcripción empieza con una marca de urgencia
+func hasUrgentPrefix(transcript string) bool {
+	return hasLeadingPhrase(transcript, urgentPrefixes)
+}
+
+// hasEmergencyPrefix indica si la transcripción empieza declarando una emergencia
+func hasEmergencyPrefix(transcript string) bool {
+	return hasLeadingPhrase(transcript, emergencyPrefixes)
+}
+
+// emergencyRequested indica si la petición trae la cabecera de emergencia
+func emergencyRequested(r *http.Request) bool {
+	if r == nil {
+		return false
+	}
+	v, err := strconv.ParseBool(strings.TrimSpace(r.Header.Get(EmergencyHeader)))
+	return err == nil && v
+}
+
+// forceEmergency envuelve el manejador de conversación para que todo clip de la
+// petición, incluso los que se difunden sin transcripción, salga como emergencia
+func forceEmergency(next func(http.ResponseWriter, *models.User, []byte, string, bool)) func(http.ResponseWriter, *models.User, []byte, string, bool) {
+	return func(w http.ResponseWriter, user *models.User, audio []byte, transcript string, _ bool) {
+		next(w, user, audio, transcript, true)
+	}
+}
+
+func hasLeadingPhrase(transcript string, phrases []string) bool {
+	normalized := strings.ToLower(strings.TrimSpace(transcript))
+	normalized = strings.TrimLeft(normalized, "¡¿!?.,;: ")
+	for _, prefix := range phrases {
+		if !strings.HasPrefix(normalized, prefix) {
+			continue
+		}
+		rest := normalized[len(prefix):]
+		if rest == "" || strings.ContainsRune(" ,.;:!¡", rune(rest[0])) {
+			return true
+		}
+	}
+	return false
+}
+
+// recordUrgentUse registra un uso de urgencia y devuelve false si el usuario superó
+// el máximo de mensajes urgentes dentro de la ventana configurada
+func recordUrgentUse(userID uint, cfg urgencyConfig, now time.Time) bool {
+	urgencyTracker.Lock()
+	defer urgencyTracker.Unlock()
+
+	cutoff := now.Add(-cfg.window)
+	recent := urgencyTracker.byUser[userID][:0]
+	for _, ts := range urgencyTracker.byUser[userID] {
+		if ts.After(cutoff) {
+			recent = append(recent, ts)
+		}
+	}
+	recent = append(recent, now)
+	urgencyTracker.byUser[userID] = recent
+
+	return len(recent) <= cfg.maxPerWindow
+}
+
+// messagePriority decide la prioridad del clip a partir de la transcripción o de la
+// cabecera de emergencia. Si el usuario abusa de la marca urgente el clip se entrega
+// con prioridad normal; las emergencias no se limitan pero quedan en las métricas y el
+// log, que es donde se ve el usuario (una etiqueta por usuario dispararía las series).
+func messagePriority(userID uint, transcript string, emergency bool) string {
+	if emergency || hasEmergencyPrefix(transcript) {
+		metrics.Inc("walkie_emergency_clips_total", nil)
+		log.Printf("[EMERGENCIA] usuario=%d mensaje marcado como emergencia", userID)
+		return PriorityEmergency
+	}
+	if !hasUrgentPrefix(transcript) {
+		return PriorityNormal
+	}
+
+	metrics.Inc("walkie_urgent_clips_total", nil)
+
+	if !recordUrgentUse(userID, loadUrgencyConfig(), time.Now()) {
+		metrics.Inc("walkie_urgent_abuse_total", nil)
+		log.Printf("[URGENTE] usuario=%d supera el límite de mensajes urgentes, se entrega como normal", userID)
+		return PriorityNormal
+	}
+
+	log.Printf("[URGENTE] usuario=%d mensaje marcado como urgente", userID)
+	return PriorityUrgent
+}
@@ -0,0 +1,112 @@
+package handlers
+
+import (
+	"testing"
+	"time"
+)
+
+func resetUrgencyTracker() {
+	urgencyTracker.Lock()
+	urgencyTracker.byUser = make(map[uint][]time.Time)
+	urgencyTracker.Unlock()
+}
+
+func TestHasUrgentPrefix(t *testing.T) {
+	cases := map[string]bool{
+		"Urgente, necesito apoyo en la puerta": true,
+		"esto es urgente: se cayó el sistema":  true,
+		"¡Es urgente! vengan ya":               true,
+		"urgente":                              true,
+		"urgentemente no":                      false,
+		"no es urgente, tranquilos":            false,
+		"":                                     false,
+	}
+	for text, want := range cases {
+		if got := hasUrgentPrefix(text); got != want {
+			t.Errorf("hasUrgentPrefix(%q) = %v, want %v", text, got, want)
+		}
+	}
+}
+
+func TestRecordUrgentUse_LimitsPerWindow(t *testing.T) {
+	resetUrgencyTracker()
+	cfg := urgencyConfig{maxPerWindow: 2, window: time.Minute}
+	now := time.Now()
+
+	if !recordUrgentUse(1, cfg, now) || !recordUrgentUse(1, cfg, now.Add(time.Second)) {
+		t.Fatal("expected first two urgent clips to be allowed")
+	}
+	if recordUrgentUse(1, cfg, now.Add(2*time.Second)) {
+		t.Fatal("expected third urgent clip within window to be flagged")
+	}
+	if !recordUrgentUse(1, cfg, now.Add(2*time.Minute)) {
+		t.Fatal("expected urgency to be allowed again after the window")
+	}
+	if !recordUrgentUse(2, cfg, now) {
+		t.Fatal("expected limits to be tracked per user")
+	}
+}
+
+func TestEnqueueAudioWithPriority_UrgentJumpsAhead(t *testing.T) {
+	globalAudioQueue.mu.Lock()
+	globalAudioQueue.queues = make(map[uint][]*PendingAudio)
+	globalAudioQueue.mu.Unlock()
+
+	EnqueueAudio(1, "canal-1", []byte("normal-1"), 1, []uint{9})
+	EnqueueAudio(1, "canal-1", []byte("normal-2"), 1, []uint{9})
+	EnqueueAudioWithPriority(2, "canal-1", []byte("urgente-1"), 1, []uint{9}, PriorityUrgent)
+	EnqueueAudioWithPriority(3, "canal-1", []byte("urgente-2"), 1, []uint{9}, PriorityUrgent)
+
+	want := []string{"urgente-1", "urgente-2", "normal-1", "normal-2"}
+	for _, expected := range want {
+		audio := DequeueAudio(9)
+		if audio == nil {
+			t.Fatalf("expected %s, queue empty", expected)
+		}
+		if string(audio.AudioData) != expected {
+			t.Fatalf("expected %s, got %s", expected, audio.AudioData)
+		}
+		if audio.IsUrgent() != (expected[:7] == "urgente") {
+			t.Fatalf("unexpected priority %q for %s", audio.Priority, expected)
+		}
+	}
+}
+
+func TestMessagePriority_Emergency(t *testing.T) {
+	resetUrgencyTracker()
+
+	cases := []struct {
+		text      string
+		header    bool
+		wantPrior string
+	}{
+		{"Emergencia, hay humo en el almacén", false, PriorityEmergency},
+		{"¡Esto es una emergencia!", false, PriorityEmergency},
+		{"no es una emergencia", false, PriorityNormal},
+		{"hola a todos", true, PriorityEmergency},
+		{"urgente, vengan", false, PriorityUrgent},
+	}
+	for _, tc := range cases {
+		if got := messagePriority(1, tc.text, tc.header); got != tc.wantPrior {
+			t.Errorf("messagePriority(%q, %v) = %s, want %s", tc.text, tc.header, got, tc.wantPrior)
+		}
+	}
+}
+
+func TestEnqueueAudioWithPriority_EmergencyFirst(t *testing.T) {
+	globalAudioQueue.mu.Lock()
+	globalAudioQueue.queues = make(map[uint][]*PendingAudio)
+	globalAudioQueue.mu.Unlock()
+
+	EnqueueAudio(1, "canal-1", []byte("normal"), 1, []uint{9})
+	EnqueueAudioWithPriority(2, "canal-1", []byte("urgente"), 1, []uint{9}, PriorityUrgent)
+	EnqueueAudioWithPriority(3, "canal-1", []byte("emergencia-1"), 1, []uint{9}, PriorityEmergency)
+	EnqueueAudioWithPriority(4, "canal-1", []byte("emergencia-2"), 1, []uint{9}, PriorityEmergency)
+
+	for _, expected := range []string{"emergencia-1", "emergencia-2", "urgente", "normal"} {
+		audio := DequeueAudio(9)
+		if audio == nil || string(audio.AudioData) != expected {
+			t.Fatalf("expected %s, got %+v", expected, audio)
+		}
+	}
+}
@@ -0,0 +1,152 @@
+package handlers
+
+import (
+	"context"
+	"log"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"walkie-backend/internal/config"
+	"walkie-backend/internal/metrics"
+	"walkie-backend/internal/models"
+	"walkie-backend/internal/response"
+	"walkie-backend/internal/services"
+	"walkie-backend/pkg/qwen"
+)
+
+const (
+	intentQuotaExceeded = "quota_exceeded"
+	maxUsageDays        = 31
+)
+
+// recordUsage suma en segundo plano el coste de una petición al día del usuario
+func recordUsage(userID uint, sttSeconds float64, aiTokens int) {
+	db := config.DB
+	if db == nil || !config.DBAvailable() || (sttSeconds <= 0 && aiTokens <= 0) {
+		return
+	}
+	go func() {
+		if err := services.RecordUsage(db, userID, time.Now(), sttSeconds, aiTokens); err != nil {
+			log.Printf("[USO] usuario=%d error=%v", userID, err)
+		}
+	}()
+}
+
+// withUsageAccounting cuenta los tokens de cada llamada a la IA hecha con ctx
+func withUsageAccounting(ctx context.Context, userID uint) context.Context {
+	return qwen.WithUsageReport(ctx, func(u qwen.Usage) {
+		recordUsage(userID, 0, u.TotalTokens)
+	})
+}
+
+// quotaStage corta la petición antes del STT si el usuario agotó su cuota del día. Si no
+// se puede leer el uso se deja pasar: la cuota no debe tumbar el servicio.
+func quotaStage(w http.ResponseWriter, user *models.User, tracker *stageTimer) bool {
+	quota := services.DailyQuota()
+	if quota == (services.Quota{}) {
+		return true
+	}
+	now := time.Now()
+	usage, err := services.UsageFor(config.DB, user.ID, services.UsageDay(now))
+	if err != nil {
+		log.Printf("[USO] usuario=%d no se pudo leer el uso: %v", user.ID, err)
+		return true
+	}
+	if !quota.Exceeded(usage) {
+		return true
+	}
+
+	metrics.Inc("walkie_quota_exceeded_total", nil)
+	log.Printf("[USO] usuario=%d cuota agotada stt=%.0fs tokens=%d", user.ID, usage.STTSeconds, usage.AITokens)
+	w.Header().Set("Retry-After", strconv.Itoa(secondsUntilNextDay(now)))
+	response.WriteJSON(w, http.StatusTooManyRequests, CommandResponse{
+		Status:  "error",
+		Intent:  intentQuotaExceeded,
+		Message: "Cuota agotada: ya usaste todo tu tiempo de voz de hoy. Vuelve a intentarlo mañana",
+	})
+	tracker.LogFinal("quota_exceeded")
+	return false
+}
+
+// secondsUntilNextDay es lo que falta para que cambie el día de la cuota (UTC)
+func secondsUntilNextDay(now time.Time) int {
+	now = now.UTC()
+	next := time.Date(now.Year(), now.Month(), now.Day()+1, 0, 0, 0, 0, time.UTC)
+	return int(math.Ceil(next.Sub(now).Seconds()))
+}
+
+type usageDayItem struct {
+	Day           string  `json:"day"`
+	STTSeconds    float64 `json:"sttSeconds"`
+	AITokens      int     `json:"aiTokens"`
+	Requests      int     `json:"requests"`
+	EstimatedCost float64 `json:"estimatedCost"`
+}
+
+func newUsageDayItem(u models.UserUsage) usageDayItem {
+	return usageDayItem{
+		Day:           u.Day,
+		STTSeconds:    u.STTSeconds,
+		AITokens:      u.AITokens,
+		Requests:      u.Requests,
+		EstimatedCost: services.UsageCost(u),
+	}
+}
+
+// GET /me/usage?days=N
+// Devuelve el uso de hoy, la cuota y lo que queda, y el detalle de los últimos N días (7)
+func MeUsage(w http.ResponseWriter, r *http.Request) {
+	if !requireDB(w) {
+		return
+	}
+	user, err := resolveUserFromRequest(r)
+	if err != nil {
+		response.WriteErr(w, http.StatusUnauthorized, "Token inválido o expirado")
+		return
+	}
+
+	days := 7
+	if raw := r.URL.Query().Get("days"); raw != "" {
+		v, err := strconv.Atoi(raw)
+		if err != nil || v <= 0 || v > maxUsageDays {
+			response.WriteErr(w, http.StatusBadRequest, "days debe estar entre 1 y "+strconv.Itoa(maxUsageDays))
+			return
+		}
+		days = v
+	}
+
+	now := time.Now()
+	today, err := services.UsageFor(config.DB, user.ID, services.UsageDay(now))
+	if err != nil {
+		response.WriteErr(w, http.StatusInternalServerError, "No se pudo obtener el uso")
+		return
+	}
+	rows, err := services.UsageHistory(config.DB, user.ID, days, now)
+	if err != nil {
+		response.WriteErr(w, http.StatusInternalServerError, "No se pudo obtener el uso")
+		return
+	}
+	history := make([]usageDayItem, 0, len(rows))
+	for _, u := range rows {
+		history = append(history, newUsageDayItem(u))
+	}
+
+	quota := services.DailyQuota()
+	out := map[string]any{
+		"today":    newUsageDayItem(today),
+		"quota":    quota,
+		"exceeded": quota.Exceeded(today),
+		"history":  history,
+	}
+	remaining := map[string]any{}
+	if quota.STTSeconds > 0 {
+		remaining["sttSeconds"] = math.Max(0, quota.STTSeconds-today.STTSeconds)
+	}
+	if quota.AITokens > 0 {
+		remaining["aiTokens"] = max(0, quota.AITokens-today.AITokens)
+	}
+	out["remaining"] = remaining
+	response.WriteJSON(w, http.StatusOK, out)
+}
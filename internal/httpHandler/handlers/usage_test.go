@@ -0,0 +1,65 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"walkie-backend/internal/models"
+	"walkie-backend/internal/services"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestQuotaStage_BlocksWhenExhaustedAndMeUsageReports(t *testing.T) {
+	db := setupTestDB(t)
+	require.NoError(t, db.AutoMigrate(&models.UserUsage{}))
+	t.Setenv("QUOTA_STT_SECONDS_PER_DAY", "30")
+	t.Setenv("QUOTA_AI_TOKENS_PER_DAY", "1000")
+	user := createTestUser(t, db, 661, "token-usage", "")
+
+	rec := httptest.NewRecorder()
+	assert.True(t, quotaStage(rec, user, newStageTimer(user.ID)), "fresh users are within quota")
+
+	require.NoError(t, services.RecordUsage(db, user.ID, time.Now(), 31, 200))
+	rec = httptest.NewRecorder()
+	assert.False(t, quotaStage(rec, user, newStageTimer(user.ID)))
+	assert.Equal(t, http.StatusTooManyRequests, rec.Code)
+	assert.NotEmpty(t, rec.Header().Get("Retry-After"))
+	var resp CommandResponse
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+	assert.Equal(t, intentQuotaExceeded, resp.Intent)
+	assert.Contains(t, resp.Message, "Cuota agotada")
+
+	req := httptest.NewRequest(http.MethodGet, "/me/usage?days=3", nil)
+	req = req.WithContext(withAuthUser(req.Context(), user))
+	rec = httptest.NewRecorder()
+	MeUsage(rec, req)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	var body struct {
+		Today     usageDayItem   `json:"today"`
+		Exceeded  bool           `json:"exceeded"`
+		Remaining map[string]any `json:"remaining"`
+		History   []usageDayItem `json:"history"`
+	}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+	assert.Equal(t, 31.0, body.Today.STTSeconds)
+	assert.True(t, body.Exceeded)
+	assert.Equal(t, 0.0, body.Remaining["sttSeconds"])
+	assert.Equal(t, 800.0, body.Remaining["aiTokens"])
+	assert.Len(t, body.History, 1)
+
+	req = httptest.NewRequest(http.MethodGet, "/me/usage?days=0", nil)
+	req = req.WithContext(withAuthUser(req.Context(), user))
+	rec = httptest.NewRecorder()
+	MeUsage(rec, req)
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+}
+
+func TestSecondsUntilNextDay(t *testing.T) {
+	now := time.Date(2026, 5, 1, 23, 59, 0, 0, time.UTC)
+	assert.Equal(t, 60, secondsUntilNextDay(now))
+}
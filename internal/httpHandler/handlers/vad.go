@@ -0,0 +1,105 @@
+package handlers
+
+import (
+	"log"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"walkie-backend/internal/config"
+	"walkie-backend/internal/metrics"
+	"walkie-backend/internal/models"
+	"walkie-backend/pkg/audio"
+	"walkie-backend/pkg/stt"
+)
+
+// vadEnabled indica si se descartan los clips sin voz antes del STT (VAD_ENABLED=false lo desactiva)
+func vadEnabled() bool {
+	return strings.TrimSpace(strings.ToLower(os.Getenv("VAD_ENABLED"))) != "false"
+}
+
+// vadThresholds lee VAD_MIN_RMS, VAD_MIN_DELTA y VAD_MIN_BYTES; por defecto los de stt.IsHumanSpeech
+func vadThresholds() stt.SpeechThresholds {
+	th := stt.DefaultSpeechThresholds()
+	th.MinRMS = float64(config.IntFromEnv("VAD_MIN_RMS", int(th.MinRMS)))
+	th.MinDelta = config.IntFromEnv("VAD_MIN_DELTA", th.MinDelta)
+	th.MinPayload = config.IntFromEnv("VAD_MIN_BYTES", th.MinPayload)
+	return th
+}
+
+// detectSpeech aplica el detector de voz a los clips WAV; los formatos comprimidos no se
+// pueden medir sin decodificar, así que se dejan pasar (checked=false)
+func detectSpeech(data []byte, format string) (speech, checked bool) {
+	if !vadEnabled() || !audio.IsWAV(data) {
+		return true, false
+	}
+	return stt.DetectSpeech(data, vadThresholds()), true
+}
+
+// voiceActivityStage descarta los clips sin voz detectable antes de gastar STT o LLM
+func voiceActivityStage(w http.ResponseWriter, deps audioIngestDeps, user *models.User, data []byte, format string, tracker *stageTimer) bool {
+	stageStart := time.Now()
+	speech, checked := deps.detectSpeech(data, format)
+	tracker.LogStage("vad", stageStart, map[string]any{
+		"speech":  speech,
+		"checked": checked,
+	})
+	if speech {
+		return true
+	}
+
+	log.Printf("[VAD] usuario=%d clip sin voz descartado bytes=%d", user.ID, len(data))
+	dropClip(w, user, audio.ClassSilence)
+	tracker.LogFinal("no_speech")
+	return false
+}
+
+// noiseFilterEnabled indica si se descartan los clips de ruido (NOISE_FILTER=false lo desactiva)
+func noiseFilterEnabled() bool {
+	return strings.TrimSpace(strings.ToLower(os.Getenv("NOISE_FILTER"))) != "false"
+}
+
+// classifyClip distingue voz de ruido accidental en los clips WAV; el resto pasa sin medir
+func classifyClip(data []byte, format string) (audio.Classification, bool) {
+	if !noiseFilterEnabled() {
+		return audio.Classification{Class: audio.ClassSpeech}, false
+	}
+	c, ok := audio.ClassifyWAV(data)
+	if !ok {
+		return audio.Classification{Class: audio.ClassSpeech}, false
+	}
+	return c, true
+}
+
+// noiseFilterStage descarta las pulsaciones accidentales del PTT (bolsillo, viento) que
+// superan el VAD por volumen pero no suenan a voz
+func noiseFilterStage(w http.ResponseWriter, deps audioIngestDeps, user *models.User, data []byte, format string, tracker *stageTimer) bool {
+	stageStart := time.Now()
+	c, checked := deps.classifyClip(data, format)
+	tracker.LogStage("noise_filter", stageStart, map[string]any{
+		"class":            c.Class,
+		"checked":          checked,
+		"active_frames":    c.ActiveFrames,
+		"voiced_ratio":     c.VoicedRatio,
+		"energy_variation": c.EnergyVariation,
+	})
+	if c.Class != audio.ClassNoise {
+		return true
+	}
+
+	log.Printf("[RUIDO] usuario=%d clip de ruido descartado bytes=%d voz=%.2f", user.ID, len(data), c.VoicedRatio)
+	dropClip(w, user, audio.ClassNoise)
+	tracker.LogFinal("noise")
+	return false
+}
+
+// dropClip cuenta el clip descartado y responde igual que a un audio incoherente
+func dropClip(w http.ResponseWriter, user *models.User, reason string) {
+	metrics.Inc("walkie_dropped_clips_total", map[string]string{"reason": reason})
+	if user.IsInChannel() {
+		w.WriteHeader(http.StatusNoContent)
+	} else {
+		writeUnintelligibleResponse(w)
+	}
+}
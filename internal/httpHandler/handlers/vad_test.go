@@ -0,0 +1,118 @@
+package handlers
+
+import (
+	"encoding/binary"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"walkie-backend/internal/ai"
+	"walkie-backend/internal/metrics"
+	"walkie-backend/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+func silentWAV(payload int) []byte {
+	data := buildTestWAV(payload)
+	clear(data[44:])
+	return data
+}
+
+func TestDetectSpeech(t *testing.T) {
+	speech, checked := detectSpeech(buildTestWAV(4000), "audio/wav")
+	assert.True(t, speech)
+	assert.True(t, checked)
+
+	speech, checked = detectSpeech(silentWAV(4000), "audio/wav")
+	assert.False(t, speech)
+	assert.True(t, checked)
+
+	// Los formatos comprimidos no se miden
+	speech, checked = detectSpeech([]byte("OggS-no-decodificable"), "audio/ogg")
+	assert.True(t, speech)
+	assert.False(t, checked)
+
+	t.Setenv("VAD_MIN_RMS", "5000")
+	t.Setenv("VAD_MIN_DELTA", "5000")
+	speech, _ = detectSpeech(buildTestWAV(4000), "audio/wav")
+	assert.False(t, speech, "umbrales altos deben descartar el clip")
+
+	t.Setenv("VAD_ENABLED", "false")
+	speech, checked = detectSpeech(silentWAV(4000), "audio/wav")
+	assert.True(t, speech)
+	assert.False(t, checked)
+}
+
+func TestRunAudioIngest_VADDropsSilenceBeforeSTT(t *testing.T) {
+	user := &models.User{Model: gorm.Model{ID: 191}, CurrentChannel: &models.Channel{Code: "canal-1"}}
+	channelID := uint(1)
+	user.CurrentChannelID = &channelID
+
+	sttCalled := false
+	deps := newAudioIngestDeps()
+	deps.readUserID = func(*http.Request) (uint, error) { return user.ID, nil }
+	deps.newUserService = func() userService { return &mockUserService{user: user} }
+	deps.localSTT = func() sttClient { return nil }
+	deps.ensureSTT = func() (sttClient, error) {
+		sttCalled = true
+		return &mockSTT{text: "hola"}, nil
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "/audio/ingest", strings.NewReader(string(silentWAV(8000))))
+	req.Header.Set("Content-Type", "audio/wav")
+	rec := httptest.NewRecorder()
+	runAudioIngest(rec, req, deps)
+
+	require.Equal(t, http.StatusNoContent, rec.Code, rec.Body.String())
+	assert.False(t, sttCalled, "no debe llamarse al STT con un clip sin voz")
+}
+
+// hissWAV simula el roce del micrófono en el bolsillo: ruido de banda ancha con volumen
+func hissWAV(payload int) []byte {
+	data := buildTestWAV(payload)
+	seed := uint32(7)
+	for i := 44; i+1 < len(data); i += 2 {
+		seed = seed*1664525 + 1013904223
+		binary.LittleEndian.PutUint16(data[i:i+2], uint16(int16(seed>>16)/4))
+	}
+	return data
+}
+
+func TestRunAudioIngest_NoiseFilterDropsPocketNoise(t *testing.T) {
+	user := &models.User{Model: gorm.Model{ID: 192}}
+	labels := map[string]string{"reason": "noise"}
+	before := metrics.Default().Counter("walkie_dropped_clips_total", labels)
+
+	sttCalled := false
+	deps := newAudioIngestDeps()
+	deps.readUserID = func(*http.Request) (uint, error) { return user.ID, nil }
+	deps.newUserService = func() userService { return &mockUserService{user: user} }
+	deps.localSTT = func() sttClient { return nil }
+	deps.ensureSTT = func() (sttClient, error) {
+		sttCalled = true
+		return &mockSTT{text: "hola"}, nil
+	}
+	deps.ensureAI = func() (ai.Analyzer, error) { return &mockQwen{result: ai.CommandResult{Intent: "conversation"}}, nil }
+
+	ingest := func(data []byte) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/audio/ingest", strings.NewReader(string(data)))
+		req.Header.Set("Content-Type", "audio/wav")
+		rec := httptest.NewRecorder()
+		runAudioIngest(rec, req, deps)
+		return rec
+	}
+
+	rec := ingest(hissWAV(16000))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), `"status":"ignored"`)
+	assert.False(t, sttCalled, "no debe llamarse al STT con ruido")
+	assert.Equal(t, before+1, metrics.Default().Counter("walkie_dropped_clips_total", labels))
+
+	t.Setenv("NOISE_FILTER", "false")
+	ingest(hissWAV(16000))
+	assert.True(t, sttCalled, "con NOISE_FILTER=false el clip llega al STT")
+}
@@ -0,0 +1,87 @@
+package handlers
+
+import (
+	"log"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"walkie-backend/internal/metrics"
+	"walkie-backend/internal/models"
+	"walkie-backend/pkg/qwen"
+)
+
+const defaultWakeWords = "asistente,radio"
+
+// wakeWordRequired indica si sólo se analizan como comando las frases que empiezan por
+// una palabra de activación (WAKE_WORD_REQUIRED=true)
+func wakeWordRequired() bool {
+	return strings.ToLower(strings.TrimSpace(os.Getenv("WAKE_WORD_REQUIRED"))) == "true"
+}
+
+// wakeWords devuelve las palabras de activación de WAKE_WORDS (separadas por comas),
+// normalizadas y de la más larga a la más corta
+func wakeWords() []string {
+	raw := os.Getenv("WAKE_WORDS")
+	if strings.TrimSpace(raw) == "" {
+		raw = defaultWakeWords
+	}
+	var words []string
+	for _, w := range strings.Split(raw, ",") {
+		if w = qwen.NormalizePhrase(w); w != "" {
+			words = append(words, w)
+		}
+	}
+	// "oye radio" debe probarse antes que "radio"
+	sort.SliceStable(words, func(i, j int) bool {
+		return len(strings.Fields(words[i])) > len(strings.Fields(words[j]))
+	})
+	return words
+}
+
+// stripWakeWord quita la palabra de activación del principio de la transcripción y
+// devuelve el resto tal cual se dijo; ok es false si no empieza por ninguna
+func stripWakeWord(text string, words []string) (rest string, ok bool) {
+	fields := strings.Fields(text)
+	for _, w := range words {
+		n := len(strings.Fields(w))
+		if n == 0 || len(fields) < n {
+			continue
+		}
+		if qwen.NormalizePhrase(strings.Join(fields[:n], " ")) != w {
+			continue
+		}
+		rest = strings.Join(fields[n:], " ")
+		return strings.TrimLeft(rest, ",.;:!¡?¿ "), true
+	}
+	return "", false
+}
+
+// wakeWordStage decide si la transcripción pasa por la IA. Sin WAKE_WORD_REQUIRED todo
+// se analiza; con él, sólo lo que empieza por la palabra de activación (sin ella) y,
+// además, lo de quien no está en un canal o tiene una pregunta pendiente. El resto va
+// directo a difusión
+func wakeWordStage(user *models.User, text string, tracker *stageTimer) (commandText string, analyze bool) {
+	if !wakeWordRequired() {
+		return text, true
+	}
+	stageStart := time.Now()
+	rest, addressed := stripWakeWord(text, wakeWords())
+	analyze = addressed || !user.IsInChannel() || pendingChannelFor(user.ID) != ""
+	commandText = text
+	if addressed {
+		commandText = rest
+	}
+
+	metrics.Inc("walkie_wake_word_total", map[string]string{"addressed": strconv.FormatBool(addressed)})
+	tracker.LogStage("wake_word", stageStart, map[string]any{
+		"addressed": addressed,
+		"analyze":   analyze,
+	})
+	if !analyze {
+		log.Printf("[ACTIVACION] usuario=%d sin palabra de activación, directo a difusión", user.ID)
+	}
+	return commandText, analyze
+}
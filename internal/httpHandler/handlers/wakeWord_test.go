@@ -0,0 +1,91 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"walkie-backend/internal/ai"
+	"walkie-backend/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+// textRecordingAnalyzer guarda el texto que le llega a la IA
+type textRecordingAnalyzer struct {
+	texts  []string
+	result ai.CommandResult
+}
+
+func (a *textRecordingAnalyzer) AnalyzeTranscript(ctx context.Context, text string, channels []string, state string, pendingChannel string) (ai.CommandResult, error) {
+	a.texts = append(a.texts, text)
+	return a.result, nil
+}
+
+func TestStripWakeWord(t *testing.T) {
+	t.Setenv("WAKE_WORDS", "radio, Oye Asistente")
+	words := wakeWords()
+	assert.Equal(t, []string{"oye asistente", "radio"}, words)
+
+	rest, ok := stripWakeWord("Radio, conéctame al canal dos", words)
+	assert.True(t, ok)
+	assert.Equal(t, "conéctame al canal dos", rest)
+
+	rest, ok = stripWakeWord("¡Oye, asistente! lista de canales", words)
+	assert.True(t, ok)
+	assert.Equal(t, "lista de canales", rest)
+
+	_, ok = stripWakeWord("la radiografía ya está lista", words)
+	assert.False(t, ok)
+	_, ok = stripWakeWord("pon la radio", words)
+	assert.False(t, ok)
+}
+
+func TestRunAudioIngest_WakeWordSkipsAIForChatter(t *testing.T) {
+	t.Setenv("WAKE_WORD_REQUIRED", "true")
+	t.Setenv("WAKE_WORDS", "")
+	channelID := uint(1)
+	user := &models.User{Model: gorm.Model{ID: 669}, CurrentChannelID: &channelID, CurrentChannel: &models.Channel{Code: "canal-1"}}
+
+	analyzer := &textRecordingAnalyzer{result: ai.CommandResult{IsCommand: true, Intent: "request_channel_list"}}
+	var broadcast []string
+	transcript := ""
+	deps := newAudioIngestDeps()
+	deps.readUserID = func(*http.Request) (uint, error) { return user.ID, nil }
+	deps.newUserService = func() userService { return &mockUserService{user: user} }
+	deps.ensureSTT = func() (sttClient, error) { return &mockSTT{text: transcript}, nil }
+	deps.ensureAI = func() (ai.Analyzer, error) { return analyzer, nil }
+	deps.validateAudio = func([]byte, string) bool { return true }
+	deps.readAudio = func(*http.Request) ([]byte, string, error) { return []byte("audio data"), "audio/wav", nil }
+	deps.classifyAbuse = func(context.Context, string) (bool, error) { return false, nil }
+	deps.handleConversation = func(w http.ResponseWriter, _ *models.User, _ []byte, text string, _ bool) {
+		broadcast = append(broadcast, text)
+		w.WriteHeader(http.StatusNoContent)
+	}
+	deps.executeCommand = func(*models.User, userService, ai.CommandResult) (CommandResponse, error) {
+		return CommandResponse{Status: "ok", Intent: "request_channel_list", Message: "Canales: 1, 2"}, nil
+	}
+	ingest := func(text string) *httptest.ResponseRecorder {
+		transcript = text
+		rec := httptest.NewRecorder()
+		runAudioIngest(rec, httptest.NewRequest(http.MethodPost, "/audio/ingest", bytes.NewReader(nil)), deps)
+		return rec
+	}
+
+	rec := ingest("llego en cinco minutos a la lista de canales")
+	assert.Equal(t, http.StatusNoContent, rec.Code)
+	assert.Empty(t, analyzer.texts, "sin palabra de activación no se llama a la IA")
+	assert.Equal(t, []string{"llego en cinco minutos a la lista de canales"}, broadcast)
+
+	rec = ingest("Asistente, dame la lista de canales")
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, []string{"dame la lista de canales"}, analyzer.texts)
+
+	// Quien no está en un canal no tiene a dónde difundir: se analiza todo
+	user.CurrentChannelID, user.CurrentChannel = nil, nil
+	ingest("lista de canales")
+	assert.Equal(t, []string{"dame la lista de canales", "lista de canales"}, analyzer.texts)
+}
@@ -0,0 +1,213 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"walkie-backend/internal/config"
+	"walkie-backend/internal/models"
+	"walkie-backend/internal/response"
+	"walkie-backend/internal/services"
+)
+
+const webhookSecretBytes = 32
+
+type webhookSubscriptionRequest struct {
+	URL     string   `json:"url"`
+	Events  []string `json:"events"`
+	Channel string   `json:"channel"`
+	// Secret es opcional; si no se indica se genera uno
+	Secret string `json:"secret"`
+}
+
+type webhookSubscriptionView struct {
+	ID        uint      `json:"id"`
+	URL       string    `json:"url"`
+	Events    []string  `json:"events"`
+	Channel   string    `json:"channel,omitempty"`
+	CreatedBy string    `json:"createdBy,omitempty"`
+	CreatedAt time.Time `json:"createdAt"`
+	// Secret sólo se devuelve al crearla
+	Secret string `json:"secret,omitempty"`
+}
+
+func toWebhookSubscriptionView(s *models.WebhookSubscription) webhookSubscriptionView {
+	return webhookSubscriptionView{
+		ID:        s.ID,
+		URL:       s.URL,
+		Events:    strings.Split(s.Events, ","),
+		Channel:   s.Channel,
+		CreatedBy: s.CreatedBy,
+		CreatedAt: s.CreatedAt,
+	}
+}
+
+// GET /admin/webhook-subscriptions lista las suscripciones; POST /admin/webhook-subscriptions
+// registra una URL para recibir eventos de canal firmados con HMAC
+func AdminWebhookSubscriptions(w http.ResponseWriter, r *http.Request) {
+	if !requireAdmin(w, r) || !requireDB(w) {
+		return
+	}
+
+	svc := services.NewWebhookSubscriptionService(config.DB)
+	if r.Method == http.MethodGet {
+		items, err := svc.List()
+		if err != nil {
+			response.WriteErr(w, http.StatusInternalServerError, "No se pudieron obtener las suscripciones")
+			return
+		}
+		out := make([]webhookSubscriptionView, 0, len(items))
+		for i := range items {
+			out = append(out, toWebhookSubscriptionView(&items[i]))
+		}
+		response.WriteJSON(w, http.StatusOK, out)
+		return
+	}
+
+	var in webhookSubscriptionRequest
+	if err := json.NewDecoder(io.LimitReader(r.Body, 64*1024)).Decode(&in); err != nil {
+		response.WriteErr(w, http.StatusBadRequest, "JSON inválido")
+		return
+	}
+	secret := strings.TrimSpace(in.Secret)
+	if secret == "" {
+		generated, err := generateToken(webhookSecretBytes)
+		if err != nil {
+			response.WriteErr(w, http.StatusInternalServerError, "No se pudo generar el secreto")
+			return
+		}
+		secret = generated
+	}
+
+	sub := models.WebhookSubscription{
+		URL:       in.URL,
+		Secret:    secret,
+		Events:    strings.Join(in.Events, ","),
+		Channel:   in.Channel,
+		CreatedBy: adminActor(r),
+	}
+	if err := svc.Create(&sub); err != nil {
+		writeWebhookSubscriptionError(w, err)
+		return
+	}
+	services.RecordAudit(nil, models.AuditEntry{
+		Actor:   sub.CreatedBy,
+		Action:  "webhook_subscription_create",
+		Channel: sub.Channel,
+		Details: fmt.Sprintf("id=%d url=%s events=%s", sub.ID, sub.URL, sub.Events),
+		Source:  models.EventSourceHTTP,
+	})
+
+	view := toWebhookSubscriptionView(&sub)
+	view.Secret = secret
+	response.WriteJSON(w, http.StatusCreated, view)
+}
+
+// DELETE /admin/webhook-subscriptions/{id}
+func AdminWebhookSubscription(w http.ResponseWriter, r *http.Request) {
+	if !requireAdmin(w, r) || !requireDB(w) {
+		return
+	}
+
+	id, err := strconv.ParseUint(r.PathValue("id"), 10, 64)
+	if err != nil || id == 0 {
+		response.WriteErr(w, http.StatusBadRequest, "ID de suscripción inválido")
+		return
+	}
+	if err := services.NewWebhookSubscriptionService(config.DB).Delete(uint(id)); err != nil {
+		writeWebhookSubscriptionError(w, err)
+		return
+	}
+	services.RecordAudit(nil, models.AuditEntry{
+		Actor:   adminActor(r),
+		Action:  "webhook_subscription_delete",
+		Details: fmt.Sprintf("id=%d", id),
+		Source:  models.EventSourceHTTP,
+	})
+	response.WriteJSON(w, http.StatusOK, map[string]any{"status": "deleted", "id": id})
+}
+
+// GET /admin/webhook-dead-letters?subscription=ID&limit=N
+// Envíos que agotaron los reintentos, del más reciente al más antiguo
+func AdminWebhookDeadLetters(w http.ResponseWriter, r *http.Request) {
+	if !requireAdmin(w, r) || !requireDB(w) {
+		return
+	}
+
+	q := r.URL.Query()
+	var subscriptionID uint64
+	if raw := q.Get("subscription"); raw != "" {
+		id, err := strconv.ParseUint(raw, 10, 64)
+		if err != nil || id == 0 {
+			response.WriteErr(w, http.StatusBadRequest, "Parámetro subscription inválido")
+			return
+		}
+		subscriptionID = id
+	}
+	limit, _ := strconv.Atoi(q.Get("limit"))
+
+	items, err := services.NewWebhookSubscriptionService(config.DB).DeadLetters(uint(subscriptionID), limit)
+	if err != nil {
+		response.WriteErr(w, http.StatusInternalServerError, "No se pudieron obtener los envíos fallidos")
+		return
+	}
+	out := make([]map[string]any, 0, len(items))
+	for _, dl := range items {
+		out = append(out, map[string]any{
+			"id":             dl.ID,
+			"createdAt":      dl.CreatedAt,
+			"subscriptionId": dl.SubscriptionID,
+			"deliveryId":     dl.DeliveryID,
+			"event":          dl.Event,
+			"payload":        json.RawMessage(dl.Payload),
+			"attempts":       dl.Attempts,
+			"statusCode":     dl.StatusCode,
+			"lastError":      dl.LastError,
+		})
+	}
+	response.WriteJSON(w, http.StatusOK, out)
+}
+
+// POST /admin/webhook-dead-letters/{id}/retry reenvía una vez un envío fallido
+func AdminWebhookDeadLetterRetry(w http.ResponseWriter, r *http.Request) {
+	if !requireAdmin(w, r) || !requireDB(w) {
+		return
+	}
+
+	id, err := strconv.ParseUint(r.PathValue("id"), 10, 64)
+	if err != nil || id == 0 {
+		response.WriteErr(w, http.StatusBadRequest, "ID de envío inválido")
+		return
+	}
+	if err := services.NewWebhookSubscriptionService(config.DB).Redeliver(uint(id)); err != nil {
+		switch {
+		case errors.Is(err, services.ErrDeadLetterNotFound), errors.Is(err, services.ErrSubscriptionNotFound):
+			writeWebhookSubscriptionError(w, err)
+		default:
+			log.Printf("[WEBHOOKS] reenvío %d fallido: %v", id, err)
+			response.WriteErr(w, http.StatusBadGateway, "El destino no aceptó el envío: "+err.Error())
+		}
+		return
+	}
+	response.WriteJSON(w, http.StatusOK, map[string]any{"status": "delivered", "id": id})
+}
+
+func writeWebhookSubscriptionError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, services.ErrSubscriptionNotFound):
+		response.WriteErr(w, http.StatusNotFound, "Suscripción no encontrada")
+	case errors.Is(err, services.ErrDeadLetterNotFound):
+		response.WriteErr(w, http.StatusNotFound, "Envío fallido no encontrado")
+	case errors.Is(err, services.ErrInvalidSubscription):
+		response.WriteErr(w, http.StatusBadRequest, err.Error())
+	default:
+		response.WriteErr(w, http.StatusInternalServerError, "No se pudo guardar la suscripción")
+	}
+}
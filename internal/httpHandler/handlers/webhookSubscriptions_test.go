@@ -0,0 +1,59 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"walkie-backend/internal/config"
+	"walkie-backend/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAdminWebhookSubscriptions_Lifecycle(t *testing.T) {
+	cleanup := setupChannelsTestDB(t)
+	defer cleanup()
+	t.Setenv("ADMIN_TOKEN", "secreto")
+	require.NoError(t, config.DB.AutoMigrate(&models.WebhookSubscription{}, &models.WebhookDeadLetter{}, &models.AuditEntry{}))
+
+	post := func(body string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		AdminWebhookSubscriptions(rec, adminRequest(http.MethodPost, "/admin/webhook-subscriptions", body))
+		return rec
+	}
+	assert.Equal(t, http.StatusBadRequest, post(`{"url":"https://ops.example.com/hook","events":["otro"]}`).Code)
+	assert.Equal(t, http.StatusBadRequest, post(`{"url":"nada","events":["user_joined"]}`).Code)
+
+	rec := post(`{"url":"https://ops.example.com/hook","events":["transcript_available","command_executed"]}`)
+	require.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+	var created webhookSubscriptionView
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &created))
+	assert.Len(t, created.Secret, webhookSecretBytes*2, "sin secret se genera uno")
+	assert.Equal(t, []string{"command_executed", "transcript_available"}, created.Events)
+
+	rec = httptest.NewRecorder()
+	AdminWebhookSubscriptions(rec, adminRequest(http.MethodGet, "/admin/webhook-subscriptions", ""))
+	require.Equal(t, http.StatusOK, rec.Code)
+	var listed []webhookSubscriptionView
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &listed))
+	require.Len(t, listed, 1)
+	assert.Empty(t, listed[0].Secret, "el secreto no se vuelve a mostrar")
+
+	rec = httptest.NewRecorder()
+	AdminWebhookDeadLetters(rec, adminRequest(http.MethodGet, "/admin/webhook-dead-letters?subscription=x", ""))
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+	del := func() int {
+		req := adminRequest(http.MethodDelete, fmt.Sprintf("/admin/webhook-subscriptions/%d", created.ID), "")
+		req.SetPathValue("id", fmt.Sprint(created.ID))
+		rec := httptest.NewRecorder()
+		AdminWebhookSubscription(rec, req)
+		return rec.Code
+	}
+	assert.Equal(t, http.StatusOK, del())
+	assert.Equal(t, http.StatusNotFound, del())
+}
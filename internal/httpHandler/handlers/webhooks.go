@@ -0,0 +1,275 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"walkie-backend/internal/config"
+	"walkie-backend/internal/metrics"
+	"walkie-backend/internal/models"
+	"walkie-backend/internal/response"
+	"walkie-backend/internal/services"
+)
+
+const (
+	webhookTokenBytes = 24
+	webhookPathPrefix = "/integrations/webhooks/"
+	// maxWebhookText limita lo que se sintetiza por aviso
+	maxWebhookText = 500
+)
+
+// WebhookLimiter evita que una integración que falla en bucle inunde el canal
+var WebhookLimiter = rateLimitFromEnv("webhook", 30, 10)
+
+type webhookRequest struct {
+	Name     string `json:"name"`
+	Channel  string `json:"channel"`
+	Priority string `json:"priority"`
+}
+
+type webhookView struct {
+	ID         uint       `json:"id"`
+	Name       string     `json:"name"`
+	Channel    string     `json:"channel"`
+	Priority   string     `json:"priority"`
+	CreatedBy  string     `json:"createdBy,omitempty"`
+	LastUsedAt *time.Time `json:"lastUsedAt,omitempty"`
+	Uses       int        `json:"uses"`
+	// Token y URL sólo se devuelven al crearlo
+	Token string `json:"token,omitempty"`
+	URL   string `json:"url,omitempty"`
+}
+
+func toWebhookView(w *models.IncomingWebhook) webhookView {
+	return webhookView{
+		ID:         w.ID,
+		Name:       w.Name,
+		Channel:    w.Channel,
+		Priority:   w.Priority,
+		CreatedBy:  w.CreatedBy,
+		LastUsedAt: w.LastUsedAt,
+		Uses:       w.Uses,
+	}
+}
+
+// alertPriority valida la prioridad de un aviso; vacía usa fallback
+func alertPriority(raw, fallback string) (string, bool) {
+	switch p := strings.TrimSpace(raw); p {
+	case "":
+		return fallback, true
+	case PriorityNormal, PriorityUrgent, PriorityEmergency:
+		return p, true
+	default:
+		return "", false
+	}
+}
+
+// GET /admin/webhooks lista los webhooks de entrada; POST /admin/webhooks crea uno y
+// devuelve su token, que no se vuelve a mostrar
+func AdminWebhooks(w http.ResponseWriter, r *http.Request) {
+	if !requireAdmin(w, r) || !requireDB(w) {
+		return
+	}
+
+	svc := services.NewIncomingWebhookService(config.DB)
+	if r.Method == http.MethodGet {
+		items, err := svc.List()
+		if err != nil {
+			response.WriteErr(w, http.StatusInternalServerError, "No se pudieron obtener los webhooks")
+			return
+		}
+		out := make([]webhookView, 0, len(items))
+		for i := range items {
+			out = append(out, toWebhookView(&items[i]))
+		}
+		response.WriteJSON(w, http.StatusOK, out)
+		return
+	}
+
+	var in webhookRequest
+	if err := json.NewDecoder(io.LimitReader(r.Body, 64*1024)).Decode(&in); err != nil {
+		response.WriteErr(w, http.StatusBadRequest, "JSON inválido")
+		return
+	}
+	priority, ok := alertPriority(in.Priority, PriorityNormal)
+	if !ok {
+		response.WriteErr(w, http.StatusBadRequest, "priority debe ser normal, urgent o emergency")
+		return
+	}
+	token, err := generateToken(webhookTokenBytes)
+	if err != nil {
+		response.WriteErr(w, http.StatusInternalServerError, "No se pudo generar el token")
+		return
+	}
+
+	hook := models.IncomingWebhook{
+		Name:      in.Name,
+		Channel:   in.Channel,
+		Priority:  priority,
+		TokenHash: hashToken(token),
+		CreatedBy: adminActor(r),
+	}
+	if err := svc.Create(&hook); err != nil {
+		writeWebhookError(w, err)
+		return
+	}
+	services.RecordAudit(nil, models.AuditEntry{
+		Actor:   hook.CreatedBy,
+		Action:  "webhook_create",
+		Channel: hook.Channel,
+		Details: fmt.Sprintf("id=%d name=%s priority=%s", hook.ID, hook.Name, hook.Priority),
+		Source:  models.EventSourceHTTP,
+	})
+
+	view := toWebhookView(&hook)
+	view.Token = token
+	view.URL = webhookPathPrefix + token
+	response.WriteJSON(w, http.StatusCreated, view)
+}
+
+// DELETE /admin/webhooks/{id} revoca un webhook
+func AdminWebhook(w http.ResponseWriter, r *http.Request) {
+	if !requireAdmin(w, r) || !requireDB(w) {
+		return
+	}
+
+	id, err := strconv.ParseUint(r.PathValue("id"), 10, 64)
+	if err != nil || id == 0 {
+		response.WriteErr(w, http.StatusBadRequest, "ID de webhook inválido")
+		return
+	}
+	if err := services.NewIncomingWebhookService(config.DB).Delete(uint(id)); err != nil {
+		writeWebhookError(w, err)
+		return
+	}
+	services.RecordAudit(nil, models.AuditEntry{
+		Actor:   adminActor(r),
+		Action:  "webhook_delete",
+		Details: fmt.Sprintf("id=%d", id),
+		Source:  models.EventSourceHTTP,
+	})
+	response.WriteJSON(w, http.StatusOK, map[string]any{"status": "deleted", "id": id})
+}
+
+func writeWebhookError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, services.ErrWebhookNotFound):
+		response.WriteErr(w, http.StatusNotFound, "Webhook no encontrado")
+	case errors.Is(err, services.ErrInvalidWebhook):
+		response.WriteErr(w, http.StatusBadRequest, err.Error())
+	default:
+		response.WriteErr(w, http.StatusInternalServerError, "No se pudo guardar el webhook")
+	}
+}
+
+// webhookAlert es el cuerpo JSON de un aviso: text se sintetiza con la voz del asistente
+type webhookAlert struct {
+	Text     string `json:"text"`
+	Priority string `json:"priority"`
+}
+
+// POST /integrations/webhooks/{token}
+// Publica un aviso en el canal del webhook. Acepta JSON {"text":"...","priority":"urgent"}
+// o el audio directamente (WAV, FLAC, Opus u WebM) con su Content-Type; en ese caso la
+// transcripción puede ir en X-Alert-Text.
+func WebhookAlert(w http.ResponseWriter, r *http.Request) {
+	if !requireDB(w) {
+		return
+	}
+
+	svc := services.NewIncomingWebhookService(config.DB)
+	hook, err := svc.FindByTokenHash(hashToken(strings.TrimSpace(r.PathValue("token"))))
+	if err != nil {
+		if !errors.Is(err, services.ErrWebhookNotFound) {
+			log.Printf("[WEBHOOK] error buscando webhook: %v", err)
+		}
+		response.WriteErr(w, http.StatusNotFound, "Webhook no encontrado")
+		return
+	}
+
+	if allowed, wait := WebhookLimiter.Allow(fmt.Sprintf("webhook:%d", hook.ID)); !allowed {
+		metrics.Inc("walkie_rate_limited_total", map[string]string{"route": "webhook"})
+		w.Header().Set("Retry-After", strconv.Itoa(max(1, int(wait.Seconds()+0.999))))
+		response.WriteErr(w, http.StatusTooManyRequests, "Demasiadas peticiones, inténtalo más tarde")
+		return
+	}
+
+	audioData, text, priority, status, message := readWebhookAlert(r, hook)
+	if status != 0 {
+		response.WriteErr(w, status, message)
+		return
+	}
+
+	recipients, err := announceOnChannel(hook.Channel, hook.Name, audioData, text, priority)
+	if err != nil {
+		log.Printf("[WEBHOOK] webhook=%d canal=%s error obteniendo miembros: %v", hook.ID, hook.Channel, err)
+		response.WriteErr(w, http.StatusInternalServerError, "No se pudo publicar el aviso")
+		return
+	}
+	if err := svc.MarkUsed(hook.ID, time.Now()); err != nil {
+		log.Printf("[WEBHOOK] webhook=%d error anotando uso: %v", hook.ID, err)
+	}
+	services.RecordAudit(nil, models.AuditEntry{
+		Actor:   "webhook:" + hook.Name,
+		Action:  "webhook_alert",
+		Channel: hook.Channel,
+		Details: fmt.Sprintf("id=%d priority=%s bytes=%d", hook.ID, priority, len(audioData)),
+		Source:  models.EventSourceHTTP,
+	})
+	metrics.Inc("walkie_webhook_alerts_total", map[string]string{"webhook": hook.Name})
+	log.Printf("[WEBHOOK] webhook=%d aviso emitido en canal=%s a %d usuarios", hook.ID, hook.Channel, recipients)
+
+	response.WriteJSON(w, http.StatusAccepted, map[string]any{
+		"status":     "sent",
+		"channel":    hook.Channel,
+		"priority":   priority,
+		"recipients": recipients,
+	})
+}
+
+// readWebhookAlert lee el aviso como JSON (sintetizando el texto) o como audio; status
+// distinto de cero indica el error que hay que responder
+func readWebhookAlert(r *http.Request, hook *models.IncomingWebhook) (audioData []byte, text, priority string, status int, message string) {
+	contentType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if contentType != "application/json" {
+		priority, ok := alertPriority(r.Header.Get("X-Alert-Priority"), hook.Priority)
+		if !ok {
+			return nil, "", "", http.StatusBadRequest, "X-Alert-Priority debe ser normal, urgent o emergency"
+		}
+		data, err := io.ReadAll(io.LimitReader(r.Body, maxAudioSize+1))
+		if err != nil || len(data) == 0 || len(data) > maxAudioSize || !validateAudioFormat(data, contentType) {
+			return nil, "", "", http.StatusBadRequest, "Audio inválido. Se requiere WAV, FLAC, Opus (Ogg) o WebM de hasta 10 MB"
+		}
+		return data, strings.TrimSpace(r.Header.Get("X-Alert-Text")), priority, 0, ""
+	}
+
+	var in webhookAlert
+	if err := json.NewDecoder(io.LimitReader(r.Body, 64*1024)).Decode(&in); err != nil {
+		return nil, "", "", http.StatusBadRequest, "JSON inválido"
+	}
+	text = strings.TrimSpace(in.Text)
+	if text == "" || len([]rune(text)) > maxWebhookText {
+		return nil, "", "", http.StatusBadRequest, fmt.Sprintf("text es obligatorio y admite hasta %d caracteres", maxWebhookText)
+	}
+	priority, ok := alertPriority(in.Priority, hook.Priority)
+	if !ok {
+		return nil, "", "", http.StatusBadRequest, "priority debe ser normal, urgent o emergency"
+	}
+	ctx, cancel := context.WithTimeout(r.Context(), announcementSynthTimeout)
+	defer cancel()
+	audioData, err := synthesizeSpeech(ctx, text)
+	if err != nil {
+		log.Printf("[WEBHOOK] webhook=%d error sintetizando aviso: %v", hook.ID, err)
+		return nil, "", "", http.StatusServiceUnavailable, "Síntesis de voz no disponible"
+	}
+	return audioData, text, priority, 0, ""
+}
@@ -0,0 +1,119 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"walkie-backend/internal/config"
+	"walkie-backend/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+func webhookAlertRequest(token, contentType string, body []byte) *http.Request {
+	req := httptest.NewRequest(http.MethodPost, webhookPathPrefix+token, bytes.NewReader(body))
+	req.Header.Set("Content-Type", contentType)
+	req.SetPathValue("token", token)
+	return req
+}
+
+func TestWebhooks_CreateAndPostAlert(t *testing.T) {
+	cleanup := setupChannelsTestDB(t)
+	defer cleanup()
+	t.Setenv("ADMIN_TOKEN", "secreto")
+	db := config.DB
+	require.NoError(t, db.AutoMigrate(&models.IncomingWebhook{}, &models.AuditEntry{}))
+
+	channel := models.Channel{Code: "alertas-1", Name: "Mantenimiento", MaxUsers: 10}
+	require.NoError(t, db.Create(&channel).Error)
+	listener := models.User{Model: gorm.Model{ID: 691}, DisplayName: "Lucía", IsActive: true, CurrentChannelID: &channel.ID}
+	require.NoError(t, db.Create(&listener).Error)
+	require.NoError(t, db.Create(&models.ChannelMembership{UserID: listener.ID, ChannelID: channel.ID, Active: true}).Error)
+	t.Cleanup(func() { ClearPendingAudio(listener.ID) })
+
+	origSynth := synthesizeSpeech
+	t.Cleanup(func() { synthesizeSpeech = origSynth })
+	wav := buildTestWAV(8000)
+	var spoken string
+	synthesizeSpeech = func(_ context.Context, text string) ([]byte, error) {
+		spoken = text
+		return wav, nil
+	}
+
+	rec := httptest.NewRecorder()
+	AdminWebhooks(rec, adminRequest(http.MethodPost, "/admin/webhooks", `{"name":"grafana","channel":"no-existe"}`))
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+	rec = httptest.NewRecorder()
+	AdminWebhooks(rec, adminRequest(http.MethodPost, "/admin/webhooks", `{"name":"grafana","channel":"Alertas-1","priority":"urgent"}`))
+	require.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+	var created webhookView
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &created))
+	require.NotEmpty(t, created.Token)
+	assert.Equal(t, webhookPathPrefix+created.Token, created.URL)
+	assert.Equal(t, "alertas-1", created.Channel)
+
+	rec = httptest.NewRecorder()
+	WebhookAlert(rec, webhookAlertRequest("no-existe", "application/json", []byte(`{"text":"hola"}`)))
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+
+	rec = httptest.NewRecorder()
+	WebhookAlert(rec, webhookAlertRequest(created.Token, "application/json", []byte(`{"text":""}`)))
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+	rec = httptest.NewRecorder()
+	WebhookAlert(rec, webhookAlertRequest(created.Token, "application/json", []byte(`{"text":"Compresor 3 fuera de servicio"}`)))
+	require.Equal(t, http.StatusAccepted, rec.Code, rec.Body.String())
+	assert.Equal(t, "Compresor 3 fuera de servicio", spoken)
+	clip := DequeueAudio(listener.ID)
+	require.NotNil(t, clip)
+	assert.Equal(t, AnnouncementSenderID, clip.SenderID)
+	assert.Equal(t, PriorityUrgent, clip.Priority, "sin priority se usa la del webhook")
+
+	// Audio directo con prioridad en cabecera
+	req := webhookAlertRequest(created.Token, "audio/wav", wav)
+	req.Header.Set("X-Alert-Priority", PriorityEmergency)
+	rec = httptest.NewRecorder()
+	WebhookAlert(rec, req)
+	require.Equal(t, http.StatusAccepted, rec.Code, rec.Body.String())
+	clip = DequeueAudio(listener.ID)
+	require.NotNil(t, clip)
+	assert.Equal(t, PriorityEmergency, clip.Priority)
+
+	rec = httptest.NewRecorder()
+	WebhookAlert(rec, webhookAlertRequest(created.Token, "audio/wav", []byte("no es audio")))
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+	synthesizeSpeech = func(context.Context, string) ([]byte, error) { return nil, errors.New("sin TTS") }
+	rec = httptest.NewRecorder()
+	WebhookAlert(rec, webhookAlertRequest(created.Token, "application/json", []byte(`{"text":"hola"}`)))
+	assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+
+	rec = httptest.NewRecorder()
+	AdminWebhooks(rec, adminRequest(http.MethodGet, "/admin/webhooks", ""))
+	require.Equal(t, http.StatusOK, rec.Code)
+	var listed []webhookView
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &listed))
+	require.Len(t, listed, 1)
+	assert.Equal(t, 2, listed[0].Uses)
+	assert.NotNil(t, listed[0].LastUsedAt)
+	assert.Empty(t, listed[0].Token, "el token no se vuelve a mostrar")
+
+	del := adminRequest(http.MethodDelete, fmt.Sprintf("/admin/webhooks/%d", created.ID), "")
+	del.SetPathValue("id", fmt.Sprint(created.ID))
+	rec = httptest.NewRecorder()
+	AdminWebhook(rec, del)
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	rec = httptest.NewRecorder()
+	WebhookAlert(rec, webhookAlertRequest(created.Token, "application/json", []byte(`{"text":"hola"}`)))
+	assert.Equal(t, http.StatusNotFound, rec.Code, "un webhook revocado deja de aceptar avisos")
+}
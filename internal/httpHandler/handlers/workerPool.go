@@ -0,0 +1,130 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"walkie-backend/internal/config"
+	"walkie-backend/internal/metrics"
+	"walkie-backend/internal/response"
+)
+
+const (
+	defaultIngestWorkers    = 8
+	defaultIngestQueueDepth = 32
+	defaultIngestQueueWait  = 10 * time.Second
+	// ingestRetryAfter es lo que se pide esperar al cliente cuando el pipeline está lleno
+	ingestRetryAfter = 2 * time.Second
+)
+
+// ErrIngestSaturated indica que no hay hueco en el pipeline ni en su cola de espera
+var ErrIngestSaturated = errors.New("pipeline de audio saturado")
+
+// workerPool limita cuántas peticiones usan a la vez STT y el LLM; las que no caben
+// esperan en una cola acotada y, si está llena o la espera se alarga, se rechazan
+type workerPool struct {
+	slots    chan struct{}
+	queued   atomic.Int64
+	maxQueue int64
+	maxWait  time.Duration
+}
+
+func newWorkerPool(workers, queueDepth int, maxWait time.Duration) *workerPool {
+	if workers <= 0 {
+		workers = defaultIngestWorkers
+	}
+	if maxWait <= 0 {
+		maxWait = defaultIngestQueueWait
+	}
+	return &workerPool{
+		slots:    make(chan struct{}, workers),
+		maxQueue: int64(queueDepth),
+		maxWait:  maxWait,
+	}
+}
+
+// ingestWorkers es el pool global: INGEST_WORKERS (8), INGEST_QUEUE_DEPTH (32) e INGEST_QUEUE_WAIT (10s)
+var ingestWorkers = sync.OnceValue(func() *workerPool {
+	return newWorkerPool(
+		config.IntFromEnv("INGEST_WORKERS", defaultIngestWorkers),
+		config.IntFromEnv("INGEST_QUEUE_DEPTH", defaultIngestQueueDepth),
+		config.DurationFromEnv("INGEST_QUEUE_WAIT", defaultIngestQueueWait),
+	)
+})
+
+// acquireIngestWorker reserva un hueco en el pool global
+func acquireIngestWorker(ctx context.Context) (func(), error) {
+	return ingestWorkers().Acquire(ctx)
+}
+
+// Acquire reserva un hueco y devuelve la función que lo libera (se puede llamar varias veces)
+func (p *workerPool) Acquire(ctx context.Context) (func(), error) {
+	select {
+	case p.slots <- struct{}{}:
+		return p.releaser(), nil
+	default:
+	}
+
+	if p.queued.Add(1) > p.maxQueue {
+		p.queued.Add(-1)
+		metrics.Inc("walkie_ingest_rejected_total", map[string]string{"reason": "queue_full"})
+		return nil, ErrIngestSaturated
+	}
+	p.reportDepth()
+	defer func() {
+		p.queued.Add(-1)
+		p.reportDepth()
+	}()
+
+	timer := time.NewTimer(p.maxWait)
+	defer timer.Stop()
+	select {
+	case p.slots <- struct{}{}:
+		return p.releaser(), nil
+	case <-timer.C:
+		metrics.Inc("walkie_ingest_rejected_total", map[string]string{"reason": "timeout"})
+		return nil, ErrIngestSaturated
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+func (p *workerPool) releaser() func() {
+	metrics.SetGauge("walkie_ingest_active", nil, float64(len(p.slots)))
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			<-p.slots
+			metrics.SetGauge("walkie_ingest_active", nil, float64(len(p.slots)))
+		})
+	}
+}
+
+func (p *workerPool) reportDepth() {
+	metrics.SetGauge("walkie_ingest_queued", nil, float64(p.queued.Load()))
+}
+
+// acquireWorkerStage reserva hueco para las llamadas a STT y al LLM; si el pipeline está
+// saturado responde 503 con Retry-After para que el cliente reintente
+func acquireWorkerStage(ctx context.Context, w http.ResponseWriter, deps audioIngestDeps, userID uint, tracker *stageTimer) (func(), bool) {
+	stageStart := time.Now()
+	release, err := deps.acquireWorker(ctx)
+	tracker.LogStage("worker_wait", stageStart, map[string]any{
+		"error": err != nil,
+	})
+	if err == nil {
+		return release, true
+	}
+
+	log.Printf("[PIPELINE] usuario=%d sin hueco para procesar audio: %v", userID, err)
+	w.Header().Set("Retry-After", strconv.Itoa(int(ingestRetryAfter.Seconds())))
+	response.WriteErr(w, http.StatusServiceUnavailable, "Servidor ocupado, inténtalo en unos segundos")
+	tracker.LogFinal("pipeline_saturated")
+	return nil, false
+}
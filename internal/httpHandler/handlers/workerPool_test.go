@@ -0,0 +1,82 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+
+	"walkie-backend/internal/models"
+)
+
+func TestWorkerPool_QueuesThenRejects(t *testing.T) {
+	pool := newWorkerPool(1, 1, 50*time.Millisecond)
+
+	release, err := pool.Acquire(context.Background())
+	require.NoError(t, err)
+
+	// El segundo espera en la cola y entra en cuanto se libera el hueco
+	acquired := make(chan error, 1)
+	go func() {
+		r, err := pool.Acquire(context.Background())
+		if err == nil {
+			defer r()
+		}
+		acquired <- err
+	}()
+	require.Eventually(t, func() bool { return pool.queued.Load() == 1 }, time.Second, time.Millisecond)
+
+	// Con la cola llena el tercero se rechaza sin esperar
+	_, err = pool.Acquire(context.Background())
+	assert.ErrorIs(t, err, ErrIngestSaturated)
+
+	release()
+	release()
+	assert.NoError(t, <-acquired)
+}
+
+func TestWorkerPool_TimesOutAndHonoursContext(t *testing.T) {
+	pool := newWorkerPool(1, 4, 20*time.Millisecond)
+	release, err := pool.Acquire(context.Background())
+	require.NoError(t, err)
+	defer release()
+
+	_, err = pool.Acquire(context.Background())
+	assert.ErrorIs(t, err, ErrIngestSaturated)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = pool.Acquire(ctx)
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Equal(t, int64(0), pool.queued.Load())
+}
+
+func TestRunAudioIngest_SaturatedPipelineReturns503(t *testing.T) {
+	user := &models.User{Model: gorm.Model{ID: 193}}
+	sttCalled := false
+	deps := newAudioIngestDeps()
+	deps.readUserID = func(*http.Request) (uint, error) { return user.ID, nil }
+	deps.newUserService = func() userService { return &mockUserService{user: user} }
+	deps.validateAudio = func([]byte, string) bool { return true }
+	deps.readAudio = func(*http.Request) ([]byte, string, error) { return buildTestWAV(8000), "audio/wav", nil }
+	deps.detectSpeech = func([]byte, string) (bool, bool) { return true, false }
+	deps.localSTT = func() sttClient { return nil }
+	deps.ensureSTT = func() (sttClient, error) {
+		sttCalled = true
+		return &mockSTT{text: "hola"}, nil
+	}
+	deps.acquireWorker = func(context.Context) (func(), error) { return nil, ErrIngestSaturated }
+
+	rec := httptest.NewRecorder()
+	runAudioIngest(rec, httptest.NewRequest(http.MethodPost, "/audio/ingest", bytes.NewReader(nil)), deps)
+
+	assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+	assert.Equal(t, "2", rec.Header().Get("Retry-After"))
+	assert.False(t, sttCalled, "no debe llamarse al STT sin hueco en el pipeline")
+}
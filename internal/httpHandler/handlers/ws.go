@@ -7,8 +7,13 @@ import (
 	"os"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
+	"walkie-backend/internal/config"
+	"walkie-backend/internal/metrics"
+	"walkie-backend/internal/services"
+
 	"github.com/gorilla/websocket"
 )
 
@@ -18,14 +23,31 @@ const (
 	pongWait       = 60 * time.Second
 	writeWait      = 10 * time.Second
 	maxMessageSize = 15 * 1024 * 1024
+	// floorMaxHold libera la palabra aunque no llegue el STOP (p. ej. si el proceso falla)
+	floorMaxHold = 90 * time.Second
+	// defaultWSSendQueue es la cola por cliente; si se llena, se descartan mensajes
+	defaultWSSendQueue = 256
 )
 
 type wsClient struct {
 	conn    *websocket.Conn
 	userID  uint
+	name    string
 	channel string
 	mu      sync.Mutex
-	send    chan []byte
+	// send es la única vía de escritura: writePump la vacía en la conexión
+	send       chan []byte
+	sendMu     sync.RWMutex
+	sendClosed bool
+	// dropped cuenta los mensajes descartados por tener la cola llena
+	dropped atomic.Uint64
+
+	// uploads recibe los clips enviados como frames binarios; nil los ignora
+	uploads   chan wsUpload
+	uploadSeq uint64
+
+	// lastSeen es el último pong o mensaje recibido (UnixNano); lo usa el supervisor
+	lastSeen atomic.Int64
 }
 
 var (
@@ -39,9 +61,12 @@ var (
 		sync.RWMutex
 		byUser    map[uint]*wsClient
 		byChannel map[string]map[uint]*wsClient
+		// floor indica quién tiene la palabra en cada canal (un hablante a la vez)
+		floor map[string]floorHold
 	}{
 		byUser:    make(map[uint]*wsClient),
 		byChannel: make(map[string]map[uint]*wsClient),
+		floor:     make(map[string]floorHold),
 	}
 
 	allowedOriginsOnce sync.Once
@@ -75,19 +100,7 @@ func checkWSOrigin(r *http.Request) bool {
 
 func getAllowedWSOrigins() []string {
 	allowedOriginsOnce.Do(func() {
-		raw := os.Getenv("ALLOWED_WS_ORIGINS")
-		if raw == "" {
-			allowedWSOrigins = []string{}
-			return
-		}
-
-		parts := strings.Split(raw, ",")
-		allowedWSOrigins = make([]string, 0, len(parts))
-		for _, part := range parts {
-			if trimmed := strings.TrimSpace(part); trimmed != "" {
-				allowedWSOrigins = append(allowedWSOrigins, trimmed)
-			}
-		}
+		allowedWSOrigins = parseOrigins(os.Getenv("ALLOWED_WS_ORIGINS"))
 	})
 	return allowedWSOrigins
 }
@@ -110,7 +123,15 @@ func HandleWebSocket(w http.ResponseWriter, r *http.Request) {
 	defer func() {
 		if client != nil {
 			removeClient(client)
-			close(client.send)
+			registry.RLock()
+			_, replaced := registry.byUser[client.userID]
+			registry.RUnlock()
+			if !replaced {
+				markResumeDisconnected(client.userID, client.channel, time.Now())
+			}
+			presence.Disconnect(client.userID)
+			client.closeSend()
+			close(client.uploads)
 		}
 		conn.Close()
 	}()
@@ -125,6 +146,8 @@ func HandleWebSocket(w http.ResponseWriter, r *http.Request) {
 		UserID  uint   `json:"userId"`
 		Channel string `json:"channel"`
 		Token   string `json:"token"`
+		// LastReceivedSeq es el último frame de audio recibido antes de reconectar
+		LastReceivedSeq *uint64 `json:"lastReceivedSeq"`
 	}
 	if err := json.Unmarshal(raw, &handshake); err != nil || handshake.UserID == 0 || strings.TrimSpace(handshake.Token) == "" {
 		_ = conn.WriteMessage(websocket.TextMessage, []byte("Handshake inválido"))
@@ -146,26 +169,29 @@ func HandleWebSocket(w http.ResponseWriter, r *http.Request) {
 	client = &wsClient{
 		conn:    conn,
 		userID:  user.ID,
+		name:    user.DisplayName,
 		channel: channel,
-		send:    make(chan []byte, 256),
+		send:    make(chan []byte, wsSendQueueSize()),
+		uploads: make(chan wsUpload, wsUploadQueue),
 	}
-	registerClient(client)
+	registerResumedClient(client, handshake.LastReceivedSeq)
+	presence.Connect(user.ID, user.DisplayName, channel)
 
 	log.Printf("Cliente WebSocket conectado: usuario=%d, canal=%s", user.ID, channel)
 
-	_ = conn.WriteJSON(map[string]string{
-		"message": "Conexión establecida",
-		"channel": channel,
-	})
-
 	go client.writePump()
+	go client.uploadWorker()
 	client.readPump()
 }
 
 func registerClient(c *wsClient) {
 	registry.Lock()
 	defer registry.Unlock()
+	registerClientUnsafe(c)
+}
 
+func registerClientUnsafe(c *wsClient) {
+	c.touch()
 	if oldClient, exists := registry.byUser[c.userID]; exists {
 		removeClientUnsafe(oldClient)
 	}
@@ -187,9 +213,13 @@ func removeClient(c *wsClient) {
 	removeClientUnsafe(c)
 }
 
+// removeClientUnsafe quita el cliente si sigue registrado; una conexión antigua que se
+// cierra tarde no debe quitar a la que la sustituyó al reconectar
 func removeClientUnsafe(c *wsClient) {
-	delete(registry.byUser, c.userID)
-	if c.channel != "" && registry.byChannel[c.channel] != nil {
+	if registry.byUser[c.userID] == c {
+		delete(registry.byUser, c.userID)
+	}
+	if c.channel != "" && registry.byChannel[c.channel] != nil && registry.byChannel[c.channel][c.userID] == c {
 		delete(registry.byChannel[c.channel], c.userID)
 		if len(registry.byChannel[c.channel]) == 0 {
 			delete(registry.byChannel, c.channel)
@@ -199,6 +229,8 @@ func removeClientUnsafe(c *wsClient) {
 }
 
 func moveClientToChannel(userID uint, newChannel string) {
+	defer presence.Move(userID, newChannel)
+	rememberMembership(userID, newChannel)
 	registry.Lock()
 	defer registry.Unlock()
 
@@ -219,7 +251,8 @@ func moveClientToChannel(userID uint, newChannel string) {
 		delete(registry.byUser, userID)
 		client.channel = ""
 		notifyChannelChange(client, "")
-		closeWebSocket(client)
+		// Cerrar la cola deja que writePump envíe el aviso antes de cerrar la conexión
+		client.closeSend()
 		log.Printf("Cliente desconectado: usuario=%d", userID)
 		return
 	}
@@ -235,22 +268,14 @@ func moveClientToChannel(userID uint, newChannel string) {
 }
 
 func notifyChannelChange(c *wsClient, channel string) {
-	if c == nil || c.conn == nil {
+	if c == nil {
 		return
 	}
-
-	payload := map[string]string{
+	payload, _ := json.Marshal(map[string]string{
 		"type":    "channel_changed",
 		"channel": channel,
-	}
-
-	c.mu.Lock()
-	err := c.conn.WriteJSON(payload)
-	c.mu.Unlock()
-
-	if err != nil {
-		log.Printf("Error notificando al usuario %d del cambio de canal: %v", c.userID, err)
-	}
+	})
+	c.enqueue(payload)
 }
 
 func closeWebSocket(c *wsClient) {
@@ -270,17 +295,25 @@ func (c *wsClient) readPump() {
 	c.conn.SetReadDeadline(time.Now().Add(pongWait))
 	c.conn.SetPongHandler(func(string) error {
 		c.conn.SetReadDeadline(time.Now().Add(pongWait))
+		c.touch()
 		return nil
 	})
 
 	for {
-		_, _, err := c.conn.ReadMessage()
+		msgType, data, err := c.conn.ReadMessage()
 		if err != nil {
 			if websocket.IsUnexpectedCloseError(err, websocket.CloseGoingAway, websocket.CloseAbnormalClosure) {
 				log.Printf("ws error user=%d: %v", c.userID, err)
 			}
 			break
 		}
+		c.touch()
+		switch msgType {
+		case websocket.BinaryMessage:
+			c.queueUpload(data)
+		case websocket.TextMessage:
+			c.handleTextFrame(data)
+		}
 	}
 }
 
@@ -294,56 +327,149 @@ func (c *wsClient) writePump() {
 	for {
 		select {
 		case message, ok := <-c.send:
-			c.conn.SetWriteDeadline(time.Now().Add(writeWait))
-			if !ok {
-				c.conn.WriteMessage(websocket.CloseMessage, []byte{})
+			if !c.writeQueued(message, ok) {
 				return
 			}
 
-			w, err := c.conn.NextWriter(websocket.BinaryMessage)
+		case <-ticker.C:
+			c.mu.Lock()
+			c.conn.SetWriteDeadline(time.Now().Add(writeWait))
+			err := c.conn.WriteMessage(websocket.PingMessage, nil)
+			c.mu.Unlock()
 			if err != nil {
 				return
 			}
-			if _, err := w.Write(message); err != nil {
-				return
-			}
-
-			n := len(c.send)
-			for i := 0; i < n; i++ {
-				if _, err := w.Write(<-c.send); err != nil {
-					return
-				}
-			}
+		}
+	}
+}
 
-			if err := w.Close(); err != nil {
-				return
-			}
+// writeQueued escribe el mensaje y los que ya esperan en la cola, cada uno en su frame
+func (c *wsClient) writeQueued(message []byte, ok bool) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
 
-		case <-ticker.C:
-			c.conn.SetWriteDeadline(time.Now().Add(writeWait))
-			if err := c.conn.WriteMessage(websocket.PingMessage, nil); err != nil {
-				return
-			}
+	for n := len(c.send); ; n-- {
+		c.conn.SetWriteDeadline(time.Now().Add(writeWait))
+		if !ok {
+			c.conn.WriteMessage(websocket.CloseMessage, []byte{})
+			return false
 		}
+		if err := c.conn.WriteMessage(frameType(message), message); err != nil {
+			return false
+		}
+		if n <= 0 {
+			return true
+		}
+		message, ok = <-c.send
 	}
 }
 
-func startTransmission(channel string, speakerID uint) {
-	registry.RLock()
-	defer registry.RUnlock()
+// frameType elige el tipo de frame: los mensajes de control son objetos JSON y el audio
+// (WAV, Ogg, WebM o el sobre cifrado) nunca empieza por '{'
+func frameType(msg []byte) int {
+	if len(msg) > 0 && msg[0] == '{' {
+		return websocket.TextMessage
+	}
+	return websocket.BinaryMessage
+}
+
+// enqueue deja el mensaje en la cola del cliente sin bloquear. Con la cola llena lo
+// descarta y lo cuenta, para que un cliente lento no retrase al resto del canal.
+func (c *wsClient) enqueue(msg []byte) bool {
+	c.sendMu.RLock()
+	defer c.sendMu.RUnlock()
+	if c.send == nil || c.sendClosed {
+		return false
+	}
+	select {
+	case c.send <- msg:
+		return true
+	default:
+	}
+
+	kind := "audio"
+	if frameType(msg) == websocket.TextMessage {
+		kind = "control"
+	}
+	metrics.Inc("walkie_ws_dropped_total", map[string]string{"kind": kind})
+	if n := c.dropped.Add(1); n == 1 || n%100 == 0 {
+		log.Printf("[WS] usuario=%d cola llena, mensajes descartados=%d", c.userID, n)
+	}
+	return false
+}
+
+// closeSend cierra la cola; writePump escribe lo pendiente y cierra la conexión
+func (c *wsClient) closeSend() {
+	c.sendMu.Lock()
+	defer c.sendMu.Unlock()
+	if c.send == nil || c.sendClosed {
+		return
+	}
+	c.sendClosed = true
+	close(c.send)
+}
+
+func wsSendQueueSize() int {
+	if n := config.IntFromEnv("WS_SEND_QUEUE", defaultWSSendQueue); n > 0 {
+		return n
+	}
+	return defaultWSSendQueue
+}
+
+type floorHold struct {
+	speakerID uint
+	since     time.Time
+	priority  string
+}
+
+// startTransmission da la palabra a speakerID y avisa al canal. Si otro usuario está
+// hablando devuelve su ID y false, y envía la señal BUSY sólo a quien intentó hablar.
+// Una emergencia interrumpe al hablante actual salvo que también sea una emergencia.
+func startTransmission(channel string, speakerID uint, priority string) (uint, bool) {
+	registry.Lock()
+	defer registry.Unlock()
+
+	now := time.Now()
+	hold, held := registry.floor[channel]
+	busy := held && hold.speakerID != speakerID && now.Sub(hold.since) < floorMaxHold
+	if busy && priority == PriorityEmergency && hold.priority != PriorityEmergency {
+		interruptTransmission(channel, hold.speakerID, speakerID)
+		busy = false
+	}
+	if busy {
+		log.Printf("Canal %s ocupado por usuario %d, rechazando a %d", channel, hold.speakerID, speakerID)
+		if c, ok := registry.byUser[speakerID]; ok {
+			msgBytes, _ := json.Marshal(map[string]interface{}{
+				"type":    "transmission",
+				"action":  "busy",
+				"signal":  "BUSY",
+				"channel": channel,
+				"from":    hold.speakerID,
+			})
+			deliverControl(c, msgBytes)
+		}
+		return hold.speakerID, false
+	}
+	registry.floor[channel] = floorHold{speakerID: speakerID, since: now, priority: priority}
+	if !held || hold.speakerID != speakerID {
+		services.EmitWebhookEvent(services.WebhookEventTransmissionStarted, channel, map[string]any{"userId": speakerID, "priority": priority})
+	}
 
 	clients := registry.byChannel[channel]
 	if len(clients) == 0 {
 		log.Printf("No hay clientes WebSocket en canal %s para iniciar transmisión", channel)
-		return
+		return speakerID, true
 	}
 
 	log.Printf("Iniciando transmisión en canal %s, hablante=%d", channel, speakerID)
 
 	message := map[string]interface{}{
-		"type":   "transmission",
-		"from":   speakerID,
-		"action": "start",
+		"type":       "transmission",
+		"from":       speakerID,
+		"action":     "start",
+		"enqueuedAt": time.Now().UTC().Format(time.RFC3339Nano),
+		"ageSeconds": 0,
+		"priority":   priority,
 	}
 
 	for id, c := range clients {
@@ -354,28 +480,43 @@ func startTransmission(channel string, speakerID uint) {
 		}
 
 		msgBytes, _ := json.Marshal(message)
-		if c.conn != nil {
-			c.mu.Lock()
-			err := c.conn.WriteMessage(websocket.TextMessage, msgBytes)
-			c.mu.Unlock()
-			if err != nil {
-				log.Printf("Error enviando señal START a usuario %d: %v", id, err)
-			}
-			continue
-		}
+		c.enqueue(msgBytes)
+	}
+	return speakerID, true
+}
 
-		if c.send != nil {
-			select {
-			case c.send <- msgBytes:
-			default:
-			}
-		}
+// scheduleStopTransmission libera la palabra cuando termina el clip; usa un temporizador
+// en vez de una goroutine dormida por cada transmisión
+func scheduleStopTransmission(channel string, speakerID uint, after time.Duration) {
+	time.AfterFunc(after, func() {
+		stopTransmission(channel, speakerID)
+	})
+}
+
+// refreshTransmission renueva la palabra de speakerID en transmisiones en directo que duran
+// más que floorMaxHold; si ya no la tiene no hace nada
+func refreshTransmission(channel string, speakerID uint) {
+	registry.Lock()
+	defer registry.Unlock()
+
+	if hold, ok := registry.floor[channel]; ok && hold.speakerID == speakerID {
+		hold.since = time.Now()
+		registry.floor[channel] = hold
 	}
 }
 
+// stopTransmission libera la palabra si la tenía speakerID y avisa al canal
 func stopTransmission(channel string, speakerID uint) {
-	registry.RLock()
-	defer registry.RUnlock()
+	registry.Lock()
+	defer registry.Unlock()
+
+	if hold, ok := registry.floor[channel]; ok {
+		if hold.speakerID != speakerID {
+			// Otro usuario tomó la palabra (p. ej. una emergencia); no cortarle
+			return
+		}
+		delete(registry.floor, channel)
+	}
 
 	clients := registry.byChannel[channel]
 	if len(clients) == 0 {
@@ -394,23 +535,8 @@ func stopTransmission(channel string, speakerID uint) {
 
 	msgBytes, _ := json.Marshal(message)
 
-	for id, c := range clients {
-		if c.conn != nil {
-			c.mu.Lock()
-			err := c.conn.WriteMessage(websocket.TextMessage, msgBytes)
-			c.mu.Unlock()
-			if err != nil {
-				log.Printf("Error enviando señal STOP a usuario %d: %v", id, err)
-			}
-			continue
-		}
-
-		if c.send != nil {
-			select {
-			case c.send <- msgBytes:
-			default:
-			}
-		}
+	for _, c := range clients {
+		c.enqueue(msgBytes)
 	}
 }
 
@@ -419,9 +545,24 @@ func broadcastAudio(channel string, senderID uint, audio []byte) {
 		log.Printf("Audio demasiado grande: %d bytes (max: %d)", len(audio), maxAudioSize)
 		return
 	}
+	feedChannelListeners(channel, audio)
+
+	muted := mutedRecipients(channel, senderID)
+	dnd := dndInChannel(channel)
 
 	registry.RLock()
 	defer registry.RUnlock()
+	// Quien tiene no molestar no recibe el audio en directo, salvo las emergencias;
+	// le queda en la cola
+	if hold, ok := registry.floor[channel]; len(dnd) > 0 && !(ok && hold.speakerID == senderID && hold.priority == PriorityEmergency) {
+		if muted == nil {
+			muted = make(map[uint]bool, len(dnd))
+		}
+		for id := range dnd {
+			muted[id] = true
+		}
+	}
+	recordGapFrames(channel, senderID, audio, muted)
 
 	clients := registry.byChannel[channel]
 	if len(clients) == 0 {
@@ -432,21 +573,49 @@ func broadcastAudio(channel string, senderID uint, audio []byte) {
 	log.Printf("Broadcasting audio en canal %s desde usuario %d a %d clientes", channel, senderID, len(clients))
 
 	for id, c := range clients {
-		if c.conn != nil {
-			c.mu.Lock()
-			err := c.conn.WriteMessage(websocket.BinaryMessage, audio)
-			c.mu.Unlock()
-			if err != nil {
-				log.Printf("Error enviando audio a usuario %d en canal %s: %v", id, channel, err)
-			}
+		if muted[id] {
 			continue
 		}
+		recordResumeFrame(id, audio)
+		c.enqueue(audio)
+	}
+}
 
-		if c.send != nil {
-			select {
-			case c.send <- audio:
-			default:
-			}
-		}
+// interruptTransmission avisa a todo el canal de que una emergencia corta al hablante
+// actual. Debe llamarse con registry bloqueado.
+func interruptTransmission(channel string, interruptedID, emergencyID uint) {
+	log.Printf("[EMERGENCIA] canal=%s usuario=%d interrumpe a usuario=%d", channel, emergencyID, interruptedID)
+	msgBytes, _ := json.Marshal(map[string]interface{}{
+		"type":        "transmission",
+		"action":      "interrupt",
+		"signal":      "STOP",
+		"channel":     channel,
+		"from":        emergencyID,
+		"interrupted": interruptedID,
+		"priority":    PriorityEmergency,
+	})
+	for _, c := range registry.byChannel[channel] {
+		deliverControl(c, msgBytes)
+	}
+}
+
+// deliverControl envía un mensaje de control a la cola del cliente
+func deliverControl(c *wsClient, msg []byte) {
+	c.enqueue(msg)
+}
+
+// sendJSONToUser envía un mensaje de control al WebSocket del usuario si está conectado
+func sendJSONToUser(userID uint, payload any) bool {
+	registry.RLock()
+	c, ok := registry.byUser[userID]
+	registry.RUnlock()
+	if !ok || c == nil {
+		return false
+	}
+	msg, err := json.Marshal(payload)
+	if err != nil {
+		log.Printf("Error enviando mensaje a usuario %d: %v", userID, err)
+		return false
 	}
+	return c.enqueue(msg)
 }
@@ -0,0 +1,139 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"strings"
+	"sync/atomic"
+
+	"walkie-backend/pkg/audio"
+)
+
+// wsUploadQueue es cuántos clips puede tener en espera un cliente WebSocket
+const wsUploadQueue = 2
+
+// wsUpload es un clip recibido como frame binario por el WebSocket
+type wsUpload struct {
+	seq  uint64
+	data []byte
+}
+
+// wsIngestResult es la respuesta al clip, equivalente a la de POST /audio/ingest
+type wsIngestResult struct {
+	Type       string          `json:"type"`
+	Seq        uint64          `json:"seq"`
+	Status     int             `json:"status"`
+	Data       json.RawMessage `json:"data,omitempty"`
+	Error      string          `json:"error,omitempty"`
+	RetryAfter int             `json:"retry_after,omitempty"`
+}
+
+// bufferedResponse captura la respuesta del pipeline HTTP para reenviarla por el socket
+type bufferedResponse struct {
+	header http.Header
+	status int
+	body   bytes.Buffer
+}
+
+func newBufferedResponse() *bufferedResponse {
+	return &bufferedResponse{header: make(http.Header)}
+}
+
+func (b *bufferedResponse) Header() http.Header { return b.header }
+
+func (b *bufferedResponse) Write(p []byte) (int, error) {
+	if b.status == 0 {
+		b.status = http.StatusOK
+	}
+	return b.body.Write(p)
+}
+
+func (b *bufferedResponse) WriteHeader(status int) {
+	if b.status == 0 {
+		b.status = status
+	}
+}
+
+// queueUpload encola un clip sin bloquear readPump; responde de inmediato si el
+// usuario supera el límite o ya tiene demasiados clips en proceso
+func (c *wsClient) queueUpload(data []byte) {
+	if c.uploads == nil {
+		return
+	}
+	seq := atomic.AddUint64(&c.uploadSeq, 1)
+
+	if allowed, wait := IngestLimiter.Allow(fmt.Sprintf("user:%d", c.userID)); !allowed {
+		c.writeJSON(wsIngestResult{
+			Type:       "ingest_result",
+			Seq:        seq,
+			Status:     http.StatusTooManyRequests,
+			Error:      "Demasiadas peticiones, inténtalo más tarde",
+			RetryAfter: int(math.Max(1, math.Ceil(wait.Seconds()))),
+		})
+		return
+	}
+
+	select {
+	case c.uploads <- wsUpload{seq: seq, data: data}:
+	default:
+		c.writeJSON(wsIngestResult{
+			Type:   "ingest_result",
+			Seq:    seq,
+			Status: http.StatusServiceUnavailable,
+			Error:  "Hay otro audio en proceso",
+		})
+	}
+}
+
+// uploadWorker procesa los clips del cliente en orden, de uno en uno
+func (c *wsClient) uploadWorker() {
+	for upload := range c.uploads {
+		result := runWSIngest(c.userID, upload.data, newAudioIngestDeps())
+		result.Seq = upload.seq
+		c.writeJSON(result)
+	}
+}
+
+// runWSIngest pasa el clip por el mismo pipeline que POST /audio/ingest (STT, intención,
+// comandos y difusión) usando el usuario ya autenticado en el handshake
+func runWSIngest(userID uint, data []byte, deps audioIngestDeps) wsIngestResult {
+	req, err := http.NewRequestWithContext(context.Background(), http.MethodPost, "/audio/ingest", bytes.NewReader(data))
+	if err != nil {
+		return wsIngestResult{Type: "ingest_result", Status: http.StatusInternalServerError, Error: err.Error()}
+	}
+	req.Header.Set("Content-Type", audio.MimeType(audio.Detect(data)))
+	deps.readUserID = func(*http.Request) (uint, error) { return userID, nil }
+
+	rw := newBufferedResponse()
+	runAudioIngest(rw, req, deps)
+
+	result := wsIngestResult{Type: "ingest_result", Status: rw.status}
+	if result.Status == 0 {
+		result.Status = http.StatusOK
+	}
+	body := bytes.TrimSpace(rw.body.Bytes())
+	switch {
+	case len(body) == 0:
+	case json.Valid(body):
+		result.Data = json.RawMessage(body)
+	default:
+		result.Error = strings.TrimSpace(string(body))
+	}
+	log.Printf("[WS_INGEST] usuario=%d estado=%d bytes=%d", userID, result.Status, len(data))
+	return result
+}
+
+// writeJSON encola un mensaje de control para el socket del cliente
+func (c *wsClient) writeJSON(payload any) {
+	msg, err := json.Marshal(payload)
+	if err != nil {
+		log.Printf("Error enviando mensaje a usuario %d: %v", c.userID, err)
+		return
+	}
+	c.enqueue(msg)
+}
@@ -0,0 +1,68 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"walkie-backend/internal/ai"
+	"walkie-backend/internal/models"
+
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+func TestRunWSIngest_ReturnsCommandResponse(t *testing.T) {
+	mockUser := &models.User{Model: gorm.Model{ID: 71}, DisplayName: "ws"}
+	deps := newAudioIngestDeps()
+	deps.newUserService = func() userService { return &mockUserService{user: mockUser} }
+	deps.localSTT = func() sttClient { return nil }
+	deps.ensureSTT = func() (sttClient, error) { return &mockSTT{text: "dame la lista de canales"}, nil }
+	deps.ensureAI = func() (ai.Analyzer, error) {
+		return &mockQwen{result: ai.CommandResult{IsCommand: true, Intent: "request_channel_list"}}, nil
+	}
+	deps.executeCommand = func(*models.User, userService, ai.CommandResult) (CommandResponse, error) {
+		return CommandResponse{Status: "ok", Intent: "request_channel_list", Message: "Canales: 1"}, nil
+	}
+
+	result := runWSIngest(71, buildTestWAV(3200), deps)
+
+	assert.Equal(t, "ingest_result", result.Type)
+	assert.Equal(t, http.StatusOK, result.Status)
+	var resp CommandResponse
+	require.NoError(t, json.Unmarshal(result.Data, &resp))
+	assert.Equal(t, "Canales: 1", resp.Message)
+}
+
+func TestHandleWebSocket_BinaryFrameRunsIngest(t *testing.T) {
+	db := setupTestDB(t)
+	user := createTestUser(t, db, 72, "token-ws-ingest", "")
+
+	s := httptest.NewServer(http.HandlerFunc(HandleWebSocket))
+	defer s.Close()
+
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(s.URL, "http"), nil)
+	require.NoError(t, err)
+	defer conn.Close()
+
+	handshake, _ := json.Marshal(map[string]any{"userId": user.ID, "token": user.AuthToken})
+	require.NoError(t, conn.WriteMessage(websocket.TextMessage, handshake))
+	_, _, err = conn.ReadMessage()
+	require.NoError(t, err)
+
+	// No es audio: el pipeline lo rechaza y la respuesta vuelve por el socket
+	require.NoError(t, conn.WriteMessage(websocket.BinaryMessage, []byte("esto no es audio")))
+
+	_ = conn.SetReadDeadline(time.Now().Add(3 * time.Second))
+	var result wsIngestResult
+	require.NoError(t, conn.ReadJSON(&result))
+	assert.Equal(t, "ingest_result", result.Type)
+	assert.Equal(t, uint64(1), result.Seq)
+	assert.Equal(t, http.StatusBadRequest, result.Status)
+	assert.Contains(t, result.Error, "Formato de audio inválido")
+}
@@ -0,0 +1,185 @@
+package handlers
+
+import (
+	"walkie-backend/internal/config"
+
+	"encoding/json"
+	"log"
+	"sync"
+	"time"
+)
+
+const (
+	defaultWSResumeBuffer = 32
+	defaultWSResumeWindow = 60 * time.Second
+)
+
+// resumeFrame es un frame de audio ya numerado para poder reenviarlo al reconectar
+type resumeFrame struct {
+	seq  uint64
+	data []byte
+}
+
+// resumeBuffer guarda los últimos frames de audio de un usuario. Mientras está
+// desconectado (disconnectedAt no es cero) sigue acumulando el audio de su canal durante
+// WS_RESUME_WINDOW para entregárselo si vuelve.
+type resumeBuffer struct {
+	channel        string
+	seq            uint64
+	frames         []resumeFrame
+	disconnectedAt time.Time
+}
+
+var resumeBuffers = struct {
+	sync.Mutex
+	byUser map[uint]*resumeBuffer
+}{
+	byUser: make(map[uint]*resumeBuffer),
+}
+
+func wsResumeBufferSize() int {
+	return config.IntFromEnv("WS_RESUME_BUFFER", defaultWSResumeBuffer)
+}
+
+// add numera el frame y lo guarda descartando los más antiguos
+func (b *resumeBuffer) add(data []byte, size int) {
+	b.seq++
+	if size <= 0 {
+		b.frames = nil
+		return
+	}
+	b.frames = append(b.frames, resumeFrame{seq: b.seq, data: data})
+	if extra := len(b.frames) - size; extra > 0 {
+		b.frames = append(b.frames[:0:0], b.frames[extra:]...)
+	}
+}
+
+// recordResumeFrame numera un frame de audio enviado al WebSocket del usuario
+func recordResumeFrame(userID uint, data []byte) {
+	resumeBuffers.Lock()
+	defer resumeBuffers.Unlock()
+
+	b := resumeBuffers.byUser[userID]
+	if b == nil {
+		b = &resumeBuffer{}
+		resumeBuffers.byUser[userID] = b
+	}
+	b.add(data, wsResumeBufferSize())
+}
+
+// recordGapFrames guarda el audio del canal para los usuarios que se desconectaron hace
+// menos de WS_RESUME_WINDOW, salvo el emisor y quienes lo tienen silenciado
+func recordGapFrames(channel string, senderID uint, data []byte, muted map[uint]bool) {
+	window := config.DurationFromEnv("WS_RESUME_WINDOW", defaultWSResumeWindow)
+	size := wsResumeBufferSize()
+	now := time.Now()
+
+	resumeBuffers.Lock()
+	defer resumeBuffers.Unlock()
+	for userID, b := range resumeBuffers.byUser {
+		if b.disconnectedAt.IsZero() || b.channel != channel || userID == senderID || muted[userID] {
+			continue
+		}
+		if now.Sub(b.disconnectedAt) > window {
+			continue
+		}
+		b.add(data, size)
+	}
+}
+
+// markResumeDisconnected empieza a acumular el audio que el usuario se pierde
+func markResumeDisconnected(userID uint, channel string, now time.Time) {
+	resumeBuffers.Lock()
+	defer resumeBuffers.Unlock()
+
+	b := resumeBuffers.byUser[userID]
+	if b == nil {
+		b = &resumeBuffer{}
+		resumeBuffers.byUser[userID] = b
+	}
+	b.channel = channel
+	b.disconnectedAt = now
+}
+
+// resumeFrames marca al usuario como conectado en channel y devuelve los frames posteriores
+// a lastSeq que siguen guardados, cuántos se perdieron por no caber y el número de secuencia
+// anterior al primer frame que recibirá esta conexión. Sin lastSeq, o con 0 (sesión nueva),
+// no se reenvía nada.
+func resumeFrames(userID uint, channel string, lastSeq *uint64) ([]resumeFrame, uint64, uint64) {
+	resumeBuffers.Lock()
+	defer resumeBuffers.Unlock()
+
+	b := resumeBuffers.byUser[userID]
+	if b == nil {
+		b = &resumeBuffer{}
+		resumeBuffers.byUser[userID] = b
+	}
+	sameChannel := b.channel == channel
+	b.channel = channel
+	b.disconnectedAt = time.Time{}
+
+	if lastSeq == nil || *lastSeq == 0 || *lastSeq >= b.seq || !sameChannel {
+		return nil, 0, b.seq
+	}
+
+	var replay []resumeFrame
+	for _, f := range b.frames {
+		if f.seq > *lastSeq {
+			replay = append(replay, f)
+		}
+	}
+	if len(replay) == 0 {
+		return nil, b.seq - *lastSeq, b.seq
+	}
+	first := replay[0].seq
+	return replay, first - 1 - *lastSeq, first - 1
+}
+
+// pruneResumeBuffers olvida los búferes de usuarios que no volvieron a tiempo
+func pruneResumeBuffers(now time.Time) {
+	window := config.DurationFromEnv("WS_RESUME_WINDOW", defaultWSResumeWindow)
+
+	resumeBuffers.Lock()
+	defer resumeBuffers.Unlock()
+	for userID, b := range resumeBuffers.byUser {
+		if !b.disconnectedAt.IsZero() && now.Sub(b.disconnectedAt) > window {
+			delete(resumeBuffers.byUser, userID)
+		}
+	}
+}
+
+// registerResumedClient registra el cliente, le envía el saludo y el mensaje "resume" con
+// el número de secuencia, y reenvía el audio que se perdió. Todo ocurre con registry bloqueado para que ningún
+// audio nuevo se cuele entre el saludo y los frames reenviados.
+func registerResumedClient(c *wsClient, lastSeq *uint64) {
+	registry.Lock()
+	defer registry.Unlock()
+	registerClientUnsafe(c)
+
+	replay, missed, seq := resumeFrames(c.userID, c.channel, lastSeq)
+	if len(replay) > 0 || missed > 0 {
+		log.Printf("[WS_RESUME] usuario=%d reenviados=%d perdidos=%d", c.userID, len(replay), missed)
+	}
+
+	welcome, _ := json.Marshal(map[string]string{
+		"message": "Conexión establecida",
+		"channel": c.channel,
+	})
+	c.enqueue(welcome)
+	if lastSeq == nil {
+		// Cliente sin soporte de reanudación: el protocolo no cambia
+		return
+	}
+	// seq es el número anterior al primer frame binario de audio que llegará; el cliente
+	// cuenta los frames binarios a partir de ahí
+	resume, _ := json.Marshal(map[string]any{
+		"type":     "resume",
+		"seq":      seq,
+		"replayed": len(replay),
+		"missed":   missed,
+	})
+	c.enqueue(resume)
+	for _, f := range replay {
+		c.enqueue(f.data)
+	}
+}
@@ -0,0 +1,107 @@
+package handlers
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func forgetResume(userIDs ...uint) {
+	resumeBuffers.Lock()
+	defer resumeBuffers.Unlock()
+	for _, id := range userIDs {
+		delete(resumeBuffers.byUser, id)
+	}
+}
+
+func TestRegisterResumedClient_ReplaysGapFrames(t *testing.T) {
+	const channel = "resume-1"
+	defer forgetResume(630, 631)
+
+	first := &wsClient{userID: 630, channel: channel, send: make(chan []byte, 8)}
+	registerResumedClient(first, nil)
+	broadcastAudio(channel, 631, []byte("clip-1"))
+	require.Equal(t, 2, len(first.send), "saludo y clip")
+
+	// Se cae la conexión y el canal sigue hablando
+	removeClient(first)
+	markResumeDisconnected(630, channel, time.Now())
+	broadcastAudio(channel, 631, []byte("clip-2"))
+	broadcastAudio(channel, 631, []byte("clip-3"))
+
+	second := &wsClient{userID: 630, channel: channel, send: make(chan []byte, 8)}
+	last := uint64(1)
+	registerResumedClient(second, &last)
+	defer removeClient(second)
+
+	<-second.send // saludo
+	var resume struct {
+		Type     string `json:"type"`
+		Seq      uint64 `json:"seq"`
+		Replayed int    `json:"replayed"`
+		Missed   uint64 `json:"missed"`
+	}
+	require.NoError(t, json.Unmarshal(<-second.send, &resume))
+	assert.Equal(t, "resume", resume.Type)
+	assert.Equal(t, uint64(1), resume.Seq)
+	assert.Equal(t, 2, resume.Replayed)
+	assert.Zero(t, resume.Missed)
+	assert.Equal(t, []byte("clip-2"), <-second.send)
+	assert.Equal(t, []byte("clip-3"), <-second.send)
+
+	broadcastAudio(channel, 631, []byte("clip-4"))
+	assert.Equal(t, []byte("clip-4"), <-second.send, "tras reconectar ya no se acumula como hueco")
+}
+
+func TestResumeFrames_ReportsEvictedFrames(t *testing.T) {
+	t.Setenv("WS_RESUME_BUFFER", "2")
+	defer forgetResume(632)
+
+	for _, clip := range []string{"a", "b", "c", "d"} {
+		recordResumeFrame(632, []byte(clip))
+	}
+	last := uint64(1)
+	replay, missed, seq := resumeFrames(632, "", &last)
+	require.Len(t, replay, 2)
+	assert.Equal(t, []byte("c"), replay[0].data)
+	assert.Equal(t, uint64(1), missed, "el frame 2 ya no estaba")
+	assert.Equal(t, uint64(2), seq)
+
+	fresh := uint64(0)
+	replay, _, seq = resumeFrames(632, "", &fresh)
+	assert.Empty(t, replay, "0 es una sesión nueva")
+	assert.Equal(t, uint64(4), seq)
+}
+
+func TestPruneResumeBuffers_DropsExpiredGaps(t *testing.T) {
+	t.Setenv("WS_RESUME_WINDOW", "1s")
+	defer forgetResume(633)
+
+	markResumeDisconnected(633, "resume-2", time.Now().Add(-2*time.Second))
+	broadcastAudio("resume-2", 1, []byte("tarde"))
+	resumeBuffers.Lock()
+	assert.Zero(t, resumeBuffers.byUser[633].seq, "fuera de la ventana no se acumula")
+	resumeBuffers.Unlock()
+
+	pruneResumeBuffers(time.Now())
+	resumeBuffers.Lock()
+	assert.NotContains(t, resumeBuffers.byUser, uint(633))
+	resumeBuffers.Unlock()
+}
+
+func TestRemoveClient_KeepsReplacement(t *testing.T) {
+	old := &wsClient{userID: 634, channel: "resume-3", send: make(chan []byte, 1)}
+	replacement := &wsClient{userID: 634, channel: "resume-3", send: make(chan []byte, 1)}
+	registerClient(old)
+	registerClient(replacement)
+	defer removeClient(replacement)
+
+	removeClient(old)
+	registry.RLock()
+	defer registry.RUnlock()
+	assert.Equal(t, replacement, registry.byUser[634])
+	assert.Equal(t, replacement, registry.byChannel["resume-3"][634])
+}
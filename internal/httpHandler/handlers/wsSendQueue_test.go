@@ -0,0 +1,86 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBroadcastAudio_SlowClientDropsWithoutBlocking(t *testing.T) {
+	const channel = "send-queue-1"
+	slow := &wsClient{userID: 683, channel: channel, send: make(chan []byte, 1)}
+	fast := &wsClient{userID: 684, channel: channel, send: make(chan []byte, 4)}
+	registerClient(slow)
+	registerClient(fast)
+	t.Cleanup(func() {
+		removeClient(slow)
+		removeClient(fast)
+	})
+	slow.send <- []byte("pendiente")
+
+	done := make(chan struct{})
+	go func() {
+		broadcastAudio(channel, 1, []byte("RIFF audio"))
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("broadcast blocked on a full client queue")
+	}
+
+	assert.Equal(t, []byte("RIFF audio"), <-fast.send)
+	assert.Equal(t, uint64(1), slow.dropped.Load())
+	assert.Equal(t, uint64(0), fast.dropped.Load())
+
+	slow.closeSend()
+	slow.closeSend()
+	assert.False(t, slow.enqueue([]byte("{}")), "una cola cerrada no acepta mensajes")
+}
+
+func TestWritePump_FrameTypes(t *testing.T) {
+	type frame struct {
+		msgType int
+		data    string
+	}
+	received := make(chan frame, 4)
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		require.NoError(t, err)
+		defer conn.Close()
+		for {
+			msgType, data, err := conn.ReadMessage()
+			if err != nil {
+				return
+			}
+			received <- frame{msgType, string(data)}
+		}
+	}))
+	defer s.Close()
+
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(s.URL, "http"), nil)
+	require.NoError(t, err)
+	client := &wsClient{conn: conn, userID: 685, send: make(chan []byte, 4)}
+	client.enqueue([]byte(`{"type":"transmission"}`))
+	client.enqueue([]byte("RIFF audio"))
+	go client.writePump()
+
+	for _, want := range []frame{
+		{websocket.TextMessage, `{"type":"transmission"}`},
+		{websocket.BinaryMessage, "RIFF audio"},
+	} {
+		select {
+		case got := <-received:
+			assert.Equal(t, want, got)
+		case <-time.After(time.Second):
+			t.Fatalf("missing frame %q", want.data)
+		}
+	}
+	client.closeSend()
+}
@@ -0,0 +1,175 @@
+package handlers
+
+import (
+	"log"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"walkie-backend/internal/config"
+	"walkie-backend/internal/metrics"
+	"walkie-backend/internal/response"
+)
+
+// defaultWSStaleAfter da margen a un ping sin respuesta antes de echar al cliente
+const defaultWSStaleAfter = pongWait + pingInterval
+
+var (
+	wsSupervisorOnce sync.Once
+
+	// channelGauges recuerda los canales publicados para ponerlos a 0 cuando se vacían
+	channelGauges = struct {
+		sync.Mutex
+		seen map[string]bool
+	}{
+		seen: make(map[string]bool),
+	}
+)
+
+// touch anota que el cliente sigue vivo (pong o cualquier mensaje recibido)
+func (c *wsClient) touch() {
+	c.lastSeen.Store(time.Now().UnixNano())
+}
+
+func (c *wsClient) idle(now time.Time) time.Duration {
+	seen := c.lastSeen.Load()
+	if seen == 0 {
+		return 0
+	}
+	return now.Sub(time.Unix(0, seen))
+}
+
+// StartWSSupervisor revisa el registro cada WS_SUPERVISOR_INTERVAL (30s): echa a los
+// clientes que llevan WS_STALE_AFTER sin responder a los pings y publica cuántos hay por canal
+func StartWSSupervisor() {
+	wsSupervisorOnce.Do(func() {
+		interval := config.DurationFromEnv("WS_SUPERVISOR_INTERVAL", pingInterval)
+		go func() {
+			ticker := time.NewTicker(interval)
+			defer ticker.Stop()
+			for range ticker.C {
+				superviseClients(time.Now())
+			}
+		}()
+		log.Printf("[WS] supervisor cada %s", interval)
+	})
+}
+
+// superviseClients echa a los clientes sin señales de vida y actualiza las métricas;
+// devuelve cuántos echó
+func superviseClients(now time.Time) int {
+	staleAfter := config.DurationFromEnv("WS_STALE_AFTER", defaultWSStaleAfter)
+
+	registry.Lock()
+	var stale []*wsClient
+	for _, c := range registry.byUser {
+		if c.idle(now) > staleAfter {
+			stale = append(stale, c)
+		}
+	}
+	for _, c := range stale {
+		removeClientUnsafe(c)
+	}
+	counts := channelCounts()
+	total := len(registry.byUser)
+	registry.Unlock()
+
+	for _, c := range stale {
+		log.Printf("[WS] usuario=%d canal=%s expulsado tras %s sin pong", c.userID, c.channel, c.idle(now).Round(time.Second))
+		metrics.Inc("walkie_ws_evicted_total", nil)
+		closeWebSocket(c)
+	}
+	publishRegistryMetrics(total, counts)
+	return len(stale)
+}
+
+// channelCounts cuenta los clientes de cada canal; debe llamarse con registry bloqueado
+func channelCounts() map[string]int {
+	counts := make(map[string]int, len(registry.byChannel))
+	for channel, clients := range registry.byChannel {
+		counts[channel] = len(clients)
+	}
+	return counts
+}
+
+func publishRegistryMetrics(total int, counts map[string]int) {
+	metrics.SetGauge("walkie_ws_clients", nil, float64(total))
+
+	channelGauges.Lock()
+	defer channelGauges.Unlock()
+	for channel := range channelGauges.seen {
+		if _, ok := counts[channel]; !ok {
+			metrics.SetGauge("walkie_ws_channel_clients", map[string]string{"channel": channel}, 0)
+			delete(channelGauges.seen, channel)
+		}
+	}
+	for channel, n := range counts {
+		metrics.SetGauge("walkie_ws_channel_clients", map[string]string{"channel": channel}, float64(n))
+		channelGauges.seen[channel] = true
+	}
+}
+
+// wsSlowClient es un cliente al que se le descartaron mensajes por tener la cola llena
+type wsSlowClient struct {
+	UserID  uint   `json:"user_id"`
+	Dropped uint64 `json:"dropped"`
+	Queued  int    `json:"queued"`
+}
+
+// maxSlowClients limita los clientes lentos que lista /admin/ws-stats
+const maxSlowClients = 10
+
+type wsChannelStats struct {
+	Channel string `json:"channel"`
+	Clients int    `json:"clients"`
+	Speaker uint   `json:"speaker,omitempty"`
+}
+
+// GET /admin/ws-stats
+// Estado del registro de WebSockets de esta réplica
+func AdminWSStats(w http.ResponseWriter, r *http.Request) {
+	if !requireAdmin(w, r) {
+		return
+	}
+
+	now := time.Now()
+	registry.RLock()
+	total := len(registry.byUser)
+	channels := make([]wsChannelStats, 0, len(registry.byChannel))
+	for channel, clients := range registry.byChannel {
+		stats := wsChannelStats{Channel: channel, Clients: len(clients)}
+		if hold, ok := registry.floor[channel]; ok {
+			stats.Speaker = hold.speakerID
+		}
+		channels = append(channels, stats)
+	}
+	var maxIdle time.Duration
+	var dropped uint64
+	slowest := make([]wsSlowClient, 0)
+	for _, c := range registry.byUser {
+		if idle := c.idle(now); idle > maxIdle {
+			maxIdle = idle
+		}
+		if n := c.dropped.Load(); n > 0 {
+			dropped += n
+			slowest = append(slowest, wsSlowClient{UserID: c.userID, Dropped: n, Queued: len(c.send)})
+		}
+	}
+	registry.RUnlock()
+
+	sort.Slice(channels, func(i, j int) bool { return channels[i].Channel < channels[j].Channel })
+	sort.Slice(slowest, func(i, j int) bool { return slowest[i].Dropped > slowest[j].Dropped })
+	if len(slowest) > maxSlowClients {
+		slowest = slowest[:maxSlowClients]
+	}
+	response.WriteJSON(w, http.StatusOK, map[string]any{
+		"clients":           total,
+		"channels":          channels,
+		"maxIdleSeconds":    maxIdle.Seconds(),
+		"staleAfterSeconds": config.DurationFromEnv("WS_STALE_AFTER", defaultWSStaleAfter).Seconds(),
+		"evicted":           metrics.Default().Counter("walkie_ws_evicted_total", nil),
+		"dropped":           dropped,
+		"slowClients":       slowest,
+	})
+}
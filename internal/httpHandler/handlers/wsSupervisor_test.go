@@ -0,0 +1,70 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"walkie-backend/internal/metrics"
+)
+
+func TestSuperviseClients_EvictsStaleAndPublishesGauges(t *testing.T) {
+	const channel = "supervisor-1"
+	stale := &wsClient{userID: 640, channel: channel, send: make(chan []byte, 1)}
+	alive := &wsClient{userID: 641, channel: channel, send: make(chan []byte, 1)}
+	registerClient(stale)
+	registerClient(alive)
+	defer removeClient(alive)
+	stale.lastSeen.Store(time.Now().Add(-2 * defaultWSStaleAfter).UnixNano())
+
+	before := metrics.Default().Counter("walkie_ws_evicted_total", nil)
+	assert.Equal(t, 1, superviseClients(time.Now()))
+	assert.Equal(t, before+1, metrics.Default().Counter("walkie_ws_evicted_total", nil))
+
+	registry.RLock()
+	assert.NotContains(t, registry.byUser, uint(640))
+	assert.Equal(t, alive, registry.byUser[641])
+	registry.RUnlock()
+
+	rec := httptest.NewRecorder()
+	metrics.Default().WritePrometheus(rec)
+	assert.Contains(t, rec.Body.String(), `walkie_ws_channel_clients{channel="supervisor-1"} 1`)
+
+	removeClient(alive)
+	superviseClients(time.Now())
+	rec = httptest.NewRecorder()
+	metrics.Default().WritePrometheus(rec)
+	assert.Contains(t, rec.Body.String(), `walkie_ws_channel_clients{channel="supervisor-1"} 0`, "un canal vacío vuelve a 0")
+}
+
+func TestAdminWSStats(t *testing.T) {
+	t.Setenv("ADMIN_TOKEN", "admin-secret")
+	const channel = "supervisor-2"
+	client := &wsClient{userID: 642, channel: channel, send: make(chan []byte, 1)}
+	registerClient(client)
+	defer removeClient(client)
+
+	get := func(token string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/admin/ws-stats", nil)
+		req.Header.Set("X-Admin-Token", token)
+		rec := httptest.NewRecorder()
+		AdminWSStats(rec, req)
+		return rec
+	}
+	assert.Equal(t, http.StatusUnauthorized, get("otro").Code)
+
+	rec := get("admin-secret")
+	require.Equal(t, http.StatusOK, rec.Code)
+	var body struct {
+		Clients  int              `json:"clients"`
+		Channels []wsChannelStats `json:"channels"`
+	}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+	assert.GreaterOrEqual(t, body.Clients, 1)
+	assert.Contains(t, body.Channels, wsChannelStats{Channel: channel, Clients: 1})
+}
@@ -251,7 +251,7 @@ func TestStartTransmission(t *testing.T) {
 	registerClient(client1)
 	registerClient(client2)
 
-	startTransmission("test", 1)
+	startTransmission("test", 1, PriorityNormal)
 
 	select {
 	case msg := <-client1.send:
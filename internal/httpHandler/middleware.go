@@ -0,0 +1,106 @@
+package httphandler
+
+import (
+	"bufio"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"runtime/debug"
+	"strings"
+	"time"
+
+	"walkie-backend/internal/response"
+)
+
+// Middleware envuelve un handler; es la misma forma que RequireAuth o RateLimiter.Middleware
+type Middleware func(http.HandlerFunc) http.HandlerFunc
+
+// Chain aplica los middlewares de modo que el primero es el más externo
+func Chain(h http.HandlerFunc, mws ...Middleware) http.HandlerFunc {
+	for i := len(mws) - 1; i >= 0; i-- {
+		h = mws[i](h)
+	}
+	return h
+}
+
+// Recover convierte un panic en un 500 para que una petición rota no tumbe el servidor
+func Recover(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		defer func() {
+			if err := recover(); err != nil {
+				if err == http.ErrAbortHandler {
+					panic(err)
+				}
+				log.Printf("[PANIC] %s %s: %v\n%s", r.Method, r.URL.Path, err, debug.Stack())
+				response.WriteErr(w, http.StatusInternalServerError, "Error interno")
+			}
+		}()
+		next(w, r)
+	}
+}
+
+// statusRecorder guarda el código de respuesta para el log de acceso
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (s *statusRecorder) WriteHeader(code int) {
+	if s.status == 0 {
+		s.status = code
+	}
+	s.ResponseWriter.WriteHeader(code)
+}
+
+func (s *statusRecorder) Write(b []byte) (int, error) {
+	if s.status == 0 {
+		s.status = http.StatusOK
+	}
+	return s.ResponseWriter.Write(b)
+}
+
+// Flush mantiene el streaming de /audio/stream a través del log de acceso
+func (s *statusRecorder) Flush() {
+	if f, ok := s.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Hijack permite el upgrade de /ws; la conexión pasa a ser del WebSocket
+func (s *statusRecorder) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	h, ok := s.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, fmt.Errorf("el ResponseWriter no admite Hijack")
+	}
+	if s.status == 0 {
+		s.status = http.StatusSwitchingProtocols
+	}
+	return h.Hijack()
+}
+
+func (s *statusRecorder) Unwrap() http.ResponseWriter {
+	return s.ResponseWriter
+}
+
+// LogRequests escribe una línea por petición con método, ruta, estado y duración
+func LogRequests(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		rec := &statusRecorder{ResponseWriter: w}
+		next(rec, r)
+		status := rec.status
+		if status == 0 {
+			status = http.StatusOK
+		}
+		log.Printf("[HTTP] %s %s estado=%d duracion=%s", r.Method, loggedPath(r.URL.Path), status, time.Since(start).Round(time.Millisecond))
+	}
+}
+
+// loggedPath oculta los tokens que viajan en la ruta, como el de los webhooks de entrada
+func loggedPath(path string) string {
+	if _, token, ok := strings.Cut(path, "/integrations/webhooks/"); ok && token != "" {
+		return "/integrations/webhooks/***"
+	}
+	return path
+}
@@ -0,0 +1,129 @@
+package httphandler
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"reflect"
+	"strings"
+	"sync"
+
+	"walkie-backend/internal/httpHandler/handlers"
+	"walkie-backend/internal/response"
+	"walkie-backend/pkg/openapi"
+)
+
+// swaggerUIVersion es la versión de swagger-ui-dist que carga /docs desde el CDN
+const swaggerUIVersion = "5.17.14"
+
+// routerDocs documenta las rutas que registra el propio router
+var routerDocs = []handlers.APIDoc{
+	{Route: "/api/versions", Operation: openapi.Operation{Method: http.MethodGet, Tag: "ops", Summary: "Versiones de la API disponibles",
+		Responses: []openapi.Response{{Status: http.StatusOK, Body: openapi.Fields{
+			"current": reflect.TypeFor[string](), "supported": reflect.TypeFor[[]string](), "prefix": reflect.TypeFor[string](),
+			"legacyRoutes": reflect.TypeFor[bool](), "legacySunset": reflect.TypeFor[string](),
+		}}}}},
+	{Route: "/openapi.json", Operation: openapi.Operation{Method: http.MethodGet, Tag: "ops", Summary: "Esta especificación",
+		Responses: []openapi.Response{{Status: http.StatusOK, Body: openapi.Raw{}}}}},
+	{Route: "/docs", Operation: openapi.Operation{Method: http.MethodGet, Tag: "ops", Summary: "Swagger UI",
+		Responses: []openapi.Response{{Status: http.StatusOK, Content: "text/html", Body: openapi.Raw{}}}}},
+}
+
+// OpenAPI genera la especificación a partir de las rutas registradas; las rutas
+// versionadas se publican con su prefijo y los alias obsoletos no se listan
+func (rt *Router) OpenAPI(docs []handlers.APIDoc) (map[string]any, error) {
+	b := openapi.NewBuilder(openapi.Info{
+		Title:   "Walkie-Talkie IA",
+		Version: apiVersion,
+		Description: "Las rutas sin el prefijo " + apiPrefix + " siguen disponibles como alias obsoletos " +
+			"(cabecera Deprecation). Los clips de audio se entregan en el cuerpo y sus metadatos en cabeceras X-Audio-*.",
+	}, reflect.TypeFor[map[string]string]())
+	b.Bearer("bearerAuth", "JWT", "Authorization: Bearer con el access_token de /auth")
+	b.APIKey("authToken", "X-Auth-Token", "Token de sesión de /auth")
+	b.APIKey("adminToken", "X-Admin-Token", "ADMIN_TOKEN del servidor")
+
+	for _, doc := range docs {
+		rr, ok := rt.routes[doc.Route]
+		if !ok || rr.endpoints[doc.Operation.Method] == nil {
+			return nil, fmt.Errorf("documentación de %s %s sin ruta registrada", doc.Operation.Method, doc.Route)
+		}
+		op := doc.Operation
+		if op.Path == "" {
+			op.Path = doc.Route
+		}
+		if _, versioned := rt.routes[apiPrefix+doc.Route]; versioned {
+			op.Path = apiPrefix + op.Path
+		}
+		b.Add(op)
+	}
+	return b.Document(), nil
+}
+
+// undocumented devuelve los "MÉTODO patrón" registrados sin entrada en docs
+func (rt *Router) undocumented(docs []handlers.APIDoc) []string {
+	seen := make(map[string]bool, len(docs))
+	for _, doc := range docs {
+		seen[doc.Operation.Method+" "+doc.Route] = true
+	}
+	var missing []string
+	for pattern, rr := range rt.routes {
+		if strings.HasPrefix(pattern, apiPrefix+"/") {
+			continue // mismo handler que el alias sin versión
+		}
+		for method := range rr.endpoints {
+			if !seen[method+" "+pattern] {
+				missing = append(missing, method+" "+pattern)
+			}
+		}
+	}
+	return missing
+}
+
+// openAPIHandler sirve /openapi.json; el documento se genera en la primera petición,
+// cuando ya están registradas todas las rutas
+func (rt *Router) openAPIHandler(docs func() []handlers.APIDoc) http.HandlerFunc {
+	var (
+		once sync.Once
+		body []byte
+		err  error
+	)
+	return func(w http.ResponseWriter, r *http.Request) {
+		once.Do(func() {
+			var doc map[string]any
+			if doc, err = rt.OpenAPI(docs()); err == nil {
+				body, err = json.Marshal(doc)
+			}
+			if err != nil {
+				log.Printf("[OPENAPI] no se pudo generar la especificación: %v", err)
+			}
+		})
+		if err != nil {
+			response.WriteErr(w, http.StatusInternalServerError, "No se pudo generar la especificación")
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write(body)
+	}
+}
+
+// SwaggerUI sirve /docs con Swagger UI apuntando a /openapi.json
+func SwaggerUI(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	_, _ = fmt.Fprintf(w, swaggerUIPage, swaggerUIVersion, swaggerUIVersion)
+}
+
+const swaggerUIPage = `<!DOCTYPE html>
+<html lang="es">
+<head>
+<meta charset="utf-8">
+<title>Walkie-Talkie IA API</title>
+<link rel="stylesheet" href="https://unpkg.com/swagger-ui-dist@%s/swagger-ui.css">
+</head>
+<body>
+<div id="swagger-ui"></div>
+<script src="https://unpkg.com/swagger-ui-dist@%s/swagger-ui-bundle.js"></script>
+<script>window.ui = SwaggerUIBundle({url: "/openapi.json", dom_id: "#swagger-ui"});</script>
+</body>
+</html>
+`
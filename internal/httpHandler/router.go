@@ -2,15 +2,205 @@ package httphandler
 
 import (
 	"net/http"
+	"sort"
+	"strings"
 
 	"walkie-backend/internal/httpHandler/handlers"
+	"walkie-backend/internal/metrics"
+	"walkie-backend/internal/response"
 )
 
+// Router registra rutas por método sobre un ServeMux y aplica los middlewares globales a
+// todas ellas. Cada ruta entra en el mux con su método ("GET /v1/me"), así dos patrones
+// que se solapan con métodos distintos no chocan. Lo que no casa con ningún método cae en
+// un comodín que responde 405 con la cabecera Allow, o 404.
+type Router struct {
+	mux     *http.ServeMux
+	global  []Middleware
+	routes  map[string]*route
+	methods map[string]bool
+}
+
+type route struct {
+	handlers  map[string]http.HandlerFunc
+	endpoints map[string]http.HandlerFunc
+}
+
+// NewRouter crea un router sobre mux; los middlewares globales envuelven cada ruta
+func NewRouter(mux *http.ServeMux, global ...Middleware) *Router {
+	rt := &Router{mux: mux, global: global, routes: make(map[string]*route), methods: make(map[string]bool)}
+	// El comodín también pasa por los globales: CORS responde ahí los preflight OPTIONS
+	mux.HandleFunc("/", Chain(rt.fallback, global...))
+	return rt
+}
+
+// Handle registra h para method y pattern (admite comodines de ServeMux como {code}),
+// envuelto en los middlewares de la ruta. La ruta se sirve en /v1 y, sin versión, como
+// alias obsoleto.
+func (rt *Router) Handle(method, pattern string, h http.HandlerFunc, mws ...Middleware) {
+	rt.handle(method, apiPrefix+pattern, h, mws, negotiateVersion, stripVersion)
+	rt.handle(method, pattern, h, mws, negotiateVersion, deprecatedAlias)
+}
+
+// HandleUnversioned registra una ruta fuera de la API versionada, como las sondas de salud
+// o las métricas, que no cambian con las versiones
+func (rt *Router) HandleUnversioned(method, pattern string, h http.HandlerFunc, mws ...Middleware) {
+	rt.handle(method, pattern, h, mws)
+}
+
+func (rt *Router) handle(method, pattern string, h http.HandlerFunc, mws []Middleware, wrap ...Middleware) {
+	rr, ok := rt.routes[pattern]
+	if !ok {
+		rr = &route{handlers: make(map[string]http.HandlerFunc), endpoints: make(map[string]http.HandlerFunc)}
+		rt.routes[pattern] = rr
+	}
+	rr.endpoints[method] = h
+	rr.handlers[method] = Chain(h, mws...)
+	rt.methods[method] = true
+	rt.mux.HandleFunc(method+" "+pattern, Chain(Chain(rr.handlers[method], wrap...), rt.global...))
+}
+
+// Endpoint devuelve el handler registrado sin middlewares, o nil si no existe
+func (rt *Router) Endpoint(method, pattern string) http.HandlerFunc {
+	if rr, ok := rt.routes[pattern]; ok {
+		return rr.endpoints[method]
+	}
+	return nil
+}
+
+// fallback atiende lo que no casa con ninguna ruta: si la ruta existe con otros métodos
+// responde 405 con Allow; si no, 404
+func (rt *Router) fallback(w http.ResponseWriter, r *http.Request) {
+	if allow := rt.allow(r); allow != "" {
+		w.Header().Set("Allow", allow)
+		response.WriteErr(w, http.StatusMethodNotAllowed, "Método no permitido")
+		return
+	}
+	response.WriteErr(w, http.StatusNotFound, "Ruta no encontrada")
+}
+
+// allow pregunta al mux qué métodos registrados aceptaría para la ruta de r. Un patrón
+// acabado en / sin la barra en la petición sería una redirección, no la misma ruta.
+func (rt *Router) allow(r *http.Request) string {
+	var methods []string
+	for m := range rt.methods {
+		probe := r.Clone(r.Context())
+		probe.Method = m
+		_, pattern := rt.mux.Handler(probe)
+		if pattern == "/" || pattern == "" || (strings.HasSuffix(pattern, "/") && !strings.HasSuffix(r.URL.Path, "/")) {
+			continue
+		}
+		methods = append(methods, m)
+	}
+	sort.Strings(methods)
+	return strings.Join(methods, ", ")
+}
+
 func Routes(mux *http.ServeMux) {
-	mux.HandleFunc("/channels/public", handlers.ListPublicChannels)
-	mux.HandleFunc("/channel-users", handlers.ChannelUsers)
-	mux.HandleFunc("/ws", handlers.HandleWebSocket)
-	mux.HandleFunc("/audio/ingest", handlers.AudioIngest)
-	mux.HandleFunc("/audio/poll", handlers.AudioPoll)
-	mux.HandleFunc("/auth", handlers.Authenticate)
+	register(NewRouter(mux, Recover, LogRequests, handlers.CORS))
+}
+
+// apiDocs es la documentación de todas las rutas de register
+func apiDocs() []handlers.APIDoc {
+	return append(handlers.APIDocs(), routerDocs...)
+}
+
+func register(rt *Router) {
+	auth := Middleware(handlers.RequireAuth)
+	ingestLimit := Middleware(handlers.IngestLimiter.Middleware)
+	pollLimit := Middleware(handlers.PollLimiter.Middleware)
+
+	rt.Handle(http.MethodGet, "/channels/public", handlers.ListPublicChannels)
+	rt.Handle(http.MethodGet, "/channels/{code}/history", handlers.ChannelHistory, auth)
+	rt.Handle(http.MethodGet, "/channels/{code}/history/{id}/audio", handlers.ChannelTransmissionAudio, auth)
+	rt.Handle(http.MethodGet, "/channels/{code}/presence", handlers.ChannelPresence, auth)
+	rt.Handle(http.MethodGet, "/channels/{code}/messages", handlers.ChannelMessages, auth)
+	rt.Handle(http.MethodGet, "/channels/{code}/transcripts", handlers.ChannelTranscripts, auth)
+	rt.Handle(http.MethodGet, "/channels/{code}/summary", handlers.ChannelSummary, auth)
+	rt.Handle(http.MethodGet, "/channels/{code}/recordings", handlers.ChannelRecordings, auth)
+	rt.Handle(http.MethodGet, "/channels/{code}/recordings/{id}/export", handlers.ChannelRecordingExport, auth)
+	rt.Handle(http.MethodPatch, "/channels/{code}/alias", handlers.ChannelAlias, auth)
+	rt.Handle(http.MethodPut, "/channels/{code}/roles/{userID}", handlers.ChannelRole, auth)
+	rt.Handle(http.MethodDelete, "/channels/{code}/roles/{userID}", handlers.ChannelRole, auth)
+	rt.Handle(http.MethodPost, "/channels/{code}/kick/{userID}", handlers.KickChannelUser, auth)
+	rt.Handle(http.MethodPost, "/channels/{code}/mute/{userID}", handlers.ChannelMute, auth)
+	rt.Handle(http.MethodDelete, "/channels/{code}/mute/{userID}", handlers.ChannelMute, auth)
+	rt.Handle(http.MethodGet, "/channels/{code}/listen", handlers.ChannelListen, auth)
+	rt.Handle(http.MethodPost, "/channels/{code}/connect", handlers.ChannelConnect, auth)
+	rt.Handle(http.MethodPost, "/channels/disconnect", handlers.ChannelDisconnect, auth)
+	rt.Handle(http.MethodGet, "/channel-users", handlers.ChannelUsers)
+	rt.Handle(http.MethodGet, "/ws", handlers.HandleWebSocket)
+	rt.Handle(http.MethodPost, "/audio/ingest", handlers.AudioIngest, auth, ingestLimit)
+	rt.Handle(http.MethodPost, "/audio/direct/{userID}", handlers.AudioDirect, auth, ingestLimit)
+	rt.Handle(http.MethodPost, "/audio/live", handlers.AudioLive, auth, ingestLimit)
+	rt.Handle(http.MethodPost, "/audio/encrypted", handlers.AudioEncrypted, auth, ingestLimit)
+	rt.Handle(http.MethodGet, "/audio/poll", handlers.AudioPoll, auth, pollLimit)
+	rt.Handle(http.MethodPost, "/audio/ack/{id}", handlers.AudioAck, auth)
+	rt.Handle(http.MethodPost, "/audio/played/{id}", handlers.AudioPlayed, auth)
+	rt.Handle(http.MethodGet, "/audio/{id}/receipts", handlers.AudioReceipts, auth)
+	rt.Handle(http.MethodGet, "/audio/stream", handlers.AudioStream, auth)
+	rt.Handle(http.MethodGet, "/audio/queue-status", handlers.AudioQueueStatus, auth)
+	rt.Handle(http.MethodPost, "/devices", handlers.RegisterDevice, auth)
+	rt.Handle(http.MethodPut, "/e2ee/key", handlers.PublicKey, auth)
+	rt.Handle(http.MethodDelete, "/e2ee/key", handlers.PublicKey, auth)
+	rt.Handle(http.MethodGet, "/search", handlers.Search, auth)
+	rt.Handle(http.MethodGet, "/me", handlers.MeProfile, auth)
+	rt.Handle(http.MethodPatch, "/me", handlers.Me, auth)
+	rt.Handle(http.MethodGet, "/me/usage", handlers.MeUsage, auth)
+	rt.Handle(http.MethodGet, "/me/devices", handlers.MeDevices, auth)
+	rt.Handle(http.MethodDelete, "/me/devices/{id}", handlers.RevokeMeDevice, auth)
+	rt.Handle(http.MethodPost, "/me/location", handlers.MeLocation, auth)
+	rt.Handle(http.MethodDelete, "/me/location", handlers.MeLocation, auth)
+	rt.Handle(http.MethodGet, "/me/nearby", handlers.MeNearby, auth)
+	rt.Handle(http.MethodGet, "/me/favorites", handlers.MeFavorites, auth)
+	rt.Handle(http.MethodPost, "/me/favorites/{code}", handlers.MeFavorite, auth)
+	rt.Handle(http.MethodDelete, "/me/favorites/{code}", handlers.MeFavorite, auth)
+	rt.Handle(http.MethodPost, "/auth", handlers.Authenticate)
+	rt.Handle(http.MethodPost, "/auth/refresh", handlers.RefreshToken)
+	rt.Handle(http.MethodPost, "/auth/logout", handlers.Logout, auth)
+	rt.Handle(http.MethodPost, "/admin/memberships/bulk", handlers.BulkMemberships)
+	rt.Handle(http.MethodGet, "/admin/keys", handlers.AdminKeys)
+	rt.Handle(http.MethodPost, "/admin/keys", handlers.AdminKeys)
+	rt.Handle(http.MethodDelete, "/admin/keys/{kid}", handlers.AdminKeyRetire)
+	rt.Handle(http.MethodGet, "/admin/channel-events", handlers.AdminChannelEvents)
+	rt.Handle(http.MethodGet, "/admin/ws-stats", handlers.AdminWSStats)
+	rt.Handle(http.MethodPost, "/admin/clients/reload", handlers.AdminReloadClients)
+	rt.Handle(http.MethodGet, "/admin/overview", handlers.AdminOverview)
+	rt.Handle(http.MethodGet, "/admin/audit", handlers.AdminAudit)
+	rt.Handle(http.MethodPost, "/admin/channels", handlers.AdminChannels)
+	rt.Handle(http.MethodPut, "/admin/channels/{code}", handlers.AdminChannel)
+	rt.Handle(http.MethodDelete, "/admin/channels/{code}", handlers.AdminChannel)
+	rt.Handle(http.MethodPut, "/admin/channels/{code}/roles/{userID}", handlers.AdminChannelRole)
+	rt.Handle(http.MethodDelete, "/admin/channels/{code}/roles/{userID}", handlers.AdminChannelRole)
+	rt.Handle(http.MethodPost, "/admin/channels/{code}/recording", handlers.AdminChannelRecording)
+	rt.Handle(http.MethodDelete, "/admin/channels/{code}/recording", handlers.AdminChannelRecording)
+	rt.Handle(http.MethodGet, "/admin/intents", handlers.AdminIntentPatterns)
+	rt.Handle(http.MethodPost, "/admin/intents", handlers.AdminIntentPatterns)
+	rt.Handle(http.MethodPut, "/admin/intents/{id}", handlers.AdminIntentPattern)
+	rt.Handle(http.MethodDelete, "/admin/intents/{id}", handlers.AdminIntentPattern)
+	rt.Handle(http.MethodGet, "/admin/moderation-rules", handlers.AdminModerationRules)
+	rt.Handle(http.MethodPost, "/admin/moderation-rules", handlers.AdminModerationRules)
+	rt.Handle(http.MethodPut, "/admin/moderation-rules/{id}", handlers.AdminModerationRule)
+	rt.Handle(http.MethodDelete, "/admin/moderation-rules/{id}", handlers.AdminModerationRule)
+	rt.Handle(http.MethodGet, "/admin/announcements", handlers.AdminAnnouncements)
+	rt.Handle(http.MethodPost, "/admin/announcements", handlers.AdminAnnouncements)
+	rt.Handle(http.MethodDelete, "/admin/announcements/{id}", handlers.AdminAnnouncement)
+	rt.Handle(http.MethodGet, "/admin/webhooks", handlers.AdminWebhooks)
+	rt.Handle(http.MethodPost, "/admin/webhooks", handlers.AdminWebhooks)
+	rt.Handle(http.MethodDelete, "/admin/webhooks/{id}", handlers.AdminWebhook)
+	rt.Handle(http.MethodPost, "/integrations/webhooks/{token}", handlers.WebhookAlert)
+	rt.Handle(http.MethodGet, "/admin/webhook-subscriptions", handlers.AdminWebhookSubscriptions)
+	rt.Handle(http.MethodPost, "/admin/webhook-subscriptions", handlers.AdminWebhookSubscriptions)
+	rt.Handle(http.MethodDelete, "/admin/webhook-subscriptions/{id}", handlers.AdminWebhookSubscription)
+	rt.Handle(http.MethodGet, "/admin/webhook-dead-letters", handlers.AdminWebhookDeadLetters)
+	rt.Handle(http.MethodPost, "/admin/webhook-dead-letters/{id}/retry", handlers.AdminWebhookDeadLetterRetry)
+	rt.Handle(http.MethodPost, "/telephony/twilio/voice", handlers.TwilioVoice)
+	rt.Handle(http.MethodPost, "/telephony/twilio/gather", handlers.TwilioGather)
+	rt.Handle(http.MethodGet, "/telephony/twilio/stream", handlers.TwilioStream)
+	rt.HandleUnversioned(http.MethodGet, "/api/versions", APIVersions)
+	rt.HandleUnversioned(http.MethodGet, "/openapi.json", rt.openAPIHandler(apiDocs))
+	rt.HandleUnversioned(http.MethodGet, "/docs", SwaggerUI)
+	rt.HandleUnversioned(http.MethodGet, "/metrics", metrics.Handler)
+	rt.HandleUnversioned(http.MethodGet, "/healthz", handlers.Healthz)
+	rt.HandleUnversioned(http.MethodGet, "/readyz", handlers.Readyz)
 }
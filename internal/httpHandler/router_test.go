@@ -1,45 +1,452 @@
 package httphandler
 
 import (
+	"crypto/hmac"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/json"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
 	"reflect"
+	"regexp"
+	"sort"
+	"strings"
 	"testing"
 
+	"walkie-backend/internal/config"
 	"walkie-backend/internal/httpHandler/handlers"
+	"walkie-backend/internal/metrics"
+	"walkie-backend/internal/models"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
 )
 
 func TestRoutes_RegistersHandlers(t *testing.T) {
 	mux := http.NewServeMux()
-	Routes(mux)
+	rt := NewRouter(mux)
+	register(rt)
 
 	tests := []struct {
+		method  string
 		path    string
 		handler http.HandlerFunc
 	}{
-		{"/channels/public", handlers.ListPublicChannels},
-		{"/channel-users", handlers.ChannelUsers},
-		{"/ws", handlers.HandleWebSocket},
-		{"/audio/ingest", handlers.AudioIngest},
-		{"/audio/poll", handlers.AudioPoll},
-		{"/auth", handlers.Authenticate},
+		{http.MethodGet, "/channels/public", handlers.ListPublicChannels},
+		{http.MethodGet, "/channel-users", handlers.ChannelUsers},
+		{http.MethodGet, "/ws", handlers.HandleWebSocket},
+		{http.MethodPost, "/auth", handlers.Authenticate},
+		{http.MethodPost, "/auth/refresh", handlers.RefreshToken},
+		{http.MethodPost, "/admin/memberships/bulk", handlers.BulkMemberships},
+		{http.MethodGet, "/admin/keys", handlers.AdminKeys},
+		{http.MethodPost, "/admin/keys", handlers.AdminKeys},
+		{http.MethodDelete, "/admin/keys/{kid}", handlers.AdminKeyRetire},
+		{http.MethodGet, "/admin/channel-events", handlers.AdminChannelEvents},
+		{http.MethodGet, "/admin/ws-stats", handlers.AdminWSStats},
+		{http.MethodGet, "/admin/overview", handlers.AdminOverview},
+		{http.MethodGet, "/admin/audit", handlers.AdminAudit},
+		{http.MethodPost, "/admin/channels", handlers.AdminChannels},
+		{http.MethodPut, "/admin/channels/{code}", handlers.AdminChannel},
+		{http.MethodDelete, "/admin/channels/{code}", handlers.AdminChannel},
+		{http.MethodPut, "/admin/channels/{code}/roles/{userID}", handlers.AdminChannelRole},
+		{http.MethodPost, "/admin/channels/{code}/recording", handlers.AdminChannelRecording},
+		{http.MethodGet, "/admin/intents", handlers.AdminIntentPatterns},
+		{http.MethodPost, "/admin/intents", handlers.AdminIntentPatterns},
+		{http.MethodPut, "/admin/intents/{id}", handlers.AdminIntentPattern},
+		{http.MethodDelete, "/admin/intents/{id}", handlers.AdminIntentPattern},
+		{http.MethodGet, "/admin/moderation-rules", handlers.AdminModerationRules},
+		{http.MethodPost, "/admin/moderation-rules", handlers.AdminModerationRules},
+		{http.MethodPut, "/admin/moderation-rules/{id}", handlers.AdminModerationRule},
+		{http.MethodDelete, "/admin/moderation-rules/{id}", handlers.AdminModerationRule},
+		{http.MethodGet, "/admin/announcements", handlers.AdminAnnouncements},
+		{http.MethodPost, "/admin/announcements", handlers.AdminAnnouncements},
+		{http.MethodDelete, "/admin/announcements/{id}", handlers.AdminAnnouncement},
+		{http.MethodGet, "/metrics", metrics.Handler},
+		{http.MethodGet, "/healthz", handlers.Healthz},
+		{http.MethodGet, "/readyz", handlers.Readyz},
 	}
 
 	for _, tc := range tests {
-		req := httptest.NewRequest(http.MethodGet, tc.path, nil)
-		gotHandler, pattern := mux.Handler(req)
+		req := httptest.NewRequest(tc.method, tc.path, nil)
+		if _, pattern := mux.Handler(req); pattern != tc.method+" "+tc.path {
+			t.Fatalf("%s %s: expected pattern %s, got %s", tc.method, tc.path, tc.path, pattern)
+		}
+
+		hf := rt.Endpoint(tc.method, tc.path)
+		if hf == nil {
+			t.Fatalf("%s %s: no handler registered", tc.method, tc.path)
+		}
+		if reflect.ValueOf(hf).Pointer() != reflect.ValueOf(tc.handler).Pointer() {
+			t.Fatalf("%s %s: unexpected handler registration", tc.method, tc.path)
+		}
+	}
+}
+
+func TestRoutes_ProtectedRoutesRequireToken(t *testing.T) {
+	mux := http.NewServeMux()
+	Routes(mux)
+
+	tests := []struct {
+		method  string
+		path    string
+		pattern string
+	}{
+		{http.MethodGet, "/channels/canal-1/history", "/channels/{code}/history"},
+		{http.MethodGet, "/channels/canal-1/history/3/audio", "/channels/{code}/history/{id}/audio"},
+		{http.MethodGet, "/channels/canal-1/recordings/3/export", "/channels/{code}/recordings/{id}/export"},
+		{http.MethodPatch, "/channels/canal-1/alias", "/channels/{code}/alias"},
+		{http.MethodPut, "/channels/canal-1/roles/7", "/channels/{code}/roles/{userID}"},
+		{http.MethodPost, "/channels/canal-1/kick/7", "/channels/{code}/kick/{userID}"},
+		{http.MethodPost, "/channels/canal-1/mute/7", "/channels/{code}/mute/{userID}"},
+		{http.MethodPost, "/audio/ingest", "/audio/ingest"},
+		{http.MethodPost, "/audio/direct/7", "/audio/direct/{userID}"},
+		{http.MethodPost, "/audio/live", "/audio/live"},
+		{http.MethodPost, "/audio/encrypted", "/audio/encrypted"},
+		{http.MethodGet, "/audio/poll", "/audio/poll"},
+		{http.MethodPost, "/audio/ack/abc123", "/audio/ack/{id}"},
+		{http.MethodGet, "/audio/stream", "/audio/stream"},
+		{http.MethodGet, "/audio/queue-status", "/audio/queue-status"},
+		{http.MethodGet, "/audio/abc123/receipts", "/audio/{id}/receipts"},
+		{http.MethodPost, "/auth/logout", "/auth/logout"},
+		{http.MethodPost, "/devices", "/devices"},
+		{http.MethodPut, "/e2ee/key", "/e2ee/key"},
+		{http.MethodGet, "/search", "/search"},
+		{http.MethodGet, "/me", "/me"},
+		{http.MethodPatch, "/me", "/me"},
+		{http.MethodGet, "/me/usage", "/me/usage"},
+		{http.MethodGet, "/me/devices", "/me/devices"},
+		{http.MethodDelete, "/me/devices/3", "/me/devices/{id}"},
+		{http.MethodPost, "/me/location", "/me/location"},
+		{http.MethodDelete, "/me/location", "/me/location"},
+		{http.MethodGet, "/me/nearby", "/me/nearby"},
+		{http.MethodGet, "/me/favorites", "/me/favorites"},
+		{http.MethodPost, "/me/favorites/canal-3", "/me/favorites/{code}"},
+		{http.MethodDelete, "/me/favorites/canal-3", "/me/favorites/{code}"},
+	}
 
-		if pattern != tc.path {
-			t.Fatalf("path %s: expected pattern %s, got %s", tc.path, tc.path, pattern)
+	for _, tc := range tests {
+		req := httptest.NewRequest(tc.method, tc.path, nil)
+		if _, pattern := mux.Handler(req); pattern != tc.method+" "+tc.pattern {
+			t.Fatalf("path %s: expected pattern %s, got %s", tc.path, tc.pattern, pattern)
 		}
 
-		hf, ok := gotHandler.(http.HandlerFunc)
-		if !ok {
-			t.Fatalf("path %s: handler is %T, expected httpHandler.HandlerFunc", tc.path, gotHandler)
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, req)
+		if rec.Code != http.StatusUnauthorized {
+			t.Fatalf("%s %s: expected 401 without token, got %d", tc.method, tc.path, rec.Code)
+		}
+		if rec.Header().Get("WWW-Authenticate") == "" {
+			t.Fatalf("path %s: missing WWW-Authenticate header", tc.path)
 		}
+	}
+}
 
-		if reflect.ValueOf(hf).Pointer() != reflect.ValueOf(tc.handler).Pointer() {
-			t.Fatalf("path %s: unexpected handler registration", tc.path)
+func TestRoutes_MethodNotAllowed(t *testing.T) {
+	mux := http.NewServeMux()
+	Routes(mux)
+
+	tests := []struct {
+		method string
+		path   string
+		allow  string
+	}{
+		{http.MethodGet, "/audio/ingest", "POST"},
+		{http.MethodPut, "/audio/ingest", "POST"},
+		{http.MethodDelete, "/audio/ingest", "POST"},
+		{http.MethodGet, "/auth", "POST"},
+		{http.MethodGet, "/channels/canal-3/alias", "PATCH"},
+		{http.MethodPatch, "/admin/keys", "GET, POST"},
+		{http.MethodPost, "/v1/audio/abc123/receipts", "GET"},
+	}
+
+	for _, tc := range tests {
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequest(tc.method, tc.path, nil))
+		if rec.Code != http.StatusMethodNotAllowed {
+			t.Fatalf("%s %s: expected 405, got %d", tc.method, tc.path, rec.Code)
 		}
+		if got := rec.Header().Get("Allow"); got != tc.allow {
+			t.Errorf("%s %s: expected Allow %q, got %q", tc.method, tc.path, tc.allow, got)
+		}
+		if !strings.Contains(rec.Body.String(), `"Método no permitido"`) {
+			t.Errorf("%s %s: unexpected body: %s", tc.method, tc.path, rec.Body.String())
+		}
+	}
+}
+
+func TestRecover_ReturnsInternalServerError(t *testing.T) {
+	h := Chain(func(http.ResponseWriter, *http.Request) { panic("boom") }, Recover, LogRequests)
+
+	rec := httptest.NewRecorder()
+	h(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	if rec.Code != http.StatusInternalServerError {
+		t.Fatalf("expected 500 after panic, got %d", rec.Code)
+	}
+}
+
+func TestChain_OrderOutermostFirst(t *testing.T) {
+	var order []string
+	mw := func(name string) Middleware {
+		return func(next http.HandlerFunc) http.HandlerFunc {
+			return func(w http.ResponseWriter, r *http.Request) {
+				order = append(order, name)
+				next(w, r)
+			}
+		}
+	}
+	h := Chain(func(http.ResponseWriter, *http.Request) { order = append(order, "handler") }, mw("a"), mw("b"))
+	h(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+
+	if strings.Join(order, ",") != "a,b,handler" {
+		t.Fatalf("unexpected middleware order: %v", order)
+	}
+}
+
+func TestRoutes_CORSPreflight(t *testing.T) {
+	t.Setenv("ALLOWED_ORIGINS", "*")
+	mux := http.NewServeMux()
+	Routes(mux)
+
+	for _, path := range []string{"/auth", "/audio/ingest", "/channels/canal-1/messages"} {
+		req := httptest.NewRequest(http.MethodOptions, path, nil)
+		req.Header.Set("Origin", "https://app.walkie.dev")
+		req.Header.Set("Access-Control-Request-Method", http.MethodPost)
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, req)
+		if rec.Code != http.StatusNoContent {
+			t.Fatalf("%s: expected 204 preflight, got %d", path, rec.Code)
+		}
+		if rec.Header().Get("Access-Control-Allow-Origin") != "https://app.walkie.dev" {
+			t.Fatalf("%s: missing Access-Control-Allow-Origin", path)
+		}
+	}
+}
+
+func TestRouter_VersionedRoutesAndDeprecatedAliases(t *testing.T) {
+	t.Setenv("API_LEGACY_SUNSET", "Wed, 01 Jul 2026 00:00:00 GMT")
+	mux := http.NewServeMux()
+	rt := NewRouter(mux)
+	var gotPath, gotCode string
+	rt.Handle(http.MethodGet, "/channels/{code}/alias", func(w http.ResponseWriter, r *http.Request) {
+		gotPath, gotCode = r.URL.Path, r.PathValue("code")
+		w.WriteHeader(http.StatusNoContent)
+	})
+	rt.HandleUnversioned(http.MethodGet, "/healthz", handlers.Healthz)
+
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/v1/channels/canal-1/alias", nil))
+	if rec.Code != http.StatusNoContent || gotPath != "/channels/canal-1/alias" || gotCode != "canal-1" {
+		t.Fatalf("versioned route: code=%d path=%q code=%q", rec.Code, gotPath, gotCode)
+	}
+	if rec.Header().Get("API-Version") != "1" || rec.Header().Get("Deprecation") != "" {
+		t.Fatalf("unexpected versioned headers: %v", rec.Header())
+	}
+
+	rec = httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/channels/canal-1/alias", nil))
+	if rec.Code != http.StatusNoContent || rec.Header().Get("Deprecation") != "true" {
+		t.Fatalf("legacy alias: code=%d headers=%v", rec.Code, rec.Header())
+	}
+	if got := rec.Header().Get("Link"); got != `</v1/channels/canal-1/alias>; rel="successor-version"` {
+		t.Fatalf("unexpected Link: %q", got)
+	}
+	if rec.Header().Get("Sunset") == "" {
+		t.Fatal("expected Sunset header on legacy alias")
+	}
+
+	rec = httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+	if rec.Header().Get("Deprecation") != "" {
+		t.Fatal("unversioned routes must not be deprecated")
+	}
+}
+
+func TestRouter_VersionNegotiation(t *testing.T) {
+	mux := http.NewServeMux()
+	rt := NewRouter(mux)
+	rt.Handle(http.MethodGet, "/me", func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusOK) })
+
+	tests := []struct {
+		header, value string
+		code          int
+	}{
+		{"API-Version", "1", http.StatusOK},
+		{"API-Version", "v1", http.StatusOK},
+		{"API-Version", "2", http.StatusNotAcceptable},
+		{"Accept", "application/vnd.walkie.v1+json", http.StatusOK},
+		{"Accept", "text/html, application/vnd.walkie.v3+json;q=0.9", http.StatusNotAcceptable},
+		{"Accept", "application/json", http.StatusOK},
+	}
+	for _, tc := range tests {
+		req := httptest.NewRequest(http.MethodGet, "/me", nil)
+		req.Header.Set(tc.header, tc.value)
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, req)
+		if rec.Code != tc.code {
+			t.Fatalf("%s: %s expected %d, got %d", tc.header, tc.value, tc.code, rec.Code)
+		}
+		if tc.code == http.StatusNotAcceptable && rec.Header().Get("API-Supported-Versions") != "1" {
+			t.Fatalf("expected API-Supported-Versions on 406, got %v", rec.Header())
+		}
+	}
+
+	t.Setenv("API_LEGACY_ROUTES", "false")
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/me", nil))
+	if rec.Code != http.StatusGone {
+		t.Fatalf("expected 410 with legacy routes disabled, got %d", rec.Code)
+	}
+	rec = httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/v1/me", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected /v1 to keep working, got %d", rec.Code)
+	}
+}
+
+func TestOpenAPI_DocumentsEveryRoute(t *testing.T) {
+	rt := NewRouter(http.NewServeMux())
+	register(rt)
+
+	if missing := rt.undocumented(apiDocs()); len(missing) > 0 {
+		t.Fatalf("routes without OpenAPI docs: %v", missing)
+	}
+	if _, err := rt.OpenAPI(apiDocs()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestOpenAPI_ServesSpecAndDocs(t *testing.T) {
+	mux := http.NewServeMux()
+	Routes(mux)
+
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/openapi.json", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var doc struct {
+		OpenAPI string `json:"openapi"`
+		Paths   map[string]map[string]struct {
+			Responses map[string]struct {
+				Headers map[string]any `json:"headers"`
+			} `json:"responses"`
+		} `json:"paths"`
+		Components struct {
+			Schemas map[string]struct {
+				Required []string `json:"required"`
+			} `json:"schemas"`
+		} `json:"components"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &doc); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	if !strings.HasPrefix(doc.OpenAPI, "3.") {
+		t.Fatalf("unexpected openapi version %q", doc.OpenAPI)
+	}
+	poll, ok := doc.Paths["/v1/audio/poll"]["get"]
+	if !ok {
+		t.Fatal("missing GET /v1/audio/poll")
+	}
+	if _, ok := poll.Responses["200"].Headers["X-Audio-From"]; !ok {
+		t.Fatal("expected X-Audio-From documented on the poll response")
+	}
+	if _, ok := doc.Paths["/v1/channels/{code}/messages"]["get"]; !ok {
+		t.Fatal("expected prefix routes to list their concrete paths")
+	}
+	if _, ok := doc.Paths["/healthz"]["get"]; !ok {
+		t.Fatal("expected unversioned routes without prefix")
+	}
+	if _, ok := doc.Paths["/audio/poll"]; ok {
+		t.Fatal("legacy aliases must not be listed")
+	}
+	if got := doc.Components.Schemas["AuthenticationRequest"].Required; strings.Join(got, ",") != "nombre,pin" {
+		t.Fatalf("unexpected required fields for AuthenticationRequest: %v", got)
+	}
+
+	rec = httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/docs", nil))
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), `url: "/openapi.json"`) {
+		t.Fatalf("unexpected /docs response: %d %s", rec.Code, rec.Body.String())
+	}
+}
+
+// signedTwilioPost firma el formulario como lo hace Twilio
+func signedTwilioPost(publicURL, token, path string, form url.Values) *http.Request {
+	keys := make([]string, 0, len(form))
+	for k := range form {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	data := publicURL + path
+	for _, k := range keys {
+		data += k + form.Get(k)
+	}
+	mac := hmac.New(sha1.New, []byte(token))
+	mac.Write([]byte(data))
+
+	req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("X-Twilio-Signature", base64.StdEncoding.EncodeToString(mac.Sum(nil)))
+	return req
+}
+
+func TestTelephony_TwiMLURLsWorkWithoutLegacyRoutes(t *testing.T) {
+	const publicURL = "https://walkie.example.com"
+	t.Setenv("TWILIO_AUTH_TOKEN", "twilio-secreto")
+	t.Setenv("TELEPHONY_PUBLIC_URL", publicURL)
+	t.Setenv("API_LEGACY_ROUTES", "false")
+
+	db, err := gorm.Open(sqlite.Open("file:telephony_routes?mode=memory&cache=shared"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("sqlite open: %v", err)
+	}
+	if err := db.AutoMigrate(&models.Channel{}); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	if err := db.Create(&models.Channel{Code: "obra-sur", Name: "Obra sur", MaxUsers: 5, DialInCode: "777"}).Error; err != nil {
+		t.Fatalf("create channel: %v", err)
+	}
+	oldDB := config.DB
+	config.DB = db
+	t.Cleanup(func() {
+		sqlDB, _ := db.DB()
+		_ = sqlDB.Close()
+		config.DB = oldDB
+	})
+
+	mux := http.NewServeMux()
+	register(NewRouter(mux))
+	post := func(path string, form url.Values) string {
+		t.Helper()
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, signedTwilioPost(publicURL, "twilio-secreto", path, form))
+		if rec.Code != http.StatusOK {
+			t.Fatalf("POST %s: expected 200, got %d: %s", path, rec.Code, rec.Body.String())
+		}
+		return rec.Body.String()
+	}
+	find := func(body, pattern string) string {
+		t.Helper()
+		m := regexp.MustCompile(pattern).FindStringSubmatch(body)
+		if m == nil {
+			t.Fatalf("%q not found in %s", pattern, body)
+		}
+		return m[1]
+	}
+
+	action := find(post("/v1/telephony/twilio/voice", url.Values{"CallSid": {"CA9"}}), `action="([^"]+)"`)
+	redirect := find(post(action, url.Values{"Digits": {"000"}}), `<Redirect method="POST">([^<]+)</Redirect>`)
+	post(redirect, url.Values{"CallSid": {"CA9"}})
+
+	stream := find(post(action, url.Values{"Digits": {"777"}, "CallSid": {"CA9"}}), `<Stream url="([^"]+)"`)
+	path, ok := strings.CutPrefix(stream, "wss://walkie.example.com")
+	if !ok {
+		t.Fatalf("unexpected stream URL %q", stream)
+	}
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+	if rec.Code == http.StatusGone || rec.Code == http.StatusNotFound {
+		t.Fatalf("stream URL %s is not routed: %d", path, rec.Code)
 	}
 }
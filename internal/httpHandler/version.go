@@ -0,0 +1,122 @@
+package httphandler
+
+import (
+	"net/http"
+	"os"
+	"strings"
+
+	"walkie-backend/internal/metrics"
+	"walkie-backend/internal/response"
+)
+
+const (
+	// apiVersion es la versión actual de la API; sus rutas cuelgan de apiPrefix
+	apiVersion = "1"
+	apiPrefix  = "/v" + apiVersion
+	// apiMediaType permite pedir la versión por Accept en lugar de por cabecera
+	apiMediaType = "application/vnd.walkie.v"
+)
+
+// supportedAPIVersions son las versiones que el servidor sabe servir
+var supportedAPIVersions = []string{apiVersion}
+
+// requestedAPIVersion lee la versión que pide el cliente en API-Version o en
+// Accept: application/vnd.walkie.vN+json; vacío si no pide ninguna
+func requestedAPIVersion(r *http.Request) string {
+	if v := strings.TrimSpace(r.Header.Get("API-Version")); v != "" {
+		return strings.TrimPrefix(strings.ToLower(v), "v")
+	}
+	for _, part := range strings.Split(r.Header.Get("Accept"), ",") {
+		mediaType, _, _ := strings.Cut(strings.TrimSpace(part), ";")
+		if v, ok := strings.CutPrefix(strings.ToLower(mediaType), apiMediaType); ok {
+			v, _, _ = strings.Cut(v, "+")
+			return v
+		}
+	}
+	return ""
+}
+
+func supportsAPIVersion(v string) bool {
+	for _, s := range supportedAPIVersions {
+		if s == v {
+			return true
+		}
+	}
+	return false
+}
+
+// negotiateVersion responde 406 si el cliente pide una versión que no existe y anota en
+// API-Version la que se sirve
+func negotiateVersion(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if v := requestedAPIVersion(r); v != "" && !supportsAPIVersion(v) {
+			w.Header().Set("API-Supported-Versions", strings.Join(supportedAPIVersions, ", "))
+			response.WriteErr(w, http.StatusNotAcceptable, "Versión de API no soportada: "+v)
+			return
+		}
+		w.Header().Set("API-Version", apiVersion)
+		next(w, r)
+	}
+}
+
+// stripVersion quita /v1 de la ruta para que los handlers que la leen a mano vean la
+// misma que sin versión; los comodines del patrón se conservan en la copia
+func stripVersion(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		r2 := new(http.Request)
+		*r2 = *r
+		u := *r.URL
+		u.Path = strings.TrimPrefix(u.Path, apiPrefix)
+		u.RawPath = strings.TrimPrefix(u.RawPath, apiPrefix)
+		r2.URL = &u
+		next(w, r2)
+	}
+}
+
+// legacyRoutesEnabled indica si las rutas sin versión siguen activas (API_LEGACY_ROUTES)
+func legacyRoutesEnabled() bool {
+	switch strings.ToLower(os.Getenv("API_LEGACY_ROUTES")) {
+	case "0", "false", "off", "no":
+		return false
+	}
+	return true
+}
+
+// deprecatedAlias sirve la ruta sin versión como alias obsoleto de /v1: avisa con
+// Deprecation, Link a la sucesora y Sunset si API_LEGACY_SUNSET tiene fecha
+func deprecatedAlias(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		successor := apiPrefix + r.URL.Path
+		if !legacyRoutesEnabled() {
+			w.Header().Set("Link", "<"+successor+`>; rel="successor-version"`)
+			response.WriteErr(w, http.StatusGone, "Ruta retirada, usa "+successor)
+			return
+		}
+		metrics.Inc("walkie_api_legacy_requests_total", nil)
+		h := w.Header()
+		h.Set("Deprecation", "true")
+		h.Set("Link", "<"+successor+`>; rel="successor-version"`)
+		if sunset := os.Getenv("API_LEGACY_SUNSET"); sunset != "" {
+			h.Set("Sunset", sunset)
+		}
+		next(w, r)
+	}
+}
+
+// APIVersions lista las versiones disponibles para que el cliente elija antes de migrar
+func APIVersions(w http.ResponseWriter, r *http.Request) {
+	versions := make([]string, 0, len(supportedAPIVersions))
+	for _, v := range supportedAPIVersions {
+		versions = append(versions, "v"+v)
+	}
+	body := map[string]any{
+		"current":      "v" + apiVersion,
+		"supported":    versions,
+		"prefix":       apiPrefix,
+		"legacyRoutes": legacyRoutesEnabled(),
+	}
+	if sunset := os.Getenv("API_LEGACY_SUNSET"); sunset != "" {
+		body["legacySunset"] = sunset
+	}
+	response.WriteJSON(w, http.StatusOK, body)
+}
@@ -0,0 +1,76 @@
+package keyring
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strings"
+)
+
+var ErrInvalidJWT = errors.New("keyring: JWT inválido")
+
+type jwtHeader struct {
+	Alg string `json:"alg"`
+	Typ string `json:"typ"`
+	KID string `json:"kid"`
+}
+
+// SignJWT serializa claims como JWT HS256 firmado con la clave primaria; el kid va
+// en la cabecera para poder verificar tras una rotación
+func (k *Keyring) SignJWT(claims any) (string, error) {
+	body, err := json.Marshal(claims)
+	if err != nil {
+		return "", err
+	}
+
+	k.mu.RLock()
+	kid := k.primary
+	k.mu.RUnlock()
+	if kid == "" {
+		return "", ErrNoActiveKey
+	}
+	header, _ := json.Marshal(jwtHeader{Alg: "HS256", Typ: "JWT", KID: kid})
+
+	signingInput := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(body)
+	usedKID, sig, err := k.Sign([]byte(signingInput))
+	if err != nil {
+		return "", err
+	}
+	if usedKID != kid {
+		// La primaria rotó entre la lectura y la firma; se firma de nuevo con la nueva
+		return k.SignJWT(claims)
+	}
+	return signingInput + "." + sig, nil
+}
+
+// VerifyJWT comprueba la firma y decodifica los claims. La caducidad la valida quien llama.
+func (k *Keyring) VerifyJWT(token string, claims any) error {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return ErrInvalidJWT
+	}
+	rawHeader, err := base64.RawURLEncoding.DecodeString(parts[0])
+	if err != nil {
+		return ErrInvalidJWT
+	}
+	var header jwtHeader
+	if err := json.Unmarshal(rawHeader, &header); err != nil || header.Alg != "HS256" {
+		return ErrInvalidJWT
+	}
+	if !k.Verify(header.KID, []byte(parts[0]+"."+parts[1]), parts[2]) {
+		return ErrInvalidJWT
+	}
+	body, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return ErrInvalidJWT
+	}
+	if err := json.Unmarshal(body, claims); err != nil {
+		return ErrInvalidJWT
+	}
+	return nil
+}
+
+// LooksLikeJWT distingue un JWT de los tokens opacos "<hex>.<kid>.<firma>"
+func LooksLikeJWT(token string) bool {
+	return strings.HasPrefix(token, "eyJ") && strings.Count(token, ".") == 2
+}
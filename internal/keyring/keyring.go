@@ -0,0 +1,330 @@
+package keyring
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"walkie-backend/internal/models"
+)
+
+var (
+	ErrNoActiveKey   = errors.New("keyring: no hay claves activas")
+	ErrKeyNotFound   = errors.New("keyring: clave no encontrada")
+	ErrLastActiveKey = errors.New("keyring: no se puede retirar la única clave activa")
+)
+
+// Store persiste las claves para que todas las réplicas compartan el mismo keyring
+type Store interface {
+	LoadKeys() ([]models.SigningKey, error)
+	SaveKey(*models.SigningKey) error
+	RetireKey(kid string, at time.Time) error
+}
+
+// KeyInfo describe una clave sin exponer el secreto
+type KeyInfo struct {
+	KID       string     `json:"kid"`
+	CreatedAt time.Time  `json:"createdAt"`
+	RetiredAt *time.Time `json:"retiredAt,omitempty"`
+	Primary   bool       `json:"primary"`
+}
+
+type key struct {
+	kid       string
+	secret    []byte
+	createdAt time.Time
+	retiredAt *time.Time
+}
+
+// Keyring firma con la clave primaria y verifica con cualquier clave activa
+type Keyring struct {
+	mu      sync.RWMutex
+	keys    map[string]*key
+	primary string
+	store   Store
+	now     func() time.Time
+
+	lastLoad time.Time
+}
+
+const reloadCooldown = 10 * time.Second
+
+func New(store Store) *Keyring {
+	return &Keyring{
+		keys:  make(map[string]*key),
+		store: store,
+		now:   time.Now,
+	}
+}
+
+// Load lee las claves del store y crea una si no existe ninguna activa
+func (k *Keyring) Load() error {
+	stored, err := k.store.LoadKeys()
+	if err != nil {
+		return fmt.Errorf("keyring: cargar claves: %w", err)
+	}
+
+	k.mu.Lock()
+	k.lastLoad = k.now()
+	k.keys = make(map[string]*key, len(stored))
+	for _, sk := range stored {
+		secret, err := base64.StdEncoding.DecodeString(sk.Secret)
+		if err != nil {
+			log.Printf("keyring: clave %s con secreto inválido, se ignora", sk.KID)
+			continue
+		}
+		k.keys[sk.KID] = &key{kid: sk.KID, secret: secret, createdAt: sk.CreatedAt, retiredAt: sk.RetiredAt}
+	}
+	k.electPrimaryLocked()
+	hasPrimary := k.primary != ""
+	k.mu.Unlock()
+
+	if !hasPrimary {
+		_, err := k.Rotate()
+		return err
+	}
+	return nil
+}
+
+// Rotate crea una clave nueva y la convierte en primaria; las anteriores siguen activas
+func (k *Keyring) Rotate() (KeyInfo, error) {
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return KeyInfo{}, err
+	}
+	idBytes := make([]byte, 6)
+	if _, err := rand.Read(idBytes); err != nil {
+		return KeyInfo{}, err
+	}
+
+	sk := &models.SigningKey{
+		KID:    hex.EncodeToString(idBytes),
+		Secret: base64.StdEncoding.EncodeToString(secret),
+	}
+	if err := k.store.SaveKey(sk); err != nil {
+		return KeyInfo{}, fmt.Errorf("keyring: guardar clave: %w", err)
+	}
+	created := sk.CreatedAt
+	if created.IsZero() {
+		created = k.now()
+	}
+
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	k.keys[sk.KID] = &key{kid: sk.KID, secret: secret, createdAt: created}
+	k.primary = sk.KID
+	log.Printf("keyring: nueva clave primaria kid=%s", sk.KID)
+	return KeyInfo{KID: sk.KID, CreatedAt: created, Primary: true}, nil
+}
+
+// Retire deja de aceptar una clave. La clave primaria pasa a la activa más reciente.
+func (k *Keyring) Retire(kid string) error {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	target, ok := k.keys[kid]
+	if !ok {
+		return ErrKeyNotFound
+	}
+	if target.retiredAt != nil {
+		return nil
+	}
+	if k.activeCountLocked() <= 1 {
+		return ErrLastActiveKey
+	}
+
+	now := k.now()
+	if err := k.store.RetireKey(kid, now); err != nil {
+		return fmt.Errorf("keyring: retirar clave: %w", err)
+	}
+	target.retiredAt = &now
+	if k.primary == kid {
+		k.electPrimaryLocked()
+	}
+	log.Printf("keyring: clave retirada kid=%s", kid)
+	return nil
+}
+
+// RetireOlderThan retira las claves no primarias creadas antes de maxAge
+func (k *Keyring) RetireOlderThan(maxAge time.Duration) {
+	cutoff := k.now().Add(-maxAge)
+	for _, info := range k.Keys() {
+		if info.Primary || info.RetiredAt != nil || info.CreatedAt.After(cutoff) {
+			continue
+		}
+		if err := k.Retire(info.KID); err != nil {
+			log.Printf("keyring: no se pudo retirar kid=%s: %v", info.KID, err)
+		}
+	}
+}
+
+// Sign firma el payload con la clave primaria y devuelve el kid usado
+func (k *Keyring) Sign(payload []byte) (string, string, error) {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+
+	primary, ok := k.keys[k.primary]
+	if !ok {
+		return "", "", ErrNoActiveKey
+	}
+	return primary.kid, mac(primary.secret, payload), nil
+}
+
+// Verify acepta la firma si corresponde a cualquier clave activa con ese kid
+func (k *Keyring) Verify(kid string, payload []byte, signature string) bool {
+	candidate, ok := k.lookup(kid)
+	if !ok || candidate.retiredAt != nil {
+		return false
+	}
+	expected := mac(candidate.secret, payload)
+	return hmac.Equal([]byte(expected), []byte(signature))
+}
+
+// lookup busca el kid y recarga el store si es desconocido (p. ej. rotado en otra réplica)
+func (k *Keyring) lookup(kid string) (*key, bool) {
+	k.mu.RLock()
+	candidate, ok := k.keys[kid]
+	stale := k.now().Sub(k.lastLoad) > reloadCooldown
+	k.mu.RUnlock()
+	if ok || !stale {
+		return candidate, ok
+	}
+
+	if err := k.Load(); err != nil {
+		log.Printf("keyring: recarga fallida: %v", err)
+		return nil, false
+	}
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+	candidate, ok = k.keys[kid]
+	return candidate, ok
+}
+
+// primaryAge devuelve la antigüedad de la clave primaria
+func (k *Keyring) primaryAge() time.Duration {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+	if primary, ok := k.keys[k.primary]; ok {
+		return k.now().Sub(primary.createdAt)
+	}
+	return 0
+}
+
+// SignToken produce "<payload>.<kid>.<firma>"
+func (k *Keyring) SignToken(payload string) (string, error) {
+	kid, sig, err := k.Sign([]byte(payload))
+	if err != nil {
+		return "", err
+	}
+	return payload + "." + kid + "." + sig, nil
+}
+
+// VerifyToken valida un token generado por SignToken y devuelve su payload
+func (k *Keyring) VerifyToken(token string) (string, bool) {
+	lastDot := strings.LastIndex(token, ".")
+	if lastDot <= 0 {
+		return "", false
+	}
+	kidDot := strings.LastIndex(token[:lastDot], ".")
+	if kidDot <= 0 {
+		return "", false
+	}
+	payload, kid, sig := token[:kidDot], token[kidDot+1:lastDot], token[lastDot+1:]
+	if !k.Verify(kid, []byte(payload), sig) {
+		return "", false
+	}
+	return payload, true
+}
+
+// Keys lista las claves ordenadas de más nueva a más antigua
+func (k *Keyring) Keys() []KeyInfo {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+
+	out := make([]KeyInfo, 0, len(k.keys))
+	for _, key := range k.keys {
+		out = append(out, KeyInfo{
+			KID:       key.kid,
+			CreatedAt: key.createdAt,
+			RetiredAt: key.retiredAt,
+			Primary:   key.kid == k.primary,
+		})
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].CreatedAt.After(out[j].CreatedAt) })
+	return out
+}
+
+// StartRotation rota la clave primaria cada interval y retira las claves que
+// superen interval+grace, de modo que los artefactos firmados no caduquen de golpe.
+func (k *Keyring) StartRotation(ctx context.Context, interval, grace time.Duration) {
+	if interval <= 0 {
+		return
+	}
+	check := interval / 4
+	if check < time.Second {
+		check = time.Second
+	}
+	go func() {
+		ticker := time.NewTicker(check)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if err := k.Load(); err != nil {
+					log.Printf("keyring: recarga antes de rotar falló: %v", err)
+					continue
+				}
+				if k.primaryAge() < interval {
+					continue
+				}
+				if _, err := k.Rotate(); err != nil {
+					log.Printf("keyring: rotación programada falló: %v", err)
+					continue
+				}
+				k.RetireOlderThan(interval + grace)
+			}
+		}
+	}()
+}
+
+func (k *Keyring) electPrimaryLocked() {
+	k.primary = ""
+	var newest time.Time
+	for _, key := range k.keys {
+		if key.retiredAt != nil {
+			continue
+		}
+		if k.primary == "" || key.createdAt.After(newest) {
+			k.primary = key.kid
+			newest = key.createdAt
+		}
+	}
+}
+
+func (k *Keyring) activeCountLocked() int {
+	n := 0
+	for _, key := range k.keys {
+		if key.retiredAt == nil {
+			n++
+		}
+	}
+	return n
+}
+
+func mac(secret, payload []byte) string {
+	h := hmac.New(sha256.New, secret)
+	h.Write(payload)
+	return base64.RawURLEncoding.EncodeToString(h.Sum(nil))
+}
@@ -0,0 +1,166 @@
+package keyring
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"walkie-backend/internal/models"
+)
+
+type memoryStore struct {
+	keys []models.SigningKey
+}
+
+func (m *memoryStore) LoadKeys() ([]models.SigningKey, error) {
+	return append([]models.SigningKey(nil), m.keys...), nil
+}
+
+func (m *memoryStore) SaveKey(k *models.SigningKey) error {
+	k.CreatedAt = time.Now().Add(time.Duration(len(m.keys)) * time.Millisecond)
+	m.keys = append(m.keys, *k)
+	return nil
+}
+
+func (m *memoryStore) RetireKey(kid string, at time.Time) error {
+	for i := range m.keys {
+		if m.keys[i].KID == kid {
+			m.keys[i].RetiredAt = &at
+			return nil
+		}
+	}
+	return ErrKeyNotFound
+}
+
+func TestKeyring_LoadCreatesPrimary(t *testing.T) {
+	kr := New(&memoryStore{})
+	if err := kr.Load(); err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	keys := kr.Keys()
+	if len(keys) != 1 || !keys[0].Primary {
+		t.Fatalf("expected one primary key, got %+v", keys)
+	}
+}
+
+func TestKeyring_RotationKeepsOldSignaturesValid(t *testing.T) {
+	kr := New(&memoryStore{})
+	if err := kr.Load(); err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+
+	oldToken, err := kr.SignToken("abc")
+	if err != nil {
+		t.Fatalf("SignToken: %v", err)
+	}
+	oldKID := kr.Keys()[0].KID
+
+	if _, err := kr.Rotate(); err != nil {
+		t.Fatalf("Rotate: %v", err)
+	}
+
+	if payload, ok := kr.VerifyToken(oldToken); !ok || payload != "abc" {
+		t.Fatalf("expected old token to remain valid, got %q %v", payload, ok)
+	}
+
+	newToken, _ := kr.SignToken("abc")
+	if newToken == oldToken {
+		t.Fatal("expected new primary key to produce a different signature")
+	}
+
+	if err := kr.Retire(oldKID); err != nil {
+		t.Fatalf("Retire: %v", err)
+	}
+	if _, ok := kr.VerifyToken(oldToken); ok {
+		t.Fatal("expected token signed with retired key to be rejected")
+	}
+	if _, ok := kr.VerifyToken(newToken); !ok {
+		t.Fatal("expected token signed with primary key to be valid")
+	}
+}
+
+func TestKeyring_CannotRetireLastKey(t *testing.T) {
+	kr := New(&memoryStore{})
+	_ = kr.Load()
+	kid := kr.Keys()[0].KID
+
+	if err := kr.Retire(kid); !errors.Is(err, ErrLastActiveKey) {
+		t.Fatalf("expected ErrLastActiveKey, got %v", err)
+	}
+	if err := kr.Retire("missing"); !errors.Is(err, ErrKeyNotFound) {
+		t.Fatalf("expected ErrKeyNotFound, got %v", err)
+	}
+}
+
+func TestKeyring_VerifyTokenRejectsTampering(t *testing.T) {
+	kr := New(&memoryStore{})
+	_ = kr.Load()
+
+	token, _ := kr.SignToken("payload")
+	if _, ok := kr.VerifyToken("otro" + token[len("payload"):]); ok {
+		t.Fatal("expected tampered payload to be rejected")
+	}
+	if _, ok := kr.VerifyToken("sin-firma"); ok {
+		t.Fatal("expected malformed token to be rejected")
+	}
+}
+
+func TestKeyring_RetireOlderThan(t *testing.T) {
+	store := &memoryStore{}
+	kr := New(store)
+	_ = kr.Load()
+	kr.now = func() time.Time { return time.Now().Add(2 * time.Hour) }
+	if _, err := kr.Rotate(); err != nil {
+		t.Fatalf("Rotate: %v", err)
+	}
+
+	kr.RetireOlderThan(time.Hour)
+
+	active := 0
+	for _, k := range kr.Keys() {
+		if k.RetiredAt == nil {
+			active++
+		}
+	}
+	if active != 1 {
+		t.Fatalf("expected only the primary key to remain active, got %d", active)
+	}
+}
+
+func TestKeyring_JWTRoundTripAcrossRotation(t *testing.T) {
+	kr := New(&memoryStore{})
+	if err := kr.Load(); err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+
+	type claims struct {
+		Sub string `json:"sub"`
+		Exp int64  `json:"exp"`
+	}
+	token, err := kr.SignJWT(claims{Sub: "7", Exp: 123})
+	if err != nil {
+		t.Fatalf("SignJWT: %v", err)
+	}
+	if !LooksLikeJWT(token) {
+		t.Fatalf("token no parece JWT: %s", token)
+	}
+
+	if _, err := kr.Rotate(); err != nil {
+		t.Fatalf("Rotate: %v", err)
+	}
+	var got claims
+	if err := kr.VerifyJWT(token, &got); err != nil {
+		t.Fatalf("VerifyJWT tras rotar: %v", err)
+	}
+	if got.Sub != "7" || got.Exp != 123 {
+		t.Fatalf("claims inesperados: %+v", got)
+	}
+
+	tampered := token[:len(token)-2] + "xx"
+	if err := kr.VerifyJWT(tampered, &got); !errors.Is(err, ErrInvalidJWT) {
+		t.Fatalf("se esperaba ErrInvalidJWT, got %v", err)
+	}
+	if LooksLikeJWT("abcd.kid.sig") {
+		t.Fatal("un token opaco no debe parecer JWT")
+	}
+}
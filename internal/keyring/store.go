@@ -0,0 +1,68 @@
+package keyring
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"walkie-backend/internal/config"
+	"walkie-backend/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// GormStore guarda las claves en la tabla signing_keys
+type GormStore struct {
+	DB *gorm.DB
+}
+
+func (s GormStore) LoadKeys() ([]models.SigningKey, error) {
+	var keys []models.SigningKey
+	err := s.DB.Order("created_at ASC").Find(&keys).Error
+	return keys, err
+}
+
+func (s GormStore) SaveKey(k *models.SigningKey) error {
+	return s.DB.Create(k).Error
+}
+
+func (s GormStore) RetireKey(kid string, at time.Time) error {
+	return s.DB.Model(&models.SigningKey{}).Where("kid = ?", kid).Update("retired_at", at).Error
+}
+
+var (
+	defaultMu      sync.Mutex
+	defaultKeyring *Keyring
+)
+
+// Default devuelve el keyring compartido respaldado por config.DB.
+// Si la carga falla se reintenta en la siguiente llamada.
+func Default() (*Keyring, error) {
+	defaultMu.Lock()
+	defer defaultMu.Unlock()
+
+	if defaultKeyring != nil {
+		return defaultKeyring, nil
+	}
+	if config.DB == nil {
+		return nil, config.ErrDBUnavailable
+	}
+
+	kr := New(GormStore{DB: config.DB})
+	if err := kr.Load(); err != nil {
+		return nil, err
+	}
+	defaultKeyring = kr
+
+	interval := config.OptionalDurationFromEnv("KEY_ROTATION_INTERVAL", 0)
+	grace := config.OptionalDurationFromEnv("KEY_ROTATION_GRACE", 24*time.Hour)
+	kr.StartRotation(context.Background(), interval, grace)
+	return kr, nil
+}
+
+// SetDefault reemplaza el keyring compartido (nil fuerza una nueva carga)
+func SetDefault(k *Keyring) {
+	defaultMu.Lock()
+	defaultKeyring = k
+	defaultMu.Unlock()
+}
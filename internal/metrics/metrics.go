@@ -0,0 +1,230 @@
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Registry guarda contadores y tiempos en memoria y los expone en formato de texto de Prometheus
+type Registry struct {
+	mu       sync.Mutex
+	counters map[string]*series
+	timings  map[string]*series
+	gauges   map[string]*series
+}
+
+type series struct {
+	name   string
+	labels string
+	// labelSet conserva las etiquetas para poder agrupar por una de ellas
+	labelSet map[string]string
+	value    float64
+	count    uint64
+	sum      float64
+	max      float64
+}
+
+var defaultRegistry = NewRegistry()
+
+func NewRegistry() *Registry {
+	return &Registry{
+		counters: make(map[string]*series),
+		timings:  make(map[string]*series),
+		gauges:   make(map[string]*series),
+	}
+}
+
+// Default devuelve el registro global del proceso
+func Default() *Registry {
+	return defaultRegistry
+}
+
+// Inc incrementa un contador en el registro global
+func Inc(name string, labels map[string]string) {
+	defaultRegistry.Add(name, labels, 1)
+}
+
+// Observe registra una duración en el registro global
+func Observe(name string, labels map[string]string, d time.Duration) {
+	defaultRegistry.Observe(name, labels, d)
+}
+
+// SetGauge fija el valor actual de un indicador en el registro global
+func SetGauge(name string, labels map[string]string, v float64) {
+	defaultRegistry.SetGauge(name, labels, v)
+}
+
+func (r *Registry) Add(name string, labels map[string]string, delta float64) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.get(r.counters, name, labels).value += delta
+}
+
+func (r *Registry) SetGauge(name string, labels map[string]string, v float64) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.get(r.gauges, name, labels).value = v
+}
+
+func (r *Registry) Observe(name string, labels map[string]string, d time.Duration) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	s := r.get(r.timings, name, labels)
+	secs := d.Seconds()
+	s.count++
+	s.sum += secs
+	if secs > s.max {
+		s.max = secs
+	}
+}
+
+// Counter devuelve el valor actual de un contador (útil en tests y en el panel de administración)
+func (r *Registry) Counter(name string, labels map[string]string) float64 {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if s, ok := r.counters[key(name, labels)]; ok {
+		return s.value
+	}
+	return 0
+}
+
+// Timing devuelve cantidad y suma en segundos de una serie de tiempos
+func (r *Registry) Timing(name string, labels map[string]string) (uint64, float64) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if s, ok := r.timings[key(name, labels)]; ok {
+		return s.count, s.sum
+	}
+	return 0, 0
+}
+
+// TimingStat resume una serie de tiempos
+type TimingStat struct {
+	Count uint64
+	Sum   float64
+	Max   float64
+}
+
+// Avg es la media en segundos, o 0 sin observaciones
+func (t TimingStat) Avg() float64 {
+	if t.Count == 0 {
+		return 0
+	}
+	return t.Sum / float64(t.Count)
+}
+
+// TimingsByLabel agrupa las series de tiempos de name por el valor de la etiqueta label
+func (r *Registry) TimingsByLabel(name, label string) map[string]TimingStat {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	out := make(map[string]TimingStat)
+	for _, s := range r.timings {
+		if s.name != name {
+			continue
+		}
+		v := s.labelSet[label]
+		t := out[v]
+		t.Count += s.count
+		t.Sum += s.sum
+		if s.max > t.Max {
+			t.Max = s.max
+		}
+		out[v] = t
+	}
+	return out
+}
+
+// CountersByLabel suma los contadores de name agrupados por el valor de la etiqueta label
+func (r *Registry) CountersByLabel(name, label string) map[string]float64 {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	out := make(map[string]float64)
+	for _, s := range r.counters {
+		if s.name == name {
+			out[s.labelSet[label]] += s.value
+		}
+	}
+	return out
+}
+
+func (r *Registry) get(m map[string]*series, name string, labels map[string]string) *series {
+	k := key(name, labels)
+	s, ok := m[k]
+	if !ok {
+		s = &series{name: name, labels: formatLabels(labels), labelSet: copyLabels(labels)}
+		m[k] = s
+	}
+	return s
+}
+
+// WritePrometheus escribe todas las series ordenadas por nombre
+func (r *Registry) WritePrometheus(w io.Writer) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, s := range sortedSeries(r.counters) {
+		fmt.Fprintf(w, "%s%s %g\n", s.name, s.labels, s.value)
+	}
+	for _, s := range sortedSeries(r.gauges) {
+		fmt.Fprintf(w, "%s%s %g\n", s.name, s.labels, s.value)
+	}
+	for _, s := range sortedSeries(r.timings) {
+		fmt.Fprintf(w, "%s_count%s %d\n", s.name, s.labels, s.count)
+		fmt.Fprintf(w, "%s_sum_seconds%s %g\n", s.name, s.labels, s.sum)
+		fmt.Fprintf(w, "%s_max_seconds%s %g\n", s.name, s.labels, s.max)
+	}
+}
+
+// Handler expone el registro global en GET /metrics
+func Handler(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	defaultRegistry.WritePrometheus(w)
+}
+
+func sortedSeries(m map[string]*series) []*series {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	out := make([]*series, 0, len(keys))
+	for _, k := range keys {
+		out = append(out, m[k])
+	}
+	return out
+}
+
+func key(name string, labels map[string]string) string {
+	return name + formatLabels(labels)
+}
+
+func copyLabels(labels map[string]string) map[string]string {
+	out := make(map[string]string, len(labels))
+	for k, v := range labels {
+		out[k] = v
+	}
+	return out
+}
+
+func formatLabels(labels map[string]string) string {
+	if len(labels) == 0 {
+		return ""
+	}
+	names := make([]string, 0, len(labels))
+	for k := range labels {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+
+	parts := make([]string, 0, len(names))
+	for _, k := range names {
+		v := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(labels[k])
+		parts = append(parts, fmt.Sprintf(`%s="%s"`, k, v))
+	}
+	return "{" + strings.Join(parts, ",") + "}"
+}
@@ -0,0 +1,72 @@
+package metrics
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRegistry_WritePrometheus(t *testing.T) {
+	r := NewRegistry()
+	r.Add("walkie_requests_total", map[string]string{"route": "/auth"}, 1)
+	r.Add("walkie_requests_total", map[string]string{"route": "/auth"}, 2)
+	r.SetGauge("walkie_ws_clients", nil, 4)
+	r.Observe("walkie_db_query", map[string]string{"op": "query", "table": "users"}, 250*time.Millisecond)
+	r.Observe("walkie_db_query", map[string]string{"op": "query", "table": "users"}, 750*time.Millisecond)
+
+	var buf bytes.Buffer
+	r.WritePrometheus(&buf)
+	out := buf.String()
+
+	for _, want := range []string{
+		`walkie_requests_total{route="/auth"} 3`,
+		`walkie_ws_clients 4`,
+		`walkie_db_query_count{op="query",table="users"} 2`,
+		`walkie_db_query_sum_seconds{op="query",table="users"} 1`,
+		`walkie_db_query_max_seconds{op="query",table="users"} 0.75`,
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected output to contain %q\n%s", want, out)
+		}
+	}
+}
+
+func TestRegistry_Accessors(t *testing.T) {
+	r := NewRegistry()
+	r.Add("c", map[string]string{"a": "1"}, 5)
+	if got := r.Counter("c", map[string]string{"a": "1"}); got != 5 {
+		t.Fatalf("expected 5, got %v", got)
+	}
+	r.Observe("t", nil, time.Second)
+	if count, sum := r.Timing("t", nil); count != 1 || sum != 1 {
+		t.Fatalf("unexpected timing %d %v", count, sum)
+	}
+}
+
+func TestFormatLabels_Escapes(t *testing.T) {
+	got := formatLabels(map[string]string{"q": `a"b`})
+	if got != `{q="a\"b"}` {
+		t.Fatalf("unexpected labels %s", got)
+	}
+}
+
+func TestRegistry_GroupByLabel(t *testing.T) {
+	r := NewRegistry()
+	r.Observe("stage", map[string]string{"stage": "stt", "node": "a"}, time.Second)
+	r.Observe("stage", map[string]string{"stage": "stt", "node": "b"}, 3*time.Second)
+	r.Observe("stage", map[string]string{"stage": "ai"}, 500*time.Millisecond)
+	r.Add("results", map[string]string{"result": "error"}, 1)
+	r.Add("results", map[string]string{"result": "ok"}, 3)
+
+	timings := r.TimingsByLabel("stage", "stage")
+	if stt := timings["stt"]; stt.Count != 2 || stt.Avg() != 2 || stt.Max != 3 {
+		t.Fatalf("unexpected stt timing %+v", stt)
+	}
+	if timings["ai"].Count != 1 {
+		t.Fatalf("unexpected timings %+v", timings)
+	}
+	if counters := r.CountersByLabel("results", "result"); counters["ok"] != 3 || counters["error"] != 1 {
+		t.Fatalf("unexpected counters %+v", counters)
+	}
+}
@@ -0,0 +1,42 @@
+package models
+
+import (
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Announcement es un clip programado que se difunde en uno o varios canales, una vez o
+// cada IntervalSeconds
+type Announcement struct {
+	gorm.Model
+	// Channels son los códigos de canal separados por comas
+	Channels string `gorm:"size:1024;not null"`
+	Text     string `gorm:"type:text"`
+	Audio    []byte `gorm:"not null"`
+	Format   string `gorm:"size:64;not null"`
+	Priority string `gorm:"size:16;not null;default:normal"`
+	// NextRunAt es la próxima emisión; nil cuando ya no quedan
+	NextRunAt       *time.Time `gorm:"index"`
+	IntervalSeconds int64
+	LastRunAt       *time.Time
+	Runs            int    `gorm:"not null;default:0"`
+	CreatedBy       string `gorm:"size:255"`
+}
+
+// ChannelCodes devuelve los canales del anuncio
+func (a *Announcement) ChannelCodes() []string {
+	var codes []string
+	for _, c := range strings.Split(a.Channels, ",") {
+		if c = strings.TrimSpace(c); c != "" {
+			codes = append(codes, c)
+		}
+	}
+	return codes
+}
+
+// Interval es el periodo de repetición; cero si sólo se emite una vez
+func (a *Announcement) Interval() time.Duration {
+	return time.Duration(a.IntervalSeconds) * time.Second
+}
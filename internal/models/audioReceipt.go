@@ -0,0 +1,15 @@
+package models
+
+import "time"
+
+// AudioReceipt sigue, por destinatario, si una transmisión se entregó y se reprodujo
+type AudioReceipt struct {
+	ID             uint      `gorm:"primarykey"`
+	CreatedAt      time.Time `gorm:"index;not null"`
+	TransmissionID string    `gorm:"size:32;uniqueIndex:idx_receipt_transmission_recipient,priority:1;not null"`
+	RecipientID    uint      `gorm:"uniqueIndex:idx_receipt_transmission_recipient,priority:2;not null"`
+	SenderID       uint      `gorm:"index;not null"`
+	Channel        string    `gorm:"size:64"`
+	DeliveredAt    *time.Time
+	PlayedAt       *time.Time
+}
@@ -0,0 +1,19 @@
+package models
+
+import "gorm.io/gorm"
+
+// AuditEntry registra un cambio administrativo, de estado de canal o un comando de voz
+type AuditEntry struct {
+	gorm.Model
+	Actor    string `gorm:"size:255;index"`
+	Action   string `gorm:"size:64;index;not null"`
+	UserID   *uint  `gorm:"index"`
+	Channel  string `gorm:"size:64;index"`
+	Details  string `gorm:"type:text"`
+	Source   string `gorm:"size:32"`
+	Outcome  string `gorm:"size:32"`
+	ErrorMsg string `gorm:"size:512"`
+	// Intent y LatencyMs sólo se rellenan en los comandos de voz (Action "command")
+	Intent    string `gorm:"size:64;index"`
+	LatencyMs int64
+}
@@ -2,13 +2,29 @@ package models
 
 import "gorm.io/gorm"
 
+const (
+	ChannelKindStandard = "standard"
+	ChannelKindEcho     = "echo"
+)
+
 type Channel struct {
 	gorm.Model
-	Code      string              `gorm:"uniqueIndex;not null"`
-	Name      string              `gorm:"not null"`
-	MaxUsers  int                 `gorm:"default:100"`
-	IsPrivate bool                `gorm:"default:false"`
-	Members   []ChannelMembership `gorm:"foreignKey:ChannelID"`
+	Code      string `gorm:"uniqueIndex;not null"`
+	Name      string `gorm:"not null"`
+	MaxUsers  int    `gorm:"default:100"`
+	IsPrivate bool   `gorm:"default:false"`
+	Kind      string `gorm:"size:16;default:standard"`
+	// Assistant activa el asistente de IA que responde en el canal cuando se le nombra
+	Assistant bool `gorm:"not null;default:false"`
+	// DialInCode son los dígitos que marca quien llama por teléfono para entrar al canal;
+	// vacío no admite llamadas
+	DialInCode string              `gorm:"size:16;index"`
+	Members    []ChannelMembership `gorm:"foreignKey:ChannelID"`
+}
+
+// IsEcho indica si el canal devuelve cada clip a quien lo envió
+func (c *Channel) IsEcho() bool {
+	return c != nil && c.Kind == ChannelKindEcho
 }
 
 // GetActiveMembers obtiene los miembros activos del canal
@@ -0,0 +1,14 @@
+package models
+
+import "time"
+
+// ChannelAlias es el nombre propio que un usuario le da a un canal ("obra norte" -> canal-3).
+// Cada usuario tiene como mucho un alias por canal y no puede repetir un alias en dos canales.
+type ChannelAlias struct {
+	ID          uint `gorm:"primarykey"`
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+	UserID      uint   `gorm:"not null;uniqueIndex:idx_channel_alias_user_channel,priority:1;uniqueIndex:idx_channel_alias_user_alias,priority:1"`
+	ChannelCode string `gorm:"size:64;not null;uniqueIndex:idx_channel_alias_user_channel,priority:2"`
+	Alias       string `gorm:"size:64;not null;uniqueIndex:idx_channel_alias_user_alias,priority:2"`
+}
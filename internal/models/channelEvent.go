@@ -0,0 +1,29 @@
+package models
+
+import "time"
+
+const (
+	ChannelEventConnect        = "connect"
+	ChannelEventDisconnect     = "disconnect"
+	ChannelEventMove           = "move"
+	ChannelEventKick           = "kick"
+	ChannelEventAutoDisconnect = "auto_disconnect"
+
+	EventSourceVoice  = "voice"
+	EventSourceHTTP   = "http"
+	EventSourceSystem = "system"
+)
+
+// ChannelEvent es un registro inmutable de un cambio de canal de un usuario.
+// No usa gorm.Model: los eventos nunca se actualizan ni se borran.
+type ChannelEvent struct {
+	ID          uint      `gorm:"primarykey"`
+	CreatedAt   time.Time `gorm:"index;not null"`
+	UserID      uint      `gorm:"index;not null"`
+	Type        string    `gorm:"size:32;not null"`
+	FromChannel string    `gorm:"size:64"`
+	ToChannel   string    `gorm:"size:64"`
+	Actor       string    `gorm:"size:255"`
+	Source      string    `gorm:"size:16"`
+	RequestID   string    `gorm:"size:64;index"`
+}
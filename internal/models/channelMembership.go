@@ -6,6 +6,13 @@ import (
 	"gorm.io/gorm"
 )
 
+// Roles de un usuario dentro de un canal
+const (
+	ChannelRoleOwner     = "owner"
+	ChannelRoleModerator = "moderator"
+	ChannelRoleMember    = "member"
+)
+
 type ChannelMembership struct {
 	gorm.Model
 	UserID    uint      `gorm:"index;not null"`
@@ -15,6 +22,9 @@ type ChannelMembership struct {
 	Active    bool      `gorm:"default:true;index"`
 	JoinedAt  time.Time `gorm:"default:CURRENT_TIMESTAMP"`
 	LeftAt    *time.Time
+	Role      string `gorm:"size:16;not null;default:member"`
+	// MutedUntil impide hablar en el canal hasta esa hora (silencio automático por abuso)
+	MutedUntil *time.Time
 }
 
 // Activate marca la membresía como activa
@@ -29,3 +39,13 @@ func (cm *ChannelMembership) Deactivate() {
 	now := time.Now()
 	cm.LeftAt = &now
 }
+
+// CanModerate indica si el rol permite expulsar usuarios del canal
+func (cm *ChannelMembership) CanModerate() bool {
+	return cm.Role == ChannelRoleOwner || cm.Role == ChannelRoleModerator
+}
+
+// IsSpeakMuted indica si el usuario tiene prohibido hablar en el canal en ese momento
+func (cm *ChannelMembership) IsSpeakMuted(now time.Time) bool {
+	return cm.MutedUntil != nil && now.Before(*cm.MutedUntil)
+}
@@ -30,3 +30,17 @@ func TestChannelMembership_ActivateDeactivate(t *testing.T) {
 		t.Errorf("expected LeftAt to be nil after Activate")
 	}
 }
+
+func TestChannelMembership_CanModerate(t *testing.T) {
+	for role, want := range map[string]bool{
+		ChannelRoleOwner:     true,
+		ChannelRoleModerator: true,
+		ChannelRoleMember:    false,
+		"":                   false,
+	} {
+		m := ChannelMembership{Role: role}
+		if got := m.CanModerate(); got != want {
+			t.Errorf("role %q: expected CanModerate=%t, got %t", role, want, got)
+		}
+	}
+}
@@ -0,0 +1,16 @@
+package models
+
+import "time"
+
+// MaxChannelMessageLength limita los mensajes de texto a frases cortas, como en la radio
+const MaxChannelMessageLength = 500
+
+// ChannelMessage es un mensaje de texto enviado a un canal junto al audio
+type ChannelMessage struct {
+	ID          uint      `gorm:"primarykey"`
+	CreatedAt   time.Time `gorm:"not null"`
+	ChannelCode string    `gorm:"size:64;index:idx_message_channel_id,priority:1;not null"`
+	SenderID    uint      `gorm:"index;not null"`
+	SenderName  string    `gorm:"size:255"`
+	Text        string    `gorm:"size:500;not null"`
+}
@@ -0,0 +1,13 @@
+package models
+
+import "time"
+
+// ChannelMute indica que UserID no quiere oír a MutedUserID en un canal. Sólo afecta a la
+// entrega del audio del silenciado a quien lo silenció.
+type ChannelMute struct {
+	ID          uint `gorm:"primarykey"`
+	CreatedAt   time.Time
+	ChannelCode string `gorm:"size:64;not null;uniqueIndex:idx_channel_mute,priority:1"`
+	MutedUserID uint   `gorm:"not null;uniqueIndex:idx_channel_mute,priority:2"`
+	UserID      uint   `gorm:"not null;uniqueIndex:idx_channel_mute,priority:3"`
+}
@@ -0,0 +1,27 @@
+package models
+
+import "time"
+
+// ChannelRecording es una sesión de grabación de un canal; StoppedAt es nil mientras graba
+type ChannelRecording struct {
+	ID          uint      `gorm:"primarykey"`
+	ChannelCode string    `gorm:"size:64;index;not null"`
+	StartedAt   time.Time `gorm:"not null"`
+	StoppedAt   *time.Time
+	StartedBy   string `gorm:"size:64"`
+}
+
+// IsActive indica si la grabación sigue en curso
+func (r *ChannelRecording) IsActive() bool {
+	return r.StoppedAt == nil
+}
+
+// RecordingClip es una transmisión del canal añadida a una grabación
+type RecordingClip struct {
+	ID          uint      `gorm:"primarykey"`
+	RecordingID uint      `gorm:"index;not null"`
+	CreatedAt   time.Time `gorm:"not null"`
+	SenderID    uint      `gorm:"not null"`
+	Duration    float64
+	Data        []byte `gorm:"not null"`
+}
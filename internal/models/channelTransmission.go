@@ -0,0 +1,30 @@
+package models
+
+import "time"
+
+// ChannelTransmission registra cada mensaje difundido en un canal para que quien
+// llegue tarde pueda escuchar los más recientes
+type ChannelTransmission struct {
+	ID          uint      `gorm:"primarykey"`
+	CreatedAt   time.Time `gorm:"index:idx_transmission_channel_time,priority:2;not null"`
+	ChannelCode string    `gorm:"size:64;index:idx_transmission_channel_time,priority:1;not null"`
+	SenderID    uint      `gorm:"index;not null"`
+	Duration    float64
+	Format      string `gorm:"size:16"`
+	Priority    string `gorm:"size:16"`
+	SizeBytes   int
+	AudioBlobID *uint
+	// AudioKey es la clave del audio en el almacenamiento de objetos, si se usa
+	AudioKey string `gorm:"size:255"`
+}
+
+// HasAudio indica si se guardó el audio de la transmisión
+func (t *ChannelTransmission) HasAudio() bool {
+	return t.AudioBlobID != nil || t.AudioKey != ""
+}
+
+// TransmissionBlob guarda el audio de una transmisión aparte del historial
+type TransmissionBlob struct {
+	ID   uint   `gorm:"primarykey"`
+	Data []byte `gorm:"not null"`
+}
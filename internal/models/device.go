@@ -0,0 +1,19 @@
+package models
+
+import "time"
+
+const (
+	DevicePlatformAndroid = "android"
+	DevicePlatformIOS     = "ios"
+	DevicePlatformWeb     = "web"
+)
+
+// Device es un dispositivo del usuario registrado para recibir notificaciones push
+type Device struct {
+	ID        uint   `gorm:"primarykey"`
+	UserID    uint   `gorm:"index;not null"`
+	Token     string `gorm:"uniqueIndex;size:512;not null"`
+	Platform  string `gorm:"size:16"`
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
@@ -0,0 +1,12 @@
+package models
+
+import "time"
+
+// FavoriteChannel es un canal que el usuario marcó como favorito; los favoritos se
+// nombran primero al listar canales
+type FavoriteChannel struct {
+	ID          uint `gorm:"primarykey"`
+	CreatedAt   time.Time
+	UserID      uint   `gorm:"not null;uniqueIndex:idx_favorite_user_channel,priority:1"`
+	ChannelCode string `gorm:"size:64;not null;uniqueIndex:idx_favorite_user_channel,priority:2"`
+}
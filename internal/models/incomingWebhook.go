@@ -0,0 +1,21 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// IncomingWebhook es una integración externa (alarmas, CI...) que puede hablar en un
+// canal con POST /integrations/webhooks/{token}. Sólo se guarda el hash del token.
+type IncomingWebhook struct {
+	gorm.Model
+	Name      string `gorm:"size:128;not null"`
+	TokenHash string `gorm:"size:64;uniqueIndex;not null"`
+	Channel   string `gorm:"size:64;not null"`
+	// Priority es la prioridad de sus avisos si la petición no indica otra
+	Priority   string `gorm:"size:16;not null;default:normal"`
+	CreatedBy  string `gorm:"size:255"`
+	LastUsedAt *time.Time
+	Uses       int `gorm:"not null;default:0"`
+}
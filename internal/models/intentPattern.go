@@ -0,0 +1,14 @@
+package models
+
+import "time"
+
+// IntentPattern es una frase que activa un comando de voz, configurable por los
+// operadores sin recompilar
+type IntentPattern struct {
+	ID        uint `gorm:"primarykey"`
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	Intent    string `gorm:"size:64;not null"`
+	Phrase    string `gorm:"size:255;not null;uniqueIndex:idx_intent_pattern_phrase"`
+	Language  string `gorm:"size:8;not null;default:es;uniqueIndex:idx_intent_pattern_phrase"`
+}
@@ -0,0 +1,40 @@
+package models
+
+import "time"
+
+// QueuedAudio es un clip pendiente de entrega guardado en base de datos para que
+// la cola sobreviva a reinicios y se comparta entre réplicas
+type QueuedAudio struct {
+	ID          uint   `gorm:"primarykey"`
+	RecipientID uint   `gorm:"index:idx_queued_audio_recipient,priority:1;not null"`
+	Urgent      bool   `gorm:"index:idx_queued_audio_recipient,priority:2;not null;default:false"`
+	SenderID    uint   `gorm:"not null"`
+	Channel     string `gorm:"size:64;not null"`
+	AudioData   []byte `gorm:"not null"`
+	Duration    float64
+	SampleRate  int
+	Format      string    `gorm:"size:16"`
+	Priority    string    `gorm:"size:16"`
+	Direct      bool      `gorm:"not null;default:false"`
+	EnqueuedAt  time.Time `gorm:"index;not null"`
+	// Attempts cuenta las entregas sin ACK antes de volver a encolarlo
+	Attempts int `gorm:"not null;default:0"`
+	// ObjectKey apunta al audio en el almacenamiento de objetos; entonces AudioData va vacío
+	ObjectKey string `gorm:"size:255"`
+	// BlobKey apunta al audio compartido en queued_audio_blobs; entonces AudioData va vacío
+	BlobKey   string `gorm:"size:64;index"`
+	SizeBytes int
+	// TransmissionID enlaza el clip con sus confirmaciones de escucha
+	TransmissionID string `gorm:"size:32"`
+	// DeliveryID y LeaseUntil marcan un clip entregado por polling con ACK: queda oculto
+	// hasta LeaseUntil y se borra al confirmarse; si el ACK no llega vuelve a entregarse
+	DeliveryID string     `gorm:"size:32;index"`
+	LeaseUntil *time.Time `gorm:"index"`
+}
+
+// QueuedAudioBlob guarda una sola vez el audio de un clip repartido a varios
+// destinatarios; sus filas de queued_audios lo referencian por BlobKey
+type QueuedAudioBlob struct {
+	BlobKey string `gorm:"primaryKey;size:64"`
+	Data    []byte `gorm:"not null"`
+}
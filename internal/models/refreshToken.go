@@ -0,0 +1,20 @@
+package models
+
+import "time"
+
+// RefreshToken guarda el hash de un token de refresco; el valor en claro sólo lo tiene el cliente
+type RefreshToken struct {
+	ID        uint      `gorm:"primarykey"`
+	UserID    uint      `gorm:"index;not null"`
+	TokenHash string    `gorm:"uniqueIndex;size:64;not null"`
+	ExpiresAt time.Time `gorm:"not null"`
+	RevokedAt *time.Time
+	CreatedAt time.Time
+	// SessionID es la sesión que lo emitió; revocarla revoca también este token
+	SessionID *uint `gorm:"index"`
+}
+
+// IsUsable indica si el token sigue sin revocar ni caducar
+func (t *RefreshToken) IsUsable(now time.Time) bool {
+	return t.RevokedAt == nil && now.Before(t.ExpiresAt)
+}
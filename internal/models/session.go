@@ -0,0 +1,23 @@
+package models
+
+import "time"
+
+// Session es un dispositivo con la sesión abierta. Cada inicio de sesión crea una, así
+// que un usuario puede estar conectado desde varios dispositivos a la vez. Del token
+// opaco sólo se guarda el hash.
+type Session struct {
+	ID         uint   `gorm:"primarykey"`
+	UserID     uint   `gorm:"index;not null"`
+	TokenHash  string `gorm:"uniqueIndex;size:64;not null"`
+	DeviceName string `gorm:"size:100"`
+	Platform   string `gorm:"size:16"`
+	UserAgent  string `gorm:"size:255"`
+	CreatedAt  time.Time
+	LastSeenAt time.Time
+	RevokedAt  *time.Time
+}
+
+// IsActive indica si la sesión no se ha revocado
+func (s *Session) IsActive() bool {
+	return s.RevokedAt == nil
+}
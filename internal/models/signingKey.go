@@ -0,0 +1,20 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// SigningKey es una clave HMAC del keyring usada para firmar tokens, enlaces y webhooks
+type SigningKey struct {
+	gorm.Model
+	KID       string `gorm:"uniqueIndex;size:32;not null"`
+	Secret    string `gorm:"size:128;not null"`
+	RetiredAt *time.Time
+}
+
+// IsActive indica si la clave sigue aceptándose para verificar firmas
+func (k *SigningKey) IsActive() bool {
+	return k.RetiredAt == nil
+}
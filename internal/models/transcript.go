@@ -0,0 +1,14 @@
+package models
+
+import "time"
+
+// Transcript guarda lo que se dijo en cada transmisión de un canal
+type Transcript struct {
+	ID          uint      `gorm:"primarykey"`
+	CreatedAt   time.Time `gorm:"index:idx_transcript_channel_time,priority:2;not null"`
+	ChannelCode string    `gorm:"size:64;index:idx_transcript_channel_time,priority:1;not null"`
+	SenderID    uint      `gorm:"index;not null"`
+	SenderName  string    `gorm:"size:255"`
+	Text        string    `gorm:"type:text;not null"`
+	Priority    string    `gorm:"size:16"`
+}
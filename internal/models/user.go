@@ -17,6 +17,17 @@ type User struct {
 	Memberships      []ChannelMembership `gorm:"foreignKey:UserID"`
 	PinHash          string              `gorm:"size:255"`
 	AuthToken        string              `gorm:"size:255;index"`
+	TokenVersion     uint                `gorm:"not null;default:0"`
+	Language         string              `gorm:"size:8;not null;default:es"`
+	// PublicKey es la clave pública X25519 (base64) para recibir las claves de canal cifradas
+	PublicKey string `gorm:"size:64"`
+	// PushMuted silencia las notificaciones push salvo emergencias; PushDirectOnly
+	// sólo avisa de los mensajes directos
+	PushMuted      bool `gorm:"not null;default:false"`
+	PushDirectOnly bool `gorm:"not null;default:false"`
+	// DoNotDisturb guarda el audio en la cola sin avisar ni difundirlo por WebSocket,
+	// salvo emergencias
+	DoNotDisturb bool `gorm:"not null;default:false"`
 }
 
 // IsInChannel verifica si el usuario está actualmente en un canal
@@ -24,6 +35,14 @@ func (u *User) IsInChannel() bool {
 	return u.CurrentChannelID != nil
 }
 
+// GetLanguage devuelve el idioma preferido del usuario ("es" si no eligió ninguno)
+func (u *User) GetLanguage() string {
+	if u == nil || u.Language == "" {
+		return "es"
+	}
+	return u.Language
+}
+
 // GetCurrentChannelCode obtiene el código del canal actual
 func (u *User) GetCurrentChannelCode() string {
 	if u.CurrentChannel != nil {
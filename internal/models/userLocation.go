@@ -0,0 +1,14 @@
+package models
+
+import "time"
+
+// UserLocation es la última posición que compartió el usuario (opcional); se usa para
+// saber quién está cerca y sugerir canales
+type UserLocation struct {
+	UserID    uint    `gorm:"primaryKey;autoIncrement:false"`
+	Latitude  float64 `gorm:"not null;index:idx_user_locations_lat_lon"`
+	Longitude float64 `gorm:"not null;index:idx_user_locations_lat_lon"`
+	// Accuracy es el radio de incertidumbre en metros que informa el dispositivo
+	Accuracy  float64
+	UpdatedAt time.Time `gorm:"index"`
+}
@@ -0,0 +1,15 @@
+package models
+
+import "time"
+
+// UserUsage acumula por usuario y día (UTC) lo que cuesta dinero: segundos de
+// transcripción y tokens de IA
+type UserUsage struct {
+	ID         uint `gorm:"primarykey"`
+	UpdatedAt  time.Time
+	UserID     uint    `gorm:"not null;uniqueIndex:idx_user_usage_day,priority:1"`
+	Day        string  `gorm:"size:10;not null;uniqueIndex:idx_user_usage_day,priority:2"`
+	STTSeconds float64 `gorm:"not null;default:0"`
+	AITokens   int     `gorm:"not null;default:0"`
+	Requests   int     `gorm:"not null;default:0"`
+}
@@ -0,0 +1,33 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// WebhookSubscription es un sistema externo al que se avisa por HTTP de lo que pasa en
+// los canales. Events es la lista de tipos separados por comas; Channel vacío recibe
+// los eventos de todos los canales. Secret firma los envíos con HMAC-SHA256.
+type WebhookSubscription struct {
+	gorm.Model
+	URL       string `gorm:"size:512;not null"`
+	Secret    string `gorm:"size:128;not null"`
+	Events    string `gorm:"size:255;not null"`
+	Channel   string `gorm:"size:64;index"`
+	CreatedBy string `gorm:"size:255"`
+}
+
+// WebhookDeadLetter guarda un envío que agotó los reintentos, con el cuerpo tal cual
+// para poder reenviarlo
+type WebhookDeadLetter struct {
+	ID             uint      `gorm:"primarykey"`
+	CreatedAt      time.Time `gorm:"index;not null"`
+	SubscriptionID uint      `gorm:"index;not null"`
+	DeliveryID     string    `gorm:"size:64;not null"`
+	Event          string    `gorm:"size:32;not null"`
+	Payload        []byte    `gorm:"not null"`
+	Attempts       int       `gorm:"not null"`
+	StatusCode     int
+	LastError      string `gorm:"size:512"`
+}
@@ -0,0 +1,22 @@
+package reqctx
+
+import "context"
+
+type requestIDKey struct{}
+
+// WithRequestID asocia el ID de la petición al contexto para logs y trazas
+func WithRequestID(ctx context.Context, id string) context.Context {
+	if id == "" {
+		return ctx
+	}
+	return context.WithValue(ctx, requestIDKey{}, id)
+}
+
+// RequestID devuelve el ID asociado o "" si no hay
+func RequestID(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
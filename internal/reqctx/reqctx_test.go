@@ -0,0 +1,19 @@
+package reqctx
+
+import (
+	"context"
+	"testing"
+)
+
+func TestRequestIDRoundTrip(t *testing.T) {
+	ctx := WithRequestID(context.Background(), "abc123")
+	if got := RequestID(ctx); got != "abc123" {
+		t.Fatalf("expected abc123, got %q", got)
+	}
+	if got := RequestID(context.Background()); got != "" {
+		t.Fatalf("expected empty id, got %q", got)
+	}
+	if WithRequestID(context.Background(), "") != context.Background() {
+		t.Fatal("expected empty id to leave context untouched")
+	}
+}
@@ -0,0 +1,72 @@
+package services
+
+import (
+	"fmt"
+	"time"
+
+	"walkie-backend/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// Acciones de la moderación de lenguaje ofensivo tal como quedan en la auditoría
+const (
+	AbuseActionWarn = "abuse_warn"
+	AbuseActionDrop = "abuse_drop"
+	AbuseActionMute = "abuse_mute"
+)
+
+// CountAbuseOffences cuenta los avisos y descartes por lenguaje ofensivo de userID en el
+// canal desde since
+func CountAbuseOffences(db *gorm.DB, userID uint, channelCode string, since time.Time) (int64, error) {
+	if db == nil {
+		return 0, fmt.Errorf("base de datos no disponible")
+	}
+	var count int64
+	err := db.Model(&models.AuditEntry{}).
+		Where("user_id = ? AND channel = ? AND action IN ? AND created_at >= ?",
+			userID, channelCode, []string{AbuseActionWarn, AbuseActionDrop}, since).
+		Count(&count).Error
+	return count, err
+}
+
+// MuteSpeaker impide a userID hablar en el canal hasta until; sigue escuchando y puede
+// dar órdenes de voz
+func MuteSpeaker(db *gorm.DB, channelCode string, userID uint, until time.Time) error {
+	if db == nil {
+		return fmt.Errorf("base de datos no disponible")
+	}
+	channel, err := findChannel(db, channelCode)
+	if err != nil {
+		return err
+	}
+	res := db.Model(&models.ChannelMembership{}).
+		Where("user_id = ? AND channel_id = ?", userID, channel.ID).
+		Update("muted_until", until)
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return ErrUserNotInChannel
+	}
+	return nil
+}
+
+// SpeakerMutedUntil devuelve hasta cuándo userID no puede hablar en el canal, o nil si puede
+func SpeakerMutedUntil(db *gorm.DB, channelCode string, userID uint, now time.Time) (*time.Time, error) {
+	if db == nil {
+		return nil, fmt.Errorf("base de datos no disponible")
+	}
+	channel, err := findChannel(db, channelCode)
+	if err != nil {
+		return nil, err
+	}
+	membership, err := findMembership(db, userID, channel.ID)
+	if err != nil {
+		return nil, err
+	}
+	if !membership.IsSpeakMuted(now) {
+		return nil, nil
+	}
+	return membership.MutedUntil, nil
+}
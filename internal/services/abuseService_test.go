@@ -0,0 +1,49 @@
+package services
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"walkie-backend/internal/config"
+	"walkie-backend/internal/models"
+)
+
+func TestMuteSpeaker_ExpiresAndCountsOffences(t *testing.T) {
+	cleanup := setupUserServiceTestDB(t)
+	defer cleanup()
+	db := config.DB
+	if err := db.AutoMigrate(&models.AuditEntry{}); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	db.Create(&models.Channel{Code: "canal-1", Name: "Canal 1", MaxUsers: 10})
+	user := models.User{DisplayName: "Bruno", IsActive: true}
+	db.Create(&user)
+
+	now := time.Now()
+	if err := MuteSpeaker(db, "canal-1", user.ID, now.Add(time.Minute)); !errors.Is(err, ErrUserNotInChannel) {
+		t.Fatalf("expected ErrUserNotInChannel, got %v", err)
+	}
+	if err := NewUserService().ConnectUserToChannel(user.ID, "canal-1"); err != nil {
+		t.Fatalf("connect: %v", err)
+	}
+	if err := MuteSpeaker(db, "canal-1", user.ID, now.Add(time.Minute)); err != nil {
+		t.Fatalf("MuteSpeaker: %v", err)
+	}
+	if until, err := SpeakerMutedUntil(db, "canal-1", user.ID, now); err != nil || until == nil {
+		t.Fatalf("expected muted speaker, got %v (%v)", until, err)
+	}
+	if until, _ := SpeakerMutedUntil(db, "canal-1", user.ID, now.Add(2*time.Minute)); until != nil {
+		t.Fatalf("mute should have expired, got %v", until)
+	}
+
+	userID := user.ID
+	for _, action := range []string{AbuseActionWarn, AbuseActionDrop, AbuseActionMute, "command"} {
+		db.Create(&models.AuditEntry{Action: action, UserID: &userID, Channel: "canal-1"})
+	}
+	db.Create(&models.AuditEntry{Action: AbuseActionWarn, UserID: &userID, Channel: "canal-2"})
+	count, err := CountAbuseOffences(db, user.ID, "canal-1", now.Add(-time.Hour))
+	if err != nil || count != 2 {
+		t.Fatalf("expected 2 offences, got %d (%v)", count, err)
+	}
+}
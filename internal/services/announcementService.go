@@ -0,0 +1,127 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"walkie-backend/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// MinAnnouncementInterval evita que un anuncio periódico inunde los canales
+const MinAnnouncementInterval = time.Minute
+
+var (
+	ErrAnnouncementNotFound = errors.New("anuncio no encontrado")
+	ErrInvalidAnnouncement  = errors.New("anuncio inválido")
+)
+
+// AnnouncementService administra los anuncios programados
+type AnnouncementService struct {
+	db *gorm.DB
+}
+
+func NewAnnouncementService(db *gorm.DB) *AnnouncementService {
+	return &AnnouncementService{db: db}
+}
+
+// Create valida los canales, el audio y la programación y guarda el anuncio
+func (s *AnnouncementService) Create(a *models.Announcement) error {
+	codes := a.ChannelCodes()
+	if len(codes) == 0 {
+		return fmt.Errorf("%w: indica al menos un canal", ErrInvalidAnnouncement)
+	}
+	seen := make(map[string]bool, len(codes))
+	unique := codes[:0]
+	for _, code := range codes {
+		code = strings.ToLower(code)
+		if seen[code] {
+			continue
+		}
+		seen[code] = true
+		if _, err := findChannel(s.db, code); err != nil {
+			if errors.Is(err, ErrChannelNotFound) {
+				return fmt.Errorf("%w: el canal %s no existe", ErrInvalidAnnouncement, code)
+			}
+			return err
+		}
+		unique = append(unique, code)
+	}
+	a.Channels = strings.Join(unique, ",")
+
+	if len(a.Audio) == 0 {
+		return fmt.Errorf("%w: falta el audio", ErrInvalidAnnouncement)
+	}
+	if a.IntervalSeconds < 0 || (a.IntervalSeconds > 0 && a.Interval() < MinAnnouncementInterval) {
+		return fmt.Errorf("%w: el intervalo mínimo es %s", ErrInvalidAnnouncement, MinAnnouncementInterval)
+	}
+	if a.NextRunAt == nil {
+		now := time.Now()
+		a.NextRunAt = &now
+	}
+	if err := s.db.Create(a).Error; err != nil {
+		return fmt.Errorf("error creando anuncio: %w", err)
+	}
+	return nil
+}
+
+// List devuelve los anuncios sin el audio, los más recientes primero
+func (s *AnnouncementService) List() ([]models.Announcement, error) {
+	var items []models.Announcement
+	err := s.db.Omit("audio").Order("id DESC").Find(&items).Error
+	if err != nil {
+		return nil, fmt.Errorf("error leyendo anuncios: %w", err)
+	}
+	return items, nil
+}
+
+// Delete cancela un anuncio
+func (s *AnnouncementService) Delete(id uint) error {
+	res := s.db.Delete(&models.Announcement{}, id)
+	if res.Error != nil {
+		return fmt.Errorf("error borrando anuncio: %w", res.Error)
+	}
+	if res.RowsAffected == 0 {
+		return ErrAnnouncementNotFound
+	}
+	return nil
+}
+
+// Due devuelve los anuncios cuya emisión ya toca
+func (s *AnnouncementService) Due(now time.Time) ([]models.Announcement, error) {
+	var items []models.Announcement
+	err := s.db.Where("next_run_at IS NOT NULL AND next_run_at <= ?", now).Order("next_run_at ASC").Find(&items).Error
+	return items, err
+}
+
+// Claim reserva la emisión de a y programa la siguiente. Sólo una réplica lo consigue:
+// la actualización exige que Runs no haya cambiado desde que se leyó
+func (s *AnnouncementService) Claim(a *models.Announcement, now time.Time) (bool, error) {
+	var next *time.Time
+	if interval := a.Interval(); interval > 0 && a.NextRunAt != nil {
+		t := a.NextRunAt.Add(interval)
+		if !t.After(now) {
+			// Tras una parada no se recuperan las emisiones perdidas
+			t = now.Add(interval)
+		}
+		next = &t
+	}
+	res := s.db.Model(&models.Announcement{}).
+		Where("id = ? AND runs = ?", a.ID, a.Runs).
+		Updates(map[string]interface{}{
+			"next_run_at": next,
+			"last_run_at": now,
+			"runs":        a.Runs + 1,
+		})
+	if res.Error != nil {
+		return false, res.Error
+	}
+	if res.RowsAffected == 0 {
+		return false, nil
+	}
+	a.NextRunAt, a.LastRunAt, a.Runs = next, &now, a.Runs+1
+	return true, nil
+}
@@ -0,0 +1,81 @@
+package services
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"walkie-backend/internal/config"
+	"walkie-backend/internal/models"
+)
+
+func TestAnnouncementService_CreateAndClaim(t *testing.T) {
+	cleanup := setupUserServiceTestDB(t)
+	defer cleanup()
+	db := config.DB
+	if err := db.AutoMigrate(&models.Announcement{}); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	db.Create(&models.Channel{Code: "canal-1", Name: "Canal 1", MaxUsers: 10})
+	db.Create(&models.Channel{Code: "canal-2", Name: "Canal 2", MaxUsers: 10})
+	svc := NewAnnouncementService(db)
+
+	for _, a := range []models.Announcement{
+		{Channels: " , ", Audio: []byte("x")},
+		{Channels: "canal-9", Audio: []byte("x")},
+		{Channels: "canal-1"},
+		{Channels: "canal-1", Audio: []byte("x"), IntervalSeconds: 30},
+	} {
+		if err := svc.Create(&a); !errors.Is(err, ErrInvalidAnnouncement) {
+			t.Fatalf("expected ErrInvalidAnnouncement for %+v, got %v", a, err)
+		}
+	}
+
+	start := time.Now().Add(-time.Second)
+	a := models.Announcement{Channels: "Canal-1, canal-2,canal-1", Audio: []byte("x"), Format: "audio/wav", NextRunAt: &start, IntervalSeconds: 3600}
+	if err := svc.Create(&a); err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	if a.Channels != "canal-1,canal-2" {
+		t.Fatalf("unexpected channels %q", a.Channels)
+	}
+
+	now := time.Now()
+	due, err := svc.Due(now)
+	if err != nil || len(due) != 1 {
+		t.Fatalf("expected one due announcement, got %d (%v)", len(due), err)
+	}
+	stale := due[0]
+	ok, err := svc.Claim(&due[0], now)
+	if err != nil || !ok {
+		t.Fatalf("first claim should win: %v %v", ok, err)
+	}
+	if ok, _ := svc.Claim(&stale, now); ok {
+		t.Fatal("a second replica must not claim the same run")
+	}
+	if due[0].NextRunAt == nil || due[0].NextRunAt.Sub(start) != time.Hour {
+		t.Fatalf("expected next run one hour after start, got %v", due[0].NextRunAt)
+	}
+	if due, _ := svc.Due(now); len(due) != 0 {
+		t.Fatalf("nothing should be due until the next run, got %d", len(due))
+	}
+
+	once := models.Announcement{Channels: "canal-2", Audio: []byte("x"), Format: "audio/wav"}
+	if err := svc.Create(&once); err != nil {
+		t.Fatalf("create once: %v", err)
+	}
+	if ok, _ := svc.Claim(&once, time.Now()); !ok || once.NextRunAt != nil {
+		t.Fatalf("one-off announcement should finish after its run: %+v", once)
+	}
+
+	items, err := svc.List()
+	if err != nil || len(items) != 2 || items[0].Audio != nil {
+		t.Fatalf("unexpected list %d (%v)", len(items), err)
+	}
+	if err := svc.Delete(a.ID); err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+	if err := svc.Delete(a.ID); !errors.Is(err, ErrAnnouncementNotFound) {
+		t.Fatalf("expected ErrAnnouncementNotFound, got %v", err)
+	}
+}
@@ -0,0 +1,138 @@
+package services
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"time"
+
+	"walkie-backend/internal/config"
+	"walkie-backend/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// RecordAudit guarda una entrada de auditoría; los errores sólo se registran en el log
+func RecordAudit(db *gorm.DB, entry models.AuditEntry) {
+	if db == nil {
+		db = config.DB
+	}
+	if db == nil {
+		return
+	}
+	if entry.Outcome == "" {
+		entry.Outcome = "ok"
+	}
+	if err := db.Create(&entry).Error; err != nil {
+		log.Printf("No se pudo registrar auditoría action=%s actor=%s: %v", entry.Action, entry.Actor, err)
+	}
+}
+
+const (
+	AuditKindEntry        = "audit"
+	AuditKindChannelEvent = "channel_event"
+
+	defaultAuditLimit = 200
+	MaxAuditLimit     = 1000
+)
+
+// AuditFilter acota la consulta del registro de auditoría; los campos vacíos no filtran
+type AuditFilter struct {
+	UserID  uint
+	Channel string
+	Since   time.Time
+	Limit   int
+}
+
+// AuditRecord es una fila del registro unificado: una entrada de auditoría o un
+// evento de canal (conexión, desconexión, cambio, expulsión)
+type AuditRecord struct {
+	At        time.Time `json:"at"`
+	Kind      string    `json:"kind"`
+	Action    string    `json:"action"`
+	Actor     string    `json:"actor,omitempty"`
+	Source    string    `json:"source,omitempty"`
+	UserID    *uint     `json:"userId,omitempty"`
+	Channel   string    `json:"channel,omitempty"`
+	From      string    `json:"from,omitempty"`
+	To        string    `json:"to,omitempty"`
+	Intent    string    `json:"intent,omitempty"`
+	Outcome   string    `json:"outcome,omitempty"`
+	LatencyMs int64     `json:"latencyMs,omitempty"`
+	Details   string    `json:"details,omitempty"`
+	Error     string    `json:"error,omitempty"`
+	RequestID string    `json:"requestId,omitempty"`
+}
+
+// AuditLog mezcla las entradas de auditoría y los eventos de canal que cumplen el
+// filtro, de la más reciente a la más antigua
+func AuditLog(db *gorm.DB, f AuditFilter) ([]AuditRecord, error) {
+	if db == nil {
+		return nil, fmt.Errorf("base de datos no disponible")
+	}
+	if f.Limit <= 0 || f.Limit > MaxAuditLimit {
+		f.Limit = defaultAuditLimit
+	}
+
+	entriesQ := db.Model(&models.AuditEntry{})
+	eventsQ := db.Model(&models.ChannelEvent{})
+	if f.UserID != 0 {
+		entriesQ = entriesQ.Where("user_id = ?", f.UserID)
+		eventsQ = eventsQ.Where("user_id = ?", f.UserID)
+	}
+	if f.Channel != "" {
+		entriesQ = entriesQ.Where("channel = ?", f.Channel)
+		eventsQ = eventsQ.Where("from_channel = ? OR to_channel = ?", f.Channel, f.Channel)
+	}
+	if !f.Since.IsZero() {
+		entriesQ = entriesQ.Where("created_at >= ?", f.Since)
+		eventsQ = eventsQ.Where("created_at >= ?", f.Since)
+	}
+
+	var entries []models.AuditEntry
+	if err := entriesQ.Order("created_at DESC, id DESC").Limit(f.Limit).Find(&entries).Error; err != nil {
+		return nil, fmt.Errorf("error leyendo auditoría: %w", err)
+	}
+	var events []models.ChannelEvent
+	if err := eventsQ.Order("created_at DESC, id DESC").Limit(f.Limit).Find(&events).Error; err != nil {
+		return nil, fmt.Errorf("error leyendo eventos: %w", err)
+	}
+
+	records := make([]AuditRecord, 0, len(entries)+len(events))
+	for _, e := range entries {
+		records = append(records, AuditRecord{
+			At:        e.CreatedAt,
+			Kind:      AuditKindEntry,
+			Action:    e.Action,
+			Actor:     e.Actor,
+			Source:    e.Source,
+			UserID:    e.UserID,
+			Channel:   e.Channel,
+			Intent:    e.Intent,
+			Outcome:   e.Outcome,
+			LatencyMs: e.LatencyMs,
+			Details:   e.Details,
+			Error:     e.ErrorMsg,
+		})
+	}
+	for _, ev := range events {
+		userID := ev.UserID
+		records = append(records, AuditRecord{
+			At:        ev.CreatedAt,
+			Kind:      AuditKindChannelEvent,
+			Action:    ev.Type,
+			Actor:     ev.Actor,
+			Source:    ev.Source,
+			UserID:    &userID,
+			From:      ev.FromChannel,
+			To:        ev.ToChannel,
+			RequestID: ev.RequestID,
+		})
+	}
+
+	sort.SliceStable(records, func(i, j int) bool { return records[i].At.After(records[j].At) })
+	if len(records) > f.Limit {
+		records = records[:f.Limit]
+	}
+	return records, nil
+}
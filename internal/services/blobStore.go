@@ -0,0 +1,115 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"log"
+	"os"
+	"sync"
+	"time"
+
+	"walkie-backend/internal/config"
+	"walkie-backend/pkg/objstore"
+)
+
+const (
+	defaultBlobURLTTL = 15 * time.Minute
+	// blobTimeout limita cada subida o descarga del almacenamiento de objetos
+	blobTimeout = 10 * time.Second
+)
+
+// BlobStore guarda el audio fuera de la base de datos; las claves son lo único
+// que se persiste en las tablas
+type BlobStore interface {
+	Put(ctx context.Context, key string, data []byte, contentType string) error
+	Get(ctx context.Context, key string) ([]byte, error)
+	Delete(ctx context.Context, key string) error
+	PresignGet(key string, ttl time.Duration) (string, error)
+}
+
+var blobState = struct {
+	sync.RWMutex
+	once  sync.Once
+	store BlobStore
+}{}
+
+// AudioBlobStore devuelve el almacenamiento de objetos configurado con S3_BUCKET, o
+// nil si el audio se guarda en memoria y en la base de datos como siempre
+func AudioBlobStore() BlobStore {
+	blobState.once.Do(func() {
+		client, err := objstore.NewClientFromEnv()
+		if err != nil {
+			if !errors.Is(err, objstore.ErrNotConfigured) {
+				log.Printf("[BLOBS] no se pudo crear el cliente S3: %v", err)
+			}
+			return
+		}
+		log.Printf("[BLOBS] audio en el bucket %s", os.Getenv("S3_BUCKET"))
+		blobState.Lock()
+		blobState.store = client
+		blobState.Unlock()
+	})
+
+	blobState.RLock()
+	defer blobState.RUnlock()
+	return blobState.store
+}
+
+// SetAudioBlobStore reemplaza el almacenamiento de objetos (nil lo desactiva)
+func SetAudioBlobStore(store BlobStore) {
+	blobState.once.Do(func() {})
+	blobState.Lock()
+	blobState.store = store
+	blobState.Unlock()
+}
+
+// BlobURLTTL es la vigencia de las URLs prefirmadas (BLOB_URL_TTL, 15 min por defecto)
+func BlobURLTTL() time.Duration {
+	return config.DurationFromEnv("BLOB_URL_TTL", defaultBlobURLTTL)
+}
+
+// NewBlobKey genera una clave única bajo prefix, agrupada por día
+func NewBlobKey(prefix, ext string) string {
+	buf := make([]byte, 16)
+	_, _ = rand.Read(buf)
+	key := prefix + "/" + time.Now().UTC().Format("2006/01/02") + "/" + hex.EncodeToString(buf)
+	if ext != "" {
+		key += "." + ext
+	}
+	return key
+}
+
+// PutBlob sube el audio con un tiempo límite
+func PutBlob(store BlobStore, key string, data []byte, contentType string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), blobTimeout)
+	defer cancel()
+	return store.Put(ctx, key, data, contentType)
+}
+
+// GetBlob descarga el audio con un tiempo límite
+func GetBlob(store BlobStore, key string) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), blobTimeout)
+	defer cancel()
+	return store.Get(ctx, key)
+}
+
+// PresignBlob devuelve la URL de descarga directa del objeto
+func PresignBlob(key string) (string, error) {
+	store := AudioBlobStore()
+	if store == nil {
+		return "", objstore.ErrNotConfigured
+	}
+	return store.PresignGet(key, BlobURLTTL())
+}
+
+func deleteBlobs(store BlobStore, keys []string) {
+	ctx, cancel := context.WithTimeout(context.Background(), blobTimeout)
+	defer cancel()
+	for _, key := range keys {
+		if err := store.Delete(ctx, key); err != nil {
+			log.Printf("[BLOBS] no se pudo borrar %s: %v", key, err)
+		}
+	}
+}
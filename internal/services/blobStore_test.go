@@ -0,0 +1,84 @@
+package services
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"walkie-backend/internal/config"
+	"walkie-backend/internal/models"
+	"walkie-backend/pkg/objstore"
+)
+
+type memoryBlobStore struct {
+	mu      sync.Mutex
+	objects map[string][]byte
+}
+
+func (m *memoryBlobStore) Put(_ context.Context, key string, data []byte, _ string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.objects[key] = data
+	return nil
+}
+
+func (m *memoryBlobStore) Get(_ context.Context, key string) ([]byte, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	data, ok := m.objects[key]
+	if !ok {
+		return nil, objstore.ErrNotFound
+	}
+	return data, nil
+}
+
+func (m *memoryBlobStore) Delete(_ context.Context, key string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.objects, key)
+	return nil
+}
+
+func (m *memoryBlobStore) PresignGet(key string, ttl time.Duration) (string, error) {
+	return "https://bucket.example/" + key + "?ttl=" + ttl.String(), nil
+}
+
+func TestRecordTransmission_StoresAudioInObjectStore(t *testing.T) {
+	cleanup := setupUserServiceTestDB(t)
+	defer cleanup()
+	t.Setenv("CHANNEL_HISTORY_KEEP", "1")
+	store := &memoryBlobStore{objects: map[string][]byte{}}
+	SetAudioBlobStore(store)
+	defer SetAudioBlobStore(nil)
+
+	db := config.DB
+	if err := db.AutoMigrate(&models.ChannelTransmission{}, &models.TransmissionBlob{}); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+
+	for i := 0; i < 2; i++ {
+		tx := models.ChannelTransmission{ChannelCode: "canal-4", SenderID: uint(i + 1), Format: "wav", CreatedAt: time.Now().Add(time.Duration(i) * time.Second)}
+		if err := RecordTransmission(db, tx, []byte{byte(i)}); err != nil {
+			t.Fatalf("record %d: %v", i, err)
+		}
+	}
+
+	var blobs int64
+	db.Model(&models.TransmissionBlob{}).Count(&blobs)
+	if blobs != 0 {
+		t.Fatalf("expected no audio in the database, got %d blobs", blobs)
+	}
+	if len(store.objects) != 1 {
+		t.Fatalf("expected the pruned object to be deleted, got %d objects", len(store.objects))
+	}
+
+	history, err := ChannelHistory(db, "canal-4", 10)
+	if err != nil || len(history) != 1 || history[0].AudioKey == "" {
+		t.Fatalf("unexpected history %+v (err=%v)", history, err)
+	}
+	_, data, err := TransmissionAudio(db, "canal-4", history[0].ID)
+	if err != nil || len(data) != 1 || data[0] != 1 {
+		t.Fatalf("unexpected audio %v (err=%v)", data, err)
+	}
+}
@@ -0,0 +1,87 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"unicode"
+
+	"walkie-backend/internal/models"
+	"walkie-backend/pkg/qwen"
+
+	"gorm.io/gorm"
+)
+
+const maxAliasLength = 64
+
+var (
+	ErrInvalidAlias = errors.New("alias inválido")
+	ErrAliasTaken   = errors.New("ya usas ese alias para otro canal")
+)
+
+// SetChannelAlias guarda el alias que el usuario da a un canal; un alias vacío lo borra.
+// Devuelve el alias ya normalizado tal y como lo compara el detector de comandos.
+func SetChannelAlias(db *gorm.DB, userID uint, channelCode, alias string) (string, error) {
+	if db == nil {
+		return "", fmt.Errorf("base de datos no disponible")
+	}
+	var channel models.Channel
+	if err := db.Where("code = ?", channelCode).First(&channel).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return "", ErrChannelNotFound
+		}
+		return "", err
+	}
+
+	alias = qwen.NormalizeAlias(alias)
+	if alias == "" {
+		if err := db.Where("user_id = ? AND channel_code = ?", userID, channelCode).Delete(&models.ChannelAlias{}).Error; err != nil {
+			return "", fmt.Errorf("error borrando alias: %w", err)
+		}
+		return "", nil
+	}
+	if len(alias) > maxAliasLength || !strings.ContainsFunc(alias, unicode.IsLetter) {
+		return "", fmt.Errorf("%w: debe tener letras y como mucho %d caracteres", ErrInvalidAlias, maxAliasLength)
+	}
+
+	var taken int64
+	if err := db.Model(&models.ChannelAlias{}).
+		Where("user_id = ? AND alias = ? AND channel_code <> ?", userID, alias, channelCode).
+		Count(&taken).Error; err != nil {
+		return "", err
+	}
+	if taken > 0 {
+		return "", ErrAliasTaken
+	}
+
+	var existing models.ChannelAlias
+	err := db.Where("user_id = ? AND channel_code = ?", userID, channelCode).First(&existing).Error
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		existing = models.ChannelAlias{UserID: userID, ChannelCode: channelCode, Alias: alias}
+		err = db.Create(&existing).Error
+	case err == nil:
+		existing.Alias = alias
+		err = db.Save(&existing).Error
+	}
+	if err != nil {
+		return "", fmt.Errorf("error guardando alias: %w", err)
+	}
+	return alias, nil
+}
+
+// ChannelAliases devuelve los alias del usuario (alias -> código de canal)
+func ChannelAliases(db *gorm.DB, userID uint) (map[string]string, error) {
+	if db == nil {
+		return nil, fmt.Errorf("base de datos no disponible")
+	}
+	var rows []models.ChannelAlias
+	if err := db.Where("user_id = ?", userID).Find(&rows).Error; err != nil {
+		return nil, fmt.Errorf("error leyendo alias: %w", err)
+	}
+	aliases := make(map[string]string, len(rows))
+	for _, row := range rows {
+		aliases[row.Alias] = row.ChannelCode
+	}
+	return aliases, nil
+}
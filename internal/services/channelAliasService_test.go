@@ -0,0 +1,56 @@
+package services
+
+import (
+	"errors"
+	"testing"
+
+	"walkie-backend/internal/config"
+	"walkie-backend/internal/models"
+)
+
+func TestSetChannelAlias(t *testing.T) {
+	cleanup := setupUserServiceTestDB(t)
+	defer cleanup()
+
+	db := config.DB
+	if err := db.AutoMigrate(&models.ChannelAlias{}); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	for _, code := range []string{"canal-3", "canal-4"} {
+		if err := db.Create(&models.Channel{Code: code, Name: code}).Error; err != nil {
+			t.Fatalf("create channel: %v", err)
+		}
+	}
+
+	alias, err := SetChannelAlias(db, 1, "canal-3", "  Obra Norte ")
+	if err != nil || alias != "obra norte" {
+		t.Fatalf("unexpected alias %q (err=%v)", alias, err)
+	}
+	if _, err := SetChannelAlias(db, 1, "canal-4", "obra norte"); !errors.Is(err, ErrAliasTaken) {
+		t.Fatalf("expected ErrAliasTaken, got %v", err)
+	}
+	if _, err := SetChannelAlias(db, 2, "canal-4", "obra norte"); err != nil {
+		t.Fatalf("other users may reuse the alias: %v", err)
+	}
+	if _, err := SetChannelAlias(db, 1, "canal-9", "bodega"); !errors.Is(err, ErrChannelNotFound) {
+		t.Fatalf("expected ErrChannelNotFound, got %v", err)
+	}
+	if _, err := SetChannelAlias(db, 1, "canal-4", "42"); !errors.Is(err, ErrInvalidAlias) {
+		t.Fatalf("expected ErrInvalidAlias, got %v", err)
+	}
+
+	if _, err := SetChannelAlias(db, 1, "canal-3", "obra sur"); err != nil {
+		t.Fatalf("rename: %v", err)
+	}
+	aliases, err := ChannelAliases(db, 1)
+	if err != nil || len(aliases) != 1 || aliases["obra sur"] != "canal-3" {
+		t.Fatalf("unexpected aliases %v (err=%v)", aliases, err)
+	}
+
+	if _, err := SetChannelAlias(db, 1, "canal-3", ""); err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+	if aliases, _ := ChannelAliases(db, 1); len(aliases) != 0 {
+		t.Fatalf("expected no aliases, got %v", aliases)
+	}
+}
@@ -0,0 +1,97 @@
+package services
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"walkie-backend/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// EventMeta identifica quién y por qué vía provocó un cambio de canal
+type EventMeta struct {
+	Actor     string
+	Source    string
+	RequestID string
+}
+
+func (m EventMeta) withDefaults() EventMeta {
+	if m.Source == "" {
+		m.Source = models.EventSourceSystem
+	}
+	if m.Actor == "" {
+		m.Actor = "system"
+	}
+	return m
+}
+
+// AppendChannelEvent agrega un evento al historial; un fallo no revierte el cambio de estado
+func AppendChannelEvent(db *gorm.DB, meta EventMeta, userID uint, eventType, from, to string) {
+	if db == nil {
+		return
+	}
+	meta = meta.withDefaults()
+	event := models.ChannelEvent{
+		CreatedAt:   time.Now(),
+		UserID:      userID,
+		Type:        eventType,
+		FromChannel: from,
+		ToChannel:   to,
+		Actor:       meta.Actor,
+		Source:      meta.Source,
+		RequestID:   meta.RequestID,
+	}
+	if err := db.Create(&event).Error; err != nil {
+		log.Printf("No se pudo registrar evento de canal usuario=%d tipo=%s: %v", userID, eventType, err)
+	}
+	emitMembershipWebhooks(event)
+}
+
+// emitMembershipWebhooks traduce el evento de canal a entradas y salidas para los webhooks
+func emitMembershipWebhooks(ev models.ChannelEvent) {
+	data := func() map[string]any {
+		return map[string]any{"userId": ev.UserID, "reason": ev.Type, "actor": ev.Actor, "source": ev.Source}
+	}
+	if ev.FromChannel != "" && ev.Type != models.ChannelEventConnect {
+		EmitWebhookEvent(WebhookEventUserLeft, ev.FromChannel, data())
+	}
+	if ev.ToChannel != "" && (ev.Type == models.ChannelEventConnect || ev.Type == models.ChannelEventMove) {
+		EmitWebhookEvent(WebhookEventUserJoined, ev.ToChannel, data())
+	}
+}
+
+// ChannelEvents devuelve los eventos de un usuario hasta el instante indicado, en orden
+func ChannelEvents(db *gorm.DB, userID uint, until time.Time) ([]models.ChannelEvent, error) {
+	var events []models.ChannelEvent
+	err := db.Where("user_id = ? AND created_at <= ?", userID, until).
+		Order("created_at ASC, id ASC").
+		Find(&events).Error
+	if err != nil {
+		return nil, fmt.Errorf("error leyendo eventos: %w", err)
+	}
+	return events, nil
+}
+
+// ReplayChannelState reconstruye el canal en el que estaba un usuario en el instante indicado
+func ReplayChannelState(db *gorm.DB, userID uint, at time.Time) (string, []models.ChannelEvent, error) {
+	events, err := ChannelEvents(db, userID, at)
+	if err != nil {
+		return "", nil, err
+	}
+	return applyChannelEvents(events), events, nil
+}
+
+func applyChannelEvents(events []models.ChannelEvent) string {
+	channel := ""
+	for _, ev := range events {
+		switch ev.Type {
+		case models.ChannelEventConnect, models.ChannelEventMove:
+			channel = ev.ToChannel
+		case models.ChannelEventDisconnect, models.ChannelEventKick, models.ChannelEventAutoDisconnect:
+			channel = ""
+		}
+	}
+	return channel
+}
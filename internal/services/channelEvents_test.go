@@ -0,0 +1,90 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"walkie-backend/internal/config"
+	"walkie-backend/internal/models"
+)
+
+func TestChannelEvents_RecordedAndReplayed(t *testing.T) {
+	cleanup := setupUserServiceTestDB(t)
+	defer cleanup()
+
+	db := config.DB
+	if err := db.AutoMigrate(&models.ChannelEvent{}); err != nil {
+		t.Fatalf("migrate events: %v", err)
+	}
+
+	user := models.User{DisplayName: "Juan"}
+	db.Create(&user)
+	db.Create(&models.Channel{Code: "canal-1", Name: "Canal 1", MaxUsers: 10})
+	db.Create(&models.Channel{Code: "canal-3", Name: "Canal 3", MaxUsers: 10})
+
+	svc := NewUserService().WithEventMeta(EventMeta{Actor: "user:1", Source: models.EventSourceVoice, RequestID: "req-1"})
+
+	if err := svc.ConnectUserToChannel(user.ID, "canal-1"); err != nil {
+		t.Fatalf("connect: %v", err)
+	}
+	if err := svc.ConnectUserToChannel(user.ID, "canal-3"); err != nil {
+		t.Fatalf("move: %v", err)
+	}
+	time.Sleep(5 * time.Millisecond)
+	afterMove := time.Now()
+	time.Sleep(5 * time.Millisecond)
+	if err := svc.DisconnectUserFromCurrentChannel(user.ID); err != nil {
+		t.Fatalf("disconnect: %v", err)
+	}
+
+	events, err := ChannelEvents(db, user.ID, time.Now())
+	if err != nil {
+		t.Fatalf("ChannelEvents: %v", err)
+	}
+	wantTypes := []string{models.ChannelEventConnect, models.ChannelEventMove, models.ChannelEventDisconnect}
+	if len(events) != len(wantTypes) {
+		t.Fatalf("expected %d events, got %d", len(wantTypes), len(events))
+	}
+	for i, want := range wantTypes {
+		if events[i].Type != want {
+			t.Errorf("event %d: expected %s, got %s", i, want, events[i].Type)
+		}
+		if events[i].Source != models.EventSourceVoice || events[i].RequestID != "req-1" {
+			t.Errorf("event %d: unexpected metadata %+v", i, events[i])
+		}
+	}
+	if events[1].FromChannel != "canal-1" || events[1].ToChannel != "canal-3" {
+		t.Errorf("unexpected move event %+v", events[1])
+	}
+
+	channel, _, err := ReplayChannelState(db, user.ID, afterMove)
+	if err != nil {
+		t.Fatalf("replay: %v", err)
+	}
+	if channel != "canal-3" {
+		t.Fatalf("expected canal-3 at afterMove, got %q", channel)
+	}
+
+	channel, _, _ = ReplayChannelState(db, user.ID, time.Now())
+	if channel != "" {
+		t.Fatalf("expected no channel after disconnect, got %q", channel)
+	}
+}
+
+func TestApplyChannelEvents_KickAndAutoDisconnect(t *testing.T) {
+	events := []models.ChannelEvent{
+		{Type: models.ChannelEventConnect, ToChannel: "canal-2"},
+		{Type: models.ChannelEventKick, FromChannel: "canal-2"},
+	}
+	if got := applyChannelEvents(events); got != "" {
+		t.Fatalf("expected empty after kick, got %q", got)
+	}
+
+	events = append(events,
+		models.ChannelEvent{Type: models.ChannelEventConnect, ToChannel: "canal-4"},
+		models.ChannelEvent{Type: models.ChannelEventAutoDisconnect, FromChannel: "canal-4"},
+	)
+	if got := applyChannelEvents(events); got != "" {
+		t.Fatalf("expected empty after auto-disconnect, got %q", got)
+	}
+}
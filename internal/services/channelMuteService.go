@@ -0,0 +1,55 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+
+	"walkie-backend/internal/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var ErrCannotMuteSelf = errors.New("no puedes silenciarte a ti mismo")
+
+// MuteUser hace que userID deje de recibir el audio de mutedID en el canal; repetirlo no
+// tiene efecto
+func MuteUser(db *gorm.DB, userID uint, channelCode string, mutedID uint) error {
+	if db == nil {
+		return fmt.Errorf("base de datos no disponible")
+	}
+	if userID == mutedID {
+		return ErrCannotMuteSelf
+	}
+	if _, err := findChannel(db, channelCode); err != nil {
+		return err
+	}
+	var users int64
+	if err := db.Model(&models.User{}).Where("id = ?", mutedID).Count(&users).Error; err != nil {
+		return err
+	}
+	if users == 0 {
+		return ErrUserNotFound
+	}
+
+	mute := models.ChannelMute{UserID: userID, ChannelCode: channelCode, MutedUserID: mutedID}
+	return db.Clauses(clause.OnConflict{DoNothing: true}).Create(&mute).Error
+}
+
+// UnmuteUser vuelve a entregar a userID el audio de mutedID en el canal
+func UnmuteUser(db *gorm.DB, userID uint, channelCode string, mutedID uint) error {
+	if db == nil {
+		return fmt.Errorf("base de datos no disponible")
+	}
+	return db.Where("user_id = ? AND channel_code = ? AND muted_user_id = ?", userID, channelCode, mutedID).
+		Delete(&models.ChannelMute{}).Error
+}
+
+// MutedBy devuelve los usuarios que silenciaron a senderID en el canal
+func MutedBy(db *gorm.DB, channelCode string, senderID uint) ([]uint, error) {
+	var ids []uint
+	err := db.Model(&models.ChannelMute{}).
+		Where("channel_code = ? AND muted_user_id = ?", channelCode, senderID).
+		Pluck("user_id", &ids).Error
+	return ids, err
+}
@@ -0,0 +1,54 @@
+package services
+
+import (
+	"errors"
+	"testing"
+
+	"walkie-backend/internal/config"
+	"walkie-backend/internal/models"
+)
+
+func TestMuteUser(t *testing.T) {
+	cleanup := setupUserServiceTestDB(t)
+	defer cleanup()
+
+	db := config.DB
+	if err := db.AutoMigrate(&models.ChannelMute{}); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	db.Create(&models.Channel{Code: "canal-1", Name: "Canal 1"})
+	db.Create(&models.Channel{Code: "canal-2", Name: "Canal 2"})
+	juan := models.User{DisplayName: "Juan", IsActive: true}
+	db.Create(&juan)
+
+	if err := MuteUser(db, 5, "canal-1", juan.ID); err != nil {
+		t.Fatalf("MuteUser: %v", err)
+	}
+	if err := MuteUser(db, 5, "canal-1", juan.ID); err != nil {
+		t.Fatalf("muting twice should be a no-op: %v", err)
+	}
+	if err := MuteUser(db, 6, "canal-2", juan.ID); err != nil {
+		t.Fatalf("MuteUser: %v", err)
+	}
+	if err := MuteUser(db, juan.ID, "canal-1", juan.ID); !errors.Is(err, ErrCannotMuteSelf) {
+		t.Fatalf("expected ErrCannotMuteSelf, got %v", err)
+	}
+	if err := MuteUser(db, 5, "canal-9", juan.ID); !errors.Is(err, ErrChannelNotFound) {
+		t.Fatalf("expected ErrChannelNotFound, got %v", err)
+	}
+	if err := MuteUser(db, 5, "canal-1", 999); !errors.Is(err, ErrUserNotFound) {
+		t.Fatalf("expected ErrUserNotFound, got %v", err)
+	}
+
+	ids, err := MutedBy(db, "canal-1", juan.ID)
+	if err != nil || len(ids) != 1 || ids[0] != 5 {
+		t.Fatalf("expected only user 5 muting juan in canal-1, got %v (%v)", ids, err)
+	}
+
+	if err := UnmuteUser(db, 5, "canal-1", juan.ID); err != nil {
+		t.Fatalf("UnmuteUser: %v", err)
+	}
+	if ids, _ := MutedBy(db, "canal-1", juan.ID); len(ids) != 0 {
+		t.Fatalf("expected no mutes after unmute, got %v", ids)
+	}
+}
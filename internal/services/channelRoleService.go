@@ -0,0 +1,182 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"walkie-backend/internal/models"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrInvalidRole      = errors.New("rol inválido")
+	ErrRoleForbidden    = errors.New("no tienes permiso para cambiar ese rol")
+	ErrNotModerator     = errors.New("sólo los moderadores pueden expulsar usuarios")
+	ErrCannotKick       = errors.New("no puedes expulsar a ese usuario")
+	ErrUserNotInChannel = errors.New("el usuario no está en el canal")
+	ErrUserNotFound     = errors.New("usuario no encontrado")
+)
+
+// ChannelRole devuelve el rol del usuario en el canal; sin membresía es member
+func ChannelRole(db *gorm.DB, userID uint, channelCode string) (string, error) {
+	channel, err := findChannel(db, channelCode)
+	if err != nil {
+		return "", err
+	}
+	membership, err := findMembership(db, userID, channel.ID)
+	if err != nil {
+		return "", err
+	}
+	return membership.Role, nil
+}
+
+// SetChannelRole asigna un rol sin comprobar permisos; es la vía de administración y la
+// única que puede nombrar propietarios
+func SetChannelRole(db *gorm.DB, channelCode string, userID uint, role string) error {
+	if !validRole(role) {
+		return ErrInvalidRole
+	}
+	return db.Transaction(func(tx *gorm.DB) error {
+		channel, err := findChannel(tx, channelCode)
+		if err != nil {
+			return err
+		}
+		return saveRole(tx, channel.ID, userID, role)
+	})
+}
+
+// ManageChannelRole cambia el rol de target a petición de actor: sólo el propietario del
+// canal nombra o retira moderadores, y nunca toca a otro propietario
+func ManageChannelRole(db *gorm.DB, actorID uint, channelCode string, targetID uint, role string) error {
+	if role != models.ChannelRoleModerator && role != models.ChannelRoleMember {
+		return ErrInvalidRole
+	}
+	return db.Transaction(func(tx *gorm.DB) error {
+		channel, err := findChannel(tx, channelCode)
+		if err != nil {
+			return err
+		}
+		actor, err := findMembership(tx, actorID, channel.ID)
+		if err != nil {
+			return err
+		}
+		if actor.Role != models.ChannelRoleOwner || actorID == targetID {
+			return ErrRoleForbidden
+		}
+		target, err := findMembership(tx, targetID, channel.ID)
+		if err != nil {
+			return err
+		}
+		if target.Role == models.ChannelRoleOwner {
+			return ErrRoleForbidden
+		}
+		return saveRole(tx, channel.ID, targetID, role)
+	})
+}
+
+// KickFromChannel saca a target del canal si actor es moderador. Un moderador no puede
+// expulsar a otro moderador ni al propietario.
+func KickFromChannel(db *gorm.DB, meta EventMeta, channelCode string, actorID, targetID uint) error {
+	if actorID == targetID {
+		return ErrCannotKick
+	}
+	return db.Transaction(func(tx *gorm.DB) error {
+		channel, err := findChannel(tx, channelCode)
+		if err != nil {
+			return err
+		}
+		actor, err := findMembership(tx, actorID, channel.ID)
+		if err != nil {
+			return err
+		}
+		if !actor.CanModerate() {
+			return ErrNotModerator
+		}
+		target, err := findMembership(tx, targetID, channel.ID)
+		if err != nil {
+			return err
+		}
+		if target.Role == models.ChannelRoleOwner ||
+			(target.Role == models.ChannelRoleModerator && actor.Role != models.ChannelRoleOwner) {
+			return ErrCannotKick
+		}
+
+		var inChannel int64
+		if err := tx.Model(&models.User{}).Where("id = ? AND current_channel_id = ?", targetID, channel.ID).Count(&inChannel).Error; err != nil {
+			return err
+		}
+		if inChannel == 0 {
+			return ErrUserNotInChannel
+		}
+
+		users := &UserService{db: tx, meta: meta}
+		previous, err := users.disconnectCurrent(tx, targetID)
+		if err != nil {
+			return err
+		}
+		AppendChannelEvent(tx, meta.withDefaults(), targetID, models.ChannelEventKick, previous, "")
+		return nil
+	})
+}
+
+func validRole(role string) bool {
+	switch role {
+	case models.ChannelRoleOwner, models.ChannelRoleModerator, models.ChannelRoleMember:
+		return true
+	}
+	return false
+}
+
+func findChannel(db *gorm.DB, code string) (*models.Channel, error) {
+	var channel models.Channel
+	if err := db.Where("code = ?", code).First(&channel).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrChannelNotFound
+		}
+		return nil, err
+	}
+	return &channel, nil
+}
+
+// findMembership devuelve la membresía del usuario; si nunca entró al canal es un member
+// sin fila
+func findMembership(db *gorm.DB, userID, channelID uint) (*models.ChannelMembership, error) {
+	var membership models.ChannelMembership
+	err := db.Where("user_id = ? AND channel_id = ?", userID, channelID).First(&membership).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return &models.ChannelMembership{UserID: userID, ChannelID: channelID, Role: models.ChannelRoleMember}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &membership, nil
+}
+
+// saveRole guarda el rol; si el usuario nunca entró al canal crea una membresía inactiva
+// para conservarlo hasta que se conecte
+func saveRole(tx *gorm.DB, channelID, userID uint, role string) error {
+	var users int64
+	if err := tx.Model(&models.User{}).Where("id = ?", userID).Count(&users).Error; err != nil {
+		return err
+	}
+	if users == 0 {
+		return ErrUserNotFound
+	}
+
+	var membership models.ChannelMembership
+	err := tx.Where("user_id = ? AND channel_id = ?", userID, channelID).First(&membership).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		membership = models.ChannelMembership{UserID: userID, ChannelID: channelID, Role: role, JoinedAt: time.Now()}
+		if err := tx.Create(&membership).Error; err != nil {
+			return fmt.Errorf("error creando membresía: %w", err)
+		}
+		// Active tiene default:true en la base de datos, así que se desactiva aparte
+		return tx.Model(&membership).Update("active", false).Error
+	}
+	if err != nil {
+		return err
+	}
+	return tx.Model(&membership).Update("role", role).Error
+}
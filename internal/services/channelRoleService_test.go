@@ -0,0 +1,92 @@
+package services
+
+import (
+	"errors"
+	"testing"
+
+	"walkie-backend/internal/config"
+	"walkie-backend/internal/models"
+)
+
+func TestSetChannelRole_KeepsRoleForUsersNotConnected(t *testing.T) {
+	cleanup := setupUserServiceTestDB(t)
+	defer cleanup()
+
+	db := config.DB
+	db.Create(&models.Channel{Code: "canal-1", Name: "Canal 1", MaxUsers: 10})
+	user := models.User{DisplayName: "Dueña", IsActive: true}
+	db.Create(&user)
+
+	if err := SetChannelRole(db, "canal-1", user.ID, "jefe"); !errors.Is(err, ErrInvalidRole) {
+		t.Fatalf("expected ErrInvalidRole, got %v", err)
+	}
+	if err := SetChannelRole(db, "canal-1", 999, models.ChannelRoleOwner); !errors.Is(err, ErrUserNotFound) {
+		t.Fatalf("expected ErrUserNotFound, got %v", err)
+	}
+	if err := SetChannelRole(db, "canal-1", user.ID, models.ChannelRoleOwner); err != nil {
+		t.Fatalf("SetChannelRole: %v", err)
+	}
+
+	var membership models.ChannelMembership
+	db.Where("user_id = ?", user.ID).First(&membership)
+	if membership.Active || membership.Role != models.ChannelRoleOwner {
+		t.Fatalf("expected inactive owner membership, got %+v", membership)
+	}
+
+	// Al conectarse conserva el rol
+	if err := NewUserService().ConnectUserToChannel(user.ID, "canal-1"); err != nil {
+		t.Fatalf("connect: %v", err)
+	}
+	role, err := ChannelRole(db, user.ID, "canal-1")
+	if err != nil || role != models.ChannelRoleOwner {
+		t.Fatalf("expected owner after connecting, got %q (%v)", role, err)
+	}
+}
+
+func TestKickFromChannel_Permissions(t *testing.T) {
+	cleanup := setupUserServiceTestDB(t)
+	defer cleanup()
+
+	db := config.DB
+	if err := db.AutoMigrate(&models.ChannelEvent{}); err != nil {
+		t.Fatalf("migrate events: %v", err)
+	}
+	db.Create(&models.Channel{Code: "canal-2", Name: "Canal 2", MaxUsers: 10})
+	users := []models.User{{DisplayName: "Dueña"}, {DisplayName: "Moderador"}, {DisplayName: "Oyente"}}
+	for i := range users {
+		users[i].IsActive = true
+		db.Create(&users[i])
+		if err := NewUserService().ConnectUserToChannel(users[i].ID, "canal-2"); err != nil {
+			t.Fatalf("connect: %v", err)
+		}
+	}
+	owner, mod, other := users[0].ID, users[1].ID, users[2].ID
+	SetChannelRole(db, "canal-2", owner, models.ChannelRoleOwner)
+	SetChannelRole(db, "canal-2", mod, models.ChannelRoleModerator)
+	meta := EventMeta{Actor: "user:test"}
+
+	if err := KickFromChannel(db, meta, "canal-2", other, mod); !errors.Is(err, ErrNotModerator) {
+		t.Fatalf("expected ErrNotModerator, got %v", err)
+	}
+	if err := ManageChannelRole(db, mod, "canal-2", other, models.ChannelRoleModerator); !errors.Is(err, ErrRoleForbidden) {
+		t.Fatalf("expected ErrRoleForbidden for moderator granting, got %v", err)
+	}
+	if err := KickFromChannel(db, meta, "canal-2", mod, owner); !errors.Is(err, ErrCannotKick) {
+		t.Fatalf("expected ErrCannotKick for owner, got %v", err)
+	}
+	if err := KickFromChannel(db, meta, "canal-2", mod, other); err != nil {
+		t.Fatalf("KickFromChannel: %v", err)
+	}
+	if err := KickFromChannel(db, meta, "canal-2", owner, other); !errors.Is(err, ErrUserNotInChannel) {
+		t.Fatalf("expected ErrUserNotInChannel, got %v", err)
+	}
+	if err := KickFromChannel(db, meta, "canal-2", owner, mod); err != nil {
+		t.Fatalf("owner should kick moderators: %v", err)
+	}
+
+	var kicks int64
+	db.Model(&models.ChannelEvent{}).Where("type = ? AND actor = ?", models.ChannelEventKick, "user:test").Count(&kicks)
+	if kicks != 2 {
+		t.Fatalf("expected 2 kick events, got %d", kicks)
+	}
+}
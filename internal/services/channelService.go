@@ -0,0 +1,243 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"walkie-backend/internal/models"
+
+	"gorm.io/gorm"
+)
+
+const defaultChannelMaxUsers = 100
+
+var (
+	ErrChannelNotFound      = errors.New("canal no encontrado")
+	ErrChannelExists        = errors.New("ya existe un canal con ese código")
+	ErrInvalidChannel       = errors.New("datos de canal inválidos")
+	ErrCapacityBelowMembers = errors.New("la capacidad es menor que los usuarios conectados")
+
+	channelCodePattern = regexp.MustCompile(`^[a-z0-9][a-z0-9-]{0,63}$`)
+	dialInCodePattern  = regexp.MustCompile(`^[0-9]{3,8}$`)
+)
+
+// ChannelInput son los campos editables de un canal; los nil no se modifican
+type ChannelInput struct {
+	Code      string  `json:"code"`
+	Name      *string `json:"name"`
+	MaxUsers  *int    `json:"maxUsers"`
+	IsPrivate *bool   `json:"isPrivate"`
+	Kind      *string `json:"kind"`
+	Assistant *bool   `json:"assistant"`
+	// DialInCode activa las llamadas telefónicas con ese código; "" las desactiva
+	DialInCode *string `json:"dialInCode"`
+}
+
+// ChannelService administra canales en tiempo de ejecución
+type ChannelService struct {
+	db *gorm.DB
+}
+
+func NewChannelService(db *gorm.DB) *ChannelService {
+	return &ChannelService{db: db}
+}
+
+// Create da de alta un canal nuevo
+func (s *ChannelService) Create(in ChannelInput) (*models.Channel, error) {
+	code := strings.ToLower(strings.TrimSpace(in.Code))
+	if !channelCodePattern.MatchString(code) {
+		return nil, fmt.Errorf("%w: código debe usar minúsculas, números y guiones", ErrInvalidChannel)
+	}
+
+	channel := models.Channel{
+		Code:     code,
+		Name:     code,
+		MaxUsers: defaultChannelMaxUsers,
+		Kind:     models.ChannelKindStandard,
+	}
+	if err := applyChannelInput(&channel, in); err != nil {
+		return nil, err
+	}
+
+	var count int64
+	if err := s.db.Unscoped().Model(&models.Channel{}).Where("code = ?", code).Count(&count).Error; err != nil {
+		return nil, err
+	}
+	if count > 0 {
+		return nil, ErrChannelExists
+	}
+	if err := s.checkDialInCode(&channel); err != nil {
+		return nil, err
+	}
+	if err := s.db.Create(&channel).Error; err != nil {
+		return nil, fmt.Errorf("error creando canal: %w", err)
+	}
+	return &channel, nil
+}
+
+// Update renombra, cambia la capacidad o la visibilidad de un canal existente.
+// No permite bajar MaxUsers por debajo de los usuarios conectados en ese momento.
+func (s *ChannelService) Update(code string, in ChannelInput) (*models.Channel, error) {
+	var channel models.Channel
+	if err := s.db.Where("code = ?", code).First(&channel).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrChannelNotFound
+		}
+		return nil, err
+	}
+
+	if err := applyChannelInput(&channel, in); err != nil {
+		return nil, err
+	}
+
+	if in.MaxUsers != nil {
+		var connected int64
+		if err := s.db.Model(&models.User{}).Where("current_channel_id = ?", channel.ID).Count(&connected).Error; err != nil {
+			return nil, err
+		}
+		if int64(channel.MaxUsers) < connected {
+			return nil, fmt.Errorf("%w (%d conectados)", ErrCapacityBelowMembers, connected)
+		}
+	}
+
+	if err := s.checkDialInCode(&channel); err != nil {
+		return nil, err
+	}
+	if err := s.db.Save(&channel).Error; err != nil {
+		return nil, fmt.Errorf("error actualizando canal: %w", err)
+	}
+	return &channel, nil
+}
+
+// Delete desconecta a los usuarios del canal, elimina sus membresías y borra el canal.
+// Devuelve los IDs de los usuarios que estaban conectados para avisarles.
+func (s *ChannelService) Delete(code string, meta EventMeta) ([]uint, error) {
+	var affected []uint
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var channel models.Channel
+		if err := tx.Where("code = ?", code).First(&channel).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrChannelNotFound
+			}
+			return err
+		}
+
+		if err := tx.Model(&models.User{}).Where("current_channel_id = ?", channel.ID).Pluck("id", &affected).Error; err != nil {
+			return err
+		}
+
+		users := &UserService{db: tx, meta: meta}
+		for _, userID := range affected {
+			previous, err := users.disconnectCurrent(tx, userID)
+			if err != nil {
+				return err
+			}
+			AppendChannelEvent(tx, meta.withDefaults(), userID, models.ChannelEventKick, previous, "")
+		}
+
+		if err := tx.Unscoped().Where("channel_id = ?", channel.ID).Delete(&models.ChannelMembership{}).Error; err != nil {
+			return err
+		}
+		return tx.Unscoped().Delete(&channel).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return affected, nil
+}
+
+func applyChannelInput(channel *models.Channel, in ChannelInput) error {
+	if in.Name != nil {
+		name := strings.TrimSpace(*in.Name)
+		if name == "" {
+			return fmt.Errorf("%w: nombre vacío", ErrInvalidChannel)
+		}
+		channel.Name = name
+	}
+	if in.MaxUsers != nil {
+		if *in.MaxUsers <= 0 {
+			return fmt.Errorf("%w: maxUsers debe ser mayor que cero", ErrInvalidChannel)
+		}
+		channel.MaxUsers = *in.MaxUsers
+	}
+	if in.IsPrivate != nil {
+		channel.IsPrivate = *in.IsPrivate
+	}
+	if in.Kind != nil {
+		switch *in.Kind {
+		case models.ChannelKindStandard, models.ChannelKindEcho:
+			channel.Kind = *in.Kind
+		default:
+			return fmt.Errorf("%w: tipo de canal desconocido", ErrInvalidChannel)
+		}
+	}
+	if in.Assistant != nil {
+		channel.Assistant = *in.Assistant
+	}
+	if in.DialInCode != nil {
+		code := strings.TrimSpace(*in.DialInCode)
+		if code != "" && !dialInCodePattern.MatchString(code) {
+			return fmt.Errorf("%w: dialInCode debe tener entre 3 y 8 dígitos", ErrInvalidChannel)
+		}
+		channel.DialInCode = code
+	}
+	return nil
+}
+
+// checkDialInCode evita que dos canales compartan el código de llamada
+func (s *ChannelService) checkDialInCode(channel *models.Channel) error {
+	if channel.DialInCode == "" {
+		return nil
+	}
+	var count int64
+	q := s.db.Model(&models.Channel{}).Where("dial_in_code = ?", channel.DialInCode)
+	if channel.ID != 0 {
+		q = q.Where("id <> ?", channel.ID)
+	}
+	if err := q.Count(&count).Error; err != nil {
+		return err
+	}
+	if count > 0 {
+		return fmt.Errorf("%w: otro canal ya usa ese dialInCode", ErrInvalidChannel)
+	}
+	return nil
+}
+
+// FindByDialInCode devuelve el canal que admite llamadas con ese código
+func (s *ChannelService) FindByDialInCode(code string) (*models.Channel, error) {
+	code = strings.TrimSpace(code)
+	if code == "" {
+		return nil, ErrChannelNotFound
+	}
+	var channel models.Channel
+	if err := s.db.Where("dial_in_code = ?", code).First(&channel).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrChannelNotFound
+		}
+		return nil, err
+	}
+	return &channel, nil
+}
+
+// ConnectedCounts devuelve cuántos usuarios hay conectados a cada canal con alguien dentro
+func ConnectedCounts(db *gorm.DB) (map[string]int, error) {
+	var rows []struct {
+		Code  string
+		Users int
+	}
+	err := db.Model(&models.User{}).
+		Select("channels.code AS code, COUNT(users.id) AS users").
+		Joins("JOIN channels ON channels.id = users.current_channel_id AND channels.deleted_at IS NULL").
+		Group("channels.code").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+	out := make(map[string]int, len(rows))
+	for _, row := range rows {
+		out[row.Code] = row.Users
+	}
+	return out, nil
+}
@@ -0,0 +1,45 @@
+package services
+
+import (
+	"fmt"
+
+	"walkie-backend/internal/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// AddFavoriteChannel marca el canal como favorito del usuario; repetirlo no hace nada
+func AddFavoriteChannel(db *gorm.DB, userID uint, channelCode string) error {
+	if _, err := findChannel(db, channelCode); err != nil {
+		return err
+	}
+	fav := models.FavoriteChannel{UserID: userID, ChannelCode: channelCode}
+	if err := db.Clauses(clause.OnConflict{DoNothing: true}).Create(&fav).Error; err != nil {
+		return fmt.Errorf("error guardando favorito: %w", err)
+	}
+	return nil
+}
+
+// RemoveFavoriteChannel quita el canal de los favoritos del usuario; si no lo era no hace nada
+func RemoveFavoriteChannel(db *gorm.DB, userID uint, channelCode string) error {
+	err := db.Where("user_id = ? AND channel_code = ?", userID, channelCode).Delete(&models.FavoriteChannel{}).Error
+	if err != nil {
+		return fmt.Errorf("error borrando favorito: %w", err)
+	}
+	return nil
+}
+
+// FavoriteChannels devuelve los códigos de los canales favoritos en el orden en que se
+// marcaron
+func FavoriteChannels(db *gorm.DB, userID uint) ([]string, error) {
+	var codes []string
+	err := db.Model(&models.FavoriteChannel{}).
+		Where("user_id = ?", userID).
+		Order("created_at, id").
+		Pluck("channel_code", &codes).Error
+	if err != nil {
+		return nil, fmt.Errorf("error leyendo favoritos: %w", err)
+	}
+	return codes, nil
+}
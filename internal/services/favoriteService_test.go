@@ -0,0 +1,44 @@
+package services
+
+import (
+	"errors"
+	"testing"
+
+	"walkie-backend/internal/config"
+	"walkie-backend/internal/models"
+)
+
+func TestFavoriteChannels_AddListRemove(t *testing.T) {
+	cleanup := setupUserServiceTestDB(t)
+	defer cleanup()
+	db := config.DB
+	if err := db.AutoMigrate(&models.FavoriteChannel{}); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	db.Create(&models.Channel{Code: "canal-1", Name: "Canal 1", MaxUsers: 10})
+	db.Create(&models.Channel{Code: "canal-3", Name: "Canal 3", MaxUsers: 10})
+
+	if err := AddFavoriteChannel(db, 7, "canal-9"); !errors.Is(err, ErrChannelNotFound) {
+		t.Fatalf("expected ErrChannelNotFound, got %v", err)
+	}
+	for _, code := range []string{"canal-3", "canal-1", "canal-3"} {
+		if err := AddFavoriteChannel(db, 7, code); err != nil {
+			t.Fatalf("AddFavoriteChannel(%s): %v", code, err)
+		}
+	}
+	favorites, err := FavoriteChannels(db, 7)
+	if err != nil || len(favorites) != 2 || favorites[0] != "canal-3" || favorites[1] != "canal-1" {
+		t.Fatalf("unexpected favorites %v (%v)", favorites, err)
+	}
+	if other, _ := FavoriteChannels(db, 8); len(other) != 0 {
+		t.Fatalf("favorites are per user, got %v", other)
+	}
+
+	if err := RemoveFavoriteChannel(db, 7, "canal-3"); err != nil {
+		t.Fatalf("RemoveFavoriteChannel: %v", err)
+	}
+	favorites, _ = FavoriteChannels(db, 7)
+	if len(favorites) != 1 || favorites[0] != "canal-1" {
+		t.Fatalf("unexpected favorites after removal %v", favorites)
+	}
+}
@@ -0,0 +1,90 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"walkie-backend/internal/models"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrWebhookNotFound = errors.New("webhook no encontrado")
+	ErrInvalidWebhook  = errors.New("webhook inválido")
+)
+
+// IncomingWebhookService administra las integraciones que publican avisos en canales
+type IncomingWebhookService struct {
+	db *gorm.DB
+}
+
+func NewIncomingWebhookService(db *gorm.DB) *IncomingWebhookService {
+	return &IncomingWebhookService{db: db}
+}
+
+// Create valida el nombre y el canal y guarda el webhook; TokenHash lo pone el llamador
+func (s *IncomingWebhookService) Create(w *models.IncomingWebhook) error {
+	w.Name = strings.TrimSpace(w.Name)
+	w.Channel = strings.ToLower(strings.TrimSpace(w.Channel))
+	if w.Name == "" {
+		return fmt.Errorf("%w: indica un nombre", ErrInvalidWebhook)
+	}
+	if w.TokenHash == "" {
+		return fmt.Errorf("%w: falta el token", ErrInvalidWebhook)
+	}
+	if _, err := findChannel(s.db, w.Channel); err != nil {
+		if errors.Is(err, ErrChannelNotFound) {
+			return fmt.Errorf("%w: el canal %s no existe", ErrInvalidWebhook, w.Channel)
+		}
+		return err
+	}
+	if err := s.db.Create(w).Error; err != nil {
+		return fmt.Errorf("error creando webhook: %w", err)
+	}
+	return nil
+}
+
+// List devuelve los webhooks, los más recientes primero
+func (s *IncomingWebhookService) List() ([]models.IncomingWebhook, error) {
+	var items []models.IncomingWebhook
+	if err := s.db.Order("id DESC").Find(&items).Error; err != nil {
+		return nil, fmt.Errorf("error leyendo webhooks: %w", err)
+	}
+	return items, nil
+}
+
+// Delete revoca un webhook; su token deja de funcionar al momento
+func (s *IncomingWebhookService) Delete(id uint) error {
+	res := s.db.Delete(&models.IncomingWebhook{}, id)
+	if res.Error != nil {
+		return fmt.Errorf("error borrando webhook: %w", res.Error)
+	}
+	if res.RowsAffected == 0 {
+		return ErrWebhookNotFound
+	}
+	return nil
+}
+
+// FindByTokenHash devuelve el webhook del token
+func (s *IncomingWebhookService) FindByTokenHash(hash string) (*models.IncomingWebhook, error) {
+	var w models.IncomingWebhook
+	if err := s.db.Where("token_hash = ?", hash).First(&w).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrWebhookNotFound
+		}
+		return nil, err
+	}
+	return &w, nil
+}
+
+// MarkUsed anota un aviso publicado por el webhook
+func (s *IncomingWebhookService) MarkUsed(id uint, now time.Time) error {
+	return s.db.Model(&models.IncomingWebhook{}).Where("id = ?", id).
+		Updates(map[string]interface{}{
+			"last_used_at": now,
+			"uses":         gorm.Expr("uses + 1"),
+		}).Error
+}
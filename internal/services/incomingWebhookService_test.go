@@ -0,0 +1,64 @@
+package services
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"walkie-backend/internal/config"
+	"walkie-backend/internal/models"
+)
+
+func TestIncomingWebhookService_Lifecycle(t *testing.T) {
+	cleanup := setupUserServiceTestDB(t)
+	defer cleanup()
+	db := config.DB
+	if err := db.AutoMigrate(&models.IncomingWebhook{}); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	db.Create(&models.Channel{Code: "canal-1", Name: "Canal 1", MaxUsers: 10})
+	svc := NewIncomingWebhookService(db)
+
+	for _, w := range []models.IncomingWebhook{
+		{Name: " ", Channel: "canal-1", TokenHash: "h0"},
+		{Name: "alarmas", Channel: "canal-9", TokenHash: "h0"},
+		{Name: "alarmas", Channel: "canal-1"},
+	} {
+		if err := svc.Create(&w); !errors.Is(err, ErrInvalidWebhook) {
+			t.Fatalf("expected ErrInvalidWebhook for %+v, got %v", w, err)
+		}
+	}
+
+	w := models.IncomingWebhook{Name: " alarmas ", Channel: "Canal-1", TokenHash: "h1", Priority: "urgent"}
+	if err := svc.Create(&w); err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	if w.Name != "alarmas" || w.Channel != "canal-1" {
+		t.Fatalf("unexpected webhook %+v", w)
+	}
+
+	found, err := svc.FindByTokenHash("h1")
+	if err != nil || found.ID != w.ID {
+		t.Fatalf("expected webhook by hash, got %+v (%v)", found, err)
+	}
+	if _, err := svc.FindByTokenHash("otro"); !errors.Is(err, ErrWebhookNotFound) {
+		t.Fatalf("expected ErrWebhookNotFound, got %v", err)
+	}
+
+	if err := svc.MarkUsed(w.ID, time.Now()); err != nil {
+		t.Fatalf("mark used: %v", err)
+	}
+	if found, _ := svc.FindByTokenHash("h1"); found.Uses != 1 || found.LastUsedAt == nil {
+		t.Fatalf("expected one use recorded, got %+v", found)
+	}
+
+	if err := svc.Delete(w.ID); err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+	if err := svc.Delete(w.ID); !errors.Is(err, ErrWebhookNotFound) {
+		t.Fatalf("expected ErrWebhookNotFound on second delete, got %v", err)
+	}
+	if _, err := svc.FindByTokenHash("h1"); !errors.Is(err, ErrWebhookNotFound) {
+		t.Fatalf("revoked webhook must not authenticate, got %v", err)
+	}
+}
@@ -0,0 +1,141 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+
+	"walkie-backend/internal/models"
+	"walkie-backend/pkg/lang"
+	"walkie-backend/pkg/qwen"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrIntentPatternNotFound = errors.New("patrón no encontrado")
+	ErrIntentPatternExists   = errors.New("ya existe un patrón con esa frase")
+	ErrInvalidIntentPattern  = errors.New("patrón inválido")
+)
+
+// IntentPatternInput son los campos editables de un patrón; los nil no se modifican
+type IntentPatternInput struct {
+	Intent   *string `json:"intent"`
+	Phrase   *string `json:"phrase"`
+	Language *string `json:"language"`
+}
+
+// IntentPatternService administra las frases configurables de los comandos de voz
+type IntentPatternService struct {
+	db *gorm.DB
+}
+
+func NewIntentPatternService(db *gorm.DB) *IntentPatternService {
+	return &IntentPatternService{db: db}
+}
+
+// List devuelve todos los patrones ordenados por intent y frase
+func (s *IntentPatternService) List() ([]models.IntentPattern, error) {
+	var patterns []models.IntentPattern
+	if err := s.db.Order("intent ASC, phrase ASC").Find(&patterns).Error; err != nil {
+		return nil, fmt.Errorf("error leyendo patrones: %w", err)
+	}
+	return patterns, nil
+}
+
+// Create da de alta un patrón; intent y phrase son obligatorios
+func (s *IntentPatternService) Create(in IntentPatternInput) (*models.IntentPattern, error) {
+	pattern := models.IntentPattern{Language: lang.Default}
+	if in.Intent == nil || in.Phrase == nil {
+		return nil, fmt.Errorf("%w: intent y phrase son obligatorios", ErrInvalidIntentPattern)
+	}
+	if err := applyIntentPatternInput(&pattern, in); err != nil {
+		return nil, err
+	}
+	if err := s.ensureUnique(&pattern); err != nil {
+		return nil, err
+	}
+	if err := s.db.Create(&pattern).Error; err != nil {
+		return nil, fmt.Errorf("error creando patrón: %w", err)
+	}
+	return &pattern, nil
+}
+
+// Update modifica el intent, la frase o el idioma de un patrón
+func (s *IntentPatternService) Update(id uint, in IntentPatternInput) (*models.IntentPattern, error) {
+	pattern, err := s.find(id)
+	if err != nil {
+		return nil, err
+	}
+	if err := applyIntentPatternInput(pattern, in); err != nil {
+		return nil, err
+	}
+	if err := s.ensureUnique(pattern); err != nil {
+		return nil, err
+	}
+	if err := s.db.Save(pattern).Error; err != nil {
+		return nil, fmt.Errorf("error actualizando patrón: %w", err)
+	}
+	return pattern, nil
+}
+
+// Delete borra un patrón
+func (s *IntentPatternService) Delete(id uint) error {
+	res := s.db.Delete(&models.IntentPattern{}, id)
+	if res.Error != nil {
+		return fmt.Errorf("error borrando patrón: %w", res.Error)
+	}
+	if res.RowsAffected == 0 {
+		return ErrIntentPatternNotFound
+	}
+	return nil
+}
+
+func (s *IntentPatternService) find(id uint) (*models.IntentPattern, error) {
+	var pattern models.IntentPattern
+	if err := s.db.First(&pattern, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrIntentPatternNotFound
+		}
+		return nil, err
+	}
+	return &pattern, nil
+}
+
+func (s *IntentPatternService) ensureUnique(p *models.IntentPattern) error {
+	var count int64
+	err := s.db.Model(&models.IntentPattern{}).
+		Where("phrase = ? AND language = ? AND id <> ?", p.Phrase, p.Language, p.ID).
+		Count(&count).Error
+	if err != nil {
+		return err
+	}
+	if count > 0 {
+		return ErrIntentPatternExists
+	}
+	return nil
+}
+
+// applyIntentPatternInput valida los campos y guarda la frase ya normalizada
+func applyIntentPatternInput(p *models.IntentPattern, in IntentPatternInput) error {
+	if in.Intent != nil {
+		if !qwen.IsCommandIntent(*in.Intent) {
+			return fmt.Errorf("%w: intent desconocido %q", ErrInvalidIntentPattern, *in.Intent)
+		}
+		p.Intent = *in.Intent
+	}
+	if in.Phrase != nil {
+		phrase := qwen.NormalizePhrase(*in.Phrase)
+		if phrase == "" || len(phrase) > 255 {
+			return fmt.Errorf("%w: phrase vacía o demasiado larga", ErrInvalidIntentPattern)
+		}
+		p.Phrase = phrase
+	}
+	if in.Language != nil {
+		language, ok := lang.Normalize(*in.Language)
+		if !ok {
+			return fmt.Errorf("%w: idioma no soportado %q", ErrInvalidIntentPattern, *in.Language)
+		}
+		p.Language = language
+	}
+	return nil
+}
@@ -0,0 +1,64 @@
+package services
+
+import (
+	"errors"
+	"testing"
+
+	"walkie-backend/internal/config"
+	"walkie-backend/internal/models"
+)
+
+func strPtr(s string) *string { return &s }
+
+func TestIntentPatternService_CRUD(t *testing.T) {
+	cleanup := setupUserServiceTestDB(t)
+	defer cleanup()
+	if err := config.DB.AutoMigrate(&models.IntentPattern{}); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	svc := NewIntentPatternService(config.DB)
+
+	created, err := svc.Create(IntentPatternInput{Intent: strPtr("request_channel_connect"), Phrase: strPtr("  ¡Ponme en el CANAL! ")})
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	if created.Phrase != "ponme en el canal" || created.Language != "es" {
+		t.Fatalf("unexpected pattern %+v", created)
+	}
+
+	if _, err := svc.Create(IntentPatternInput{Intent: strPtr("request_user_list"), Phrase: strPtr("ponme en el canal")}); !errors.Is(err, ErrIntentPatternExists) {
+		t.Fatalf("expected ErrIntentPatternExists, got %v", err)
+	}
+	if _, err := svc.Create(IntentPatternInput{Intent: strPtr("request_user_list"), Phrase: strPtr("ponme en el canal"), Language: strPtr("en")}); err != nil {
+		t.Fatalf("same phrase in another language should be allowed: %v", err)
+	}
+	for _, in := range []IntentPatternInput{
+		{Phrase: strPtr("sin intent")},
+		{Intent: strPtr("inventado"), Phrase: strPtr("algo")},
+		{Intent: strPtr("request_user_list"), Phrase: strPtr(" ¿? ")},
+		{Intent: strPtr("request_user_list"), Phrase: strPtr("algo"), Language: strPtr("fr")},
+	} {
+		if _, err := svc.Create(in); !errors.Is(err, ErrInvalidIntentPattern) {
+			t.Fatalf("expected ErrInvalidIntentPattern for %+v, got %v", in, err)
+		}
+	}
+
+	updated, err := svc.Update(created.ID, IntentPatternInput{Phrase: strPtr("llévame al canal")})
+	if err != nil || updated.Phrase != "llevame al canal" || updated.Intent != "request_channel_connect" {
+		t.Fatalf("unexpected update %+v (err=%v)", updated, err)
+	}
+	if _, err := svc.Update(999, IntentPatternInput{}); !errors.Is(err, ErrIntentPatternNotFound) {
+		t.Fatalf("expected ErrIntentPatternNotFound, got %v", err)
+	}
+
+	if err := svc.Delete(created.ID); err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+	if err := svc.Delete(created.ID); !errors.Is(err, ErrIntentPatternNotFound) {
+		t.Fatalf("expected ErrIntentPatternNotFound on second delete, got %v", err)
+	}
+	remaining, err := svc.List()
+	if err != nil || len(remaining) != 1 || remaining[0].Language != "en" {
+		t.Fatalf("unexpected list %+v (err=%v)", remaining, err)
+	}
+}
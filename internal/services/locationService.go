@@ -0,0 +1,194 @@
+package services
+
+import (
+	"errors"
+	"math"
+	"sort"
+	"time"
+
+	"walkie-backend/internal/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	earthRadiusMeters = 6371000.0
+	// metersPerDegree es la longitud aproximada de un grado de latitud
+	metersPerDegree = 111320.0
+)
+
+var ErrInvalidLocation = errors.New("ubicación inválida: lat entre -90 y 90, lon entre -180 y 180")
+
+// NearbyUser es un usuario con ubicación reciente dentro del radio
+type NearbyUser struct {
+	UserID         uint    `json:"userId"`
+	Name           string  `json:"name"`
+	Channel        string  `json:"channel,omitempty"`
+	DistanceMeters float64 `json:"distanceMeters"`
+}
+
+// ChannelSuggestion es un canal público con gente cerca
+type ChannelSuggestion struct {
+	Code          string  `json:"code"`
+	Name          string  `json:"name"`
+	NearbyUsers   int     `json:"nearbyUsers"`
+	ClosestMeters float64 `json:"closestMeters"`
+}
+
+// UpdateLocation guarda la posición actual del usuario, sustituyendo la anterior
+func UpdateLocation(db *gorm.DB, userID uint, lat, lon, accuracy float64) error {
+	if math.IsNaN(lat) || math.IsNaN(lon) || lat < -90 || lat > 90 || lon < -180 || lon > 180 {
+		return ErrInvalidLocation
+	}
+	if math.IsNaN(accuracy) || accuracy < 0 {
+		accuracy = 0
+	}
+	loc := models.UserLocation{
+		UserID:    userID,
+		Latitude:  lat,
+		Longitude: lon,
+		Accuracy:  accuracy,
+		UpdatedAt: time.Now(),
+	}
+	return db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"latitude", "longitude", "accuracy", "updated_at"}),
+	}).Create(&loc).Error
+}
+
+// ClearLocation borra la ubicación del usuario: deja de aparecer cerca de nadie
+func ClearLocation(db *gorm.DB, userID uint) error {
+	return db.Where("user_id = ?", userID).Delete(&models.UserLocation{}).Error
+}
+
+// FreshLocation devuelve la ubicación del usuario si se actualizó después de since;
+// nil si no la compartió o ya es vieja
+func FreshLocation(db *gorm.DB, userID uint, since time.Time) (*models.UserLocation, error) {
+	var loc models.UserLocation
+	err := db.Where("user_id = ? AND updated_at >= ?", userID, since).Take(&loc).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &loc, nil
+}
+
+// DistanceMeters calcula la distancia sobre la superficie terrestre (haversine)
+func DistanceMeters(lat1, lon1, lat2, lon2 float64) float64 {
+	rad := func(deg float64) float64 { return deg * math.Pi / 180 }
+	dLat := rad(lat2 - lat1)
+	dLon := rad(lon2 - lon1)
+	a := math.Sin(dLat/2)*math.Sin(dLat/2) +
+		math.Cos(rad(lat1))*math.Cos(rad(lat2))*math.Sin(dLon/2)*math.Sin(dLon/2)
+	return 2 * earthRadiusMeters * math.Asin(math.Min(1, math.Sqrt(a)))
+}
+
+// NearbyUsers devuelve, del más cercano al más lejano, los usuarios con ubicación
+// posterior a since a menos de radius metros de origin. Con channelCode sólo cuenta a
+// quienes están en ese canal
+func NearbyUsers(db *gorm.DB, origin *models.UserLocation, channelCode string, radius float64, since time.Time) ([]NearbyUser, error) {
+	if origin == nil || radius <= 0 {
+		return nil, nil
+	}
+
+	// Un recuadro alrededor del origen descarta casi todo en la consulta; la distancia
+	// exacta se calcula después
+	dLat := radius / metersPerDegree
+	query := db.Where("user_id <> ? AND updated_at >= ?", origin.UserID, since).
+		Where("latitude BETWEEN ? AND ?", origin.Latitude-dLat, origin.Latitude+dLat)
+	if cos := math.Cos(origin.Latitude * math.Pi / 180); cos > 0.01 {
+		dLon := dLat / cos
+		if origin.Longitude-dLon >= -180 && origin.Longitude+dLon <= 180 {
+			query = query.Where("longitude BETWEEN ? AND ?", origin.Longitude-dLon, origin.Longitude+dLon)
+		}
+	}
+	var locs []models.UserLocation
+	if err := query.Find(&locs).Error; err != nil {
+		return nil, err
+	}
+	if len(locs) == 0 {
+		return nil, nil
+	}
+
+	distances := make(map[uint]float64, len(locs))
+	ids := make([]uint, 0, len(locs))
+	for _, l := range locs {
+		d := DistanceMeters(origin.Latitude, origin.Longitude, l.Latitude, l.Longitude)
+		if d <= radius {
+			distances[l.UserID] = d
+			ids = append(ids, l.UserID)
+		}
+	}
+	if len(ids) == 0 {
+		return nil, nil
+	}
+
+	var users []models.User
+	if err := db.Preload("CurrentChannel").Where("id IN ? AND is_active = ?", ids, true).Find(&users).Error; err != nil {
+		return nil, err
+	}
+	out := make([]NearbyUser, 0, len(users))
+	for i := range users {
+		code := users[i].GetCurrentChannelCode()
+		if channelCode != "" && code != channelCode {
+			continue
+		}
+		out = append(out, NearbyUser{
+			UserID:         users[i].ID,
+			Name:           users[i].DisplayName,
+			Channel:        code,
+			DistanceMeters: math.Round(distances[users[i].ID]),
+		})
+	}
+	sort.SliceStable(out, func(i, j int) bool { return out[i].DistanceMeters < out[j].DistanceMeters })
+	return out, nil
+}
+
+// NearbyChannels sugiere los canales públicos donde hay gente a menos de radius metros,
+// primero los que tienen más gente cerca. exclude (el canal actual) no se sugiere
+func NearbyChannels(db *gorm.DB, origin *models.UserLocation, exclude string, radius float64, since time.Time) ([]ChannelSuggestion, error) {
+	nearby, err := NearbyUsers(db, origin, "", radius, since)
+	if err != nil || len(nearby) == 0 {
+		return nil, err
+	}
+
+	byCode := make(map[string]*ChannelSuggestion)
+	var codes []string
+	for _, u := range nearby {
+		if u.Channel == "" || u.Channel == exclude {
+			continue
+		}
+		s, ok := byCode[u.Channel]
+		if !ok {
+			s = &ChannelSuggestion{Code: u.Channel, ClosestMeters: u.DistanceMeters}
+			byCode[u.Channel] = s
+			codes = append(codes, u.Channel)
+		}
+		s.NearbyUsers++
+		s.ClosestMeters = math.Min(s.ClosestMeters, u.DistanceMeters)
+	}
+	if len(codes) == 0 {
+		return nil, nil
+	}
+
+	var channels []models.Channel
+	if err := db.Where("code IN ? AND is_private = ?", codes, false).Find(&channels).Error; err != nil {
+		return nil, err
+	}
+	out := make([]ChannelSuggestion, 0, len(channels))
+	for _, ch := range channels {
+		s := byCode[ch.Code]
+		s.Name = ch.Name
+		out = append(out, *s)
+	}
+	sort.SliceStable(out, func(i, j int) bool {
+		if out[i].NearbyUsers != out[j].NearbyUsers {
+			return out[i].NearbyUsers > out[j].NearbyUsers
+		}
+		return out[i].ClosestMeters < out[j].ClosestMeters
+	})
+	return out, nil
+}
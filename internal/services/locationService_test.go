@@ -0,0 +1,91 @@
+package services
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"walkie-backend/internal/config"
+	"walkie-backend/internal/models"
+)
+
+func TestDistanceMeters(t *testing.T) {
+	// Madrid (Sol) a Barcelona (Plaça Catalunya) son unos 505 km
+	d := DistanceMeters(40.4168, -3.7038, 41.3870, 2.1701)
+	if d < 500000 || d > 510000 {
+		t.Fatalf("unexpected distance %.0f", d)
+	}
+	if d := DistanceMeters(40.4168, -3.7038, 40.4178, -3.7038); d < 100 || d > 120 {
+		t.Fatalf("expected ~111 m, got %.1f", d)
+	}
+}
+
+func TestNearbyUsersAndChannels(t *testing.T) {
+	cleanup := setupUserServiceTestDB(t)
+	defer cleanup()
+	db := config.DB
+	if err := db.AutoMigrate(&models.UserLocation{}); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	db.Create(&models.Channel{Code: "canal-1", Name: "Canal 1", MaxUsers: 10})
+	db.Create(&models.Channel{Code: "canal-2", Name: "Obra Norte", MaxUsers: 10})
+	db.Create(&models.Channel{Code: "privado", Name: "Privado", MaxUsers: 10, IsPrivate: true})
+
+	const lat, lon = 40.4168, -3.7038
+	svc := NewUserService()
+	place := func(name, channel string, dLat float64) models.User {
+		u := models.User{DisplayName: name, IsActive: true}
+		db.Create(&u)
+		if channel != "" {
+			if err := svc.ConnectUserToChannel(u.ID, channel); err != nil {
+				t.Fatalf("connect %s: %v", name, err)
+			}
+		}
+		if err := UpdateLocation(db, u.ID, lat+dLat, lon, 5); err != nil {
+			t.Fatalf("UpdateLocation %s: %v", name, err)
+		}
+		return u
+	}
+	me := place("Yo", "canal-1", 0)
+	place("Ana", "canal-1", 0.001)
+	place("Beto", "canal-2", 0.003)
+	place("Carla", "canal-2", 0.002)
+	place("Dani", "privado", 0.0005)
+	place("Eva", "canal-1", 0.1)
+	stale := place("Fede", "canal-1", 0.0002)
+	db.Model(&models.UserLocation{}).Where("user_id = ?", stale.ID).Update("updated_at", time.Now().Add(-time.Hour))
+
+	if err := UpdateLocation(db, me.ID, 91, 0, 0); !errors.Is(err, ErrInvalidLocation) {
+		t.Fatalf("expected ErrInvalidLocation, got %v", err)
+	}
+	since := time.Now().Add(-15 * time.Minute)
+	origin, err := FreshLocation(db, me.ID, since)
+	if err != nil || origin == nil || origin.Latitude != lat {
+		t.Fatalf("FreshLocation: %+v (%v)", origin, err)
+	}
+
+	members, err := NearbyUsers(db, origin, "canal-1", 1000, since)
+	if err != nil || len(members) != 1 || members[0].Name != "Ana" {
+		t.Fatalf("expected only Ana in canal-1, got %+v (%v)", members, err)
+	}
+
+	all, err := NearbyUsers(db, origin, "", 1000, since)
+	if err != nil || len(all) != 4 || all[0].Name != "Dani" || all[3].Name != "Beto" {
+		t.Fatalf("unexpected nearby users %+v (%v)", all, err)
+	}
+
+	suggestions, err := NearbyChannels(db, origin, "canal-1", 1000, since)
+	if err != nil || len(suggestions) != 1 {
+		t.Fatalf("expected one suggestion, got %+v (%v)", suggestions, err)
+	}
+	if s := suggestions[0]; s.Code != "canal-2" || s.Name != "Obra Norte" || s.NearbyUsers != 2 || s.ClosestMeters > 250 {
+		t.Fatalf("unexpected suggestion %+v", s)
+	}
+
+	if err := ClearLocation(db, me.ID); err != nil {
+		t.Fatalf("ClearLocation: %v", err)
+	}
+	if origin, _ := FreshLocation(db, me.ID, since); origin != nil {
+		t.Fatalf("location should be gone, got %+v", origin)
+	}
+}
@@ -0,0 +1,64 @@
+package services
+
+import (
+	"fmt"
+	"time"
+
+	"walkie-backend/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// janitorMeta identifica en el historial los cambios hechos por el mantenimiento
+var janitorMeta = EventMeta{Actor: "system:janitor", Source: models.EventSourceSystem}
+
+// DeactivateExpiredUsers marca inactivos a los usuarios sin actividad desde hace más de ttl
+func DeactivateExpiredUsers(db *gorm.DB, ttl time.Duration) (int64, error) {
+	if ttl <= 0 {
+		return 0, nil
+	}
+	res := db.Model(&models.User{}).
+		Where("is_active = ? AND last_active_at < ?", true, time.Now().Add(-ttl)).
+		Update("is_active", false)
+	if res.Error != nil {
+		return 0, fmt.Errorf("error desactivando usuarios: %w", res.Error)
+	}
+	return res.RowsAffected, nil
+}
+
+// DisconnectIdleUsers saca de su canal a los usuarios inactivos desde hace más de idle y
+// desactiva sus membresías. No toca last_active_at para que el token siga caducando.
+// Devuelve los usuarios desconectados.
+func DisconnectIdleUsers(db *gorm.DB, idle time.Duration) ([]uint, error) {
+	if idle <= 0 {
+		return nil, nil
+	}
+	var users []models.User
+	if err := db.Preload("CurrentChannel").
+		Where("current_channel_id IS NOT NULL AND last_active_at < ?", time.Now().Add(-idle)).
+		Find(&users).Error; err != nil {
+		return nil, fmt.Errorf("error buscando usuarios inactivos: %w", err)
+	}
+
+	disconnected := make([]uint, 0, len(users))
+	for i := range users {
+		user := &users[i]
+		previous := user.GetCurrentChannelCode()
+		err := db.Transaction(func(tx *gorm.DB) error {
+			now := time.Now()
+			if err := tx.Model(&models.ChannelMembership{}).
+				Where("user_id = ? AND active = ?", user.ID, true).
+				Updates(map[string]interface{}{"active": false, "left_at": now}).Error; err != nil {
+				return fmt.Errorf("error desactivando membresías: %w", err)
+			}
+			return tx.Model(&models.User{}).Where("id = ?", user.ID).
+				Update("current_channel_id", nil).Error
+		})
+		if err != nil {
+			return disconnected, fmt.Errorf("usuario %d: %w", user.ID, err)
+		}
+		AppendChannelEvent(db, janitorMeta, user.ID, models.ChannelEventAutoDisconnect, previous, "")
+		disconnected = append(disconnected, user.ID)
+	}
+	return disconnected, nil
+}
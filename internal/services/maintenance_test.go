@@ -0,0 +1,94 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"walkie-backend/internal/config"
+	"walkie-backend/internal/models"
+)
+
+func TestDeactivateExpiredUsers(t *testing.T) {
+	cleanup := setupUserServiceTestDB(t)
+	defer cleanup()
+
+	db := config.DB
+	stale := models.User{DisplayName: "Viejo", IsActive: true, LastActiveAt: time.Now().Add(-48 * time.Hour)}
+	fresh := models.User{DisplayName: "Nuevo", IsActive: true, LastActiveAt: time.Now()}
+	db.Create(&stale)
+	db.Create(&fresh)
+
+	n, err := DeactivateExpiredUsers(db, 24*time.Hour)
+	if err != nil {
+		t.Fatalf("DeactivateExpiredUsers: %v", err)
+	}
+	if n != 1 {
+		t.Fatalf("expected 1 expired user, got %d", n)
+	}
+
+	db.First(&stale, stale.ID)
+	db.First(&fresh, fresh.ID)
+	if stale.IsActive {
+		t.Error("expected stale user to be inactive")
+	}
+	if !fresh.IsActive {
+		t.Error("expected fresh user to stay active")
+	}
+}
+
+func TestDisconnectIdleUsers(t *testing.T) {
+	cleanup := setupUserServiceTestDB(t)
+	defer cleanup()
+
+	db := config.DB
+	if err := db.AutoMigrate(&models.ChannelEvent{}); err != nil {
+		t.Fatalf("migrate events: %v", err)
+	}
+	db.Create(&models.Channel{Code: "canal-1", Name: "Canal 1", MaxUsers: 10})
+
+	idle := models.User{DisplayName: "Dormido", IsActive: true}
+	busy := models.User{DisplayName: "Activo", IsActive: true}
+	db.Create(&idle)
+	db.Create(&busy)
+	svc := NewUserService()
+	for _, id := range []uint{idle.ID, busy.ID} {
+		if err := svc.ConnectUserToChannel(id, "canal-1"); err != nil {
+			t.Fatalf("connect: %v", err)
+		}
+	}
+	idleSince := time.Now().Add(-time.Hour)
+	db.Model(&models.User{}).Where("id = ?", idle.ID).Update("last_active_at", idleSince)
+
+	disconnected, err := DisconnectIdleUsers(db, 30*time.Minute)
+	if err != nil {
+		t.Fatalf("DisconnectIdleUsers: %v", err)
+	}
+	if len(disconnected) != 1 || disconnected[0] != idle.ID {
+		t.Fatalf("expected only idle user disconnected, got %v", disconnected)
+	}
+
+	var reloaded models.User
+	db.First(&reloaded, idle.ID)
+	if reloaded.CurrentChannelID != nil {
+		t.Error("expected idle user out of channel")
+	}
+	if reloaded.LastActiveAt.After(idleSince.Add(time.Minute)) {
+		t.Errorf("last_active_at should not be refreshed, got %v", reloaded.LastActiveAt)
+	}
+
+	var active int64
+	db.Model(&models.ChannelMembership{}).Where("user_id = ? AND active = ?", idle.ID, true).Count(&active)
+	if active != 0 {
+		t.Errorf("expected no active memberships, got %d", active)
+	}
+	db.Model(&models.ChannelMembership{}).Where("user_id = ? AND active = ?", busy.ID, true).Count(&active)
+	if active != 1 {
+		t.Errorf("expected busy user to keep membership, got %d", active)
+	}
+
+	events, _ := ChannelEvents(db, idle.ID, time.Now())
+	last := events[len(events)-1]
+	if last.Type != models.ChannelEventAutoDisconnect || last.FromChannel != "canal-1" || last.Actor != "system:janitor" {
+		t.Errorf("unexpected event %+v", last)
+	}
+}
@@ -0,0 +1,171 @@
+package services
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"walkie-backend/internal/models"
+
+	"gorm.io/gorm"
+)
+
+const (
+	MembershipActionAdd    = "add"
+	MembershipActionRemove = "remove"
+
+	bulkBatchSize = 50
+)
+
+// MembershipAssignment es una fila de la carga masiva de membresías
+type MembershipAssignment struct {
+	User    string `json:"user"`
+	Channel string `json:"channel"`
+	Action  string `json:"action,omitempty"`
+}
+
+// MembershipResult describe el resultado de aplicar una fila
+type MembershipResult struct {
+	Row     int    `json:"row"`
+	User    string `json:"user"`
+	UserID  uint   `json:"userId,omitempty"`
+	Channel string `json:"channel"`
+	Action  string `json:"action"`
+	Status  string `json:"status"`
+	Error   string `json:"error,omitempty"`
+}
+
+// MembershipService aplica cambios masivos de membresía
+type MembershipService struct {
+	db *gorm.DB
+}
+
+func NewMembershipService(db *gorm.DB) *MembershipService {
+	return &MembershipService{db: db}
+}
+
+// ApplyBulk valida cada fila y aplica las válidas en lotes transaccionales.
+// Si un lote falla se revierte completo y sus filas se marcan con error.
+func (s *MembershipService) ApplyBulk(rows []MembershipAssignment, meta EventMeta) []MembershipResult {
+	results := make([]MembershipResult, len(rows))
+	valid := make([]int, 0, len(rows))
+
+	for i, row := range rows {
+		res, userID := s.validateRow(i+1, row)
+		res.UserID = userID
+		results[i] = res
+		if res.Status == "" {
+			valid = append(valid, i)
+		}
+	}
+
+	for start := 0; start < len(valid); start += bulkBatchSize {
+		end := start + bulkBatchSize
+		if end > len(valid) {
+			end = len(valid)
+		}
+		batch := valid[start:end]
+
+		err := s.db.Transaction(func(tx *gorm.DB) error {
+			svc := &UserService{db: tx, meta: meta}
+			for _, idx := range batch {
+				res := &results[idx]
+				if err := applyAssignment(svc, tx, res); err != nil {
+					return fmt.Errorf("fila %d: %w", res.Row, err)
+				}
+				RecordAudit(tx, models.AuditEntry{
+					Actor:   meta.Actor,
+					Action:  "membership_" + res.Action,
+					UserID:  &res.UserID,
+					Channel: res.Channel,
+					Source:  meta.Source,
+					Details: "bulk " + meta.RequestID,
+				})
+			}
+			return nil
+		})
+
+		for _, idx := range batch {
+			if err != nil {
+				results[idx].Status = "error"
+				results[idx].Error = "lote revertido: " + err.Error()
+				continue
+			}
+			results[idx].Status = "ok"
+		}
+	}
+
+	return results
+}
+
+func (s *MembershipService) validateRow(row int, in MembershipAssignment) (MembershipResult, uint) {
+	res := MembershipResult{
+		Row:     row,
+		User:    strings.TrimSpace(in.User),
+		Channel: strings.TrimSpace(in.Channel),
+		Action:  strings.ToLower(strings.TrimSpace(in.Action)),
+	}
+	if res.Action == "" {
+		res.Action = MembershipActionAdd
+	}
+
+	fail := func(msg string) (MembershipResult, uint) {
+		res.Status = "error"
+		res.Error = msg
+		return res, 0
+	}
+
+	if res.Action != MembershipActionAdd && res.Action != MembershipActionRemove {
+		return fail("acción inválida: " + res.Action)
+	}
+	if res.User == "" || res.Channel == "" {
+		return fail("usuario y canal son requeridos")
+	}
+
+	user, err := s.findUser(res.User)
+	if err != nil {
+		return fail("usuario no encontrado")
+	}
+
+	var channel models.Channel
+	if err := s.db.Where("code = ?", res.Channel).First(&channel).Error; err != nil {
+		return fail("canal no encontrado")
+	}
+
+	return res, user.ID
+}
+
+// findUser acepta un ID numérico o un nombre visible
+func (s *MembershipService) findUser(ref string) (*models.User, error) {
+	var user models.User
+	if id, err := strconv.ParseUint(ref, 10, 64); err == nil {
+		if err := s.db.First(&user, uint(id)).Error; err == nil {
+			return &user, nil
+		}
+	}
+	if err := s.db.Where("display_name = ?", ref).First(&user).Error; err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+func applyAssignment(svc *UserService, tx *gorm.DB, res *MembershipResult) error {
+	if res.Action == MembershipActionAdd {
+		return svc.ConnectUserToChannel(res.UserID, res.Channel)
+	}
+
+	var user models.User
+	if err := tx.Preload("CurrentChannel").First(&user, res.UserID).Error; err != nil {
+		return err
+	}
+	if user.GetCurrentChannelCode() == res.Channel {
+		return svc.DisconnectUserFromCurrentChannel(res.UserID)
+	}
+
+	// Updates no devuelve ErrRecordNotFound: si el usuario no era miembro no cambia ninguna
+	// fila y la baja no hace nada, sin revertir el lote
+	return tx.Model(&models.ChannelMembership{}).
+		Where("user_id = ? AND channel_id = (?) AND active = ?", res.UserID,
+			tx.Model(&models.Channel{}).Select("id").Where("code = ?", res.Channel), true).
+		Updates(map[string]interface{}{"active": false, "left_at": gorm.Expr("CURRENT_TIMESTAMP")}).Error
+}
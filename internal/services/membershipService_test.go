@@ -0,0 +1,79 @@
+package services
+
+import (
+	"testing"
+
+	"walkie-backend/internal/config"
+	"walkie-backend/internal/models"
+)
+
+func TestMembershipServiceApplyBulk(t *testing.T) {
+	cleanup := setupUserServiceTestDB(t)
+	defer cleanup()
+
+	db := config.DB
+	if err := db.AutoMigrate(&models.AuditEntry{}); err != nil {
+		t.Fatalf("failed to migrate audit: %v", err)
+	}
+
+	ana := models.User{DisplayName: "Ana"}
+	beto := models.User{DisplayName: "Beto"}
+	db.Create(&ana)
+	db.Create(&beto)
+	db.Create(&models.Channel{Code: "canal-1", Name: "Canal 1", MaxUsers: 10})
+
+	rows := []MembershipAssignment{
+		{User: "Ana", Channel: "canal-1"},
+		{User: "2", Channel: "canal-1", Action: "add"},
+		{User: "Nadie", Channel: "canal-1"},
+		{User: "Ana", Channel: "canal-9"},
+		{User: "Ana", Channel: "canal-1", Action: "mover"},
+	}
+
+	results := NewMembershipService(db).ApplyBulk(rows, EventMeta{Actor: "admin", Source: models.EventSourceHTTP})
+
+	wantStatus := []string{"ok", "ok", "error", "error", "error"}
+	for i, want := range wantStatus {
+		if results[i].Status != want {
+			t.Errorf("row %d: expected %s, got %s (%s)", i+1, want, results[i].Status, results[i].Error)
+		}
+	}
+
+	var active int64
+	db.Model(&models.ChannelMembership{}).Where("active = ?", true).Count(&active)
+	if active != 2 {
+		t.Fatalf("expected 2 active memberships, got %d", active)
+	}
+
+	var audits int64
+	db.Model(&models.AuditEntry{}).Where("action = ?", "membership_add").Count(&audits)
+	if audits != 2 {
+		t.Fatalf("expected 2 audit entries, got %d", audits)
+	}
+
+	removal := NewMembershipService(db).ApplyBulk([]MembershipAssignment{
+		{User: "Ana", Channel: "canal-1", Action: "remove"},
+	}, EventMeta{Actor: "admin", Source: models.EventSourceHTTP})
+	if removal[0].Status != "ok" {
+		t.Fatalf("expected removal ok, got %+v", removal[0])
+	}
+
+	var updated models.User
+	db.First(&updated, ana.ID)
+	if updated.CurrentChannelID != nil {
+		t.Fatal("expected Ana to be disconnected")
+	}
+
+	// Dar de baja de un canal del que no es miembro no hace nada ni revierte el lote
+	db.Create(&models.Channel{Code: "canal-2", Name: "Canal 2", MaxUsers: 10})
+	noop := NewMembershipService(db).ApplyBulk([]MembershipAssignment{
+		{User: "Beto", Channel: "canal-2", Action: "remove"},
+	}, EventMeta{Actor: "admin", Source: models.EventSourceHTTP})
+	if noop[0].Status != "ok" {
+		t.Fatalf("expected no-op removal ok, got %+v", noop[0])
+	}
+	db.Model(&models.ChannelMembership{}).Where("user_id = ? AND active = ?", beto.ID, true).Count(&active)
+	if active != 1 {
+		t.Fatalf("expected Beto to stay in canal-1, got %d active memberships", active)
+	}
+}
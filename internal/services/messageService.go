@@ -0,0 +1,70 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"walkie-backend/internal/models"
+
+	"gorm.io/gorm"
+)
+
+const (
+	defaultMessagesLimit = 50
+	maxMessagesLimit     = 200
+)
+
+var (
+	ErrEmptyMessage   = errors.New("mensaje vacío")
+	ErrMessageTooLong = fmt.Errorf("el mensaje supera %d caracteres", models.MaxChannelMessageLength)
+)
+
+// PostChannelMessage valida y guarda un mensaje de texto del canal
+func PostChannelMessage(db *gorm.DB, channel string, sender *models.User, text string) (*models.ChannelMessage, error) {
+	if db == nil {
+		return nil, fmt.Errorf("base de datos no disponible")
+	}
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return nil, ErrEmptyMessage
+	}
+	if utf8.RuneCountInString(text) > models.MaxChannelMessageLength {
+		return nil, ErrMessageTooLong
+	}
+
+	msg := models.ChannelMessage{
+		CreatedAt:   time.Now(),
+		ChannelCode: channel,
+		SenderID:    sender.ID,
+		SenderName:  sender.DisplayName,
+		Text:        text,
+	}
+	if err := db.Create(&msg).Error; err != nil {
+		return nil, fmt.Errorf("error guardando mensaje: %w", err)
+	}
+	return &msg, nil
+}
+
+// ChannelMessages devuelve hasta limit mensajes del canal anteriores al ID before
+// (0 para empezar por el más reciente), del más reciente al más antiguo
+func ChannelMessages(db *gorm.DB, channel string, before uint, limit int) ([]models.ChannelMessage, error) {
+	if limit <= 0 {
+		limit = defaultMessagesLimit
+	}
+	if limit > maxMessagesLimit {
+		limit = maxMessagesLimit
+	}
+
+	query := db.Where("channel_code = ?", channel)
+	if before > 0 {
+		query = query.Where("id < ?", before)
+	}
+	var items []models.ChannelMessage
+	if err := query.Order("id DESC").Limit(limit).Find(&items).Error; err != nil {
+		return nil, fmt.Errorf("error leyendo mensajes: %w", err)
+	}
+	return items, nil
+}
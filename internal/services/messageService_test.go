@@ -0,0 +1,57 @@
+package services
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"walkie-backend/internal/config"
+	"walkie-backend/internal/models"
+)
+
+func TestChannelMessages_PaginatesNewestFirst(t *testing.T) {
+	cleanup := setupUserServiceTestDB(t)
+	defer cleanup()
+
+	db := config.DB
+	if err := db.AutoMigrate(&models.ChannelMessage{}); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	sender := &models.User{DisplayName: "ana"}
+	sender.ID = 1
+
+	for _, text := range []string{"uno", "dos", "tres", "cuatro"} {
+		if _, err := PostChannelMessage(db, "canal-1", sender, text); err != nil {
+			t.Fatalf("post %s: %v", text, err)
+		}
+	}
+	if _, err := PostChannelMessage(db, "canal-2", sender, "otro canal"); err != nil {
+		t.Fatalf("post other: %v", err)
+	}
+
+	page, err := ChannelMessages(db, "canal-1", 0, 3)
+	if err != nil || len(page) != 3 || page[0].Text != "cuatro" || page[2].Text != "dos" {
+		t.Fatalf("unexpected first page %+v (err=%v)", page, err)
+	}
+	rest, err := ChannelMessages(db, "canal-1", page[2].ID, 3)
+	if err != nil || len(rest) != 1 || rest[0].Text != "uno" || rest[0].SenderName != "ana" {
+		t.Fatalf("unexpected second page %+v (err=%v)", rest, err)
+	}
+}
+
+func TestPostChannelMessage_Validates(t *testing.T) {
+	cleanup := setupUserServiceTestDB(t)
+	defer cleanup()
+	if err := config.DB.AutoMigrate(&models.ChannelMessage{}); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	sender := &models.User{}
+
+	if _, err := PostChannelMessage(config.DB, "canal-1", sender, "   "); !errors.Is(err, ErrEmptyMessage) {
+		t.Fatalf("expected ErrEmptyMessage, got %v", err)
+	}
+	long := strings.Repeat("ñ", models.MaxChannelMessageLength+1)
+	if _, err := PostChannelMessage(config.DB, "canal-1", sender, long); !errors.Is(err, ErrMessageTooLong) {
+		t.Fatalf("expected ErrMessageTooLong, got %v", err)
+	}
+}
@@ -0,0 +1,143 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"walkie-backend/internal/models"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrModerationRuleNotFound = errors.New("regla no encontrada")
+	ErrModerationRuleExists   = errors.New("ya existe una regla con ese patrón")
+	ErrInvalidModerationRule  = errors.New("regla inválida")
+)
+
+// ModerationRuleInput son los campos editables de una regla; los nil no se modifican
+type ModerationRuleInput struct {
+	Pattern     *string `json:"pattern"`
+	Regex       *bool   `json:"regex"`
+	Action      *string `json:"action"`
+	Description *string `json:"description"`
+}
+
+// ModerationRuleService administra las reglas de moderación de las transcripciones
+type ModerationRuleService struct {
+	db *gorm.DB
+}
+
+func NewModerationRuleService(db *gorm.DB) *ModerationRuleService {
+	return &ModerationRuleService{db: db}
+}
+
+// List devuelve todas las reglas por orden de alta
+func (s *ModerationRuleService) List() ([]models.ModerationRule, error) {
+	var rules []models.ModerationRule
+	if err := s.db.Order("id ASC").Find(&rules).Error; err != nil {
+		return nil, fmt.Errorf("error leyendo reglas: %w", err)
+	}
+	return rules, nil
+}
+
+// Create da de alta una regla; pattern es obligatorio y action es block por defecto
+func (s *ModerationRuleService) Create(in ModerationRuleInput) (*models.ModerationRule, error) {
+	if in.Pattern == nil {
+		return nil, fmt.Errorf("%w: pattern es obligatorio", ErrInvalidModerationRule)
+	}
+	rule := models.ModerationRule{Action: models.ModerationActionBlock}
+	if err := applyModerationRuleInput(&rule, in); err != nil {
+		return nil, err
+	}
+	if err := s.ensureUnique(&rule); err != nil {
+		return nil, err
+	}
+	if err := s.db.Create(&rule).Error; err != nil {
+		return nil, fmt.Errorf("error creando regla: %w", err)
+	}
+	return &rule, nil
+}
+
+// Update modifica una regla
+func (s *ModerationRuleService) Update(id uint, in ModerationRuleInput) (*models.ModerationRule, error) {
+	var rule models.ModerationRule
+	if err := s.db.First(&rule, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrModerationRuleNotFound
+		}
+		return nil, err
+	}
+	if err := applyModerationRuleInput(&rule, in); err != nil {
+		return nil, err
+	}
+	if err := s.ensureUnique(&rule); err != nil {
+		return nil, err
+	}
+	if err := s.db.Save(&rule).Error; err != nil {
+		return nil, fmt.Errorf("error actualizando regla: %w", err)
+	}
+	return &rule, nil
+}
+
+// Delete borra una regla
+func (s *ModerationRuleService) Delete(id uint) error {
+	res := s.db.Delete(&models.ModerationRule{}, id)
+	if res.Error != nil {
+		return fmt.Errorf("error borrando regla: %w", res.Error)
+	}
+	if res.RowsAffected == 0 {
+		return ErrModerationRuleNotFound
+	}
+	return nil
+}
+
+func (s *ModerationRuleService) ensureUnique(rule *models.ModerationRule) error {
+	var count int64
+	err := s.db.Model(&models.ModerationRule{}).
+		Where("pattern = ? AND is_regex = ? AND id <> ?", rule.Pattern, rule.IsRegex, rule.ID).
+		Count(&count).Error
+	if err != nil {
+		return err
+	}
+	if count > 0 {
+		return ErrModerationRuleExists
+	}
+	return nil
+}
+
+// applyModerationRuleInput valida los campos; las frases se guardan normalizadas y las
+// expresiones regulares deben compilar
+func applyModerationRuleInput(rule *models.ModerationRule, in ModerationRuleInput) error {
+	if in.Regex != nil {
+		rule.IsRegex = *in.Regex
+	}
+	if in.Pattern != nil {
+		rule.Pattern = strings.TrimSpace(*in.Pattern)
+	}
+	if !rule.IsRegex {
+		rule.Pattern = models.NormalizeModerationText(rule.Pattern)
+	}
+	if rule.Pattern == "" || len(rule.Pattern) > 255 {
+		return fmt.Errorf("%w: pattern vacío o demasiado largo", ErrInvalidModerationRule)
+	}
+	if rule.IsRegex {
+		if _, err := regexp.Compile(rule.Pattern); err != nil {
+			return fmt.Errorf("%w: expresión regular inválida: %v", ErrInvalidModerationRule, err)
+		}
+	}
+	if in.Action != nil {
+		switch action := strings.ToLower(strings.TrimSpace(*in.Action)); action {
+		case models.ModerationActionBlock, models.ModerationActionFlag, models.ModerationActionLog:
+			rule.Action = action
+		default:
+			return fmt.Errorf("%w: action debe ser block, flag o log", ErrInvalidModerationRule)
+		}
+	}
+	if in.Description != nil {
+		rule.Description = strings.TrimSpace(*in.Description)
+	}
+	return nil
+}
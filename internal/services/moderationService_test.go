@@ -0,0 +1,65 @@
+package services
+
+import (
+	"errors"
+	"testing"
+
+	"walkie-backend/internal/config"
+	"walkie-backend/internal/models"
+)
+
+func TestModerationRuleService_CRUD(t *testing.T) {
+	cleanup := setupUserServiceTestDB(t)
+	defer cleanup()
+	if err := config.DB.AutoMigrate(&models.ModerationRule{}); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	svc := NewModerationRuleService(config.DB)
+	regex := true
+
+	created, err := svc.Create(ModerationRuleInput{Pattern: strPtr("  Olvida_TUS  reglas ")})
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	if created.Pattern != "olvida tus reglas" || created.Action != models.ModerationActionBlock || created.IsRegex {
+		t.Fatalf("unexpected rule %+v", created)
+	}
+	if _, err := svc.Create(ModerationRuleInput{Pattern: strPtr("olvida tus reglas"), Action: strPtr("log")}); !errors.Is(err, ErrModerationRuleExists) {
+		t.Fatalf("expected ErrModerationRuleExists, got %v", err)
+	}
+	if _, err := svc.Create(ModerationRuleInput{Pattern: strPtr("olvida tus reglas"), Regex: &regex}); err != nil {
+		t.Fatalf("same pattern as regex should be allowed: %v", err)
+	}
+	for _, in := range []ModerationRuleInput{
+		{Action: strPtr("flag")},
+		{Pattern: strPtr(" -_ ")},
+		{Pattern: strPtr("[a-"), Regex: &regex},
+		{Pattern: strPtr("algo"), Action: strPtr("borrar")},
+	} {
+		if _, err := svc.Create(in); !errors.Is(err, ErrInvalidModerationRule) {
+			t.Fatalf("expected ErrInvalidModerationRule for %+v, got %v", in, err)
+		}
+	}
+
+	updated, err := svc.Update(created.ID, ModerationRuleInput{Action: strPtr(" FLAG "), Description: strPtr("solo avisar")})
+	if err != nil {
+		t.Fatalf("update: %v", err)
+	}
+	if updated.Action != models.ModerationActionFlag || updated.Description != "solo avisar" || updated.Pattern != "olvida tus reglas" {
+		t.Fatalf("unexpected update %+v", updated)
+	}
+	if _, err := svc.Update(999, ModerationRuleInput{}); !errors.Is(err, ErrModerationRuleNotFound) {
+		t.Fatalf("expected ErrModerationRuleNotFound, got %v", err)
+	}
+
+	if err := svc.Delete(created.ID); err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+	if err := svc.Delete(created.ID); !errors.Is(err, ErrModerationRuleNotFound) {
+		t.Fatalf("expected ErrModerationRuleNotFound, got %v", err)
+	}
+	rules, err := svc.List()
+	if err != nil || len(rules) != 1 || !rules[0].IsRegex {
+		t.Fatalf("unexpected list %+v (%v)", rules, err)
+	}
+}
@@ -0,0 +1,98 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"walkie-backend/internal/models"
+
+	"gorm.io/gorm"
+)
+
+var ErrReceiptNotFound = errors.New("confirmación de escucha no encontrada")
+
+// CreateReceipts abre una confirmación pendiente por cada destinatario de la transmisión
+func CreateReceipts(db *gorm.DB, transmissionID string, senderID uint, channel string, recipients []uint, at time.Time) error {
+	if db == nil {
+		return fmt.Errorf("base de datos no disponible")
+	}
+	if transmissionID == "" || len(recipients) == 0 {
+		return nil
+	}
+	rows := make([]models.AudioReceipt, 0, len(recipients))
+	for _, id := range recipients {
+		rows = append(rows, models.AudioReceipt{
+			CreatedAt:      at,
+			TransmissionID: transmissionID,
+			RecipientID:    id,
+			SenderID:       senderID,
+			Channel:        channel,
+		})
+	}
+	return db.Create(&rows).Error
+}
+
+// MarkDelivered anota la primera entrega del clip al destinatario
+func MarkDelivered(db *gorm.DB, transmissionID string, recipientID uint, at time.Time) error {
+	if db == nil {
+		return fmt.Errorf("base de datos no disponible")
+	}
+	return db.Model(&models.AudioReceipt{}).
+		Where("transmission_id = ? AND recipient_id = ? AND delivered_at IS NULL", transmissionID, recipientID).
+		Update("delivered_at", at).Error
+}
+
+// MarkPlayed anota que el destinatario terminó de reproducir el clip. played es false si
+// ya constaba como escuchado, para no avisar dos veces al emisor.
+func MarkPlayed(db *gorm.DB, transmissionID string, recipientID uint, at time.Time) (receipt *models.AudioReceipt, played bool, err error) {
+	if db == nil {
+		return nil, false, fmt.Errorf("base de datos no disponible")
+	}
+	var r models.AudioReceipt
+	err = db.Where("transmission_id = ? AND recipient_id = ?", transmissionID, recipientID).First(&r).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, false, ErrReceiptNotFound
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	if r.PlayedAt != nil {
+		return &r, false, nil
+	}
+
+	updates := map[string]any{"played_at": at}
+	if r.DeliveredAt == nil {
+		r.DeliveredAt = &at
+		updates["delivered_at"] = at
+	}
+	res := db.Model(&models.AudioReceipt{}).Where("id = ? AND played_at IS NULL", r.ID).Updates(updates)
+	if res.Error != nil {
+		return nil, false, res.Error
+	}
+	r.PlayedAt = &at
+	// Otra petición pudo marcarlo a la vez; sólo avisa la que lo cambió
+	return &r, res.RowsAffected > 0, nil
+}
+
+// TransmissionReceipts devuelve las confirmaciones de una transmisión; sólo las ve quien
+// la envió
+func TransmissionReceipts(db *gorm.DB, transmissionID string, senderID uint) ([]models.AudioReceipt, error) {
+	if db == nil {
+		return nil, fmt.Errorf("base de datos no disponible")
+	}
+	var rows []models.AudioReceipt
+	if err := db.Where("transmission_id = ? AND sender_id = ?", transmissionID, senderID).Order("recipient_id ASC").Find(&rows).Error; err != nil {
+		return nil, err
+	}
+	if len(rows) == 0 {
+		return nil, ErrReceiptNotFound
+	}
+	return rows, nil
+}
+
+// PurgeReceipts borra las confirmaciones anteriores a cutoff
+func PurgeReceipts(db *gorm.DB, cutoff time.Time) (int64, error) {
+	res := db.Where("created_at < ?", cutoff).Delete(&models.AudioReceipt{})
+	return res.RowsAffected, res.Error
+}
@@ -0,0 +1,57 @@
+package services
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"walkie-backend/internal/config"
+	"walkie-backend/internal/models"
+)
+
+func TestReceipts_DeliveredOnceAndPlayedOnce(t *testing.T) {
+	cleanup := setupUserServiceTestDB(t)
+	defer cleanup()
+
+	db := config.DB
+	if err := db.AutoMigrate(&models.AudioReceipt{}); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	start := time.Now().Add(-time.Minute).Truncate(time.Second)
+	if err := CreateReceipts(db, "tx1", 1, "canal-1", []uint{2, 3}, start); err != nil {
+		t.Fatalf("create: %v", err)
+	}
+
+	first := start.Add(time.Second)
+	if err := MarkDelivered(db, "tx1", 2, first); err != nil {
+		t.Fatalf("delivered: %v", err)
+	}
+	if err := MarkDelivered(db, "tx1", 2, first.Add(time.Hour)); err != nil {
+		t.Fatalf("redelivered: %v", err)
+	}
+
+	r, played, err := MarkPlayed(db, "tx1", 3, first)
+	if err != nil || !played || r.DeliveredAt == nil {
+		t.Fatalf("expected first play to also mark delivery, got %+v %t %v", r, played, err)
+	}
+	if _, played, err := MarkPlayed(db, "tx1", 3, first.Add(time.Minute)); err != nil || played {
+		t.Fatalf("expected second play to be ignored, got %t %v", played, err)
+	}
+	if _, _, err := MarkPlayed(db, "tx1", 9, first); !errors.Is(err, ErrReceiptNotFound) {
+		t.Fatalf("expected ErrReceiptNotFound for a non recipient, got %v", err)
+	}
+
+	if _, err := TransmissionReceipts(db, "tx1", 2); !errors.Is(err, ErrReceiptNotFound) {
+		t.Fatalf("expected only the sender to read receipts, got %v", err)
+	}
+	rows, err := TransmissionReceipts(db, "tx1", 1)
+	if err != nil || len(rows) != 2 {
+		t.Fatalf("expected 2 receipts, got %d %v", len(rows), err)
+	}
+	if rows[0].DeliveredAt == nil || !rows[0].DeliveredAt.Equal(first) {
+		t.Fatalf("expected the first delivery time to be kept, got %v", rows[0].DeliveredAt)
+	}
+	if rows[0].PlayedAt != nil || rows[1].PlayedAt == nil {
+		t.Fatalf("unexpected played state: %+v", rows)
+	}
+}
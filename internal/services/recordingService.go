@@ -0,0 +1,119 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"walkie-backend/internal/models"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrRecordingActive   = errors.New("el canal ya se está grabando")
+	ErrNotRecording      = errors.New("el canal no se está grabando")
+	ErrRecordingNotFound = errors.New("grabación no encontrada")
+)
+
+// StartRecording abre una sesión de grabación en el canal; si ya hay una la devuelve
+// junto con ErrRecordingActive
+func StartRecording(db *gorm.DB, channelCode, startedBy string) (*models.ChannelRecording, error) {
+	if db == nil {
+		return nil, fmt.Errorf("base de datos no disponible")
+	}
+	if _, err := findChannel(db, channelCode); err != nil {
+		return nil, err
+	}
+	active, err := ActiveRecording(db, channelCode)
+	if err != nil {
+		return nil, err
+	}
+	if active != nil {
+		return active, ErrRecordingActive
+	}
+
+	rec := models.ChannelRecording{ChannelCode: channelCode, StartedAt: time.Now(), StartedBy: startedBy}
+	if err := db.Create(&rec).Error; err != nil {
+		return nil, err
+	}
+	return &rec, nil
+}
+
+// StopRecording cierra la grabación en curso del canal
+func StopRecording(db *gorm.DB, channelCode string) (*models.ChannelRecording, error) {
+	if db == nil {
+		return nil, fmt.Errorf("base de datos no disponible")
+	}
+	active, err := ActiveRecording(db, channelCode)
+	if err != nil {
+		return nil, err
+	}
+	if active == nil {
+		return nil, ErrNotRecording
+	}
+	now := time.Now()
+	if err := db.Model(active).Update("stopped_at", now).Error; err != nil {
+		return nil, err
+	}
+	active.StoppedAt = &now
+	return active, nil
+}
+
+// ActiveRecording devuelve la grabación en curso del canal o nil si no hay ninguna
+func ActiveRecording(db *gorm.DB, channelCode string) (*models.ChannelRecording, error) {
+	var rec models.ChannelRecording
+	err := db.Where("channel_code = ? AND stopped_at IS NULL", channelCode).Order("id DESC").First(&rec).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &rec, nil
+}
+
+// AppendRecordingClip añade la transmisión a la grabación en curso del canal; devuelve
+// false si el canal no se está grabando
+func AppendRecordingClip(db *gorm.DB, channelCode string, clip models.RecordingClip) (bool, error) {
+	if db == nil {
+		return false, fmt.Errorf("base de datos no disponible")
+	}
+	active, err := ActiveRecording(db, channelCode)
+	if err != nil || active == nil {
+		return false, err
+	}
+	clip.RecordingID = active.ID
+	if clip.CreatedAt.IsZero() {
+		clip.CreatedAt = time.Now()
+	}
+	if err := db.Create(&clip).Error; err != nil {
+		return false, fmt.Errorf("error guardando clip de la grabación: %w", err)
+	}
+	return true, nil
+}
+
+// ChannelRecordings lista las grabaciones del canal, de la más reciente a la más antigua
+func ChannelRecordings(db *gorm.DB, channelCode string) ([]models.ChannelRecording, error) {
+	var items []models.ChannelRecording
+	if err := db.Where("channel_code = ?", channelCode).Order("id DESC").Find(&items).Error; err != nil {
+		return nil, fmt.Errorf("error leyendo grabaciones: %w", err)
+	}
+	return items, nil
+}
+
+// RecordingClips devuelve la grabación y sus clips en orden, comprobando que sea del canal
+func RecordingClips(db *gorm.DB, channelCode string, id uint) (*models.ChannelRecording, []models.RecordingClip, error) {
+	var rec models.ChannelRecording
+	if err := db.Where("id = ? AND channel_code = ?", id, channelCode).First(&rec).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil, ErrRecordingNotFound
+		}
+		return nil, nil, err
+	}
+	var clips []models.RecordingClip
+	if err := db.Where("recording_id = ?", rec.ID).Order("created_at ASC, id ASC").Find(&clips).Error; err != nil {
+		return nil, nil, err
+	}
+	return &rec, clips, nil
+}
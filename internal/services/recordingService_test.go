@@ -0,0 +1,68 @@
+package services
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"walkie-backend/internal/config"
+	"walkie-backend/internal/models"
+)
+
+func TestRecording_StartAppendStop(t *testing.T) {
+	cleanup := setupUserServiceTestDB(t)
+	defer cleanup()
+
+	db := config.DB
+	if err := db.AutoMigrate(&models.ChannelRecording{}, &models.RecordingClip{}); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	if err := db.Create(&models.Channel{Code: "canal-1", Name: "Canal 1"}).Error; err != nil {
+		t.Fatalf("channel: %v", err)
+	}
+
+	if _, err := StartRecording(db, "canal-9", "admin"); !errors.Is(err, ErrChannelNotFound) {
+		t.Fatalf("expected ErrChannelNotFound, got %v", err)
+	}
+	if ok, err := AppendRecordingClip(db, "canal-1", models.RecordingClip{SenderID: 1, Data: []byte("x")}); ok || err != nil {
+		t.Fatalf("expected no recording, got %t %v", ok, err)
+	}
+
+	rec, err := StartRecording(db, "canal-1", "admin")
+	if err != nil {
+		t.Fatalf("start: %v", err)
+	}
+	if again, err := StartRecording(db, "canal-1", "otro"); !errors.Is(err, ErrRecordingActive) || again.ID != rec.ID {
+		t.Fatalf("expected the active recording back, got %v %v", again, err)
+	}
+
+	base := time.Now()
+	for i, offset := range []time.Duration{2 * time.Second, 0} {
+		clip := models.RecordingClip{SenderID: uint(i + 1), CreatedAt: base.Add(offset), Data: []byte{byte(i)}}
+		if ok, err := AppendRecordingClip(db, "canal-1", clip); !ok || err != nil {
+			t.Fatalf("append %d: %t %v", i, ok, err)
+		}
+	}
+
+	stopped, err := StopRecording(db, "canal-1")
+	if err != nil || stopped.IsActive() {
+		t.Fatalf("stop: %v %v", stopped, err)
+	}
+	if _, err := StopRecording(db, "canal-1"); !errors.Is(err, ErrNotRecording) {
+		t.Fatalf("expected ErrNotRecording, got %v", err)
+	}
+	if ok, _ := AppendRecordingClip(db, "canal-1", models.RecordingClip{SenderID: 3, Data: []byte("x")}); ok {
+		t.Fatal("expected clips to be ignored after stopping")
+	}
+
+	got, clips, err := RecordingClips(db, "canal-1", rec.ID)
+	if err != nil || got.ID != rec.ID {
+		t.Fatalf("clips: %v", err)
+	}
+	if len(clips) != 2 || clips[0].SenderID != 2 {
+		t.Fatalf("expected clips ordered by time, got %+v", clips)
+	}
+	if _, _, err := RecordingClips(db, "canal-2", rec.ID); !errors.Is(err, ErrRecordingNotFound) {
+		t.Fatalf("expected ErrRecordingNotFound for another channel, got %v", err)
+	}
+}
@@ -0,0 +1,89 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"unicode"
+
+	"walkie-backend/internal/config"
+	"walkie-backend/internal/models"
+
+	"gorm.io/gorm"
+)
+
+const (
+	defaultSearchLimit = 20
+	maxSearchLimit     = 100
+	maxSearchTerms     = 8
+)
+
+// ErrEmptyQuery indica que la búsqueda no tiene ninguna palabra válida
+var ErrEmptyQuery = errors.New("búsqueda vacía")
+
+// AccessibleChannelCodes devuelve los canales en los que el usuario ha estado alguna vez
+func AccessibleChannelCodes(db *gorm.DB, userID uint) ([]string, error) {
+	var codes []string
+	err := db.Model(&models.ChannelMembership{}).
+		Joins("JOIN channels ON channels.id = channel_memberships.channel_id AND channels.deleted_at IS NULL").
+		Where("channel_memberships.user_id = ?", userID).
+		Distinct().
+		Pluck("channels.code", &codes).Error
+	if err != nil {
+		return nil, fmt.Errorf("error leyendo canales del usuario: %w", err)
+	}
+	return codes, nil
+}
+
+// searchTerms separa la consulta en palabras (letras y números) para no pasar
+// operadores del usuario al motor de búsqueda
+func searchTerms(query string) []string {
+	terms := strings.FieldsFunc(strings.ToLower(query), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	if len(terms) > maxSearchTerms {
+		terms = terms[:maxSearchTerms]
+	}
+	return terms
+}
+
+// SearchTranscripts busca las palabras de query en las transcripciones de los canales
+// indicados y devuelve primero las más recientes. Exige que aparezcan todas las palabras.
+func SearchTranscripts(db *gorm.DB, channels []string, query string, limit int) ([]models.Transcript, error) {
+	terms := searchTerms(query)
+	if len(terms) == 0 {
+		return nil, ErrEmptyQuery
+	}
+	if len(channels) == 0 {
+		return []models.Transcript{}, nil
+	}
+	if limit <= 0 {
+		limit = defaultSearchLimit
+	}
+	if limit > maxSearchLimit {
+		limit = maxSearchLimit
+	}
+
+	q := db.Model(&models.Transcript{}).Where("transcripts.channel_code IN ?", channels)
+	switch db.Dialector.Name() {
+	case "postgres":
+		q = q.Where("to_tsvector('spanish', transcripts.text) @@ plainto_tsquery('spanish', ?)", strings.Join(terms, " "))
+	case "sqlite":
+		quoted := make([]string, len(terms))
+		for i, term := range terms {
+			quoted[i] = `"` + term + `"`
+		}
+		q = q.Joins("JOIN "+config.TranscriptSearchTable+" ON "+config.TranscriptSearchTable+".docid = transcripts.id").
+			Where(config.TranscriptSearchTable+" MATCH ?", strings.Join(quoted, " "))
+	default:
+		for _, term := range terms {
+			q = q.Where("LOWER(transcripts.text) LIKE ?", "%"+term+"%")
+		}
+	}
+
+	var items []models.Transcript
+	if err := q.Order("transcripts.created_at DESC, transcripts.id DESC").Limit(limit).Find(&items).Error; err != nil {
+		return nil, fmt.Errorf("error buscando transcripciones: %w", err)
+	}
+	return items, nil
+}
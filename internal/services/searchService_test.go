@@ -0,0 +1,79 @@
+package services
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"walkie-backend/internal/config"
+	"walkie-backend/internal/models"
+)
+
+func TestSearchTranscripts_MatchesAllTermsInGivenChannels(t *testing.T) {
+	cleanup := setupUserServiceTestDB(t)
+	defer cleanup()
+
+	db := config.DB
+	if err := db.AutoMigrate(&models.Transcript{}); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	// Una transcripción previa al índice debe quedar indexada al crearlo
+	if err := RecordTranscript(db, models.Transcript{ChannelCode: "canal-1", SenderID: 1, Text: "Camión en el almacén norte"}); err != nil {
+		t.Fatalf("record: %v", err)
+	}
+	if err := config.EnsureTranscriptSearch(db); err != nil {
+		t.Fatalf("ensure search: %v", err)
+	}
+
+	base := time.Now()
+	for i, tr := range []models.Transcript{
+		{ChannelCode: "canal-1", Text: "el camion llega al almacen sur"},
+		{ChannelCode: "canal-1", Text: "sin novedad en la puerta"},
+		{ChannelCode: "canal-2", Text: "camión averiado en el almacén"},
+	} {
+		tr.SenderID = 1
+		tr.CreatedAt = base.Add(time.Duration(i+1) * time.Minute)
+		if err := RecordTranscript(db, tr); err != nil {
+			t.Fatalf("record: %v", err)
+		}
+	}
+
+	hits, err := SearchTranscripts(db, []string{"canal-1"}, "CAMIÓN almacén", 0)
+	if err != nil {
+		t.Fatalf("search: %v", err)
+	}
+	if len(hits) != 2 || hits[0].Text != "el camion llega al almacen sur" || hits[1].Text != "Camión en el almacén norte" {
+		t.Fatalf("unexpected hits %+v", hits)
+	}
+
+	if hits, _ := SearchTranscripts(db, []string{"canal-1"}, `camión" OR "puerta`, 0); len(hits) != 0 {
+		t.Fatalf("operators must not be passed through, got %+v", hits)
+	}
+	if _, err := SearchTranscripts(db, []string{"canal-1"}, " ¿? ", 0); !errors.Is(err, ErrEmptyQuery) {
+		t.Fatalf("expected ErrEmptyQuery, got %v", err)
+	}
+}
+
+func TestAccessibleChannelCodes(t *testing.T) {
+	cleanup := setupUserServiceTestDB(t)
+	defer cleanup()
+
+	db := config.DB
+	channels := []models.Channel{{Code: "canal-1", Name: "1"}, {Code: "canal-2", Name: "2"}, {Code: "canal-3", Name: "3"}}
+	if err := db.Create(&channels).Error; err != nil {
+		t.Fatalf("create channels: %v", err)
+	}
+	memberships := []models.ChannelMembership{
+		{UserID: 7, ChannelID: channels[0].ID},
+		{UserID: 7, ChannelID: channels[1].ID},
+		{UserID: 8, ChannelID: channels[2].ID},
+	}
+	if err := db.Create(&memberships).Error; err != nil {
+		t.Fatalf("create memberships: %v", err)
+	}
+
+	codes, err := AccessibleChannelCodes(db, 7)
+	if err != nil || len(codes) != 2 {
+		t.Fatalf("unexpected codes %v (err=%v)", codes, err)
+	}
+}
@@ -0,0 +1,67 @@
+package services
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"walkie-backend/internal/models"
+
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+)
+
+const (
+	// PhoneCallerPrefix distingue a los usuarios que entran por teléfono
+	PhoneCallerPrefix = "Teléfono "
+	maxCallerIDLength = 64
+)
+
+// PhoneCallerUser devuelve el usuario que representa al número que llama, creándolo la
+// primera vez. Su PIN es un secreto aleatorio que nadie conoce, así que la cuenta no se
+// puede usar desde la app.
+func PhoneCallerUser(db *gorm.DB, callerID string) (*models.User, error) {
+	callerID = strings.TrimSpace(callerID)
+	if callerID == "" {
+		callerID = "anónimo"
+	}
+	if len(callerID) > maxCallerIDLength {
+		callerID = callerID[:maxCallerIDLength]
+	}
+	name := PhoneCallerPrefix + callerID
+
+	var user models.User
+	err := db.Where("display_name = ?", name).First(&user).Error
+	if err == nil {
+		return &user, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return nil, err
+	}
+	pinHash, err := bcrypt.GenerateFromPassword([]byte(hex.EncodeToString(secret)), bcrypt.DefaultCost)
+	if err != nil {
+		return nil, err
+	}
+	user = models.User{
+		DisplayName:  name,
+		IsActive:     true,
+		LastActiveAt: time.Now(),
+		PinHash:      string(pinHash),
+	}
+	if err := db.Create(&user).Error; err != nil {
+		// Otra llamada del mismo número pudo crearlo a la vez
+		if again := db.Where("display_name = ?", name).First(&user).Error; again == nil {
+			return &user, nil
+		}
+		return nil, fmt.Errorf("error creando usuario telefónico: %w", err)
+	}
+	return &user, nil
+}
@@ -0,0 +1,83 @@
+package services
+
+import (
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"walkie-backend/internal/models"
+
+	"gorm.io/gorm"
+)
+
+const (
+	defaultTranscriptsLimit = 100
+	maxTranscriptsLimit     = 500
+)
+
+// TranscriptsEnabled indica si se guardan las transcripciones (CHANNEL_TRANSCRIPTS=false las desactiva)
+func TranscriptsEnabled() bool {
+	return strings.TrimSpace(strings.ToLower(os.Getenv("CHANNEL_TRANSCRIPTS"))) != "false"
+}
+
+// RecordTranscript guarda la transcripción de una transmisión
+func RecordTranscript(db *gorm.DB, t models.Transcript) error {
+	if db == nil {
+		return fmt.Errorf("base de datos no disponible")
+	}
+	t.Text = strings.TrimSpace(t.Text)
+	if t.Text == "" {
+		return nil
+	}
+	if t.CreatedAt.IsZero() {
+		t.CreatedAt = time.Now()
+	}
+	if err := db.Create(&t).Error; err != nil {
+		return fmt.Errorf("error guardando transcripción: %w", err)
+	}
+	return nil
+}
+
+// ChannelTranscripts devuelve las transcripciones del canal posteriores a since, de la
+// más antigua a la más reciente
+func ChannelTranscripts(db *gorm.DB, channel string, since time.Time, limit int) ([]models.Transcript, error) {
+	if limit <= 0 {
+		limit = defaultTranscriptsLimit
+	}
+	if limit > maxTranscriptsLimit {
+		limit = maxTranscriptsLimit
+	}
+
+	query := db.Where("channel_code = ?", channel)
+	if !since.IsZero() {
+		query = query.Where("created_at > ?", since)
+	}
+	var items []models.Transcript
+	if err := query.Order("created_at ASC, id ASC").Limit(limit).Find(&items).Error; err != nil {
+		return nil, fmt.Errorf("error leyendo transcripciones: %w", err)
+	}
+	return items, nil
+}
+
+// RecentTranscripts devuelve las últimas limit transcripciones del canal en orden cronológico
+func RecentTranscripts(db *gorm.DB, channel string, limit int) ([]models.Transcript, error) {
+	if limit <= 0 {
+		limit = defaultTranscriptsLimit
+	}
+	if limit > maxTranscriptsLimit {
+		limit = maxTranscriptsLimit
+	}
+
+	var items []models.Transcript
+	err := db.Where("channel_code = ?", channel).
+		Order("created_at DESC, id DESC").
+		Limit(limit).
+		Find(&items).Error
+	if err != nil {
+		return nil, fmt.Errorf("error leyendo transcripciones: %w", err)
+	}
+	slices.Reverse(items)
+	return items, nil
+}
@@ -0,0 +1,64 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"walkie-backend/internal/config"
+	"walkie-backend/internal/models"
+)
+
+func TestChannelTranscripts_FiltersBySince(t *testing.T) {
+	cleanup := setupUserServiceTestDB(t)
+	defer cleanup()
+
+	db := config.DB
+	if err := db.AutoMigrate(&models.Transcript{}); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	base := time.Date(2025, 3, 1, 10, 0, 0, 0, time.UTC)
+	for i, text := range []string{"uno", "dos", "  ", "tres"} {
+		err := RecordTranscript(db, models.Transcript{
+			CreatedAt:   base.Add(time.Duration(i) * time.Minute),
+			ChannelCode: "canal-1",
+			SenderID:    1,
+			Text:        text,
+		})
+		if err != nil {
+			t.Fatalf("record %q: %v", text, err)
+		}
+	}
+	if err := RecordTranscript(db, models.Transcript{ChannelCode: "canal-2", SenderID: 1, Text: "otro"}); err != nil {
+		t.Fatalf("record other: %v", err)
+	}
+
+	all, err := ChannelTranscripts(db, "canal-1", time.Time{}, 0)
+	if err != nil || len(all) != 3 || all[0].Text != "uno" || all[2].Text != "tres" {
+		t.Fatalf("unexpected transcripts %+v (err=%v)", all, err)
+	}
+	recent, err := ChannelTranscripts(db, "canal-1", base.Add(30*time.Second), 0)
+	if err != nil || len(recent) != 2 || recent[0].Text != "dos" {
+		t.Fatalf("unexpected transcripts since %+v (err=%v)", recent, err)
+	}
+}
+
+func TestRecentTranscripts_ReturnsLatestInOrder(t *testing.T) {
+	cleanup := setupUserServiceTestDB(t)
+	defer cleanup()
+
+	db := config.DB
+	if err := db.AutoMigrate(&models.Transcript{}); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	base := time.Now()
+	for i, text := range []string{"uno", "dos", "tres"} {
+		if err := RecordTranscript(db, models.Transcript{CreatedAt: base.Add(time.Duration(i) * time.Second), ChannelCode: "canal-1", SenderID: 1, Text: text}); err != nil {
+			t.Fatalf("record: %v", err)
+		}
+	}
+
+	items, err := RecentTranscripts(db, "canal-1", 2)
+	if err != nil || len(items) != 2 || items[0].Text != "dos" || items[1].Text != "tres" {
+		t.Fatalf("unexpected transcripts %+v (err=%v)", items, err)
+	}
+}
@@ -0,0 +1,179 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"walkie-backend/internal/config"
+	"walkie-backend/internal/models"
+	"walkie-backend/pkg/audio"
+	"walkie-backend/pkg/objstore"
+
+	"gorm.io/gorm"
+)
+
+const (
+	defaultHistoryKeep  = 50
+	defaultHistoryLimit = 20
+)
+
+var ErrTransmissionNotFound = errors.New("transmisión no encontrada")
+
+// HistoryKeep es cuántas transmisiones se conservan por canal (CHANNEL_HISTORY_KEEP)
+func HistoryKeep() int {
+	return config.PositiveIntFromEnv("CHANNEL_HISTORY_KEEP", defaultHistoryKeep)
+}
+
+// historyStoresAudio indica si se guarda el audio además de los metadatos;
+// CHANNEL_HISTORY_AUDIO=false sólo conserva quién habló y cuándo
+func historyStoresAudio() bool {
+	return strings.TrimSpace(strings.ToLower(os.Getenv("CHANNEL_HISTORY_AUDIO"))) != "false"
+}
+
+// RecordTransmission guarda la transmisión y su audio, y recorta el historial del canal
+func RecordTransmission(db *gorm.DB, tx models.ChannelTransmission, data []byte) error {
+	if db == nil {
+		return fmt.Errorf("base de datos no disponible")
+	}
+	if tx.CreatedAt.IsZero() {
+		tx.CreatedAt = time.Now()
+	}
+	tx.SizeBytes = len(data)
+
+	// Con almacenamiento de objetos la tabla sólo guarda la clave; si la subida
+	// falla el audio se guarda en la base de datos como siempre
+	if store := AudioBlobStore(); store != nil && historyStoresAudio() && len(data) > 0 {
+		key := NewBlobKey("history/"+tx.ChannelCode, tx.Format)
+		if err := PutBlob(store, key, data, audio.MimeType(tx.Format)); err != nil {
+			log.Printf("[BLOBS] canal=%s no se pudo subir el audio: %v", tx.ChannelCode, err)
+		} else {
+			tx.AudioKey = key
+		}
+	}
+
+	err := db.Transaction(func(t *gorm.DB) error {
+		if historyStoresAudio() && len(data) > 0 && tx.AudioKey == "" {
+			blob := models.TransmissionBlob{Data: data}
+			if err := t.Create(&blob).Error; err != nil {
+				return err
+			}
+			tx.AudioBlobID = &blob.ID
+		}
+		return t.Create(&tx).Error
+	})
+	if err != nil {
+		return fmt.Errorf("error guardando transmisión: %w", err)
+	}
+
+	if err := pruneHistory(db, tx.ChannelCode, HistoryKeep()); err != nil {
+		log.Printf("No se pudo recortar el historial del canal %s: %v", tx.ChannelCode, err)
+	}
+	return nil
+}
+
+// pruneHistory borra las transmisiones más antiguas del canal a partir de la posición keep
+func pruneHistory(db *gorm.DB, channel string, keep int) error {
+	var stale []models.ChannelTransmission
+	if err := db.Where("channel_code = ?", channel).
+		Order("created_at DESC, id DESC").
+		Offset(keep).
+		Find(&stale).Error; err != nil {
+		return err
+	}
+	if len(stale) == 0 {
+		return nil
+	}
+
+	ids := make([]uint, 0, len(stale))
+	blobIDs := make([]uint, 0, len(stale))
+	var keys []string
+	for _, t := range stale {
+		ids = append(ids, t.ID)
+		if t.AudioBlobID != nil {
+			blobIDs = append(blobIDs, *t.AudioBlobID)
+		}
+		if t.AudioKey != "" {
+			keys = append(keys, t.AudioKey)
+		}
+	}
+	if store := AudioBlobStore(); store != nil && len(keys) > 0 {
+		defer deleteBlobs(store, keys)
+	}
+	return db.Transaction(func(t *gorm.DB) error {
+		if err := t.Delete(&models.ChannelTransmission{}, ids).Error; err != nil {
+			return err
+		}
+		if len(blobIDs) == 0 {
+			return nil
+		}
+		return t.Delete(&models.TransmissionBlob{}, blobIDs).Error
+	})
+}
+
+// ChannelHistory devuelve las últimas transmisiones del canal, de la más antigua a la más reciente
+func ChannelHistory(db *gorm.DB, channel string, limit int) ([]models.ChannelTransmission, error) {
+	if limit <= 0 {
+		limit = defaultHistoryLimit
+	}
+	var items []models.ChannelTransmission
+	if err := db.Where("channel_code = ?", channel).
+		Order("created_at DESC, id DESC").
+		Limit(limit).
+		Find(&items).Error; err != nil {
+		return nil, fmt.Errorf("error leyendo historial: %w", err)
+	}
+	for i, j := 0, len(items)-1; i < j; i, j = i+1, j-1 {
+		items[i], items[j] = items[j], items[i]
+	}
+	return items, nil
+}
+
+// FindTransmission devuelve la transmisión, comprobando que sea del canal indicado
+func FindTransmission(db *gorm.DB, channel string, id uint) (*models.ChannelTransmission, error) {
+	var tx models.ChannelTransmission
+	if err := db.Where("id = ? AND channel_code = ?", id, channel).First(&tx).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrTransmissionNotFound
+		}
+		return nil, err
+	}
+	return &tx, nil
+}
+
+// TransmissionAudio devuelve la transmisión y su audio, comprobando que sea del canal indicado
+func TransmissionAudio(db *gorm.DB, channel string, id uint) (*models.ChannelTransmission, []byte, error) {
+	tx, err := FindTransmission(db, channel, id)
+	if err != nil {
+		return nil, nil, err
+	}
+	if tx.AudioKey != "" {
+		store := AudioBlobStore()
+		if store == nil {
+			return tx, nil, ErrTransmissionNotFound
+		}
+		data, err := GetBlob(store, tx.AudioKey)
+		if errors.Is(err, objstore.ErrNotFound) {
+			return tx, nil, ErrTransmissionNotFound
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+		return tx, data, nil
+	}
+	if tx.AudioBlobID == nil {
+		return tx, nil, ErrTransmissionNotFound
+	}
+
+	var blob models.TransmissionBlob
+	if err := db.First(&blob, *tx.AudioBlobID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return tx, nil, ErrTransmissionNotFound
+		}
+		return nil, nil, err
+	}
+	return tx, blob.Data, nil
+}
@@ -0,0 +1,57 @@
+package services
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"walkie-backend/internal/config"
+	"walkie-backend/internal/models"
+)
+
+func TestRecordTransmission_KeepsMostRecentPerChannel(t *testing.T) {
+	cleanup := setupUserServiceTestDB(t)
+	defer cleanup()
+	t.Setenv("CHANNEL_HISTORY_KEEP", "3")
+
+	db := config.DB
+	if err := db.AutoMigrate(&models.ChannelTransmission{}, &models.TransmissionBlob{}); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+
+	base := time.Now().Add(-time.Hour)
+	for i := 0; i < 5; i++ {
+		tx := models.ChannelTransmission{ChannelCode: "canal-1", SenderID: uint(i + 1), CreatedAt: base.Add(time.Duration(i) * time.Minute)}
+		if err := RecordTransmission(db, tx, []byte{byte(i)}); err != nil {
+			t.Fatalf("record %d: %v", i, err)
+		}
+	}
+	if err := RecordTransmission(db, models.ChannelTransmission{ChannelCode: "canal-2", SenderID: 9}, []byte("otro")); err != nil {
+		t.Fatalf("record other channel: %v", err)
+	}
+
+	history, err := ChannelHistory(db, "canal-1", 10)
+	if err != nil {
+		t.Fatalf("history: %v", err)
+	}
+	if len(history) != 3 {
+		t.Fatalf("expected 3 transmissions kept, got %d", len(history))
+	}
+	if history[0].SenderID != 3 || history[2].SenderID != 5 {
+		t.Fatalf("expected oldest-to-newest senders 3..5, got %d..%d", history[0].SenderID, history[2].SenderID)
+	}
+
+	var blobs int64
+	db.Model(&models.TransmissionBlob{}).Count(&blobs)
+	if blobs != 4 {
+		t.Fatalf("expected pruned blobs to be deleted (4 left), got %d", blobs)
+	}
+
+	_, data, err := TransmissionAudio(db, "canal-1", history[2].ID)
+	if err != nil || len(data) != 1 || data[0] != 4 {
+		t.Fatalf("unexpected audio %v (err=%v)", data, err)
+	}
+	if _, _, err := TransmissionAudio(db, "canal-2", history[2].ID); !errors.Is(err, ErrTransmissionNotFound) {
+		t.Fatalf("expected not found for other channel, got %v", err)
+	}
+}
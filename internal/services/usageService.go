@@ -0,0 +1,105 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"walkie-backend/internal/config"
+	"walkie-backend/internal/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const usageDayFormat = "2006-01-02"
+
+var ErrQuotaExceeded = errors.New("cuota agotada")
+
+// Quota son los límites diarios de cada usuario; 0 es sin límite
+type Quota struct {
+	STTSeconds float64 `json:"sttSeconds"`
+	AITokens   int     `json:"aiTokens"`
+}
+
+// DailyQuota lee QUOTA_STT_SECONDS_PER_DAY y QUOTA_AI_TOKENS_PER_DAY
+func DailyQuota() Quota {
+	return Quota{
+		STTSeconds: config.FloatFromEnv("QUOTA_STT_SECONDS_PER_DAY", 0, 0, math.MaxFloat64),
+		AITokens:   config.IntFromEnv("QUOTA_AI_TOKENS_PER_DAY", 0),
+	}
+}
+
+// Exceeded indica si el uso del día ya alcanzó alguno de los límites
+func (q Quota) Exceeded(u models.UserUsage) bool {
+	return (q.STTSeconds > 0 && u.STTSeconds >= q.STTSeconds) ||
+		(q.AITokens > 0 && u.AITokens >= q.AITokens)
+}
+
+// UsageDay es el día (UTC) al que se imputa el uso
+func UsageDay(t time.Time) string {
+	return t.UTC().Format(usageDayFormat)
+}
+
+// UsageCost estima el coste en dólares con COST_STT_PER_MINUTE y COST_AI_PER_1K_TOKENS
+func UsageCost(u models.UserUsage) float64 {
+	return u.STTSeconds/60*costFromEnv("COST_STT_PER_MINUTE") +
+		float64(u.AITokens)/1000*costFromEnv("COST_AI_PER_1K_TOKENS")
+}
+
+func costFromEnv(key string) float64 {
+	return config.FloatFromEnv(key, 0, 0, math.MaxFloat64)
+}
+
+// RecordUsage suma segundos de transcripción y tokens de IA al día del usuario. Cada
+// llamada con segundos de STT cuenta como una petición.
+func RecordUsage(db *gorm.DB, userID uint, at time.Time, sttSeconds float64, aiTokens int) error {
+	if db == nil {
+		return fmt.Errorf("base de datos no disponible")
+	}
+	requests := 0
+	if sttSeconds > 0 {
+		requests = 1
+	}
+	row := models.UserUsage{
+		UpdatedAt:  time.Now(),
+		UserID:     userID,
+		Day:        UsageDay(at),
+		STTSeconds: sttSeconds,
+		AITokens:   aiTokens,
+		Requests:   requests,
+	}
+	return db.Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "user_id"}, {Name: "day"}},
+		DoUpdates: clause.Assignments(map[string]any{
+			"stt_seconds": gorm.Expr("user_usages.stt_seconds + ?", sttSeconds),
+			"ai_tokens":   gorm.Expr("user_usages.ai_tokens + ?", aiTokens),
+			"requests":    gorm.Expr("user_usages.requests + ?", requests),
+			"updated_at":  row.UpdatedAt,
+		}),
+	}).Create(&row).Error
+}
+
+// UsageFor devuelve el uso del usuario en el día; sin registros devuelve ceros
+func UsageFor(db *gorm.DB, userID uint, day string) (models.UserUsage, error) {
+	var u models.UserUsage
+	err := db.Where("user_id = ? AND day = ?", userID, day).First(&u).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return models.UserUsage{UserID: userID, Day: day}, nil
+	}
+	return u, err
+}
+
+// UsageHistory devuelve el uso de los últimos days días, del más reciente al más antiguo
+func UsageHistory(db *gorm.DB, userID uint, days int, now time.Time) ([]models.UserUsage, error) {
+	if days <= 0 {
+		days = 1
+	}
+	since := UsageDay(now.AddDate(0, 0, -(days - 1)))
+	var rows []models.UserUsage
+	err := db.Where("user_id = ? AND day >= ?", userID, since).
+		Order("day DESC").
+		Find(&rows).Error
+	return rows, err
+}
@@ -0,0 +1,59 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"walkie-backend/internal/config"
+	"walkie-backend/internal/models"
+)
+
+func TestRecordUsage_AccumulatesPerDayAndQuota(t *testing.T) {
+	cleanup := setupUserServiceTestDB(t)
+	defer cleanup()
+	t.Setenv("QUOTA_STT_SECONDS_PER_DAY", "60")
+	t.Setenv("COST_STT_PER_MINUTE", "0.006")
+	t.Setenv("COST_AI_PER_1K_TOKENS", "0.002")
+	db := config.DB
+	if err := db.AutoMigrate(&models.UserUsage{}); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+
+	today := time.Date(2026, 3, 2, 10, 0, 0, 0, time.UTC)
+	for _, call := range []struct {
+		at     time.Time
+		sec    float64
+		tokens int
+	}{
+		{today, 30, 0},
+		{today.Add(time.Minute), 0, 500},
+		{today.Add(time.Hour), 30, 1500},
+		{today.AddDate(0, 0, -1), 10, 100},
+	} {
+		if err := RecordUsage(db, 7, call.at, call.sec, call.tokens); err != nil {
+			t.Fatalf("record: %v", err)
+		}
+	}
+
+	u, err := UsageFor(db, 7, UsageDay(today))
+	if err != nil {
+		t.Fatalf("usage: %v", err)
+	}
+	if u.STTSeconds != 60 || u.AITokens != 2000 || u.Requests != 2 {
+		t.Fatalf("unexpected usage %+v", u)
+	}
+	if !DailyQuota().Exceeded(u) {
+		t.Fatal("expected the STT quota to be exhausted")
+	}
+	if cost := UsageCost(u); cost < 0.0099 || cost > 0.0101 {
+		t.Fatalf("unexpected cost %f", cost)
+	}
+
+	history, err := UsageHistory(db, 7, 7, today)
+	if err != nil || len(history) != 2 || history[0].Day != "2026-03-02" {
+		t.Fatalf("unexpected history %+v (err=%v)", history, err)
+	}
+	if other, _ := UsageFor(db, 8, UsageDay(today)); DailyQuota().Exceeded(other) {
+		t.Fatal("a user without usage should not be over quota")
+	}
+}
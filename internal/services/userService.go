@@ -1,47 +1,135 @@
 package services
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"strings"
 	"time"
 
 	"walkie-backend/internal/config"
 	"walkie-backend/internal/models"
+	"walkie-backend/internal/reqctx"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	// ErrChannelFull indica que el canal ya tiene MaxUsers miembros activos
+	ErrChannelFull = errors.New("canal lleno")
+	// ErrDisplayNameTaken indica que otro usuario ya usa ese nombre
+	ErrDisplayNameTaken = errors.New("nombre de usuario en uso")
 )
 
 type UserService struct {
 	db *gorm.DB
+	// reader es la réplica para las lecturas que sondean los clientes; nil lee de db
+	reader *gorm.DB
+	meta   EventMeta
 }
 
 func NewUserService() *UserService {
-	return &UserService{db: config.DB}
+	return &UserService{db: config.DB, reader: config.ReadReplica()}
+}
+
+// WithEventMeta devuelve una copia que registra los eventos de canal con ese actor y origen
+func (s *UserService) WithEventMeta(meta EventMeta) *UserService {
+	copy := *s
+	copy.meta = meta
+	return &copy
+}
+
+// query devuelve una sesión con el timeout por consulta configurado
+func (s *UserService) query() (*gorm.DB, context.CancelFunc) {
+	return s.session(s.db)
 }
 
-// ConnectUserToChannel conecta un usuario a un canal específico
+// readQuery es como query pero usa la réplica de lectura si la hay; sólo para lecturas que
+// toleran unos instantes de retraso
+func (s *UserService) readQuery() (*gorm.DB, context.CancelFunc) {
+	if s.reader != nil {
+		return s.session(s.reader)
+	}
+	return s.session(s.db)
+}
+
+func (s *UserService) session(db *gorm.DB) (*gorm.DB, context.CancelFunc) {
+	ctx := reqctx.WithRequestID(context.Background(), s.meta.RequestID)
+	ctx, cancel := context.WithTimeout(ctx, config.QueryTimeout())
+	return db.WithContext(ctx), cancel
+}
+
+// dbError traduce timeouts y caídas de conexión a config.ErrDBUnavailable
+func dbError(err error) error {
+	if err == nil {
+		return nil
+	}
+	if errors.Is(err, context.DeadlineExceeded) || !config.DBAvailable() {
+		return fmt.Errorf("%w: %v", config.ErrDBUnavailable, err)
+	}
+	return err
+}
+
+// ConnectUserToChannel conecta un usuario a un canal específico. Todo el cambio va en una
+// transacción que bloquea la fila del canal, así dos conexiones simultáneas no pueden
+// superar MaxUsers ni dejar al usuario a medio mover.
 func (s *UserService) ConnectUserToChannel(userID uint, channelCode string) error {
+	db, cancel := s.query()
+	defer cancel()
+
+	var previous string
+	err := db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		previous, err = s.connectInTx(tx, userID, channelCode)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	eventType := models.ChannelEventConnect
+	if previous != "" {
+		eventType = models.ChannelEventMove
+	}
+	AppendChannelEvent(db, s.meta, userID, eventType, previous, channelCode)
+
+	return nil
+}
+
+// connectInTx aplica la conexión dentro de tx y devuelve el canal anterior
+func (s *UserService) connectInTx(tx *gorm.DB, userID uint, channelCode string) (string, error) {
 	var channel models.Channel
-	if err := s.db.Where("code = ?", channelCode).First(&channel).Error; err != nil {
-		return fmt.Errorf("canal no encontrado: %s", channelCode)
+	query := tx.Where("code = ?", channelCode)
+	if tx.Dialector.Name() == "postgres" {
+		// SQLite serializa las escrituras por sí mismo y no admite FOR UPDATE
+		query = query.Clauses(clause.Locking{Strength: "UPDATE"})
+	}
+	if err := query.First(&channel).Error; err != nil {
+		if err = dbError(err); errors.Is(err, config.ErrDBUnavailable) {
+			return "", err
+		}
+		return "", fmt.Errorf("canal no encontrado: %s", channelCode)
 	}
 
 	// Verificar capacidad del canal
-	activeCount, err := channel.GetActiveMemberCount(s.db)
+	activeCount, err := channel.GetActiveMemberCount(tx)
 	if err != nil {
-		return fmt.Errorf("error verificando capacidad del canal: %w", err)
+		return "", fmt.Errorf("error verificando capacidad del canal: %w", err)
 	}
 	if activeCount >= int64(channel.MaxUsers) {
-		return fmt.Errorf("canal lleno: %s", channelCode)
+		return "", fmt.Errorf("%w: %s", ErrChannelFull, channelCode)
 	}
 
 	// Desconectar del canal actual si existe
-	if err := s.DisconnectUserFromCurrentChannel(userID); err != nil {
-		return fmt.Errorf("error desconectando del canal actual: %w", err)
+	previous, err := s.disconnectCurrent(tx, userID)
+	if err != nil {
+		return "", fmt.Errorf("error desconectando del canal actual: %w", err)
 	}
 
 	// Buscar o crear membresía
 	var membership models.ChannelMembership
-	err = s.db.Where("user_id = ? AND channel_id = ?", userID, channel.ID).First(&membership).Error
+	err = tx.Where("user_id = ? AND channel_id = ?", userID, channel.ID).First(&membership).Error
 	if err == gorm.ErrRecordNotFound {
 		// Crear nueva membresía
 		membership = models.ChannelMembership{
@@ -50,85 +138,220 @@ func (s *UserService) ConnectUserToChannel(userID uint, channelCode string) erro
 			Active:    true,
 			JoinedAt:  time.Now(),
 		}
-		if err := s.db.Create(&membership).Error; err != nil {
-			return fmt.Errorf("error creando membresía: %w", err)
+		if err := tx.Create(&membership).Error; err != nil {
+			return "", fmt.Errorf("error creando membresía: %w", err)
 		}
 	} else if err != nil {
-		return fmt.Errorf("error buscando membresía: %w", err)
+		return "", fmt.Errorf("error buscando membresía: %w", err)
 	} else {
 		// Activar membresía existente
 		membership.Activate()
-		if err := s.db.Save(&membership).Error; err != nil {
-			return fmt.Errorf("error activando membresía: %w", err)
+		if err := tx.Save(&membership).Error; err != nil {
+			return "", fmt.Errorf("error activando membresía: %w", err)
 		}
 	}
 
 	// Actualizar usuario
-	if err := s.db.Model(&models.User{}).Where("id = ?", userID).Updates(map[string]interface{}{
+	if err := tx.Model(&models.User{}).Where("id = ?", userID).Updates(map[string]interface{}{
 		"current_channel_id": channel.ID,
 		"last_active_at":     time.Now(),
 	}).Error; err != nil {
-		return fmt.Errorf("error actualizando usuario: %w", err)
+		return "", fmt.Errorf("error actualizando usuario: %w", err)
 	}
 
-	return nil
+	return previous, nil
 }
 
 // DisconnectUserFromCurrentChannel desconecta al usuario de su canal actual
 func (s *UserService) DisconnectUserFromCurrentChannel(userID uint) error {
+	return s.disconnectWithEvent(userID, models.ChannelEventDisconnect)
+}
+
+// disconnectWithEvent desconecta al usuario y registra el tipo de evento indicado
+func (s *UserService) disconnectWithEvent(userID uint, eventType string) error {
+	db, cancel := s.query()
+	defer cancel()
+
+	previous, err := s.disconnectCurrent(db, userID)
+	if err != nil {
+		return err
+	}
+	if previous != "" {
+		AppendChannelEvent(db, s.meta, userID, eventType, previous, "")
+	}
+	return nil
+}
+
+// disconnectCurrent limpia el canal actual y devuelve su código ("" si no estaba en ninguno)
+func (s *UserService) disconnectCurrent(db *gorm.DB, userID uint) (string, error) {
 	var user models.User
-	if err := s.db.First(&user, userID).Error; err != nil {
-		return fmt.Errorf("usuario no encontrado: %w", err)
+	if err := db.Preload("CurrentChannel").First(&user, userID).Error; err != nil {
+		return "", fmt.Errorf("usuario no encontrado: %w", err)
 	}
 
 	if user.CurrentChannelID == nil {
-		return nil // Ya no está en ningún canal
+		return "", nil // Ya no está en ningún canal
 	}
+	previous := user.GetCurrentChannelCode()
 
 	// Desactivar membresía actual
 	var membership models.ChannelMembership
-	if err := s.db.Where("user_id = ? AND channel_id = ? AND active = ?", userID, *user.CurrentChannelID, true).First(&membership).Error; err == nil {
+	if err := db.Where("user_id = ? AND channel_id = ? AND active = ?", userID, *user.CurrentChannelID, true).First(&membership).Error; err == nil {
 		membership.Deactivate()
-		if err := s.db.Save(&membership).Error; err != nil {
-			return fmt.Errorf("error desactivando membresía: %w", err)
+		if err := db.Save(&membership).Error; err != nil {
+			return "", fmt.Errorf("error desactivando membresía: %w", err)
 		}
 	}
 
 	// Limpiar canal actual del usuario
-	if err := s.db.Model(&user).Updates(map[string]interface{}{
+	if err := db.Model(&models.User{}).Where("id = ?", userID).Updates(map[string]interface{}{
 		"current_channel_id": nil,
 		"last_active_at":     time.Now(),
 	}).Error; err != nil {
-		return fmt.Errorf("error actualizando usuario: %w", err)
+		return "", fmt.Errorf("error actualizando usuario: %w", err)
 	}
 
-	return nil
+	return previous, nil
 }
 
 // GetUserWithChannel obtiene un usuario con su canal actual cargado
 func (s *UserService) GetUserWithChannel(userID uint) (*models.User, error) {
+	db, cancel := s.query()
+	defer cancel()
+
+	var user models.User
+	if err := db.Preload("CurrentChannel").First(&user, userID).Error; err != nil {
+		return nil, fmt.Errorf("usuario no encontrado: %w", dbError(err))
+	}
+	return &user, nil
+}
+
+// FindUserByDisplayName busca un usuario activo por su nombre sin distinguir mayúsculas
+func (s *UserService) FindUserByDisplayName(name string) (*models.User, error) {
+	db, cancel := s.query()
+	defer cancel()
+
 	var user models.User
-	if err := s.db.Preload("CurrentChannel").First(&user, userID).Error; err != nil {
-		return nil, fmt.Errorf("usuario no encontrado: %w", err)
+	err := db.Where("LOWER(display_name) = ? AND is_active = ?", strings.ToLower(strings.TrimSpace(name)), true).First(&user).Error
+	if err != nil {
+		return nil, fmt.Errorf("usuario no encontrado: %w", dbError(err))
 	}
 	return &user, nil
 }
 
+// UpdateLanguage guarda el idioma preferido del usuario (ya normalizado)
+func (s *UserService) UpdateLanguage(userID uint, language string) error {
+	db, cancel := s.query()
+	defer cancel()
+
+	res := db.Model(&models.User{}).Where("id = ?", userID).Update("language", language)
+	if res.Error != nil {
+		return fmt.Errorf("error actualizando idioma: %w", dbError(res.Error))
+	}
+	if res.RowsAffected == 0 {
+		return fmt.Errorf("usuario no encontrado: %w", gorm.ErrRecordNotFound)
+	}
+	return nil
+}
+
+// ProfileUpdate son los campos del perfil que el usuario puede cambiar; nil no los toca
+type ProfileUpdate struct {
+	DisplayName    *string
+	Language       *string
+	PushMuted      *bool
+	PushDirectOnly *bool
+	DoNotDisturb   *bool
+}
+
+// UpdateProfile aplica los cambios del perfil. El nombre se compara sin distinguir
+// mayúsculas porque los mensajes directos buscan al destinatario así.
+func (s *UserService) UpdateProfile(userID uint, in ProfileUpdate) error {
+	db, cancel := s.query()
+	defer cancel()
+
+	updates := map[string]any{}
+	if in.DisplayName != nil {
+		var taken int64
+		err := db.Model(&models.User{}).
+			Where("LOWER(display_name) = LOWER(?) AND id <> ?", *in.DisplayName, userID).
+			Count(&taken).Error
+		if err != nil {
+			return fmt.Errorf("error comprobando nombre: %w", dbError(err))
+		}
+		if taken > 0 {
+			return ErrDisplayNameTaken
+		}
+		updates["display_name"] = *in.DisplayName
+	}
+	if in.Language != nil {
+		updates["language"] = *in.Language
+	}
+	if in.PushMuted != nil {
+		updates["push_muted"] = *in.PushMuted
+	}
+	if in.PushDirectOnly != nil {
+		updates["push_direct_only"] = *in.PushDirectOnly
+	}
+	if in.DoNotDisturb != nil {
+		updates["do_not_disturb"] = *in.DoNotDisturb
+	}
+	if len(updates) == 0 {
+		return nil
+	}
+
+	res := db.Model(&models.User{}).Where("id = ?", userID).Updates(updates)
+	if res.Error != nil {
+		return fmt.Errorf("error actualizando perfil: %w", dbError(res.Error))
+	}
+	if res.RowsAffected == 0 {
+		return fmt.Errorf("usuario no encontrado: %w", gorm.ErrRecordNotFound)
+	}
+	return nil
+}
+
 // GetChannelActiveUsers obtiene los usuarios activos de un canal
 func (s *UserService) GetChannelActiveUsers(channelCode string) ([]models.User, error) {
+	db, cancel := s.readQuery()
+	defer cancel()
+
 	var users []models.User
-	err := s.db.Joins("JOIN channel_memberships ON users.id = channel_memberships.user_id").
+	err := db.Joins("JOIN channel_memberships ON users.id = channel_memberships.user_id").
 		Joins("JOIN channels ON channel_memberships.channel_id = channels.id").
 		Where("channels.code = ? AND channel_memberships.active = ?", channelCode, true).
 		Find(&users).Error
-	return users, err
+	return users, dbError(err)
 }
 
 // GetAvailableChannels obtiene los canales públicos disponibles
 func (s *UserService) GetAvailableChannels() ([]models.Channel, error) {
+	db, cancel := s.readQuery()
+	defer cancel()
+
 	var channels []models.Channel
-	if err := s.db.Where("is_private = ?", false).Find(&channels).Error; err != nil {
-		return nil, fmt.Errorf("error obteniendo canales: %w", err)
+	if err := db.Where("is_private = ?", false).Find(&channels).Error; err != nil {
+		return nil, fmt.Errorf("error obteniendo canales: %w", dbError(err))
 	}
 	return channels, nil
 }
+
+// DoNotDisturbAmong devuelve cuáles de ids tienen activado el modo no molestar
+func DoNotDisturbAmong(db *gorm.DB, ids []uint) ([]uint, error) {
+	if len(ids) == 0 {
+		return nil, nil
+	}
+	var dnd []uint
+	err := db.Model(&models.User{}).
+		Where("id IN ? AND do_not_disturb = ?", ids, true).
+		Pluck("id", &dnd).Error
+	return dnd, err
+}
+
+// DoNotDisturbInChannel devuelve los usuarios con no molestar cuyo canal actual es channelCode
+func DoNotDisturbInChannel(db *gorm.DB, channelCode string) ([]uint, error) {
+	var dnd []uint
+	err := db.Model(&models.User{}).
+		Joins("JOIN channels ON channels.id = users.current_channel_id").
+		Where("channels.code = ? AND users.do_not_disturb = ?", channelCode, true).
+		Pluck("users.id", &dnd).Error
+	return dnd, err
+}
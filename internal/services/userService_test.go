@@ -3,7 +3,9 @@ package services
 import (
 	"errors"
 	"fmt"
+	"path/filepath"
 	"strings"
+	"sync"
 	"testing"
 	"time"
 
@@ -116,9 +118,13 @@ func TestUserServiceConnectUserToChannel_ChannelFull(t *testing.T) {
 		t.Fatalf("unexpected error connecting first user: %v", err)
 	}
 
-	if err := service.ConnectUserToChannel(user2.ID, "canal-full"); err == nil {
+	err := service.ConnectUserToChannel(user2.ID, "canal-full")
+	if err == nil {
 		t.Fatalf("expected error when channel is full, got nil")
 	}
+	if !errors.Is(err, ErrChannelFull) {
+		t.Fatalf("expected ErrChannelFull, got %v", err)
+	}
 }
 
 func TestUserServiceConnectUserToChannel_ChannelNotFound(t *testing.T) {
@@ -480,3 +486,99 @@ func TestUserServiceGetAvailableChannels_DBError(t *testing.T) {
 		t.Error("expected error from DB")
 	}
 }
+
+func TestUserServiceConnectUserToChannel_ConcurrentConnectsRespectCapacity(t *testing.T) {
+	originalDB := config.DB
+	// Archivo con BEGIN IMMEDIATE para que SQLite serialice las transacciones como lo
+	// haría el bloqueo de fila en Postgres
+	dsn := "file:" + filepath.Join(t.TempDir(), "concurrent.db") + "?_txlock=immediate&_busy_timeout=5000"
+	db, err := gorm.Open(sqlite.Open(dsn), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	if err != nil {
+		t.Fatalf("open sqlite: %v", err)
+	}
+	if err := db.AutoMigrate(&models.User{}, &models.Channel{}, &models.ChannelMembership{}, &models.ChannelEvent{}); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	config.DB = db
+	defer func() {
+		sqlDB, _ := db.DB()
+		_ = sqlDB.Close()
+		config.DB = originalDB
+	}()
+
+	const capacity, contenders = 3, 12
+	channel := models.Channel{Code: "canal-carrera", Name: "Carrera", MaxUsers: capacity}
+	db.Create(&channel)
+	users := make([]models.User, contenders)
+	for i := range users {
+		users[i] = models.User{DisplayName: fmt.Sprintf("U%d", i)}
+		db.Create(&users[i])
+	}
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	connected, full := 0, 0
+	for i := range users {
+		wg.Add(1)
+		go func(id uint) {
+			defer wg.Done()
+			err := NewUserService().ConnectUserToChannel(id, "canal-carrera")
+			mu.Lock()
+			defer mu.Unlock()
+			switch {
+			case err == nil:
+				connected++
+			case strings.Contains(err.Error(), "canal lleno"):
+				full++
+			default:
+				t.Errorf("user %d: unexpected error %v", id, err)
+			}
+		}(users[i].ID)
+	}
+	wg.Wait()
+
+	if connected != capacity || full != contenders-capacity {
+		t.Fatalf("expected %d connected and %d rejected, got %d and %d", capacity, contenders-capacity, connected, full)
+	}
+
+	var active int64
+	db.Model(&models.ChannelMembership{}).Where("channel_id = ? AND active = ?", channel.ID, true).Count(&active)
+	var inChannel int64
+	db.Model(&models.User{}).Where("current_channel_id = ?", channel.ID).Count(&inChannel)
+	if active != capacity || inChannel != capacity {
+		t.Fatalf("inconsistent state: %d active memberships, %d users in channel", active, inChannel)
+	}
+}
+
+func TestUserService_ReadsFromReplica(t *testing.T) {
+	cleanup := setupUserServiceTestDB(t)
+	defer cleanup()
+
+	replica, err := gorm.Open(sqlite.Open("file:replica_read?mode=memory&cache=shared"), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	if err != nil {
+		t.Fatalf("failed to open replica: %v", err)
+	}
+	if err := replica.AutoMigrate(&models.Channel{}); err != nil {
+		t.Fatalf("failed to migrate replica: %v", err)
+	}
+	if err := replica.Create(&models.Channel{Code: "canal-replica", Name: "Réplica"}).Error; err != nil {
+		t.Fatalf("failed to seed replica: %v", err)
+	}
+	if err := config.DB.Create(&models.Channel{Code: "canal-principal", Name: "Principal"}).Error; err != nil {
+		t.Fatalf("failed to seed primary: %v", err)
+	}
+
+	service := &UserService{db: config.DB, reader: replica}
+	channels, err := service.GetAvailableChannels()
+	if err != nil || len(channels) != 1 || channels[0].Code != "canal-replica" {
+		t.Fatalf("expected replica channels, got %v (%v)", channels, err)
+	}
+
+	// Sin réplica se lee de la base principal
+	channels, err = (&UserService{db: config.DB}).GetAvailableChannels()
+	if err != nil || len(channels) != 1 || channels[0].Code != "canal-principal" {
+		t.Fatalf("expected primary channels, got %v (%v)", channels, err)
+	}
+}
@@ -0,0 +1,262 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"walkie-backend/internal/config"
+	"walkie-backend/internal/metrics"
+	"walkie-backend/internal/models"
+
+	"gorm.io/gorm"
+)
+
+const (
+	webhookWorkers      = 4
+	webhookEventBuffer  = 1024
+	webhookTimeout      = 10 * time.Second
+	webhookSubsTTL      = 30 * time.Second
+	maxWebhookErrorSize = 512
+)
+
+// webhookRetryDelays son las esperas entre intentos; al agotarlas el envío va al
+// registro de fallidos
+var webhookRetryDelays = []time.Duration{time.Second, 10 * time.Second, time.Minute, 5 * time.Minute}
+
+// WebhookEvent es el cuerpo JSON que recibe cada suscripción
+type WebhookEvent struct {
+	ID      string         `json:"id"`
+	Type    string         `json:"type"`
+	Channel string         `json:"channel,omitempty"`
+	At      time.Time      `json:"at"`
+	Data    map[string]any `json:"data,omitempty"`
+}
+
+// queuedWebhookEvent guarda la conexión vigente al emitir el evento
+type queuedWebhookEvent struct {
+	db    *gorm.DB
+	event WebhookEvent
+}
+
+type webhookJob struct {
+	db      *gorm.DB
+	sub     models.WebhookSubscription
+	id      string
+	event   string
+	payload []byte
+	attempt int
+}
+
+var webhookState = struct {
+	sync.Mutex
+	once     sync.Once
+	events   chan queuedWebhookEvent
+	jobs     chan webhookJob
+	subsDB   *gorm.DB
+	subs     []models.WebhookSubscription
+	loadedAt time.Time
+}{}
+
+// EmitWebhookEvent avisa a las suscripciones del evento sin bloquear al llamador; si la
+// cola está llena el evento se descarta
+func EmitWebhookEvent(eventType, channel string, data map[string]any) {
+	db := config.DB
+	if db == nil || !config.DBAvailable() {
+		return
+	}
+	startWebhookDispatcher()
+	event := WebhookEvent{
+		ID:      newWebhookDeliveryID(),
+		Type:    eventType,
+		Channel: channel,
+		At:      time.Now().UTC(),
+		Data:    data,
+	}
+	select {
+	case webhookState.events <- queuedWebhookEvent{db: db, event: event}:
+	default:
+		metrics.Inc("walkie_webhook_dropped_total", map[string]string{"event": eventType})
+	}
+}
+
+func startWebhookDispatcher() {
+	webhookState.once.Do(func() {
+		webhookState.events = make(chan queuedWebhookEvent, webhookEventBuffer)
+		webhookState.jobs = make(chan webhookJob, webhookEventBuffer)
+		go dispatchWebhookEvents()
+		for i := 0; i < webhookWorkers; i++ {
+			go runWebhookWorker()
+		}
+	})
+}
+
+// dispatchWebhookEvents reparte cada evento entre las suscripciones que lo piden
+func dispatchWebhookEvents() {
+	for queued := range webhookState.events {
+		event := queued.event
+		subs := webhookSubscriptionsFor(queued.db, event.Type, event.Channel)
+		if len(subs) == 0 {
+			continue
+		}
+		payload, err := json.Marshal(event)
+		if err != nil {
+			log.Printf("[WEBHOOKS] no se pudo serializar evento %s: %v", event.Type, err)
+			continue
+		}
+		for _, sub := range subs {
+			webhookState.jobs <- webhookJob{db: queued.db, sub: sub, id: event.ID, event: event.Type, payload: payload, attempt: 1}
+		}
+	}
+}
+
+func runWebhookWorker() {
+	for job := range webhookState.jobs {
+		runWebhookJob(job)
+	}
+}
+
+// runWebhookJob hace un intento; si falla programa el siguiente con un temporizador para
+// no ocupar al worker, y al agotar los reintentos lo guarda en el registro de fallidos
+func runWebhookJob(job webhookJob) {
+	status, err := deliverWebhook(&job.sub, job.id, job.event, job.payload)
+	if err == nil {
+		metrics.Inc("walkie_webhook_deliveries_total", map[string]string{"event": job.event, "result": "ok"})
+		return
+	}
+	if job.attempt <= len(webhookRetryDelays) {
+		metrics.Inc("walkie_webhook_deliveries_total", map[string]string{"event": job.event, "result": "retry"})
+		delay := webhookRetryDelays[job.attempt-1]
+		job.attempt++
+		time.AfterFunc(delay, func() {
+			select {
+			case webhookState.jobs <- job:
+			default:
+				recordWebhookDeadLetter(job, status, fmt.Errorf("cola de envíos llena: %w", err))
+			}
+		})
+		return
+	}
+	recordWebhookDeadLetter(job, status, err)
+}
+
+func recordWebhookDeadLetter(job webhookJob, status int, err error) {
+	metrics.Inc("walkie_webhook_deliveries_total", map[string]string{"event": job.event, "result": "failed"})
+	log.Printf("[WEBHOOKS] suscripción=%d evento=%s agotó %d intentos: %v", job.sub.ID, job.event, job.attempt, err)
+	dl := models.WebhookDeadLetter{
+		CreatedAt:      time.Now(),
+		SubscriptionID: job.sub.ID,
+		DeliveryID:     job.id,
+		Event:          job.event,
+		Payload:        job.payload,
+		Attempts:       job.attempt,
+		StatusCode:     status,
+		LastError:      truncateWebhookError(err),
+	}
+	if err := job.db.Create(&dl).Error; err != nil {
+		log.Printf("[WEBHOOKS] no se pudo guardar el envío fallido suscripción=%d: %v", job.sub.ID, err)
+	}
+}
+
+// deliverWebhook hace un POST firmado y devuelve el código HTTP; sólo 2xx cuenta como entregado.
+// La firma es HMAC-SHA256 de "<timestamp>.<cuerpo>" con el secreto de la suscripción.
+func deliverWebhook(sub *models.WebhookSubscription, deliveryID, event string, payload []byte) (int, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), webhookTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, sub.URL, bytes.NewReader(payload))
+	if err != nil {
+		return 0, err
+	}
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "walkie-backend-webhooks")
+	req.Header.Set("X-Walkie-Event", event)
+	req.Header.Set("X-Walkie-Delivery", deliveryID)
+	req.Header.Set("X-Walkie-Timestamp", timestamp)
+	req.Header.Set("X-Walkie-Signature", "sha256="+SignWebhookPayload(sub.Secret, timestamp, payload))
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64*1024))
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, fmt.Errorf("respuesta %d", resp.StatusCode)
+	}
+	return resp.StatusCode, nil
+}
+
+// SignWebhookPayload calcula la firma que va en X-Walkie-Signature (sin el prefijo sha256=)
+func SignWebhookPayload(secret, timestamp string, payload []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(payload)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// webhookSubscriptionsFor devuelve las suscripciones al evento, con una caché corta
+// para no consultar la base de datos por cada transmisión
+func webhookSubscriptionsFor(db *gorm.DB, eventType, channel string) []models.WebhookSubscription {
+	webhookState.Lock()
+	defer webhookState.Unlock()
+	if webhookState.subsDB != db || webhookState.loadedAt.IsZero() || time.Since(webhookState.loadedAt) > webhookSubsTTL {
+		// Un fallo también se guarda para no reintentar la consulta en cada evento
+		var subs []models.WebhookSubscription
+		if err := db.Find(&subs).Error; err != nil {
+			log.Printf("[WEBHOOKS] error leyendo suscripciones: %v", err)
+			subs = nil
+		}
+		webhookState.subs = subs
+		webhookState.subsDB = db
+		webhookState.loadedAt = time.Now()
+	}
+
+	var out []models.WebhookSubscription
+	for _, sub := range webhookState.subs {
+		if sub.Channel != "" && sub.Channel != channel {
+			continue
+		}
+		for _, ev := range strings.Split(sub.Events, ",") {
+			if ev == eventType {
+				out = append(out, sub)
+				break
+			}
+		}
+	}
+	return out
+}
+
+// invalidateWebhookSubscriptions fuerza a releer las suscripciones en el próximo evento
+func invalidateWebhookSubscriptions() {
+	webhookState.Lock()
+	webhookState.loadedAt = time.Time{}
+	webhookState.Unlock()
+}
+
+func newWebhookDeliveryID() string {
+	buf := make([]byte, 16)
+	_, _ = rand.Read(buf)
+	return hex.EncodeToString(buf)
+}
+
+func truncateWebhookError(err error) string {
+	msg := err.Error()
+	if len(msg) > maxWebhookErrorSize {
+		msg = msg[:maxWebhookErrorSize]
+	}
+	return msg
+}
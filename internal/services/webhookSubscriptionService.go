@@ -0,0 +1,170 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+
+	"walkie-backend/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// Tipos de evento que se pueden suscribir
+const (
+	WebhookEventTransmissionStarted = "transmission_started"
+	WebhookEventCommandExecuted     = "command_executed"
+	WebhookEventUserJoined          = "user_joined"
+	WebhookEventUserLeft            = "user_left"
+	WebhookEventTranscriptAvailable = "transcript_available"
+)
+
+var webhookEventTypes = map[string]bool{
+	WebhookEventTransmissionStarted: true,
+	WebhookEventCommandExecuted:     true,
+	WebhookEventUserJoined:          true,
+	WebhookEventUserLeft:            true,
+	WebhookEventTranscriptAvailable: true,
+}
+
+var (
+	ErrSubscriptionNotFound = errors.New("suscripción no encontrada")
+	ErrInvalidSubscription  = errors.New("suscripción inválida")
+	ErrDeadLetterNotFound   = errors.New("envío fallido no encontrado")
+)
+
+const (
+	defaultDeadLetterLimit = 100
+	MaxDeadLetterLimit     = 500
+)
+
+// WebhookSubscriptionService administra las suscripciones a eventos de canal y el
+// registro de envíos que agotaron los reintentos
+type WebhookSubscriptionService struct {
+	db *gorm.DB
+}
+
+func NewWebhookSubscriptionService(db *gorm.DB) *WebhookSubscriptionService {
+	return &WebhookSubscriptionService{db: db}
+}
+
+// Create valida la URL, los eventos y el canal y guarda la suscripción; Secret lo pone el llamador
+func (s *WebhookSubscriptionService) Create(sub *models.WebhookSubscription) error {
+	sub.URL = strings.TrimSpace(sub.URL)
+	sub.Channel = strings.ToLower(strings.TrimSpace(sub.Channel))
+	u, err := url.Parse(sub.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("%w: la URL debe ser http o https", ErrInvalidSubscription)
+	}
+	if sub.Secret == "" {
+		return fmt.Errorf("%w: falta el secreto", ErrInvalidSubscription)
+	}
+	events, err := normalizeWebhookEvents(sub.Events)
+	if err != nil {
+		return err
+	}
+	sub.Events = events
+	if sub.Channel != "" {
+		if _, err := findChannel(s.db, sub.Channel); err != nil {
+			if errors.Is(err, ErrChannelNotFound) {
+				return fmt.Errorf("%w: el canal %s no existe", ErrInvalidSubscription, sub.Channel)
+			}
+			return err
+		}
+	}
+	if err := s.db.Create(sub).Error; err != nil {
+		return fmt.Errorf("error creando suscripción: %w", err)
+	}
+	invalidateWebhookSubscriptions()
+	return nil
+}
+
+// normalizeWebhookEvents valida la lista separada por comas y la deja ordenada y sin duplicados
+func normalizeWebhookEvents(raw string) (string, error) {
+	seen := make(map[string]bool)
+	for _, ev := range strings.Split(raw, ",") {
+		ev = strings.ToLower(strings.TrimSpace(ev))
+		if ev == "" {
+			continue
+		}
+		if !webhookEventTypes[ev] {
+			return "", fmt.Errorf("%w: evento desconocido %q", ErrInvalidSubscription, ev)
+		}
+		seen[ev] = true
+	}
+	if len(seen) == 0 {
+		return "", fmt.Errorf("%w: indica al menos un evento", ErrInvalidSubscription)
+	}
+	events := make([]string, 0, len(seen))
+	for ev := range seen {
+		events = append(events, ev)
+	}
+	sort.Strings(events)
+	return strings.Join(events, ","), nil
+}
+
+// List devuelve las suscripciones, las más recientes primero
+func (s *WebhookSubscriptionService) List() ([]models.WebhookSubscription, error) {
+	var items []models.WebhookSubscription
+	if err := s.db.Order("id DESC").Find(&items).Error; err != nil {
+		return nil, fmt.Errorf("error leyendo suscripciones: %w", err)
+	}
+	return items, nil
+}
+
+// Delete borra una suscripción; los envíos que ya estén en curso terminan
+func (s *WebhookSubscriptionService) Delete(id uint) error {
+	res := s.db.Delete(&models.WebhookSubscription{}, id)
+	if res.Error != nil {
+		return fmt.Errorf("error borrando suscripción: %w", res.Error)
+	}
+	if res.RowsAffected == 0 {
+		return ErrSubscriptionNotFound
+	}
+	invalidateWebhookSubscriptions()
+	return nil
+}
+
+// DeadLetters devuelve los envíos fallidos, los más recientes primero; subscriptionID 0 no filtra
+func (s *WebhookSubscriptionService) DeadLetters(subscriptionID uint, limit int) ([]models.WebhookDeadLetter, error) {
+	if limit <= 0 || limit > MaxDeadLetterLimit {
+		limit = defaultDeadLetterLimit
+	}
+	q := s.db.Order("id DESC").Limit(limit)
+	if subscriptionID != 0 {
+		q = q.Where("subscription_id = ?", subscriptionID)
+	}
+	var items []models.WebhookDeadLetter
+	if err := q.Find(&items).Error; err != nil {
+		return nil, fmt.Errorf("error leyendo envíos fallidos: %w", err)
+	}
+	return items, nil
+}
+
+// Redeliver reintenta una vez un envío fallido y lo borra del registro si llega
+func (s *WebhookSubscriptionService) Redeliver(id uint) error {
+	var dl models.WebhookDeadLetter
+	if err := s.db.First(&dl, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrDeadLetterNotFound
+		}
+		return err
+	}
+	var sub models.WebhookSubscription
+	if err := s.db.First(&sub, dl.SubscriptionID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrSubscriptionNotFound
+		}
+		return err
+	}
+	if _, err := deliverWebhook(&sub, dl.DeliveryID, dl.Event, dl.Payload); err != nil {
+		s.db.Model(&dl).Updates(map[string]interface{}{
+			"attempts":   gorm.Expr("attempts + 1"),
+			"last_error": truncateWebhookError(err),
+		})
+		return err
+	}
+	return s.db.Delete(&dl).Error
+}
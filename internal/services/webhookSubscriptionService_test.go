@@ -0,0 +1,141 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"walkie-backend/internal/config"
+	"walkie-backend/internal/models"
+)
+
+func TestWebhookSubscriptionService_Validation(t *testing.T) {
+	cleanup := setupUserServiceTestDB(t)
+	defer cleanup()
+	db := config.DB
+	if err := db.AutoMigrate(&models.WebhookSubscription{}, &models.WebhookDeadLetter{}); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	svc := NewWebhookSubscriptionService(db)
+
+	cases := []models.WebhookSubscription{
+		{URL: "ftp://example.com", Secret: "s", Events: WebhookEventUserJoined},
+		{URL: "https://example.com", Secret: "s", Events: "otro_evento"},
+		{URL: "https://example.com", Secret: "s", Events: " , "},
+		{URL: "https://example.com", Secret: "s", Events: WebhookEventUserJoined, Channel: "no-existe"},
+	}
+	for _, c := range cases {
+		if err := svc.Create(&c); !errors.Is(err, ErrInvalidSubscription) {
+			t.Fatalf("esperaba ErrInvalidSubscription para %+v, obtuve %v", c, err)
+		}
+	}
+
+	sub := models.WebhookSubscription{URL: "https://example.com/hook", Secret: "s", Events: "user_left, USER_JOINED,user_left"}
+	if err := svc.Create(&sub); err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	if sub.Events != "user_joined,user_left" {
+		t.Fatalf("eventos normalizados = %q", sub.Events)
+	}
+	if err := svc.Delete(sub.ID); err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+	if err := svc.Delete(sub.ID); !errors.Is(err, ErrSubscriptionNotFound) {
+		t.Fatalf("esperaba ErrSubscriptionNotFound, obtuve %v", err)
+	}
+}
+
+func TestWebhookDispatcher_SignsRetriesAndDeadLetters(t *testing.T) {
+	cleanup := setupUserServiceTestDB(t)
+	defer cleanup()
+	db := config.DB
+	if err := db.AutoMigrate(&models.WebhookSubscription{}, &models.WebhookDeadLetter{}, &models.ChannelEvent{}); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	db.Create(&models.Channel{Code: "canal-1", Name: "Canal 1", MaxUsers: 10})
+	webhookRetryDelays = []time.Duration{time.Millisecond, time.Millisecond}
+
+	received := make(chan *http.Request, 10)
+	bodies := make(chan []byte, 10)
+	ok := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		received <- r
+		bodies <- body
+	}))
+	defer ok.Close()
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer failing.Close()
+
+	svc := NewWebhookSubscriptionService(db)
+	good := models.WebhookSubscription{URL: ok.URL, Secret: "secreto", Events: WebhookEventUserJoined, Channel: "canal-1"}
+	bad := models.WebhookSubscription{URL: failing.URL, Secret: "otro", Events: WebhookEventUserJoined}
+	for _, sub := range []*models.WebhookSubscription{&good, &bad} {
+		if err := svc.Create(sub); err != nil {
+			t.Fatalf("create: %v", err)
+		}
+	}
+
+	// Otro canal: sólo lo recibe la suscripción sin filtro de canal
+	AppendChannelEvent(db, EventMeta{Actor: "user:7"}, 7, models.ChannelEventConnect, "", "canal-2")
+	AppendChannelEvent(db, EventMeta{Actor: "user:7"}, 7, models.ChannelEventMove, "canal-2", "canal-1")
+
+	select {
+	case r := <-received:
+		body := <-bodies
+		if r.Header.Get("X-Walkie-Event") != WebhookEventUserJoined {
+			t.Fatalf("evento = %q", r.Header.Get("X-Walkie-Event"))
+		}
+		want := "sha256=" + SignWebhookPayload("secreto", r.Header.Get("X-Walkie-Timestamp"), body)
+		if r.Header.Get("X-Walkie-Signature") != want {
+			t.Fatalf("firma inválida")
+		}
+		var event WebhookEvent
+		if err := json.Unmarshal(body, &event); err != nil {
+			t.Fatalf("cuerpo: %v", err)
+		}
+		if event.Channel != "canal-1" || event.Data["userId"] != float64(7) {
+			t.Fatalf("evento inesperado: %+v", event)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("el webhook no llegó")
+	}
+	select {
+	case <-received:
+		t.Fatal("la suscripción de canal-1 no debe recibir eventos de canal-2")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	// La suscripción que falla agota 3 intentos por evento y queda en el registro
+	deadline := time.Now().Add(5 * time.Second)
+	var dead []models.WebhookDeadLetter
+	for time.Now().Before(deadline) {
+		dead, _ = svc.DeadLetters(bad.ID, 0)
+		if len(dead) == 2 {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if len(dead) != 2 {
+		t.Fatalf("envíos fallidos = %d, esperaba 2", len(dead))
+	}
+	if dead[0].Attempts != 3 || dead[0].StatusCode != http.StatusBadGateway {
+		t.Fatalf("envío fallido inesperado: %+v", dead[0])
+	}
+
+	// Reenviarlo a una URL que ya responde lo saca del registro
+	db.Model(&models.WebhookSubscription{}).Where("id = ?", bad.ID).Update("url", ok.URL)
+	if err := svc.Redeliver(dead[0].ID); err != nil {
+		t.Fatalf("redeliver: %v", err)
+	}
+	<-received
+	<-bodies
+	if remaining, _ := svc.DeadLetters(bad.ID, 0); len(remaining) != 1 {
+		t.Fatalf("quedan %d envíos fallidos, esperaba 1", len(remaining))
+	}
+}
@@ -0,0 +1,290 @@
+package audio
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"math"
+	"time"
+)
+
+// Formatos de contenedor reconocidos
+const (
+	FormatWAV     = "wav"
+	FormatFLAC    = "flac"
+	FormatOggOpus = "ogg"
+	FormatWebM    = "webm"
+)
+
+const opusGranuleRate = 48000
+
+var (
+	ErrUnknownContainer = errors.New("contenedor de audio desconocido")
+	ErrNotOpus          = errors.New("el flujo Ogg no contiene Opus")
+	ErrNotWebM          = errors.New("el archivo EBML no es WebM")
+
+	ebmlMagic = []byte{0x1A, 0x45, 0xDF, 0xA3}
+)
+
+// Detect identifica el contenedor por sus bytes mágicos
+func Detect(data []byte) string {
+	switch {
+	case IsWAV(data):
+		return FormatWAV
+	case len(data) > 4 && string(data[:4]) == "fLaC":
+		return FormatFLAC
+	case len(data) > 4 && string(data[:4]) == "OggS":
+		return FormatOggOpus
+	case len(data) > 4 && bytes.Equal(data[:4], ebmlMagic):
+		return FormatWebM
+	default:
+		return ""
+	}
+}
+
+// MimeType devuelve el Content-Type de un formato; WAV si es desconocido
+func MimeType(format string) string {
+	switch format {
+	case FormatFLAC:
+		return "audio/flac"
+	case FormatOggOpus:
+		return "audio/ogg"
+	case FormatWebM:
+		return "audio/webm"
+	default:
+		return "audio/wav"
+	}
+}
+
+// FormatForMime traduce el Content-Type de la subida al formato interno
+func FormatForMime(mime string) string {
+	switch mime {
+	case "audio/wav", "audio/x-wav", "audio/wave":
+		return FormatWAV
+	case "audio/flac", "audio/x-flac":
+		return FormatFLAC
+	case "audio/ogg", "audio/opus", "application/ogg":
+		return FormatOggOpus
+	case "audio/webm", "video/webm":
+		return FormatWebM
+	default:
+		return ""
+	}
+}
+
+// ValidateOggOpus comprueba que la primera página Ogg lleve la cabecera OpusHead
+func ValidateOggOpus(data []byte) error {
+	page, _, ok := readOggPage(data)
+	if !ok {
+		return ErrUnknownContainer
+	}
+	if len(page.body) < 19 || string(page.body[:8]) != "OpusHead" {
+		return ErrNotOpus
+	}
+	return nil
+}
+
+// OggOpusDuration calcula la duración a partir de la posición granular de la última página
+func OggOpusDuration(data []byte) (time.Duration, bool) {
+	if ValidateOggOpus(data) != nil {
+		return 0, false
+	}
+	first, _, _ := readOggPage(data)
+	preSkip := int64(binary.LittleEndian.Uint16(first.body[10:12]))
+
+	var last int64 = -1
+	for rest := data; len(rest) > 0; {
+		page, n, ok := readOggPage(rest)
+		if !ok {
+			break
+		}
+		if page.granule >= 0 {
+			last = page.granule
+		}
+		rest = rest[n:]
+	}
+	if last < preSkip {
+		return 0, false
+	}
+	samples := last - preSkip
+	return time.Duration(samples) * time.Second / opusGranuleRate, true
+}
+
+type oggPage struct {
+	granule int64
+	body    []byte
+}
+
+func readOggPage(data []byte) (oggPage, int, bool) {
+	const headerSize = 27
+	if len(data) < headerSize || string(data[:4]) != "OggS" {
+		return oggPage{}, 0, false
+	}
+	segments := int(data[26])
+	if len(data) < headerSize+segments {
+		return oggPage{}, 0, false
+	}
+	bodyLen := 0
+	for _, l := range data[headerSize : headerSize+segments] {
+		bodyLen += int(l)
+	}
+	start := headerSize + segments
+	if len(data) < start+bodyLen {
+		return oggPage{}, 0, false
+	}
+	return oggPage{
+		granule: int64(binary.LittleEndian.Uint64(data[6:14])),
+		body:    data[start : start+bodyLen],
+	}, start + bodyLen, true
+}
+
+// IDs EBML usados para validar WebM y leer su duración
+const (
+	ebmlIDHeader        = 0x1A45DFA3
+	ebmlIDDocType       = 0x4282
+	ebmlIDSegment       = 0x18538067
+	ebmlIDInfo          = 0x1549A966
+	ebmlIDTimecodeScale = 0x2AD7B1
+	ebmlIDDuration      = 0x4489
+
+	// ebmlUnknownSize marca elementos de tamaño desconocido (grabación en streaming),
+	// que se extienden hasta el final de los datos
+	ebmlUnknownSize = math.MaxUint64
+)
+
+// ValidateWebM comprueba la cabecera EBML y que el DocType sea "webm"
+func ValidateWebM(data []byte) error {
+	id, size, n, ok := readEBMLElement(data)
+	if !ok || id != ebmlIDHeader {
+		return ErrUnknownContainer
+	}
+	body, ok := sliceEBML(data[n:], size)
+	if !ok {
+		return ErrUnknownContainer
+	}
+	docType, found := findEBMLChild(body, ebmlIDDocType)
+	if !found || string(docType) != "webm" {
+		return ErrNotWebM
+	}
+	return nil
+}
+
+// WebMDuration lee la duración declarada en Segment/Info; las grabaciones en vivo
+// suelen omitirla, en ese caso devuelve false
+func WebMDuration(data []byte) (time.Duration, bool) {
+	if ValidateWebM(data) != nil {
+		return 0, false
+	}
+	_, size, n, _ := readEBMLElement(data)
+	header, _ := sliceEBML(data[n:], size)
+	rest := data[n+len(header):]
+
+	id, segSize, n, ok := readEBMLElement(rest)
+	if !ok || id != ebmlIDSegment {
+		return 0, false
+	}
+	segment, ok := sliceEBML(rest[n:], segSize)
+	if !ok {
+		return 0, false
+	}
+	info, found := findEBMLChild(segment, ebmlIDInfo)
+	if !found {
+		return 0, false
+	}
+
+	scale := uint64(1000000)
+	if raw, found := findEBMLChild(info, ebmlIDTimecodeScale); found {
+		scale = readEBMLUint(raw)
+	}
+	raw, found := findEBMLChild(info, ebmlIDDuration)
+	if !found {
+		return 0, false
+	}
+
+	var ticks float64
+	switch len(raw) {
+	case 4:
+		ticks = float64(math.Float32frombits(binary.BigEndian.Uint32(raw)))
+	case 8:
+		ticks = math.Float64frombits(binary.BigEndian.Uint64(raw))
+	default:
+		return 0, false
+	}
+	return time.Duration(ticks * float64(scale)), true
+}
+
+// findEBMLChild recorre los hijos directos de un elemento buscando el ID indicado
+func findEBMLChild(data []byte, target uint64) ([]byte, bool) {
+	for len(data) > 0 {
+		id, size, n, ok := readEBMLElement(data)
+		if !ok {
+			return nil, false
+		}
+		body, ok := sliceEBML(data[n:], size)
+		if !ok {
+			return nil, false
+		}
+		if id == target {
+			return body, true
+		}
+		data = data[n+len(body):]
+	}
+	return nil, false
+}
+
+func sliceEBML(data []byte, size uint64) ([]byte, bool) {
+	if size == ebmlUnknownSize {
+		return data, true
+	}
+	if size > uint64(len(data)) {
+		return nil, false
+	}
+	return data[:size], true
+}
+
+// readEBMLElement lee el ID y el tamaño de un elemento y devuelve los bytes consumidos
+func readEBMLElement(data []byte) (id, size uint64, n int, ok bool) {
+	id, idLen, ok := readVint(data, false)
+	if !ok {
+		return 0, 0, 0, false
+	}
+	size, sizeLen, ok := readVint(data[idLen:], true)
+	if !ok {
+		return 0, 0, 0, false
+	}
+	return id, size, idLen + sizeLen, true
+}
+
+// readVint decodifica un entero de longitud variable; con mask elimina el marcador
+// de longitud (tamaños) y sin él lo conserva (IDs)
+func readVint(data []byte, mask bool) (uint64, int, bool) {
+	if len(data) == 0 || data[0] == 0 {
+		return 0, 0, false
+	}
+	length := 1
+	for b := data[0]; b&0x80 == 0; b <<= 1 {
+		length++
+	}
+	if length > 8 || len(data) < length {
+		return 0, 0, false
+	}
+	value := uint64(data[0])
+	if mask {
+		value &= uint64(0xFF >> length)
+	}
+	for _, b := range data[1:length] {
+		value = value<<8 | uint64(b)
+	}
+	if mask && value == (uint64(1)<<(7*length))-1 {
+		return ebmlUnknownSize, length, true
+	}
+	return value, length, true
+}
+
+func readEBMLUint(raw []byte) uint64 {
+	var v uint64
+	for _, b := range raw {
+		v = v<<8 | uint64(b)
+	}
+	return v
+}
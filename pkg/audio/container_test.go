@@ -0,0 +1,96 @@
+package audio
+
+import (
+	"encoding/binary"
+	"math"
+	"testing"
+	"time"
+)
+
+func oggPageBytes(granule uint64, body []byte) []byte {
+	page := make([]byte, 27, 27+1+len(body))
+	copy(page[:4], "OggS")
+	binary.LittleEndian.PutUint64(page[6:14], granule)
+	page[26] = 1
+	page = append(page, byte(len(body)))
+	return append(page, body...)
+}
+
+func oggOpusClip(preSkip uint16, finalGranule uint64) []byte {
+	head := make([]byte, 19)
+	copy(head, "OpusHead")
+	head[8] = 1
+	head[9] = 1
+	binary.LittleEndian.PutUint16(head[10:12], preSkip)
+
+	data := oggPageBytes(0, head)
+	data = append(data, oggPageBytes(0, []byte("OpusTags"))...)
+	return append(data, oggPageBytes(finalGranule, []byte{0xFC, 0x00})...)
+}
+
+func ebmlElement(id []byte, body []byte) []byte {
+	out := append([]byte{}, id...)
+	out = append(out, 0x80|byte(len(body)))
+	return append(out, body...)
+}
+
+func webmClip(docType string, durationMs float64) []byte {
+	header := ebmlElement([]byte{0x1A, 0x45, 0xDF, 0xA3}, ebmlElement([]byte{0x42, 0x82}, []byte(docType)))
+
+	dur := make([]byte, 8)
+	binary.BigEndian.PutUint64(dur, math.Float64bits(durationMs))
+	info := ebmlElement([]byte{0x2A, 0xD7, 0xB1}, []byte{0x0F, 0x42, 0x40})
+	info = append(info, ebmlElement([]byte{0x44, 0x89}, dur)...)
+
+	segment := []byte{0x18, 0x53, 0x80, 0x67, 0x01, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF}
+	segment = append(segment, ebmlElement([]byte{0x15, 0x49, 0xA9, 0x66}, info)...)
+	return append(header, segment...)
+}
+
+func TestDetectAndMime(t *testing.T) {
+	if got := Detect(oggOpusClip(312, 48000)); got != FormatOggOpus {
+		t.Fatalf("expected ogg, got %q", got)
+	}
+	if got := Detect(webmClip("webm", 1000)); got != FormatWebM {
+		t.Fatalf("expected webm, got %q", got)
+	}
+	if got := Detect([]byte("garbage")); got != "" {
+		t.Fatalf("expected unknown, got %q", got)
+	}
+	if FormatForMime("audio/opus") != FormatOggOpus || MimeType(FormatWebM) != "audio/webm" {
+		t.Fatal("unexpected mime mapping")
+	}
+}
+
+func TestOggOpusDuration(t *testing.T) {
+	d, ok := OggOpusDuration(oggOpusClip(312, 312+96000))
+	if !ok {
+		t.Fatal("expected duration")
+	}
+	if d != 2*time.Second {
+		t.Fatalf("expected 2s, got %s", d)
+	}
+}
+
+func TestValidateOggOpus_RejectsOtherCodecs(t *testing.T) {
+	vorbis := oggPageBytes(0, append([]byte("\x01vorbis"), make([]byte, 20)...))
+	if err := ValidateOggOpus(vorbis); err != ErrNotOpus {
+		t.Fatalf("expected ErrNotOpus, got %v", err)
+	}
+}
+
+func TestWebMDuration(t *testing.T) {
+	d, ok := WebMDuration(webmClip("webm", 1500))
+	if !ok {
+		t.Fatal("expected duration")
+	}
+	if d != 1500*time.Millisecond {
+		t.Fatalf("expected 1.5s, got %s", d)
+	}
+}
+
+func TestValidateWebM_RejectsMatroska(t *testing.T) {
+	if err := ValidateWebM(webmClip("matroska", 1000)); err != ErrNotWebM {
+		t.Fatalf("expected ErrNotWebM, got %v", err)
+	}
+}
@@ -0,0 +1,58 @@
+package audio
+
+// G.711 μ-law, el formato de audio de la telefonía (8 kHz, 8 bits por muestra)
+
+const (
+	muLawBias = 0x84
+	muLawClip = 32635
+)
+
+// EncodeMuLaw comprime muestras PCM 16 bits a μ-law
+func EncodeMuLaw(samples []int16) []byte {
+	out := make([]byte, len(samples))
+	for i, s := range samples {
+		out[i] = muLawByte(s)
+	}
+	return out
+}
+
+// DecodeMuLaw expande μ-law a muestras PCM 16 bits
+func DecodeMuLaw(data []byte) []int16 {
+	out := make([]int16, len(data))
+	for i, b := range data {
+		out[i] = muLawSample(b)
+	}
+	return out
+}
+
+func muLawByte(s int16) byte {
+	sample := int(s)
+	sign := 0
+	if sample < 0 {
+		sign = 0x80
+		sample = -sample
+	}
+	if sample > muLawClip {
+		sample = muLawClip
+	}
+	sample += muLawBias
+
+	exponent := 7
+	for mask := 0x4000; sample&mask == 0 && exponent > 0; mask >>= 1 {
+		exponent--
+	}
+	mantissa := (sample >> (exponent + 3)) & 0x0F
+	return ^byte(sign | exponent<<4 | mantissa)
+}
+
+func muLawSample(b byte) int16 {
+	b = ^b
+	exponent := int(b>>4) & 0x07
+	mantissa := int(b & 0x0F)
+	sample := ((mantissa << 3) + muLawBias) << exponent
+	sample -= muLawBias
+	if b&0x80 != 0 {
+		return int16(-sample)
+	}
+	return int16(sample)
+}
@@ -0,0 +1,33 @@
+package audio
+
+import "testing"
+
+func TestMuLaw_RoundTripWithinQuantization(t *testing.T) {
+	in := []int16{0, 1, -1, 100, -100, 1000, -1000, 8000, -8000, 32767, -32768}
+	out := DecodeMuLaw(EncodeMuLaw(in))
+	for i, s := range in {
+		diff := int(out[i]) - int(s)
+		if diff < 0 {
+			diff = -diff
+		}
+		// El error de cuantización crece con la amplitud: 1/16 del segmento como mucho
+		limit := 8 + abs(int(s))/16
+		if diff > limit {
+			t.Fatalf("muestra %d: %d -> %d (error %d > %d)", i, s, out[i], diff, limit)
+		}
+	}
+}
+
+func TestMuLaw_KnownCodes(t *testing.T) {
+	// Silencio es 0xFF y los extremos 0x80 / 0x00 según la tabla de G.711
+	if got := EncodeMuLaw([]int16{0, 32767, -32768}); got[0] != 0xFF || got[1] != 0x80 || got[2] != 0x00 {
+		t.Fatalf("códigos inesperados % x", got)
+	}
+}
+
+func abs(v int) int {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
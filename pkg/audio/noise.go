@@ -0,0 +1,112 @@
+package audio
+
+import (
+	"encoding/binary"
+	"math"
+)
+
+// Clases que devuelve ClassifyWAV
+const (
+	ClassSpeech  = "speech"
+	ClassSilence = "silence"
+	ClassNoise   = "noise"
+)
+
+const (
+	// frameSamples son 20 ms a 16 kHz, la ventana habitual para energía y cruces por cero
+	frameSamples = 320
+	// activeFrameRMS es el volumen mínimo para considerar que una ventana tiene sonido
+	activeFrameRMS = 150
+	// La voz sonora cruza por cero entre ~80 Hz y ~2 kHz; por debajo es viento o
+	// golpes graves, por encima roce de tela o siseo de banda ancha
+	minVoicedZCR = 0.01
+	maxVoicedZCR = 0.25
+	// minVoicedRatio es la fracción de ventanas activas que deben parecer voz
+	minVoicedRatio = 0.3
+	// La voz sube y baja de volumen con cada sílaba; un sonido largo y constante es un zumbido
+	minEnergyVariation = 0.1
+	steadyMinFrames    = 25
+)
+
+// Classification resume por qué un clip se considera voz, silencio o ruido
+type Classification struct {
+	Class           string
+	ActiveFrames    int
+	VoicedRatio     float64
+	EnergyVariation float64
+}
+
+// ClassifyWAV distingue voz de ruido accidental (bolsillo, viento, zumbidos) con la energía
+// y los cruces por cero de ventanas de 20 ms, asumiendo PCM 16 bits mono a 16 kHz
+func ClassifyWAV(data []byte) (Classification, bool) {
+	if !IsWAV(data) {
+		return Classification{}, false
+	}
+	return classifyPCM16(data[wavHeaderSize:]), true
+}
+
+func classifyPCM16(payload []byte) Classification {
+	samples := len(payload) / 2
+	var energies []float64
+	voiced := 0
+
+	for start := 0; start < samples; start += frameSamples {
+		end := min(start+frameSamples, samples)
+		if end-start < frameSamples/2 {
+			break
+		}
+		var sumSquares float64
+		crossings := 0
+		prev := int16(binary.LittleEndian.Uint16(payload[start*2:]))
+		for i := start; i < end; i++ {
+			sample := int16(binary.LittleEndian.Uint16(payload[i*2:]))
+			sumSquares += float64(sample) * float64(sample)
+			if (sample >= 0) != (prev >= 0) {
+				crossings++
+			}
+			prev = sample
+		}
+		rms := math.Sqrt(sumSquares / float64(end-start))
+		if rms < activeFrameRMS {
+			continue
+		}
+		energies = append(energies, rms)
+		zcr := float64(crossings) / float64(end-start)
+		if zcr >= minVoicedZCR && zcr <= maxVoicedZCR {
+			voiced++
+		}
+	}
+
+	c := Classification{Class: ClassSilence, ActiveFrames: len(energies)}
+	if len(energies) == 0 {
+		return c
+	}
+	c.VoicedRatio = float64(voiced) / float64(len(energies))
+	c.EnergyVariation = coefficientOfVariation(energies)
+
+	switch {
+	case c.VoicedRatio < minVoicedRatio:
+		c.Class = ClassNoise
+	case len(energies) >= steadyMinFrames && c.EnergyVariation < minEnergyVariation:
+		c.Class = ClassNoise
+	default:
+		c.Class = ClassSpeech
+	}
+	return c
+}
+
+func coefficientOfVariation(values []float64) float64 {
+	var sum float64
+	for _, v := range values {
+		sum += v
+	}
+	mean := sum / float64(len(values))
+	if mean == 0 {
+		return 0
+	}
+	var variance float64
+	for _, v := range values {
+		variance += (v - mean) * (v - mean)
+	}
+	return math.Sqrt(variance/float64(len(values))) / mean
+}
@@ -0,0 +1,61 @@
+package audio
+
+import (
+	"math"
+	"testing"
+)
+
+// tone genera n muestras de una senoide a freq Hz modulada por envelope(i)
+func tone(n int, freq float64, envelope func(i int) float64) []int16 {
+	samples := make([]int16, n)
+	for i := range samples {
+		samples[i] = int16(envelope(i) * math.Sin(2*math.Pi*freq*float64(i)/16000))
+	}
+	return samples
+}
+
+func TestClassifyWAV(t *testing.T) {
+	// Sílabas de 150 ms separadas por pausas cortas
+	syllables := func(i int) float64 {
+		if (i/2400)%2 == 0 {
+			return 6000
+		}
+		return 800
+	}
+	steady := func(int) float64 { return 6000 }
+
+	seed := uint32(1)
+	hiss := make([]int16, 16000)
+	for i := range hiss {
+		seed = seed*1664525 + 1013904223
+		hiss[i] = int16(seed>>16) / 4
+	}
+
+	tests := []struct {
+		name    string
+		samples []int16
+		want    string
+	}{
+		{"silencio", make([]int16, 16000), ClassSilence},
+		{"voz modulada", tone(16000, 220, syllables), ClassSpeech},
+		{"clip corto con voz", tone(1600, 400, steady), ClassSpeech},
+		{"siseo de banda ancha", hiss, ClassNoise},
+		{"viento grave", tone(16000, 30, syllables), ClassNoise},
+		{"zumbido constante", tone(16000, 400, steady), ClassNoise},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, ok := ClassifyWAV(wavWithSamples(tt.samples))
+			if !ok {
+				t.Fatal("expected WAV to be classified")
+			}
+			if c.Class != tt.want {
+				t.Fatalf("expected %s, got %+v", tt.want, c)
+			}
+		})
+	}
+
+	if _, ok := ClassifyWAV([]byte("OggS")); ok {
+		t.Fatal("expected non-WAV data to be rejected")
+	}
+}
@@ -0,0 +1,68 @@
+package audio
+
+import (
+	"encoding/binary"
+	"math"
+	"time"
+)
+
+const wavHeaderSize = 44
+
+// Quality resume las medidas básicas de un clip PCM de 16 bits
+type Quality struct {
+	RMS          float64
+	Peak         int
+	ClippedRatio float64
+	Duration     time.Duration
+	SampleCount  int
+	BytesPerSec  int
+}
+
+// IsWAV indica si los datos empiezan con una cabecera RIFF/WAVE
+func IsWAV(data []byte) bool {
+	return len(data) >= wavHeaderSize && string(data[0:4]) == "RIFF" && string(data[8:12]) == "WAVE"
+}
+
+// AnalyzeWAV mide volumen y saturación de un WAV PCM 16 bits; si la cabecera no se puede
+// leer asume mono a 16 kHz tras los 44 bytes canónicos
+func AnalyzeWAV(data []byte) (Quality, bool) {
+	if !IsWAV(data) {
+		return Quality{}, false
+	}
+	if f, err := ParseWAV(data); err == nil && f.IsPCM16() {
+		return analyzePCM16(data[f.DataOffset:f.DataOffset+f.DataSize], f.ByteRate), true
+	}
+	return analyzePCM16(data[wavHeaderSize:], 32000), true
+}
+
+func analyzePCM16(payload []byte, bytesPerSec int) Quality {
+	samples := len(payload) / 2
+	q := Quality{SampleCount: samples, BytesPerSec: bytesPerSec}
+	if samples == 0 {
+		return q
+	}
+
+	var sumSquares float64
+	clipped := 0
+	for i := 0; i+1 < len(payload); i += 2 {
+		sample := int(int16(binary.LittleEndian.Uint16(payload[i : i+2])))
+		sumSquares += float64(sample * sample)
+		abs := sample
+		if abs < 0 {
+			abs = -abs
+		}
+		if abs > q.Peak {
+			q.Peak = abs
+		}
+		if abs >= 32000 {
+			clipped++
+		}
+	}
+
+	q.RMS = math.Sqrt(sumSquares / float64(samples))
+	q.ClippedRatio = float64(clipped) / float64(samples)
+	if bytesPerSec > 0 {
+		q.Duration = time.Duration(float64(len(payload)) / float64(bytesPerSec) * float64(time.Second))
+	}
+	return q
+}
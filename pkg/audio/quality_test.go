@@ -0,0 +1,58 @@
+package audio
+
+import (
+	"encoding/binary"
+	"testing"
+	"time"
+)
+
+func wavWithSamples(samples []int16) []byte {
+	data := make([]byte, wavHeaderSize+len(samples)*2)
+	copy(data[0:4], "RIFF")
+	copy(data[8:12], "WAVE")
+	for i, s := range samples {
+		binary.LittleEndian.PutUint16(data[wavHeaderSize+i*2:], uint16(s))
+	}
+	return data
+}
+
+func TestAnalyzeWAV_RejectsNonWAV(t *testing.T) {
+	if _, ok := AnalyzeWAV([]byte("not a wav")); ok {
+		t.Fatal("expected non-WAV data to be rejected")
+	}
+}
+
+func TestAnalyzeWAV_MeasuresVolumeAndClipping(t *testing.T) {
+	samples := make([]int16, 16000)
+	for i := range samples {
+		if i%2 == 0 {
+			samples[i] = 32767
+		} else {
+			samples[i] = -32768
+		}
+	}
+
+	q, ok := AnalyzeWAV(wavWithSamples(samples))
+	if !ok {
+		t.Fatal("expected WAV to be analyzed")
+	}
+	if q.ClippedRatio != 1 {
+		t.Fatalf("expected full clipping, got %f", q.ClippedRatio)
+	}
+	if q.RMS < 32000 {
+		t.Fatalf("expected high RMS, got %f", q.RMS)
+	}
+	if q.Duration != time.Second {
+		t.Fatalf("expected 1s duration, got %s", q.Duration)
+	}
+}
+
+func TestAnalyzeWAV_Silence(t *testing.T) {
+	q, ok := AnalyzeWAV(wavWithSamples(make([]int16, 800)))
+	if !ok {
+		t.Fatal("expected WAV to be analyzed")
+	}
+	if q.RMS != 0 || q.Peak != 0 || q.ClippedRatio != 0 {
+		t.Fatalf("expected silent measurements, got %+v", q)
+	}
+}
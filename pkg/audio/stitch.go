@@ -0,0 +1,53 @@
+package audio
+
+import (
+	"errors"
+	"time"
+)
+
+// ErrNoSegments indica que no hay clips que unir
+var ErrNoSegments = errors.New("no hay clips WAV que unir")
+
+// Segment es un clip WAV con el momento en que empezó a sonar
+type Segment struct {
+	Start time.Time
+	Data  []byte
+}
+
+// StitchWAV une los clips en un único WAV PCM 16 bits mono a rate Hz, en orden y con
+// silencio entre ellos igual al hueco real, como mucho maxGap. Los clips que no se pueden
+// convertir se saltan; devuelve cuántos se usaron.
+func StitchWAV(segments []Segment, rate int, maxGap time.Duration) ([]byte, int, error) {
+	pcm := make([]byte, 0)
+	var prevEnd time.Time
+	used := 0
+	for _, seg := range segments {
+		clip, err := ResampleWAV(seg.Data, rate)
+		if err != nil {
+			continue
+		}
+		f, err := ParseWAV(clip)
+		if err != nil {
+			continue
+		}
+
+		if used > 0 {
+			gap := seg.Start.Sub(prevEnd)
+			if gap > maxGap {
+				gap = maxGap
+			}
+			if gap > 0 {
+				pcm = append(pcm, make([]byte, int(gap.Seconds()*float64(rate))*2)...)
+			}
+		}
+		pcm = append(pcm, clip[f.DataOffset:f.DataOffset+f.DataSize]...)
+		prevEnd = seg.Start.Add(f.Duration())
+		used++
+	}
+	if used == 0 {
+		return nil, 0, ErrNoSegments
+	}
+
+	f := WAVFormat{AudioFormat: wavFormatPCM, Channels: 1, SampleRate: rate, BitsPerSample: 16, ByteRate: rate * 2, BlockAlign: 2}
+	return append(WAVHeader(f, len(pcm)), pcm...), used, nil
+}
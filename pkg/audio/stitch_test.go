@@ -0,0 +1,46 @@
+package audio
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestStitchWAV_InsertsCappedSilence(t *testing.T) {
+	start := time.Date(2026, 1, 1, 10, 0, 0, 0, time.UTC)
+	ones := make([]int16, 8000) // 0,5 s a 16 kHz
+	for i := range ones {
+		ones[i] = 100
+	}
+	segments := []Segment{
+		{Start: start, Data: EncodeWAV(ones, 16000)},
+		{Start: start.Add(time.Second), Data: []byte("no es un wav")},
+		// 0,5 s después del final del primero
+		{Start: start.Add(time.Second), Data: EncodeWAV(ones, 16000)},
+		// Una hora después: el silencio se recorta a maxGap
+		{Start: start.Add(time.Hour), Data: stereoWAV44k(22050)},
+	}
+
+	out, used, err := StitchWAV(segments, 16000, 2*time.Second)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if used != 3 {
+		t.Fatalf("expected 3 clips used, got %d", used)
+	}
+	f, err := ParseWAV(out)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// 0,5 + 0,5 silencio + 0,5 + 2 silencio + 0,5
+	if f.Duration() != 4*time.Second {
+		t.Fatalf("expected 4s, got %s", f.Duration())
+	}
+	if out[f.DataOffset+8000*2] != 0 || out[f.DataOffset] != 100 {
+		t.Fatal("expected audio followed by silence")
+	}
+
+	if _, _, err := StitchWAV(nil, 16000, time.Second); !errors.Is(err, ErrNoSegments) {
+		t.Fatalf("expected ErrNoSegments, got %v", err)
+	}
+}
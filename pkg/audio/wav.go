@@ -0,0 +1,244 @@
+package audio
+
+import (
+	"encoding/binary"
+	"errors"
+	"time"
+)
+
+// Códigos de formato del chunk fmt que se aceptan
+const (
+	wavFormatPCM        = 1
+	wavFormatFloat      = 3
+	wavFormatExtensible = 0xFFFE
+)
+
+// Frecuencias de muestreo admitidas: por debajo no se entiende la voz y por encima la
+// cabecera está corrupta o es un intento de inflar el remuestreo
+const (
+	minWAVSampleRate = 8000
+	maxWAVSampleRate = 192000
+)
+
+var (
+	ErrInvalidWAV      = errors.New("cabecera WAV inválida")
+	ErrUnsupportedWAV  = errors.New("formato WAV no soportado")
+	ErrMissingWAVChunk = errors.New("falta el chunk fmt o data del WAV")
+)
+
+// WAVFormat describe un WAV a partir de su chunk fmt y la posición de sus muestras
+type WAVFormat struct {
+	AudioFormat   int
+	Channels      int
+	SampleRate    int
+	BitsPerSample int
+	ByteRate      int
+	BlockAlign    int
+	// DataOffset y DataSize delimitan las muestras; DataSize se recorta a lo recibido
+	DataOffset int
+	DataSize   int
+}
+
+// ParseWAV recorre los chunks RIFF hasta encontrar fmt y data; tolera chunks extra
+// (LIST, fact...) y tamaños de data incompletos o desconocidos de grabaciones en streaming
+func ParseWAV(data []byte) (WAVFormat, error) {
+	if len(data) < 12 || string(data[0:4]) != "RIFF" || string(data[8:12]) != "WAVE" {
+		return WAVFormat{}, ErrInvalidWAV
+	}
+
+	var (
+		f       WAVFormat
+		haveFmt bool
+	)
+	for pos := 12; pos+8 <= len(data); {
+		id := string(data[pos : pos+4])
+		size := int(binary.LittleEndian.Uint32(data[pos+4 : pos+8]))
+		body := pos + 8
+
+		switch id {
+		case "fmt ":
+			if size < 16 || body+16 > len(data) {
+				return WAVFormat{}, ErrInvalidWAV
+			}
+			chunk := data[body : body+16]
+			f.AudioFormat = int(binary.LittleEndian.Uint16(chunk[0:2]))
+			f.Channels = int(binary.LittleEndian.Uint16(chunk[2:4]))
+			f.SampleRate = int(binary.LittleEndian.Uint32(chunk[4:8]))
+			f.ByteRate = int(binary.LittleEndian.Uint32(chunk[8:12]))
+			f.BlockAlign = int(binary.LittleEndian.Uint16(chunk[12:14]))
+			f.BitsPerSample = int(binary.LittleEndian.Uint16(chunk[14:16]))
+			haveFmt = true
+		case "data":
+			if !haveFmt {
+				return WAVFormat{}, ErrMissingWAVChunk
+			}
+			if size <= 0 || body+size > len(data) {
+				size = len(data) - body
+			}
+			f.DataOffset, f.DataSize = body, size
+			// Algunos codificadores dejan ByteRate/BlockAlign a cero; se calculan del resto
+			if f.BlockAlign == 0 {
+				f.BlockAlign = f.Channels * f.BitsPerSample / 8
+			}
+			if f.ByteRate == 0 {
+				f.ByteRate = f.SampleRate * f.BlockAlign
+			}
+			return f, f.validate()
+		}
+
+		if size < 0 || body+size > len(data) {
+			break
+		}
+		// Los chunks de tamaño impar llevan un byte de relleno
+		pos = body + size + size%2
+	}
+	return WAVFormat{}, ErrMissingWAVChunk
+}
+
+func (f WAVFormat) validate() error {
+	switch f.AudioFormat {
+	case wavFormatPCM, wavFormatFloat, wavFormatExtensible:
+	default:
+		return ErrUnsupportedWAV
+	}
+	if f.Channels <= 0 || f.SampleRate <= 0 || f.BitsPerSample <= 0 || f.BitsPerSample%8 != 0 {
+		return ErrInvalidWAV
+	}
+	if f.SampleRate < minWAVSampleRate || f.SampleRate > maxWAVSampleRate {
+		return ErrInvalidWAV
+	}
+	if f.BlockAlign != f.Channels*f.BitsPerSample/8 || f.ByteRate != f.SampleRate*f.BlockAlign {
+		return ErrInvalidWAV
+	}
+	return nil
+}
+
+// Duration calcula la duración real a partir del tamaño de data y los bytes por segundo
+func (f WAVFormat) Duration() time.Duration {
+	if f.ByteRate <= 0 {
+		return 0
+	}
+	return time.Duration(float64(f.DataSize) / float64(f.ByteRate) * float64(time.Second))
+}
+
+// IsPCM16 indica si las muestras son enteros de 16 bits
+func (f WAVFormat) IsPCM16() bool {
+	return f.AudioFormat != wavFormatFloat && f.BitsPerSample == 16
+}
+
+// ResampleWAV convierte un WAV PCM (8, 16, 24 o 32 bits, cualquier número de canales) a
+// PCM 16 bits mono a targetRate, mezclando los canales e interpolando linealmente
+func ResampleWAV(data []byte, targetRate int) ([]byte, error) {
+	f, err := ParseWAV(data)
+	if err != nil {
+		return nil, err
+	}
+	if f.AudioFormat == wavFormatFloat || f.BitsPerSample > 32 || targetRate <= 0 {
+		return nil, ErrUnsupportedWAV
+	}
+	if f.IsPCM16() && f.Channels == 1 && f.SampleRate == targetRate {
+		return data, nil
+	}
+
+	mono := downmix(data[f.DataOffset:f.DataOffset+f.DataSize], f)
+	out := resampleLinear(mono, f.SampleRate, targetRate)
+	return EncodeWAV(out, targetRate), nil
+}
+
+// Samples16 devuelve las muestras de un WAV PCM convertidas a 16 bits mono a targetRate
+func Samples16(data []byte, targetRate int) ([]int16, error) {
+	f, err := ParseWAV(data)
+	if err != nil {
+		return nil, err
+	}
+	if f.AudioFormat == wavFormatFloat || f.BitsPerSample > 32 || targetRate <= 0 {
+		return nil, ErrUnsupportedWAV
+	}
+	mono := downmix(data[f.DataOffset:f.DataOffset+f.DataSize], f)
+	return resampleLinear(mono, f.SampleRate, targetRate), nil
+}
+
+// EncodeWAV construye un WAV PCM 16 bits mono con la cabecera canónica de 44 bytes
+func EncodeWAV(samples []int16, sampleRate int) []byte {
+	f := WAVFormat{AudioFormat: wavFormatPCM, Channels: 1, SampleRate: sampleRate, BitsPerSample: 16, ByteRate: sampleRate * 2, BlockAlign: 2}
+	out := append(WAVHeader(f, len(samples)*2), make([]byte, len(samples)*2)...)
+	for i, s := range samples {
+		binary.LittleEndian.PutUint16(out[wavHeaderSize+i*2:], uint16(s))
+	}
+	return out
+}
+
+// WAVHeader devuelve la cabecera canónica de 44 bytes para dataSize bytes de muestras en el
+// formato f; sirve para trocear un WAV largo en clips que se reproducen por separado
+func WAVHeader(f WAVFormat, dataSize int) []byte {
+	// La cabecera canónica no tiene sitio para la extensión de WAVE_FORMAT_EXTENSIBLE
+	if f.AudioFormat == wavFormatExtensible {
+		f.AudioFormat = wavFormatPCM
+	}
+	out := make([]byte, wavHeaderSize)
+	copy(out[0:4], "RIFF")
+	binary.LittleEndian.PutUint32(out[4:8], uint32(36+dataSize))
+	copy(out[8:12], "WAVE")
+	copy(out[12:16], "fmt ")
+	binary.LittleEndian.PutUint32(out[16:20], 16)
+	binary.LittleEndian.PutUint16(out[20:22], uint16(f.AudioFormat))
+	binary.LittleEndian.PutUint16(out[22:24], uint16(f.Channels))
+	binary.LittleEndian.PutUint32(out[24:28], uint32(f.SampleRate))
+	binary.LittleEndian.PutUint32(out[28:32], uint32(f.ByteRate))
+	binary.LittleEndian.PutUint16(out[32:34], uint16(f.BlockAlign))
+	binary.LittleEndian.PutUint16(out[34:36], uint16(f.BitsPerSample))
+	copy(out[36:40], "data")
+	binary.LittleEndian.PutUint32(out[40:44], uint32(dataSize))
+	return out
+}
+
+// downmix promedia los canales de cada frame y lleva la muestra a 16 bits
+func downmix(payload []byte, f WAVFormat) []int16 {
+	width := f.BitsPerSample / 8
+	frames := len(payload) / f.BlockAlign
+	out := make([]int16, frames)
+	for i := 0; i < frames; i++ {
+		frame := payload[i*f.BlockAlign:]
+		var sum int64
+		for ch := 0; ch < f.Channels; ch++ {
+			sum += int64(readSample16(frame[ch*width:], width))
+		}
+		out[i] = int16(sum / int64(f.Channels))
+	}
+	return out
+}
+
+// readSample16 lee una muestra little-endian de width bytes y la escala a 16 bits;
+// las de 8 bits no tienen signo
+func readSample16(b []byte, width int) int16 {
+	switch width {
+	case 1:
+		return int16((int(b[0]) - 128) << 8)
+	case 2:
+		return int16(binary.LittleEndian.Uint16(b))
+	case 3:
+		return int16(int32(uint32(b[0])<<8|uint32(b[1])<<16|uint32(b[2])<<24) >> 16)
+	default:
+		return int16(int32(binary.LittleEndian.Uint32(b)) >> 16)
+	}
+}
+
+func resampleLinear(in []int16, from, to int) []int16 {
+	if from == to || len(in) == 0 {
+		return in
+	}
+	n := int(int64(len(in)) * int64(to) / int64(from))
+	out := make([]int16, n)
+	step := float64(from) / float64(to)
+	for i := range out {
+		pos := float64(i) * step
+		idx := int(pos)
+		if idx+1 >= len(in) {
+			out[i] = in[len(in)-1]
+			continue
+		}
+		frac := pos - float64(idx)
+		out[i] = int16(float64(in[idx])*(1-frac) + float64(in[idx+1])*frac)
+	}
+	return out
+}
@@ -0,0 +1,111 @@
+package audio
+
+import (
+	"encoding/binary"
+	"errors"
+	"testing"
+	"time"
+)
+
+// stereoWAV44k arma un WAV de 16 bits estéreo a 44,1 kHz con un chunk LIST antes de data
+func stereoWAV44k(frames int) []byte {
+	list := []byte("LIST\x05\x00\x00\x00INFOx\x00")
+	data := make([]byte, 0, 44+len(list)+frames*4)
+	data = append(data, "RIFF\x00\x00\x00\x00WAVEfmt "...)
+	data = binary.LittleEndian.AppendUint32(data, 16)
+	data = binary.LittleEndian.AppendUint16(data, 1)
+	data = binary.LittleEndian.AppendUint16(data, 2)
+	data = binary.LittleEndian.AppendUint32(data, 44100)
+	data = binary.LittleEndian.AppendUint32(data, 44100*4)
+	data = binary.LittleEndian.AppendUint16(data, 4)
+	data = binary.LittleEndian.AppendUint16(data, 16)
+	data = append(data, list...)
+	data = append(data, "data"...)
+	data = binary.LittleEndian.AppendUint32(data, uint32(frames*4))
+	for i := 0; i < frames; i++ {
+		data = binary.LittleEndian.AppendUint16(data, uint16(int16(1000)))
+		data = binary.LittleEndian.AppendUint16(data, uint16(int16(3000)))
+	}
+	return data
+}
+
+func TestParseWAV_ReadsFmtAndSkipsChunks(t *testing.T) {
+	f, err := ParseWAV(stereoWAV44k(44100))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if f.SampleRate != 44100 || f.Channels != 2 || f.BitsPerSample != 16 {
+		t.Fatalf("unexpected format %+v", f)
+	}
+	if f.DataOffset != 58 || f.DataSize != 44100*4 {
+		t.Fatalf("unexpected data chunk offset=%d size=%d", f.DataOffset, f.DataSize)
+	}
+	if f.Duration() != time.Second {
+		t.Fatalf("expected 1s, got %s", f.Duration())
+	}
+}
+
+func TestParseWAV_TruncatedDataAndErrors(t *testing.T) {
+	wav := EncodeWAV(make([]int16, 16000), 16000)
+	// Tamaño desconocido, como en las grabaciones en streaming
+	binary.LittleEndian.PutUint32(wav[40:44], 0xFFFFFFFF)
+	f, err := ParseWAV(wav[:44+8000])
+	if err != nil || f.DataSize != 8000 {
+		t.Fatalf("expected truncated data to be accepted, got %+v %v", f, err)
+	}
+
+	if _, err := ParseWAV([]byte("RIFFxxxxWAVEdata\x00\x00\x00\x00")); !errors.Is(err, ErrMissingWAVChunk) {
+		t.Fatalf("expected missing fmt, got %v", err)
+	}
+	for _, rate := range []int{4000, 384000} {
+		if _, err := ParseWAV(EncodeWAV(make([]int16, 10), rate)); !errors.Is(err, ErrInvalidWAV) {
+			t.Fatalf("expected sample rate %d to be rejected, got %v", rate, err)
+		}
+	}
+	for _, rate := range []int{8000, 192000} {
+		if _, err := ParseWAV(EncodeWAV(make([]int16, 10), rate)); err != nil {
+			t.Fatalf("expected sample rate %d to be accepted, got %v", rate, err)
+		}
+	}
+
+	bad := EncodeWAV(make([]int16, 10), 16000)
+	binary.LittleEndian.PutUint16(bad[20:22], 0x55) // MP3
+	if _, err := ParseWAV(bad); !errors.Is(err, ErrUnsupportedWAV) {
+		t.Fatalf("expected unsupported format, got %v", err)
+	}
+}
+
+func TestResampleWAV_DownmixesAndResamples(t *testing.T) {
+	out, err := ResampleWAV(stereoWAV44k(44100), 16000)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	f, err := ParseWAV(out)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if f.SampleRate != 16000 || f.Channels != 1 || f.DataSize != 32000 {
+		t.Fatalf("unexpected output format %+v", f)
+	}
+	if sample := int16(binary.LittleEndian.Uint16(out[f.DataOffset:])); sample != 2000 {
+		t.Fatalf("expected channels averaged to 2000, got %d", sample)
+	}
+
+	same := EncodeWAV(make([]int16, 100), 16000)
+	if out, _ := ResampleWAV(same, 16000); &out[0] != &same[0] {
+		t.Fatal("expected a 16 kHz mono WAV to be returned untouched")
+	}
+}
+
+func TestSamples16_DownmixesToTelephonyRate(t *testing.T) {
+	samples, err := Samples16(stereoWAV44k(44100), 8000)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(samples) != 8000 || samples[0] != 2000 {
+		t.Fatalf("expected 8000 samples averaged to 2000, got %d samples, first %d", len(samples), samples[0])
+	}
+	if _, err := Samples16([]byte("no es un wav"), 8000); err == nil {
+		t.Fatal("expected an error for invalid data")
+	}
+}
@@ -0,0 +1,151 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// CommandResponse es la respuesta de un comando de voz o del asistente
+type CommandResponse struct {
+	Status  string         `json:"status"`
+	Intent  string         `json:"intent"`
+	Message string         `json:"message"`
+	Data    map[string]any `json:"data,omitempty"`
+}
+
+// IngestResult es el resultado de IngestAudio: Command si el audio era un comando,
+// TransmissionID si se difundió al canal
+type IngestResult struct {
+	TransmissionID string
+	Priority       string
+	Command        *CommandResponse
+}
+
+// IngestAudio envía un clip; contentType es audio/wav, audio/ogg... No se reintenta tras
+// un error de red porque el servidor podría haberlo difundido ya
+func (c *Client) IngestAudio(ctx context.Context, audio []byte, contentType string) (*IngestResult, error) {
+	resp, err := c.send(ctx, request{method: http.MethodPost, path: "/audio/ingest", raw: audio, contentType: contentType})
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	out := &IngestResult{
+		TransmissionID: resp.Header.Get("X-Transmission-ID"),
+		Priority:       resp.Header.Get("X-Audio-Priority"),
+	}
+	if resp.StatusCode == http.StatusOK {
+		var cmd CommandResponse
+		if err := json.NewDecoder(resp.Body).Decode(&cmd); err != nil {
+			return nil, fmt.Errorf("client: respuesta inválida de /audio/ingest: %w", err)
+		}
+		out.Command = &cmd
+	}
+	return out, nil
+}
+
+// PollOptions ajusta PollAudio
+type PollOptions struct {
+	// Ack pide entregas confirmadas: el clip se reentrega hasta llamar a AckAudio
+	Ack bool
+}
+
+// Clip es un audio pendiente entregado por PollAudio
+type Clip struct {
+	Audio          []byte
+	ContentType    string
+	From           uint
+	Channel        string
+	Timestamp      time.Time
+	Priority       string
+	TransmissionID string
+	Direct         bool
+	Encrypted      bool
+	Notice         string
+	// DeliveryID sólo viene con PollOptions.Ack
+	DeliveryID string
+	Attempt    int
+}
+
+// PollAudio recoge el siguiente clip pendiente; devuelve nil si no hay ninguno. Si el
+// servidor lo guarda en almacenamiento de objetos lo descarga de la URL firmada
+func (c *Client) PollAudio(ctx context.Context, opts PollOptions) (*Clip, error) {
+	req := request{method: http.MethodGet, path: "/audio/poll", idempotent: true}
+	if opts.Ack {
+		req.header = http.Header{"X-Audio-Ack": {"true"}}
+	}
+	resp, err := c.send(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNoContent {
+		return nil, nil
+	}
+
+	h := resp.Header
+	clip := &Clip{
+		ContentType:    h.Get("Content-Type"),
+		Channel:        h.Get("X-Channel"),
+		Priority:       h.Get("X-Audio-Priority"),
+		TransmissionID: h.Get("X-Transmission-ID"),
+		Direct:         h.Get("X-Audio-Direct") == "true",
+		Encrypted:      h.Get("X-Audio-Encrypted") == "true",
+		Notice:         h.Get("X-Audio-Notice"),
+		DeliveryID:     h.Get("X-Delivery-ID"),
+	}
+	if from, err := strconv.ParseUint(h.Get("X-Audio-From"), 10, 64); err == nil {
+		clip.From = uint(from)
+	}
+	if ts, err := time.Parse(time.RFC3339Nano, h.Get("X-Audio-Timestamp")); err == nil {
+		clip.Timestamp = ts
+	}
+	clip.Attempt, _ = strconv.Atoi(h.Get("X-Audio-Attempt"))
+
+	if resp.StatusCode == http.StatusSeeOther {
+		clip.Audio, clip.ContentType, err = c.download(ctx, resp.Header.Get("Location"))
+	} else {
+		clip.Audio, err = io.ReadAll(resp.Body)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return clip, nil
+}
+
+// download baja el clip de la URL firmada; no lleva credenciales del backend
+func (c *Client) download(ctx context.Context, link string) ([]byte, string, error) {
+	u, err := c.baseURL.Parse(link)
+	if err != nil {
+		return nil, "", fmt.Errorf("client: Location inválida %q", link)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, "", err
+	}
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return nil, "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, "", readAPIError(resp)
+	}
+	data, err := io.ReadAll(resp.Body)
+	return data, resp.Header.Get("Content-Type"), err
+}
+
+// AckAudio confirma una entrega recibida con PollOptions.Ack
+func (c *Client) AckAudio(ctx context.Context, deliveryID string) error {
+	return c.do(ctx, request{method: http.MethodPost, path: "/audio/ack/" + url.PathEscape(deliveryID), idempotent: true}, nil)
+}
+
+// MarkPlayed avisa al emisor de que la transmisión se reprodujo
+func (c *Client) MarkPlayed(ctx context.Context, transmissionID string) error {
+	return c.do(ctx, request{method: http.MethodPost, path: "/audio/played/" + url.PathEscape(transmissionID), idempotent: true}, nil)
+}
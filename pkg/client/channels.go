@@ -0,0 +1,78 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+)
+
+// Profile es el perfil del usuario autenticado (GET /me)
+type Profile struct {
+	ID             uint   `json:"id"`
+	DisplayName    string `json:"displayName"`
+	CurrentChannel string `json:"currentChannel,omitempty"`
+	Language       string `json:"language"`
+	DoNotDisturb   bool   `json:"doNotDisturb"`
+}
+
+// Me devuelve el perfil del usuario autenticado
+func (c *Client) Me(ctx context.Context) (*Profile, error) {
+	var p Profile
+	if err := c.do(ctx, request{method: http.MethodGet, path: "/me", idempotent: true}, &p); err != nil {
+		return nil, err
+	}
+	c.mu.Lock()
+	c.userID = p.ID
+	c.mu.Unlock()
+	return &p, nil
+}
+
+// ConnectChannel conecta al usuario al canal; si está lleno devuelve un *APIError 409
+func (c *Client) ConnectChannel(ctx context.Context, code string) (*CommandResponse, error) {
+	var out CommandResponse
+	if err := c.do(ctx, request{method: http.MethodPost, path: "/channels/" + url.PathEscape(code) + "/connect", idempotent: true}, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// DisconnectChannel saca al usuario de su canal actual
+func (c *Client) DisconnectChannel(ctx context.Context) (*CommandResponse, error) {
+	var out CommandResponse
+	if err := c.do(ctx, request{method: http.MethodPost, path: "/channels/disconnect", idempotent: true}, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// Channel es un canal público de GET /channels/public
+type Channel struct {
+	Code     string `json:"code"`
+	Name     string `json:"name"`
+	MaxUsers int    `json:"maxUsers"`
+}
+
+// ListChannels devuelve los canales públicos; no necesita sesión
+func (c *Client) ListChannels(ctx context.Context) ([]Channel, error) {
+	var out []Channel
+	if err := c.do(ctx, request{method: http.MethodGet, path: "/channels/public", noAuth: true, idempotent: true}, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// ChannelMember es un usuario conectado a un canal
+type ChannelMember struct {
+	ID          uint   `json:"id"`
+	DisplayName string `json:"displayName"`
+}
+
+// ChannelUsers devuelve los miembros activos del canal
+func (c *Client) ChannelUsers(ctx context.Context, code string) ([]ChannelMember, error) {
+	var out []ChannelMember
+	req := request{method: http.MethodGet, path: "/channel-users", query: url.Values{"channel": {code}}, noAuth: true, idempotent: true}
+	if err := c.do(ctx, req, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
@@ -0,0 +1,403 @@
+// Package client es un cliente Go tipado de la API HTTP y WebSocket del backend. Gestiona
+// los tokens (refresco automático y nueva autenticación si hace falta) y reintenta las
+// peticiones que el servidor rechaza de forma transitoria.
+//
+//	c, err := client.New("https://walkie.example.com")
+//	if _, err := c.Authenticate(ctx, "Juan", 1234); err != nil { ... }
+//	c.ConnectChannel(ctx, "canal-1")
+//	res, err := c.IngestAudio(ctx, wav, "audio/wav")
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// APIVersion es la versión de la API que habla el cliente
+const APIVersion = "1"
+
+const (
+	defaultMaxAttempts = 3
+	defaultBackoff     = 250 * time.Millisecond
+	maxBackoff         = 5 * time.Second
+	// refreshMargin renueva el token de acceso un poco antes de que caduque
+	refreshMargin = 30 * time.Second
+)
+
+// ErrNotAuthenticated lo devuelven las llamadas que necesitan sesión si no hay token
+var ErrNotAuthenticated = errors.New("client: sin sesión, llama antes a Authenticate")
+
+// APIError es una respuesta de error del servidor
+type APIError struct {
+	StatusCode int
+	Message    string
+	// RetryAfter es la espera que pide el servidor en los 429 y 503
+	RetryAfter time.Duration
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("client: HTTP %d: %s", e.StatusCode, e.Message)
+}
+
+// Client habla con un servidor; es seguro para usar desde varias goroutines
+type Client struct {
+	baseURL     *url.URL
+	http        *http.Client
+	maxAttempts int
+	backoff     time.Duration
+	userAgent   string
+
+	mu          sync.Mutex
+	token       string
+	accessToken string
+	refresh     string
+	expiresAt   time.Time
+	name        string
+	pin         int
+	userID      uint
+}
+
+// Option configura el cliente en New
+type Option func(*Client)
+
+// WithHTTPClient usa hc en lugar de http.DefaultClient
+func WithHTTPClient(hc *http.Client) Option {
+	return func(c *Client) { c.http = hc }
+}
+
+// WithRetries fija los intentos por petición (1 desactiva los reintentos) y la espera
+// inicial entre ellos, que se duplica en cada intento
+func WithRetries(attempts int, backoff time.Duration) Option {
+	return func(c *Client) {
+		c.maxAttempts = max(1, attempts)
+		c.backoff = backoff
+	}
+}
+
+// WithToken arranca con un token de sesión ya obtenido (X-Auth-Token)
+func WithToken(token string) Option {
+	return func(c *Client) { c.token = token }
+}
+
+// WithUserAgent identifica al cliente en los logs del servidor
+func WithUserAgent(ua string) Option {
+	return func(c *Client) { c.userAgent = ua }
+}
+
+// New crea un cliente para baseURL, p. ej. "http://localhost:8080"
+func New(baseURL string, opts ...Option) (*Client, error) {
+	u, err := url.Parse(strings.TrimRight(baseURL, "/"))
+	if err != nil || u.Scheme == "" || u.Host == "" {
+		return nil, fmt.Errorf("client: URL base inválida %q", baseURL)
+	}
+	c := &Client{
+		baseURL:     u,
+		http:        http.DefaultClient,
+		maxAttempts: defaultMaxAttempts,
+		backoff:     defaultBackoff,
+		userAgent:   "walkie-go-client",
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c, nil
+}
+
+// TokenPair son los tokens que devuelven /auth y /auth/refresh
+type TokenPair struct {
+	AccessToken  string `json:"access_token"`
+	RefreshToken string `json:"refresh_token"`
+	ExpiresIn    int64  `json:"expires_in"`
+	TokenType    string `json:"token_type"`
+}
+
+// AuthResult es la respuesta de Authenticate
+type AuthResult struct {
+	Message   string `json:"message"`
+	Token     string `json:"token"`
+	SessionID uint   `json:"session_id,omitempty"`
+	TokenPair
+}
+
+// Authenticate registra o inicia sesión; el cliente guarda los tokens y las credenciales
+// para volver a autenticarse si la sesión se pierde
+func (c *Client) Authenticate(ctx context.Context, name string, pin int) (*AuthResult, error) {
+	body := map[string]any{"nombre": name, "pin": pin, "dispositivo": c.userAgent, "plataforma": "go"}
+	var out AuthResult
+	if err := c.do(ctx, request{method: http.MethodPost, path: "/auth", json: body, noAuth: true}, &out); err != nil {
+		return nil, err
+	}
+	c.mu.Lock()
+	c.name, c.pin, c.userID = name, pin, 0
+	c.storeTokensLocked(out.Token, out.TokenPair)
+	c.mu.Unlock()
+	return &out, nil
+}
+
+// Refresh cambia el token de refresco por un par nuevo
+func (c *Client) Refresh(ctx context.Context) error {
+	c.mu.Lock()
+	refresh := c.refresh
+	c.mu.Unlock()
+	if refresh == "" {
+		return ErrNotAuthenticated
+	}
+	var pair TokenPair
+	if err := c.do(ctx, request{method: http.MethodPost, path: "/auth/refresh", json: map[string]string{"refresh_token": refresh}, noAuth: true}, &pair); err != nil {
+		return err
+	}
+	c.mu.Lock()
+	c.storeTokensLocked(c.token, pair)
+	c.mu.Unlock()
+	return nil
+}
+
+// Logout cierra la sesión en el servidor y olvida los tokens
+func (c *Client) Logout(ctx context.Context) error {
+	err := c.do(ctx, request{method: http.MethodPost, path: "/auth/logout"}, nil)
+	c.mu.Lock()
+	c.token, c.accessToken, c.refresh, c.name, c.userID = "", "", "", "", 0
+	c.mu.Unlock()
+	return err
+}
+
+// Token devuelve el token con el que se autentican las peticiones
+func (c *Client) Token() string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.accessToken != "" {
+		return c.accessToken
+	}
+	return c.token
+}
+
+func (c *Client) storeTokensLocked(token string, pair TokenPair) {
+	c.token = token
+	c.accessToken = pair.AccessToken
+	if pair.RefreshToken != "" {
+		c.refresh = pair.RefreshToken
+	}
+	c.expiresAt = time.Time{}
+	if pair.ExpiresIn > 0 {
+		c.expiresAt = time.Now().Add(time.Duration(pair.ExpiresIn) * time.Second)
+	}
+}
+
+// request describe una llamada; el cuerpo es json o raw con su contentType
+type request struct {
+	method      string
+	path        string
+	query       url.Values
+	header      http.Header
+	json        any
+	raw         []byte
+	contentType string
+	noAuth      bool
+	// idempotent permite reintentar también tras un error de red; sin ella sólo se
+	// reintenta cuando el servidor responde que no procesó la petición (429, 503...)
+	idempotent bool
+}
+
+// do envía la petición y decodifica la respuesta JSON en out (si no es nil)
+func (c *Client) do(ctx context.Context, req request, out any) error {
+	resp, err := c.send(ctx, req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if out == nil || resp.StatusCode == http.StatusNoContent {
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil && !errors.Is(err, io.EOF) {
+		return fmt.Errorf("client: respuesta inválida de %s: %w", req.path, err)
+	}
+	return nil
+}
+
+// send hace la petición con reintentos y renovación de token; devuelve la respuesta
+// abierta si el estado es 2xx o 3xx y un *APIError en otro caso
+func (c *Client) send(ctx context.Context, req request) (*http.Response, error) {
+	body := req.raw
+	contentType := req.contentType
+	if req.json != nil {
+		encoded, err := json.Marshal(req.json)
+		if err != nil {
+			return nil, err
+		}
+		body, contentType = encoded, "application/json"
+	}
+
+	reauthed := false
+	for attempt := 1; ; attempt++ {
+		if !req.noAuth {
+			if err := c.ensureFreshToken(ctx); err != nil {
+				return nil, err
+			}
+		}
+		resp, err := c.roundTrip(ctx, req, body, contentType)
+		if err != nil {
+			if ctx.Err() != nil || !req.idempotent || attempt >= c.maxAttempts {
+				return nil, err
+			}
+			if err := c.wait(ctx, attempt, 0); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		if resp.StatusCode < 400 {
+			return resp, nil
+		}
+
+		apiErr := readAPIError(resp)
+		if resp.StatusCode == http.StatusUnauthorized && !req.noAuth && !reauthed {
+			reauthed = true
+			if c.recoverSession(ctx) == nil {
+				attempt--
+				continue
+			}
+		}
+		if !retryableStatus(resp.StatusCode) || attempt >= c.maxAttempts {
+			return nil, apiErr
+		}
+		if err := c.wait(ctx, attempt, apiErr.RetryAfter); err != nil {
+			return nil, err
+		}
+	}
+}
+
+func (c *Client) roundTrip(ctx context.Context, req request, body []byte, contentType string) (*http.Response, error) {
+	u := *c.baseURL
+	u.Path += "/v" + APIVersion + req.path
+	u.RawQuery = req.query.Encode()
+	var reader io.Reader
+	if body != nil {
+		reader = bytes.NewReader(body)
+	}
+	httpReq, err := http.NewRequestWithContext(ctx, req.method, u.String(), reader)
+	if err != nil {
+		return nil, err
+	}
+	for k, v := range req.header {
+		httpReq.Header[k] = v
+	}
+	if contentType != "" {
+		httpReq.Header.Set("Content-Type", contentType)
+	}
+	httpReq.Header.Set("API-Version", APIVersion)
+	httpReq.Header.Set("User-Agent", c.userAgent)
+	if !req.noAuth {
+		c.mu.Lock()
+		switch {
+		case c.accessToken != "":
+			httpReq.Header.Set("Authorization", "Bearer "+c.accessToken)
+		case c.token != "":
+			httpReq.Header.Set("X-Auth-Token", c.token)
+		}
+		c.mu.Unlock()
+	}
+	return c.httpClient(req).Do(httpReq)
+}
+
+// httpClient no sigue redirecciones en GET /audio/poll: las cabeceras del clip vienen en
+// el 303 y se perderían
+func (c *Client) httpClient(req request) *http.Client {
+	if req.path != "/audio/poll" {
+		return c.http
+	}
+	hc := *c.http
+	hc.CheckRedirect = func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse }
+	return &hc
+}
+
+// ensureFreshToken renueva el token de acceso si está a punto de caducar
+func (c *Client) ensureFreshToken(ctx context.Context) error {
+	c.mu.Lock()
+	hasToken := c.token != "" || c.accessToken != ""
+	expiring := c.refresh != "" && !c.expiresAt.IsZero() && time.Until(c.expiresAt) < refreshMargin
+	c.mu.Unlock()
+	if !hasToken {
+		return ErrNotAuthenticated
+	}
+	if expiring {
+		if err := c.Refresh(ctx); err != nil {
+			return c.recoverSession(ctx)
+		}
+	}
+	return nil
+}
+
+// recoverSession intenta el refresco y, si falla, vuelve a autenticarse con las
+// credenciales guardadas
+func (c *Client) recoverSession(ctx context.Context) error {
+	if c.Refresh(ctx) == nil {
+		return nil
+	}
+	c.mu.Lock()
+	name, pin := c.name, c.pin
+	c.mu.Unlock()
+	if name == "" {
+		return ErrNotAuthenticated
+	}
+	_, err := c.Authenticate(ctx, name, pin)
+	return err
+}
+
+func retryableStatus(status int) bool {
+	switch status {
+	case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+// wait espera antes del siguiente intento: Retry-After si el servidor lo pide, si no
+// un backoff exponencial con algo de azar
+func (c *Client) wait(ctx context.Context, attempt int, retryAfter time.Duration) error {
+	d := retryAfter
+	if d <= 0 {
+		d = min(c.backoff<<(attempt-1), maxBackoff)
+		if d > 0 {
+			d += time.Duration(rand.Int63n(int64(d)/4 + 1))
+		}
+	}
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
+
+func readAPIError(resp *http.Response) *APIError {
+	defer resp.Body.Close()
+	data, _ := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+	apiErr := &APIError{StatusCode: resp.StatusCode, Message: strings.TrimSpace(string(data))}
+	var body struct {
+		Error   string `json:"error"`
+		Message string `json:"message"`
+	}
+	if json.Unmarshal(data, &body) == nil {
+		if body.Error != "" {
+			apiErr.Message = body.Error
+		} else if body.Message != "" {
+			apiErr.Message = body.Message
+		}
+	}
+	if secs, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil && secs > 0 {
+		apiErr.RetryAfter = time.Duration(secs) * time.Second
+	}
+	return apiErr
+}
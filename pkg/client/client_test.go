@@ -0,0 +1,238 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestClient(t *testing.T, mux *http.ServeMux) (*Client, *httptest.Server) {
+	t.Helper()
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+	c, err := New(srv.URL, WithRetries(3, time.Millisecond))
+	require.NoError(t, err)
+	return c, srv
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func TestClient_AuthenticateAndRefreshOn401(t *testing.T) {
+	var refreshed atomic.Int32
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /v1/auth", func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]any
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		assert.Equal(t, "Juan", body["nombre"])
+		assert.Equal(t, "1", r.Header.Get("API-Version"))
+		writeJSON(w, http.StatusOK, map[string]any{"token": "legacy", "access_token": "old", "refresh_token": "r1", "expires_in": 900})
+	})
+	mux.HandleFunc("POST /v1/auth/refresh", func(w http.ResponseWriter, r *http.Request) {
+		refreshed.Add(1)
+		writeJSON(w, http.StatusOK, map[string]any{"access_token": "new", "refresh_token": "r2", "expires_in": 900})
+	})
+	mux.HandleFunc("GET /v1/me", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer new" {
+			writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "Token expirado"})
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string]any{"id": 7, "displayName": "Juan"})
+	})
+	c, _ := newTestClient(t, mux)
+
+	_, err := c.Me(context.Background())
+	assert.ErrorIs(t, err, ErrNotAuthenticated)
+
+	_, err = c.Authenticate(context.Background(), "Juan", 1234)
+	require.NoError(t, err)
+	me, err := c.Me(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, uint(7), me.ID)
+	assert.Equal(t, int32(1), refreshed.Load())
+	assert.Equal(t, "new", c.Token())
+}
+
+func TestClient_RetriesTransientErrors(t *testing.T) {
+	var calls atomic.Int32
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /v1/channels/{code}/connect", func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) < 3 {
+			writeJSON(w, http.StatusServiceUnavailable, map[string]string{"error": "Servicio no disponible"})
+			return
+		}
+		assert.Equal(t, "tok", r.Header.Get("X-Auth-Token"))
+		writeJSON(w, http.StatusOK, map[string]any{"status": "ok", "intent": "channel_connect", "message": "Conectado al canal " + r.PathValue("code")})
+	})
+	mux.HandleFunc("POST /v1/channels/disconnect", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusForbidden, map[string]string{"error": "Prohibido"})
+	})
+	c, _ := newTestClient(t, mux)
+	WithToken("tok")(c)
+
+	resp, err := c.ConnectChannel(context.Background(), "canal-1")
+	require.NoError(t, err)
+	assert.Equal(t, "Conectado al canal canal-1", resp.Message)
+	assert.Equal(t, int32(3), calls.Load())
+
+	_, err = c.DisconnectChannel(context.Background())
+	var apiErr *APIError
+	require.True(t, errors.As(err, &apiErr))
+	assert.Equal(t, http.StatusForbidden, apiErr.StatusCode)
+	assert.Equal(t, "Prohibido", apiErr.Message)
+}
+
+func TestClient_IngestAndPollAudio(t *testing.T) {
+	var polls atomic.Int32
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /v1/audio/ingest", func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "audio/wav", r.Header.Get("Content-Type"))
+		if body, _ := io.ReadAll(r.Body); string(body) == "comando" {
+			writeJSON(w, http.StatusOK, map[string]any{"status": "ok", "intent": "request_channel_list", "message": "Canales"})
+			return
+		}
+		w.Header().Set("X-Transmission-ID", "tx-1")
+		w.WriteHeader(http.StatusNoContent)
+	})
+	mux.HandleFunc("GET /v1/audio/poll", func(w http.ResponseWriter, r *http.Request) {
+		h := w.Header()
+		h.Set("X-Audio-From", "3")
+		h.Set("X-Channel", "canal-1")
+		h.Set("X-Audio-Timestamp", "2026-01-02T03:04:05Z")
+		h.Set("X-Transmission-ID", "tx-9")
+		switch polls.Add(1) {
+		case 1:
+			assert.Equal(t, "true", r.Header.Get("X-Audio-Ack"))
+			h.Set("X-Delivery-ID", "d-1")
+			h.Set("Content-Type", "audio/wav")
+			_, _ = w.Write([]byte("RIFF"))
+		case 2:
+			h.Set("Location", "/bucket/clip.ogg")
+			w.WriteHeader(http.StatusSeeOther)
+		default:
+			w.WriteHeader(http.StatusNoContent)
+		}
+	})
+	mux.HandleFunc("GET /bucket/clip.ogg", func(w http.ResponseWriter, r *http.Request) {
+		assert.Empty(t, r.Header.Get("X-Auth-Token"))
+		w.Header().Set("Content-Type", "audio/ogg")
+		_, _ = w.Write([]byte("OggS"))
+	})
+	c, _ := newTestClient(t, mux)
+	WithToken("tok")(c)
+	ctx := context.Background()
+
+	res, err := c.IngestAudio(ctx, []byte("voz"), "audio/wav")
+	require.NoError(t, err)
+	assert.Equal(t, "tx-1", res.TransmissionID)
+	assert.Nil(t, res.Command)
+	res, err = c.IngestAudio(ctx, []byte("comando"), "audio/wav")
+	require.NoError(t, err)
+	require.NotNil(t, res.Command)
+	assert.Equal(t, "request_channel_list", res.Command.Intent)
+
+	clip, err := c.PollAudio(ctx, PollOptions{Ack: true})
+	require.NoError(t, err)
+	assert.Equal(t, []byte("RIFF"), clip.Audio)
+	assert.Equal(t, uint(3), clip.From)
+	assert.Equal(t, "canal-1", clip.Channel)
+	assert.Equal(t, "d-1", clip.DeliveryID)
+	assert.Equal(t, 2026, clip.Timestamp.Year())
+
+	clip, err = c.PollAudio(ctx, PollOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, []byte("OggS"), clip.Audio)
+	assert.Equal(t, "audio/ogg", clip.ContentType)
+	assert.Equal(t, "tx-9", clip.TransmissionID)
+
+	clip, err = c.PollAudio(ctx, PollOptions{})
+	require.NoError(t, err)
+	assert.Nil(t, clip)
+}
+
+func TestClient_SubscribeResumesAfterReconnect(t *testing.T) {
+	upgrader := websocket.Upgrader{}
+	handshakes := make(chan map[string]any, 2)
+	var conns atomic.Int32
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /v1/me", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, map[string]any{"id": 7})
+	})
+	mux.HandleFunc("GET /v1/ws", func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		require.NoError(t, err)
+		defer conn.Close()
+		var hs map[string]any
+		require.NoError(t, conn.ReadJSON(&hs))
+		handshakes <- hs
+
+		_ = conn.WriteJSON(map[string]string{"message": "Conexión establecida", "channel": "canal-1"})
+		if conns.Add(1) == 1 {
+			_ = conn.WriteJSON(map[string]any{"type": "resume", "seq": 4})
+			_ = conn.WriteMessage(websocket.BinaryMessage, []byte("audio-5"))
+			return // corta la conexión para forzar la reconexión
+		}
+		_ = conn.WriteJSON(map[string]any{"type": "resume", "seq": 5})
+		_ = conn.WriteMessage(websocket.BinaryMessage, []byte("audio-6"))
+		_, _, _ = conn.ReadMessage()
+	})
+	c, _ := newTestClient(t, mux)
+	WithToken("tok")(c)
+
+	sub, err := c.Subscribe(context.Background(), "canal-1")
+	require.NoError(t, err)
+
+	var got []string
+	for ev := range sub.Events() {
+		got = append(got, ev.Type)
+		if ev.Type == "audio" && string(ev.Audio) == "audio-6" {
+			assert.Equal(t, uint64(6), ev.Seq)
+			break
+		}
+	}
+	require.NoError(t, sub.Close())
+	assert.NoError(t, sub.Err())
+	assert.Equal(t, strings.Fields("welcome resume audio welcome resume audio"), got)
+
+	first, second := <-handshakes, <-handshakes
+	assert.Equal(t, float64(7), first["userId"])
+	assert.Equal(t, "tok", first["token"])
+	assert.Equal(t, float64(0), first["lastReceivedSeq"])
+	assert.Equal(t, float64(5), second["lastReceivedSeq"])
+}
+
+func TestClient_SubscribeRejected(t *testing.T) {
+	upgrader := websocket.Upgrader{}
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /v1/ws", func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		require.NoError(t, err)
+		defer conn.Close()
+		_, _, _ = conn.ReadMessage()
+		_ = conn.WriteMessage(websocket.TextMessage, []byte("Sesión no autorizada"))
+	})
+	c, _ := newTestClient(t, mux)
+	WithToken("tok")(c)
+	c.userID = 7
+
+	sub, err := c.Subscribe(context.Background(), "")
+	require.NoError(t, err)
+	for range sub.Events() {
+	}
+	require.Error(t, sub.Err())
+	assert.Contains(t, sub.Err().Error(), "Sesión no autorizada")
+}
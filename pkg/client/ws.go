@@ -0,0 +1,250 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"sync"
+
+	"github.com/gorilla/websocket"
+)
+
+// Event es un mensaje recibido por WebSocket: Audio en los frames binarios, Type y Raw
+// (el JSON completo) en los de control como transmission, played_by o channel_changed
+type Event struct {
+	Type  string
+	Raw   json.RawMessage
+	Audio []byte
+	// Seq es el número del frame de audio, el que se usa para reanudar
+	Seq uint64
+}
+
+// Subscription es una conexión WebSocket que se reconecta sola y reanuda desde el
+// último audio recibido
+type Subscription struct {
+	c       *Client
+	channel string
+	events  chan Event
+	cancel  context.CancelFunc
+	done    chan struct{}
+
+	mu   sync.Mutex
+	conn *websocket.Conn
+	// seq es el último frame de audio recibido; el servidor lo usa para reenviar lo perdido
+	seq uint64
+	err error
+}
+
+// errHandshake es un rechazo del servidor; no tiene sentido reintentar
+type errHandshake string
+
+func (e errHandshake) Error() string { return "client: websocket rechazado: " + string(e) }
+
+// Subscribe abre /ws en el canal (vacío: el canal actual del usuario) y entrega los
+// mensajes por Events hasta que se cancele ctx o se llame a Close
+func (c *Client) Subscribe(ctx context.Context, channel string) (*Subscription, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	s := &Subscription{
+		c:       c,
+		channel: channel,
+		events:  make(chan Event, 64),
+		cancel:  cancel,
+		done:    make(chan struct{}),
+	}
+	conn, err := s.dial(ctx)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	go s.run(ctx, conn)
+	return s, nil
+}
+
+// Events se cierra cuando termina la suscripción; Err explica por qué
+func (s *Subscription) Events() <-chan Event { return s.events }
+
+// Err devuelve el error que cerró la suscripción, nil si fue Close
+func (s *Subscription) Err() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.err
+}
+
+// Send envía un mensaje de control, p. ej. {"type":"chat","text":"hola"}
+func (s *Subscription) Send(v any) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return s.write(websocket.TextMessage, data)
+}
+
+// SendAudio envía un clip como frame binario; el servidor responde con ingest_result
+func (s *Subscription) SendAudio(audio []byte) error {
+	return s.write(websocket.BinaryMessage, audio)
+}
+
+func (s *Subscription) write(kind int, data []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.conn == nil {
+		return errors.New("client: websocket desconectado")
+	}
+	return s.conn.WriteMessage(kind, data)
+}
+
+// Close termina la suscripción y espera a que se cierre Events
+func (s *Subscription) Close() error {
+	s.cancel()
+	s.mu.Lock()
+	if s.conn != nil {
+		_ = s.conn.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""))
+		_ = s.conn.Close()
+	}
+	s.mu.Unlock()
+	<-s.done
+	return nil
+}
+
+// run lee hasta que la conexión cae y entonces reconecta con backoff
+func (s *Subscription) run(ctx context.Context, conn *websocket.Conn) {
+	defer close(s.done)
+	defer close(s.events)
+
+	for attempt := 1; ; {
+		err := s.read(ctx, conn)
+		if ctx.Err() != nil {
+			return
+		}
+		var rejected errHandshake
+		if errors.As(err, &rejected) {
+			s.fail(err)
+			return
+		}
+		for {
+			if err := s.c.wait(ctx, attempt, 0); err != nil {
+				return
+			}
+			attempt++
+			if conn, err = s.dial(ctx); err == nil {
+				attempt = 1
+				break
+			}
+			if errors.As(err, &rejected) || errors.Is(err, ErrNotAuthenticated) {
+				s.fail(err)
+				return
+			}
+		}
+	}
+}
+
+func (s *Subscription) fail(err error) {
+	s.mu.Lock()
+	s.err = err
+	s.mu.Unlock()
+}
+
+// dial conecta y manda el saludo con el token, el canal y el último seq recibido
+func (s *Subscription) dial(ctx context.Context) (*websocket.Conn, error) {
+	if err := s.c.ensureFreshToken(ctx); err != nil {
+		return nil, err
+	}
+	s.c.mu.Lock()
+	userID := s.c.userID
+	s.c.mu.Unlock()
+	if userID == 0 {
+		if _, err := s.c.Me(ctx); err != nil {
+			return nil, err
+		}
+		s.c.mu.Lock()
+		userID = s.c.userID
+		s.c.mu.Unlock()
+	}
+
+	u := *s.c.baseURL
+	switch u.Scheme {
+	case "https":
+		u.Scheme = "wss"
+	default:
+		u.Scheme = "ws"
+	}
+	u.Path += "/v" + APIVersion + "/ws"
+	header := http.Header{"API-Version": {APIVersion}, "User-Agent": {s.c.userAgent}}
+	conn, _, err := websocket.DefaultDialer.DialContext(ctx, u.String(), header)
+	if err != nil {
+		return nil, err
+	}
+
+	// lastReceivedSeq siempre va para que el servidor mande resume, aunque sea 0
+	s.mu.Lock()
+	handshake := map[string]any{"userId": userID, "channel": s.channel, "token": s.c.Token(), "lastReceivedSeq": s.seq}
+	s.mu.Unlock()
+	if err := conn.WriteJSON(handshake); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	s.mu.Lock()
+	s.conn = conn
+	s.mu.Unlock()
+	return conn, nil
+}
+
+// read entrega los mensajes de conn. El servidor contesta al saludo con un mensaje de
+// bienvenida sin type (se entrega como "welcome") y con resume, que fija el seq desde el
+// que se cuentan los frames binarios; si rechaza la sesión manda un texto plano
+func (s *Subscription) read(ctx context.Context, conn *websocket.Conn) error {
+	stop := context.AfterFunc(ctx, func() { _ = conn.Close() })
+	defer stop()
+	defer func() {
+		s.mu.Lock()
+		if s.conn == conn {
+			s.conn = nil
+		}
+		s.mu.Unlock()
+		conn.Close()
+	}()
+
+	first := true
+	for {
+		kind, data, err := conn.ReadMessage()
+		if err != nil {
+			return err
+		}
+		ev := Event{}
+		if kind == websocket.BinaryMessage {
+			s.mu.Lock()
+			s.seq++
+			ev.Seq = s.seq
+			s.mu.Unlock()
+			ev.Type, ev.Audio = "audio", data
+		} else {
+			var msg struct {
+				Type string  `json:"type"`
+				Seq  *uint64 `json:"seq"`
+			}
+			if json.Unmarshal(data, &msg) != nil {
+				if first {
+					return errHandshake(data)
+				}
+				continue
+			}
+			switch {
+			case msg.Type == "" && first:
+				msg.Type = "welcome"
+			case msg.Type == "resume" && msg.Seq != nil:
+				s.mu.Lock()
+				s.seq = *msg.Seq
+				s.mu.Unlock()
+			}
+			ev.Type, ev.Raw = msg.Type, json.RawMessage(data)
+		}
+		first = false
+
+		select {
+		case s.events <- ev:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
@@ -0,0 +1,277 @@
+package fcm
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	defaultAPIURL   = "https://fcm.googleapis.com"
+	defaultTokenURI = "https://oauth2.googleapis.com/token"
+	messagingScope  = "https://www.googleapis.com/auth/firebase.messaging"
+)
+
+var (
+	// ErrNotConfigured indica que no hay credenciales de FCM en el entorno
+	ErrNotConfigured = errors.New("fcm: credenciales no configuradas")
+	// ErrUnregistered indica que el token del dispositivo ya no es válido y debe borrarse
+	ErrUnregistered = errors.New("fcm: token de dispositivo no registrado")
+)
+
+// serviceAccount es el subconjunto del JSON de cuenta de servicio de Firebase que se usa
+type serviceAccount struct {
+	ProjectID   string `json:"project_id"`
+	ClientEmail string `json:"client_email"`
+	PrivateKey  string `json:"private_key"`
+	TokenURI    string `json:"token_uri"`
+}
+
+// Client envía mensajes de datos con la API HTTP v1 de Firebase Cloud Messaging
+type Client struct {
+	httpClient *http.Client
+	apiURL     string
+	projectID  string
+	email      string
+	tokenURI   string
+	key        *rsa.PrivateKey
+
+	mu          sync.Mutex
+	accessToken string
+	expiresAt   time.Time
+}
+
+// NewClientFromEnv lee la cuenta de servicio de FCM_CREDENTIALS_JSON o del fichero
+// FCM_CREDENTIALS_FILE. FCM_API_URL permite apuntar a otro servidor (p. ej. en pruebas).
+func NewClientFromEnv() (*Client, error) {
+	raw := strings.TrimSpace(os.Getenv("FCM_CREDENTIALS_JSON"))
+	if raw == "" {
+		path := strings.TrimSpace(os.Getenv("FCM_CREDENTIALS_FILE"))
+		if path == "" {
+			return nil, ErrNotConfigured
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("fcm: leer credenciales: %w", err)
+		}
+		raw = string(data)
+	}
+
+	client, err := NewClient([]byte(raw))
+	if err != nil {
+		return nil, err
+	}
+	if apiURL := strings.TrimSpace(os.Getenv("FCM_API_URL")); apiURL != "" {
+		client.apiURL = strings.TrimRight(apiURL, "/")
+	}
+	return client, nil
+}
+
+// NewClient crea el cliente a partir del JSON de la cuenta de servicio
+func NewClient(credentials []byte) (*Client, error) {
+	var sa serviceAccount
+	if err := json.Unmarshal(credentials, &sa); err != nil {
+		return nil, fmt.Errorf("fcm: credenciales inválidas: %w", err)
+	}
+	if sa.ProjectID == "" || sa.ClientEmail == "" || sa.PrivateKey == "" {
+		return nil, errors.New("fcm: faltan project_id, client_email o private_key")
+	}
+	key, err := parsePrivateKey(sa.PrivateKey)
+	if err != nil {
+		return nil, err
+	}
+	if sa.TokenURI == "" {
+		sa.TokenURI = defaultTokenURI
+	}
+
+	return &Client{
+		httpClient: &http.Client{Timeout: 10 * time.Second},
+		apiURL:     defaultAPIURL,
+		projectID:  sa.ProjectID,
+		email:      sa.ClientEmail,
+		tokenURI:   sa.TokenURI,
+		key:        key,
+	}, nil
+}
+
+func parsePrivateKey(pemKey string) (*rsa.PrivateKey, error) {
+	block, _ := pem.Decode([]byte(pemKey))
+	if block == nil {
+		return nil, errors.New("fcm: private_key no es PEM")
+	}
+	if key, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("fcm: private_key inválida: %w", err)
+	}
+	key, ok := parsed.(*rsa.PrivateKey)
+	if !ok {
+		return nil, errors.New("fcm: private_key no es RSA")
+	}
+	return key, nil
+}
+
+type sendRequest struct {
+	Message message `json:"message"`
+}
+
+type message struct {
+	Token   string            `json:"token"`
+	Data    map[string]string `json:"data"`
+	Android map[string]string `json:"android,omitempty"`
+	APNS    *apnsConfig       `json:"apns,omitempty"`
+}
+
+type apnsConfig struct {
+	Headers map[string]string `json:"headers"`
+	Payload map[string]any    `json:"payload"`
+}
+
+type errorResponse struct {
+	Error struct {
+		Status  string `json:"status"`
+		Message string `json:"message"`
+		Details []struct {
+			ErrorCode string `json:"errorCode"`
+		} `json:"details"`
+	} `json:"error"`
+}
+
+// Send envía un mensaje de datos de alta prioridad al dispositivo. Devuelve
+// ErrUnregistered si FCM indica que el token ya no existe.
+func (c *Client) Send(ctx context.Context, deviceToken string, data map[string]string) error {
+	accessToken, err := c.token(ctx)
+	if err != nil {
+		return err
+	}
+
+	payload, err := json.Marshal(sendRequest{Message: message{
+		Token:   deviceToken,
+		Data:    data,
+		Android: map[string]string{"priority": "high"},
+		APNS: &apnsConfig{
+			Headers: map[string]string{"apns-priority": "5", "apns-push-type": "background"},
+			Payload: map[string]any{"aps": map[string]any{"content-available": 1}},
+		},
+	}})
+	if err != nil {
+		return err
+	}
+
+	endpoint := fmt.Sprintf("%s/v1/projects/%s/messages:send", c.apiURL, url.PathEscape(c.projectID))
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+accessToken)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("fcm: enviar: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusOK {
+		_, _ = io.Copy(io.Discard, resp.Body)
+		return nil
+	}
+
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+	var decoded errorResponse
+	_ = json.Unmarshal(body, &decoded)
+	for _, d := range decoded.Error.Details {
+		if d.ErrorCode == "UNREGISTERED" {
+			return ErrUnregistered
+		}
+	}
+	if resp.StatusCode == http.StatusNotFound {
+		return ErrUnregistered
+	}
+	return fmt.Errorf("fcm: status %d: %s", resp.StatusCode, strings.TrimSpace(decoded.Error.Message))
+}
+
+// token devuelve un access token OAuth2 vigente, renovándolo un minuto antes de caducar
+func (c *Client) token(ctx context.Context) (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := time.Now()
+	if c.accessToken != "" && now.Add(time.Minute).Before(c.expiresAt) {
+		return c.accessToken, nil
+	}
+
+	assertion, err := c.signAssertion(now)
+	if err != nil {
+		return "", err
+	}
+	form := url.Values{
+		"grant_type": {"urn:ietf:params:oauth:grant-type:jwt-bearer"},
+		"assertion":  {assertion},
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.tokenURI, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("fcm: obtener token: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return "", fmt.Errorf("fcm: obtener token: status %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+
+	var decoded struct {
+		AccessToken string `json:"access_token"`
+		ExpiresIn   int    `json:"expires_in"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&decoded); err != nil || decoded.AccessToken == "" {
+		return "", fmt.Errorf("fcm: respuesta de token inválida: %v", err)
+	}
+
+	c.accessToken = decoded.AccessToken
+	c.expiresAt = now.Add(time.Duration(decoded.ExpiresIn) * time.Second)
+	return c.accessToken, nil
+}
+
+// signAssertion firma con RS256 el JWT que se intercambia por el access token
+func (c *Client) signAssertion(now time.Time) (string, error) {
+	header, _ := json.Marshal(map[string]string{"alg": "RS256", "typ": "JWT"})
+	claims, _ := json.Marshal(map[string]any{
+		"iss":   c.email,
+		"scope": messagingScope,
+		"aud":   c.tokenURI,
+		"iat":   now.Unix(),
+		"exp":   now.Add(time.Hour).Unix(),
+	})
+
+	enc := base64.RawURLEncoding
+	signingInput := enc.EncodeToString(header) + "." + enc.EncodeToString(claims)
+	digest := sha256.Sum256([]byte(signingInput))
+	signature, err := rsa.SignPKCS1v15(nil, c.key, crypto.SHA256, digest[:])
+	if err != nil {
+		return "", fmt.Errorf("fcm: firmar assertion: %w", err)
+	}
+	return signingInput + "." + enc.EncodeToString(signature), nil
+}
@@ -0,0 +1,114 @@
+package fcm
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+func testCredentials(t *testing.T, tokenURI string) []byte {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("generate key: %v", err)
+	}
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		t.Fatalf("marshal key: %v", err)
+	}
+	pemKey := pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})
+	creds, _ := json.Marshal(map[string]string{
+		"project_id":   "walkie-test",
+		"client_email": "push@walkie-test.iam.gserviceaccount.com",
+		"private_key":  string(pemKey),
+		"token_uri":    tokenURI,
+	})
+	return creds
+}
+
+func TestClient_SendReusesAccessToken(t *testing.T) {
+	var tokenCalls, sendCalls atomic.Int32
+	var lastMessage sendRequest
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/token":
+			tokenCalls.Add(1)
+			_ = r.ParseForm()
+			if r.Form.Get("grant_type") != "urn:ietf:params:oauth:grant-type:jwt-bearer" || strings.Count(r.Form.Get("assertion"), ".") != 2 {
+				http.Error(w, "bad assertion", http.StatusBadRequest)
+				return
+			}
+			_ = json.NewEncoder(w).Encode(map[string]any{"access_token": "ya29.test", "expires_in": 3600})
+		case r.URL.Path == "/v1/projects/walkie-test/messages:send":
+			sendCalls.Add(1)
+			if r.Header.Get("Authorization") != "Bearer ya29.test" {
+				http.Error(w, "unauthorized", http.StatusUnauthorized)
+				return
+			}
+			_ = json.NewDecoder(r.Body).Decode(&lastMessage)
+			_, _ = w.Write([]byte(`{"name":"projects/walkie-test/messages/1"}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	client, err := NewClient(testCredentials(t, server.URL+"/token"))
+	if err != nil {
+		t.Fatalf("NewClient: %v", err)
+	}
+	client.apiURL = server.URL
+
+	for i := 0; i < 2; i++ {
+		if err := client.Send(context.Background(), "device-1", map[string]string{"type": "audio_pending"}); err != nil {
+			t.Fatalf("Send: %v", err)
+		}
+	}
+
+	if tokenCalls.Load() != 1 || sendCalls.Load() != 2 {
+		t.Fatalf("expected 1 token call and 2 sends, got %d and %d", tokenCalls.Load(), sendCalls.Load())
+	}
+	if lastMessage.Message.Token != "device-1" || lastMessage.Message.Data["type"] != "audio_pending" {
+		t.Fatalf("unexpected message %+v", lastMessage.Message)
+	}
+}
+
+func TestClient_SendUnregistered(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/token" {
+			_ = json.NewEncoder(w).Encode(map[string]any{"access_token": "ya29.test", "expires_in": 3600})
+			return
+		}
+		w.WriteHeader(http.StatusNotFound)
+		_, _ = w.Write([]byte(`{"error":{"status":"NOT_FOUND","message":"Requested entity was not found.","details":[{"errorCode":"UNREGISTERED"}]}}`))
+	}))
+	defer server.Close()
+
+	client, err := NewClient(testCredentials(t, server.URL+"/token"))
+	if err != nil {
+		t.Fatalf("NewClient: %v", err)
+	}
+	client.apiURL = server.URL
+
+	if err := client.Send(context.Background(), "stale", nil); !errors.Is(err, ErrUnregistered) {
+		t.Fatalf("expected ErrUnregistered, got %v", err)
+	}
+}
+
+func TestNewClientFromEnv_NotConfigured(t *testing.T) {
+	t.Setenv("FCM_CREDENTIALS_JSON", "")
+	t.Setenv("FCM_CREDENTIALS_FILE", "")
+	if _, err := NewClientFromEnv(); !errors.Is(err, ErrNotConfigured) {
+		t.Fatalf("expected ErrNotConfigured, got %v", err)
+	}
+}
@@ -0,0 +1,53 @@
+package lang
+
+import (
+	"context"
+	"strings"
+)
+
+const (
+	Spanish = "es"
+	English = "en"
+
+	// Default es el idioma de los usuarios que no han elegido otro
+	Default = Spanish
+)
+
+// supported son los idiomas que entienden el STT y el análisis de comandos
+var supported = map[string]bool{Spanish: true, English: true}
+
+// Normalize convierte "EN", "en-US" o "es_MX" al código de dos letras. Devuelve
+// false si el idioma no está soportado.
+func Normalize(code string) (string, bool) {
+	code = strings.ToLower(strings.TrimSpace(code))
+	if i := strings.IndexAny(code, "-_"); i > 0 {
+		code = code[:i]
+	}
+	return code, supported[code]
+}
+
+// Supported lista los idiomas soportados
+func Supported() []string {
+	return []string{Spanish, English}
+}
+
+type languageKey struct{}
+
+// WithLanguage asocia el idioma del usuario al contexto de la petición
+func WithLanguage(ctx context.Context, code string) context.Context {
+	if normalized, ok := Normalize(code); ok {
+		return context.WithValue(ctx, languageKey{}, normalized)
+	}
+	return ctx
+}
+
+// FromContext devuelve el idioma asociado al contexto o Default si no hay
+func FromContext(ctx context.Context) string {
+	if ctx == nil {
+		return Default
+	}
+	if code, ok := ctx.Value(languageKey{}).(string); ok {
+		return code
+	}
+	return Default
+}
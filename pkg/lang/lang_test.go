@@ -0,0 +1,29 @@
+package lang
+
+import (
+	"context"
+	"testing"
+)
+
+func TestNormalize(t *testing.T) {
+	for in, want := range map[string]string{"EN": "en", " es-MX ": "es", "en_US": "en"} {
+		if got, ok := Normalize(in); !ok || got != want {
+			t.Errorf("Normalize(%q) = %q, %v; want %q", in, got, ok, want)
+		}
+	}
+	if _, ok := Normalize("fr"); ok {
+		t.Error("fr should not be supported")
+	}
+}
+
+func TestLanguageContext(t *testing.T) {
+	if got := FromContext(context.Background()); got != Default {
+		t.Fatalf("expected default language, got %q", got)
+	}
+	if got := FromContext(WithLanguage(context.Background(), "en-GB")); got != English {
+		t.Fatalf("expected en, got %q", got)
+	}
+	if got := FromContext(WithLanguage(context.Background(), "klingon")); got != Default {
+		t.Fatalf("unsupported language must keep the default, got %q", got)
+	}
+}
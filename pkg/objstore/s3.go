@@ -0,0 +1,352 @@
+package objstore
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	defaultRegion   = "us-east-1"
+	signAlgorithm   = "AWS4-HMAC-SHA256"
+	unsignedPayload = "UNSIGNED-PAYLOAD"
+	amzDateFormat   = "20060102T150405Z"
+	// maxPresignTTL es el máximo que admite S3 para una URL prefirmada
+	maxPresignTTL = 7 * 24 * time.Hour
+)
+
+var (
+	// ErrNotConfigured indica que no hay bucket configurado en el entorno
+	ErrNotConfigured = errors.New("objstore: bucket no configurado")
+	// ErrNotFound indica que el objeto no existe
+	ErrNotFound = errors.New("objstore: objeto no encontrado")
+)
+
+// Config describe un bucket S3 o de DigitalOcean Spaces
+type Config struct {
+	Endpoint  string
+	Bucket    string
+	Region    string
+	AccessKey string
+	SecretKey string
+}
+
+// Client guarda objetos en un bucket compatible con S3 firmando con SigV4. Usa
+// direcciones de tipo ruta (endpoint/bucket/clave), que aceptan S3, Spaces y MinIO.
+type Client struct {
+	httpClient *http.Client
+	endpoint   *url.URL
+	bucket     string
+	region     string
+	accessKey  string
+	secretKey  string
+	now        func() time.Time
+}
+
+// NewClientFromEnv lee S3_BUCKET, S3_ENDPOINT, S3_REGION, S3_ACCESS_KEY_ID y
+// S3_SECRET_ACCESS_KEY. Para Spaces el endpoint es https://<region>.digitaloceanspaces.com.
+func NewClientFromEnv() (*Client, error) {
+	cfg := Config{
+		Endpoint:  strings.TrimSpace(os.Getenv("S3_ENDPOINT")),
+		Bucket:    strings.TrimSpace(os.Getenv("S3_BUCKET")),
+		Region:    strings.TrimSpace(os.Getenv("S3_REGION")),
+		AccessKey: strings.TrimSpace(os.Getenv("S3_ACCESS_KEY_ID")),
+		SecretKey: strings.TrimSpace(os.Getenv("S3_SECRET_ACCESS_KEY")),
+	}
+	if cfg.Bucket == "" {
+		return nil, ErrNotConfigured
+	}
+	return NewClient(cfg)
+}
+
+// NewClient valida la configuración; sin endpoint usa el de AWS de la región
+func NewClient(cfg Config) (*Client, error) {
+	if cfg.Bucket == "" {
+		return nil, ErrNotConfigured
+	}
+	if cfg.AccessKey == "" || cfg.SecretKey == "" {
+		return nil, errors.New("objstore: faltan las credenciales de acceso")
+	}
+	if cfg.Region == "" {
+		cfg.Region = defaultRegion
+	}
+	if cfg.Endpoint == "" {
+		cfg.Endpoint = "https://s3." + cfg.Region + ".amazonaws.com"
+	}
+	endpoint, err := url.Parse(strings.TrimRight(cfg.Endpoint, "/"))
+	if err != nil || endpoint.Scheme == "" || endpoint.Host == "" {
+		return nil, fmt.Errorf("objstore: endpoint inválido %q", cfg.Endpoint)
+	}
+	return &Client{
+		httpClient: &http.Client{Timeout: 30 * time.Second},
+		endpoint:   endpoint,
+		bucket:     cfg.Bucket,
+		region:     cfg.Region,
+		accessKey:  cfg.AccessKey,
+		secretKey:  cfg.SecretKey,
+		now:        time.Now,
+	}, nil
+}
+
+// Put sube el objeto con su Content-Type
+func (c *Client) Put(ctx context.Context, key string, data []byte, contentType string) error {
+	req, err := c.newRequest(ctx, http.MethodPut, key, data)
+	if err != nil {
+		return err
+	}
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	resp, err := c.do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
+
+// Get descarga el objeto completo
+func (c *Client) Get(ctx context.Context, key string) ([]byte, error) {
+	req, err := c.newRequest(ctx, http.MethodGet, key, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := c.do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	return io.ReadAll(resp.Body)
+}
+
+// Delete borra el objeto; borrar uno que no existe no es un error
+func (c *Client) Delete(ctx context.Context, key string) error {
+	req, err := c.newRequest(ctx, http.MethodDelete, key, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := c.do(req)
+	if errors.Is(err, ErrNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
+
+// Ping comprueba que el bucket existe y las credenciales valen
+func (c *Client) Ping(ctx context.Context) error {
+	req, err := c.newRequest(ctx, http.MethodHead, "", nil)
+	if err != nil {
+		return err
+	}
+	resp, err := c.do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
+
+// PresignGet devuelve una URL con la que el cliente descarga el objeto directamente
+// del bucket durante ttl
+func (c *Client) PresignGet(key string, ttl time.Duration) (string, error) {
+	if key == "" {
+		return "", errors.New("objstore: clave vacía")
+	}
+	if ttl <= 0 || ttl > maxPresignTTL {
+		return "", fmt.Errorf("objstore: caducidad fuera de rango (%s)", ttl)
+	}
+	u := c.objectURL(key)
+	c.presign(http.MethodGet, u, c.now(), ttl)
+	return u.String(), nil
+}
+
+func (c *Client) objectURL(key string) *url.URL {
+	u := *c.endpoint
+	u.Path = strings.TrimRight(u.Path, "/") + "/" + c.bucket
+	if key != "" {
+		u.Path += "/" + strings.TrimLeft(key, "/")
+	}
+	u.RawPath = ""
+	u.RawQuery = ""
+	return &u
+}
+
+func (c *Client) newRequest(ctx context.Context, method, key string, body []byte) (*http.Request, error) {
+	req, err := http.NewRequestWithContext(ctx, method, c.objectURL(key).String(), bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.ContentLength = int64(len(body))
+	c.sign(req, body, c.now())
+	return req, nil
+}
+
+func (c *Client) do(req *http.Request) (*http.Response, error) {
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("objstore: %s %s: %w", req.Method, req.URL.Path, err)
+	}
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return resp, nil
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, ErrNotFound
+	}
+	detail, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+	return nil, fmt.Errorf("objstore: %s %s respondió %d: %s", req.Method, req.URL.Path, resp.StatusCode, strings.TrimSpace(string(detail)))
+}
+
+// sign añade la cabecera Authorization de SigV4 firmando host, fecha y hash del cuerpo
+func (c *Client) sign(req *http.Request, body []byte, now time.Time) {
+	now = now.UTC()
+	payloadHash := sha256Hex(body)
+	req.Header.Set("X-Amz-Date", now.Format(amzDateFormat))
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+
+	headers := map[string]string{
+		"host":                 req.URL.Host,
+		"x-amz-content-sha256": payloadHash,
+		"x-amz-date":           now.Format(amzDateFormat),
+	}
+	signed, canonicalHeaders := canonicalizeHeaders(headers)
+	canonical := strings.Join([]string{
+		req.Method,
+		canonicalURI(req.URL.Path),
+		canonicalQuery(req.URL.Query()),
+		canonicalHeaders,
+		signed,
+		payloadHash,
+	}, "\n")
+
+	scope := c.scope(now)
+	signature := c.signature(now, scope, canonical)
+	req.Header.Set("Authorization", fmt.Sprintf("%s Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		signAlgorithm, c.accessKey, scope, signed, signature))
+}
+
+// presign añade a u los parámetros de una URL prefirmada con SigV4
+func (c *Client) presign(method string, u *url.URL, now time.Time, ttl time.Duration) {
+	now = now.UTC()
+	scope := c.scope(now)
+	query := u.Query()
+	query.Set("X-Amz-Algorithm", signAlgorithm)
+	query.Set("X-Amz-Credential", c.accessKey+"/"+scope)
+	query.Set("X-Amz-Date", now.Format(amzDateFormat))
+	query.Set("X-Amz-Expires", strconv.Itoa(int(ttl/time.Second)))
+	query.Set("X-Amz-SignedHeaders", "host")
+
+	canonical := strings.Join([]string{
+		method,
+		canonicalURI(u.Path),
+		canonicalQuery(query),
+		"host:" + u.Host + "\n",
+		"host",
+		unsignedPayload,
+	}, "\n")
+
+	u.RawQuery = canonicalQuery(query) + "&X-Amz-Signature=" + c.signature(now, scope, canonical)
+}
+
+func (c *Client) scope(now time.Time) string {
+	return now.Format("20060102") + "/" + c.region + "/s3/aws4_request"
+}
+
+func (c *Client) signature(now time.Time, scope, canonical string) string {
+	stringToSign := strings.Join([]string{
+		signAlgorithm,
+		now.Format(amzDateFormat),
+		scope,
+		sha256Hex([]byte(canonical)),
+	}, "\n")
+
+	key := hmacSHA256([]byte("AWS4"+c.secretKey), now.Format("20060102"))
+	key = hmacSHA256(key, c.region)
+	key = hmacSHA256(key, "s3")
+	key = hmacSHA256(key, "aws4_request")
+	return hex.EncodeToString(hmacSHA256(key, stringToSign))
+}
+
+func canonicalizeHeaders(headers map[string]string) (string, string) {
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var b strings.Builder
+	for _, name := range names {
+		b.WriteString(name + ":" + strings.TrimSpace(headers[name]) + "\n")
+	}
+	return strings.Join(names, ";"), b.String()
+}
+
+// canonicalURI codifica cada segmento de la ruta sin tocar las barras
+func canonicalURI(path string) string {
+	if path == "" {
+		return "/"
+	}
+	segments := strings.Split(path, "/")
+	for i, s := range segments {
+		segments[i] = uriEncode(s)
+	}
+	return strings.Join(segments, "/")
+}
+
+func canonicalQuery(query url.Values) string {
+	keys := make([]string, 0, len(query))
+	for k := range query {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	parts := make([]string, 0, len(keys))
+	for _, k := range keys {
+		values := append([]string(nil), query[k]...)
+		sort.Strings(values)
+		for _, v := range values {
+			parts = append(parts, uriEncode(k)+"="+uriEncode(v))
+		}
+	}
+	return strings.Join(parts, "&")
+}
+
+// uriEncode codifica todo salvo los caracteres no reservados de RFC 3986, como exige SigV4
+func uriEncode(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		ch := s[i]
+		if ('A' <= ch && ch <= 'Z') || ('a' <= ch && ch <= 'z') || ('0' <= ch && ch <= '9') ||
+			ch == '-' || ch == '_' || ch == '.' || ch == '~' {
+			b.WriteByte(ch)
+			continue
+		}
+		fmt.Fprintf(&b, "%%%02X", ch)
+	}
+	return b.String()
+}
+
+func sha256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
@@ -0,0 +1,386 @@
+// Package openapi arma un documento OpenAPI 3 a partir de una lista de operaciones,
+// generando los esquemas por reflexión desde los tipos Go con sus etiquetas json.
+package openapi
+
+import (
+	"net/http"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const Version = "3.0.3"
+
+// Info es la cabecera del documento
+type Info struct {
+	Title       string
+	Version     string
+	Description string
+}
+
+// Operation describe un método de una ruta
+type Operation struct {
+	Method      string
+	Path        string
+	Summary     string
+	Description string
+	Tag         string
+	// Security son los nombres de los esquemas de seguridad que acepta, cualquiera vale
+	Security []string
+	Params   []Param
+	// Request es un reflect.Type, un Fields, un Array, un Raw o nil; RequestContent son sus tipos MIME
+	// (application/json si va vacío)
+	Request        any
+	RequestContent []string
+	Responses      []Response
+	Deprecated     bool
+}
+
+// Param es un parámetro de ruta, query o cabecera; sin Type es un string
+type Param struct {
+	Name        string
+	In          string
+	Description string
+	Required    bool
+	Type        reflect.Type
+}
+
+// Response es una respuesta posible; Body es como Operation.Request
+type Response struct {
+	Status      int
+	Description string
+	Body        any
+	// Content es el tipo MIME del cuerpo, application/json si va vacío
+	Content string
+	Headers []Header
+}
+
+// Header es una cabecera de respuesta
+type Header struct {
+	Name        string
+	Description string
+}
+
+// Fields describe un objeto JSON sin tipo Go propio: cada campo es un reflect.Type o
+// un Fields o un Array anidado
+type Fields map[string]any
+
+// Array es una lista JSON de Items (un reflect.Type, un Fields o un Array)
+type Array struct {
+	Items any
+}
+
+// Raw es un cuerpo sin esquema: binario si el tipo MIME no es JSON, objeto libre si lo es
+type Raw struct{}
+
+// Builder acumula las operaciones y los esquemas de componentes
+type Builder struct {
+	info      Info
+	security  map[string]any
+	errorBody any
+	paths     map[string]map[string]any
+	schemas   map[string]any
+	names     map[reflect.Type]string
+}
+
+// NewBuilder crea el documento; errorBody es el cuerpo de las respuestas de error
+// comunes, que se añade como respuesta "default" a cada operación
+func NewBuilder(info Info, errorBody any) *Builder {
+	return &Builder{
+		info:      info,
+		security:  make(map[string]any),
+		errorBody: errorBody,
+		paths:     make(map[string]map[string]any),
+		schemas:   make(map[string]any),
+		names:     make(map[reflect.Type]string),
+	}
+}
+
+// APIKey registra un esquema de seguridad por cabecera
+func (b *Builder) APIKey(name, header, description string) {
+	b.security[name] = map[string]any{"type": "apiKey", "in": "header", "name": header, "description": description}
+}
+
+// Bearer registra un esquema Authorization: Bearer
+func (b *Builder) Bearer(name, format, description string) {
+	b.security[name] = map[string]any{"type": "http", "scheme": "bearer", "bearerFormat": format, "description": description}
+}
+
+var pathParam = regexp.MustCompile(`\{([^}]+)\}`)
+
+// Add incorpora una operación; los parámetros de ruta se deducen de los {nombre} del
+// path salvo que op.Params ya los declare
+func (b *Builder) Add(op Operation) {
+	item, ok := b.paths[op.Path]
+	if !ok {
+		item = make(map[string]any)
+		b.paths[op.Path] = item
+	}
+
+	out := map[string]any{"summary": op.Summary}
+	if op.Description != "" {
+		out["description"] = op.Description
+	}
+	if op.Tag != "" {
+		out["tags"] = []string{op.Tag}
+	}
+	if op.Deprecated {
+		out["deprecated"] = true
+	}
+	out["operationId"] = operationID(op.Method, op.Path)
+	if len(op.Security) > 0 {
+		security := make([]map[string][]string, 0, len(op.Security))
+		for _, name := range op.Security {
+			security = append(security, map[string][]string{name: {}})
+		}
+		out["security"] = security
+	}
+
+	declared := make(map[string]bool)
+	params := make([]map[string]any, 0, len(op.Params))
+	for _, p := range op.Params {
+		if p.In == "path" {
+			declared[p.Name] = true
+		}
+		params = append(params, b.param(p))
+	}
+	for _, m := range pathParam.FindAllStringSubmatch(op.Path, -1) {
+		if !declared[m[1]] {
+			params = append(params, b.param(Param{Name: m[1], In: "path", Required: true}))
+		}
+	}
+	if len(params) > 0 {
+		out["parameters"] = params
+	}
+
+	if op.Request != nil {
+		types := op.RequestContent
+		if len(types) == 0 {
+			types = []string{"application/json"}
+		}
+		content := make(map[string]any, len(types))
+		for _, t := range types {
+			content[t] = map[string]any{"schema": b.schemaFor(op.Request, t)}
+		}
+		out["requestBody"] = map[string]any{"required": true, "content": content}
+	}
+
+	responses := make(map[string]any, len(op.Responses)+1)
+	for _, r := range op.Responses {
+		responses[strconv.Itoa(r.Status)] = b.response(r)
+	}
+	if b.errorBody != nil {
+		responses["default"] = b.response(Response{Description: "Error", Body: b.errorBody})
+	}
+	out["responses"] = responses
+
+	item[strings.ToLower(op.Method)] = out
+}
+
+func (b *Builder) param(p Param) map[string]any {
+	schema := map[string]any{"type": "string"}
+	if p.Type != nil {
+		schema = b.schema(p.Type)
+	}
+	out := map[string]any{"name": p.Name, "in": p.In, "schema": schema}
+	if p.Required || p.In == "path" {
+		out["required"] = true
+	}
+	if p.Description != "" {
+		out["description"] = p.Description
+	}
+	return out
+}
+
+func (b *Builder) response(r Response) map[string]any {
+	description := r.Description
+	if description == "" {
+		description = http.StatusText(r.Status)
+	}
+	out := map[string]any{"description": description}
+	if r.Body != nil {
+		content := r.Content
+		if content == "" {
+			content = "application/json"
+		}
+		out["content"] = map[string]any{content: map[string]any{"schema": b.schemaFor(r.Body, content)}}
+	}
+	if len(r.Headers) > 0 {
+		headers := make(map[string]any, len(r.Headers))
+		for _, h := range r.Headers {
+			headers[h.Name] = map[string]any{"description": h.Description, "schema": map[string]any{"type": "string"}}
+		}
+		out["headers"] = headers
+	}
+	return out
+}
+
+// schemaFor convierte un cuerpo; los que no son JSON (audio, CSV) son binarios
+func (b *Builder) schemaFor(body any, content string) map[string]any {
+	switch v := body.(type) {
+	case reflect.Type:
+		return b.schema(v)
+	case Fields:
+		return b.fields(v)
+	case Array:
+		return map[string]any{"type": "array", "items": b.schemaFor(v.Items, "application/json")}
+	}
+	if strings.Contains(content, "json") {
+		return map[string]any{"type": "object"}
+	}
+	return map[string]any{"type": "string", "format": "binary"}
+}
+
+func (b *Builder) fields(f Fields) map[string]any {
+	props := make(map[string]any, len(f))
+	for name, v := range f {
+		props[name] = b.schemaFor(v, "application/json")
+	}
+	return map[string]any{"type": "object", "properties": props}
+}
+
+var timeType = reflect.TypeFor[time.Time]()
+
+// schema genera el esquema de t; los structs con nombre van a components/schemas
+func (b *Builder) schema(t reflect.Type) map[string]any {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	switch {
+	case t == timeType:
+		return map[string]any{"type": "string", "format": "date-time"}
+	case t.Kind() == reflect.Slice && t.Elem().Kind() == reflect.Uint8:
+		return map[string]any{"type": "string", "format": "byte"}
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return map[string]any{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return map[string]any{"type": "integer"}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]any{"type": "integer", "minimum": 0}
+	case reflect.Float32, reflect.Float64:
+		return map[string]any{"type": "number"}
+	case reflect.String:
+		return map[string]any{"type": "string"}
+	case reflect.Slice, reflect.Array:
+		return map[string]any{"type": "array", "items": b.schema(t.Elem())}
+	case reflect.Map:
+		return map[string]any{"type": "object", "additionalProperties": b.schema(t.Elem())}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return b.object(t)
+		}
+		return map[string]any{"$ref": "#/components/schemas/" + b.component(t)}
+	}
+	return map[string]any{}
+}
+
+// component registra el struct con su nombre; si otro paquete usa el mismo nombre se
+// antepone el del paquete
+func (b *Builder) component(t reflect.Type) string {
+	if name, ok := b.names[t]; ok {
+		return name
+	}
+	name := exportedName(t.Name())
+	if _, taken := b.schemas[name]; taken {
+		pkg := t.PkgPath()
+		name = exportedName(pkg[strings.LastIndex(pkg, "/")+1:]) + name
+	}
+	b.names[t] = name
+	b.schemas[name] = map[string]any{} // reserva el nombre antes de recorrer campos recursivos
+	b.schemas[name] = b.object(t)
+	return name
+}
+
+func (b *Builder) object(t reflect.Type) map[string]any {
+	props := make(map[string]any)
+	var required []string
+	b.collectFields(t, props, &required)
+	out := map[string]any{"type": "object", "properties": props}
+	if len(required) > 0 {
+		sort.Strings(required)
+		out["required"] = required
+	}
+	return out
+}
+
+// collectFields sigue las reglas de encoding/json: los structs embebidos sin etiqueta
+// aportan sus campos, omitempty y los punteros marcan campos opcionales
+func (b *Builder) collectFields(t reflect.Type, props map[string]any, required *[]string) {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag := f.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, opts, _ := strings.Cut(tag, ",")
+		ft := f.Type
+		if f.Anonymous && name == "" {
+			for ft.Kind() == reflect.Pointer {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct {
+				b.collectFields(ft, props, required)
+				continue
+			}
+		}
+		if !f.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		props[name] = b.schema(ft)
+		if !strings.Contains(opts, "omitempty") && ft.Kind() != reflect.Pointer {
+			*required = append(*required, name)
+		}
+	}
+}
+
+// Document devuelve el documento listo para serializar
+func (b *Builder) Document() map[string]any {
+	info := map[string]any{"title": b.info.Title, "version": b.info.Version}
+	if b.info.Description != "" {
+		info["description"] = b.info.Description
+	}
+	doc := map[string]any{
+		"openapi": Version,
+		"info":    info,
+		"paths":   b.paths,
+	}
+	components := map[string]any{}
+	if len(b.schemas) > 0 {
+		components["schemas"] = b.schemas
+	}
+	if len(b.security) > 0 {
+		components["securitySchemes"] = b.security
+	}
+	if len(components) > 0 {
+		doc["components"] = components
+	}
+	return doc
+}
+
+func exportedName(name string) string {
+	if name == "" {
+		return name
+	}
+	return strings.ToUpper(name[:1]) + name[1:]
+}
+
+// operationID es "<método><Ruta>" en camelCase, p. ej. getV1ChannelsCodeAlias
+func operationID(method, path string) string {
+	var sb strings.Builder
+	sb.WriteString(strings.ToLower(method))
+	for _, part := range strings.FieldsFunc(path, func(r rune) bool {
+		return r == '/' || r == '{' || r == '}' || r == '-' || r == '.'
+	}) {
+		sb.WriteString(exportedName(part))
+	}
+	return sb.String()
+}
@@ -0,0 +1,56 @@
+package openapi
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+type tokens struct {
+	Access string `json:"access_token"`
+}
+
+type login struct {
+	Name    string     `json:"name"`
+	Device  string     `json:"device,omitempty"`
+	Seen    *time.Time `json:"seen"`
+	Audio   []byte     `json:"audio"`
+	private string
+	*tokens
+}
+
+func TestSchemaFollowsJSONTags(t *testing.T) {
+	b := NewBuilder(Info{Title: "t", Version: "1"}, nil)
+	b.Add(Operation{Method: "POST", Path: "/v1/users/{id}", Request: reflect.TypeFor[login](), Responses: []Response{{Status: 204}}})
+
+	doc := b.Document()
+	schemas := doc["components"].(map[string]any)["schemas"].(map[string]any)
+	s := schemas["Login"].(map[string]any)
+	props := s["properties"].(map[string]any)
+	for _, name := range []string{"name", "device", "seen", "audio", "access_token"} {
+		if _, ok := props[name]; !ok {
+			t.Errorf("missing property %q in %v", name, props)
+		}
+	}
+	if _, ok := props["private"]; ok {
+		t.Error("unexported fields must be skipped")
+	}
+	if got := props["seen"].(map[string]any)["format"]; got != "date-time" {
+		t.Errorf("time.Time should be date-time, got %v", got)
+	}
+	if got := props["audio"].(map[string]any)["format"]; got != "byte" {
+		t.Errorf("[]byte should be base64, got %v", got)
+	}
+	if got := s["required"].([]string); !reflect.DeepEqual(got, []string{"access_token", "audio", "name"}) {
+		t.Errorf("unexpected required fields %v", got)
+	}
+
+	op := doc["paths"].(map[string]map[string]any)["/v1/users/{id}"]["post"].(map[string]any)
+	if op["operationId"] != "postV1UsersId" {
+		t.Errorf("unexpected operationId %v", op["operationId"])
+	}
+	params := op["parameters"].([]map[string]any)
+	if len(params) != 1 || params[0]["name"] != "id" || params[0]["in"] != "path" {
+		t.Errorf("expected the path parameter to be inferred, got %v", params)
+	}
+}
@@ -225,6 +225,38 @@ func TestAnalyzeTranscript_InvalidIntent(t *testing.T) {
 	}
 }
 
+func TestAnalyzeTranscript_CurrentChannelIntentIsValid(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		resp := chatResponse{
+			Choices: []choice{
+				{
+					Message: message{
+						Role:    "assistant",
+						Content: `{"is_command":true,"intent":"request_current_channel","reply":"","channels":[],"state":"canal-2"}`,
+					},
+				},
+			},
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(resp)
+	}))
+	t.Cleanup(server.Close)
+
+	client := &Client{
+		httpClient: server.Client(),
+		baseURL:    server.URL,
+		model:      "test-model",
+	}
+
+	result, err := client.AnalyzeTranscript(context.Background(), "en qué canal estoy", nil, "canal-2", "")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !result.IsCommand || result.Intent != "request_current_channel" {
+		t.Errorf("expected request_current_channel to be kept, got %+v", result)
+	}
+}
+
 func TestExtractJSONFromResponse(t *testing.T) {
 	tests := []struct {
 		name     string
@@ -247,12 +279,13 @@ func TestExtractJSONFromResponse(t *testing.T) {
 }
 
 func TestBuildAnalysisPrompt(t *testing.T) {
-	prompt := buildAnalysisPrompt("hola", []string{"canal-1", "canal-2"}, "sin_canal", "canal-3")
+	prompt := buildAnalysisPrompt("hola", []string{"canal-1", "canal-2"}, "sin_canal", "canal-3", "es", nil, SessionContext{})
 
 	assert.Contains(t, prompt, "<user_input>\nhola\n</user_input>", "prompt missing transcript in correct tag")
 	assert.Contains(t, prompt, "<available_channels>canal-1, canal-2</available_channels>", "prompt missing channels in correct tag")
 	assert.Contains(t, prompt, "<state>sin_canal</state>", "prompt missing state in correct tag")
 	assert.Contains(t, prompt, "<pending_channel>canal-3</pending_channel>", "prompt missing pending channel in correct tag")
+	assert.Contains(t, prompt, "<language>es</language>", "prompt missing language in correct tag")
 }
 
 func TestAnalyzeTranscript_Timeout(t *testing.T) {
@@ -284,6 +317,7 @@ func TestDetectCommandFallback(t *testing.T) {
 		availableChannels []string
 		expectedIntent    string
 		expectedChannel   string
+		expectedRecipient string
 		expectedOK        bool
 	}{
 		{
@@ -292,6 +326,48 @@ func TestDetectCommandFallback(t *testing.T) {
 			expectedIntent: "request_channel_list",
 			expectedOK:     true,
 		},
+		{
+			name:           "current channel",
+			transcript:     "¿En qué canal estoy?",
+			expectedIntent: "request_current_channel",
+			expectedOK:     true,
+		},
+		{
+			name:           "list users",
+			transcript:     "¿Quién está en el canal?",
+			expectedIntent: "request_user_list",
+			expectedOK:     true,
+		},
+		{
+			name:           "nearby users",
+			transcript:     "¿Quién está cerca?",
+			expectedIntent: "request_nearby_users",
+			expectedOK:     true,
+		},
+		{
+			name:           "favorite channel",
+			transcript:     "Vuelve a mi canal favorito",
+			expectedIntent: "request_favorite_channel",
+			expectedOK:     true,
+		},
+		{
+			name:           "do not disturb on",
+			transcript:     "No me molestes",
+			expectedIntent: "request_dnd_on",
+			expectedOK:     true,
+		},
+		{
+			name:           "do not disturb off",
+			transcript:     "quita el no molestar",
+			expectedIntent: "request_dnd_off",
+			expectedOK:     true,
+		},
+		{
+			name:           "list users of channel",
+			transcript:     "dame la lista de usuarios del canal",
+			expectedIntent: "request_user_list",
+			expectedOK:     true,
+		},
 		{
 			name:           "disconnect",
 			transcript:     "desconéctame del canal",
@@ -314,6 +390,14 @@ func TestDetectCommandFallback(t *testing.T) {
 			expectedChannel:   "canal-2",
 			expectedOK:        true,
 		},
+		{
+			name:              "connect with compound word number",
+			transcript:        "conéctame al canal veintidós",
+			availableChannels: []string{"canal-2", "canal-22"},
+			expectedIntent:    "request_channel_connect",
+			expectedChannel:   "canal-22",
+			expectedOK:        true,
+		},
 		{
 			name:              "connect to unavailable channel",
 			transcript:        "conéctame al canal 99",
@@ -330,6 +414,49 @@ func TestDetectCommandFallback(t *testing.T) {
 			transcript: "conéctame a un canal",
 			expectedOK: false, // No channel number extracted
 		},
+		{
+			name:              "direct message",
+			transcript:        "Mándaselo a Juan",
+			expectedIntent:    "request_direct_message",
+			expectedRecipient: "juan",
+			expectedOK:        true,
+		},
+		{
+			name:              "mute user",
+			transcript:        "Silencia a Juan",
+			expectedIntent:    "request_user_mute",
+			expectedRecipient: "juan",
+			expectedOK:        true,
+		},
+		{
+			name:           "channel summary",
+			transcript:     "Dame un resumen del canal",
+			expectedIntent: "request_channel_summary",
+			expectedOK:     true,
+		},
+		{
+			name:           "what did I miss",
+			transcript:     "¿Qué me perdí?",
+			expectedIntent: "request_channel_summary",
+			expectedOK:     true,
+		},
+		{
+			name:           "start recording",
+			transcript:     "Graba el canal",
+			expectedIntent: "request_recording_start",
+			expectedOK:     true,
+		},
+		{
+			name:           "stop recording",
+			transcript:     "Deja de grabar",
+			expectedIntent: "request_recording_stop",
+			expectedOK:     true,
+		},
+		{
+			name:       "send to everyone is not direct",
+			transcript: "mándalo a todos",
+			expectedOK: false,
+		},
 	}
 
 	for _, tt := range tests {
@@ -345,7 +472,40 @@ func TestDetectCommandFallback(t *testing.T) {
 					assert.Len(t, result.Channels, 1)
 					assert.Equal(t, tt.expectedChannel, result.Channels[0])
 				}
+				assert.Equal(t, tt.expectedRecipient, result.Recipient)
 			}
 		})
 	}
 }
+
+func TestAnalyzeTranscript_ConfidenceIsClamped(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		resp := chatResponse{
+			Choices: []choice{
+				{
+					Message: message{
+						Role:    "assistant",
+						Content: `{"is_command":true,"intent":"request_channel_connect","reply":"","channels":["canal-2"],"state":"sin_canal","confidence":1.7}`,
+					},
+				},
+			},
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(resp)
+	}))
+	t.Cleanup(server.Close)
+
+	client := &Client{
+		httpClient: server.Client(),
+		baseURL:    server.URL,
+		model:      "test-model",
+	}
+
+	result, err := client.AnalyzeTranscript(context.Background(), "ponme en el dos creo", []string{"canal-2"}, "sin_canal", "")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.Confidence != 1 {
+		t.Errorf("expected confidence clamped to 1, got %v", result.Confidence)
+	}
+}
@@ -0,0 +1,45 @@
+package qwen
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestIsAbusive_ParsesAnswer(t *testing.T) {
+	cases := map[string]struct {
+		content string
+		want    bool
+		wantErr bool
+	}{
+		"si":       {content: "<think>insulto directo</think>\nSí.", want: true},
+		"no":       {content: "no", want: false},
+		"ingles":   {content: "Yes", want: true},
+		"invalido": {content: "quizá", wantErr: true},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			var got chatRequest
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				_ = json.NewDecoder(r.Body).Decode(&got)
+				_ = json.NewEncoder(w).Encode(chatResponse{Choices: []choice{{Message: message{Role: "assistant", Content: tc.content}}}})
+			}))
+			t.Cleanup(server.Close)
+
+			client := &Client{httpClient: server.Client(), baseURL: server.URL, model: "test-model"}
+			abusive, err := client.IsAbusive(context.Background(), "eres un inútil")
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("unexpected error %v", err)
+			}
+			if abusive != tc.want {
+				t.Errorf("expected %v, got %v", tc.want, abusive)
+			}
+			if len(got.Messages) != 2 || got.Messages[0].Content != abusePrompt || !strings.Contains(got.Messages[1].Content, "eres un inútil") {
+				t.Errorf("unexpected request %+v", got.Messages)
+			}
+		})
+	}
+}
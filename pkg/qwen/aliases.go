@@ -0,0 +1,170 @@
+package qwen
+
+import (
+	"context"
+	"regexp"
+	"sort"
+	"strings"
+
+	"walkie-backend/pkg/lang"
+)
+
+// maxAliasWords limita el nombre que se puede dar a un canal por voz
+const maxAliasWords = 4
+
+type aliasesKey struct{}
+
+var (
+	// aliasRegex captura el nombre en frases como "llamalo obra norte" o "ponle de nombre bodega"
+	aliasRegex = regexp.MustCompile(`\b(?:llamalo|llamale|llamemoslo|nombralo|bautizalo|ponle de nombre|ponle por nombre)\s+(.+)$`)
+	// englishAliasRegex hace lo mismo con "call it north site" o "name this channel dock"
+	englishAliasRegex = regexp.MustCompile(`\b(?:call it|name it|call this channel|name this channel|rename it to|rename this channel to)\s+(.+)$`)
+)
+
+// WithChannelAliases guarda en el contexto los alias del usuario (alias normalizado -> código
+// de canal) para que el análisis los incluya en el prompt y en la extracción del canal
+func WithChannelAliases(ctx context.Context, aliases map[string]string) context.Context {
+	if len(aliases) == 0 {
+		return ctx
+	}
+	return context.WithValue(ctx, aliasesKey{}, aliases)
+}
+
+func channelAliasesFrom(ctx context.Context) map[string]string {
+	aliases, _ := ctx.Value(aliasesKey{}).(map[string]string)
+	return aliases
+}
+
+// NormalizeAlias deja el alias como lo compara el detector
+func NormalizeAlias(alias string) string {
+	return normalizeTranscript(alias)
+}
+
+// aliasesKeyPart serializa los alias en orden estable para la clave de caché
+func aliasesKeyPart(aliases map[string]string) string {
+	names := sortedAliases(aliases)
+	var sb strings.Builder
+	for _, name := range names {
+		sb.WriteString(name)
+		sb.WriteString("=")
+		sb.WriteString(aliases[name])
+		sb.WriteString(";")
+	}
+	return sb.String()
+}
+
+// sortedAliases devuelve los alias del más largo al más corto para que gane el más específico
+func sortedAliases(aliases map[string]string) []string {
+	names := make([]string, 0, len(aliases))
+	for name := range aliases {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		if len(names[i]) != len(names[j]) {
+			return len(names[i]) > len(names[j])
+		}
+		return names[i] < names[j]
+	})
+	return names
+}
+
+// matchChannelAlias busca en el texto normalizado algún alias del usuario
+func matchChannelAlias(text string, aliases map[string]string, channels []string) (string, bool) {
+	padded := " " + text + " "
+	for _, name := range sortedAliases(aliases) {
+		if strings.Contains(padded, " "+name+" ") {
+			return validateChannel(aliases[name], channels)
+		}
+	}
+	return "", false
+}
+
+// channelAliasesPrompt describe al modelo los nombres que el usuario dio a los canales
+func channelAliasesPrompt(aliases map[string]string) string {
+	if len(aliases) == 0 {
+		return ""
+	}
+	var sb strings.Builder
+	sb.WriteString("    <channel_aliases>\n")
+	for _, name := range sortedAliases(aliases) {
+		sb.WriteString("        ")
+		sb.WriteString(name)
+		sb.WriteString(" = ")
+		sb.WriteString(aliases[name])
+		sb.WriteString("\n")
+	}
+	sb.WriteString("    </channel_aliases>\n")
+	return sb.String()
+}
+
+// extractAliasName devuelve el nombre que el usuario quiere dar al canal
+func extractAliasName(text, language string) (string, bool) {
+	re := aliasRegex
+	if language == lang.English {
+		re = englishAliasRegex
+	}
+	match := re.FindStringSubmatch(text)
+	if match == nil {
+		return "", false
+	}
+	words := strings.Fields(match[1])
+	if len(words) == 0 || len(words) > maxAliasWords {
+		return "", false
+	}
+	return strings.Join(words, " "), true
+}
+
+// detectWithAliases añade a la heurística local los comandos que dependen de los alias:
+// nombrar un canal ("llamalo obra norte") y conectarse por nombre ("conectame a obra norte")
+func detectWithAliases(transcript string, channels []string, currentState, language string, aliases map[string]string) (CommandResult, bool) {
+	normalized := normalizeTranscript(transcript)
+	if alias, ok := extractAliasName(normalized, language); ok {
+		result := CommandResult{IsCommand: true, Intent: "request_channel_alias", State: currentState, Alias: alias}
+		if channel, ok := extractChannelFor(strings.TrimSuffix(normalized, alias), channels, language); ok {
+			result.Channels = []string{channel}
+		}
+		return result, true
+	}
+
+	if result, ok := detectCommandForLanguage(transcript, channels, currentState, language); ok {
+		return result, true
+	}
+
+	connect := isConnect(normalized)
+	if language == lang.English {
+		connect = isEnglishConnect(normalized)
+	}
+	if connect {
+		if channel, ok := matchChannelAlias(normalized, aliases, channels); ok {
+			return CommandResult{IsCommand: true, Intent: "request_channel_connect", State: currentState, Channels: []string{channel}}, true
+		}
+	}
+	if isConnectWithoutNumber(normalized, language) {
+		return CommandResult{IsCommand: true, Intent: "request_channel_connect", State: currentState}, true
+	}
+	return CommandResult{}, false
+}
+
+// extractChannelFor busca el número de canal con las palabras del idioma
+func extractChannelFor(text string, channels []string, language string) (string, bool) {
+	if language == lang.English {
+		return extractChannelWith(text, channels, englishNumberMap)
+	}
+	return extractChannelWith(text, channels, wordNumberMap)
+}
+
+// resolveAliasChannels sustituye por su código los alias que el modelo devuelva como canal
+func resolveAliasChannels(result CommandResult, aliases map[string]string) CommandResult {
+	if len(aliases) == 0 || len(result.Channels) == 0 {
+		return result
+	}
+	resolved := make([]string, len(result.Channels))
+	for i, ch := range result.Channels {
+		if code, ok := aliases[NormalizeAlias(ch)]; ok {
+			ch = code
+		}
+		resolved[i] = ch
+	}
+	result.Channels = resolved
+	return result
+}
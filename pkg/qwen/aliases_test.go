@@ -0,0 +1,56 @@
+package qwen
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDetectWithAliases(t *testing.T) {
+	channels := []string{"canal-1", "canal-2", "canal-3"}
+	aliases := map[string]string{"obra norte": "canal-3", "obra": "canal-1"}
+
+	result, ok := detectWithAliases("Llámalo Obra Norte", channels, "canal-2", "es", aliases)
+	assert.True(t, ok)
+	assert.Equal(t, "request_channel_alias", result.Intent)
+	assert.Equal(t, "obra norte", result.Alias)
+	assert.Empty(t, result.Channels)
+
+	result, ok = detectWithAliases("conéctame a obra norte", channels, "sin_canal", "es", aliases)
+	assert.True(t, ok)
+	assert.Equal(t, "request_channel_connect", result.Intent)
+	assert.Equal(t, []string{"canal-3"}, result.Channels)
+
+	result, ok = detectWithAliases("join north site", channels, "sin_canal", "en", map[string]string{"north site": "canal-2"})
+	assert.True(t, ok)
+	assert.Equal(t, []string{"canal-2"}, result.Channels)
+
+	result, ok = detectWithAliases("name this channel the dock", channels, "canal-1", "en", nil)
+	assert.True(t, ok)
+	assert.Equal(t, "the dock", result.Alias)
+
+	// Los números siguen teniendo prioridad y sin verbo de conexión no hay comando
+	result, ok = detectWithAliases("conéctame al canal 2", channels, "sin_canal", "es", aliases)
+	assert.True(t, ok)
+	assert.Equal(t, []string{"canal-2"}, result.Channels)
+	_, ok = detectWithAliases("vamos a la obra norte", channels, "canal-1", "es", aliases)
+	assert.False(t, ok)
+	_, ok = detectWithAliases("llámalo cuando puedas por favor ya", channels, "canal-1", "es", aliases)
+	assert.False(t, ok)
+}
+
+func TestResolveAliasChannelsAndPrompt(t *testing.T) {
+	aliases := map[string]string{"obra norte": "canal-3"}
+
+	result := resolveAliasChannels(CommandResult{Intent: "request_channel_connect", Channels: []string{"Obra Norte"}}, aliases)
+	assert.Equal(t, []string{"canal-3"}, result.Channels)
+
+	prompt := buildAnalysisPrompt("conéctame a obra norte", []string{"canal-3"}, "sin_canal", "", "es", aliases, SessionContext{})
+	assert.Contains(t, prompt, "<channel_aliases>")
+	assert.Contains(t, prompt, "obra norte = canal-3")
+
+	ctx := WithChannelAliases(context.Background(), aliases)
+	assert.Equal(t, aliases, channelAliasesFrom(ctx))
+	assert.Nil(t, channelAliasesFrom(WithChannelAliases(context.Background(), nil)))
+}
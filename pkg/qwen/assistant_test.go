@@ -0,0 +1,37 @@
+package qwen
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestAnswer_SendsQuestionWithContext(t *testing.T) {
+	var got chatRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewDecoder(r.Body).Decode(&got)
+		_ = json.NewEncoder(w).Encode(chatResponse{Choices: []choice{{Message: message{
+			Role:    "assistant",
+			Content: "<think>mirando</think> El camión ya está en el almacén.",
+		}}}})
+	}))
+	t.Cleanup(server.Close)
+
+	client := &Client{httpClient: server.Client(), baseURL: server.URL, model: "test-model"}
+	answer, err := client.Answer(context.Background(), "¿dónde está el camión?", []string{"[10:00] ana: el camión llegó al almacén"})
+	if err != nil {
+		t.Fatalf("Answer returned error: %v", err)
+	}
+	if answer != "El camión ya está en el almacén." {
+		t.Errorf("unexpected answer %q", answer)
+	}
+	if len(got.Messages) != 2 || got.Messages[0].Content != assistantPrompt {
+		t.Fatalf("unexpected request %+v", got.Messages)
+	}
+	if !strings.Contains(got.Messages[1].Content, "ana: el camión") || !strings.Contains(got.Messages[1].Content, "<question>\n¿dónde está el camión?") {
+		t.Errorf("unexpected user message %q", got.Messages[1].Content)
+	}
+}
@@ -0,0 +1,124 @@
+package qwen
+
+import (
+	"container/list"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"walkie-backend/internal/metrics"
+)
+
+const (
+	defaultCacheMaxEntries = 1000
+	defaultCacheTTL        = 10 * time.Minute
+)
+
+// analysisCache guarda los análisis recientes; se configura con AI_CACHE_MAX_ENTRIES y AI_CACHE_TTL
+var analysisCache = newAnalysisCache(cacheMaxEntriesFromEnv(), cacheTTLFromEnv())
+
+type cacheEntry struct {
+	key       string
+	result    CommandResult
+	expiresAt time.Time
+}
+
+// lruCache es una caché acotada: al llenarse descarta la entrada usada hace más tiempo y
+// las entradas caducan tras ttl aunque sigan usándose
+type lruCache struct {
+	mu         sync.Mutex
+	maxEntries int
+	ttl        time.Duration
+	order      *list.List
+	items      map[string]*list.Element
+	now        func() time.Time
+}
+
+func newAnalysisCache(maxEntries int, ttl time.Duration) *lruCache {
+	if maxEntries <= 0 {
+		maxEntries = defaultCacheMaxEntries
+	}
+	if ttl <= 0 {
+		ttl = defaultCacheTTL
+	}
+	return &lruCache{
+		maxEntries: maxEntries,
+		ttl:        ttl,
+		order:      list.New(),
+		items:      make(map[string]*list.Element),
+		now:        time.Now,
+	}
+}
+
+// Get devuelve el resultado guardado si existe y no ha caducado
+func (c *lruCache) Get(key string) (CommandResult, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	elem, ok := c.items[key]
+	if !ok {
+		metrics.Inc("walkie_ai_cache_misses_total", nil)
+		return CommandResult{}, false
+	}
+	entry := elem.Value.(*cacheEntry)
+	if c.now().After(entry.expiresAt) {
+		c.remove(elem, "ttl")
+		metrics.Inc("walkie_ai_cache_misses_total", nil)
+		return CommandResult{}, false
+	}
+	c.order.MoveToFront(elem)
+	metrics.Inc("walkie_ai_cache_hits_total", nil)
+	return entry.result, true
+}
+
+// Add guarda un resultado y descarta el menos usado si se supera el máximo
+func (c *lruCache) Add(key string, result CommandResult) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	expiresAt := c.now().Add(c.ttl)
+	if elem, ok := c.items[key]; ok {
+		entry := elem.Value.(*cacheEntry)
+		entry.result = result
+		entry.expiresAt = expiresAt
+		c.order.MoveToFront(elem)
+		return
+	}
+	c.items[key] = c.order.PushFront(&cacheEntry{key: key, result: result, expiresAt: expiresAt})
+	for c.order.Len() > c.maxEntries {
+		c.remove(c.order.Back(), "size")
+	}
+	metrics.SetGauge("walkie_ai_cache_entries", nil, float64(c.order.Len()))
+}
+
+// Len devuelve cuántas entradas hay guardadas, caducadas incluidas
+func (c *lruCache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.order.Len()
+}
+
+func (c *lruCache) remove(elem *list.Element, reason string) {
+	c.order.Remove(elem)
+	delete(c.items, elem.Value.(*cacheEntry).key)
+	metrics.Inc("walkie_ai_cache_evictions_total", map[string]string{"reason": reason})
+	metrics.SetGauge("walkie_ai_cache_entries", nil, float64(c.order.Len()))
+}
+
+func cacheMaxEntriesFromEnv() int {
+	n, err := strconv.Atoi(strings.TrimSpace(os.Getenv("AI_CACHE_MAX_ENTRIES")))
+	if err != nil || n <= 0 {
+		return defaultCacheMaxEntries
+	}
+	return n
+}
+
+func cacheTTLFromEnv() time.Duration {
+	d, err := time.ParseDuration(strings.TrimSpace(os.Getenv("AI_CACHE_TTL")))
+	if err != nil || d <= 0 {
+		return defaultCacheTTL
+	}
+	return d
+}
@@ -0,0 +1,60 @@
+package qwen
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"walkie-backend/internal/metrics"
+)
+
+func TestAnalysisCache_EvictsLeastRecentlyUsed(t *testing.T) {
+	cache := newAnalysisCache(2, time.Minute)
+	evicted := metrics.Default().Counter("walkie_ai_cache_evictions_total", map[string]string{"reason": "size"})
+
+	cache.Add("a", CommandResult{Intent: "request_channel_list"})
+	cache.Add("b", CommandResult{Intent: "request_user_list"})
+	_, ok := cache.Get("a")
+	assert.True(t, ok)
+	cache.Add("c", CommandResult{Intent: "conversation"})
+
+	assert.Equal(t, 2, cache.Len())
+	_, ok = cache.Get("b")
+	assert.False(t, ok, "b was the least recently used entry")
+	result, ok := cache.Get("a")
+	assert.True(t, ok)
+	assert.Equal(t, "request_channel_list", result.Intent)
+	assert.Equal(t, evicted+1, metrics.Default().Counter("walkie_ai_cache_evictions_total", map[string]string{"reason": "size"}))
+}
+
+func TestAnalysisCache_ExpiresEntries(t *testing.T) {
+	now := time.Now()
+	cache := newAnalysisCache(10, time.Minute)
+	cache.now = func() time.Time { return now }
+	hits := metrics.Default().Counter("walkie_ai_cache_hits_total", nil)
+	misses := metrics.Default().Counter("walkie_ai_cache_misses_total", nil)
+
+	cache.Add("a", CommandResult{Intent: "request_channel_list"})
+	_, ok := cache.Get("a")
+	assert.True(t, ok)
+
+	now = now.Add(2 * time.Minute)
+	_, ok = cache.Get("a")
+	assert.False(t, ok)
+	assert.Equal(t, 0, cache.Len())
+	assert.Equal(t, hits+1, metrics.Default().Counter("walkie_ai_cache_hits_total", nil))
+	assert.Equal(t, misses+1, metrics.Default().Counter("walkie_ai_cache_misses_total", nil))
+}
+
+func TestCacheConfigFromEnv(t *testing.T) {
+	t.Setenv("AI_CACHE_MAX_ENTRIES", "50")
+	t.Setenv("AI_CACHE_TTL", "30s")
+	assert.Equal(t, 50, cacheMaxEntriesFromEnv())
+	assert.Equal(t, 30*time.Second, cacheTTLFromEnv())
+
+	t.Setenv("AI_CACHE_MAX_ENTRIES", "-1")
+	t.Setenv("AI_CACHE_TTL", "nunca")
+	assert.Equal(t, defaultCacheMaxEntries, cacheMaxEntriesFromEnv())
+	assert.Equal(t, defaultCacheTTL, cacheTTLFromEnv())
+}
@@ -0,0 +1,96 @@
+package qwen
+
+import (
+	"context"
+	"strings"
+)
+
+type channelNamesKey struct{}
+
+// WithChannelNames guarda en el contexto el nombre visible de cada canal (código -> nombre)
+// para que el prompt los muestre y la heurística reconozca "conéctame a logística"
+func WithChannelNames(ctx context.Context, names map[string]string) context.Context {
+	if len(names) == 0 {
+		return ctx
+	}
+	return context.WithValue(ctx, channelNamesKey{}, names)
+}
+
+func channelNamesFrom(ctx context.Context) map[string]string {
+	names, _ := ctx.Value(channelNamesKey{}).(map[string]string)
+	return names
+}
+
+// describeChannels acompaña cada código con su nombre visible cuando aporta algo: "obra-3 (Obra Norte)"
+func describeChannels(channels []string, names map[string]string) []string {
+	if len(names) == 0 {
+		return channels
+	}
+	out := make([]string, len(channels))
+	for i, code := range channels {
+		out[i] = code
+		name := strings.TrimSpace(names[code])
+		if name != "" && normalizeTranscript(name) != spokenChannelCode(code) {
+			out[i] = code + " (" + name + ")"
+		}
+	}
+	return out
+}
+
+// channelLookup une los alias del usuario con los nombres de los canales (nombre
+// normalizado -> código). Los alias del usuario ganan si coinciden con un nombre.
+func channelLookup(aliases, names map[string]string) map[string]string {
+	if len(names) == 0 {
+		return aliases
+	}
+	lookup := make(map[string]string, len(aliases)+len(names))
+	for code, name := range names {
+		if n := normalizeTranscript(name); n != "" {
+			lookup[n] = code
+		}
+	}
+	for alias, code := range aliases {
+		lookup[alias] = code
+	}
+	return lookup
+}
+
+// spokenChannelCode es el código tal como se diría en voz alta: "obra-norte" -> "obra norte"
+func spokenChannelCode(code string) string {
+	return strings.ReplaceAll(code, "-", " ")
+}
+
+// matchChannelCode busca un código de canal dicho tal cual; gana el más largo
+func matchChannelCode(text string, channels []string) (string, bool) {
+	padded := " " + text + " "
+	best := ""
+	for _, code := range channels {
+		spoken := spokenChannelCode(code)
+		if len(spoken) > len(spokenChannelCode(best)) && strings.Contains(padded, " "+spoken+" ") {
+			best = code
+		}
+	}
+	return best, best != ""
+}
+
+// channelForNumber resuelve "el tres" al canal-3 o, si los códigos no siguen ese
+// esquema, al único canal cuyo código termina en "-3" o es "3"
+func channelForNumber(number string, channels []string) (string, bool) {
+	legacy := "canal-" + number
+	if len(channels) == 0 {
+		return legacy, true
+	}
+	match := ""
+	for _, code := range channels {
+		if code == legacy {
+			return code, true
+		}
+		if code == number || strings.HasSuffix(code, "-"+number) {
+			if match != "" {
+				return "", false
+			}
+			match = code
+		}
+	}
+	return match, match != ""
+}
@@ -0,0 +1,60 @@
+package qwen
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExtractChannel_ArbitraryCodes(t *testing.T) {
+	channels := []string{"logistica", "obra-norte", "turno-7", "canal-12"}
+
+	channel, ok := extractChannel("conectame a logistica", channels)
+	assert.True(t, ok)
+	assert.Equal(t, "logistica", channel)
+
+	channel, ok = extractChannel("pasame a obra norte", channels)
+	assert.True(t, ok)
+	assert.Equal(t, "obra-norte", channel)
+
+	channel, ok = extractChannel("al canal siete", channels)
+	assert.True(t, ok)
+	assert.Equal(t, "turno-7", channel)
+
+	channel, ok = extractChannel("conectame al canal 12", channels)
+	assert.True(t, ok)
+	assert.Equal(t, "canal-12", channel)
+
+	_, ok = extractChannel("conectame al canal 3", channels)
+	assert.False(t, ok)
+
+	// Sin lista de canales se mantiene el esquema canal-N
+	channel, ok = extractChannel("canal ocho", nil)
+	assert.True(t, ok)
+	assert.Equal(t, "canal-8", channel)
+
+	// Un número que terminan varios códigos es ambiguo
+	_, ok = extractChannel("el dos", []string{"norte-2", "sur-2"})
+	assert.False(t, ok)
+}
+
+func TestChannelNames_PromptAndLookup(t *testing.T) {
+	channels := []string{"canal-1", "bodega-a"}
+	names := map[string]string{"canal-1": "Canal 1", "bodega-a": "Bodega Central"}
+
+	assert.Equal(t, []string{"canal-1", "bodega-a (Bodega Central)"}, describeChannels(channels, names))
+	assert.Equal(t, channels, describeChannels(channels, nil))
+
+	lookup := channelLookup(map[string]string{"bodega central": "canal-1"}, names)
+	assert.Equal(t, "canal-1", lookup["bodega central"], "el alias del usuario gana")
+	assert.Equal(t, "canal-1", lookup["canal 1"])
+
+	result, ok := detectWithAliases("conéctame a la bodega central", channels, "sin_canal", "es", channelLookup(nil, names))
+	assert.True(t, ok)
+	assert.Equal(t, []string{"bodega-a"}, result.Channels)
+
+	ctx := WithChannelNames(context.Background(), names)
+	assert.Equal(t, names, channelNamesFrom(ctx))
+	assert.Nil(t, channelNamesFrom(WithChannelNames(context.Background(), nil)))
+}
@@ -0,0 +1,166 @@
+package qwen
+
+import (
+	"regexp"
+	"strings"
+)
+
+var (
+	// englishDirectRegex captura el destinatario en "send it to john" o "tell anna ..."
+	englishDirectRegex = regexp.MustCompile(`\b(?:send (?:it|this|that) to|send to|tell|message)\s+(\p{L}+)`)
+	// englishMuteRegex captura a quién silenciar en "mute john"
+	englishMuteRegex     = regexp.MustCompile(`\bmute\s+(\p{L}+)`)
+	englishNotRecipients = map[string]bool{
+		"everyone": true, "everybody": true, "all": true, "the": true, "channel": true, "me": true, "us": true,
+	}
+)
+
+// detectEnglishCommandFallback es la heurística local para usuarios con idioma inglés
+func detectEnglishCommandFallback(transcript string, channels []string, currentState string) (CommandResult, bool) {
+	text := strings.ReplaceAll(normalizeTranscript(transcript), "'", "")
+	command := func(intent string) (CommandResult, bool) {
+		return CommandResult{IsCommand: true, Intent: intent, State: currentState}, true
+	}
+
+	if muted, ok := extractEnglishMuted(text); ok {
+		result, _ := command("request_user_mute")
+		result.Recipient = muted
+		return result, true
+	}
+
+	if recipient, ok := extractEnglishRecipient(text); ok {
+		result, _ := command("request_direct_message")
+		result.Recipient = recipient
+		return result, true
+	}
+
+	switch {
+	case isEnglishSummary(text):
+		return command("request_channel_summary")
+	case isEnglishRecordingStop(text):
+		return command("request_recording_stop")
+	case isEnglishRecordingStart(text):
+		return command("request_recording_start")
+	case isEnglishDNDOff(text):
+		return command("request_dnd_off")
+	case isEnglishDNDOn(text):
+		return command("request_dnd_on")
+	case isEnglishFavoriteChannel(text):
+		return command("request_favorite_channel")
+	case isEnglishNearbyUsers(text):
+		return command("request_nearby_users")
+	case isEnglishCurrentChannel(text):
+		return command("request_current_channel")
+	case isEnglishListUsers(text):
+		return command("request_user_list")
+	case isEnglishListChannels(text):
+		return command("request_channel_list")
+	case isEnglishDisconnect(text):
+		return command("request_channel_disconnect")
+	case isEnglishConnect(text):
+		if channel, ok := extractChannelWith(text, channels, englishNumberMap); ok {
+			result, _ := command("request_channel_connect")
+			result.Channels = []string{channel}
+			return result, true
+		}
+	}
+
+	return CommandResult{}, false
+}
+
+func extractEnglishRecipient(text string) (string, bool) {
+	match := englishDirectRegex.FindStringSubmatch(text)
+	if match == nil || englishNotRecipients[match[1]] {
+		return "", false
+	}
+	return match[1], true
+}
+
+func extractEnglishMuted(text string) (string, bool) {
+	match := englishMuteRegex.FindStringSubmatch(text)
+	if match == nil || englishNotRecipients[match[1]] {
+		return "", false
+	}
+	return match[1], true
+}
+
+func isEnglishSummary(text string) bool {
+	return strings.Contains(text, "summary") ||
+		strings.Contains(text, "summarize") ||
+		strings.Contains(text, "what did i miss") ||
+		strings.Contains(text, "catch me up")
+}
+
+func isEnglishRecordingStop(text string) bool {
+	return strings.Contains(text, "stop recording") ||
+		strings.Contains(text, "end the recording")
+}
+
+func isEnglishRecordingStart(text string) bool {
+	return strings.Contains(text, "record the channel") ||
+		strings.Contains(text, "start recording")
+}
+
+func isEnglishCurrentChannel(text string) bool {
+	return strings.Contains(text, "channel am i") ||
+		strings.Contains(text, "what channel is this") ||
+		strings.Contains(text, "current channel")
+}
+
+// isEnglishDNDOff se comprueba antes que isEnglishDNDOn
+func isEnglishDNDOff(text string) bool {
+	return containsAll(text, "off", "do not disturb") ||
+		containsAll(text, "disable", "do not disturb") ||
+		strings.Contains(text, "you can disturb me")
+}
+
+func isEnglishDNDOn(text string) bool {
+	return strings.Contains(text, "do not disturb") ||
+		strings.Contains(text, "dont disturb me")
+}
+
+func isEnglishFavoriteChannel(text string) bool {
+	return strings.Contains(text, "my favorite") ||
+		strings.Contains(text, "my favourite")
+}
+
+func isEnglishNearbyUsers(text string) bool {
+	return strings.Contains(text, "nearby") ||
+		strings.Contains(text, "near me") ||
+		strings.Contains(text, "close to me") ||
+		strings.Contains(text, "close by")
+}
+
+func isEnglishListUsers(text string) bool {
+	return strings.Contains(text, "who is here") ||
+		strings.Contains(text, "whos here") ||
+		strings.Contains(text, "who is in") ||
+		strings.Contains(text, "who is on") ||
+		containsAll(text, "list", "users") ||
+		containsAll(text, "users", "channel")
+}
+
+func isEnglishListChannels(text string) bool {
+	return containsAll(text, "list", "channels") ||
+		strings.Contains(text, "what channels") ||
+		strings.Contains(text, "which channels") ||
+		strings.Contains(text, "available channels") ||
+		containsAll(text, "show", "channels")
+}
+
+func isEnglishDisconnect(text string) bool {
+	return strings.Contains(text, "disconnect") ||
+		strings.Contains(text, "leave the channel") ||
+		strings.Contains(text, "leave channel") ||
+		strings.Contains(text, "exit the channel")
+}
+
+func isEnglishConnect(text string) bool {
+	return strings.Contains(text, "connect") ||
+		strings.Contains(text, "join") ||
+		strings.Contains(text, "switch to") ||
+		strings.Contains(text, "switch channel") ||
+		strings.Contains(text, "change channel") ||
+		strings.Contains(text, "go to channel") ||
+		strings.Contains(text, "take me to")
+}
@@ -0,0 +1,81 @@
+package qwen
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"walkie-backend/pkg/lang"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDetectEnglishCommandFallback(t *testing.T) {
+	channels := []string{"canal-1", "canal-2", "canal-21"}
+	tests := []struct {
+		transcript string
+		intent     string
+		channel    string
+		recipient  string
+	}{
+		{"Can you list the channels?", "request_channel_list", "", ""},
+		{"Connect me to channel two", "request_channel_connect", "canal-2", ""},
+		{"Join channel 1", "request_channel_connect", "canal-1", ""},
+		{"Switch to channel twenty-one", "request_channel_connect", "canal-21", ""},
+		{"Disconnect from the channel", "request_channel_disconnect", "", ""},
+		{"Who's here?", "request_user_list", "", ""},
+		{"Is anyone nearby?", "request_nearby_users", "", ""},
+		{"Do not disturb", "request_dnd_on", "", ""},
+		{"Take me back to my favorite channel", "request_favorite_channel", "", ""},
+		{"Turn off do not disturb", "request_dnd_off", "", ""},
+		{"What channel am I in?", "request_current_channel", "", ""},
+		{"Send it to John", "request_direct_message", "", "john"},
+		{"What did I miss?", "request_channel_summary", "", ""},
+		{"Mute John", "request_user_mute", "", "john"},
+		{"Start recording", "request_recording_start", "", ""},
+		{"Stop recording, please", "request_recording_stop", "", ""},
+		{"hello, we are at the gate", "", "", ""},
+		{"tell everyone we are leaving", "", "", ""},
+		{"connect to channel 99", "", "", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.transcript, func(t *testing.T) {
+			result, ok := detectEnglishCommandFallback(tt.transcript, channels, "sin_canal")
+			assert.Equal(t, tt.intent != "", ok)
+			assert.Equal(t, tt.intent, result.Intent)
+			assert.Equal(t, tt.recipient, result.Recipient)
+			if tt.channel != "" {
+				assert.Equal(t, []string{tt.channel}, result.Channels)
+			}
+		})
+	}
+
+	// Un usuario en español no activa comandos con frases en inglés
+	_, ok := detectCommandForLanguage("who's here", channels, "sin_canal", lang.Spanish)
+	assert.False(t, ok)
+}
+
+func TestAnalyzeTranscript_EnglishUserFallsBackToEnglishHeuristics(t *testing.T) {
+	var prompt string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req chatRequest
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		prompt = req.Messages[1].Content
+		_ = json.NewEncoder(w).Encode(chatResponse{Choices: []choice{{Message: message{
+			Role:    "assistant",
+			Content: `{"is_command":false,"intent":"conversation","reply":"","state":"canal-1"}`,
+		}}}})
+	}))
+	t.Cleanup(server.Close)
+
+	client := &Client{httpClient: server.Client(), baseURL: server.URL, model: "test-model"}
+	ctx := lang.WithLanguage(context.Background(), "en-US")
+	result, err := client.AnalyzeTranscript(ctx, "who is here right now", []string{"canal-1"}, "canal-1", "")
+
+	assert.NoError(t, err)
+	assert.Equal(t, "request_user_list", result.Intent)
+	assert.Contains(t, prompt, "<language>en</language>")
+}
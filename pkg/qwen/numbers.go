@@ -0,0 +1,122 @@
+package qwen
+
+import (
+	"strconv"
+	"strings"
+)
+
+var (
+	// wordNumberMap traduce números y ordinales en español (sin tildes) a cifras; incluye
+	// las grafías con seseo que devuelve a menudo el STT ("dose", "sinco")
+	wordNumberMap = map[string]string{
+		"uno": "1", "primero": "1", "primer": "1",
+		"dos": "2", "segundo": "2",
+		"tres": "3", "tercero": "3", "tercer": "3",
+		"cuatro": "4", "cuarto": "4",
+		"cinco": "5", "quinto": "5", "sinco": "5",
+		"seis": "6", "sexto": "6",
+		"siete": "7", "septimo": "7", "setimo": "7",
+		"ocho": "8", "octavo": "8",
+		"nueve": "9", "noveno": "9", "nuebe": "9",
+		"diez": "10", "decimo": "10", "dies": "10",
+		"once": "11", "undecimo": "11", "onse": "11",
+		"doce": "12", "duodecimo": "12", "dose": "12",
+		"trece": "13", "trese": "13",
+		"catorce": "14", "catorse": "14",
+		"quince": "15", "quinse": "15",
+		"dieciseis": "16", "diesiseis": "16",
+		"diecisiete": "17", "diesisiete": "17",
+		"dieciocho": "18", "diesiocho": "18",
+		"diecinueve": "19", "diesinueve": "19",
+		"veinte": "20", "vigesimo": "20", "beinte": "20",
+		"veintiuno": "21", "veintiun": "21",
+		"veintidos": "22", "veintitres": "23", "veinticuatro": "24",
+		"veinticinco": "25", "veintisinco": "25", "veintiseis": "26",
+		"veintisiete": "27", "veintiocho": "28", "veintinueve": "29",
+		"treinta": "30", "cuarenta": "40", "cincuenta": "50", "sincuenta": "50",
+		"sesenta": "60", "setenta": "70", "ochenta": "80", "noventa": "90",
+		"cien": "100", "ciento": "100",
+	}
+	englishNumberMap = map[string]string{
+		"one": "1", "first": "1",
+		"two": "2", "second": "2",
+		"three": "3", "third": "3",
+		"four": "4", "fourth": "4",
+		"five": "5", "fifth": "5",
+		"six": "6", "sixth": "6",
+		"seven": "7", "seventh": "7",
+		"eight": "8", "eighth": "8",
+		"nine": "9", "ninth": "9",
+		"ten": "10", "tenth": "10",
+		"eleven": "11", "eleventh": "11",
+		"twelve": "12", "twelfth": "12",
+		"thirteen": "13", "thirteenth": "13",
+		"fourteen": "14", "fourteenth": "14",
+		"fifteen": "15", "fifteenth": "15",
+		"sixteen": "16", "sixteenth": "16",
+		"seventeen": "17", "seventeenth": "17",
+		"eighteen": "18", "eighteenth": "18",
+		"nineteen": "19", "nineteenth": "19",
+		"twenty": "20", "twentieth": "20",
+		"thirty": "30", "thirtieth": "30",
+		"forty": "40", "fortieth": "40",
+		"fifty": "50", "fiftieth": "50",
+		"sixty": "60", "seventy": "70", "eighty": "80", "ninety": "90",
+		"hundred": "100",
+	}
+)
+
+// spokenNumber busca el primer número dicho con palabras de numbers y lo devuelve en
+// cifras. Une las decenas con las unidades ("treinta y dos", "twenty-one") y las
+// centenas con lo que sigue ("ciento cinco", "one hundred and two").
+func spokenNumber(text string, numbers map[string]string) (string, bool) {
+	words := strings.FieldsFunc(text, func(r rune) bool { return r == ' ' || r == '-' })
+	for i := range words {
+		n, next, ok := numberAt(words, i, numbers)
+		if !ok {
+			continue
+		}
+		if next < len(words) && words[next] == "hundred" && n < 10 {
+			n, next = n*100, next+1
+		}
+		if n == 100 || (n > 100 && n%100 == 0) {
+			if next < len(words) && words[next] == "and" {
+				next++
+			}
+			if rest, _, ok := numberAt(words, next, numbers); ok && rest < 100 {
+				n += rest
+			}
+		}
+		return strconv.Itoa(n), true
+	}
+	return "", false
+}
+
+// numberAt lee el número que empieza en words[i], con sus unidades si es una decena;
+// devuelve también la posición de la palabra siguiente
+func numberAt(words []string, i int, numbers map[string]string) (n, next int, ok bool) {
+	if i >= len(words) {
+		return 0, i, false
+	}
+	value, found := numbers[words[i]]
+	if !found {
+		return 0, i, false
+	}
+	n, _ = strconv.Atoi(value)
+	next = i + 1
+	if n < 20 || n >= 100 || n%10 != 0 {
+		return n, next, true
+	}
+	j := next
+	if j < len(words) && (words[j] == "y" || words[j] == "and") {
+		j++
+	}
+	if j < len(words) {
+		if unit, found := numbers[words[j]]; found {
+			if u, _ := strconv.Atoi(unit); u >= 1 && u <= 9 {
+				return n + u, j + 1, true
+			}
+		}
+	}
+	return n, next, true
+}
@@ -0,0 +1,66 @@
+package qwen
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSpokenNumber(t *testing.T) {
+	cases := []struct {
+		text    string
+		numbers map[string]string
+		want    string
+		ok      bool
+	}{
+		{"al canal dos", wordNumberMap, "2", true},
+		{"al primer canal", wordNumberMap, "1", true},
+		{"canal doce", wordNumberMap, "12", true},
+		{"canal dose", wordNumberMap, "12", true},
+		{"canal trese", wordNumberMap, "13", true},
+		{"canal diecisiete", wordNumberMap, "17", true},
+		{"canal veintiuno", wordNumberMap, "21", true},
+		{"canal veintidos", wordNumberMap, "22", true},
+		{"canal veinte", wordNumberMap, "20", true},
+		{"canal treinta y dos", wordNumberMap, "32", true},
+		{"canal cuarenta cinco", wordNumberMap, "45", true},
+		{"canal ciento cinco", wordNumberMap, "105", true},
+		{"canal cien", wordNumberMap, "100", true},
+		{"el undecimo", wordNumberMap, "11", true},
+		{"vete al canal", wordNumberMap, "", false},
+		{"channel two", englishNumberMap, "2", true},
+		{"channel twelve", englishNumberMap, "12", true},
+		{"channel twenty-one", englishNumberMap, "21", true},
+		{"channel thirty four", englishNumberMap, "34", true},
+		{"the twenty first channel", englishNumberMap, "21", true},
+		{"channel one hundred and two", englishNumberMap, "102", true},
+		{"channel three hundred", englishNumberMap, "300", true},
+		{"join the channel", englishNumberMap, "", false},
+	}
+	for _, tc := range cases {
+		got, ok := spokenNumber(tc.text, tc.numbers)
+		assert.Equal(t, tc.ok, ok, tc.text)
+		assert.Equal(t, tc.want, got, tc.text)
+	}
+}
+
+func TestExtractChannel_SpokenNumbers(t *testing.T) {
+	channels := []string{"canal-2", "canal-12", "canal-21", "canal-32"}
+	cases := []struct {
+		text     string
+		language string
+		want     string
+	}{
+		{"conectame al canal veintiuno", "es", "canal-21"},
+		{"pasame al dose", "es", "canal-12"},
+		{"cambia al treinta y dos", "es", "canal-32"},
+		{"connect to channel twenty-one", "en", "canal-21"},
+		{"join channel twelve", "en", "canal-12"},
+	}
+	for _, tc := range cases {
+		got, ok := extractChannelFor(tc.text, channels, tc.language)
+		assert.True(t, ok, tc.text)
+		assert.Equal(t, tc.want, got, tc.text)
+	}
+	assert.False(t, isConnectWithoutNumber("cambiame al canal veintidos", "es"))
+}
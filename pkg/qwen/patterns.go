@@ -0,0 +1,133 @@
+package qwen
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"sort"
+	"strings"
+	"sync"
+
+	"walkie-backend/pkg/lang"
+)
+
+// Pattern es una frase configurada por los operadores que activa un intent sin
+// necesidad de recompilar (p. ej. "ponme en el canal" -> request_channel_connect)
+type Pattern struct {
+	Intent   string
+	Phrase   string
+	Language string
+}
+
+var customPatterns = struct {
+	sync.RWMutex
+	list    []Pattern
+	version string
+}{}
+
+// IsCommandIntent indica si intent es un comando válido para un patrón
+func IsCommandIntent(intent string) bool {
+	return intent != "conversation" && validIntents[intent]
+}
+
+// NormalizePhrase deja la frase como la ve el detector: minúsculas, sin tildes ni puntuación
+func NormalizePhrase(phrase string) string {
+	return normalizeTranscript(phrase)
+}
+
+// SetPatterns reemplaza los patrones configurados; se usan tanto en el prompt como en
+// la heurística local. Los más largos se prueban primero.
+func SetPatterns(patterns []Pattern) {
+	list := make([]Pattern, 0, len(patterns))
+	for _, p := range patterns {
+		phrase := NormalizePhrase(p.Phrase)
+		if phrase == "" || !IsCommandIntent(p.Intent) {
+			continue
+		}
+		language, ok := lang.Normalize(p.Language)
+		if !ok {
+			language = lang.Default
+		}
+		list = append(list, Pattern{Intent: p.Intent, Phrase: phrase, Language: language})
+	}
+	sort.SliceStable(list, func(i, j int) bool { return len(list[i].Phrase) > len(list[j].Phrase) })
+
+	hash := sha256.New()
+	for _, p := range list {
+		hash.Write([]byte(p.Language + "|" + p.Intent + "|" + p.Phrase + "\n"))
+	}
+
+	customPatterns.Lock()
+	customPatterns.list = list
+	customPatterns.version = hex.EncodeToString(hash.Sum(nil))[:12]
+	customPatterns.Unlock()
+}
+
+// patternsFor devuelve los patrones del idioma y la versión del conjunto cargado
+func patternsFor(language string) ([]Pattern, string) {
+	customPatterns.RLock()
+	defer customPatterns.RUnlock()
+	var out []Pattern
+	for _, p := range customPatterns.list {
+		if p.Language == language {
+			out = append(out, p)
+		}
+	}
+	return out, customPatterns.version
+}
+
+// matchCustomPattern aplica los patrones configurados antes que la heurística fija
+func matchCustomPattern(transcript string, channels []string, currentState, language string) (CommandResult, bool) {
+	patterns, _ := patternsFor(language)
+	if len(patterns) == 0 {
+		return CommandResult{}, false
+	}
+	text := normalizeTranscript(transcript)
+
+	for _, p := range patterns {
+		idx := strings.Index(" "+text+" ", " "+p.Phrase+" ")
+		if idx < 0 {
+			continue
+		}
+		result := CommandResult{IsCommand: true, Intent: p.Intent, State: currentState}
+
+		switch p.Intent {
+		case "request_channel_connect":
+			numbers := wordNumberMap
+			if language == lang.English {
+				numbers = englishNumberMap
+			}
+			channel, ok := extractChannelWith(text, channels, numbers)
+			if !ok {
+				continue
+			}
+			result.Channels = []string{channel}
+		case "request_direct_message":
+			rest := strings.Fields(text[min(idx+len(p.Phrase), len(text)):])
+			if len(rest) == 0 || notRecipients[rest[0]] || englishNotRecipients[rest[0]] {
+				continue
+			}
+			result.Recipient = rest[0]
+		}
+		return result, true
+	}
+	return CommandResult{}, false
+}
+
+// customPhrasesPrompt describe al modelo las frases configuradas para el idioma
+func customPhrasesPrompt(language string) string {
+	patterns, _ := patternsFor(language)
+	if len(patterns) == 0 {
+		return ""
+	}
+	var sb strings.Builder
+	sb.WriteString("    <custom_phrases>\n")
+	for _, p := range patterns {
+		sb.WriteString("        ")
+		sb.WriteString(p.Phrase)
+		sb.WriteString(" => ")
+		sb.WriteString(p.Intent)
+		sb.WriteString("\n")
+	}
+	sb.WriteString("    </custom_phrases>\n")
+	return sb.String()
+}
@@ -0,0 +1,56 @@
+package qwen
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMatchCustomPattern(t *testing.T) {
+	SetPatterns([]Pattern{
+		{Intent: "request_channel_connect", Phrase: "Llévame al", Language: "es"},
+		{Intent: "request_direct_message", Phrase: "pásale a", Language: "es"},
+		{Intent: "request_user_list", Phrase: "quién anda por aquí", Language: "es"},
+		{Intent: "request_channel_list", Phrase: "gimme channels", Language: "en"},
+		{Intent: "conversation", Phrase: "hola", Language: "es"},
+	})
+	t.Cleanup(func() { SetPatterns(nil) })
+
+	channels := []string{"canal-1", "canal-2"}
+
+	result, ok := detectCommandForLanguage("Llévame al canal dos", channels, "sin_canal", "es")
+	assert.True(t, ok)
+	assert.Equal(t, "request_channel_connect", result.Intent)
+	assert.Equal(t, []string{"canal-2"}, result.Channels)
+
+	result, ok = detectCommandForLanguage("pásale a Marta que ya voy", channels, "canal-1", "es")
+	assert.True(t, ok)
+	assert.Equal(t, "request_direct_message", result.Intent)
+	assert.Equal(t, "marta", result.Recipient)
+
+	result, ok = detectCommandForLanguage("¿Quién anda por aquí?", channels, "canal-1", "es")
+	assert.True(t, ok)
+	assert.Equal(t, "request_user_list", result.Intent)
+
+	_, ok = detectCommandForLanguage("gimme channels", channels, "canal-1", "es")
+	assert.False(t, ok, "los patrones sólo se aplican a su idioma")
+	result, ok = detectCommandForLanguage("gimme channels", channels, "canal-1", "en")
+	assert.True(t, ok)
+	assert.Equal(t, "request_channel_list", result.Intent)
+
+	_, ok = detectCommandForLanguage("hola a todos", channels, "canal-1", "es")
+	assert.False(t, ok, "conversation no es un intent de patrón")
+
+	_, ok = detectCommandForLanguage("llévame al almacén", channels, "canal-1", "es")
+	assert.False(t, ok, "sin número de canal no hay conexión")
+}
+
+func TestBuildAnalysisPrompt_IncludesCustomPhrases(t *testing.T) {
+	SetPatterns([]Pattern{{Intent: "request_user_list", Phrase: "¿Quién anda?", Language: "es"}})
+	t.Cleanup(func() { SetPatterns(nil) })
+
+	prompt := buildAnalysisPrompt("hola", nil, "sin_canal", "", "es", nil, SessionContext{})
+	assert.True(t, strings.Contains(prompt, "quien anda => request_user_list"), prompt)
+	assert.NotContains(t, buildAnalysisPrompt("hello", nil, "sin_canal", "", "en", nil, SessionContext{}), "custom_phrases")
+}
@@ -0,0 +1,64 @@
+package qwen
+
+import (
+	"strings"
+
+	"walkie-backend/pkg/lang"
+)
+
+// PendingAnyChannel es el canal pendiente cuando se preguntó "¿a qué canal?" sin proponer
+// ninguno; cualquier otro valor es el canal que se le propuso al usuario
+const PendingAnyChannel = "*"
+
+// maxPendingReplyWords limita las respuestas que se interpretan localmente con el estado
+// pendiente; las más largas van al modelo con <pending_channel> en el contexto
+const maxPendingReplyWords = 5
+
+var affirmativeReplies = map[string]bool{
+	"si": true, "claro": true, "dale": true, "vale": true, "ok": true, "okay": true,
+	"yes": true, "yeah": true, "sure": true,
+}
+
+// detectPendingReply interpreta la respuesta a una pregunta de cambio de canal: un número
+// ("el dos") conecta a ese canal y un "sí" conecta al canal propuesto
+func detectPendingReply(transcript string, channels []string, currentState, pendingChannel, language string) (CommandResult, bool) {
+	if pendingChannel == "" {
+		return CommandResult{}, false
+	}
+	normalized := normalizeTranscript(transcript)
+	words := strings.Fields(normalized)
+	if len(words) == 0 || len(words) > maxPendingReplyWords {
+		return CommandResult{}, false
+	}
+
+	connect := func(channel string) (CommandResult, bool) {
+		return CommandResult{
+			IsCommand:      true,
+			Intent:         "request_channel_connect",
+			State:          currentState,
+			Channels:       []string{channel},
+			PendingChannel: pendingChannel,
+		}, true
+	}
+	if channel, ok := extractChannelFor(normalized, channels, language); ok {
+		return connect(channel)
+	}
+	if pendingChannel != PendingAnyChannel && affirmativeReplies[words[0]] {
+		return connect(pendingChannel)
+	}
+	return CommandResult{}, false
+}
+
+// isConnectWithoutNumber detecta "cambia de canal" sin decir a cuál: es una conexión a la
+// que le falta el canal y se responde preguntándolo
+func isConnectWithoutNumber(text, language string) bool {
+	connect, channelWord, numbers := isConnect, "canal", wordNumberMap
+	if language == lang.English {
+		connect, channelWord, numbers = isEnglishConnect, "channel", englishNumberMap
+	}
+	if !connect(text) || !strings.Contains(text, channelWord) || digitsRegex.MatchString(text) {
+		return false
+	}
+	_, hasNumber := spokenNumber(text, numbers)
+	return !hasNumber
+}
@@ -0,0 +1,66 @@
+package qwen
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDetectPendingReply(t *testing.T) {
+	channels := []string{"canal-1", "canal-2", "canal-4"}
+	tests := []struct {
+		transcript string
+		pending    string
+		language   string
+		channel    string
+	}{
+		{"el dos", PendingAnyChannel, "es", "canal-2"},
+		{"al canal cuatro", "canal-2", "es", "canal-4"},
+		{"Sí, por favor", "canal-2", "es", "canal-2"},
+		{"sí", PendingAnyChannel, "es", ""},
+		{"yes", "canal-4", "en", "canal-4"},
+		{"number two", PendingAnyChannel, "en", "canal-2"},
+		{"el dos", "", "es", ""},
+		{"oye el camión dos ya salió del almacén central", "canal-2", "es", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.transcript, func(t *testing.T) {
+			result, ok := detectPendingReply(tt.transcript, channels, "canal-1", tt.pending, tt.language)
+			assert.Equal(t, tt.channel != "", ok)
+			if tt.channel != "" {
+				assert.Equal(t, "request_channel_connect", result.Intent)
+				assert.Equal(t, []string{tt.channel}, result.Channels)
+				assert.Equal(t, tt.pending, result.PendingChannel)
+			}
+		})
+	}
+}
+
+func TestDetectWithAliases_ConnectWithoutNumber(t *testing.T) {
+	channels := []string{"canal-1", "canal-2"}
+
+	result, ok := detectWithAliases("cambia de canal", channels, "canal-1", "es", nil)
+	assert.True(t, ok)
+	assert.Equal(t, "request_channel_connect", result.Intent)
+	assert.Empty(t, result.Channels)
+
+	result, ok = detectWithAliases("switch channel", channels, "canal-1", "en", nil)
+	assert.True(t, ok)
+	assert.Empty(t, result.Channels)
+
+	// Un número que no es un canal sigue sin ser comando, y "cambia" sin "canal" es conversación
+	_, ok = detectWithAliases("conéctame al canal 9", channels, "canal-1", "es", nil)
+	assert.False(t, ok)
+	_, ok = detectWithAliases("cambia la rueda del camión", channels, "canal-1", "es", nil)
+	assert.False(t, ok)
+}
+
+func TestAnalyzeTranscript_PendingReplySkipsModel(t *testing.T) {
+	client := NewClientWithConfig(Config{BaseURL: "http://127.0.0.1:1", Model: "test"})
+
+	result, err := client.AnalyzeTranscript(context.Background(), "el uno", []string{"canal-1", "canal-2"}, "sin_canal", PendingAnyChannel)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"canal-1"}, result.Channels)
+}
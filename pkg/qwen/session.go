@@ -0,0 +1,88 @@
+package qwen
+
+import (
+	"context"
+	"regexp"
+	"strings"
+)
+
+// maxFollowUpWords limita las órdenes de seguimiento que se resuelven localmente
+const maxFollowUpWords = 5
+
+type sessionKey struct{}
+
+// followUpRegex reconoce el arranque de una orden que completa la anterior ("ahora al tres")
+var followUpRegex = regexp.MustCompile(`^(?:y|ahora|mejor|entonces|now|and|then|instead)\b`)
+
+// SessionContext son las últimas frases del usuario y el último intent detectado, para
+// entender órdenes que dependen de la anterior
+type SessionContext struct {
+	Transcripts []string
+	LastIntent  string
+}
+
+// WithSessionContext guarda en el contexto la conversación reciente del usuario para que el
+// análisis la incluya en el prompt y en la clave de caché
+func WithSessionContext(ctx context.Context, sc SessionContext) context.Context {
+	if len(sc.Transcripts) == 0 && sc.LastIntent == "" {
+		return ctx
+	}
+	return context.WithValue(ctx, sessionKey{}, sc)
+}
+
+func sessionContextFrom(ctx context.Context) SessionContext {
+	sc, _ := ctx.Value(sessionKey{}).(SessionContext)
+	return sc
+}
+
+// sessionKeyPart serializa el contexto de sesión para la clave de caché
+func sessionKeyPart(sc SessionContext) string {
+	if len(sc.Transcripts) == 0 && sc.LastIntent == "" {
+		return ""
+	}
+	return sc.LastIntent + "|" + strings.Join(sc.Transcripts, "|")
+}
+
+// sessionContextPrompt describe al modelo lo último que dijo el usuario
+func sessionContextPrompt(sc SessionContext) string {
+	if len(sc.Transcripts) == 0 && sc.LastIntent == "" {
+		return ""
+	}
+	var sb strings.Builder
+	sb.WriteString("    <recent_context>\n")
+	if sc.LastIntent != "" {
+		sb.WriteString("        <last_intent>")
+		sb.WriteString(sc.LastIntent)
+		sb.WriteString("</last_intent>\n")
+	}
+	for _, transcript := range sc.Transcripts {
+		sb.WriteString("        <utterance>")
+		sb.WriteString(transcript)
+		sb.WriteString("</utterance>\n")
+	}
+	sb.WriteString("    </recent_context>\n")
+	return sb.String()
+}
+
+// detectFollowUp resuelve órdenes cortas que repiten el último cambio de canal con otro
+// número ("ahora al tres", "y al cinco") sin necesidad de llamar al modelo
+func detectFollowUp(transcript string, channels []string, currentState, language string, sc SessionContext) (CommandResult, bool) {
+	if sc.LastIntent != "request_channel_connect" {
+		return CommandResult{}, false
+	}
+	normalized := normalizeTranscript(transcript)
+	words := strings.Fields(normalized)
+	if len(words) == 0 || len(words) > maxFollowUpWords || !followUpRegex.MatchString(normalized) {
+		return CommandResult{}, false
+	}
+	channel, ok := extractChannelFor(normalized, channels, language)
+	if !ok {
+		return CommandResult{}, false
+	}
+	return CommandResult{
+		IsCommand: true,
+		Intent:    "request_channel_connect",
+		State:     currentState,
+		Channels:  []string{channel},
+	}, true
+}
@@ -0,0 +1,58 @@
+package qwen
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDetectFollowUp(t *testing.T) {
+	channels := []string{"canal-1", "canal-2", "canal-3"}
+	connected := SessionContext{Transcripts: []string{"conéctame al uno"}, LastIntent: "request_channel_connect"}
+	tests := []struct {
+		transcript string
+		session    SessionContext
+		language   string
+		channel    string
+	}{
+		{"ahora al tres", connected, "es", "canal-3"},
+		{"Y al canal dos", connected, "es", "canal-2"},
+		{"now channel two", connected, "en", "canal-2"},
+		{"ahora al tres", SessionContext{LastIntent: "conversation"}, "es", ""},
+		{"ahora al nueve", connected, "es", ""},
+		{"el camión tres ya llegó", connected, "es", ""},
+		{"ahora que llegue el camión tres avisamos al jefe", connected, "es", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.transcript, func(t *testing.T) {
+			result, ok := detectFollowUp(tt.transcript, channels, "canal-1", tt.language, tt.session)
+			assert.Equal(t, tt.channel != "", ok)
+			if tt.channel != "" {
+				assert.Equal(t, "request_channel_connect", result.Intent)
+				assert.Equal(t, []string{tt.channel}, result.Channels)
+			}
+		})
+	}
+}
+
+func TestBuildAnalysisPrompt_IncludesRecentContext(t *testing.T) {
+	session := SessionContext{Transcripts: []string{"conéctame al uno"}, LastIntent: "request_channel_connect"}
+
+	prompt := buildAnalysisPrompt("ahora al tres", nil, "canal-1", "", "es", nil, session)
+	assert.Contains(t, prompt, "<last_intent>request_channel_connect</last_intent>")
+	assert.Contains(t, prompt, "<utterance>conéctame al uno</utterance>")
+
+	assert.NotContains(t, buildAnalysisPrompt("hola", nil, "sin_canal", "", "es", nil, SessionContext{}), "recent_context")
+}
+
+func TestAnalyzeTranscript_FollowUpSkipsModel(t *testing.T) {
+	client := NewClientWithConfig(Config{BaseURL: "http://127.0.0.1:1", Model: "test"})
+	ctx := WithSessionContext(context.Background(), SessionContext{LastIntent: "request_channel_connect"})
+
+	result, err := client.AnalyzeTranscript(ctx, "ahora al dos", []string{"canal-1", "canal-2"}, "canal-1", "")
+	assert.NoError(t, err)
+	assert.True(t, result.IsCommand)
+	assert.Equal(t, []string{"canal-2"}, result.Channels)
+}
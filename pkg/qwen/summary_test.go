@@ -0,0 +1,42 @@
+package qwen
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestSummarize_SendsTranscriptAndStripsThinking(t *testing.T) {
+	var got chatRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewDecoder(r.Body).Decode(&got)
+		_ = json.NewEncoder(w).Encode(chatResponse{Choices: []choice{{Message: message{
+			Role:    "assistant",
+			Content: "<think>analizando</think>\nAna avisó de que el camión llegó al almacén.",
+		}}}})
+	}))
+	t.Cleanup(server.Close)
+
+	client := &Client{httpClient: server.Client(), baseURL: server.URL, model: "test-model"}
+	summary, err := client.Summarize(context.Background(), []string{"[10:00] ana: el camión ya llegó al almacén"})
+	if err != nil {
+		t.Fatalf("Summarize returned error: %v", err)
+	}
+	if summary != "Ana avisó de que el camión llegó al almacén." {
+		t.Errorf("unexpected summary %q", summary)
+	}
+	if len(got.Messages) != 2 || got.Messages[0].Content != summaryPrompt || !strings.Contains(got.Messages[1].Content, "ana: el camión") {
+		t.Errorf("unexpected request %+v", got.Messages)
+	}
+}
+
+func TestSummarize_NoLines(t *testing.T) {
+	client := &Client{httpClient: http.DefaultClient, baseURL: "http://127.0.0.1:0", model: "test-model"}
+	if _, err := client.Summarize(context.Background(), nil); !errors.Is(err, ErrNothingToSummarize) {
+		t.Fatalf("expected ErrNothingToSummarize, got %v", err)
+	}
+}
@@ -0,0 +1,29 @@
+package qwen
+
+import "context"
+
+// Usage son los tokens que el proveedor facturó en una llamada
+type Usage struct {
+	PromptTokens     int `json:"prompt_tokens"`
+	CompletionTokens int `json:"completion_tokens"`
+	TotalTokens      int `json:"total_tokens"`
+}
+
+type usageKey struct{}
+
+// WithUsageReport guarda en el contexto una función que recibe los tokens de cada
+// respuesta del modelo, para llevar la cuenta del coste por usuario
+func WithUsageReport(ctx context.Context, report func(Usage)) context.Context {
+	return context.WithValue(ctx, usageKey{}, report)
+}
+
+func reportUsage(ctx context.Context, u Usage) {
+	if u.TotalTokens == 0 {
+		u.TotalTokens = u.PromptTokens + u.CompletionTokens
+	}
+	report, _ := ctx.Value(usageKey{}).(func(Usage))
+	if report == nil || u.TotalTokens == 0 {
+		return
+	}
+	report(u)
+}
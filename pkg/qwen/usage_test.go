@@ -0,0 +1,37 @@
+package qwen
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestSummarize_ReportsTokenUsage(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(chatResponse{
+			Choices: []choice{{Message: message{Role: "assistant", Content: "Nada nuevo."}}},
+			Usage:   Usage{PromptTokens: 120, CompletionTokens: 8},
+		})
+	}))
+	t.Cleanup(server.Close)
+
+	var got []Usage
+	ctx := WithUsageReport(context.Background(), func(u Usage) { got = append(got, u) })
+	client := &Client{httpClient: server.Client(), baseURL: server.URL, model: "test-model"}
+	if _, err := client.Summarize(ctx, []string{"[10:00] ana: hola"}); err != nil {
+		t.Fatalf("Summarize returned error: %v", err)
+	}
+	if len(got) != 1 || got[0].TotalTokens != 128 {
+		t.Fatalf("unexpected usage reports %+v", got)
+	}
+
+	// Sin función en el contexto no se informa nada
+	if _, err := client.Summarize(context.Background(), []string{"[10:01] ana: adiós"}); err != nil {
+		t.Fatalf("Summarize returned error: %v", err)
+	}
+	if len(got) != 1 {
+		t.Fatalf("unexpected extra usage reports %+v", got)
+	}
+}
@@ -0,0 +1,125 @@
+package stt
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"walkie-backend/pkg/lang"
+	"walkie-backend/pkg/tracing"
+)
+
+const (
+	defaultLocalModel   = "whisper-1"
+	defaultLocalTimeout = 3 * time.Second
+)
+
+// LocalClient transcribe con un servidor de la red local compatible con
+// /v1/audio/transcriptions de OpenAI (whisper.cpp, faster-whisper-server...). Se usa
+// para clips cortos en los que no compensa la ida y vuelta al STT remoto.
+type LocalClient struct {
+	url        string
+	model      string
+	httpClient *http.Client
+}
+
+// NewLocalClientFromEnv crea el cliente con LOCAL_STT_URL (URL completa del endpoint) y
+// LOCAL_STT_MODEL; devuelve nil si LOCAL_STT_URL no está configurada
+func NewLocalClientFromEnv() *LocalClient {
+	url := strings.TrimSpace(os.Getenv("LOCAL_STT_URL"))
+	if url == "" {
+		return nil
+	}
+	model := strings.TrimSpace(os.Getenv("LOCAL_STT_MODEL"))
+	if model == "" {
+		model = defaultLocalModel
+	}
+	return &LocalClient{
+		url:        url,
+		model:      model,
+		httpClient: &http.Client{Timeout: defaultLocalTimeout},
+	}
+}
+
+type localTranscription struct {
+	Text string `json:"text"`
+}
+
+func (c *LocalClient) TranscribeAudio(ctx context.Context, audioData []byte, format string) (string, error) {
+	if len(audioData) == 0 {
+		return "", fmt.Errorf("audio vacío")
+	}
+	ctx, span := tracing.Start(ctx, "stt.local")
+	defer span.End()
+	span.SetAttr("audio.bytes", len(audioData))
+
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	part, err := form.CreateFormFile("file", "audio"+localExtension(format))
+	if err == nil {
+		_, err = part.Write(audioData)
+	}
+	if err == nil {
+		err = form.WriteField("model", c.model)
+	}
+	if err == nil {
+		err = form.WriteField("language", lang.FromContext(ctx))
+	}
+	if err == nil {
+		err = form.WriteField("response_format", "json")
+	}
+	if err == nil {
+		err = form.Close()
+	}
+	if err != nil {
+		span.RecordError(err)
+		return "", err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.url, &body)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", form.FormDataContentType())
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		span.RecordError(err)
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		raw, _ := io.ReadAll(resp.Body)
+		err := fmt.Errorf("HTTP %d: %s", resp.StatusCode, string(raw))
+		span.RecordError(err)
+		return "", err
+	}
+
+	var out localTranscription
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(out.Text), nil
+}
+
+// localExtension da al fichero subido la extensión que esperan estos servidores
+func localExtension(format string) string {
+	switch {
+	case strings.Contains(format, "ogg"):
+		return ".ogg"
+	case strings.Contains(format, "webm"):
+		return ".webm"
+	case strings.Contains(format, "flac"):
+		return ".flac"
+	default:
+		return ".wav"
+	}
+}
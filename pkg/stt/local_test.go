@@ -0,0 +1,65 @@
+package stt
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"walkie-backend/pkg/lang"
+)
+
+func TestNewLocalClientFromEnv(t *testing.T) {
+	t.Setenv("LOCAL_STT_URL", "")
+	if NewLocalClientFromEnv() != nil {
+		t.Fatal("expected no local client without LOCAL_STT_URL")
+	}
+	t.Setenv("LOCAL_STT_URL", "http://whisper:8080/v1/audio/transcriptions")
+	t.Setenv("LOCAL_STT_MODEL", "")
+	client := NewLocalClientFromEnv()
+	if client == nil || client.model != defaultLocalModel {
+		t.Fatalf("unexpected client %+v", client)
+	}
+}
+
+func TestLocalClient_TranscribeAudio(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseMultipartForm(1 << 20); err != nil {
+			t.Errorf("parse form: %v", err)
+		}
+		file, header, err := r.FormFile("file")
+		if err != nil {
+			t.Errorf("missing file: %v", err)
+		} else {
+			file.Close()
+			if header.Filename != "audio.wav" {
+				t.Errorf("unexpected filename %q", header.Filename)
+			}
+		}
+		if r.FormValue("model") != "small" || r.FormValue("language") != "en" {
+			t.Errorf("unexpected fields model=%q language=%q", r.FormValue("model"), r.FormValue("language"))
+		}
+		_, _ = w.Write([]byte(`{"text":" sí "}`))
+	}))
+	defer server.Close()
+
+	t.Setenv("LOCAL_STT_URL", server.URL)
+	t.Setenv("LOCAL_STT_MODEL", "small")
+	client := NewLocalClientFromEnv()
+	text, err := client.TranscribeAudio(lang.WithLanguage(context.Background(), "en"), wavHeader(), "audio/wav")
+	if err != nil || text != "sí" {
+		t.Fatalf("expected trimmed text, got %q (err=%v)", text, err)
+	}
+}
+
+func TestLocalClient_HTTPError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "modelo no cargado", http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	t.Setenv("LOCAL_STT_URL", server.URL)
+	if _, err := NewLocalClientFromEnv().TranscribeAudio(context.Background(), wavHeader(), "audio/wav"); err == nil {
+		t.Fatal("expected error on HTTP 503")
+	}
+}
@@ -0,0 +1,201 @@
+package stt
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gorilla/websocket"
+
+	"walkie-backend/pkg/tracing"
+)
+
+const (
+	defaultStreamingURL   = "wss://streaming.assemblyai.com/v3/ws"
+	defaultStreamingModel = "universal-streaming-multilingual"
+	wavHeaderSize         = 44
+	streamChunkDuration   = 100 * time.Millisecond
+	streamCloseTimeout    = 10 * time.Second
+)
+
+var ErrNotStreamableWAV = errors.New("el audio no es WAV PCM de 16 bits")
+
+// streamingMessage cubre los mensajes de la API de streaming v3 que nos interesan
+type streamingMessage struct {
+	Type       string `json:"type"`
+	Transcript string `json:"transcript"`
+	TurnOrder  int    `json:"turn_order"`
+	EndOfTurn  bool   `json:"end_of_turn"`
+	Error      string `json:"error"`
+}
+
+func streamingURLFromEnv() string {
+	if v := strings.TrimSpace(os.Getenv("ASSEMBLYAI_STREAMING_URL")); v != "" {
+		return v
+	}
+	return defaultStreamingURL
+}
+
+func streamingModelFromEnv() string {
+	if v := strings.TrimSpace(os.Getenv("ASSEMBLYAI_STREAMING_MODEL")); v != "" {
+		return v
+	}
+	return defaultStreamingModel
+}
+
+// TranscribeWAVStream transcribe un WAV PCM 16 bits mientras se va leyendo, usando la
+// API de streaming de AssemblyAI. Permite empezar a transcribir antes de que termine
+// la subida del cliente.
+func (c *Client) TranscribeWAVStream(ctx context.Context, r io.Reader) (string, error) {
+	header := make([]byte, wavHeaderSize)
+	if _, err := io.ReadFull(r, header); err != nil {
+		return "", fmt.Errorf("leer cabecera WAV: %w", err)
+	}
+	if string(header[0:4]) != "RIFF" || string(header[8:12]) != "WAVE" || binary.LittleEndian.Uint16(header[34:36]) != 16 {
+		return "", ErrNotStreamableWAV
+	}
+	sampleRate := int(binary.LittleEndian.Uint32(header[24:28]))
+	channels := int(binary.LittleEndian.Uint16(header[22:24]))
+	if sampleRate <= 0 || channels != 1 {
+		return "", ErrNotStreamableWAV
+	}
+
+	ctx, span := tracing.Start(ctx, "stt.stream")
+	defer span.End()
+	span.SetAttr("audio.sample_rate", sampleRate)
+
+	conn, err := c.dialStreaming(ctx, sampleRate)
+	if err != nil {
+		return "", fmt.Errorf("conectar streaming: %w", err)
+	}
+	defer conn.Close()
+
+	type readResult struct {
+		text string
+		err  error
+	}
+	done := make(chan readResult, 1)
+	go func() {
+		text, err := readStreamingTurns(conn)
+		done <- readResult{text, err}
+	}()
+
+	chunk := make([]byte, sampleRate*2*int(streamChunkDuration/time.Millisecond)/1000)
+	for {
+		n, readErr := io.ReadFull(r, chunk)
+		if n > 0 {
+			if err := conn.WriteMessage(websocket.BinaryMessage, chunk[:n]); err != nil {
+				return "", fmt.Errorf("enviar audio: %w", err)
+			}
+		}
+		if readErr == io.EOF || readErr == io.ErrUnexpectedEOF {
+			break
+		}
+		if readErr != nil {
+			return "", fmt.Errorf("leer audio: %w", readErr)
+		}
+		select {
+		case res := <-done:
+			if res.err != nil {
+				return "", res.err
+			}
+			return "", errors.New("la sesión de streaming terminó antes de tiempo")
+		case <-ctx.Done():
+			return "", ctx.Err()
+		default:
+		}
+	}
+
+	if err := conn.WriteJSON(map[string]string{"type": "Terminate"}); err != nil {
+		return "", fmt.Errorf("cerrar sesión: %w", err)
+	}
+
+	select {
+	case res := <-done:
+		return res.text, res.err
+	case <-ctx.Done():
+		return "", ctx.Err()
+	case <-time.After(streamCloseTimeout):
+		return "", errors.New("tiempo de espera agotado al cerrar la sesión de streaming")
+	}
+}
+
+func (c *Client) dialStreaming(ctx context.Context, sampleRate int) (*websocket.Conn, error) {
+	base := c.streamingURL
+	if base == "" {
+		base = streamingURLFromEnv()
+	}
+	u, err := url.Parse(base)
+	if err != nil {
+		return nil, err
+	}
+	q := u.Query()
+	q.Set("sample_rate", strconv.Itoa(sampleRate))
+	q.Set("encoding", "pcm_s16le")
+	q.Set("format_turns", "true")
+	model := c.streamingModel
+	if model == "" {
+		model = streamingModelFromEnv()
+	}
+	q.Set("speech_model", model)
+	u.RawQuery = q.Encode()
+
+	header := http.Header{}
+	header.Set("Authorization", c.apiKey)
+	conn, resp, err := websocket.DefaultDialer.DialContext(ctx, u.String(), header)
+	if err != nil {
+		if resp != nil {
+			return nil, fmt.Errorf("HTTP %d: %w", resp.StatusCode, err)
+		}
+		return nil, err
+	}
+	return conn, nil
+}
+
+// readStreamingTurns acumula los turnos finales hasta recibir Termination
+func readStreamingTurns(conn *websocket.Conn) (string, error) {
+	turns := make(map[int]string)
+	for {
+		var msg streamingMessage
+		if err := conn.ReadJSON(&msg); err != nil {
+			if websocket.IsCloseError(err, websocket.CloseNormalClosure) {
+				return joinTurns(turns), nil
+			}
+			return "", fmt.Errorf("leer transcripción: %w", err)
+		}
+		switch msg.Type {
+		case "Turn":
+			if msg.EndOfTurn {
+				turns[msg.TurnOrder] = strings.TrimSpace(msg.Transcript)
+			}
+		case "Termination":
+			return joinTurns(turns), nil
+		case "Error":
+			return "", fmt.Errorf("streaming: %s", msg.Error)
+		}
+	}
+}
+
+func joinTurns(turns map[int]string) string {
+	order := make([]int, 0, len(turns))
+	for k := range turns {
+		order = append(order, k)
+	}
+	sort.Ints(order)
+	parts := make([]string, 0, len(order))
+	for _, k := range order {
+		if turns[k] != "" {
+			parts = append(parts, turns[k])
+		}
+	}
+	return strings.Join(parts, " ")
+}
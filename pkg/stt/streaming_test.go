@@ -0,0 +1,98 @@
+package stt
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func streamingTestWAV(payload int) []byte {
+	data := make([]byte, 44+payload)
+	copy(data[0:4], "RIFF")
+	binary.LittleEndian.PutUint32(data[4:8], uint32(36+payload))
+	copy(data[8:12], "WAVE")
+	copy(data[12:16], "fmt ")
+	binary.LittleEndian.PutUint32(data[16:20], 16)
+	binary.LittleEndian.PutUint16(data[20:22], 1)
+	binary.LittleEndian.PutUint16(data[22:24], 1)
+	binary.LittleEndian.PutUint32(data[24:28], 16000)
+	binary.LittleEndian.PutUint32(data[28:32], 32000)
+	binary.LittleEndian.PutUint16(data[32:34], 2)
+	binary.LittleEndian.PutUint16(data[34:36], 16)
+	copy(data[36:40], "data")
+	binary.LittleEndian.PutUint32(data[40:44], uint32(payload))
+	return data
+}
+
+func TestTranscribeWAVStream(t *testing.T) {
+	var received int
+	var query string
+	upgrader := websocket.Upgrader{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "test-key", r.Header.Get("Authorization"))
+		query = r.URL.RawQuery
+		conn, err := upgrader.Upgrade(w, r, nil)
+		require.NoError(t, err)
+		defer conn.Close()
+
+		_ = conn.WriteJSON(map[string]any{"type": "Begin"})
+		for {
+			mt, data, err := conn.ReadMessage()
+			if err != nil {
+				return
+			}
+			if mt == websocket.BinaryMessage {
+				received += len(data)
+				continue
+			}
+			if strings.Contains(string(data), "Terminate") {
+				// Los turnos llegan desordenados y con parciales intermedios
+				_ = conn.WriteJSON(map[string]any{"type": "Turn", "turn_order": 1, "end_of_turn": true, "transcript": "canal dos"})
+				_ = conn.WriteJSON(map[string]any{"type": "Turn", "turn_order": 0, "end_of_turn": false, "transcript": "cambia"})
+				_ = conn.WriteJSON(map[string]any{"type": "Turn", "turn_order": 0, "end_of_turn": true, "transcript": "Cambia al"})
+				_ = conn.WriteJSON(map[string]any{"type": "Termination"})
+				return
+			}
+		}
+	}))
+	defer server.Close()
+
+	client := &Client{apiKey: "test-key", streamingURL: "ws" + strings.TrimPrefix(server.URL, "http"), streamingModel: "test-model"}
+	text, err := client.TranscribeWAVStream(context.Background(), bytes.NewReader(streamingTestWAV(10000)))
+
+	assert.NoError(t, err)
+	assert.Equal(t, "Cambia al canal dos", text)
+	assert.Equal(t, 10000, received)
+	assert.Contains(t, query, "sample_rate=16000")
+	assert.Contains(t, query, "encoding=pcm_s16le")
+	assert.Contains(t, query, "speech_model=test-model")
+}
+
+func TestTranscribeWAVStream_ServerError(t *testing.T) {
+	upgrader := websocket.Upgrader{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		require.NoError(t, err)
+		defer conn.Close()
+		_ = conn.WriteJSON(map[string]any{"type": "Error", "error": "clave inválida"})
+	}))
+	defer server.Close()
+
+	client := &Client{apiKey: "k", streamingURL: "ws" + strings.TrimPrefix(server.URL, "http")}
+	_, err := client.TranscribeWAVStream(context.Background(), bytes.NewReader(streamingTestWAV(64000)))
+	assert.Error(t, err)
+}
+
+func TestTranscribeWAVStream_RejectsNonPCM(t *testing.T) {
+	client := &Client{apiKey: "k", streamingURL: "ws://127.0.0.1:1"}
+	_, err := client.TranscribeWAVStream(context.Background(), strings.NewReader(strings.Repeat("x", 100)))
+	assert.ErrorIs(t, err, ErrNotStreamableWAV)
+}
@@ -12,12 +12,19 @@ import (
 	"os"
 	"strings"
 	"time"
+
+	"walkie-backend/pkg/lang"
+	"walkie-backend/pkg/tracing"
 )
 
+const defaultBaseURL = "https://api.assemblyai.com/v2"
+
 type Client struct {
-	apiKey     string
-	httpClient *http.Client
-	baseURL    string
+	apiKey         string
+	httpClient     *http.Client
+	baseURL        string
+	streamingURL   string
+	streamingModel string
 }
 
 type uploadResponse struct {
@@ -44,28 +51,70 @@ func NewClient() (*Client, error) {
 	}
 
 	return &Client{
-		apiKey:     apiKey,
-		httpClient: &http.Client{Timeout: 60 * time.Second},
-		baseURL:    "https://api.assemblyai.com/v2",
+		apiKey:         apiKey,
+		httpClient:     &http.Client{Timeout: 60 * time.Second},
+		baseURL:        baseURLFromEnv(),
+		streamingURL:   streamingURLFromEnv(),
+		streamingModel: streamingModelFromEnv(),
 	}, nil
 }
 
+// baseURLFromEnv permite apuntar ASSEMBLYAI_API_URL a otro servidor compatible, p. ej. el
+// simulado de cmd/loadtest
+func baseURLFromEnv() string {
+	if v := strings.TrimRight(strings.TrimSpace(os.Getenv("ASSEMBLYAI_API_URL")), "/"); v != "" {
+		return v
+	}
+	return defaultBaseURL
+}
+
+// Ping comprueba que la API responde y acepta la clave listando una transcripción
+func (c *Client) Ping(ctx context.Context) error {
+	req, err := http.NewRequestWithContext(ctx, "GET", c.baseURL+"/transcript?limit=1", nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", c.apiKey)
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("HTTP %d: %s", resp.StatusCode, string(body))
+	}
+	return nil
+}
+
 func (c *Client) TranscribeAudio(ctx context.Context, audioData []byte, format string) (string, error) {
 	if len(audioData) == 0 {
 		return "", fmt.Errorf("audio vacío")
 	}
 
-	uploadURL, err := c.uploadAudio(ctx, audioData, format)
+	spanCtx, span := tracing.Start(ctx, "stt.upload")
+	span.SetAttr("audio.bytes", len(audioData))
+	uploadURL, err := c.uploadAudio(spanCtx, audioData, format)
+	span.RecordError(err)
+	span.End()
 	if err != nil {
 		return "", fmt.Errorf("subir audio: %w", err)
 	}
 
-	transcriptID, err := c.createTranscript(ctx, uploadURL)
+	spanCtx, span = tracing.Start(ctx, "stt.create_transcript")
+	transcriptID, err := c.createTranscript(spanCtx, uploadURL)
+	span.RecordError(err)
+	span.End()
 	if err != nil {
 		return "", fmt.Errorf("crear transcripción: %w", err)
 	}
 
-	text, err := c.pollTranscript(ctx, transcriptID)
+	spanCtx, span = tracing.Start(ctx, "stt.poll")
+	text, err := c.pollTranscript(spanCtx, transcriptID)
+	span.RecordError(err)
+	span.End()
 	if err != nil {
 		return "", fmt.Errorf("obtener transcripción: %w", err)
 	}
@@ -100,11 +149,12 @@ func (c *Client) uploadAudio(ctx context.Context, audioData []byte, format strin
 	return upload.UploadURL, nil
 }
 
+// createTranscript pide la transcripción en el idioma del usuario (lang.WithLanguage, español por defecto)
 func (c *Client) createTranscript(ctx context.Context, audioURL string) (string, error) {
 	reqBody := transcriptRequest{
 		AudioURL:     audioURL,
 		SpeechModel:  "universal",
-		LanguageCode: "es",
+		LanguageCode: lang.FromContext(ctx),
 	}
 
 	jsonData, err := json.Marshal(reqBody)
@@ -184,13 +234,32 @@ func (c *Client) pollTranscript(ctx context.Context, transcriptID string) (strin
 	}
 }
 
+// SpeechThresholds son los umbrales del detector de voz: un clip WAV PCM16 tiene voz si
+// supera MinRMS de volumen medio o MinDelta de salto entre muestras consecutivas
+type SpeechThresholds struct {
+	MinRMS   float64
+	MinDelta int
+	// MinPayload es el mínimo de bytes de audio tras la cabecera
+	MinPayload int
+}
+
+// DefaultSpeechThresholds devuelve los umbrales con los que se calibró IsHumanSpeech
+func DefaultSpeechThresholds() SpeechThresholds {
+	return SpeechThresholds{MinRMS: 300, MinDelta: 250, MinPayload: 2000}
+}
+
 func (c *Client) IsHumanSpeech(audioData []byte) bool {
+	return DetectSpeech(audioData, DefaultSpeechThresholds())
+}
+
+// DetectSpeech aplica el detector de actividad de voz con los umbrales indicados
+func DetectSpeech(audioData []byte, th SpeechThresholds) bool {
 	if len(audioData) < 44 || string(audioData[:4]) != "RIFF" || string(audioData[8:12]) != "WAVE" {
 		return false
 	}
 
 	payload := audioData[44:]
-	if len(payload) < 2000 {
+	if len(payload) < th.MinPayload {
 		return false
 	}
 
@@ -209,7 +278,7 @@ func (c *Client) IsHumanSpeech(audioData []byte) bool {
 		sample := int16(binary.LittleEndian.Uint16(payload[i : i+2]))
 		sumSquares += float64(sample) * float64(sample)
 
-		delta := int(sample - prev)
+		delta := int(sample) - int(prev)
 		if delta < 0 {
 			delta = -delta
 		}
@@ -220,5 +289,5 @@ func (c *Client) IsHumanSpeech(audioData []byte) bool {
 	}
 
 	rms := math.Sqrt(sumSquares / float64(samples))
-	return rms > 300 || maxDelta > 250
+	return rms > th.MinRMS || maxDelta > th.MinDelta
 }
@@ -10,6 +10,8 @@ import (
 	"testing"
 	"time"
 
+	"walkie-backend/pkg/lang"
+
 	"github.com/stretchr/testify/assert"
 )
 
@@ -22,6 +24,18 @@ func TestNewClient(t *testing.T) {
 		assert.Equal(t, "test-api-key", client.apiKey)
 	})
 
+	t.Run("API URL override", func(t *testing.T) {
+		t.Setenv("ASSEMBLYAI_API_KEY", "test-api-key")
+		t.Setenv("ASSEMBLYAI_API_URL", "http://localhost:9099/v2/")
+		client, err := NewClient()
+		assert.NoError(t, err)
+		assert.Equal(t, "http://localhost:9099/v2", client.baseURL)
+
+		t.Setenv("ASSEMBLYAI_API_URL", "")
+		client, _ = NewClient()
+		assert.Equal(t, defaultBaseURL, client.baseURL)
+	})
+
 	t.Run("API key is not set", func(t *testing.T) {
 		t.Setenv("ASSEMBLYAI_API_KEY", "")
 		_, err := NewClient()
@@ -253,4 +267,23 @@ func TestTranscribeAudio_Timeout(t *testing.T) {
 	_, err = client.TranscribeAudio(context.Background(), []byte("some audio data"), "audio/wav")
 	assert.Error(t, err)
 	assert.Contains(t, err.Error(), "context deadline exceeded")
-}
\ No newline at end of file
+}
+
+func TestCreateTranscript_UsesContextLanguage(t *testing.T) {
+	var got transcriptRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(&got)
+		json.NewEncoder(w).Encode(transcriptResponse{ID: "id-1", Status: "queued"})
+	}))
+	defer server.Close()
+
+	client := &Client{apiKey: "test-key", baseURL: server.URL, httpClient: server.Client()}
+
+	_, err := client.createTranscript(context.Background(), "https://cdn/audio")
+	assert.NoError(t, err)
+	assert.Equal(t, "es", got.LanguageCode)
+
+	_, err = client.createTranscript(lang.WithLanguage(context.Background(), "en"), "https://cdn/audio")
+	assert.NoError(t, err)
+	assert.Equal(t, "en", got.LanguageCode)
+}
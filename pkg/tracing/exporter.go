@@ -0,0 +1,310 @@
+package tracing
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	defaultServiceName = "walkie-backend"
+	batchSize          = 256
+	queueSize          = 2048
+	flushInterval      = 5 * time.Second
+)
+
+// SpanData es un span terminado listo para exportar
+type SpanData struct {
+	TraceID  string
+	SpanID   string
+	ParentID string
+	Name     string
+	Start    time.Time
+	End      time.Time
+	Attrs    map[string]any
+	ErrorMsg string
+}
+
+// Tracer agrupa spans y los envía por lotes al colector OTLP
+type Tracer struct {
+	endpoint string
+	service  string
+	headers  map[string]string
+	client   *http.Client
+
+	queue   chan SpanData
+	flushCh chan chan struct{}
+	done    chan struct{}
+	stop    sync.Once
+	dropped atomic.Int64
+}
+
+// NewTracer crea un tracer que envía a endpoint (URL completa de /v1/traces)
+func NewTracer(endpoint, service string, headers map[string]string) *Tracer {
+	if service == "" {
+		service = defaultServiceName
+	}
+	t := &Tracer{
+		endpoint: endpoint,
+		service:  service,
+		headers:  headers,
+		client:   &http.Client{Timeout: 10 * time.Second},
+		queue:    make(chan SpanData, queueSize),
+		flushCh:  make(chan chan struct{}),
+		done:     make(chan struct{}),
+	}
+	go t.loop()
+	return t
+}
+
+// InitFromEnv configura el tracer global con las variables estándar de OTel:
+// OTEL_EXPORTER_OTLP_TRACES_ENDPOINT u OTEL_EXPORTER_OTLP_ENDPOINT (+ /v1/traces),
+// OTEL_SERVICE_NAME y OTEL_EXPORTER_OTLP_HEADERS ("k=v,k2=v2"). Devuelve nil si no hay endpoint.
+func InitFromEnv() *Tracer {
+	endpoint := strings.TrimSpace(os.Getenv("OTEL_EXPORTER_OTLP_TRACES_ENDPOINT"))
+	if endpoint == "" {
+		base := strings.TrimRight(strings.TrimSpace(os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT")), "/")
+		if base == "" {
+			return nil
+		}
+		endpoint = base + "/v1/traces"
+	}
+	t := NewTracer(endpoint, strings.TrimSpace(os.Getenv("OTEL_SERVICE_NAME")), parseHeaders(os.Getenv("OTEL_EXPORTER_OTLP_HEADERS")))
+	SetGlobal(t)
+	log.Printf("tracing: exportando spans a %s", endpoint)
+	return t
+}
+
+func parseHeaders(raw string) map[string]string {
+	headers := make(map[string]string)
+	for _, pair := range strings.Split(raw, ",") {
+		k, v, ok := strings.Cut(pair, "=")
+		if ok && strings.TrimSpace(k) != "" {
+			headers[strings.TrimSpace(k)] = strings.TrimSpace(v)
+		}
+	}
+	return headers
+}
+
+func (t *Tracer) export(s SpanData) {
+	select {
+	case t.queue <- s:
+	default:
+		// No bloquear la petición si el colector va lento
+		t.dropped.Add(1)
+	}
+}
+
+// Flush envía los spans pendientes y espera a que termine el envío
+func (t *Tracer) Flush(ctx context.Context) {
+	ack := make(chan struct{})
+	select {
+	case t.flushCh <- ack:
+	case <-ctx.Done():
+		return
+	case <-t.done:
+		return
+	}
+	select {
+	case <-ack:
+	case <-ctx.Done():
+	}
+}
+
+// Shutdown vacía la cola y detiene el envío
+func (t *Tracer) Shutdown(ctx context.Context) {
+	t.Flush(ctx)
+	t.stop.Do(func() { close(t.done) })
+}
+
+func (t *Tracer) loop() {
+	ticker := time.NewTicker(flushInterval)
+	defer ticker.Stop()
+
+	batch := make([]SpanData, 0, batchSize)
+	send := func() {
+		if len(batch) == 0 {
+			return
+		}
+		if n := t.dropped.Swap(0); n > 0 {
+			log.Printf("tracing: %d spans descartados por cola llena", n)
+		}
+		if err := t.send(batch); err != nil {
+			log.Printf("tracing: envío fallido (%d spans): %v", len(batch), err)
+		}
+		batch = batch[:0]
+	}
+	drain := func() {
+		for {
+			select {
+			case s := <-t.queue:
+				batch = append(batch, s)
+				if len(batch) >= batchSize {
+					send()
+				}
+			default:
+				return
+			}
+		}
+	}
+
+	for {
+		select {
+		case s := <-t.queue:
+			batch = append(batch, s)
+			if len(batch) >= batchSize {
+				send()
+			}
+		case <-ticker.C:
+			send()
+		case ack := <-t.flushCh:
+			drain()
+			send()
+			close(ack)
+		case <-t.done:
+			return
+		}
+	}
+}
+
+func (t *Tracer) send(spans []SpanData) error {
+	body, err := json.Marshal(t.payload(spans))
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest(http.MethodPost, t.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range t.headers {
+		req.Header.Set(k, v)
+	}
+	resp, err := t.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("colector respondió %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// Estructuras OTLP/JSON (opentelemetry-proto, codificación JSON)
+type otlpValue struct {
+	StringValue *string  `json:"stringValue,omitempty"`
+	BoolValue   *bool    `json:"boolValue,omitempty"`
+	IntValue    *string  `json:"intValue,omitempty"`
+	DoubleValue *float64 `json:"doubleValue,omitempty"`
+}
+
+type otlpAttr struct {
+	Key   string    `json:"key"`
+	Value otlpValue `json:"value"`
+}
+
+type otlpStatus struct {
+	Code    int    `json:"code"`
+	Message string `json:"message,omitempty"`
+}
+
+type otlpSpan struct {
+	TraceID           string     `json:"traceId"`
+	SpanID            string     `json:"spanId"`
+	ParentSpanID      string     `json:"parentSpanId,omitempty"`
+	Name              string     `json:"name"`
+	Kind              int        `json:"kind"`
+	StartTimeUnixNano string     `json:"startTimeUnixNano"`
+	EndTimeUnixNano   string     `json:"endTimeUnixNano"`
+	Attributes        []otlpAttr `json:"attributes,omitempty"`
+	Status            otlpStatus `json:"status"`
+}
+
+type otlpScopeSpans struct {
+	Scope struct {
+		Name string `json:"name"`
+	} `json:"scope"`
+	Spans []otlpSpan `json:"spans"`
+}
+
+type otlpResourceSpans struct {
+	Resource struct {
+		Attributes []otlpAttr `json:"attributes"`
+	} `json:"resource"`
+	ScopeSpans []otlpScopeSpans `json:"scopeSpans"`
+}
+
+type otlpRequest struct {
+	ResourceSpans []otlpResourceSpans `json:"resourceSpans"`
+}
+
+const (
+	spanKindInternal = 1
+	statusOK         = 1
+	statusError      = 2
+)
+
+func (t *Tracer) payload(spans []SpanData) otlpRequest {
+	scope := otlpScopeSpans{Spans: make([]otlpSpan, 0, len(spans))}
+	scope.Scope.Name = t.service
+	for _, s := range spans {
+		out := otlpSpan{
+			TraceID:           s.TraceID,
+			SpanID:            s.SpanID,
+			ParentSpanID:      s.ParentID,
+			Name:              s.Name,
+			Kind:              spanKindInternal,
+			StartTimeUnixNano: strconv.FormatInt(s.Start.UnixNano(), 10),
+			EndTimeUnixNano:   strconv.FormatInt(s.End.UnixNano(), 10),
+			Attributes:        toAttrs(s.Attrs),
+			Status:            otlpStatus{Code: statusOK},
+		}
+		if s.ErrorMsg != "" {
+			out.Status = otlpStatus{Code: statusError, Message: s.ErrorMsg}
+		}
+		scope.Spans = append(scope.Spans, out)
+	}
+
+	rs := otlpResourceSpans{ScopeSpans: []otlpScopeSpans{scope}}
+	rs.Resource.Attributes = toAttrs(map[string]any{"service.name": t.service})
+	return otlpRequest{ResourceSpans: []otlpResourceSpans{rs}}
+}
+
+func toAttrs(m map[string]any) []otlpAttr {
+	attrs := make([]otlpAttr, 0, len(m))
+	for k, v := range m {
+		var val otlpValue
+		switch x := v.(type) {
+		case string:
+			val.StringValue = &x
+		case bool:
+			val.BoolValue = &x
+		case int:
+			s := strconv.Itoa(x)
+			val.IntValue = &s
+		case int64:
+			s := strconv.FormatInt(x, 10)
+			val.IntValue = &s
+		case uint:
+			s := strconv.FormatUint(uint64(x), 10)
+			val.IntValue = &s
+		case float64:
+			val.DoubleValue = &x
+		default:
+			s := fmt.Sprint(x)
+			val.StringValue = &s
+		}
+		attrs = append(attrs, otlpAttr{Key: k, Value: val})
+	}
+	return attrs
+}
@@ -0,0 +1,175 @@
+// Package tracing emite spans en formato OTLP/HTTP JSON sin depender del SDK de
+// OpenTelemetry. Si no hay endpoint configurado todas las operaciones son no-op.
+package tracing
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+type spanKey struct{}
+
+// Span es una operación con inicio y fin; un *Span nil es válido y no hace nada
+type Span struct {
+	tracer   *Tracer
+	traceID  [16]byte
+	spanID   [8]byte
+	parentID [8]byte
+	name     string
+	start    time.Time
+
+	mu     sync.Mutex
+	attrs  map[string]any
+	errMsg string
+	ended  bool
+}
+
+var (
+	globalMu sync.RWMutex
+	global   *Tracer
+)
+
+// SetGlobal instala el tracer usado por Start; nil desactiva el tracing
+func SetGlobal(t *Tracer) {
+	globalMu.Lock()
+	global = t
+	globalMu.Unlock()
+}
+
+func current() *Tracer {
+	globalMu.RLock()
+	defer globalMu.RUnlock()
+	return global
+}
+
+// Start abre un span hijo del que haya en ctx
+func Start(ctx context.Context, name string) (context.Context, *Span) {
+	return StartAt(ctx, name, time.Now())
+}
+
+// StartAt abre un span con una hora de inicio explícita, útil para etapas ya medidas
+func StartAt(ctx context.Context, name string, start time.Time) (context.Context, *Span) {
+	t := current()
+	if t == nil {
+		return ctx, nil
+	}
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	s := &Span{tracer: t, name: name, start: start}
+	if parent := FromContext(ctx); parent != nil {
+		s.traceID = parent.traceID
+		s.parentID = parent.spanID
+	} else if remote, ok := ctx.Value(remoteKey{}).(remoteParent); ok {
+		s.traceID = remote.traceID
+		s.parentID = remote.spanID
+	} else {
+		_, _ = rand.Read(s.traceID[:])
+	}
+	_, _ = rand.Read(s.spanID[:])
+	return context.WithValue(ctx, spanKey{}, s), s
+}
+
+// FromContext devuelve el span activo o nil
+func FromContext(ctx context.Context) *Span {
+	if ctx == nil {
+		return nil
+	}
+	s, _ := ctx.Value(spanKey{}).(*Span)
+	return s
+}
+
+// SetAttr añade un atributo (string, bool, enteros o float64)
+func (s *Span) SetAttr(key string, value any) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	if s.attrs == nil {
+		s.attrs = make(map[string]any)
+	}
+	s.attrs[key] = value
+	s.mu.Unlock()
+}
+
+// RecordError marca el span como fallido
+func (s *Span) RecordError(err error) {
+	if s == nil || err == nil {
+		return
+	}
+	s.mu.Lock()
+	s.errMsg = err.Error()
+	s.mu.Unlock()
+}
+
+// End cierra el span y lo entrega al exportador; llamadas repetidas se ignoran
+func (s *Span) End() {
+	s.EndAt(time.Now())
+}
+
+// EndAt cierra el span con una hora de fin explícita
+func (s *Span) EndAt(end time.Time) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	if s.ended {
+		s.mu.Unlock()
+		return
+	}
+	s.ended = true
+	data := SpanData{
+		TraceID:  hex.EncodeToString(s.traceID[:]),
+		SpanID:   hex.EncodeToString(s.spanID[:]),
+		Name:     s.name,
+		Start:    s.start,
+		End:      end,
+		Attrs:    s.attrs,
+		ErrorMsg: s.errMsg,
+	}
+	if s.parentID != ([8]byte{}) {
+		data.ParentID = hex.EncodeToString(s.parentID[:])
+	}
+	s.mu.Unlock()
+	s.tracer.export(data)
+}
+
+// TraceParent devuelve la cabecera W3C traceparent del span
+func (s *Span) TraceParent() string {
+	if s == nil {
+		return ""
+	}
+	return fmt.Sprintf("00-%s-%s-01", hex.EncodeToString(s.traceID[:]), hex.EncodeToString(s.spanID[:]))
+}
+
+type remoteKey struct{}
+
+type remoteParent struct {
+	traceID [16]byte
+	spanID  [8]byte
+}
+
+// Extract continúa la traza del cliente si envía una cabecera traceparent válida
+func Extract(ctx context.Context, h http.Header) context.Context {
+	parts := strings.Split(strings.TrimSpace(h.Get("traceparent")), "-")
+	if len(parts) != 4 || len(parts[1]) != 32 || len(parts[2]) != 16 {
+		return ctx
+	}
+	var rp remoteParent
+	if _, err := hex.Decode(rp.traceID[:], []byte(parts[1])); err != nil {
+		return ctx
+	}
+	if _, err := hex.Decode(rp.spanID[:], []byte(parts[2])); err != nil {
+		return ctx
+	}
+	if rp.traceID == ([16]byte{}) || rp.spanID == ([8]byte{}) {
+		return ctx
+	}
+	return context.WithValue(ctx, remoteKey{}, rp)
+}
@@ -0,0 +1,84 @@
+package tracing
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStart_NoopWithoutTracer(t *testing.T) {
+	SetGlobal(nil)
+	ctx, span := Start(context.Background(), "nada")
+	assert.Nil(t, span)
+	assert.Nil(t, FromContext(ctx))
+	span.SetAttr("k", "v")
+	span.RecordError(errors.New("x"))
+	span.End()
+}
+
+func TestTracer_ExportsOTLPJSON(t *testing.T) {
+	var mu sync.Mutex
+	var received otlpRequest
+	var auth string
+	collector := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/v1/traces", r.URL.Path)
+		mu.Lock()
+		defer mu.Unlock()
+		auth = r.Header.Get("Authorization")
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&received))
+	}))
+	defer collector.Close()
+
+	t.Setenv("OTEL_EXPORTER_OTLP_TRACES_ENDPOINT", "")
+	t.Setenv("OTEL_EXPORTER_OTLP_ENDPOINT", collector.URL)
+	t.Setenv("OTEL_SERVICE_NAME", "walkie-test")
+	t.Setenv("OTEL_EXPORTER_OTLP_HEADERS", "Authorization=Bearer abc")
+	tracer := InitFromEnv()
+	require.NotNil(t, tracer)
+	defer SetGlobal(nil)
+
+	header := http.Header{}
+	header.Set("traceparent", "00-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-01")
+	ctx, root := Start(Extract(context.Background(), header), "audio.ingest")
+	_, child := StartAt(ctx, "stt", time.Now().Add(-time.Second))
+	child.SetAttr("text_len", 12)
+	child.RecordError(errors.New("timeout"))
+	child.End()
+	root.End()
+
+	flushCtx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	tracer.Shutdown(flushCtx)
+
+	mu.Lock()
+	defer mu.Unlock()
+	assert.Equal(t, "Bearer abc", auth)
+	require.Len(t, received.ResourceSpans, 1)
+	spans := received.ResourceSpans[0].ScopeSpans[0].Spans
+	require.Len(t, spans, 2)
+
+	byName := map[string]otlpSpan{}
+	for _, s := range spans {
+		byName[s.Name] = s
+	}
+	assert.Equal(t, "0af7651916cd43dd8448eb211c80319c", byName["audio.ingest"].TraceID)
+	assert.Equal(t, "b7ad6b7169203331", byName["audio.ingest"].ParentSpanID)
+	assert.Equal(t, byName["audio.ingest"].SpanID, byName["stt"].ParentSpanID)
+	assert.Equal(t, statusError, byName["stt"].Status.Code)
+	require.Len(t, byName["stt"].Attributes, 1)
+	assert.Equal(t, "12", *byName["stt"].Attributes[0].Value.IntValue)
+}
+
+func TestInitFromEnv_DisabledWithoutEndpoint(t *testing.T) {
+	t.Setenv("OTEL_EXPORTER_OTLP_TRACES_ENDPOINT", "")
+	t.Setenv("OTEL_EXPORTER_OTLP_ENDPOINT", "")
+	assert.Nil(t, InitFromEnv())
+}
@@ -0,0 +1,112 @@
+package tts
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"walkie-backend/pkg/tracing"
+)
+
+const (
+	defaultBaseURL = "https://api.openai.com/v1"
+	defaultModel   = "tts-1"
+	defaultVoice   = "alloy"
+
+	// maxAudioBytes limita la respuesta del proveedor (unos minutos de WAV)
+	maxAudioBytes = 10 << 20
+)
+
+// Client sintetiza voz con una API compatible con /audio/speech de OpenAI
+type Client struct {
+	httpClient *http.Client
+	baseURL    string
+	apiKey     string
+	model      string
+	voice      string
+}
+
+type speechRequest struct {
+	Model          string `json:"model"`
+	Input          string `json:"input"`
+	Voice          string `json:"voice"`
+	ResponseFormat string `json:"response_format"`
+}
+
+func envOr(key, fallback string) string {
+	if v := strings.TrimSpace(os.Getenv(key)); v != "" {
+		return v
+	}
+	return fallback
+}
+
+// NewClient usa TTS_API_KEY (obligatoria), TTS_API_URL, TTS_MODEL y TTS_VOICE
+func NewClient() (*Client, error) {
+	apiKey := strings.TrimSpace(os.Getenv("TTS_API_KEY"))
+	if apiKey == "" {
+		return nil, fmt.Errorf("TTS_API_KEY no está configurada")
+	}
+	return &Client{
+		httpClient: &http.Client{Timeout: 60 * time.Second},
+		baseURL:    strings.TrimRight(envOr("TTS_API_URL", defaultBaseURL), "/"),
+		apiKey:     apiKey,
+		model:      envOr("TTS_MODEL", defaultModel),
+		voice:      envOr("TTS_VOICE", defaultVoice),
+	}, nil
+}
+
+// Synthesize devuelve el texto leído en voz alta como WAV
+func (c *Client) Synthesize(ctx context.Context, text string) ([]byte, error) {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return nil, fmt.Errorf("texto vacío")
+	}
+
+	ctx, span := tracing.Start(ctx, "tts.synthesize")
+	defer span.End()
+	span.SetAttr("tts.model", c.model)
+	span.SetAttr("tts.chars", len(text))
+
+	body, err := json.Marshal(speechRequest{Model: c.model, Input: text, Voice: c.voice, ResponseFormat: "wav"})
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.baseURL+"/audio/speech", bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "Bearer "+c.apiKey)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		span.RecordError(err)
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 4<<10))
+		err := fmt.Errorf("HTTP %d: %s", resp.StatusCode, string(msg))
+		span.RecordError(err)
+		return nil, err
+	}
+	audio, err := io.ReadAll(io.LimitReader(resp.Body, maxAudioBytes+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(audio) > maxAudioBytes {
+		return nil, fmt.Errorf("audio sintetizado demasiado grande")
+	}
+	if len(audio) == 0 {
+		return nil, fmt.Errorf("audio sintetizado vacío")
+	}
+	span.SetAttr("audio.bytes", len(audio))
+	return audio, nil
+}
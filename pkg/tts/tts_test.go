@@ -0,0 +1,64 @@
+package tts
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestNewClient_RequiresKey(t *testing.T) {
+	t.Setenv("TTS_API_KEY", "")
+	if _, err := NewClient(); err == nil {
+		t.Fatal("expected error without TTS_API_KEY")
+	}
+}
+
+func TestSynthesize_PostsSpeechRequest(t *testing.T) {
+	var got speechRequest
+	var auth, path string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auth, path = r.Header.Get("Authorization"), r.URL.Path
+		_ = json.NewDecoder(r.Body).Decode(&got)
+		_, _ = w.Write([]byte("RIFF-audio"))
+	}))
+	t.Cleanup(server.Close)
+
+	t.Setenv("TTS_API_KEY", "clave")
+	t.Setenv("TTS_API_URL", server.URL+"/v1/")
+	t.Setenv("TTS_VOICE", "nova")
+	client, err := NewClient()
+	if err != nil {
+		t.Fatalf("NewClient: %v", err)
+	}
+	audio, err := client.Synthesize(context.Background(), "  Todo en orden en el almacén ")
+	if err != nil {
+		t.Fatalf("Synthesize: %v", err)
+	}
+	if string(audio) != "RIFF-audio" {
+		t.Errorf("unexpected audio %q", audio)
+	}
+	if auth != "Bearer clave" || path != "/v1/audio/speech" {
+		t.Errorf("unexpected request auth=%q path=%q", auth, path)
+	}
+	want := speechRequest{Model: defaultModel, Input: "Todo en orden en el almacén", Voice: "nova", ResponseFormat: "wav"}
+	if got != want {
+		t.Errorf("unexpected body %+v", got)
+	}
+}
+
+func TestSynthesize_ProviderError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "sin saldo", http.StatusPaymentRequired)
+	}))
+	t.Cleanup(server.Close)
+
+	client := &Client{httpClient: server.Client(), baseURL: server.URL, apiKey: "k", model: defaultModel, voice: defaultVoice}
+	if _, err := client.Synthesize(context.Background(), "hola"); err == nil {
+		t.Fatal("expected provider error")
+	}
+	if _, err := client.Synthesize(context.Background(), " "); err == nil {
+		t.Fatal("expected error for empty text")
+	}
+}
@@ -0,0 +1,144 @@
+// Package twilio cubre lo necesario para atender llamadas de Twilio Programmable Voice:
+// validar la firma de sus webhooks, responder TwiML y leer los mensajes de Media Streams.
+package twilio
+
+import (
+	"crypto/hmac"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/xml"
+	"net/url"
+	"sort"
+	"strings"
+)
+
+// ValidateSignature comprueba la cabecera X-Twilio-Signature: HMAC-SHA1 con el auth token
+// de la URL completa seguida de cada parámetro POST (nombre y valor) en orden alfabético
+func ValidateSignature(authToken, fullURL string, params url.Values, signature string) bool {
+	if authToken == "" || signature == "" {
+		return false
+	}
+	keys := make([]string, 0, len(params))
+	for k := range params {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var b strings.Builder
+	b.WriteString(fullURL)
+	for _, k := range keys {
+		for _, v := range params[k] {
+			b.WriteString(k)
+			b.WriteString(v)
+		}
+	}
+	mac := hmac.New(sha1.New, []byte(authToken))
+	mac.Write([]byte(b.String()))
+	expected := base64.StdEncoding.EncodeToString(mac.Sum(nil))
+	return hmac.Equal([]byte(expected), []byte(signature))
+}
+
+// Response es el documento TwiML que se devuelve a un webhook de voz
+type Response struct {
+	XMLName xml.Name `xml:"Response"`
+	Verbs   []any
+}
+
+// Say lee un texto al llamante
+type Say struct {
+	XMLName  xml.Name `xml:"Say"`
+	Language string   `xml:"language,attr,omitempty"`
+	Text     string   `xml:",chardata"`
+}
+
+// Gather recoge dígitos marcados y los envía por POST a Action
+type Gather struct {
+	XMLName     xml.Name `xml:"Gather"`
+	Input       string   `xml:"input,attr,omitempty"`
+	Action      string   `xml:"action,attr"`
+	Method      string   `xml:"method,attr,omitempty"`
+	FinishOnKey string   `xml:"finishOnKey,attr,omitempty"`
+	Timeout     int      `xml:"timeout,attr,omitempty"`
+	Prompt      *Say
+}
+
+// Redirect continúa la llamada con otro webhook
+type Redirect struct {
+	XMLName xml.Name `xml:"Redirect"`
+	Method  string   `xml:"method,attr,omitempty"`
+	URL     string   `xml:",chardata"`
+}
+
+// Hangup cuelga la llamada
+type Hangup struct {
+	XMLName xml.Name `xml:"Hangup"`
+}
+
+// Connect une la llamada a un Media Stream bidireccional
+type Connect struct {
+	XMLName xml.Name `xml:"Connect"`
+	Stream  Stream
+}
+
+// Stream es el WebSocket al que Twilio envía y del que recibe el audio de la llamada
+type Stream struct {
+	XMLName    xml.Name `xml:"Stream"`
+	URL        string   `xml:"url,attr"`
+	Parameters []Parameter
+}
+
+// Parameter llega al WebSocket en start.customParameters
+type Parameter struct {
+	XMLName xml.Name `xml:"Parameter"`
+	Name    string   `xml:"name,attr"`
+	Value   string   `xml:"value,attr"`
+}
+
+// Marshal serializa el TwiML con la declaración XML
+func (r Response) Marshal() []byte {
+	out, _ := xml.Marshal(r)
+	return append([]byte(xml.Header), out...)
+}
+
+// StreamMessage es un mensaje que Twilio envía por el WebSocket de Media Streams
+// (connected, start, media, dtmf, mark o stop)
+type StreamMessage struct {
+	Event     string       `json:"event"`
+	StreamSid string       `json:"streamSid,omitempty"`
+	Start     *StreamStart `json:"start,omitempty"`
+	Media     *StreamMedia `json:"media,omitempty"`
+}
+
+// StreamStart describe el stream al empezar
+type StreamStart struct {
+	StreamSid        string            `json:"streamSid"`
+	CallSid          string            `json:"callSid"`
+	CustomParameters map[string]string `json:"customParameters"`
+	MediaFormat      struct {
+		Encoding   string `json:"encoding"`
+		SampleRate int    `json:"sampleRate"`
+		Channels   int    `json:"channels"`
+	} `json:"mediaFormat"`
+}
+
+// StreamMedia lleva 20 ms de audio μ-law a 8 kHz en base64
+type StreamMedia struct {
+	Track   string `json:"track,omitempty"`
+	Payload string `json:"payload"`
+}
+
+// Audio decodifica el payload
+func (m *StreamMedia) Audio() ([]byte, error) {
+	return base64.StdEncoding.DecodeString(m.Payload)
+}
+
+// MediaMessage arma el mensaje que reproduce audio μ-law en la llamada
+func MediaMessage(streamSid string, mulaw []byte) []byte {
+	out, _ := json.Marshal(StreamMessage{
+		Event:     "media",
+		StreamSid: streamSid,
+		Media:     &StreamMedia{Payload: base64.StdEncoding.EncodeToString(mulaw)},
+	})
+	return out
+}
@@ -0,0 +1,44 @@
+package twilio
+
+import (
+	"crypto/hmac"
+	"crypto/sha1"
+	"encoding/base64"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func sign(token, data string) string {
+	mac := hmac.New(sha1.New, []byte(token))
+	mac.Write([]byte(data))
+	return base64.StdEncoding.EncodeToString(mac.Sum(nil))
+}
+
+func TestValidateSignature_SortsParams(t *testing.T) {
+	params := url.Values{"To": {"+34910000000"}, "CallSid": {"CA123"}, "From": {"+34600111222"}}
+	fullURL := "https://walkie.example.com/telephony/twilio/voice"
+	sig := sign("secreto", fullURL+"CallSidCA123From+34600111222To+34910000000")
+
+	if !ValidateSignature("secreto", fullURL, params, sig) {
+		t.Fatal("expected a valid signature")
+	}
+	if ValidateSignature("otro", fullURL, params, sig) {
+		t.Fatal("expected the signature to depend on the auth token")
+	}
+	params.Set("Digits", "1234")
+	if ValidateSignature("secreto", fullURL, params, sig) {
+		t.Fatal("expected added params to invalidate the signature")
+	}
+}
+
+func TestResponse_MarshalsStreamTwiML(t *testing.T) {
+	out := string(Response{Verbs: []any{
+		Say{Language: "es-ES", Text: "Conectando"},
+		Connect{Stream: Stream{URL: "wss://walkie.example.com/s", Parameters: []Parameter{{Name: "token", Value: "abc"}}}},
+	}}.Marshal())
+	want := `<Response><Say language="es-ES">Conectando</Say><Connect><Stream url="wss://walkie.example.com/s"><Parameter name="token" value="abc"></Parameter></Stream></Connect></Response>`
+	if !strings.HasPrefix(out, "<?xml") || !strings.Contains(out, want) {
+		t.Fatalf("unexpected TwiML %s", out)
+	}
+}
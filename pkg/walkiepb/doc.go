@@ -0,0 +1,5 @@
+// Package walkiepb contiene el código generado a partir de walkie.proto, la API gRPC
+// para consolas de despacho y bots. Tras cambiar el .proto, regenera con go generate.
+package walkiepb
+
+//go:generate protoc --go_out=. --go_opt=paths=source_relative --go-grpc_out=. --go-grpc_opt=paths=source_relative walkie.proto
@@ -0,0 +1,717 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.6
+// 	protoc        (unknown)
+// source: walkie.proto
+
+package walkiepb
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type AuthenticateRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Nombre        string                 `protobuf:"bytes,1,opt,name=nombre,proto3" json:"nombre,omitempty"`
+	Pin           int32                  `protobuf:"varint,2,opt,name=pin,proto3" json:"pin,omitempty"`
+	Dispositivo   string                 `protobuf:"bytes,3,opt,name=dispositivo,proto3" json:"dispositivo,omitempty"`
+	Plataforma    string                 `protobuf:"bytes,4,opt,name=plataforma,proto3" json:"plataforma,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *AuthenticateRequest) Reset() {
+	*x = AuthenticateRequest{}
+	mi := &file_walkie_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AuthenticateRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AuthenticateRequest) ProtoMessage() {}
+
+func (x *AuthenticateRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_walkie_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AuthenticateRequest.ProtoReflect.Descriptor instead.
+func (*AuthenticateRequest) Descriptor() ([]byte, []int) {
+	return file_walkie_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *AuthenticateRequest) GetNombre() string {
+	if x != nil {
+		return x.Nombre
+	}
+	return ""
+}
+
+func (x *AuthenticateRequest) GetPin() int32 {
+	if x != nil {
+		return x.Pin
+	}
+	return 0
+}
+
+func (x *AuthenticateRequest) GetDispositivo() string {
+	if x != nil {
+		return x.Dispositivo
+	}
+	return ""
+}
+
+func (x *AuthenticateRequest) GetPlataforma() string {
+	if x != nil {
+		return x.Plataforma
+	}
+	return ""
+}
+
+type AuthenticateResponse struct {
+	state     protoimpl.MessageState `protogen:"open.v1"`
+	Message   string                 `protobuf:"bytes,1,opt,name=message,proto3" json:"message,omitempty"`
+	Token     string                 `protobuf:"bytes,2,opt,name=token,proto3" json:"token,omitempty"`
+	SessionId uint64                 `protobuf:"varint,3,opt,name=session_id,json=sessionId,proto3" json:"session_id,omitempty"`
+	// Los JWT van vacíos si el servidor no tiene keyring
+	AccessToken   string `protobuf:"bytes,4,opt,name=access_token,json=accessToken,proto3" json:"access_token,omitempty"`
+	RefreshToken  string `protobuf:"bytes,5,opt,name=refresh_token,json=refreshToken,proto3" json:"refresh_token,omitempty"`
+	ExpiresIn     int64  `protobuf:"varint,6,opt,name=expires_in,json=expiresIn,proto3" json:"expires_in,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *AuthenticateResponse) Reset() {
+	*x = AuthenticateResponse{}
+	mi := &file_walkie_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AuthenticateResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AuthenticateResponse) ProtoMessage() {}
+
+func (x *AuthenticateResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_walkie_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AuthenticateResponse.ProtoReflect.Descriptor instead.
+func (*AuthenticateResponse) Descriptor() ([]byte, []int) {
+	return file_walkie_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *AuthenticateResponse) GetMessage() string {
+	if x != nil {
+		return x.Message
+	}
+	return ""
+}
+
+func (x *AuthenticateResponse) GetToken() string {
+	if x != nil {
+		return x.Token
+	}
+	return ""
+}
+
+func (x *AuthenticateResponse) GetSessionId() uint64 {
+	if x != nil {
+		return x.SessionId
+	}
+	return 0
+}
+
+func (x *AuthenticateResponse) GetAccessToken() string {
+	if x != nil {
+		return x.AccessToken
+	}
+	return ""
+}
+
+func (x *AuthenticateResponse) GetRefreshToken() string {
+	if x != nil {
+		return x.RefreshToken
+	}
+	return ""
+}
+
+func (x *AuthenticateResponse) GetExpiresIn() int64 {
+	if x != nil {
+		return x.ExpiresIn
+	}
+	return 0
+}
+
+type ConnectChannelRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Channel       string                 `protobuf:"bytes,1,opt,name=channel,proto3" json:"channel,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ConnectChannelRequest) Reset() {
+	*x = ConnectChannelRequest{}
+	mi := &file_walkie_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ConnectChannelRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ConnectChannelRequest) ProtoMessage() {}
+
+func (x *ConnectChannelRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_walkie_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ConnectChannelRequest.ProtoReflect.Descriptor instead.
+func (*ConnectChannelRequest) Descriptor() ([]byte, []int) {
+	return file_walkie_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *ConnectChannelRequest) GetChannel() string {
+	if x != nil {
+		return x.Channel
+	}
+	return ""
+}
+
+type DisconnectChannelRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DisconnectChannelRequest) Reset() {
+	*x = DisconnectChannelRequest{}
+	mi := &file_walkie_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DisconnectChannelRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DisconnectChannelRequest) ProtoMessage() {}
+
+func (x *DisconnectChannelRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_walkie_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DisconnectChannelRequest.ProtoReflect.Descriptor instead.
+func (*DisconnectChannelRequest) Descriptor() ([]byte, []int) {
+	return file_walkie_proto_rawDescGZIP(), []int{3}
+}
+
+// CommandResponse es la respuesta de los comandos de canal; data_json lleva los
+// mismos datos que el campo "data" de la API HTTP
+type CommandResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Status        string                 `protobuf:"bytes,1,opt,name=status,proto3" json:"status,omitempty"`
+	Intent        string                 `protobuf:"bytes,2,opt,name=intent,proto3" json:"intent,omitempty"`
+	Message       string                 `protobuf:"bytes,3,opt,name=message,proto3" json:"message,omitempty"`
+	DataJson      string                 `protobuf:"bytes,4,opt,name=data_json,json=dataJson,proto3" json:"data_json,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CommandResponse) Reset() {
+	*x = CommandResponse{}
+	mi := &file_walkie_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CommandResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CommandResponse) ProtoMessage() {}
+
+func (x *CommandResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_walkie_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CommandResponse.ProtoReflect.Descriptor instead.
+func (*CommandResponse) Descriptor() ([]byte, []int) {
+	return file_walkie_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *CommandResponse) GetStatus() string {
+	if x != nil {
+		return x.Status
+	}
+	return ""
+}
+
+func (x *CommandResponse) GetIntent() string {
+	if x != nil {
+		return x.Intent
+	}
+	return ""
+}
+
+func (x *CommandResponse) GetMessage() string {
+	if x != nil {
+		return x.Message
+	}
+	return ""
+}
+
+func (x *CommandResponse) GetDataJson() string {
+	if x != nil {
+		return x.DataJson
+	}
+	return ""
+}
+
+type SendAudioRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Audio         []byte                 `protobuf:"bytes,1,opt,name=audio,proto3" json:"audio,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SendAudioRequest) Reset() {
+	*x = SendAudioRequest{}
+	mi := &file_walkie_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SendAudioRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SendAudioRequest) ProtoMessage() {}
+
+func (x *SendAudioRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_walkie_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SendAudioRequest.ProtoReflect.Descriptor instead.
+func (*SendAudioRequest) Descriptor() ([]byte, []int) {
+	return file_walkie_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *SendAudioRequest) GetAudio() []byte {
+	if x != nil {
+		return x.Audio
+	}
+	return nil
+}
+
+// IngestResult equivale a la respuesta de POST /audio/ingest: status es el código
+// HTTP y data_json el cuerpo JSON
+type IngestResult struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Seq           uint64                 `protobuf:"varint,1,opt,name=seq,proto3" json:"seq,omitempty"`
+	Status        int32                  `protobuf:"varint,2,opt,name=status,proto3" json:"status,omitempty"`
+	DataJson      string                 `protobuf:"bytes,3,opt,name=data_json,json=dataJson,proto3" json:"data_json,omitempty"`
+	Error         string                 `protobuf:"bytes,4,opt,name=error,proto3" json:"error,omitempty"`
+	RetryAfter    int32                  `protobuf:"varint,5,opt,name=retry_after,json=retryAfter,proto3" json:"retry_after,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *IngestResult) Reset() {
+	*x = IngestResult{}
+	mi := &file_walkie_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *IngestResult) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*IngestResult) ProtoMessage() {}
+
+func (x *IngestResult) ProtoReflect() protoreflect.Message {
+	mi := &file_walkie_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use IngestResult.ProtoReflect.Descriptor instead.
+func (*IngestResult) Descriptor() ([]byte, []int) {
+	return file_walkie_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *IngestResult) GetSeq() uint64 {
+	if x != nil {
+		return x.Seq
+	}
+	return 0
+}
+
+func (x *IngestResult) GetStatus() int32 {
+	if x != nil {
+		return x.Status
+	}
+	return 0
+}
+
+func (x *IngestResult) GetDataJson() string {
+	if x != nil {
+		return x.DataJson
+	}
+	return ""
+}
+
+func (x *IngestResult) GetError() string {
+	if x != nil {
+		return x.Error
+	}
+	return ""
+}
+
+func (x *IngestResult) GetRetryAfter() int32 {
+	if x != nil {
+		return x.RetryAfter
+	}
+	return 0
+}
+
+type ClientFrame struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// audio es un clip completo (WAV, Ogg/Opus o WebM)
+	Audio         []byte `protobuf:"bytes,1,opt,name=audio,proto3" json:"audio,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ClientFrame) Reset() {
+	*x = ClientFrame{}
+	mi := &file_walkie_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ClientFrame) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ClientFrame) ProtoMessage() {}
+
+func (x *ClientFrame) ProtoReflect() protoreflect.Message {
+	mi := &file_walkie_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ClientFrame.ProtoReflect.Descriptor instead.
+func (*ClientFrame) Descriptor() ([]byte, []int) {
+	return file_walkie_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *ClientFrame) GetAudio() []byte {
+	if x != nil {
+		return x.Audio
+	}
+	return nil
+}
+
+type ServerEvent struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Types that are valid to be assigned to Event:
+	//
+	//	*ServerEvent_Audio
+	//	*ServerEvent_ControlJson
+	//	*ServerEvent_IngestResult
+	Event         isServerEvent_Event `protobuf_oneof:"event"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ServerEvent) Reset() {
+	*x = ServerEvent{}
+	mi := &file_walkie_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ServerEvent) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ServerEvent) ProtoMessage() {}
+
+func (x *ServerEvent) ProtoReflect() protoreflect.Message {
+	mi := &file_walkie_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ServerEvent.ProtoReflect.Descriptor instead.
+func (*ServerEvent) Descriptor() ([]byte, []int) {
+	return file_walkie_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *ServerEvent) GetEvent() isServerEvent_Event {
+	if x != nil {
+		return x.Event
+	}
+	return nil
+}
+
+func (x *ServerEvent) GetAudio() []byte {
+	if x != nil {
+		if x, ok := x.Event.(*ServerEvent_Audio); ok {
+			return x.Audio
+		}
+	}
+	return nil
+}
+
+func (x *ServerEvent) GetControlJson() string {
+	if x != nil {
+		if x, ok := x.Event.(*ServerEvent_ControlJson); ok {
+			return x.ControlJson
+		}
+	}
+	return ""
+}
+
+func (x *ServerEvent) GetIngestResult() *IngestResult {
+	if x != nil {
+		if x, ok := x.Event.(*ServerEvent_IngestResult); ok {
+			return x.IngestResult
+		}
+	}
+	return nil
+}
+
+type isServerEvent_Event interface {
+	isServerEvent_Event()
+}
+
+type ServerEvent_Audio struct {
+	// audio es un clip del canal, tal como lo recibe el WebSocket
+	Audio []byte `protobuf:"bytes,1,opt,name=audio,proto3,oneof"`
+}
+
+type ServerEvent_ControlJson struct {
+	// control_json es un aviso del canal (transmission, channel_changed...) en el
+	// mismo formato JSON que el WebSocket
+	ControlJson string `protobuf:"bytes,2,opt,name=control_json,json=controlJson,proto3,oneof"`
+}
+
+type ServerEvent_IngestResult struct {
+	IngestResult *IngestResult `protobuf:"bytes,3,opt,name=ingest_result,json=ingestResult,proto3,oneof"`
+}
+
+func (*ServerEvent_Audio) isServerEvent_Event() {}
+
+func (*ServerEvent_ControlJson) isServerEvent_Event() {}
+
+func (*ServerEvent_IngestResult) isServerEvent_Event() {}
+
+var File_walkie_proto protoreflect.FileDescriptor
+
+const file_walkie_proto_rawDesc = "" +
+	"\n" +
+	"\fwalkie.proto\x12\twalkie.v1\"\x81\x01\n" +
+	"\x13AuthenticateRequest\x12\x16\n" +
+	"\x06nombre\x18\x01 \x01(\tR\x06nombre\x12\x10\n" +
+	"\x03pin\x18\x02 \x01(\x05R\x03pin\x12 \n" +
+	"\vdispositivo\x18\x03 \x01(\tR\vdispositivo\x12\x1e\n" +
+	"\n" +
+	"plataforma\x18\x04 \x01(\tR\n" +
+	"plataforma\"\xcc\x01\n" +
+	"\x14AuthenticateResponse\x12\x18\n" +
+	"\amessage\x18\x01 \x01(\tR\amessage\x12\x14\n" +
+	"\x05token\x18\x02 \x01(\tR\x05token\x12\x1d\n" +
+	"\n" +
+	"session_id\x18\x03 \x01(\x04R\tsessionId\x12!\n" +
+	"\faccess_token\x18\x04 \x01(\tR\vaccessToken\x12#\n" +
+	"\rrefresh_token\x18\x05 \x01(\tR\frefreshToken\x12\x1d\n" +
+	"\n" +
+	"expires_in\x18\x06 \x01(\x03R\texpiresIn\"1\n" +
+	"\x15ConnectChannelRequest\x12\x18\n" +
+	"\achannel\x18\x01 \x01(\tR\achannel\"\x1a\n" +
+	"\x18DisconnectChannelRequest\"x\n" +
+	"\x0fCommandResponse\x12\x16\n" +
+	"\x06status\x18\x01 \x01(\tR\x06status\x12\x16\n" +
+	"\x06intent\x18\x02 \x01(\tR\x06intent\x12\x18\n" +
+	"\amessage\x18\x03 \x01(\tR\amessage\x12\x1b\n" +
+	"\tdata_json\x18\x04 \x01(\tR\bdataJson\"(\n" +
+	"\x10SendAudioRequest\x12\x14\n" +
+	"\x05audio\x18\x01 \x01(\fR\x05audio\"\x8c\x01\n" +
+	"\fIngestResult\x12\x10\n" +
+	"\x03seq\x18\x01 \x01(\x04R\x03seq\x12\x16\n" +
+	"\x06status\x18\x02 \x01(\x05R\x06status\x12\x1b\n" +
+	"\tdata_json\x18\x03 \x01(\tR\bdataJson\x12\x14\n" +
+	"\x05error\x18\x04 \x01(\tR\x05error\x12\x1f\n" +
+	"\vretry_after\x18\x05 \x01(\x05R\n" +
+	"retryAfter\"#\n" +
+	"\vClientFrame\x12\x14\n" +
+	"\x05audio\x18\x01 \x01(\fR\x05audio\"\x93\x01\n" +
+	"\vServerEvent\x12\x16\n" +
+	"\x05audio\x18\x01 \x01(\fH\x00R\x05audio\x12#\n" +
+	"\fcontrol_json\x18\x02 \x01(\tH\x00R\vcontrolJson\x12>\n" +
+	"\ringest_result\x18\x03 \x01(\v2\x17.walkie.v1.IngestResultH\x00R\fingestResultB\a\n" +
+	"\x05event2\x80\x03\n" +
+	"\x06Walkie\x12O\n" +
+	"\fAuthenticate\x12\x1e.walkie.v1.AuthenticateRequest\x1a\x1f.walkie.v1.AuthenticateResponse\x12N\n" +
+	"\x0eConnectChannel\x12 .walkie.v1.ConnectChannelRequest\x1a\x1a.walkie.v1.CommandResponse\x12T\n" +
+	"\x11DisconnectChannel\x12#.walkie.v1.DisconnectChannelRequest\x1a\x1a.walkie.v1.CommandResponse\x12A\n" +
+	"\tSendAudio\x12\x1b.walkie.v1.SendAudioRequest\x1a\x17.walkie.v1.IngestResult\x12<\n" +
+	"\x06Stream\x12\x16.walkie.v1.ClientFrame\x1a\x16.walkie.v1.ServerEvent(\x010\x01B\x1dZ\x1bwalkie-backend/pkg/walkiepbb\x06proto3"
+
+var (
+	file_walkie_proto_rawDescOnce sync.Once
+	file_walkie_proto_rawDescData []byte
+)
+
+func file_walkie_proto_rawDescGZIP() []byte {
+	file_walkie_proto_rawDescOnce.Do(func() {
+		file_walkie_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_walkie_proto_rawDesc), len(file_walkie_proto_rawDesc)))
+	})
+	return file_walkie_proto_rawDescData
+}
+
+var file_walkie_proto_msgTypes = make([]protoimpl.MessageInfo, 9)
+var file_walkie_proto_goTypes = []any{
+	(*AuthenticateRequest)(nil),      // 0: walkie.v1.AuthenticateRequest
+	(*AuthenticateResponse)(nil),     // 1: walkie.v1.AuthenticateResponse
+	(*ConnectChannelRequest)(nil),    // 2: walkie.v1.ConnectChannelRequest
+	(*DisconnectChannelRequest)(nil), // 3: walkie.v1.DisconnectChannelRequest
+	(*CommandResponse)(nil),          // 4: walkie.v1.CommandResponse
+	(*SendAudioRequest)(nil),         // 5: walkie.v1.SendAudioRequest
+	(*IngestResult)(nil),             // 6: walkie.v1.IngestResult
+	(*ClientFrame)(nil),              // 7: walkie.v1.ClientFrame
+	(*ServerEvent)(nil),              // 8: walkie.v1.ServerEvent
+}
+var file_walkie_proto_depIdxs = []int32{
+	6, // 0: walkie.v1.ServerEvent.ingest_result:type_name -> walkie.v1.IngestResult
+	0, // 1: walkie.v1.Walkie.Authenticate:input_type -> walkie.v1.AuthenticateRequest
+	2, // 2: walkie.v1.Walkie.ConnectChannel:input_type -> walkie.v1.ConnectChannelRequest
+	3, // 3: walkie.v1.Walkie.DisconnectChannel:input_type -> walkie.v1.DisconnectChannelRequest
+	5, // 4: walkie.v1.Walkie.SendAudio:input_type -> walkie.v1.SendAudioRequest
+	7, // 5: walkie.v1.Walkie.Stream:input_type -> walkie.v1.ClientFrame
+	1, // 6: walkie.v1.Walkie.Authenticate:output_type -> walkie.v1.AuthenticateResponse
+	4, // 7: walkie.v1.Walkie.ConnectChannel:output_type -> walkie.v1.CommandResponse
+	4, // 8: walkie.v1.Walkie.DisconnectChannel:output_type -> walkie.v1.CommandResponse
+	6, // 9: walkie.v1.Walkie.SendAudio:output_type -> walkie.v1.IngestResult
+	8, // 10: walkie.v1.Walkie.Stream:output_type -> walkie.v1.ServerEvent
+	6, // [6:11] is the sub-list for method output_type
+	1, // [1:6] is the sub-list for method input_type
+	1, // [1:1] is the sub-list for extension type_name
+	1, // [1:1] is the sub-list for extension extendee
+	0, // [0:1] is the sub-list for field type_name
+}
+
+func init() { file_walkie_proto_init() }
+func file_walkie_proto_init() {
+	if File_walkie_proto != nil {
+		return
+	}
+	file_walkie_proto_msgTypes[8].OneofWrappers = []any{
+		(*ServerEvent_Audio)(nil),
+		(*ServerEvent_ControlJson)(nil),
+		(*ServerEvent_IngestResult)(nil),
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_walkie_proto_rawDesc), len(file_walkie_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   9,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_walkie_proto_goTypes,
+		DependencyIndexes: file_walkie_proto_depIdxs,
+		MessageInfos:      file_walkie_proto_msgTypes,
+	}.Build()
+	File_walkie_proto = out.File
+	file_walkie_proto_goTypes = nil
+	file_walkie_proto_depIdxs = nil
+}
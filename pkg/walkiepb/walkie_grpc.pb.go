@@ -0,0 +1,290 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.5.1
+// - protoc             (unknown)
+// source: walkie.proto
+
+package walkiepb
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	Walkie_Authenticate_FullMethodName      = "/walkie.v1.Walkie/Authenticate"
+	Walkie_ConnectChannel_FullMethodName    = "/walkie.v1.Walkie/ConnectChannel"
+	Walkie_DisconnectChannel_FullMethodName = "/walkie.v1.Walkie/DisconnectChannel"
+	Walkie_SendAudio_FullMethodName         = "/walkie.v1.Walkie/SendAudio"
+	Walkie_Stream_FullMethodName            = "/walkie.v1.Walkie/Stream"
+)
+
+// WalkieClient is the client API for Walkie service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// Walkie expone las operaciones básicas para consolas de despacho y bots, sin el
+// protocolo del WebSocket pensado para navegadores. Salvo Authenticate, cada llamada
+// lleva el token en los metadatos: "authorization: Bearer <token>".
+type WalkieClient interface {
+	// Authenticate inicia sesión con nombre y PIN, igual que POST /auth
+	Authenticate(ctx context.Context, in *AuthenticateRequest, opts ...grpc.CallOption) (*AuthenticateResponse, error)
+	// ConnectChannel conecta al usuario al canal, igual que el comando de voz
+	ConnectChannel(ctx context.Context, in *ConnectChannelRequest, opts ...grpc.CallOption) (*CommandResponse, error)
+	// DisconnectChannel desconecta al usuario de su canal actual
+	DisconnectChannel(ctx context.Context, in *DisconnectChannelRequest, opts ...grpc.CallOption) (*CommandResponse, error)
+	// SendAudio pasa un clip por el mismo pipeline que POST /audio/ingest
+	SendAudio(ctx context.Context, in *SendAudioRequest, opts ...grpc.CallOption) (*IngestResult, error)
+	// Stream entrega el audio y los avisos del canal actual mientras dure la llamada.
+	// Cada ClientFrame con audio es un clip que se procesa como en SendAudio; su
+	// resultado llega como ServerEvent.ingest_result con el mismo seq.
+	Stream(ctx context.Context, opts ...grpc.CallOption) (grpc.BidiStreamingClient[ClientFrame, ServerEvent], error)
+}
+
+type walkieClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewWalkieClient(cc grpc.ClientConnInterface) WalkieClient {
+	return &walkieClient{cc}
+}
+
+func (c *walkieClient) Authenticate(ctx context.Context, in *AuthenticateRequest, opts ...grpc.CallOption) (*AuthenticateResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(AuthenticateResponse)
+	err := c.cc.Invoke(ctx, Walkie_Authenticate_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *walkieClient) ConnectChannel(ctx context.Context, in *ConnectChannelRequest, opts ...grpc.CallOption) (*CommandResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(CommandResponse)
+	err := c.cc.Invoke(ctx, Walkie_ConnectChannel_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *walkieClient) DisconnectChannel(ctx context.Context, in *DisconnectChannelRequest, opts ...grpc.CallOption) (*CommandResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(CommandResponse)
+	err := c.cc.Invoke(ctx, Walkie_DisconnectChannel_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *walkieClient) SendAudio(ctx context.Context, in *SendAudioRequest, opts ...grpc.CallOption) (*IngestResult, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(IngestResult)
+	err := c.cc.Invoke(ctx, Walkie_SendAudio_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *walkieClient) Stream(ctx context.Context, opts ...grpc.CallOption) (grpc.BidiStreamingClient[ClientFrame, ServerEvent], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &Walkie_ServiceDesc.Streams[0], Walkie_Stream_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[ClientFrame, ServerEvent]{ClientStream: stream}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Walkie_StreamClient = grpc.BidiStreamingClient[ClientFrame, ServerEvent]
+
+// WalkieServer is the server API for Walkie service.
+// All implementations must embed UnimplementedWalkieServer
+// for forward compatibility.
+//
+// Walkie expone las operaciones básicas para consolas de despacho y bots, sin el
+// protocolo del WebSocket pensado para navegadores. Salvo Authenticate, cada llamada
+// lleva el token en los metadatos: "authorization: Bearer <token>".
+type WalkieServer interface {
+	// Authenticate inicia sesión con nombre y PIN, igual que POST /auth
+	Authenticate(context.Context, *AuthenticateRequest) (*AuthenticateResponse, error)
+	// ConnectChannel conecta al usuario al canal, igual que el comando de voz
+	ConnectChannel(context.Context, *ConnectChannelRequest) (*CommandResponse, error)
+	// DisconnectChannel desconecta al usuario de su canal actual
+	DisconnectChannel(context.Context, *DisconnectChannelRequest) (*CommandResponse, error)
+	// SendAudio pasa un clip por el mismo pipeline que POST /audio/ingest
+	SendAudio(context.Context, *SendAudioRequest) (*IngestResult, error)
+	// Stream entrega el audio y los avisos del canal actual mientras dure la llamada.
+	// Cada ClientFrame con audio es un clip que se procesa como en SendAudio; su
+	// resultado llega como ServerEvent.ingest_result con el mismo seq.
+	Stream(grpc.BidiStreamingServer[ClientFrame, ServerEvent]) error
+	mustEmbedUnimplementedWalkieServer()
+}
+
+// UnimplementedWalkieServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedWalkieServer struct{}
+
+func (UnimplementedWalkieServer) Authenticate(context.Context, *AuthenticateRequest) (*AuthenticateResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Authenticate not implemented")
+}
+func (UnimplementedWalkieServer) ConnectChannel(context.Context, *ConnectChannelRequest) (*CommandResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ConnectChannel not implemented")
+}
+func (UnimplementedWalkieServer) DisconnectChannel(context.Context, *DisconnectChannelRequest) (*CommandResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DisconnectChannel not implemented")
+}
+func (UnimplementedWalkieServer) SendAudio(context.Context, *SendAudioRequest) (*IngestResult, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SendAudio not implemented")
+}
+func (UnimplementedWalkieServer) Stream(grpc.BidiStreamingServer[ClientFrame, ServerEvent]) error {
+	return status.Errorf(codes.Unimplemented, "method Stream not implemented")
+}
+func (UnimplementedWalkieServer) mustEmbedUnimplementedWalkieServer() {}
+func (UnimplementedWalkieServer) testEmbeddedByValue()                {}
+
+// UnsafeWalkieServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to WalkieServer will
+// result in compilation errors.
+type UnsafeWalkieServer interface {
+	mustEmbedUnimplementedWalkieServer()
+}
+
+func RegisterWalkieServer(s grpc.ServiceRegistrar, srv WalkieServer) {
+	// If the following call pancis, it indicates UnimplementedWalkieServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&Walkie_ServiceDesc, srv)
+}
+
+func _Walkie_Authenticate_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(AuthenticateRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(WalkieServer).Authenticate(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Walkie_Authenticate_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(WalkieServer).Authenticate(ctx, req.(*AuthenticateRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Walkie_ConnectChannel_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ConnectChannelRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(WalkieServer).ConnectChannel(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Walkie_ConnectChannel_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(WalkieServer).ConnectChannel(ctx, req.(*ConnectChannelRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Walkie_DisconnectChannel_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DisconnectChannelRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(WalkieServer).DisconnectChannel(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Walkie_DisconnectChannel_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(WalkieServer).DisconnectChannel(ctx, req.(*DisconnectChannelRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Walkie_SendAudio_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SendAudioRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(WalkieServer).SendAudio(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Walkie_SendAudio_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(WalkieServer).SendAudio(ctx, req.(*SendAudioRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Walkie_Stream_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(WalkieServer).Stream(&grpc.GenericServerStream[ClientFrame, ServerEvent]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Walkie_StreamServer = grpc.BidiStreamingServer[ClientFrame, ServerEvent]
+
+// Walkie_ServiceDesc is the grpc.ServiceDesc for Walkie service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var Walkie_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "walkie.v1.Walkie",
+	HandlerType: (*WalkieServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Authenticate",
+			Handler:    _Walkie_Authenticate_Handler,
+		},
+		{
+			MethodName: "ConnectChannel",
+			Handler:    _Walkie_ConnectChannel_Handler,
+		},
+		{
+			MethodName: "DisconnectChannel",
+			Handler:    _Walkie_DisconnectChannel_Handler,
+		},
+		{
+			MethodName: "SendAudio",
+			Handler:    _Walkie_SendAudio_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "Stream",
+			Handler:       _Walkie_Stream_Handler,
+			ServerStreams: true,
+			ClientStreams: true,
+		},
+	},
+	Metadata: "walkie.proto",
+}